@@ -0,0 +1,25 @@
+// Package background holds the helpers shared by the packages that run background work: the queue, the jobs
+// scheduler, the events consumers and outbox, and the leader elector.
+package background
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// ErrPanic marks errors from recovered panics
+var ErrPanic = errors.New("panicked")
+
+// PanicError converts a value recovered from a panic in what, such as "job handler", into an error
+// matching ErrPanic
+func PanicError(what string, rec interface{}) error {
+	return fmt.Errorf("%s %w: %v", what, ErrPanic, rec)
+}
+
+// Discard closes conn instead of returning it to the pool, where it might still hold a session lock
+func Discard(conn *sql.Conn) {
+	_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
@@ -0,0 +1,16 @@
+package background
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPanicError(t *testing.T) {
+	err := PanicError("job handler", "bad payload")
+	if !errors.Is(err, ErrPanic) {
+		t.Errorf("Expected the error to match ErrPanic, got %v", err)
+	}
+	if err.Error() != "job handler panicked: bad payload" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+}
@@ -0,0 +1,91 @@
+// Package poller runs the polling loops shared by the queue workers, the webhook sender, and the events
+// outbox relay.
+package poller
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Loop calls Next back to back while it finds work, and waits Interval when it finds none
+type Loop struct {
+	// Next does one unit of work, reporting whether there was any
+	Next     func(ctx context.Context) (bool, error)
+	Interval time.Duration
+	// OnError is called with the errors Next returns before ctx is done
+	OnError func(err error)
+}
+
+// Pool runs a Loop on a number of goroutines. The zero value is ready to use.
+type Pool struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	loops  sync.WaitGroup
+}
+
+// Start runs loop on concurrency goroutines, at least one, until ctx is done or Stop is called. It
+// reports whether it started them, which it does not when the pool is already running.
+func (p *Pool) Start(ctx context.Context, concurrency int, loop Loop) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel != nil {
+		return false
+	}
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	for range max(concurrency, 1) {
+		p.loops.Add(1)
+		go p.run(ctx, loop)
+	}
+	return true
+}
+
+// Stop cancels the loops and waits for them to return, or for ctx to be done, in which case it returns
+// ctx's error. It reports whether the pool was started.
+func (p *Pool) Stop(ctx context.Context) (bool, error) {
+	p.mu.Lock()
+	cancel := p.cancel
+	p.mu.Unlock()
+
+	if cancel == nil {
+		return false, nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.loops.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true, nil
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+}
+
+// run calls loop.Next until ctx is done
+func (p *Pool) run(ctx context.Context, loop Loop) {
+	defer p.loops.Done()
+
+	for ctx.Err() == nil {
+		found, err := loop.Next(ctx)
+		if err != nil && ctx.Err() == nil && loop.OnError != nil {
+			loop.OnError(err)
+		}
+		if found {
+			continue
+		}
+
+		timer := time.NewTimer(loop.Interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
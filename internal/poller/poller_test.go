@@ -0,0 +1,74 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	var calls, errs atomic.Int32
+	loop := Loop{
+		Next: func(ctx context.Context) (bool, error) {
+			// The first ten calls find work and run back to back; later ones fail and wait
+			if calls.Add(1) <= 10 {
+				return true, nil
+			}
+			return false, errors.New("no work")
+		},
+		Interval: time.Millisecond,
+		OnError:  func(err error) { errs.Add(1) },
+	}
+
+	var p Pool
+	if running, err := p.Stop(context.Background()); running || err != nil {
+		t.Errorf("Expected Stop before Start to be a no-op, got %v and %v", running, err)
+	}
+	if !p.Start(context.Background(), 2, loop) {
+		t.Fatal("Expected the pool to start")
+	}
+	if p.Start(context.Background(), 2, loop) {
+		t.Error("Expected a running pool not to start again")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for errs.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the loops to poll")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if running, err := p.Stop(context.Background()); !running || err != nil {
+		t.Errorf("Expected the pool to stop, got %v and %v", running, err)
+	}
+	stopped := calls.Load()
+	time.Sleep(5 * time.Millisecond)
+	if calls.Load() != stopped {
+		t.Error("Expected no calls after Stop")
+	}
+}
+
+func TestPoolStopTimeout(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+
+	var p Pool
+	p.Start(context.Background(), 1, Loop{
+		Next: func(ctx context.Context) (bool, error) {
+			close(started)
+			<-release
+			return false, nil
+		},
+		Interval: time.Millisecond,
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if running, err := p.Stop(ctx); !running || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Stop to time out, got %v and %v", running, err)
+	}
+}
@@ -0,0 +1,30 @@
+// Package retry holds the retry helpers shared by the packages that store failed attempts: the queue, the
+// webhook sender, and the events outbox.
+package retry
+
+import (
+	"strings"
+	"time"
+)
+
+// maxErrorLength is the most bytes of an error message kept with a failed attempt
+const maxErrorLength = 2000
+
+// Backoff returns the delay before retrying after the given attempt, starting at base and doubling each
+// attempt up to max
+func Backoff(base, max time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	return min(delay, max)
+}
+
+// ErrorMessage returns the message of err cut to a size that is reasonable to store
+func ErrorMessage(err error) string {
+	msg := err.Error()
+	if len(msg) > maxErrorLength {
+		msg = msg[:maxErrorLength]
+	}
+	return strings.ToValidUTF8(msg, "")
+}
@@ -0,0 +1,50 @@
+package retry
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{6, 32 * time.Second},
+		{7, time.Minute},
+		{100, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(time.Second, time.Minute, tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"short", errors.New("boom"), 4},
+		{"long", errors.New(strings.Repeat("x", 5000)), 2000},
+		// Cutting inside a multi-byte rune drops the partial rune
+		{"split rune", errors.New(strings.Repeat("x", 1999) + "é"), 1999},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorMessage(tt.err); len(got) != tt.want {
+				t.Errorf("Expected %d bytes, got %d", tt.want, len(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/logging"
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminConfig holds configuration for the admin endpoints
+type AdminConfig struct {
+	// Middleware guards every admin endpoint, e.g. a validator's Middleware. It is required: without
+	// it, every admin endpoint answers 403.
+	Middleware func(http.Handler) http.Handler
+	// Pprof serves runtime profiles under pprof/; off by default
+	Pprof bool
+	// PprofLocalOnly additionally limits profiles to loopback clients, once Middleware has admitted the request
+	PprofLocalOnly bool
+	// Config is served at config/ with sensitive values redacted; nil leaves the endpoint out
+	Config interface{}
+	// LogLevel is read and changed at loglevel/
+	LogLevel *slog.LevelVar
+	// Endpoints are extra GET handlers served at their path under the admin path
+	Endpoints map[string]http.Handler
+	// Actions are extra POST handlers served at their path under the admin path, for operations that
+	// change something, such as taking a backup
+	Actions map[string]http.Handler
+}
+
+// DefaultAdminConfig provides sensible defaults
+func DefaultAdminConfig() *AdminConfig {
+	return &AdminConfig{
+		LogLevel: logging.Level(),
+	}
+}
+
+// AdminOption is a functional option for configuring the admin endpoints
+type AdminOption func(*AdminConfig)
+
+// WithAdminMiddleware sets the middleware guarding every admin endpoint, usually authentication, or
+// LocalOnly when only a sidecar or an operator on the host should reach them
+func WithAdminMiddleware(middleware func(http.Handler) http.Handler) AdminOption {
+	return func(config *AdminConfig) {
+		config.Middleware = middleware
+	}
+}
+
+// WithPprof toggles the profiling endpoints, optionally limiting them to loopback clients
+func WithPprof(enabled, localOnly bool) AdminOption {
+	return func(config *AdminConfig) {
+		config.Pprof = enabled
+		config.PprofLocalOnly = localOnly
+	}
+}
+
+// WithAdminConfig sets the configuration served, redacted, at config/
+func WithAdminConfig(cfg interface{}) AdminOption {
+	return func(config *AdminConfig) {
+		config.Config = cfg
+	}
+}
+
+// WithLogLevel sets the level variable read and changed at loglevel/
+func WithLogLevel(level *slog.LevelVar) AdminOption {
+	return func(config *AdminConfig) {
+		config.LogLevel = level
+	}
+}
+
+// WithAdminEndpoint serves handler for GET requests at path under the admin path, e.g. a
+// package's stats snapshot
+func WithAdminEndpoint(path string, handler http.Handler) AdminOption {
+	return func(config *AdminConfig) {
+		if config.Endpoints == nil {
+			config.Endpoints = make(map[string]http.Handler)
+		}
+		config.Endpoints[strings.Trim(path, "/")] = handler
+	}
+}
+
+// WithAdminAction serves handler for POST requests at path under the admin path, e.g. a package's
+// backup or export operation
+func WithAdminAction(path string, handler http.Handler) AdminOption {
+	return func(config *AdminConfig) {
+		if config.Actions == nil {
+			config.Actions = make(map[string]http.Handler)
+		}
+		config.Actions[strings.Trim(path, "/")] = handler
+	}
+}
+
+// NewAdminConfig creates a new admin config with options
+func NewAdminConfig(options ...AdminOption) *AdminConfig {
+	config := DefaultAdminConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// LogLevelChange is the body accepted by the loglevel endpoint
+type LogLevelChange struct {
+	Level string `json:"level" validate:"required"`
+}
+
+// AddAdminEndpoints adds operational endpoints under path, all behind the configured middleware, which
+// must be set with WithAdminMiddleware; without it, every admin endpoint answers 403:
+//
+//	GET  loglevel   the current log level
+//	PUT  loglevel   change the log level, with a body like {"level":"debug"}
+//	GET  pprof/*    runtime profiles from net/http/pprof, when enabled with WithPprof
+//	GET  config     the configuration with sensitive values redacted
+//	GET  build      the service version, VCS revision, and module versions, as AddBuildInfoEndpoint
+//
+// plus any endpoints added with WithAdminEndpoint and actions added with WithAdminAction.
+func (b *Base) AddAdminEndpoints(r chi.Router, path string, options ...AdminOption) {
+	config := NewAdminConfig(options...)
+	log.Printf("### 🛠️ API: admin endpoints at: %s", "/"+path+"/*")
+
+	middleware := config.Middleware
+	if middleware == nil {
+		log.Printf("### 🛠️ API: admin endpoints are disabled until a middleware is set with WithAdminMiddleware")
+		middleware = denyAdmin
+	}
+
+	r.Route("/"+path, func(r chi.Router) {
+		r.Use(middleware)
+
+		r.Get("/loglevel", func(w http.ResponseWriter, r *http.Request) {
+			b.ReturnJSON(w, LogLevelChange{Level: config.LogLevel.Level().String()})
+		})
+		r.Put("/loglevel", b.changeLogLevel(config.LogLevel))
+
+		r.Get("/build", func(w http.ResponseWriter, r *http.Request) {
+			b.ReturnJSON(w, b.Build())
+		})
+
+		if config.Config != nil {
+			r.Get("/config", b.configHandler(config.Config))
+		}
+
+		for path, handler := range config.Endpoints {
+			r.Method(http.MethodGet, "/"+path, handler)
+		}
+		for path, handler := range config.Actions {
+			r.Method(http.MethodPost, "/"+path, handler)
+		}
+
+		if config.Pprof {
+			r.Group(func(r chi.Router) {
+				if config.PprofLocalOnly {
+					r.Use(LocalOnly)
+				}
+				mountPprof(r)
+			})
+		}
+	})
+}
+
+// changeLogLevel handles requests to change the log level
+func (b *Base) changeLogLevel(level *slog.LevelVar) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var change LogLevelChange
+		if err := b.DecodeJSON(r, &change); err != nil {
+			b.ReturnProblem(w, r, err)
+			return
+		}
+
+		parsed, err := logging.ParseLevel(change.Level)
+		if err != nil {
+			problem.New("invalid-log-level", "Invalid Log Level", http.StatusBadRequest, err.Error(),
+				r.URL.Path).Respond(w, r)
+			return
+		}
+
+		previous := level.Level()
+		level.Set(parsed)
+		log.Printf("### 🛠️ API: log level changed from %s to %s by %s", previous, parsed, r.RemoteAddr)
+
+		b.ReturnJSON(w, LogLevelChange{Level: parsed.String()})
+	}
+}
+
+// mountPprof routes the net/http/pprof handlers; pprof.Index only resolves named profiles under
+// /debug/pprof/, so they are routed explicitly here
+func mountPprof(r chi.Router) {
+	r.Get("/pprof/", pprof.Index)
+	r.Get("/pprof/cmdline", pprof.Cmdline)
+	r.Get("/pprof/profile", pprof.Profile)
+	r.Get("/pprof/symbol", pprof.Symbol)
+	r.Post("/pprof/symbol", pprof.Symbol)
+	r.Get("/pprof/trace", pprof.Trace)
+	r.Get("/pprof/{profile}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(chi.URLParam(r, "profile")).ServeHTTP(w, r)
+	})
+}
+
+// denyAdmin answers every admin request with 403, guarding admin endpoints without a middleware
+func denyAdmin(http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		problem.New("forbidden", "Forbidden", http.StatusForbidden, "Admin endpoints are not enabled",
+			r.URL.Path).Respond(w, r)
+	})
+}
+
+// LocalOnly is middleware that admits only loopback clients, judged by the connection's remote
+// address rather than forwarded headers, and answers others with 403
+func LocalOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			problem.New("forbidden", "Forbidden", http.StatusForbidden,
+				"This endpoint is only available from localhost", r.URL.Path).Respond(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type adminTestConfig struct {
+	Port     int `json:"port"`
+	Database struct {
+		Host     string `json:"host"`
+		Password string `json:"password"`
+	} `json:"database"`
+	APIKeys []string `json:"apiKeys"`
+	Token   string   `json:"token"`
+}
+
+func newAdminRouter(options ...AdminOption) (chi.Router, *slog.LevelVar) {
+	level := new(slog.LevelVar)
+	cfg := adminTestConfig{Port: 8080, APIKeys: []string{"k1"}}
+	cfg.Database.Host = "db.internal"
+	cfg.Database.Password = "hunter2"
+
+	base := NewBase("orders", "1.2.0", "abc123", true)
+	router := chi.NewRouter()
+	defaults := []AdminOption{WithLogLevel(level), WithAdminConfig(cfg), WithAdminMiddleware(LocalOnly),
+		WithPprof(true, false)}
+	base.AddAdminEndpoints(router, "admin", append(defaults, options...)...)
+	return router, level
+}
+
+func adminRequest(router http.Handler, method, target, body, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	router, _ := newAdminRouter()
+	local := "127.0.0.1:5000"
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		remoteAddr string
+		wantStatus int
+		wantBody   string
+	}{
+		{"log level", "GET", "/admin/loglevel", "", local, http.StatusOK, `{"level":"INFO"}`},
+		{"build", "GET", "/admin/build", "", local, http.StatusOK, `"buildInfo":"abc123"`},
+		{"config redacts secrets", "GET", "/admin/config", "", local, http.StatusOK, `"password":"[REDACTED]"`},
+		{"config keeps other values", "GET", "/admin/config", "", local, http.StatusOK, `"host":"db.internal"`},
+		{"pprof index", "GET", "/admin/pprof/", "", "[::1]:5000", http.StatusOK, "goroutine"},
+		{"pprof named profile", "GET", "/admin/pprof/heap?debug=1", "", local, http.StatusOK, "heap profile"},
+		{"remote client", "GET", "/admin/build", "", "203.0.113.9:5000", http.StatusForbidden, `"type":"forbidden"`},
+		{"invalid level", "PUT", "/admin/loglevel", `{"level":"loud"}`, local, http.StatusBadRequest,
+			`"type":"invalid-log-level"`},
+		{"missing level", "PUT", "/admin/loglevel", `{}`, local, http.StatusUnprocessableEntity, `"field":"level"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := adminRequest(router, tt.method, tt.target, tt.body, tt.remoteAddr)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("Expected body to contain %s, got %s", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdminRoutesAreAPITraffic(t *testing.T) {
+	base := NewBase("orders", "1.2.0", "abc123", true)
+	base.AddAdminEndpoints(chi.NewRouter(), "admin", WithAdminMiddleware(LocalOnly))
+
+	if class := base.Routes().Classify("/admin/loglevel"); class != RouteClassAPI {
+		t.Errorf("Expected admin routes to be rate limited and logged as API traffic, got %s", class)
+	}
+}
+
+func TestAdminChangeLogLevel(t *testing.T) {
+	router, level := newAdminRouter()
+
+	rec := adminRequest(router, "PUT", "/admin/loglevel", `{"level":"debug"}`, "127.0.0.1:5000")
+	if rec.Code != http.StatusOK || level.Level() != slog.LevelDebug {
+		t.Fatalf("Expected the level to change to debug, got %d and %s", rec.Code, level.Level())
+	}
+
+	var change LogLevelChange
+	if err := json.Unmarshal(rec.Body.Bytes(), &change); err != nil || change.Level != "DEBUG" {
+		t.Errorf("Expected the new level in the response, got %s", rec.Body.String())
+	}
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	requireToken := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer admin" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	router, _ := newAdminRouter(WithAdminMiddleware(requireToken), WithPprof(true, true))
+
+	req := httptest.NewRequest("GET", "/admin/build", nil)
+	req.Header.Set("Authorization", "Bearer admin")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected an authenticated remote client to be admitted, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/admin/pprof/", nil)
+	req.Header.Set("Authorization", "Bearer admin")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected profiles to stay local only, got %d", rec.Code)
+	}
+
+	if rec := adminRequest(router, "GET", "/admin/build", "", "127.0.0.1:5000"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the middleware to replace the localhost check, got %d", rec.Code)
+	}
+}
+
+func TestAdminRequiresMiddleware(t *testing.T) {
+	config := DefaultAdminConfig()
+	if config.Middleware != nil || config.Pprof {
+		t.Errorf("Expected no middleware and no profiles by default, got %+v", config)
+	}
+
+	router := chi.NewRouter()
+	NewBase("orders", "1.2.0", "abc123", true).AddAdminEndpoints(router, "admin", WithPprof(true, false))
+	for _, target := range []string{"/admin/build", "/admin/loglevel", "/admin/pprof/"} {
+		if rec := adminRequest(router, "GET", target, "", "127.0.0.1:5000"); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected %s to be denied without a middleware, got %d", target, rec.Code)
+		}
+	}
+}
+
+func TestAdminEndpoint(t *testing.T) {
+	stats := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"queries":3}`))
+	})
+	router, _ := newAdminRouter(WithAdminEndpoint("/querystats", stats))
+
+	rec := adminRequest(router, "GET", "/admin/querystats", "", "127.0.0.1:5000")
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"queries":3}` {
+		t.Errorf("Expected the added endpoint to be served, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := adminRequest(router, "GET", "/admin/querystats", "", "10.0.0.8:5000"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected the added endpoint to be guarded, got %d", rec.Code)
+	}
+}
+
+func TestAdminAction(t *testing.T) {
+	backup := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	router, _ := newAdminRouter(WithAdminAction("backup", backup))
+
+	if rec := adminRequest(router, "POST", "/admin/backup", "", "127.0.0.1:5000"); rec.Code != http.StatusCreated {
+		t.Errorf("Expected the added action to be served, got %d", rec.Code)
+	}
+	if rec := adminRequest(router, "GET", "/admin/backup", "", "127.0.0.1:5000"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected the action to accept only POST, got %d", rec.Code)
+	}
+	if rec := adminRequest(router, "POST", "/admin/backup", "", "10.0.0.8:5000"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected the added action to be guarded, got %d", rec.Code)
+	}
+}
+
+func TestRedactConfig(t *testing.T) {
+	cfg := map[string]interface{}{
+		"listen":      ":8080",
+		"dbPassword":  "hunter2",
+		"emptySecret": "",
+		"clients":     []interface{}{map[string]interface{}{"name": "billing", "apiKey": "k1"}},
+	}
+
+	redacted, err := redactConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(redacted)
+	want := `{"clients":[{"apiKey":"[REDACTED]","name":"billing"}],"dbPassword":"[REDACTED]","emptySecret":"",` +
+		`"listen":":8080"}`
+	if string(body) != want {
+		t.Errorf("Expected %s, got %s", want, body)
+	}
+
+	if _, err := redactConfig(make(chan int)); err == nil {
+		t.Error("Expected an error for a configuration that cannot be encoded")
+	}
+}
@@ -4,18 +4,42 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"mime"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"github.com/Okja-Engineering/go-service-kit/pkg/validate"
 	"github.com/go-chi/chi/v5"
 )
 
 type Base struct {
 	ServiceName string
-	Healthy     bool
-	Version     string
-	BuildInfo   string
+	// Healthy is the service's own health, as reported by the health endpoint. Change it while
+	// serving with SetHealthy and SetUnhealthy, and read it with IsHealthy.
+	Healthy   bool
+	Version   string
+	BuildInfo string
+
+	healthMu sync.RWMutex
+	// unhealthyReason explains why the service is unhealthy; autoUnhealthy marks that a
+	// critical check made it so, rather than SetUnhealthy
+	unhealthyReason string
+	autoUnhealthy   bool
+
+	initOnce sync.Once
+	life     *lifecycle
+	health   *healthRegistry
+	startup  *startupRegistry
+	mapper   *problem.Mapper
+
+	deprecations *deprecationRegistry
+	diagnostics  *diagnosticsRegistry
+	routes       *RouteClassifier
+	stats        *serviceStats
 }
 
 func NewBase(name, ver, info string, healthy bool) *Base {
@@ -27,15 +51,42 @@ func NewBase(name, ver, info string, healthy bool) *Base {
 	}
 }
 
+// init lazily sets up internal state, so a zero Base literal is usable
+func (b *Base) init() {
+	b.life = &lifecycle{config: DefaultShutdownConfig(), stopping: make(chan struct{})}
+	b.health = &healthRegistry{checks: make(map[string]*healthCheck)}
+	b.startup = &startupRegistry{}
+	b.mapper = problem.DefaultMapper()
+	b.deprecations = &deprecationRegistry{usage: make(map[deprecationKey]*DeprecationUsage)}
+	b.routes = NewRouteClassifier(DefaultInfrastructureRoutes...)
+	b.stats = &serviceStats{}
+	b.diagnostics = &diagnosticsRegistry{
+		funcs:    make(map[string]DiagnosticsFunc),
+		limiters: make(map[string][]*rateLimiter),
+	}
+}
+
 func (b *Base) ReturnJSON(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, http.StatusOK, data)
+}
+
+// writeJSON encodes data as the response body with status, adding a Link header for pages
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if page, ok := data.(linker); ok && page.LinkHeader() != "" {
+		w.Header().Set("Link", page.LinkHeader())
+	}
+
 	dataBytes, err := json.Marshal(data)
 	if err != nil {
 		problem.Wrap(500, "json-encoding", "api-internals", err).Send(w)
 		return
 	}
 
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+	}
 	_, _ = w.Write(dataBytes)
 }
 
@@ -52,6 +103,56 @@ func (b *Base) ReturnOKJSON(w http.ResponseWriter) {
 	b.ReturnJSON(w, map[string]string{"result": "ok"})
 }
 
+// DecodeJSON checks the request is JSON, then decodes and validates the body into dst.
+// Errors carry a suitable status (400, 413, 415, or 422) and can be sent with ReturnProblem.
+func (b *Base) DecodeJSON(r *http.Request, dst interface{}, options ...validate.DecodeOption) error {
+	return decodeJSON(r, dst, options...)
+}
+
+func decodeJSON(r *http.Request, dst interface{}, options ...validate.DecodeOption) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		return &validate.DecodeError{
+			Status: http.StatusUnsupportedMediaType,
+			Detail: "Content-Type must be application/json",
+		}
+	}
+
+	return validate.Decode(r, dst, options...)
+}
+
+// BindQuery maps query parameters onto the fields of dst, see ParseQuery for the supported tags
+func (b *Base) BindQuery(r *http.Request, dst interface{}) error {
+	return ParseQuery(r, dst)
+}
+
+// ReturnProblem sends an error from DecodeJSON or BindQuery as a problem+json response,
+// keeping its status and per-field details. Other errors are sent as a 500.
+func (b *Base) ReturnProblem(w http.ResponseWriter, r *http.Request, err error) {
+	validate.SendError(w, r, err)
+}
+
+// SetErrorMapper replaces the mapper HandleError uses; the default maps sql.ErrNoRows and
+// context.DeadlineExceeded and turns anything else into a 500
+func (b *Base) SetErrorMapper(mapper *problem.Mapper) {
+	b.initOnce.Do(b.init)
+	b.mapper = mapper
+}
+
+// HandleError responds with the problem the error mapper produces for err. Server errors are
+// logged with the original error, since their response detail is kept generic.
+func (b *Base) HandleError(w http.ResponseWriter, r *http.Request, err error) {
+	b.initOnce.Do(b.init)
+
+	p := b.mapper.Map(err, r.URL.Path)
+	if p.Status >= http.StatusInternalServerError {
+		log.Printf("### 💥 API: %s %s failed: %v", r.Method, r.URL.Path, err)
+	}
+	p.Respond(w, r)
+}
+
+// StartServer listens on the given port and blocks until a shutdown signal is received.
+// It then shuts down gracefully and exits the process with the report's exit code.
 func (b *Base) StartServer(port int, router chi.Router, timeout time.Duration) {
 	srv := &http.Server{
 		Handler:      router,
@@ -62,6 +163,8 @@ func (b *Base) StartServer(port int, router chi.Router, timeout time.Duration) {
 	}
 
 	log.Printf("### 🌐 %s API, listening on port: %d", b.ServiceName, port)
-	log.Printf("### 🚀 Build details: %s (%s)", b.Version, b.BuildInfo)
-	log.Fatal(srv.ListenAndServe())
+	log.Printf("### 🚀 Build details: %s (%s)", b.Version, b.Build().BuildInfo)
+
+	report := b.Serve(srv)
+	os.Exit(report.ExitCode)
 }
@@ -1,13 +1,18 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"github.com/Okja-Engineering/go-service-kit/pkg/validate"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -137,6 +142,76 @@ func TestReturnOKJSON(t *testing.T) {
 	}
 }
 
+func TestDecodeJSON(t *testing.T) {
+	base := NewBase("TestService", "1.0.0", "test-build", true)
+
+	type payload struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{"valid", "application/json", `{"name":"widget"}`, http.StatusOK},
+		{"valid with charset", "application/json; charset=utf-8", `{"name":"widget"}`, http.StatusOK},
+		{"vendor json", "application/vnd.api+json", `{"name":"widget"}`, http.StatusOK},
+		{"missing content type", "", `{"name":"widget"}`, http.StatusUnsupportedMediaType},
+		{"form content type", "application/x-www-form-urlencoded", `name=widget`, http.StatusUnsupportedMediaType},
+		{"malformed", "application/json", `{"name":`, http.StatusBadRequest},
+		{"invalid", "application/json", `{"name":""}`, http.StatusUnprocessableEntity},
+		{"too large", "application/json", `{"name":"` + strings.Repeat("x", 64) + `"}`,
+			http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/widgets", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			var dst payload
+			err := base.DecodeJSON(r, &dst, validate.WithMaxBodySize(48))
+
+			w := httptest.NewRecorder()
+			if err != nil {
+				base.ReturnProblem(w, r, err)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d (%v)", tt.wantStatus, w.Code, err)
+			}
+			if tt.wantStatus == http.StatusOK && dst.Name != "widget" {
+				t.Errorf("Expected name 'widget', got '%s'", dst.Name)
+			}
+		})
+	}
+}
+
+func TestBindQuery(t *testing.T) {
+	base := NewBase("TestService", "1.0.0", "test-build", true)
+
+	var params struct {
+		Page int `query:"page" default:"1" min:"1"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/widgets?page=3", nil)
+	if err := base.BindQuery(r, &params); err != nil || params.Page != 3 {
+		t.Errorf("Expected page 3, got %d (%v)", params.Page, err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/widgets?page=0", nil)
+	err := base.BindQuery(r, &params)
+	w := httptest.NewRecorder()
+	base.ReturnProblem(w, r, err)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
 func TestStartServer(t *testing.T) {
 	base := NewBase("TestService", "1.0.0", "test-build", true)
 
@@ -157,3 +232,41 @@ func TestStartServer(t *testing.T) {
 	// Give the server a moment to start
 	time.Sleep(10 * time.Millisecond)
 }
+
+func TestHandleError(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"default mapping", fmt.Errorf("finding order: %w", sql.ErrNoRows), http.StatusNotFound},
+		{"query error renders itself", &QueryError{}, http.StatusBadRequest},
+		{"unmapped", errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			b.HandleError(w, httptest.NewRequest(http.MethodGet, "/orders/1", nil), tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if w.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("Expected problem+json, got %s", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+
+	errLocked := errors.New("account locked")
+	b.SetErrorMapper(problem.NewMapper().Register(errLocked,
+		problem.Template{Type: "locked", Title: "Locked", Status: http.StatusLocked}))
+
+	w := httptest.NewRecorder()
+	b.HandleError(w, httptest.NewRequest(http.MethodGet, "/", nil), errLocked)
+	if w.Code != http.StatusLocked {
+		t.Errorf("Expected custom mapper to be used, got %d", w.Code)
+	}
+}
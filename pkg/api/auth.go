@@ -0,0 +1,30 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+)
+
+// RequireAuth creates middleware that validates requests with validator, letting infrastructure routes
+// such as health checks and metrics through. Any auth.Validator works, so passing an
+// auth.PassthroughValidator switches auth off in development, and tests can pass a mock.
+func (b *Base) RequireAuth(validator auth.Validator) func(next http.Handler) http.Handler {
+	if _, ok := validator.(*auth.PassthroughValidator); ok {
+		log.Printf("### 🔐 API: auth is disabled, requests are not validated")
+	}
+
+	return func(next http.Handler) http.Handler {
+		protected := validator.Middleware(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if b.isInfrastructure(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			protected.ServeHTTP(w, r)
+		})
+	}
+}
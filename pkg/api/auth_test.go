@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockValidator accepts requests carrying the token "good"
+type mockValidator struct{}
+
+func (m *mockValidator) ValidateRequest(r *http.Request) auth.ValidationResult {
+	if r.Header.Get("Authorization") != "Bearer good" {
+		return auth.ValidationResult{ErrorCode: "INVALID_TOKEN", Error: "bad token"}
+	}
+	return auth.ValidationResult{Valid: true, Claims: jwt.MapClaims{"sub": "user-1"}}
+}
+
+func (m *mockValidator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := m.ValidateRequest(r)
+		if !result.Valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), auth.JWTClaimsKey, result.Claims)))
+	})
+}
+
+func (m *mockValidator) Protect(next http.HandlerFunc) http.HandlerFunc {
+	return m.Middleware(next).ServeHTTP
+}
+
+func TestRequireAuth(t *testing.T) {
+	base := NewBase("test", "1.0", "", true)
+
+	var userID string
+	handler := base.RequireAuth(&mockValidator{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID = getUserIDFromJWT(r)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+		wantUser   string
+	}{
+		{name: "valid token", path: "/orders", token: "good", wantStatus: http.StatusOK, wantUser: "user-1"},
+		{name: "invalid token", path: "/orders", token: "bad", wantStatus: http.StatusUnauthorized},
+		{name: "missing token", path: "/orders", wantStatus: http.StatusUnauthorized},
+		{name: "infrastructure route", path: "/healthz", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID = ""
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if userID != tt.wantUser {
+				t.Errorf("Expected user %q, got %q", tt.wantUser, userID)
+			}
+		})
+	}
+}
+
+func TestRequireAuthPassthrough(t *testing.T) {
+	base := NewBase("test", "1.0", "", true)
+	validator := &auth.PassthroughValidator{Claims: jwt.MapClaims{"sub": "dev"}}
+
+	var userID string
+	handler := base.RequireAuth(validator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID = getUserIDFromJWT(r)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if w.Code != http.StatusOK || userID != "dev" {
+		t.Errorf("Expected passthrough with user dev, got status %d user %q", w.Code, userID)
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// BuildDetails describes the running build: the version and build info passed to NewBase, plus
+// what the Go toolchain embedded in the binary
+type BuildDetails struct {
+	Service   string `json:"service"`
+	Version   string `json:"version"`
+	BuildInfo string `json:"buildInfo"`
+	GoVersion string `json:"goVersion"`
+	// Module is the main module's path and, when built with go install, its version
+	Module        string `json:"module,omitempty"`
+	ModuleVersion string `json:"moduleVersion,omitempty"`
+	// VCS is set when the binary was built from a version-controlled checkout
+	VCS          *VCSInfo     `json:"vcs,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+}
+
+// VCSInfo is the version control state the binary was built from
+type VCSInfo struct {
+	System   string `json:"system"`
+	Revision string `json:"revision"`
+	Time     string `json:"time,omitempty"`
+	// Modified is true when the checkout had uncommitted changes
+	Modified bool `json:"modified"`
+}
+
+// Dependency is a module compiled into the binary
+type Dependency struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	// Replace is the replacement module, from a replace directive, if any
+	Replace string `json:"replace,omitempty"`
+}
+
+// embeddedBuild reads the binary's build info once, as it cannot change while running
+var embeddedBuild = sync.OnceValue(func() BuildDetails {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return BuildDetails{GoVersion: runtime.Version()}
+	}
+	return parseBuildInfo(info)
+})
+
+// parseBuildInfo extracts the module, VCS, and dependency details from the toolchain's build info
+func parseBuildInfo(info *debug.BuildInfo) BuildDetails {
+	details := BuildDetails{GoVersion: info.GoVersion, Module: info.Main.Path}
+	if info.Main.Version != "(devel)" {
+		details.ModuleVersion = info.Main.Version
+	}
+
+	var vcs VCSInfo
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs":
+			vcs.System = setting.Value
+		case "vcs.revision":
+			vcs.Revision = setting.Value
+		case "vcs.time":
+			vcs.Time = setting.Value
+		case "vcs.modified":
+			vcs.Modified = setting.Value == "true"
+		}
+	}
+	if vcs.Revision != "" {
+		details.VCS = &vcs
+	}
+
+	for _, dep := range info.Deps {
+		d := Dependency{Path: dep.Path, Version: dep.Version}
+		if dep.Replace != nil {
+			d.Replace = dep.Replace.Path
+			if dep.Replace.Version != "" {
+				d.Replace += "@" + dep.Replace.Version
+			}
+		}
+		details.Dependencies = append(details.Dependencies, d)
+	}
+
+	return details
+}
+
+// shortRevision describes a VCS revision as its first 12 characters, marked when modified
+func (v *VCSInfo) shortRevision() string {
+	revision := v.Revision
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if v.Modified {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+// Build returns the details of the running build. When no build info was passed to NewBase, the
+// VCS revision the binary was built from is used instead.
+func (b *Base) Build() BuildDetails {
+	details := embeddedBuild()
+	details.Service = b.ServiceName
+	details.Version = b.Version
+	details.BuildInfo = b.buildInfo(details.VCS)
+	return details
+}
+
+// buildInfo returns the build info passed to NewBase, falling back to the VCS revision
+func (b *Base) buildInfo(vcs *VCSInfo) string {
+	if b.BuildInfo == "" && vcs != nil {
+		return vcs.shortRevision()
+	}
+	return b.BuildInfo
+}
+
+// AddBuildInfoEndpoint serves the details of the running build, including the VCS revision and the
+// versions of every module compiled in. The dependency list helps when auditing for vulnerable
+// versions, but also tells attackers what to look for, so mount this behind authentication.
+func (b *Base) AddBuildInfoEndpoint(r chi.Router, path string) {
+	log.Printf("### 🏷️ API: build info endpoint at: %s", "/"+path)
+	b.Routes().Add("/" + path)
+
+	r.Get("/"+path, func(w http.ResponseWriter, r *http.Request) {
+		b.ReturnJSON(w, b.Build())
+	})
+}
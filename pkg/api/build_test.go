@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestParseBuildInfo(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.24.1",
+		Main:      debug.Module{Path: "example.com/orders", Version: "v1.4.0"},
+		Deps: []*debug.Module{
+			{Path: "github.com/go-chi/chi/v5", Version: "v5.2.1"},
+			{Path: "github.com/lib/pq", Version: "v1.10.9", Replace: &debug.Module{Path: "../pq"}},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "-tags", Value: "netgo"},
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "4f2c8a1b9d0e7f6a5b4c3d2e1f0a9b8c7d6e5f4a"},
+			{Key: "vcs.time", Value: "2024-03-01T12:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	details := parseBuildInfo(info)
+	if details.GoVersion != "go1.24.1" || details.Module != "example.com/orders" || details.ModuleVersion != "v1.4.0" {
+		t.Errorf("Unexpected module details %+v", details)
+	}
+	want := VCSInfo{System: "git", Revision: "4f2c8a1b9d0e7f6a5b4c3d2e1f0a9b8c7d6e5f4a",
+		Time: "2024-03-01T12:00:00Z", Modified: true}
+	if details.VCS == nil || *details.VCS != want {
+		t.Errorf("Expected VCS %+v, got %+v", want, details.VCS)
+	}
+	if len(details.Dependencies) != 2 || details.Dependencies[1].Replace != "../pq" {
+		t.Errorf("Expected dependencies with replacements, got %+v", details.Dependencies)
+	}
+	if got := details.VCS.shortRevision(); got != "4f2c8a1b9d0e-dirty" {
+		t.Errorf("Expected a short dirty revision, got %s", got)
+	}
+
+	details = parseBuildInfo(&debug.BuildInfo{Main: debug.Module{Path: "example.com/orders", Version: "(devel)"}})
+	if details.ModuleVersion != "" || details.VCS != nil {
+		t.Errorf("Expected no version or VCS for a local build, got %+v", details)
+	}
+}
+
+func TestBuildInfoFallback(t *testing.T) {
+	vcs := &VCSInfo{Revision: "4f2c8a1b9d0e7f6a"}
+
+	if got := NewBase("orders", "1.0.0", "", true).buildInfo(vcs); got != "4f2c8a1b9d0e" {
+		t.Errorf("Expected the revision when no build info is given, got %s", got)
+	}
+	if got := NewBase("orders", "1.0.0", "ci-1234", true).buildInfo(vcs); got != "ci-1234" {
+		t.Errorf("Expected the given build info to take precedence, got %s", got)
+	}
+	if got := NewBase("orders", "1.0.0", "", true).buildInfo(nil); got != "" {
+		t.Errorf("Expected no build info without VCS details, got %s", got)
+	}
+}
+
+func TestAddBuildInfoEndpoint(t *testing.T) {
+	base := NewBase("orders", "1.0.0", "ci-1234", true)
+	router := chi.NewRouter()
+	base.AddBuildInfoEndpoint(router, "build")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/build", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var details BuildDetails
+	if err := json.Unmarshal(rec.Body.Bytes(), &details); err != nil {
+		t.Fatalf("Failed to decode build details: %v", err)
+	}
+	if details.Service != "orders" || details.Version != "1.0.0" || details.BuildInfo != "ci-1234" {
+		t.Errorf("Expected the service's own details, got %+v", details)
+	}
+	if details.GoVersion == "" || len(details.Dependencies) == 0 {
+		t.Errorf("Expected the toolchain's build info, got %+v", details)
+	}
+	if !base.Routes().IsInfrastructure("/build") {
+		t.Error("Expected the endpoint to be registered as infrastructure")
+	}
+}
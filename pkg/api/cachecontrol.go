@@ -0,0 +1,180 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheConfig holds the caching headers set by SetCacheControl
+type CacheConfig struct {
+	// Private limits caching to the client, for responses that differ per user
+	Private bool
+	// SharedMaxAge overrides the max age for shared caches such as CDNs
+	SharedMaxAge time.Duration
+	// StaleWhileRevalidate lets caches serve a stale response while fetching a fresh one
+	StaleWhileRevalidate time.Duration
+	// MustRevalidate stops caches serving the response once stale
+	MustRevalidate bool
+	// Immutable tells clients the response never changes, e.g. for versioned assets
+	Immutable bool
+	// Vary lists request headers that select between responses, e.g. Accept-Language
+	Vary []string
+	// LastModified, if set, is sent as the Last-Modified header
+	LastModified time.Time
+}
+
+// CacheOption is a functional option for configuring caching headers
+type CacheOption func(*CacheConfig)
+
+// WithPrivateCache limits caching to the client
+func WithPrivateCache() CacheOption {
+	return func(config *CacheConfig) {
+		config.Private = true
+	}
+}
+
+// WithSharedMaxAge sets a different max age for shared caches
+func WithSharedMaxAge(maxAge time.Duration) CacheOption {
+	return func(config *CacheConfig) {
+		config.SharedMaxAge = maxAge
+	}
+}
+
+// WithStaleWhileRevalidate lets caches serve a stale response for up to d while revalidating
+func WithStaleWhileRevalidate(d time.Duration) CacheOption {
+	return func(config *CacheConfig) {
+		config.StaleWhileRevalidate = d
+	}
+}
+
+// WithMustRevalidate stops caches serving the response once stale
+func WithMustRevalidate() CacheOption {
+	return func(config *CacheConfig) {
+		config.MustRevalidate = true
+	}
+}
+
+// WithImmutable marks the response as never changing
+func WithImmutable() CacheOption {
+	return func(config *CacheConfig) {
+		config.Immutable = true
+	}
+}
+
+// WithVaryOn adds request headers that select between responses
+func WithVaryOn(headers ...string) CacheOption {
+	return func(config *CacheConfig) {
+		config.Vary = append(config.Vary, headers...)
+	}
+}
+
+// WithLastModified sets the Last-Modified header
+func WithLastModified(t time.Time) CacheOption {
+	return func(config *CacheConfig) {
+		config.LastModified = t
+	}
+}
+
+// NewCacheConfig creates a new cache config with options
+func NewCacheConfig(options ...CacheOption) *CacheConfig {
+	config := &CacheConfig{}
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// SetCacheControl lets the response be cached for maxAge, setting Cache-Control, Expires for
+// HTTP/1.0 caches, and the Vary and Last-Modified headers from the options. Responses are public
+// unless WithPrivateCache is given. Any no-store default set by NoStore is replaced.
+func SetCacheControl(w http.ResponseWriter, maxAge time.Duration, options ...CacheOption) {
+	config := NewCacheConfig(options...)
+
+	directives := []string{"public"}
+	if config.Private {
+		directives[0] = "private"
+	}
+	directives = append(directives, "max-age="+seconds(maxAge))
+	if config.SharedMaxAge > 0 && !config.Private {
+		directives = append(directives, "s-maxage="+seconds(config.SharedMaxAge))
+	}
+	if config.StaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+seconds(config.StaleWhileRevalidate))
+	}
+	if config.MustRevalidate {
+		directives = append(directives, "must-revalidate")
+	}
+	if config.Immutable {
+		directives = append(directives, "immutable")
+	}
+
+	header := w.Header()
+	header.Set("Cache-Control", strings.Join(directives, ", "))
+	header.Set("Expires", time.Now().Add(maxAge).UTC().Format(http.TimeFormat))
+	header.Del("Pragma")
+	AddVary(w, config.Vary...)
+	if !config.LastModified.IsZero() {
+		header.Set("Last-Modified", config.LastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// SetNoStore stops the response being stored by any cache, including browsers and proxies
+func SetNoStore(w http.ResponseWriter) {
+	header := w.Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("Pragma", "no-cache")
+	header.Set("Expires", "0")
+}
+
+// AddVary adds headers to the Vary header, skipping any already listed
+func AddVary(w http.ResponseWriter, headers ...string) {
+	listed := make(map[string]bool)
+	for _, value := range w.Header().Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			listed[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+		}
+	}
+
+	for _, name := range headers {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if name != "" && !listed[name] && !listed["*"] {
+			w.Header().Add("Vary", name)
+			listed[name] = true
+		}
+	}
+}
+
+// seconds formats a duration as whole seconds for Cache-Control
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+// ReturnJSONCached writes data as JSON that may be cached for maxAge
+func (b *Base) ReturnJSONCached(w http.ResponseWriter, data interface{}, maxAge time.Duration,
+	options ...CacheOption) {
+	SetCacheControl(w, maxAge, options...)
+	b.ReturnJSON(w, data)
+}
+
+// NoStore is middleware that marks responses no-store by default, so responses for one user are
+// never kept by a shared proxy and served to another. Use it on authenticated route groups; a
+// handler can still opt a response into caching with SetCacheControl.
+func NoStore(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetNoStore(w)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NoStoreAuthenticated is NoStore for requests that carry credentials in an Authorization header,
+// API key header, or cookie, for routers that mix public and authenticated routes
+func NoStoreAuthenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || r.Header.Get("X-Api-Key") != "" || r.Header.Get("Cookie") != "" {
+			SetNoStore(w)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
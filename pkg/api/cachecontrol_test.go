@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSetCacheControl(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		maxAge  time.Duration
+		options []CacheOption
+		want    string
+	}{
+		{"public", time.Minute, nil, "public, max-age=60"},
+		{"private", time.Minute, []CacheOption{WithPrivateCache(), WithSharedMaxAge(time.Hour)},
+			"private, max-age=60"},
+		{"shared", time.Minute, []CacheOption{WithSharedMaxAge(time.Hour), WithStaleWhileRevalidate(30 * time.Second)},
+			"public, max-age=60, s-maxage=3600, stale-while-revalidate=30"},
+		{"immutable", 365 * 24 * time.Hour, []CacheOption{WithImmutable(), WithMustRevalidate()},
+			"public, max-age=31536000, must-revalidate, immutable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			SetNoStore(w)
+			SetCacheControl(w, tt.maxAge, append(tt.options, WithLastModified(modified))...)
+
+			if got := w.Header().Get("Cache-Control"); got != tt.want {
+				t.Errorf("Expected Cache-Control %q, got %q", tt.want, got)
+			}
+			expires, err := http.ParseTime(w.Header().Get("Expires"))
+			if err != nil || expires.Before(time.Now().Add(tt.maxAge-2*time.Second)) {
+				t.Errorf("Expected Expires about %s from now, got %q", tt.maxAge, w.Header().Get("Expires"))
+			}
+			if w.Header().Get("Pragma") != "" {
+				t.Error("Expected the no-store Pragma to be removed")
+			}
+			if got := w.Header().Get("Last-Modified"); got != "Fri, 01 Mar 2024 12:00:00 GMT" {
+				t.Errorf("Expected Last-Modified, got %q", got)
+			}
+		})
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("Vary", "Accept-Encoding, origin")
+
+	AddVary(w, "accept-language", "Origin", "Accept-Language", "")
+
+	got := w.Header().Values("Vary")
+	if len(got) != 2 || got[1] != "Accept-Language" {
+		t.Errorf("Expected only Accept-Language to be added, got %v", got)
+	}
+}
+
+func TestReturnJSONCached(t *testing.T) {
+	base := NewBase("test", "1.0", "", true)
+	w := httptest.NewRecorder()
+
+	base.ReturnJSONCached(w, map[string]string{"status": "ok"}, 5*time.Minute, WithVaryOn("Accept-Language"))
+
+	if w.Header().Get("Cache-Control") != "public, max-age=300" || w.Header().Get("Vary") != "Accept-Language" {
+		t.Errorf("Expected caching headers, got %v", w.Header())
+	}
+	if w.Body.String() != `{"status":"ok"}` {
+		t.Errorf("Expected the JSON body, got %s", w.Body.String())
+	}
+}
+
+func TestNoStore(t *testing.T) {
+	cached := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetCacheControl(w, time.Minute, WithPrivateCache())
+	})
+	plain := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name       string
+		middleware func(http.Handler) http.Handler
+		handler    http.Handler
+		header     string
+		want       string
+	}{
+		{"default", NoStore, plain, "", "no-store"},
+		{"handler opts in", NoStore, cached, "", "private, max-age=60"},
+		{"bearer token", NoStoreAuthenticated, plain, "Authorization", "no-store"},
+		{"api key", NoStoreAuthenticated, plain, "X-Api-Key", "no-store"},
+		{"session cookie", NoStoreAuthenticated, plain, "Cookie", "no-store"},
+		{"anonymous", NoStoreAuthenticated, plain, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/orders", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, "credential")
+			}
+			w := httptest.NewRecorder()
+			tt.middleware(tt.handler).ServeHTTP(w, r)
+
+			if got := w.Header().Get("Cache-Control"); got != tt.want {
+				t.Errorf("Expected Cache-Control %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
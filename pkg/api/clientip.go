@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const clientIPKey contextKey = "clientIP"
+
+// DefaultTrustedProxies are the loopback and private networks load balancers and sidecars usually run in
+var DefaultTrustedProxies = []string{
+	"127.0.0.0/8", "::1/128", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7",
+}
+
+// ClientIPConfig holds configuration for resolving client IPs
+type ClientIPConfig struct {
+	// TrustedProxies are the CIDRs or addresses of peers whose forwarded headers are honored
+	TrustedProxies []string
+	// Headers carrying the client IP, checked in order once the peer is trusted
+	Headers []string
+}
+
+// DefaultClientIPConfig provides sensible defaults: headers are honored from private networks only
+func DefaultClientIPConfig() *ClientIPConfig {
+	return &ClientIPConfig{
+		TrustedProxies: DefaultTrustedProxies,
+		Headers:        []string{"X-Forwarded-For", "X-Real-IP", "X-Client-IP"},
+	}
+}
+
+// ClientIPOption is a functional option for configuring client IP resolution
+type ClientIPOption func(*ClientIPConfig)
+
+// WithTrustedProxies sets the CIDRs or addresses of trusted proxies, replacing the defaults; with
+// none, forwarded headers are always ignored
+func WithTrustedProxies(proxies ...string) ClientIPOption {
+	return func(config *ClientIPConfig) {
+		config.TrustedProxies = proxies
+	}
+}
+
+// WithClientIPHeaders sets the headers carrying the client IP, e.g. "CF-Connecting-IP"
+func WithClientIPHeaders(headers ...string) ClientIPOption {
+	return func(config *ClientIPConfig) {
+		config.Headers = headers
+	}
+}
+
+// NewClientIPConfig creates a new client IP config with options
+func NewClientIPConfig(options ...ClientIPOption) *ClientIPConfig {
+	config := DefaultClientIPConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// ClientIPResolver finds the IP of the client behind any trusted proxies
+type ClientIPResolver struct {
+	config  *ClientIPConfig
+	proxies []netip.Prefix
+}
+
+// NewClientIPResolver parses the trusted proxies; bare addresses are treated as single-host networks
+func NewClientIPResolver(options ...ClientIPOption) (*ClientIPResolver, error) {
+	config := NewClientIPConfig(options...)
+
+	resolver := &ClientIPResolver{config: config}
+	for _, raw := range config.TrustedProxies {
+		prefix, err := parsePrefix(raw, "trusted proxy")
+		if err != nil {
+			return nil, err
+		}
+		resolver.proxies = append(resolver.proxies, prefix)
+	}
+
+	return resolver, nil
+}
+
+// parsePrefix parses a CIDR or a single address, naming what it is in errors
+func parsePrefix(raw, what string) (netip.Prefix, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.Contains(raw, "/") {
+		prefix, err := netip.ParsePrefix(raw)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("%s %q: %w", what, raw, err)
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(raw)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%s %q: %w", what, raw, err)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// defaultClientIPResolver resolves client IPs for requests that have not passed through ClientIPMiddleware
+var defaultClientIPResolver, _ = NewClientIPResolver()
+
+// Resolve returns the client IP of a request. Forwarded headers are only read when the connection's peer
+// is a trusted proxy, and a forwarding chain is read from the right, skipping trusted proxies, so the
+// result is the first address no trusted proxy can vouch for rather than whatever the client claimed.
+func (c *ClientIPResolver) Resolve(r *http.Request) string {
+	peer := peerHost(r.RemoteAddr)
+	if !c.Trusted(peer) {
+		return peer
+	}
+
+	for _, header := range c.config.Headers {
+		if value := r.Header.Get(header); value != "" {
+			return c.fromChain(value)
+		}
+	}
+	return peer
+}
+
+// fromChain returns the rightmost untrusted address of a comma separated chain, or the leftmost
+// when every hop is trusted
+func (c *ClientIPResolver) fromChain(chain string) string {
+	hops := strings.Split(chain, ",")
+	for i := len(hops) - 1; i > 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if !c.Trusted(hop) {
+			return hop
+		}
+	}
+	return strings.TrimSpace(hops[0])
+}
+
+// Trusted reports whether ip belongs to a trusted proxy
+func (c *ClientIPResolver) Trusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range c.proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerHost returns the host of a connection's remote address
+func peerHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// ClientIPMiddleware resolves each request's client IP once and stores it in the context, where rate
+// limiting and the other middleware find it. A nil resolver uses DefaultTrustedProxies.
+func (b *Base) ClientIPMiddleware(resolver *ClientIPResolver) func(next http.Handler) http.Handler {
+	if resolver == nil {
+		resolver = defaultClientIPResolver
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), clientIPKey, resolver.Resolve(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientIPFromContext returns the client IP stored by ClientIPMiddleware
+func ClientIPFromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(clientIPKey).(string)
+	return ip, ok
+}
+
+// ClientIP returns the client IP stored by ClientIPMiddleware, or resolves it with the default
+// trusted proxies when the middleware is not in use
+func ClientIP(r *http.Request) string {
+	if ip, ok := ClientIPFromContext(r.Context()); ok {
+		return ip
+	}
+	return defaultClientIPResolver.Resolve(r)
+}
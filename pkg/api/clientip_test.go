@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	resolver, err := NewClientIPResolver(WithTrustedProxies("10.0.0.0/8", "2001:db8::1"))
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     string
+		value      string
+		expected   string
+	}{
+		{"untrusted peer ignores headers", "203.0.113.9:5000", "X-Forwarded-For", "1.2.3.4", "203.0.113.9"},
+		{"trusted peer honors header", "10.0.0.5:5000", "X-Forwarded-For", "198.51.100.7", "198.51.100.7"},
+		{"spoofed hop skipped", "10.0.0.5:5000", "X-Forwarded-For", "1.2.3.4, 198.51.100.7, 10.1.2.3", "198.51.100.7"},
+		{"all hops trusted", "10.0.0.5:5000", "X-Forwarded-For", "10.9.9.9, 10.1.2.3", "10.9.9.9"},
+		{"trusted IPv6 peer", "[2001:db8::1]:5000", "X-Real-IP", "198.51.100.8", "198.51.100.8"},
+		{"untrusted IPv6 peer", "[2001:db8::2]:5000", "X-Real-IP", "198.51.100.8", "2001:db8::2"},
+		{"no header", "10.0.0.5:5000", "", "", "10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+
+			if ip := resolver.Resolve(req); ip != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, ip)
+			}
+		})
+	}
+}
+
+func TestNewClientIPResolverInvalid(t *testing.T) {
+	for _, proxy := range []string{"10.0.0.0/33", "not-an-ip", ""} {
+		if _, err := NewClientIPResolver(WithTrustedProxies(proxy)); err == nil {
+			t.Errorf("Expected an error for %q", proxy)
+		}
+	}
+}
+
+func TestClientIPResolverNoProxies(t *testing.T) {
+	resolver, err := NewClientIPResolver(WithTrustedProxies())
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "127.0.0.1:5000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+	if ip := resolver.Resolve(req); ip != "127.0.0.1" {
+		t.Errorf("Expected headers to be ignored without trusted proxies, got %s", ip)
+	}
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	resolver, err := NewClientIPResolver(WithTrustedProxies("192.0.2.0/24"), WithClientIPHeaders("CF-Connecting-IP"))
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %v", err)
+	}
+
+	var fromContext, fromRequest string
+	handler := b.ClientIPMiddleware(resolver)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromContext, _ = ClientIPFromContext(r.Context())
+		fromRequest = ClientIP(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil) // RemoteAddr is 192.0.2.1
+	req.Header.Set("CF-Connecting-IP", "198.51.100.7")
+	req.Header.Set("X-Forwarded-For", "1.2.3.4")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if fromContext != "198.51.100.7" || fromRequest != "198.51.100.7" {
+		t.Errorf("Expected the resolved IP in the context, got %q and %q", fromContext, fromRequest)
+	}
+}
+
+func TestRateLimitByIPIgnoresSpoofedHeaders(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	handler := b.RateLimitByIP(NewRateLimiterConfig(WithRequestsPerSecond(0.001), WithBurst(1)))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := make([]int, 0, 2)
+	for _, spoofed := range []string{"1.1.1.1", "2.2.2.2"} {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.RemoteAddr = "203.0.113.9:5000"
+		req.Header.Set("X-Forwarded-For", spoofed)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("Expected a rotating X-Forwarded-For not to evade the limit, got %v", codes)
+	}
+}
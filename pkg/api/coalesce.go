@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// CoalesceConfig holds configuration for request coalescing
+type CoalesceConfig struct {
+	// Headers are part of the default key, so callers with different credentials or representations never
+	// share a response
+	Headers []string
+	// KeyFunc identifies identical requests, replacing the default of path, query, and Headers
+	KeyFunc func(r *http.Request) string
+	// MaxResponseSize is the largest response shared; waiting requests run the handler themselves when it is larger
+	MaxResponseSize int
+}
+
+// DefaultCoalesceConfig provides sensible defaults
+func DefaultCoalesceConfig() *CoalesceConfig {
+	return &CoalesceConfig{
+		Headers:         []string{"Authorization", "X-API-Key", "Cookie", "Accept", "Accept-Encoding"},
+		MaxResponseSize: 1 << 20,
+	}
+}
+
+// CoalesceOption is a functional option for configuring request coalescing
+type CoalesceOption func(*CoalesceConfig)
+
+// WithCoalesceHeaders sets the headers that are part of the default key
+func WithCoalesceHeaders(headers ...string) CoalesceOption {
+	return func(config *CoalesceConfig) {
+		config.Headers = headers
+	}
+}
+
+// WithCoalesceKeyFunc sets how identical requests are identified
+func WithCoalesceKeyFunc(fn func(r *http.Request) string) CoalesceOption {
+	return func(config *CoalesceConfig) {
+		config.KeyFunc = fn
+	}
+}
+
+// WithCoalesceMaxResponseSize sets the largest response that is shared
+func WithCoalesceMaxResponseSize(size int) CoalesceOption {
+	return func(config *CoalesceConfig) {
+		config.MaxResponseSize = size
+	}
+}
+
+// NewCoalesceConfig creates a new coalescing config with options
+func NewCoalesceConfig(options ...CoalesceOption) *CoalesceConfig {
+	config := DefaultCoalesceConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// coalescedCall is a request in progress and, once done, its response
+type coalescedCall struct {
+	done      chan struct{}
+	status    int
+	header    http.Header
+	body      []byte
+	shareable bool
+}
+
+// coalesceGroup tracks the requests in progress by key
+type coalesceGroup struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// join returns the call in progress for key, or starts one, reporting whether the caller leads it
+func (g *coalesceGroup) join(key string) (*coalescedCall, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if call, ok := g.calls[key]; ok {
+		return call, false
+	}
+	call := &coalescedCall{done: make(chan struct{})}
+	g.calls[key] = call
+	return call, true
+}
+
+// finish releases the callers waiting on a call; later requests start a new one
+func (g *coalesceGroup) finish(key string, call *coalescedCall) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	close(call.done)
+}
+
+// Coalesce creates middleware that collapses concurrent identical GET requests into one handler
+// execution, sending its response to every caller. Use it on expensive reads that many clients
+// request at once, such as a popular resource after a cache expires, so the database sees one query
+// instead of a thundering herd. The shared execution isn't cancelled when its first caller goes away,
+// so place Timeout inside this middleware, not outside it.
+func (b *Base) Coalesce(config *CoalesceConfig) func(next http.Handler) http.Handler {
+	if config == nil {
+		config = DefaultCoalesceConfig()
+	}
+	keyFunc := config.KeyFunc
+	if keyFunc == nil {
+		keyFunc = func(r *http.Request) string { return coalesceKey(r, config.Headers) }
+	}
+
+	group := &coalesceGroup{calls: make(map[string]*coalescedCall)}
+
+	log.Printf("### 🤖 API: request coalescing for GET requests")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := keyFunc(r)
+			call, leader := group.join(key)
+			if !leader {
+				serveCoalesced(w, r, call, next)
+				return
+			}
+
+			rec := &duplicateRecorder{ResponseWriter: w, status: http.StatusOK, limit: config.MaxResponseSize}
+			before := w.Header().Clone()
+			defer func() {
+				// A panic is not a response; waiting requests run the handler themselves
+				recovered := recover()
+				call.status, call.header, call.body = rec.status, handlerHeaders(before, w.Header()), rec.body.Bytes()
+				call.shareable = !rec.overflow && recovered == nil
+				group.finish(key, call)
+				if recovered != nil {
+					panic(recovered)
+				}
+			}()
+
+			next.ServeHTTP(rec, r.WithContext(context.WithoutCancel(r.Context())))
+		})
+	}
+}
+
+// serveCoalesced waits for the leading request and copies its response, running the handler
+// itself when the response was too large to share
+func serveCoalesced(w http.ResponseWriter, r *http.Request, call *coalescedCall, next http.Handler) {
+	select {
+	case <-call.done:
+	case <-r.Context().Done():
+		return
+	}
+
+	if !call.shareable {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	for name, values := range call.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("X-Coalesced-Request", "shared")
+	w.WriteHeader(call.status)
+	_, _ = w.Write(call.body)
+}
+
+// handlerHeaders returns the headers the handler set, leaving out those outer middleware had already set
+// for the leading request, such as CORS headers, which waiting requests get from their own middleware
+func handlerHeaders(before, after http.Header) http.Header {
+	header := make(http.Header)
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			header[name] = slices.Clone(values)
+		}
+	}
+	return header
+}
+
+// coalesceKey identifies a request by its path, query, and the given headers
+func coalesceKey(r *http.Request, headers []string) string {
+	h := sha256.New()
+	h.Write([]byte(r.URL.RequestURI()))
+	for _, name := range headers {
+		h.Write([]byte{0})
+		h.Write([]byte(r.Header.Get(name)))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
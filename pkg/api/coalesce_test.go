@@ -0,0 +1,190 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// coalesceRequests sends n concurrent requests built by newRequest once the first has reached the
+// handler, releasing the handler when they are all waiting
+func coalesceRequests(t *testing.T, handler http.Handler, n int,
+	newRequest func(i int) *http.Request, entered <-chan struct{}, release chan<- struct{}) []*httptest.ResponseRecorder {
+	t.Helper()
+
+	recorders := make([]*httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		recorders[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(recorders[i], newRequest(i))
+		}()
+		if i == 0 {
+			<-entered
+		}
+	}
+
+	time.Sleep(20 * time.Millisecond) // let the others join the call in progress
+	close(release)
+	wg.Wait()
+	return recorders
+}
+
+func countingHandler(calls *atomic.Int32, entered chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			entered <- struct{}{}
+		}
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"42"}`))
+	})
+}
+
+func TestCoalesce(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	var calls atomic.Int32
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	handler := b.Coalesce(nil)(countingHandler(&calls, entered, release))
+
+	recorders := coalesceRequests(t, handler, 5, func(int) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/orders/42?expand=items", nil)
+		r.Header.Set("Authorization", "Bearer same")
+		return r
+	}, entered, release)
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected the handler to run once, ran %d times", calls.Load())
+	}
+	shared := 0
+	for _, w := range recorders {
+		if w.Code != http.StatusOK || w.Body.String() != `{"id":"42"}` ||
+			w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected response: %d %q %v", w.Code, w.Body.String(), w.Header())
+		}
+		if w.Header().Get("X-Coalesced-Request") == "shared" {
+			shared++
+		}
+	}
+	if shared != 4 {
+		t.Errorf("Expected 4 shared responses, got %d", shared)
+	}
+}
+
+func TestCoalesceSeparatesCallers(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	var calls atomic.Int32
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	handler := b.Coalesce(nil)(countingHandler(&calls, entered, release))
+
+	coalesceRequests(t, handler, 3, func(i int) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+		r.Header.Set("Authorization", "Bearer token-"+string(rune('a'+i)))
+		return r
+	}, entered, release)
+
+	if calls.Load() != 3 {
+		t.Errorf("Expected each caller to run the handler, ran %d times", calls.Load())
+	}
+}
+
+func TestCoalesceOnlyGet(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	var calls atomic.Int32
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	handler := b.Coalesce(nil)(countingHandler(&calls, entered, release))
+
+	coalesceRequests(t, handler, 3, func(int) *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/orders", nil)
+	}, entered, release)
+
+	if calls.Load() != 3 {
+		t.Errorf("Expected POST requests not to be coalesced, ran %d times", calls.Load())
+	}
+}
+
+func TestCoalesceLargeResponse(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	var calls atomic.Int32
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	handler := b.Coalesce(NewCoalesceConfig(WithCoalesceMaxResponseSize(4)))(countingHandler(&calls, entered, release))
+
+	recorders := coalesceRequests(t, handler, 3, func(int) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	}, entered, release)
+
+	if calls.Load() != 3 {
+		t.Errorf("Expected waiting requests to run the handler themselves, ran %d times", calls.Load())
+	}
+	for _, w := range recorders {
+		if w.Body.String() != `{"id":"42"}` {
+			t.Errorf("Unexpected body %q", w.Body.String())
+		}
+	}
+}
+
+func TestCoalescePanic(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	var calls atomic.Int32
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	inner := countingHandler(&calls, entered, release)
+	handler := b.Coalesce(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner.ServeHTTP(w, r)
+		if calls.Load() == 1 {
+			panic("boom")
+		}
+	}))
+
+	// The leader's panic is recovered here, as the Recoverer middleware would
+	recovering := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if recover() != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}()
+		handler.ServeHTTP(w, r)
+	})
+
+	recorders := coalesceRequests(t, recovering, 3, func(int) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	}, entered, release)
+
+	if calls.Load() != 3 {
+		t.Errorf("Expected waiting requests to run the handler after a panic, ran %d times", calls.Load())
+	}
+	for _, w := range recorders[1:] {
+		if w.Header().Get("X-Coalesced-Request") != "" {
+			t.Error("Expected no response to be shared after a panic")
+		}
+	}
+}
+
+func TestCoalesceHandlerHeadersOnly(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	var calls atomic.Int32
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	coalesced := b.Coalesce(nil)(countingHandler(&calls, entered, release))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+		coalesced.ServeHTTP(w, r)
+	})
+
+	recorders := coalesceRequests(t, handler, 2, func(i int) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+		r.Header.Set("Origin", []string{"https://a.test", "https://b.test"}[i])
+		return r
+	}, entered, release)
+
+	if calls.Load() != 1 {
+		t.Fatalf("Expected the requests to be coalesced, ran %d times", calls.Load())
+	}
+	shared := recorders[1].Header()
+	if shared.Get("Access-Control-Allow-Origin") != "https://b.test" || shared.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected the handler's headers with the follower's own CORS header, got %v", shared)
+	}
+}
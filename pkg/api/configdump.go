@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/redact"
+	"github.com/go-chi/chi/v5"
+)
+
+// configRedactor redacts configuration dumps, treating any key, DSN, or connection string as a secret
+var configRedactor = redact.New(redact.WithFields("key", "dsn", "connectionstring"))
+
+// AddConfigEndpoint serves cfg as JSON, so you can check what configuration a running replica
+// actually loaded. Struct fields tagged secret:"true" are redacted, as are values whose names look
+// sensitive, such as passwords, keys, and tokens, however deeply nested. Pass a pointer to see
+// changes made after startup. Even redacted, configuration reveals internals, so mount this behind
+// authentication.
+func (b *Base) AddConfigEndpoint(r chi.Router, path string, cfg interface{}) {
+	log.Printf("### ⚙️ API: config endpoint at: %s", "/"+path)
+
+	r.Get("/"+path, b.configHandler(cfg))
+}
+
+// configHandler serves cfg with its secrets redacted
+func (b *Base) configHandler(cfg interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		redactedConfig, err := redactConfig(cfg)
+		if err != nil {
+			b.HandleError(w, r, err)
+			return
+		}
+		b.ReturnJSON(w, redactedConfig)
+	}
+}
+
+// redactConfig converts cfg to its JSON form and redacts fields tagged secret:"true" and the values
+// of sensitive keys, however deeply nested
+func redactConfig(cfg interface{}) (interface{}, error) {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree interface{}
+	if err := json.Unmarshal(raw, &tree); err != nil {
+		return nil, err
+	}
+
+	maskSecrets(reflect.ValueOf(cfg), tree)
+	return configRedactor.Tree(tree), nil
+}
+
+// maskSecrets walks v alongside its decoded JSON form, masking the fields tagged secret:"true"
+func maskSecrets(v reflect.Value, node interface{}) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if fields, ok := node.(map[string]interface{}); ok {
+			maskStructSecrets(v, fields)
+		}
+	case reflect.Slice, reflect.Array:
+		if items, ok := node.([]interface{}); ok {
+			for i := 0; i < v.Len() && i < len(items); i++ {
+				maskSecrets(v.Index(i), items[i])
+			}
+		}
+	case reflect.Map:
+		if entries, ok := node.(map[string]interface{}); ok {
+			iter := v.MapRange()
+			for iter.Next() {
+				maskSecrets(iter.Value(), entries[fmt.Sprint(iter.Key().Interface())])
+			}
+		}
+	}
+}
+
+// maskStructSecrets masks the secret fields of a struct, and looks for more in the others
+func maskStructSecrets(v reflect.Value, fields map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, named := jsonFieldName(field)
+		switch {
+		case !field.IsExported() && !field.Anonymous, name == "-":
+			continue
+		case field.Anonymous && !named:
+			// Embedded structs without a JSON name are flattened into their parent
+			maskSecrets(v.Field(i), fields)
+		case field.Tag.Get("secret") == "true":
+			if value, ok := fields[name]; ok && value != nil && value != "" {
+				fields[name] = redact.Mask
+			}
+		default:
+			maskSecrets(v.Field(i), fields[name])
+		}
+	}
+}
+
+// jsonFieldName returns the name a field is encoded under, and whether its json tag set one
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "-", true
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, true
+	}
+	return field.Name, false
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type testDatabaseConfig struct {
+	Host string `json:"host"`
+	// Secret by tag, though the name doesn't look sensitive
+	URL string `json:"url" secret:"true"`
+}
+
+type testUpstream struct {
+	Name    string `json:"name"`
+	Bearer  string `json:"bearer" secret:"true"`
+	Timeout int    `json:"timeout"`
+}
+
+type testLicense struct {
+	LicenseID string `secret:"true"`
+}
+
+type testServiceConfig struct {
+	testLicense
+	Listen    string                  `json:"listen"`
+	Database  *testDatabaseConfig     `json:"database"`
+	Upstreams []testUpstream          `json:"upstreams"`
+	Regions   map[string]testUpstream `json:"regions"`
+	APIToken  string                  `json:"apiToken"`
+	Webhook   string                  `json:"webhook" secret:"true"`
+	Ignored   string                  `json:"-" secret:"true"`
+}
+
+func TestRedactConfigSecretTags(t *testing.T) {
+	cfg := &testServiceConfig{
+		testLicense: testLicense{LicenseID: "lic-1"},
+		Listen:      ":8080",
+		Database:    &testDatabaseConfig{Host: "db.internal", URL: "postgres://app:pw@db.internal/app"},
+		Upstreams:   []testUpstream{{Name: "billing", Bearer: "b1", Timeout: 5}},
+		Regions:     map[string]testUpstream{"eu": {Name: "eu", Bearer: "b2"}},
+		APIToken:    "t1",
+	}
+
+	redacted, err := redactConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(redacted)
+	want := `{"LicenseID":"[REDACTED]","apiToken":"[REDACTED]",` +
+		`"database":{"host":"db.internal","url":"[REDACTED]"},"listen":":8080",` +
+		`"regions":{"eu":{"bearer":"[REDACTED]","name":"eu","timeout":0}},` +
+		`"upstreams":[{"bearer":"[REDACTED]","name":"billing","timeout":5}],"webhook":""}`
+	if string(body) != want {
+		t.Errorf("Expected %s, got %s", want, body)
+	}
+	if cfg.Database.URL != "postgres://app:pw@db.internal/app" || cfg.Upstreams[0].Bearer != "b1" {
+		t.Error("Expected the configuration itself to be left unchanged")
+	}
+}
+
+func TestAddConfigEndpoint(t *testing.T) {
+	base := NewBase("orders", "1.0.0", "", true)
+	cfg := &testDatabaseConfig{Host: "db.internal", URL: "postgres://app:pw@db.internal/app"}
+	router := chi.NewRouter()
+	base.AddConfigEndpoint(router, "config", cfg)
+
+	// Changes after startup are served, as the config is read on each request
+	cfg.Host = "db2.internal"
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if want := `{"host":"db2.internal","url":"[REDACTED]"}`; rec.Body.String() != want {
+		t.Errorf("Expected %s, got %s", want, rec.Body.String())
+	}
+}
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// deprecatedRequestsTotal counts calls to deprecated routes, exposed by AddMetricsEndpoint
+var deprecatedRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_deprecated_requests_total",
+	Help: "Total number of requests to deprecated routes",
+}, []string{"route"})
+
+// maxDeprecationClients bounds the usage table; further clients are counted under "other"
+const maxDeprecationClients = 10000
+
+// DeprecationConfig holds configuration for a deprecated route
+type DeprecationConfig struct {
+	// Deprecated is when the route was deprecated; zero sends "Deprecation: true"
+	Deprecated time.Time
+	// Sunset is when the route will be removed; zero omits the Sunset header
+	Sunset time.Time
+	// DocURL links to documentation about the deprecation
+	DocURL string
+	// SuccessorURL links to the replacement route
+	SuccessorURL string
+	// ClientFunc identifies the caller for usage counting; by default the API key, JWT subject, or IP
+	ClientFunc func(r *http.Request) string
+}
+
+// DefaultDeprecationConfig provides sensible defaults
+func DefaultDeprecationConfig() *DeprecationConfig {
+	return &DeprecationConfig{
+		ClientFunc: defaultDeprecationClient,
+	}
+}
+
+// DeprecationOption is a functional option for configuring deprecation
+type DeprecationOption func(*DeprecationConfig)
+
+// WithDeprecatedSince sets when the route was deprecated
+func WithDeprecatedSince(t time.Time) DeprecationOption {
+	return func(config *DeprecationConfig) {
+		config.Deprecated = t
+	}
+}
+
+// WithSunset sets when the route will be removed
+func WithSunset(t time.Time) DeprecationOption {
+	return func(config *DeprecationConfig) {
+		config.Sunset = t
+	}
+}
+
+// WithDeprecationDoc links to documentation about the deprecation
+func WithDeprecationDoc(url string) DeprecationOption {
+	return func(config *DeprecationConfig) {
+		config.DocURL = url
+	}
+}
+
+// WithSuccessor links to the route that replaces the deprecated one
+func WithSuccessor(url string) DeprecationOption {
+	return func(config *DeprecationConfig) {
+		config.SuccessorURL = url
+	}
+}
+
+// WithDeprecationClientFunc sets how callers are identified for usage counting
+func WithDeprecationClientFunc(fn func(r *http.Request) string) DeprecationOption {
+	return func(config *DeprecationConfig) {
+		config.ClientFunc = fn
+	}
+}
+
+// NewDeprecationConfig creates a new deprecation config with options
+func NewDeprecationConfig(options ...DeprecationOption) *DeprecationConfig {
+	config := DefaultDeprecationConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+func defaultDeprecationClient(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return "key:" + maskToken(key)
+	}
+	if userID := getUserIDFromJWT(r); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + getClientIP(r)
+}
+
+// DeprecationUsage is how often a client has called a deprecated route
+type DeprecationUsage struct {
+	Route     string    `json:"route"`
+	Client    string    `json:"client"`
+	Count     int64     `json:"count"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+type deprecationKey struct {
+	route  string
+	client string
+}
+
+// deprecationRegistry holds usage of deprecated routes on a Base
+type deprecationRegistry struct {
+	mu    sync.Mutex
+	usage map[deprecationKey]*DeprecationUsage
+}
+
+func (dr *deprecationRegistry) record(route, client string) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+
+	key := deprecationKey{route: route, client: client}
+	if _, ok := dr.usage[key]; !ok && len(dr.usage) >= maxDeprecationClients {
+		key.client = "other"
+	}
+
+	now := time.Now().UTC()
+	u, ok := dr.usage[key]
+	if !ok {
+		u = &DeprecationUsage{Route: key.route, Client: key.client, FirstSeen: now}
+		dr.usage[key] = u
+	}
+	u.Count++
+	u.LastSeen = now
+}
+
+// Deprecated creates middleware for deprecated routes. It sets the Deprecation, Sunset, and Link
+// headers and counts calls per route and client, so owners can see who still uses a route before
+// removing it. Usage is available from DeprecationUsage and the http_deprecated_requests_total metric.
+func (b *Base) Deprecated(config *DeprecationConfig) func(next http.Handler) http.Handler {
+	b.initOnce.Do(b.init)
+
+	if config == nil {
+		config = DefaultDeprecationConfig()
+	}
+
+	headers := deprecationHeaders(config)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for name, value := range headers {
+				w.Header().Set(name, value)
+			}
+
+			next.ServeHTTP(w, r)
+
+			route := r.URL.Path
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			deprecatedRequestsTotal.WithLabelValues(route).Inc()
+			b.deprecations.record(route, config.ClientFunc(r))
+		})
+	}
+}
+
+// deprecationHeaders builds the response headers for a deprecated route (RFC 9745 and RFC 8594)
+func deprecationHeaders(config *DeprecationConfig) map[string]string {
+	headers := map[string]string{"Deprecation": "true"}
+	if !config.Deprecated.IsZero() {
+		headers["Deprecation"] = fmt.Sprintf("@%d", config.Deprecated.Unix())
+	}
+	if !config.Sunset.IsZero() {
+		headers["Sunset"] = config.Sunset.UTC().Format(http.TimeFormat)
+	}
+
+	var links []string
+	if config.DocURL != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="deprecation"; type="text/html"`, config.DocURL))
+	}
+	if config.SuccessorURL != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="successor-version"`, config.SuccessorURL))
+	}
+	if len(links) > 0 {
+		headers["Link"] = strings.Join(links, ", ")
+	}
+
+	return headers
+}
+
+// DeprecationUsage returns calls to deprecated routes per client, by route and then most calls first
+func (b *Base) DeprecationUsage() []DeprecationUsage {
+	b.initOnce.Do(b.init)
+
+	b.deprecations.mu.Lock()
+	defer b.deprecations.mu.Unlock()
+
+	usage := make([]DeprecationUsage, 0, len(b.deprecations.usage))
+	for _, u := range b.deprecations.usage {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Route != usage[j].Route {
+			return usage[i].Route < usage[j].Route
+		}
+		return usage[i].Count > usage[j].Count
+	})
+
+	return usage
+}
+
+// AddDeprecationUsageEndpoint serves the usage of deprecated routes as JSON
+func (b *Base) AddDeprecationUsageEndpoint(r chi.Router, path string) {
+	log.Printf("### 🪦 API: deprecation usage endpoint at: %s", "/"+path)
+
+	r.Get("/"+path, func(w http.ResponseWriter, r *http.Request) {
+		b.ReturnJSON(w, b.DeprecationUsage())
+	})
+}
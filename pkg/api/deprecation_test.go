@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestDeprecatedHeaders(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+
+	tests := []struct {
+		name    string
+		options []DeprecationOption
+		want    map[string]string
+	}{
+		{"minimal", nil, map[string]string{"Deprecation": "true", "Sunset": "", "Link": ""}},
+		{"full", []DeprecationOption{
+			WithDeprecatedSince(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+			WithSunset(time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)),
+			WithDeprecationDoc("https://docs.example.com/v1"),
+			WithSuccessor("/v2/orders"),
+		}, map[string]string{
+			"Deprecation": "@1704067200",
+			"Sunset":      "Tue, 31 Dec 2024 00:00:00 GMT",
+			"Link": `<https://docs.example.com/v1>; rel="deprecation"; type="text/html", ` +
+				`</v2/orders>; rel="successor-version"`,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := b.Deprecated(NewDeprecationConfig(tt.options...))(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/orders", nil))
+
+			for name, want := range tt.want {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("Expected %s header %q, got %q", name, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDeprecationUsage(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	router := chi.NewRouter()
+	router.With(b.Deprecated(nil)).Get("/v1/orders/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	b.AddDeprecationUsageEndpoint(router, "deprecations")
+
+	send := func(header, value string) {
+		r := httptest.NewRequest(http.MethodGet, "/v1/orders/7", nil)
+		r.RemoteAddr = "127.0.0.1:40000" // a local proxy, so X-Forwarded-For is honored
+		r.Header.Set(header, value)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	send("X-API-Key", "sk_live_abcdefgh12345678")
+	send("X-API-Key", "sk_live_abcdefgh12345678")
+	send("X-Forwarded-For", "10.0.0.1")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/deprecations", nil))
+
+	var usage []DeprecationUsage
+	if err := json.Unmarshal(w.Body.Bytes(), &usage); err != nil {
+		t.Fatalf("Failed to decode usage: %v", err)
+	}
+	if len(usage) != 2 {
+		t.Fatalf("Expected 2 clients, got %+v", usage)
+	}
+	if usage[0].Route != "/v1/orders/{id}" || usage[0].Client != "key:sk_l...5678" || usage[0].Count != 2 {
+		t.Errorf("Unexpected top usage: %+v", usage[0])
+	}
+	if usage[1].Client != "ip:10.0.0.1" || usage[1].Count != 1 {
+		t.Errorf("Unexpected usage: %+v", usage[1])
+	}
+}
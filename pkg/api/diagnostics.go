@@ -0,0 +1,217 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// DiagnosticsFunc reports a component's internal state, which must be JSON serializable
+type DiagnosticsFunc func() interface{}
+
+// DiagnosticsConfig holds configuration for signal-triggered diagnostics
+type DiagnosticsConfig struct {
+	// Logger receives the dumps
+	Logger problem.Logger
+	// StacksSignal dumps goroutine stacks and memory statistics; SIGUSR1 where supported
+	StacksSignal os.Signal
+	// StateSignal dumps the kit's internal state; SIGUSR2 where supported
+	StateSignal os.Signal
+}
+
+// DefaultDiagnosticsConfig provides sensible defaults for the platform
+func DefaultDiagnosticsConfig() *DiagnosticsConfig {
+	stacks, state := defaultDiagnosticsSignals()
+	return &DiagnosticsConfig{
+		Logger:       &problem.DefaultLogger{},
+		StacksSignal: stacks,
+		StateSignal:  state,
+	}
+}
+
+// DiagnosticsOption is a functional option for configuring diagnostics
+type DiagnosticsOption func(*DiagnosticsConfig)
+
+// WithDiagnosticsLogger sets the logger that receives the dumps
+func WithDiagnosticsLogger(logger problem.Logger) DiagnosticsOption {
+	return func(config *DiagnosticsConfig) {
+		config.Logger = logger
+	}
+}
+
+// WithDiagnosticsSignals sets the signals that trigger the stack and state dumps
+func WithDiagnosticsSignals(stacks, state os.Signal) DiagnosticsOption {
+	return func(config *DiagnosticsConfig) {
+		config.StacksSignal = stacks
+		config.StateSignal = state
+	}
+}
+
+// NewDiagnosticsConfig creates a new diagnostics config with options
+func NewDiagnosticsConfig(options ...DiagnosticsOption) *DiagnosticsConfig {
+	config := DefaultDiagnosticsConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// diagnosticsRegistry holds the state reporters registered on a Base
+type diagnosticsRegistry struct {
+	mu       sync.RWMutex
+	funcs    map[string]DiagnosticsFunc
+	limiters map[string][]*rateLimiter
+	// closeHooked is set once CloseRateLimiters is registered as a shutdown hook
+	closeHooked bool
+}
+
+// RegisterDiagnostics adds a component to the state dump, for example database pool statistics
+func (b *Base) RegisterDiagnostics(name string, fn DiagnosticsFunc) {
+	b.initOnce.Do(b.init)
+
+	b.diagnostics.mu.Lock()
+	defer b.diagnostics.mu.Unlock()
+	b.diagnostics.funcs[name] = fn
+}
+
+// trackLimiter includes a rate limiter's size in the state dump, and closes it during graceful shutdown
+func (b *Base) trackLimiter(kind string, limiter *rateLimiter) {
+	b.initOnce.Do(b.init)
+
+	b.diagnostics.mu.Lock()
+	b.diagnostics.limiters[kind] = append(b.diagnostics.limiters[kind], limiter)
+	hook := !b.diagnostics.closeHooked
+	b.diagnostics.closeHooked = true
+	b.diagnostics.mu.Unlock()
+
+	if hook {
+		b.OnShutdown("rate-limiters", func(context.Context) error {
+			b.CloseRateLimiters()
+			return nil
+		})
+	}
+}
+
+// CloseRateLimiters stops the background cleanup of every rate limiter created by the Base and
+// forgets them, so middleware built afterwards starts afresh. It runs during graceful shutdown;
+// call it when rebuilding rate limiting middleware, or at the end of tests.
+func (b *Base) CloseRateLimiters() {
+	b.initOnce.Do(b.init)
+
+	b.diagnostics.mu.Lock()
+	limiters := b.diagnostics.limiters
+	b.diagnostics.limiters = make(map[string][]*rateLimiter)
+	b.diagnostics.mu.Unlock()
+
+	for _, list := range limiters {
+		for _, limiter := range list {
+			limiter.Close()
+		}
+	}
+}
+
+// DiagnosticsState collects the kit's internal state: active rate limiter keys, dependency
+// checks, and every component added with RegisterDiagnostics
+func (b *Base) DiagnosticsState() map[string]interface{} {
+	b.initOnce.Do(b.init)
+
+	b.diagnostics.mu.RLock()
+	defer b.diagnostics.mu.RUnlock()
+
+	limiters := make(map[string][]int, len(b.diagnostics.limiters))
+	for kind, list := range b.diagnostics.limiters {
+		for _, limiter := range list {
+			limiters[kind] = append(limiters[kind], limiter.size())
+		}
+	}
+
+	state := map[string]interface{}{
+		"rateLimiters": limiters,
+		"healthChecks": b.HealthChecks(),
+	}
+	for name, fn := range b.diagnostics.funcs {
+		state[name] = fn()
+	}
+
+	return state
+}
+
+// DumpStacks writes all goroutine stacks and memory statistics to the logger
+func DumpStacks(logger problem.Logger) {
+	var sb strings.Builder
+	_ = pprof.Lookup("goroutine").WriteTo(&sb, 2)
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	logger.Printf("### 🩺 Diagnostics: %d goroutines\n%s", runtime.NumGoroutine(), sb.String())
+	logger.Printf("### 🩺 Diagnostics: memory alloc=%d totalAlloc=%d sys=%d heapObjects=%d numGC=%d pauseTotal=%dns",
+		mem.Alloc, mem.TotalAlloc, mem.Sys, mem.HeapObjects, mem.NumGC, mem.PauseTotalNs)
+}
+
+// DumpState writes the kit's internal state to the logger
+func (b *Base) DumpState(logger problem.Logger) {
+	state := b.DiagnosticsState()
+
+	names := make([]string, 0, len(state))
+	for name := range state {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data, err := json.Marshal(state[name])
+		if err != nil {
+			data = []byte(fmt.Sprintf("%q", err.Error()))
+		}
+		logger.Printf("### 🩺 Diagnostics: %s: %s", name, data)
+	}
+}
+
+// EnableSignalDiagnostics installs signal handlers that dump goroutine stacks and memory statistics
+// (SIGUSR1) or internal state (SIGUSR2) to the logger, for debugging instances where debug endpoints
+// are disabled. Handlers are removed when ctx is done. Signals are not available on Windows.
+func (b *Base) EnableSignalDiagnostics(ctx context.Context, options ...DiagnosticsOption) {
+	config := NewDiagnosticsConfig(options...)
+	if config.StacksSignal == nil && config.StateSignal == nil {
+		log.Printf("### 🩺 API: signal diagnostics are not supported on %s", runtime.GOOS)
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	for _, sig := range []os.Signal{config.StacksSignal, config.StateSignal} {
+		if sig != nil {
+			signal.Notify(signals, sig)
+		}
+	}
+
+	log.Printf("### 🩺 API: signal diagnostics enabled, stacks on %v and state on %v",
+		config.StacksSignal, config.StateSignal)
+
+	go func() {
+		defer signal.Stop(signals)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signals:
+				if sig == config.StacksSignal {
+					DumpStacks(config.Logger)
+				} else {
+					b.DumpState(config.Logger)
+				}
+			}
+		}
+	}()
+}
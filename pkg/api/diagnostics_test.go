@@ -0,0 +1,73 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCloseRateLimiters(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	b.RateLimitByIP(nil)
+	b.RateLimitByToken(nil)
+	limiter := b.diagnostics.limiters["ip"][0]
+
+	b.CloseRateLimiters()
+
+	select {
+	case <-limiter.done:
+	default:
+		t.Error("Expected the cleanup goroutine to have stopped")
+	}
+	if len(b.DiagnosticsState()["rateLimiters"].(map[string][]int)) != 0 {
+		t.Error("Expected closed limiters to be forgotten")
+	}
+
+	b.CloseRateLimiters()
+	if hooks := len(b.lifecycle().hooks); hooks != 1 {
+		t.Errorf("Expected one shutdown hook for all limiters, got %d", hooks)
+	}
+}
+
+func TestDiagnosticsState(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	b.RateLimitByIP(nil)
+	b.UserAgentFilter(NewUserAgentConfig(WithClassRateLimit(UAClassBot, nil)))
+	b.RegisterDiagnostics("database", func() interface{} {
+		return map[string]int{"openConnections": 3}
+	})
+
+	limiter := b.diagnostics.limiters["ip"][0]
+	limiter.getLimiter("10.0.0.1")
+	limiter.getLimiter("10.0.0.2")
+
+	state := b.DiagnosticsState()
+	limiters, ok := state["rateLimiters"].(map[string][]int)
+	if !ok || len(limiters["ip"]) != 1 || limiters["ip"][0] != 2 {
+		t.Errorf("Expected ip limiter with 2 keys, got %v", state["rateLimiters"])
+	}
+	if _, ok := limiters["userAgent:bot"]; !ok {
+		t.Errorf("Expected user-agent limiter to be tracked, got %v", limiters)
+	}
+	if _, ok := state["database"]; !ok {
+		t.Error("Expected registered diagnostics in state")
+	}
+
+	logger := &bufferLogger{}
+	b.DumpState(logger)
+	for _, want := range []string{`database: {"openConnections":3}`, `rateLimiters: {"ip":[2]`, "healthChecks: []"} {
+		if !strings.Contains(logger.buf.String(), want) {
+			t.Errorf("Expected state dump to contain %q, got %s", want, logger.buf.String())
+		}
+	}
+}
+
+func TestDumpStacks(t *testing.T) {
+	logger := &bufferLogger{}
+	DumpStacks(logger)
+
+	for _, want := range []string{"goroutine", "TestDumpStacks", "heapObjects="} {
+		if !strings.Contains(logger.buf.String(), want) {
+			t.Errorf("Expected dump to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package api
+
+import (
+	"os"
+	"syscall"
+)
+
+func defaultDiagnosticsSignals() (os.Signal, os.Signal) {
+	return syscall.SIGUSR1, syscall.SIGUSR2
+}
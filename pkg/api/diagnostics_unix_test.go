@@ -0,0 +1,54 @@
+//go:build !windows
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type syncLogger struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *syncLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(&l.buf, format, v...)
+}
+
+func (l *syncLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+func TestEnableSignalDiagnostics(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	b.RegisterDiagnostics("cache", func() interface{} { return map[string]int{"entries": 42} })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := &syncLogger{}
+	b.EnableSignalDiagnostics(ctx, WithDiagnosticsLogger(logger))
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatalf("Failed to send signal: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(logger.String(), `cache: {"entries":42}`) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected state dump after SIGUSR2, got %s", logger.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package api
+
+import "os"
+
+// Windows has no user-defined signals, so diagnostics are only triggered by explicit signals
+func defaultDiagnosticsSignals() (os.Signal, os.Signal) {
+	return nil, nil
+}
@@ -0,0 +1,290 @@
+package api
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// DuplicateMode decides how a duplicate submission is answered
+type DuplicateMode int
+
+const (
+	// DuplicateReject answers duplicates with 409 Conflict
+	DuplicateReject DuplicateMode = iota
+	// DuplicateReplay answers duplicates with the response of the original request
+	DuplicateReplay
+)
+
+// DuplicateConfig holds configuration for duplicate submission detection
+type DuplicateConfig struct {
+	// Window is how long after a request an identical one is treated as a duplicate
+	Window time.Duration
+	Mode   DuplicateMode
+	// Methods that are checked; idempotent methods don't need the guard
+	Methods []string
+	// MaxBodySize is the largest body that is hashed; larger requests are not checked
+	MaxBodySize int64
+	// MaxResponseSize is the largest response kept for replay; larger ones are rejected as duplicates instead
+	MaxResponseSize int
+	// UserFunc identifies the caller; by default the JWT subject, falling back to the client IP
+	UserFunc func(r *http.Request) string
+}
+
+// DefaultDuplicateConfig provides sensible defaults
+func DefaultDuplicateConfig() *DuplicateConfig {
+	return &DuplicateConfig{
+		Window:          5 * time.Second,
+		Mode:            DuplicateReject,
+		Methods:         []string{http.MethodPost, http.MethodPatch},
+		MaxBodySize:     1 << 20,
+		MaxResponseSize: 64 << 10,
+		UserFunc:        defaultDuplicateUser,
+	}
+}
+
+// DuplicateOption is a functional option for configuring duplicate detection
+type DuplicateOption func(*DuplicateConfig)
+
+// WithDuplicateWindow sets how long identical requests are treated as duplicates
+func WithDuplicateWindow(window time.Duration) DuplicateOption {
+	return func(config *DuplicateConfig) {
+		config.Window = window
+	}
+}
+
+// WithDuplicateReplay returns the original response to duplicates instead of 409
+func WithDuplicateReplay() DuplicateOption {
+	return func(config *DuplicateConfig) {
+		config.Mode = DuplicateReplay
+	}
+}
+
+// WithDuplicateMethods sets the HTTP methods that are checked
+func WithDuplicateMethods(methods ...string) DuplicateOption {
+	return func(config *DuplicateConfig) {
+		config.Methods = methods
+	}
+}
+
+// WithDuplicateMaxBodySize sets the largest request body that is hashed
+func WithDuplicateMaxBodySize(size int64) DuplicateOption {
+	return func(config *DuplicateConfig) {
+		config.MaxBodySize = size
+	}
+}
+
+// WithDuplicateUserFunc sets how the caller is identified
+func WithDuplicateUserFunc(fn func(r *http.Request) string) DuplicateOption {
+	return func(config *DuplicateConfig) {
+		config.UserFunc = fn
+	}
+}
+
+// NewDuplicateConfig creates a new duplicate detection config with options
+func NewDuplicateConfig(options ...DuplicateOption) *DuplicateConfig {
+	config := DefaultDuplicateConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+func defaultDuplicateUser(r *http.Request) string {
+	if userID := getUserIDFromJWT(r); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + getClientIP(r)
+}
+
+// duplicateEntry is a recently seen request and, once complete, its response
+type duplicateEntry struct {
+	key     string
+	expires time.Time
+	done    chan struct{}
+	status  int
+	header  http.Header
+	body    []byte
+	// replayable is false when the response was too large or failed, so it can't be replayed
+	replayable bool
+}
+
+// duplicateStore tracks recently seen requests by hash
+type duplicateStore struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // oldest first, which is also expiry order since the window is fixed
+}
+
+// newDuplicateStore creates an empty store
+func newDuplicateStore() *duplicateStore {
+	return &duplicateStore{entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// claim returns the existing entry for key, or registers a new one and reports true
+func (s *duplicateStore) claim(key string, window time.Duration) (*duplicateEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop expired entries from the front, so memory is bounded by the request rate
+	now := time.Now()
+	for oldest := s.order.Front(); oldest != nil && !now.Before(oldest.Value.(*duplicateEntry).expires); {
+		next := oldest.Next()
+		s.remove(oldest)
+		oldest = next
+	}
+
+	if elem, ok := s.entries[key]; ok {
+		return elem.Value.(*duplicateEntry), false
+	}
+
+	entry := &duplicateEntry{key: key, expires: now.Add(window), done: make(chan struct{})}
+	s.entries[key] = s.order.PushBack(entry)
+	return entry, true
+}
+
+// release forgets a request so that it can be retried, used when the original failed
+func (s *duplicateStore) release(key string, entry *duplicateEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok && elem.Value == entry {
+		s.remove(elem)
+	}
+}
+
+// remove drops an entry from the map and the expiry order
+func (s *duplicateStore) remove(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.entries, elem.Value.(*duplicateEntry).key)
+}
+
+// DuplicateGuard creates middleware that detects double submissions, such as a browser posting a
+// form twice, for clients that can't send an Idempotency-Key. Requests with the same method, path,
+// body, and caller within the window are answered with 409, or with the original response in replay
+// mode. Detection is best effort and per instance; requests carrying an Idempotency-Key are skipped.
+func (b *Base) DuplicateGuard(config *DuplicateConfig) func(next http.Handler) http.Handler {
+	if config == nil {
+		config = DefaultDuplicateConfig()
+	}
+
+	store := newDuplicateStore()
+
+	log.Printf("### 🤖 API: duplicate submission guard with %s window", config.Window)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(config.Methods, r.Method) || r.Header.Get("Idempotency-Key") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, config.MaxBodySize+1))
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+			if int64(len(body)) > config.MaxBodySize {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := duplicateKey(r, config.UserFunc(r), body)
+			entry, isNew := store.claim(key, config.Window)
+			if !isNew {
+				handleDuplicate(w, r, entry, config)
+				return
+			}
+
+			rec := &duplicateRecorder{ResponseWriter: w, status: http.StatusOK, limit: config.MaxResponseSize}
+			defer func() {
+				entry.status, entry.header, entry.body = rec.status, w.Header().Clone(), rec.body.Bytes()
+				entry.replayable = !rec.overflow && rec.status < http.StatusInternalServerError
+				if rec.status >= http.StatusInternalServerError {
+					store.release(key, entry)
+				}
+				close(entry.done)
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+func duplicateKey(r *http.Request, user string, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{r.Method, r.URL.RequestURI(), user} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// handleDuplicate answers a duplicate request according to the configured mode
+func handleDuplicate(w http.ResponseWriter, r *http.Request, entry *duplicateEntry, config *DuplicateConfig) {
+	log.Printf("### 🚫 Duplicate submission detected: %s %s", r.Method, r.URL.Path)
+
+	if config.Mode == DuplicateReplay {
+		select {
+		case <-entry.done:
+		case <-r.Context().Done():
+			return
+		}
+
+		if entry.replayable {
+			for name, values := range entry.header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("X-Duplicate-Request", "replayed")
+			w.WriteHeader(entry.status)
+			_, _ = w.Write(entry.body)
+			return
+		}
+	}
+
+	problem.New("duplicate-request", "Duplicate Request", http.StatusConflict,
+		"An identical request was submitted moments ago", r.URL.Path).Respond(w, r)
+}
+
+// duplicateRecorder captures the status and body of a response as it is written
+type duplicateRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (dr *duplicateRecorder) WriteHeader(status int) {
+	dr.status = status
+	dr.ResponseWriter.WriteHeader(status)
+}
+
+func (dr *duplicateRecorder) Write(p []byte) (int, error) {
+	if dr.body.Len()+len(p) > dr.limit {
+		dr.overflow = true
+	} else if !dr.overflow {
+		dr.body.Write(p)
+	}
+	return dr.ResponseWriter.Write(p)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (dr *duplicateRecorder) Unwrap() http.ResponseWriter {
+	return dr.ResponseWriter
+}
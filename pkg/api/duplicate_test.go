@@ -0,0 +1,157 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDuplicateGuard(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+
+	tests := []struct {
+		name       string
+		options    []DuplicateOption
+		first      string
+		second     string
+		header     string
+		wantStatus int
+		wantCalls  int32
+	}{
+		{"duplicate rejected", nil, "a", "a", "", http.StatusConflict, 1},
+		{"different body", nil, "a", "b", "", http.StatusCreated, 2},
+		{"idempotency key skips guard", nil, "a", "a", "key-1", http.StatusCreated, 2},
+		{"replay", []DuplicateOption{WithDuplicateReplay()}, "a", "a", "", http.StatusCreated, 1},
+		{"window elapsed", []DuplicateOption{WithDuplicateWindow(time.Nanosecond)}, "a", "a", "", http.StatusCreated, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			handler := b.DuplicateGuard(NewDuplicateConfig(tt.options...))(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls.Add(1)
+					body, _ := io.ReadAll(r.Body)
+					w.Header().Set("Location", "/orders/"+string(body))
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{"id":"` + string(body) + `"}`))
+				}))
+
+			send := func(body string) *httptest.ResponseRecorder {
+				r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+				if tt.header != "" {
+					r.Header.Set("Idempotency-Key", tt.header)
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				return w
+			}
+
+			first := send(tt.first)
+			if first.Body.String() != `{"id":"`+tt.first+`"}` {
+				t.Errorf("Expected handler to see the full body, got %s", first.Body.String())
+			}
+
+			time.Sleep(time.Millisecond)
+			second := send(tt.second)
+			if second.Code != tt.wantStatus {
+				t.Errorf("Expected status %d for second request, got %d", tt.wantStatus, second.Code)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("Expected %d handler calls, got %d", tt.wantCalls, calls.Load())
+			}
+		})
+	}
+}
+
+func TestDuplicateGuardReplaysResponse(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	handler := b.DuplicateGuard(NewDuplicateConfig(WithDuplicateReplay()))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Location", "/orders/1")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"1"}`))
+		}))
+
+	var responses []*httptest.ResponseRecorder
+	for range 2 {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"qty":1}`)))
+		responses = append(responses, w)
+	}
+
+	replayed := responses[1]
+	if replayed.Body.String() != `{"id":"1"}` || replayed.Header().Get("Location") != "/orders/1" {
+		t.Errorf("Expected original response to be replayed, got %s", replayed.Body.String())
+	}
+	if replayed.Header().Get("X-Duplicate-Request") != "replayed" {
+		t.Error("Expected replayed responses to be marked")
+	}
+}
+
+func TestDuplicateGuardSkips(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+
+	var calls atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	handler := b.DuplicateGuard(NewDuplicateConfig(WithDuplicateMaxBodySize(4)))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(int(status.Load()))
+		}))
+
+	serve := func(method, body, user string) {
+		r := httptest.NewRequest(method, "/orders", strings.NewReader(body))
+		r.RemoteAddr = user + ":1234"
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	// Failed requests are released so they can be retried
+	serve(http.MethodPost, "a", "10.0.0.1")
+	serve(http.MethodPost, "a", "10.0.0.1")
+	// Different callers, oversized bodies, and unchecked methods always pass
+	status.Store(http.StatusOK)
+	serve(http.MethodPost, "b", "10.0.0.1")
+	serve(http.MethodPost, "b", "10.0.0.2")
+	serve(http.MethodPost, "too large", "10.0.0.1")
+	serve(http.MethodPost, "too large", "10.0.0.1")
+	serve(http.MethodPut, "c", "10.0.0.1")
+	serve(http.MethodPut, "c", "10.0.0.1")
+
+	if calls.Load() != 8 {
+		t.Errorf("Expected all 8 requests to reach the handler, got %d", calls.Load())
+	}
+}
+
+func TestDuplicateStoreExpires(t *testing.T) {
+	store := newDuplicateStore()
+
+	first, claimed := store.claim("a", time.Millisecond)
+	if !claimed {
+		t.Fatal("Expected a new key to be claimed")
+	}
+	if _, claimed := store.claim("b", time.Hour); !claimed {
+		t.Fatal("Expected a second key to be claimed")
+	}
+	if entry, claimed := store.claim("b", time.Hour); claimed || entry == nil {
+		t.Error("Expected a live key to be reported as a duplicate")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if entry, claimed := store.claim("a", time.Hour); !claimed || entry == first {
+		t.Error("Expected an expired key to be claimed again")
+	}
+	if store.order.Len() != 2 || len(store.entries) != 2 {
+		t.Errorf("Expected the expired entry to be dropped, got %d and %d", store.order.Len(), len(store.entries))
+	}
+
+	store.release("b", store.entries["b"].Value.(*duplicateEntry))
+	if store.order.Len() != 1 || len(store.entries) != 1 {
+		t.Errorf("Expected the released entry to be dropped, got %d and %d", store.order.Len(), len(store.entries))
+	}
+}
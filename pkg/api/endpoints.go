@@ -4,17 +4,21 @@ import (
 	"log"
 	"net/http"
 	"runtime"
+	"time"
 
 	"github.com/elastic/go-sysinfo"
 	"github.com/go-chi/chi/v5"
 	metrics "github.com/m8as/go-chi-metrics"
 
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Status struct {
 	Service      string `json:"service"`
 	Healthy      bool   `json:"healthy"`
+	HealthReason string `json:"healthReason,omitempty"`
 	Version      string `json:"version"`
 	BuildInfo    string `json:"buildInfo"`
 	Hostname     string `json:"hostname"`
@@ -24,11 +28,16 @@ type Status struct {
 	GoVersion    string `json:"goVersion"`
 	ClientAddr   string `json:"clientAddr"`
 	ServerHost   string `json:"serverHost"`
-	Uptime       string `json:"uptime"`
+	// Uptime is how long the service has been running, not the machine
+	Uptime string `json:"uptime"`
+	// VCS is the revision the binary was built from, when known
+	VCS   *VCSInfo     `json:"vcs,omitempty"`
+	Stats RuntimeStats `json:"stats"`
 }
 
 func (b *Base) AddOKEndpoint(r chi.Router, path string) {
 	log.Printf("### 🍏 API: 200 OK endpoint at: %s", "/"+path)
+	b.Routes().Add("/" + path)
 
 	r.Get("/"+path, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -38,20 +47,28 @@ func (b *Base) AddOKEndpoint(r chi.Router, path string) {
 
 func (b *Base) AddMetricsEndpoint(r chi.Router, path string) {
 	log.Printf("### 🔬 API: metrics endpoint at: %s", "/"+path)
+	b.Routes().Add("/" + path)
 
-	r.Use(metrics.SetRequestDuration)
-	r.Use(metrics.IncRequestCount)
+	r.Use(b.SkipInfrastructure(metrics.SetRequestDuration))
+	r.Use(b.SkipInfrastructure(metrics.IncRequestCount))
+	r.Use(b.SkipInfrastructure(b.countErrors))
 	r.Handle("/"+path, promhttp.Handler())
 }
 
 func (b *Base) AddHealthEndpoint(r chi.Router, path string) {
 	log.Printf("### 💚 API: health endpoint at: %s", "/"+path)
+	b.Routes().Add("/" + path)
 
 	r.HandleFunc("/"+path, func(w http.ResponseWriter, r *http.Request) {
-		if b.Healthy {
+		healthy, reason := b.healthState()
+		switch {
+		case healthy:
 			w.WriteHeader(http.StatusOK)
 			b.ReturnText(w, "OK: Service is healthy")
-		} else {
+		case reason != "":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			b.ReturnText(w, "Error: Service is not healthy: "+reason)
+		default:
 			w.WriteHeader(http.StatusServiceUnavailable)
 			b.ReturnText(w, "Error: Service is not healthy")
 		}
@@ -60,16 +77,19 @@ func (b *Base) AddHealthEndpoint(r chi.Router, path string) {
 
 func (b *Base) AddStatusEndpoint(r chi.Router, path string) {
 	log.Printf("### 🔮 API: status endpoint at: %s", "/"+path)
+	b.Routes().Add("/" + path)
 
 	r.HandleFunc("/"+path, func(w http.ResponseWriter, r *http.Request) {
 		host, _ := sysinfo.Host()
-		host.Info().Uptime()
+		build := embeddedBuild()
 
+		healthy, reason := b.healthState()
 		status := Status{
 			Service:      b.ServiceName,
-			Healthy:      b.Healthy,
+			Healthy:      healthy,
+			HealthReason: reason,
 			Version:      b.Version,
-			BuildInfo:    b.BuildInfo,
+			BuildInfo:    b.buildInfo(build.VCS),
 			Hostname:     host.Info().Hostname,
 			GoVersion:    runtime.Version(),
 			OS:           runtime.GOOS,
@@ -77,9 +97,29 @@ func (b *Base) AddStatusEndpoint(r chi.Router, path string) {
 			CPUCount:     runtime.NumCPU(),
 			ClientAddr:   r.RemoteAddr,
 			ServerHost:   r.Host,
-			Uptime:       host.Info().Uptime().String(),
+			Uptime:       b.Uptime().Round(time.Second).String(),
+			VCS:          build.VCS,
+			Stats:        b.RuntimeStats(),
 		}
 
 		b.ReturnJSON(w, status)
 	})
 }
+
+// AddErrorLookupEndpoint serves the redacted error context captured for a request ID at /path/{requestID}.
+// Pair it with problem.WithInstanceTemplate so the instance of each problem links here. The context
+// can include internal error details, so mount this behind authentication.
+func (b *Base) AddErrorLookupEndpoint(r chi.Router, path string, store problem.ContextStore) {
+	log.Printf("### 🔎 API: error lookup endpoint at: %s", "/"+path+"/{requestID}")
+
+	r.Get("/"+path+"/{requestID}", func(w http.ResponseWriter, r *http.Request) {
+		ec, ok := store.Get(chi.URLParam(r, "requestID"))
+		if !ok {
+			problem.New("error-not-found", "Error Not Found", http.StatusNotFound,
+				"No error context was captured for this request ID", r.URL.Path).Send(w)
+			return
+		}
+
+		b.ReturnJSON(w, ec)
+	})
+}
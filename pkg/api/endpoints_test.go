@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -178,3 +179,40 @@ func TestAddMetricsEndpoint(t *testing.T) {
 		t.Error("Expected metrics response to be substantial")
 	}
 }
+
+func TestAddErrorLookupEndpoint(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	store := problem.NewMemoryContextStore(10)
+	store.Save(problem.ErrorContext{RequestID: "req-1", Path: "/orders", Status: 500})
+
+	router := chi.NewRouter()
+	b.AddErrorLookupEndpoint(router, "errors", store)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/errors/req-1", http.StatusOK},
+		{"/errors/req-2", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("GET %s: expected status %d, got %d", tt.path, tt.wantStatus, w.Code)
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+
+		var ec problem.ErrorContext
+		if err := json.Unmarshal(w.Body.Bytes(), &ec); err != nil {
+			t.Fatalf("Failed to decode error context: %v", err)
+		}
+		if ec.Path != "/orders" || ec.Status != 500 {
+			t.Errorf("Unexpected error context: %+v", ec)
+		}
+	}
+}
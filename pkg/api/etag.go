@@ -0,0 +1,228 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// ComputeETag returns a quoted entity tag for a representation, prefixed with W/ when weak
+func ComputeETag(data []byte, weak bool) string {
+	sum := sha256.Sum256(data)
+	tag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// ETagConfig holds configuration for the ETag middleware
+type ETagConfig struct {
+	// Weak generates weak validators, for responses that are semantically but not byte-for-byte equal
+	Weak bool
+	// MaxBodySize is the largest response that is buffered and tagged; larger ones pass through untagged
+	MaxBodySize int
+}
+
+// DefaultETagConfig provides sensible defaults
+func DefaultETagConfig() *ETagConfig {
+	return &ETagConfig{
+		MaxBodySize: 1 << 20,
+	}
+}
+
+// ETagOption is a functional option for configuring the ETag middleware
+type ETagOption func(*ETagConfig)
+
+// WithWeakETags generates weak validators
+func WithWeakETags() ETagOption {
+	return func(config *ETagConfig) {
+		config.Weak = true
+	}
+}
+
+// WithETagMaxBodySize sets the largest response that is tagged
+func WithETagMaxBodySize(size int) ETagOption {
+	return func(config *ETagConfig) {
+		config.MaxBodySize = size
+	}
+}
+
+// NewETagConfig creates a new ETag config with options
+func NewETagConfig(options ...ETagOption) *ETagConfig {
+	config := DefaultETagConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// ETag creates middleware that tags successful GET and HEAD responses with an ETag computed from the
+// body, unless the handler set one, and answers a matching If-None-Match with 304 Not Modified
+func (b *Base) ETag(config *ETagConfig) func(next http.Handler) http.Handler {
+	if config == nil {
+		config = DefaultETagConfig()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ew := &etagWriter{ResponseWriter: w, maxSize: config.MaxBodySize}
+			next.ServeHTTP(ew, r)
+
+			if ew.passthrough {
+				return
+			}
+			if ew.status == 0 {
+				ew.status = http.StatusOK
+			}
+
+			body := ew.buf.Bytes()
+			if ew.status == http.StatusOK {
+				etag := w.Header().Get("ETag")
+				if etag == "" {
+					etag = ComputeETag(body, config.Weak)
+					w.Header().Set("ETag", etag)
+				}
+				if NoneMatch(r, etag) {
+					writeNotModified(w)
+					return
+				}
+			}
+
+			w.WriteHeader(ew.status)
+			_, _ = w.Write(body)
+		})
+	}
+}
+
+// etagWriter buffers a response so it can be tagged, switching to pass-through once it outgrows maxSize
+type etagWriter struct {
+	http.ResponseWriter
+	buf         bytes.Buffer
+	status      int
+	maxSize     int
+	passthrough bool
+}
+
+func (ew *etagWriter) WriteHeader(status int) {
+	if ew.status == 0 {
+		ew.status = status
+	}
+}
+
+func (ew *etagWriter) Write(p []byte) (int, error) {
+	if ew.status == 0 {
+		ew.status = http.StatusOK
+	}
+	if ew.passthrough {
+		return ew.ResponseWriter.Write(p)
+	}
+	if ew.buf.Len()+len(p) <= ew.maxSize {
+		return ew.buf.Write(p)
+	}
+
+	ew.passthrough = true
+	ew.ResponseWriter.WriteHeader(ew.status)
+	if _, err := ew.ResponseWriter.Write(ew.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return ew.ResponseWriter.Write(p)
+}
+
+// ReturnJSONWithETag sends data as JSON with a strong ETag, or 304 Not Modified when the request's
+// If-None-Match already has the current representation
+func (b *Base) ReturnJSONWithETag(w http.ResponseWriter, r *http.Request, data interface{}) {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		problem.Wrap(500, "json-encoding", "api-internals", err).Send(w)
+		return
+	}
+
+	etag := ComputeETag(dataBytes, false)
+	w.Header().Set("ETag", etag)
+	if NoneMatch(r, etag) {
+		writeNotModified(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if page, ok := data.(linker); ok && page.LinkHeader() != "" {
+		w.Header().Set("Link", page.LinkHeader())
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(dataBytes)))
+	_, _ = w.Write(dataBytes)
+}
+
+// CheckIfMatch guards a write with optimistic concurrency. When the request has an If-Match header
+// that doesn't match the resource's current ETag, it responds 412 Precondition Failed and returns false.
+// Requests without If-Match are allowed; use RequireIfMatch to insist on one.
+func (b *Base) CheckIfMatch(w http.ResponseWriter, r *http.Request, current string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" || etagListMatches(header, current, true) {
+		return true
+	}
+
+	problem.New("precondition-failed", "Precondition Failed", http.StatusPreconditionFailed,
+		"The resource has been modified since it was retrieved", r.URL.Path).Respond(w, r)
+	return false
+}
+
+// RequireIfMatch is CheckIfMatch, but responds 428 Precondition Required when If-Match is missing
+func (b *Base) RequireIfMatch(w http.ResponseWriter, r *http.Request, current string) bool {
+	if r.Header.Get("If-Match") == "" {
+		problem.New("precondition-required", "Precondition Required", http.StatusPreconditionRequired,
+			"This request must be conditional, send If-Match with the resource's ETag", r.URL.Path).Respond(w, r)
+		return false
+	}
+	return b.CheckIfMatch(w, r, current)
+}
+
+// NoneMatch reports whether the request's If-None-Match header matches etag, meaning the client's
+// cached copy is current. It uses weak comparison, as RFC 9110 requires for If-None-Match.
+func NoneMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	return header != "" && etagListMatches(header, etag, false)
+}
+
+// etagListMatches compares a comma-separated If-Match or If-None-Match list against the current tag
+func etagListMatches(header, current string, strong bool) bool {
+	if current == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong {
+			if candidate == current && !strings.HasPrefix(candidate, "W/") {
+				return true
+			}
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(current, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNotModified sends 304 without the content headers that only apply to a body
+func writeNotModified(w http.ResponseWriter) {
+	for _, name := range []string{"Content-Type", "Content-Length", "Content-Encoding"} {
+		w.Header().Del(name)
+	}
+	w.WriteHeader(http.StatusNotModified)
+}
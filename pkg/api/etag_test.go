@@ -0,0 +1,159 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestComputeETag(t *testing.T) {
+	strong := ComputeETag([]byte("hello"), false)
+	weak := ComputeETag([]byte("hello"), true)
+
+	if !strings.HasPrefix(strong, `"`) || !strings.HasSuffix(strong, `"`) {
+		t.Errorf("Expected quoted tag, got %s", strong)
+	}
+	if weak != "W/"+strong {
+		t.Errorf("Expected weak tag W/%s, got %s", strong, weak)
+	}
+	if ComputeETag([]byte("other"), false) == strong {
+		t.Error("Expected different content to produce different tags")
+	}
+}
+
+func TestEtagListMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		current string
+		strong  bool
+		want    bool
+	}{
+		{"exact", `"abc"`, `"abc"`, true, true},
+		{"list", `"x", "abc"`, `"abc"`, true, true},
+		{"mismatch", `"x"`, `"abc"`, true, false},
+		{"wildcard", "*", `"abc"`, true, true},
+		{"wildcard without resource", "*", "", true, false},
+		{"weak never matches strongly", `W/"abc"`, `"abc"`, true, false},
+		{"weak matches weakly", `W/"abc"`, `"abc"`, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagListMatches(tt.header, tt.current, tt.strong); got != tt.want {
+				t.Errorf("etagListMatches(%q, %q, %v) = %v, want %v", tt.header, tt.current, tt.strong, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestETagMiddleware(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	handler := b.ETag(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("hello"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/greeting", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != "hello" || etag == "" {
+		t.Fatalf("Expected tagged 200 response, got %d %q etag %q", w.Code, w.Body.String(), etag)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/greeting", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected empty 304, got %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("ETag") != etag {
+		t.Error("Expected 304 to repeat the ETag")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/greeting", nil))
+	if w.Header().Get("ETag") != "" {
+		t.Error("Expected writes not to be tagged")
+	}
+}
+
+func TestETagMiddlewareLargeResponse(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	body := strings.Repeat("x", 100)
+	handler := b.ETag(NewETagConfig(WithETagMaxBodySize(10)))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for i := 0; i < len(body); i += 25 {
+				_, _ = w.Write([]byte(body[i : i+25]))
+			}
+		}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/large", nil))
+
+	if w.Body.String() != body || w.Header().Get("ETag") != "" {
+		t.Errorf("Expected large response to pass through untagged, got %d bytes etag %q",
+			w.Body.Len(), w.Header().Get("ETag"))
+	}
+}
+
+func TestReturnJSONWithETag(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	data := map[string]string{"name": "widget"}
+
+	w := httptest.NewRecorder()
+	b.ReturnJSONWithETag(w, httptest.NewRequest(http.MethodGet, "/items/1", nil), data)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || strings.HasPrefix(etag, "W/") {
+		t.Fatalf("Expected 200 with strong ETag, got %d %q", w.Code, etag)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	r.Header.Set("If-None-Match", "W/"+etag)
+	w = httptest.NewRecorder()
+	b.ReturnJSONWithETag(w, r, data)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for weakly matching If-None-Match, got %d", w.Code)
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	current := ComputeETag([]byte("v1"), false)
+
+	tests := []struct {
+		name       string
+		ifMatch    string
+		require    bool
+		wantOK     bool
+		wantStatus int
+	}{
+		{"no header allowed", "", false, true, http.StatusOK},
+		{"no header required", "", true, false, http.StatusPreconditionRequired},
+		{"current", current, true, true, http.StatusOK},
+		{"stale", ComputeETag([]byte("v0"), false), false, false, http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/items/1", nil)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			check := b.CheckIfMatch
+			if tt.require {
+				check = b.RequireIfMatch
+			}
+			if ok := check(w, r, current); ok != tt.wantOK {
+				t.Errorf("Expected %v, got %v", tt.wantOK, ok)
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"github.com/Okja-Engineering/go-service-kit/pkg/validate"
+	"github.com/go-chi/chi/v5"
+)
+
+// Empty is a request or response without content. A handler returning Empty answers 204 No Content.
+type Empty struct{}
+
+// PathError aggregates every path parameter that failed to bind
+type PathError struct {
+	Errors []problem.FieldError
+}
+
+func (e *PathError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		messages = append(messages, fe.Field+": "+fe.Message)
+	}
+	return "invalid path parameters: " + strings.Join(messages, "; ")
+}
+
+// Problem converts the error into a 400 problem+json response listing each invalid parameter
+func (e *PathError) Problem(instance string) *problem.Problem {
+	p := problem.New("invalid-path", "Invalid path parameters", http.StatusBadRequest,
+		fmt.Sprintf("%d path parameter(s) are invalid", len(e.Errors)), instance)
+	p.Errors = e.Errors
+	return p
+}
+
+// HandleConfig holds configuration for typed handlers
+type HandleConfig struct {
+	// Status is sent with successful responses; handlers returning Empty always send 204
+	Status int
+	// Mapper turns errors into problems, overriding the Base's
+	Mapper *problem.Mapper
+	// Base is the service whose error mapper, set with SetErrorMapper, is used when Mapper is nil
+	Base *Base
+	// DecodeOptions configure decoding of the JSON body
+	DecodeOptions []validate.DecodeOption
+}
+
+// DefaultHandleConfig provides sensible defaults
+func DefaultHandleConfig() *HandleConfig {
+	return &HandleConfig{
+		Status: http.StatusOK,
+	}
+}
+
+// HandleOption is a functional option for configuring typed handlers
+type HandleOption func(*HandleConfig)
+
+// WithStatus sets the status of successful responses, such as 201 for creation
+func WithStatus(status int) HandleOption {
+	return func(config *HandleConfig) {
+		config.Status = status
+	}
+}
+
+// WithErrorMapper sets the mapper that turns errors into problems
+func WithErrorMapper(mapper *problem.Mapper) HandleOption {
+	return func(config *HandleConfig) {
+		config.Mapper = mapper
+	}
+}
+
+// WithBase maps errors with the Base's error mapper, so typed handlers answer errors as HandleError does
+func WithBase(b *Base) HandleOption {
+	return func(config *HandleConfig) {
+		config.Base = b
+	}
+}
+
+// WithDecodeOptions sets options for decoding the JSON body
+func WithDecodeOptions(options ...validate.DecodeOption) HandleOption {
+	return func(config *HandleConfig) {
+		config.DecodeOptions = options
+	}
+}
+
+// NewHandleConfig creates a new typed handler config with options
+func NewHandleConfig(options ...HandleOption) *HandleConfig {
+	config := DefaultHandleConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// TypedHandler is implemented by handlers created with Handle, so tools such as API document
+// generators can find the request and response types of a route
+type TypedHandler interface {
+	http.Handler
+	RequestType() reflect.Type
+	ResponseType() reflect.Type
+}
+
+// typedHandler adapts a typed function to http.Handler
+type typedHandler[Req, Resp any] struct {
+	fn     func(ctx context.Context, req Req) (Resp, error)
+	config *HandleConfig
+}
+
+// Handle adapts fn to an http.Handler. The request struct is bound from the JSON body when there is
+// one, then path parameters (fields tagged path:"name") and query parameters (see ParseQuery), which
+// take precedence over the body, then validated. Errors from binding or fn are sent as problems
+// through the error mapper, and the response is encoded as JSON. Req must be a struct; Handle panics
+// otherwise.
+//
+//	r.Method(http.MethodPost, "/orders/{id}/items", api.Handle(addItem, api.WithStatus(http.StatusCreated)))
+func Handle[Req, Resp any](fn func(ctx context.Context, req Req) (Resp, error),
+	options ...HandleOption) TypedHandler {
+	if reflect.TypeFor[Req]().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("api.Handle: request type must be a struct, got %s", reflect.TypeFor[Req]()))
+	}
+	config := NewHandleConfig(options...)
+	if config.Mapper == nil && config.Base == nil {
+		config.Mapper = problem.DefaultMapper()
+	}
+	return &typedHandler[Req, Resp]{fn: fn, config: config}
+}
+
+func (h *typedHandler[Req, Resp]) RequestType() reflect.Type {
+	return reflect.TypeFor[Req]()
+}
+
+func (h *typedHandler[Req, Resp]) ResponseType() reflect.Type {
+	return reflect.TypeFor[Resp]()
+}
+
+func (h *typedHandler[Req, Resp]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Req
+	if err := bindRequest(r, &req, h.config.DecodeOptions); err != nil {
+		h.respondError(w, r, err)
+		return
+	}
+
+	resp, err := h.fn(r.Context(), req)
+	if err != nil {
+		h.respondError(w, r, err)
+		return
+	}
+
+	if _, empty := any(resp).(Empty); empty {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, h.config.Status, resp)
+}
+
+// respondError sends err through the mapper, logging server errors as HandleError does
+func (h *typedHandler[Req, Resp]) respondError(w http.ResponseWriter, r *http.Request, err error) {
+	p := h.mapper().Map(err, r.URL.Path)
+	if p.Status >= http.StatusInternalServerError {
+		log.Printf("### 💥 API: %s %s failed: %v", r.Method, r.URL.Path, err)
+	}
+	p.Respond(w, r)
+}
+
+// mapper returns the handler's mapper, or the Base's when none is set
+func (h *typedHandler[Req, Resp]) mapper() *problem.Mapper {
+	if h.config.Mapper != nil {
+		return h.config.Mapper
+	}
+	h.config.Base.initOnce.Do(h.config.Base.init)
+	return h.config.Base.mapper
+}
+
+// bindRequest fills dst from the JSON body, the path, and the query string, then validates it. The path and
+// query are bound last, so a body can't override the resource the URL names.
+func bindRequest(r *http.Request, dst interface{}, options []validate.DecodeOption) error {
+	if hasBody(r) {
+		options = append(slices.Clip(options), validate.WithoutValidation())
+		if err := decodeJSON(r, dst, options...); err != nil {
+			return err
+		}
+	}
+
+	if err := ParsePath(r, dst); err != nil {
+		return err
+	}
+	if err := ParseQuery(r, dst); err != nil {
+		return err
+	}
+	return validate.Struct(dst)
+}
+
+// hasBody reports whether the request carries a body to decode
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
+
+// ParsePath binds chi URL parameters onto the struct pointed to by dst, using path:"name" field tags.
+// Fields take the types ParseQuery supports, except slices. All failures are collected into a *PathError.
+func ParsePath(r *http.Request, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ParsePath requires a pointer to a struct, got %T", dst)
+	}
+
+	routeCtx := chi.RouteContext(r.Context())
+	elem := rv.Elem()
+	pathErr := &PathError{}
+
+	for i := 0; i < elem.NumField(); i++ {
+		field := elem.Type().Field(i)
+		name := field.Tag.Get("path")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		var value string
+		if routeCtx != nil {
+			value = routeCtx.URLParam(name)
+		}
+		if value == "" {
+			pathErr.Errors = append(pathErr.Errors, problem.FieldError{Field: name, Code: "required", Message: "is required"})
+			continue
+		}
+		if err := setQueryValue(elem.Field(i), field, value); err != nil {
+			pathErr.Errors = append(pathErr.Errors, problem.FieldError{
+				Field: name, Code: problem.CodeInvalid, Message: err.Error(),
+			})
+		}
+	}
+
+	if len(pathErr.Errors) > 0 {
+		return pathErr
+	}
+
+	return nil
+}
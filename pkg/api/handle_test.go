@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"github.com/go-chi/chi/v5"
+)
+
+type addItemRequest struct {
+	OrderID int64  `path:"id" json:"-"`
+	DryRun  bool   `query:"dryRun" json:"-"`
+	SKU     string `json:"sku" validate:"required"`
+	Qty     int    `json:"qty" validate:"min=1"`
+}
+
+type addItemResponse struct {
+	OrderID int64  `json:"orderId"`
+	SKU     string `json:"sku"`
+	Qty     int    `json:"qty"`
+	DryRun  bool   `json:"dryRun"`
+}
+
+var errOrderClosed = errors.New("order is closed")
+
+func addItem(_ context.Context, req addItemRequest) (addItemResponse, error) {
+	if req.OrderID == 13 {
+		return addItemResponse{}, errOrderClosed
+	}
+	if req.OrderID == 99 {
+		return addItemResponse{}, errors.New("database unavailable")
+	}
+	return addItemResponse{OrderID: req.OrderID, SKU: req.SKU, Qty: req.Qty, DryRun: req.DryRun}, nil
+}
+
+func newHandleRouter() chi.Router {
+	mapper := problem.DefaultMapper()
+	mapper.Register(errOrderClosed, problem.Template{Type: "order-closed", Title: "Order Closed",
+		Status: http.StatusConflict})
+
+	r := chi.NewRouter()
+	r.Method(http.MethodPost, "/orders/{id}/items", Handle(addItem, WithStatus(http.StatusCreated),
+		WithErrorMapper(mapper)))
+	r.Method(http.MethodDelete, "/orders/{id}", Handle(func(_ context.Context, req struct {
+		ID string `path:"id"`
+	}) (Empty, error) {
+		return Empty{}, nil
+	}))
+	return r
+}
+
+func TestHandle(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"created", http.MethodPost, "/orders/42/items?dryRun=true", `{"sku":"A1","qty":2}`, http.StatusCreated,
+			`{"orderId":42,"sku":"A1","qty":2,"dryRun":true}`},
+		{"invalid path", http.MethodPost, "/orders/abc/items", `{"sku":"A1","qty":2}`, http.StatusBadRequest,
+			`"type":"invalid-path"`},
+		{"invalid query", http.MethodPost, "/orders/42/items?dryRun=maybe", `{"sku":"A1","qty":2}`,
+			http.StatusBadRequest, `"type":"invalid-query"`},
+		{"malformed body", http.MethodPost, "/orders/42/items", `{"sku":`, http.StatusBadRequest,
+			`"type":"invalid-body"`},
+		{"invalid body", http.MethodPost, "/orders/42/items", `{"qty":0}`, http.StatusUnprocessableEntity,
+			`"field":"sku"`},
+		{"missing body", http.MethodPost, "/orders/42/items", "", http.StatusUnprocessableEntity, `"field":"sku"`},
+		{"mapped error", http.MethodPost, "/orders/13/items", `{"sku":"A1","qty":1}`, http.StatusConflict,
+			`"type":"order-closed"`},
+		{"unmapped error", http.MethodPost, "/orders/99/items", `{"sku":"A1","qty":1}`,
+			http.StatusInternalServerError, `"status":500`},
+		{"empty response", http.MethodDelete, "/orders/42", "", http.StatusNoContent, ""},
+	}
+
+	router := newHandleRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("Expected body to contain %s, got %s", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandlePathOverridesBody(t *testing.T) {
+	type updateOrder struct {
+		ID   int64  `path:"id" json:"id" validate:"min=1"`
+		Note string `json:"note"`
+	}
+	handler := Handle(func(_ context.Context, req updateOrder) (updateOrder, error) { return req, nil })
+	r := chi.NewRouter()
+	r.Method(http.MethodPut, "/orders/{id}", handler)
+
+	req := httptest.NewRequest(http.MethodPut, "/orders/42", strings.NewReader(`{"id":7,"note":"rush"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"id":42,"note":"rush"}` {
+		t.Errorf("Expected the path to name the order, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleWithBase(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	handler := Handle(addItem, WithBase(b))
+	r := chi.NewRouter()
+	r.Method(http.MethodPost, "/orders/{id}/items", handler)
+
+	// The Base's mapper is read per request, so a mapper set after Handle applies
+	mapper := problem.DefaultMapper()
+	mapper.Register(errOrderClosed, problem.Template{Type: "order-closed", Title: "Order Closed",
+		Status: http.StatusConflict})
+	b.SetErrorMapper(mapper)
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/13/items", strings.NewReader(`{"sku":"A1","qty":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected the Base's mapper to map the error, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleRejectsNonJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/orders/42/items", strings.NewReader(`sku=A1`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	newHandleRouter().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected 415, got %d", rec.Code)
+	}
+}
+
+func TestHandleTypes(t *testing.T) {
+	h := Handle(addItem)
+	if h.RequestType() != reflect.TypeOf(addItemRequest{}) || h.ResponseType() != reflect.TypeOf(addItemResponse{}) {
+		t.Errorf("Unexpected types: %s, %s", h.RequestType(), h.ResponseType())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Handle to panic for a non-struct request type")
+		}
+	}()
+	Handle(func(context.Context, []string) (Empty, error) { return Empty{}, nil })
+}
+
+func TestParsePath(t *testing.T) {
+	var dst struct {
+		ID   int64  `path:"id"`
+		Slug string `path:"slug"`
+	}
+
+	r := chi.NewRouter()
+	var err error
+	r.Get("/{id}/{slug}", func(w http.ResponseWriter, r *http.Request) { err = ParsePath(r, &dst) })
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/7/hello", nil))
+	if err != nil || dst.ID != 7 || dst.Slug != "hello" {
+		t.Errorf("Expected id 7 and slug hello, got %+v and %v", dst, err)
+	}
+
+	err = ParsePath(httptest.NewRequest(http.MethodGet, "/", nil), &dst)
+	var pathErr *PathError
+	if !errors.As(err, &pathErr) || len(pathErr.Errors) != 2 {
+		t.Fatalf("Expected both parameters to be reported missing, got %v", err)
+	}
+	body, _ := json.Marshal(pathErr.Problem("/"))
+	if !strings.Contains(string(body), `"type":"invalid-path"`) {
+		t.Errorf("Unexpected problem: %s", body)
+	}
+
+	if err := ParsePath(httptest.NewRequest(http.MethodGet, "/", nil), dst); err == nil {
+		t.Error("Expected an error for a non-pointer destination")
+	}
+}
@@ -0,0 +1,397 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CheckFunc checks a single dependency, returning an error when it is unavailable
+type CheckFunc func(ctx context.Context) error
+
+// CheckConfig holds configuration for a dependency health check
+type CheckConfig struct {
+	// Interval between background checks
+	Interval time.Duration
+	// Timeout applied to each check
+	Timeout time.Duration
+	// FailureThreshold is the number of consecutive failures before reporting unhealthy
+	FailureThreshold int
+	// SuccessThreshold is the number of consecutive successes before recovering
+	SuccessThreshold int
+	// Critical checks gate readiness; informational checks are only reported
+	Critical bool
+	// CacheTTL is how long a result is reused when a probe asks for fresh results, so probes
+	// arriving together cost one call to the dependency
+	CacheTTL time.Duration
+	// MarkUnhealthy makes the whole service unhealthy while this critical check fails, so the
+	// health endpoint fails too and the orchestrator restarts the service, not just stops routing to it
+	MarkUnhealthy bool
+}
+
+// DefaultCheckConfig provides sensible defaults
+func DefaultCheckConfig() *CheckConfig {
+	return &CheckConfig{
+		Interval:         10 * time.Second,
+		Timeout:          2 * time.Second,
+		FailureThreshold: 3,
+		SuccessThreshold: 2,
+		Critical:         true,
+		CacheTTL:         5 * time.Second,
+	}
+}
+
+// CheckOption is a functional option for configuring a health check
+type CheckOption func(*CheckConfig)
+
+// WithCheckInterval sets the interval between background checks
+func WithCheckInterval(interval time.Duration) CheckOption {
+	return func(config *CheckConfig) {
+		config.Interval = interval
+	}
+}
+
+// WithCheckTimeout sets the timeout applied to each check
+func WithCheckTimeout(timeout time.Duration) CheckOption {
+	return func(config *CheckConfig) {
+		config.Timeout = timeout
+	}
+}
+
+// WithFailureThreshold sets how many consecutive failures mark a dependency unhealthy
+func WithFailureThreshold(threshold int) CheckOption {
+	return func(config *CheckConfig) {
+		config.FailureThreshold = threshold
+	}
+}
+
+// WithSuccessThreshold sets how many consecutive successes mark a dependency healthy again
+func WithSuccessThreshold(threshold int) CheckOption {
+	return func(config *CheckConfig) {
+		config.SuccessThreshold = threshold
+	}
+}
+
+// WithCritical sets whether a failing check makes the service not ready
+func WithCritical(critical bool) CheckOption {
+	return func(config *CheckConfig) {
+		config.Critical = critical
+	}
+}
+
+// WithCacheTTL sets how long a result is reused before a probe triggers a new check
+func WithCacheTTL(ttl time.Duration) CheckOption {
+	return func(config *CheckConfig) {
+		config.CacheTTL = ttl
+	}
+}
+
+// WithMarkUnhealthy sets whether a failing critical check makes the whole service unhealthy
+func WithMarkUnhealthy(markUnhealthy bool) CheckOption {
+	return func(config *CheckConfig) {
+		config.MarkUnhealthy = markUnhealthy
+	}
+}
+
+// NewCheckConfig creates a new check config with options
+func NewCheckConfig(options ...CheckOption) *CheckConfig {
+	config := DefaultCheckConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// CheckStatus is the debounced state of a dependency health check
+type CheckStatus struct {
+	Name                 string    `json:"name"`
+	Healthy              bool      `json:"healthy"`
+	Critical             bool      `json:"critical"`
+	LatencyMs            float64   `json:"latencyMs"`
+	ConsecutiveFailures  int       `json:"consecutiveFailures"`
+	ConsecutiveSuccesses int       `json:"consecutiveSuccesses"`
+	LastError            string    `json:"lastError,omitempty"`
+	LastChecked          time.Time `json:"lastChecked,omitempty"`
+}
+
+// healthCheck is a registered dependency check with hysteresis state
+type healthCheck struct {
+	fn     CheckFunc
+	config *CheckConfig
+	// onResult is called after each result is recorded
+	onResult func()
+
+	mu       sync.RWMutex
+	status   CheckStatus
+	observed bool
+
+	// refreshMu lets concurrent refreshes share a single run
+	refreshMu sync.Mutex
+}
+
+// run executes the check once with its timeout and records the result
+func (c *healthCheck) run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.fn(ctx)
+	c.record(err, start, time.Since(start))
+	if c.onResult != nil {
+		c.onResult()
+	}
+}
+
+// refresh runs the check unless its last result is within the cache TTL. Callers arriving while a
+// run is in flight wait for it and reuse its result rather than starting their own.
+func (c *healthCheck) refresh(ctx context.Context) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.mu.RLock()
+	fresh := c.observed && time.Since(c.status.LastChecked) < c.config.CacheTTL
+	c.mu.RUnlock()
+
+	if !fresh {
+		c.run(ctx)
+	}
+}
+
+// record applies a check result to the hysteresis state machine.
+// The first result is taken as-is; afterwards the state only flips once the
+// configured number of consecutive failures or successes has been observed.
+func (c *healthCheck) record(err error, at time.Time, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.status.LastChecked = at
+	c.status.LatencyMs = float64(latency.Microseconds()) / 1000
+	wasHealthy := c.status.Healthy
+
+	if err != nil {
+		c.status.ConsecutiveFailures++
+		c.status.ConsecutiveSuccesses = 0
+		c.status.LastError = err.Error()
+		if !c.observed || c.status.ConsecutiveFailures >= c.config.FailureThreshold {
+			c.status.Healthy = false
+		}
+	} else {
+		c.status.ConsecutiveSuccesses++
+		c.status.ConsecutiveFailures = 0
+		c.status.LastError = ""
+		if !c.observed || c.status.ConsecutiveSuccesses >= c.config.SuccessThreshold {
+			c.status.Healthy = true
+		}
+	}
+
+	if c.observed && wasHealthy != c.status.Healthy {
+		log.Printf("### 💚 API: dependency %s healthy=%t (%s)", c.status.Name, c.status.Healthy, c.status.LastError)
+	}
+	c.observed = true
+}
+
+// snapshot returns a copy of the current status
+func (c *healthCheck) snapshot() CheckStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.status
+}
+
+// healthRegistry holds the dependency checks registered on a Base
+type healthRegistry struct {
+	mu     sync.RWMutex
+	checks map[string]*healthCheck
+}
+
+// AddHealthCheck registers a dependency check. Until its first result the check reports unhealthy.
+func (b *Base) AddHealthCheck(name string, fn CheckFunc, options ...CheckOption) {
+	b.initOnce.Do(b.init)
+
+	b.health.mu.Lock()
+	defer b.health.mu.Unlock()
+
+	config := NewCheckConfig(options...)
+	check := &healthCheck{
+		fn:     fn,
+		config: config,
+		status: CheckStatus{Name: name, Critical: config.Critical},
+	}
+	if config.Critical && config.MarkUnhealthy {
+		check.onResult = b.syncCheckHealth
+	}
+	b.health.checks[name] = check
+}
+
+// StartHealthChecks runs every registered check immediately and then on its interval until ctx is done
+func (b *Base) StartHealthChecks(ctx context.Context) {
+	b.initOnce.Do(b.init)
+
+	b.health.mu.RLock()
+	defer b.health.mu.RUnlock()
+
+	for _, check := range b.health.checks {
+		go func(c *healthCheck) {
+			c.run(ctx)
+
+			ticker := time.NewTicker(c.config.Interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					c.run(ctx)
+				}
+			}
+		}(check)
+	}
+}
+
+// HealthChecks returns the current status of every registered check, sorted by name
+func (b *Base) HealthChecks() []CheckStatus {
+	b.initOnce.Do(b.init)
+
+	b.health.mu.RLock()
+	defer b.health.mu.RUnlock()
+
+	statuses := make([]CheckStatus, 0, len(b.health.checks))
+	for _, check := range b.health.checks {
+		statuses = append(statuses, check.snapshot())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	return statuses
+}
+
+// Ready reports whether the service is healthy, has started, is not shutting down, and every critical
+// dependency check is passing
+func (b *Base) Ready() bool {
+	if !b.IsHealthy() || !b.Started() || b.Draining() {
+		return false
+	}
+
+	for _, status := range b.HealthChecks() {
+		if status.Critical && !status.Healthy {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Overall health states reported by HealthDetails
+const (
+	HealthPass = "pass"
+	// HealthWarn means only informational checks are failing; the service is still ready
+	HealthWarn = "warn"
+	HealthFail = "fail"
+)
+
+// HealthDetails is the detailed health of the service and each of its dependencies
+type HealthDetails struct {
+	Status  string `json:"status"`
+	Service string `json:"service"`
+	Version string `json:"version"`
+	// Reason explains why the service is unhealthy, if it is
+	Reason string        `json:"reason,omitempty"`
+	Checks []CheckStatus `json:"checks"`
+}
+
+// RefreshHealthChecks runs, in parallel, every check whose last result is older than its cache TTL
+func (b *Base) RefreshHealthChecks(ctx context.Context) {
+	b.initOnce.Do(b.init)
+
+	b.health.mu.RLock()
+	checks := make([]*healthCheck, 0, len(b.health.checks))
+	for _, check := range b.health.checks {
+		checks = append(checks, check)
+	}
+	b.health.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(c *healthCheck) {
+			defer wg.Done()
+			c.refresh(ctx)
+		}(check)
+	}
+	wg.Wait()
+}
+
+// HealthDetails refreshes stale checks and reports the overall status with every check's result
+func (b *Base) HealthDetails(ctx context.Context) HealthDetails {
+	b.RefreshHealthChecks(ctx)
+
+	details := HealthDetails{
+		Status:  HealthPass,
+		Service: b.ServiceName,
+		Version: b.Version,
+		Reason:  b.UnhealthyReason(),
+		Checks:  b.HealthChecks(),
+	}
+	if details.Reason == "" && b.Draining() {
+		details.Reason = "shutting down"
+	}
+	if !b.Ready() {
+		details.Status = HealthFail
+		return details
+	}
+	for _, status := range details.Checks {
+		if !status.Healthy {
+			details.Status = HealthWarn
+		}
+	}
+
+	return details
+}
+
+// AddReadinessEndpoint adds an endpoint returning 200 when Ready, or 503 otherwise,
+// with the status of every dependency check in the body
+func (b *Base) AddReadinessEndpoint(r chi.Router, path string) {
+	log.Printf("### 🚦 API: readiness endpoint at: %s", "/"+path)
+	b.Routes().Add("/" + path)
+
+	r.HandleFunc("/"+path, func(w http.ResponseWriter, r *http.Request) {
+		ready := b.Ready()
+		body := map[string]interface{}{
+			"ready":  ready,
+			"checks": b.HealthChecks(),
+		}
+		if reason := b.UnhealthyReason(); reason != "" {
+			body["reason"] = reason
+		} else if b.Draining() {
+			body["reason"] = "shutting down"
+		}
+
+		if !ready {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		b.ReturnJSON(w, body)
+	})
+}
+
+// AddHealthDetailsEndpoint adds an endpoint reporting HealthDetails, with the latency and last error
+// of each dependency. It returns 503 when a critical check fails. Stale results are refreshed on
+// request, so the endpoint also works without StartHealthChecks.
+func (b *Base) AddHealthDetailsEndpoint(r chi.Router, path string) {
+	log.Printf("### 🩺 API: health details endpoint at: %s", "/"+path)
+	b.Routes().Add("/" + path)
+
+	r.Get("/"+path, func(w http.ResponseWriter, r *http.Request) {
+		details := b.HealthDetails(r.Context())
+
+		status := http.StatusOK
+		if details.Status == HealthFail {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, details)
+	})
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNewCheckConfig(t *testing.T) {
+	config := NewCheckConfig(
+		WithCheckInterval(time.Second),
+		WithCheckTimeout(500*time.Millisecond),
+		WithFailureThreshold(5),
+		WithSuccessThreshold(3),
+	)
+
+	if config.Interval != time.Second {
+		t.Errorf("Expected interval 1s, got %v", config.Interval)
+	}
+	if config.Timeout != 500*time.Millisecond {
+		t.Errorf("Expected timeout 500ms, got %v", config.Timeout)
+	}
+	if config.FailureThreshold != 5 {
+		t.Errorf("Expected failure threshold 5, got %d", config.FailureThreshold)
+	}
+	if config.SuccessThreshold != 3 {
+		t.Errorf("Expected success threshold 3, got %d", config.SuccessThreshold)
+	}
+}
+
+func TestHealthCheckHysteresis(t *testing.T) {
+	check := &healthCheck{
+		config: NewCheckConfig(WithFailureThreshold(3), WithSuccessThreshold(2)),
+		status: CheckStatus{Name: "db"},
+	}
+	failure := errors.New("connection refused")
+	now := time.Now()
+
+	steps := []struct {
+		err     error
+		healthy bool
+	}{
+		{nil, true},      // first observation is taken as-is
+		{failure, true},  // 1 failure: still healthy
+		{failure, true},  // 2 failures: still healthy
+		{nil, true},      // success resets the failure count
+		{failure, true},  // 1 failure
+		{failure, true},  // 2 failures
+		{failure, false}, // 3 failures: unhealthy
+		{nil, false},     // 1 success: still unhealthy
+		{nil, true},      // 2 successes: recovered
+	}
+
+	for i, step := range steps {
+		check.record(step.err, now, time.Millisecond)
+		if status := check.snapshot(); status.Healthy != step.healthy {
+			t.Fatalf("Step %d: expected healthy=%t, got %t", i, step.healthy, status.Healthy)
+		}
+	}
+}
+
+func TestHealthCheckFirstFailure(t *testing.T) {
+	check := &healthCheck{config: DefaultCheckConfig(), status: CheckStatus{Name: "db"}}
+
+	check.record(errors.New("down"), time.Now(), time.Millisecond)
+
+	status := check.snapshot()
+	if status.Healthy {
+		t.Error("Expected first failure to mark the check unhealthy")
+	}
+	if status.LastError != "down" {
+		t.Errorf("Expected last error 'down', got '%s'", status.LastError)
+	}
+}
+
+func TestReadinessEndpoint(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	router := chi.NewRouter()
+	base.AddReadinessEndpoint(router, "readyz")
+
+	fail := true
+	base.AddHealthCheck("db", func(ctx context.Context) error {
+		if fail {
+			return errors.New("down")
+		}
+		return nil
+	}, WithCheckInterval(time.Hour))
+
+	// Pending checks are not ready
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 before first check, got %d", w.Code)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fail = false
+	base.StartHealthChecks(ctx)
+	deadline := time.Now().Add(time.Second)
+	for !base.Ready() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	var body struct {
+		Ready  bool          `json:"ready"`
+		Checks []CheckStatus `json:"checks"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !body.Ready || len(body.Checks) != 1 || body.Checks[0].Name != "db" {
+		t.Errorf("Unexpected readiness body: %+v", body)
+	}
+}
+
+func TestInformationalCheck(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	base.AddHealthCheck("db", func(ctx context.Context) error { return nil })
+	base.AddHealthCheck("search", func(ctx context.Context) error { return errors.New("down") },
+		WithCritical(false))
+
+	details := base.HealthDetails(context.Background())
+	if !base.Ready() || details.Status != HealthWarn {
+		t.Errorf("Expected a failing informational check to warn without affecting readiness, got %+v", details)
+	}
+
+	base.AddHealthCheck("cache", func(ctx context.Context) error { return errors.New("down") })
+	if details = base.HealthDetails(context.Background()); details.Status != HealthFail || base.Ready() {
+		t.Errorf("Expected a failing critical check to fail, got %+v", details)
+	}
+}
+
+func TestHealthCheckCache(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	var calls atomic.Int32
+	base.AddHealthCheck("db", func(ctx context.Context) error {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}, WithCacheTTL(time.Hour))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			base.RefreshHealthChecks(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Expected concurrent refreshes to share one run, got %d runs", got)
+	}
+	if status := base.HealthChecks()[0]; status.LatencyMs < 10 {
+		t.Errorf("Expected the latency to be recorded, got %vms", status.LatencyMs)
+	}
+}
+
+func TestHealthDetailsEndpoint(t *testing.T) {
+	base := NewBase("orders", "1.2.0", "test", true)
+	router := chi.NewRouter()
+	base.AddHealthDetailsEndpoint(router, "health/details")
+
+	fail := false
+	base.AddHealthCheck("db", func(ctx context.Context) error {
+		if fail {
+			return errors.New("connection refused")
+		}
+		return nil
+	}, WithCacheTTL(0), WithFailureThreshold(1))
+
+	tests := []struct {
+		name       string
+		fail       bool
+		wantStatus int
+		wantHealth string
+	}{
+		{"passing", false, http.StatusOK, HealthPass},
+		{"failing", true, http.StatusServiceUnavailable, HealthFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fail = tt.fail
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/health/details", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			var details HealthDetails
+			if err := json.Unmarshal(w.Body.Bytes(), &details); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if details.Status != tt.wantHealth || details.Service != "orders" || len(details.Checks) != 1 ||
+				!details.Checks[0].Critical {
+				t.Errorf("Unexpected details: %+v", details)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"fmt"
+	"log"
+)
+
+// IsHealthy reports whether the service considers itself healthy
+func (b *Base) IsHealthy() bool {
+	healthy, _ := b.healthState()
+	return healthy
+}
+
+// UnhealthyReason returns why the service is unhealthy, or "" when it is healthy or no reason was given
+func (b *Base) UnhealthyReason() string {
+	_, reason := b.healthState()
+	return reason
+}
+
+// healthState returns the service's health and the reason it is unhealthy
+func (b *Base) healthState() (bool, string) {
+	b.healthMu.RLock()
+	defer b.healthMu.RUnlock()
+	return b.Healthy, b.unhealthyReason
+}
+
+// SetHealthy sets whether the service is healthy, clearing any reason given to SetUnhealthy. It is
+// safe to call while serving requests.
+func (b *Base) SetHealthy(healthy bool) {
+	b.healthMu.Lock()
+	defer b.healthMu.Unlock()
+	b.setHealth(healthy, "", false)
+}
+
+// SetUnhealthy marks the service unhealthy, with a reason shown by the health endpoints, e.g.
+// "db unreachable". It is safe to call while serving requests.
+func (b *Base) SetUnhealthy(reason string) {
+	b.healthMu.Lock()
+	defer b.healthMu.Unlock()
+	b.setHealth(false, reason, false)
+}
+
+// setHealth changes the health, logging transitions; callers hold healthMu
+func (b *Base) setHealth(healthy bool, reason string, auto bool) {
+	if b.Healthy != healthy {
+		if healthy {
+			log.Printf("### 💚 API: service is healthy")
+		} else {
+			log.Printf("### 💔 API: service is unhealthy: %s", reason)
+		}
+	}
+	b.Healthy, b.unhealthyReason, b.autoUnhealthy = healthy, reason, auto
+}
+
+// syncCheckHealth marks the service unhealthy while a check registered with WithMarkUnhealthy is
+// failing, and healthy again once they all pass. Health set with SetUnhealthy is left alone.
+func (b *Base) syncCheckHealth() {
+	failing := ""
+	for _, status := range b.HealthChecks() {
+		// Checks yet to report a result are not counted as failing
+		if b.marksUnhealthy(status.Name) && !status.Healthy && !status.LastChecked.IsZero() {
+			failing = fmt.Sprintf("dependency %s failing", status.Name)
+			if status.LastError != "" {
+				failing += ": " + status.LastError
+			}
+			break
+		}
+	}
+
+	b.healthMu.Lock()
+	defer b.healthMu.Unlock()
+
+	switch {
+	case failing != "" && (b.Healthy || b.autoUnhealthy):
+		b.setHealth(false, failing, true)
+	case failing == "" && b.autoUnhealthy:
+		b.setHealth(true, "", false)
+	}
+}
+
+// marksUnhealthy reports whether the named check was registered with WithMarkUnhealthy
+func (b *Base) marksUnhealthy(name string) bool {
+	b.health.mu.RLock()
+	defer b.health.mu.RUnlock()
+	check, ok := b.health.checks[name]
+	return ok && check.onResult != nil
+}
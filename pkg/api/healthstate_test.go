@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestSetHealthy(t *testing.T) {
+	base := NewBase("TestService", "1.0.0", "test-build", true)
+	router := chi.NewRouter()
+	base.AddHealthEndpoint(router, "health")
+
+	health := func() (int, string) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	base.SetUnhealthy("db unreachable")
+	code, body := health()
+	if code != http.StatusServiceUnavailable || body != "Error: Service is not healthy: db unreachable" {
+		t.Errorf("Expected 503 with the reason, got %d %q", code, body)
+	}
+	if base.IsHealthy() || base.UnhealthyReason() != "db unreachable" {
+		t.Errorf("Expected unhealthy with a reason, got %t %q", base.IsHealthy(), base.UnhealthyReason())
+	}
+
+	base.SetHealthy(true)
+	if code, _ := health(); code != http.StatusOK || base.UnhealthyReason() != "" {
+		t.Errorf("Expected healthy with no reason, got %d %q", code, base.UnhealthyReason())
+	}
+}
+
+func TestSetHealthyConcurrently(t *testing.T) {
+	base := NewBase("TestService", "1.0.0", "test-build", true)
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				base.SetUnhealthy("draining")
+			} else {
+				base.SetHealthy(true)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			_ = base.Ready()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMarkUnhealthyCheck(t *testing.T) {
+	base := NewBase("TestService", "1.0.0", "test-build", true)
+	var err error
+	check := func(ctx context.Context) error { return err }
+	base.AddHealthCheck("db", check, WithMarkUnhealthy(true), WithFailureThreshold(1), WithSuccessThreshold(1),
+		WithCacheTTL(0))
+	base.AddHealthCheck("cache", func(ctx context.Context) error { return errors.New("miss") }, WithCacheTTL(0))
+
+	base.RefreshHealthChecks(context.Background())
+	if !base.IsHealthy() {
+		t.Fatalf("Expected a failing check without WithMarkUnhealthy to leave the service healthy")
+	}
+
+	err = errors.New("connection refused")
+	base.RefreshHealthChecks(context.Background())
+	if base.IsHealthy() || base.UnhealthyReason() != "dependency db failing: connection refused" {
+		t.Errorf("Expected the failing check to make the service unhealthy, got %q", base.UnhealthyReason())
+	}
+
+	err = nil
+	base.RefreshHealthChecks(context.Background())
+	if !base.IsHealthy() {
+		t.Errorf("Expected the service to recover with the check, got %q", base.UnhealthyReason())
+	}
+
+	// Health set by hand is not overridden by checks recovering
+	base.SetUnhealthy("maintenance")
+	base.RefreshHealthChecks(context.Background())
+	if base.IsHealthy() || base.UnhealthyReason() != "maintenance" {
+		t.Errorf("Expected the manual reason to stay, got %q", base.UnhealthyReason())
+	}
+}
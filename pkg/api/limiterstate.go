@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/state"
+	"golang.org/x/time/rate"
+)
+
+// limiterSnapshotKey is the state key rate limiter buckets are saved under
+const limiterSnapshotKey = "rate-limits"
+
+// limiterSnapshot is the persisted form of the Base's rate limiters, holding the tokens left per
+// client for every bucket that isn't full
+type limiterSnapshot struct {
+	SavedAt  time.Time                     `json:"savedAt"`
+	Limiters map[string]map[string]float64 `json:"limiters"`
+}
+
+// snapshot returns the tokens left for each key that has used part of its burst. Only token buckets
+// are saved; window counts are short-lived enough to start afresh.
+func (rl *rateLimiter) snapshot(now time.Time) map[string]float64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	tokens := make(map[string]float64)
+	for key, elem := range rl.entries {
+		bucket, ok := elem.Value.(*limiterEntry).limiter.(*tokenBucket)
+		if !ok {
+			continue
+		}
+		if left := bucket.limiter.TokensAt(now); left < float64(bucket.limiter.Burst()) {
+			tokens[key] = left
+		}
+	}
+	return tokens
+}
+
+// restore recreates buckets from a snapshot, crediting tokens refilled since it was taken
+func (rl *rateLimiter) restore(tokens map[string]float64, elapsed time.Duration, now time.Time) int {
+	if rl.config.Algorithm != "" && rl.config.Algorithm != TokenBucket {
+		return 0
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	restored := 0
+	for key, left := range tokens {
+		left += elapsed.Seconds() * rl.config.RequestsPerSecond
+		if left >= float64(rl.config.Burst) {
+			continue
+		}
+
+		limiter := rate.NewLimiter(rate.Limit(rl.config.RequestsPerSecond), rl.config.Burst)
+		limiter.AllowN(now, int(math.Round(float64(rl.config.Burst)-left)))
+		rl.put(key, &tokenBucket{limiter: limiter}, now)
+		restored++
+	}
+	return restored
+}
+
+// namedLimiters returns the Base's rate limiters by a name that is stable across restarts, as long
+// as the middleware is created in the same order
+func (b *Base) namedLimiters() map[string]*rateLimiter {
+	b.initOnce.Do(b.init)
+
+	b.diagnostics.mu.RLock()
+	defer b.diagnostics.mu.RUnlock()
+
+	named := make(map[string]*rateLimiter)
+	for kind, list := range b.diagnostics.limiters {
+		for i, limiter := range list {
+			named[fmt.Sprintf("%s/%d", kind, i)] = limiter
+		}
+	}
+	return named
+}
+
+// SaveRateLimits persists the buckets of every rate limiter created by the Base
+func (b *Base) SaveRateLimits(ctx context.Context, config *state.Config) error {
+	now := time.Now()
+	snapshot := limiterSnapshot{SavedAt: now, Limiters: make(map[string]map[string]float64)}
+	for name, limiter := range b.namedLimiters() {
+		snapshot.Limiters[name] = limiter.snapshot(now)
+	}
+
+	return config.Save(ctx, limiterSnapshotKey, snapshot)
+}
+
+// LoadRateLimits restores buckets saved by SaveRateLimits, so clients don't get a fresh allowance
+// after a restart. Call it once the rate limiting middleware has been created. A missing snapshot
+// is not an error.
+func (b *Base) LoadRateLimits(ctx context.Context, config *state.Config) error {
+	var snapshot limiterSnapshot
+	if err := config.Load(ctx, limiterSnapshotKey, &snapshot); err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(snapshot.SavedAt)
+	restored := 0
+	for name, limiter := range b.namedLimiters() {
+		restored += limiter.restore(snapshot.Limiters[name], elapsed, now)
+	}
+
+	log.Printf("### 🤖 API: restored %d rate limit buckets saved %s ago", restored, elapsed.Round(time.Second))
+	return nil
+}
+
+// PersistRateLimits restores saved buckets now and saves them again during graceful shutdown
+func (b *Base) PersistRateLimits(ctx context.Context, config *state.Config) error {
+	if err := b.LoadRateLimits(ctx, config); err != nil {
+		return err
+	}
+
+	b.OnShutdown("rate-limits", func(ctx context.Context) error {
+		return b.SaveRateLimits(ctx, config)
+	})
+	return nil
+}
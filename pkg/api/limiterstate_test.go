@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/state"
+)
+
+func TestSaveLoadRateLimits(t *testing.T) {
+	ctx := context.Background()
+	config := state.NewConfig(state.WithStore(state.NewFileStore(filepath.Join(t.TempDir(), "state"))))
+	limits := NewRateLimiterConfig(WithRequestsPerSecond(0.001), WithBurst(2))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(h http.Handler) int {
+		r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		r.RemoteAddr = "203.0.113.7:1234"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	old := NewBase("test", "1.0", "", true)
+	oldHandler := old.RateLimitByIP(limits)(handler)
+	serve(oldHandler)
+	serve(oldHandler)
+	if err := old.SaveRateLimits(ctx, config); err != nil {
+		t.Fatalf("SaveRateLimits failed: %v", err)
+	}
+
+	restarted := NewBase("test", "1.0", "", true)
+	restartedHandler := restarted.RateLimitByIP(limits)(handler)
+	if err := restarted.LoadRateLimits(ctx, config); err != nil {
+		t.Fatalf("LoadRateLimits failed: %v", err)
+	}
+
+	if code := serve(restartedHandler); code != http.StatusTooManyRequests {
+		t.Errorf("Expected exhausted bucket to survive the restart, got %d", code)
+	}
+}
+
+func TestPersistRateLimitsWithoutSnapshot(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	config := state.NewConfig(state.WithStore(state.NewFileStore(filepath.Join(t.TempDir(), "state"))))
+
+	if err := b.PersistRateLimits(context.Background(), config); err != nil {
+		t.Errorf("Expected missing snapshot to be ignored, got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// inFlightRequests tracks requests being served by each concurrency limiter, exposed by AddMetricsEndpoint
+	inFlightRequests = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of requests being served, by concurrency limiter",
+	}, []string{"limiter"})
+
+	// queuedRequests tracks requests waiting for a slot, by concurrency limiter
+	queuedRequests = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_queued",
+		Help: "Number of requests waiting for a concurrency slot, by concurrency limiter",
+	}, []string{"limiter"})
+
+	// shedRequestsTotal counts requests turned away by concurrency limiters
+	shedRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_shed_total",
+		Help: "Total number of requests shed by concurrency limiters, by reason",
+	}, []string{"limiter", "reason"})
+)
+
+// Reasons a request is shed, used as the reason label of http_requests_shed_total
+const (
+	ShedQueueFull = "queue_full"
+	ShedTimeout   = "timeout"
+	ShedCanceled  = "canceled"
+)
+
+// ConcurrencyConfig holds configuration for concurrency limiting
+type ConcurrencyConfig struct {
+	// Name labels the limiter's metrics, e.g. the route it guards
+	Name string
+	// MaxInFlight is how many requests are served at once
+	MaxInFlight int
+	// MaxQueue is how many further requests may wait for a slot; the rest are shed immediately
+	MaxQueue int
+	// QueueTimeout is how long a request waits for a slot before it is shed
+	QueueTimeout time.Duration
+	// Status is sent to shed requests, 503 Service Unavailable or 429 Too Many Requests
+	Status int
+	// RetryAfter is suggested to shed clients in the Retry-After header
+	RetryAfter time.Duration
+}
+
+// DefaultConcurrencyConfig provides sensible defaults
+func DefaultConcurrencyConfig() *ConcurrencyConfig {
+	return &ConcurrencyConfig{
+		Name:         "global",
+		MaxInFlight:  100,
+		MaxQueue:     100,
+		QueueTimeout: time.Second,
+		Status:       http.StatusServiceUnavailable,
+		RetryAfter:   time.Second,
+	}
+}
+
+// ConcurrencyOption is a functional option for configuring concurrency limiting
+type ConcurrencyOption func(*ConcurrencyConfig)
+
+// WithLimiterName sets the name labelling the limiter's metrics
+func WithLimiterName(name string) ConcurrencyOption {
+	return func(config *ConcurrencyConfig) {
+		config.Name = name
+	}
+}
+
+// WithMaxInFlight sets how many requests are served at once
+func WithMaxInFlight(limit int) ConcurrencyOption {
+	return func(config *ConcurrencyConfig) {
+		config.MaxInFlight = limit
+	}
+}
+
+// WithQueue sets how many requests may wait for a slot, and for how long
+func WithQueue(size int, timeout time.Duration) ConcurrencyOption {
+	return func(config *ConcurrencyConfig) {
+		config.MaxQueue = size
+		config.QueueTimeout = timeout
+	}
+}
+
+// WithShedStatus sets the status sent to shed requests, e.g. http.StatusTooManyRequests
+func WithShedStatus(status int) ConcurrencyOption {
+	return func(config *ConcurrencyConfig) {
+		config.Status = status
+	}
+}
+
+// WithRetryAfter sets the delay suggested to shed clients
+func WithRetryAfter(delay time.Duration) ConcurrencyOption {
+	return func(config *ConcurrencyConfig) {
+		config.RetryAfter = delay
+	}
+}
+
+// NewConcurrencyConfig creates a new concurrency config with options
+func NewConcurrencyConfig(options ...ConcurrencyOption) *ConcurrencyConfig {
+	config := DefaultConcurrencyConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// concurrencyLimiter is a semaphore with a bounded wait queue
+type concurrencyLimiter struct {
+	config  *ConcurrencyConfig
+	slots   chan struct{}
+	waiting atomic.Int64
+}
+
+// acquire takes a slot, waiting in the queue if there is room, and returns the reason the request
+// is shed if it can't have one
+func (cl *concurrencyLimiter) acquire(ctx context.Context) string {
+	select {
+	case cl.slots <- struct{}{}:
+		return ""
+	default:
+	}
+
+	if cl.waiting.Add(1) > int64(cl.config.MaxQueue) {
+		cl.waiting.Add(-1)
+		return ShedQueueFull
+	}
+	queued := queuedRequests.WithLabelValues(cl.config.Name)
+	queued.Inc()
+	defer func() {
+		cl.waiting.Add(-1)
+		queued.Dec()
+	}()
+
+	timer := time.NewTimer(cl.config.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case cl.slots <- struct{}{}:
+		return ""
+	case <-timer.C:
+		return ShedTimeout
+	case <-ctx.Done():
+		return ShedCanceled
+	}
+}
+
+// release returns a slot
+func (cl *concurrencyLimiter) release() {
+	<-cl.slots
+}
+
+// ConcurrencyLimit creates middleware that caps the requests served at once. Requests over the cap
+// wait in a bounded queue for a slot; when the queue is full, or the wait times out, they are shed
+// with a problem+json 503 (or the configured status) and a Retry-After header. Unlike rate limiting
+// this bounds the work in progress, so slow dependencies can't pile up requests until the service
+// falls over. Use it with router.Use for a global limit, or router.With for a tighter limit on
+// expensive routes, giving each a name. Infrastructure routes are never limited.
+func (b *Base) ConcurrencyLimit(config *ConcurrencyConfig) func(next http.Handler) http.Handler {
+	if config == nil {
+		config = DefaultConcurrencyConfig()
+	}
+
+	limiter := &concurrencyLimiter{config: config, slots: make(chan struct{}, max(config.MaxInFlight, 1))}
+	inFlight := inFlightRequests.WithLabelValues(config.Name)
+	retryAfter := strconv.Itoa(int(math.Ceil(config.RetryAfter.Seconds())))
+
+	log.Printf("### 🤖 API: concurrency limit %s with %d in flight and %d queued", config.Name,
+		config.MaxInFlight, config.MaxQueue)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if b.isInfrastructure(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if reason := limiter.acquire(r.Context()); reason != "" {
+				shedRequestsTotal.WithLabelValues(config.Name, reason).Inc()
+				if reason == ShedCanceled {
+					return
+				}
+				w.Header().Set("Retry-After", retryAfter)
+				problem.New("overloaded", "Service Overloaded", config.Status,
+					"The service is handling too many requests; retry shortly", r.URL.Path).Respond(w, r)
+				return
+			}
+			defer limiter.release()
+
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
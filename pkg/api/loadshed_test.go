@@ -0,0 +1,167 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func shedCount(t *testing.T, limiter, reason string) float64 {
+	t.Helper()
+
+	var metric dto.Metric
+	if err := shedRequestsTotal.WithLabelValues(limiter, reason).Write(&metric); err != nil {
+		t.Fatalf("Failed to read metric: %v", err)
+	}
+	return metric.GetCounter().GetValue()
+}
+
+func queuedCount(t *testing.T, limiter string) float64 {
+	t.Helper()
+
+	var metric dto.Metric
+	if err := queuedRequests.WithLabelValues(limiter).Write(&metric); err != nil {
+		t.Fatalf("Failed to read metric: %v", err)
+	}
+	return metric.GetGauge().GetValue()
+}
+
+// blockingHandler holds requests until release is closed, signalling each arrival on entered
+func blockingHandler(entered chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})
+}
+
+func TestConcurrencyLimitQueueFull(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	handler := b.ConcurrencyLimit(NewConcurrencyConfig(
+		WithLimiterName("queue-full"), WithMaxInFlight(1), WithQueue(0, time.Second),
+		WithShedStatus(http.StatusTooManyRequests), WithRetryAfter(1500*time.Millisecond),
+	))(blockingHandler(entered, release))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	}()
+	<-entered
+	before := shedCount(t, "queue-full", ShedQueueFull)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "2" {
+		t.Errorf("Expected Retry-After 2, got %q", w.Header().Get("Retry-After"))
+	}
+	if count := shedCount(t, "queue-full", ShedQueueFull) - before; count != 1 {
+		t.Errorf("Expected 1 shed request, got %v", count)
+	}
+
+	close(release)
+	wg.Wait()
+}
+
+func TestConcurrencyLimitQueue(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	entered := make(chan struct{}, 2)
+	release := make(chan struct{})
+	handler := b.ConcurrencyLimit(NewConcurrencyConfig(
+		WithLimiterName("queued"), WithMaxInFlight(1), WithQueue(1, 5*time.Second),
+	))(blockingHandler(entered, release))
+
+	codes := make(chan int, 2)
+	serve := func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+		codes <- w.Code
+	}
+	go serve()
+	<-entered
+	go serve() // waits in the queue for the first to finish
+
+	deadline := time.Now().Add(time.Second)
+	for queuedCount(t, "queued") != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	for range 2 {
+		if code := <-codes; code != http.StatusOK {
+			t.Errorf("Expected queued requests to be served, got %d", code)
+		}
+	}
+}
+
+func TestConcurrencyLimitQueueTimeout(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	handler := b.ConcurrencyLimit(NewConcurrencyConfig(
+		WithLimiterName("timeout"), WithMaxInFlight(1), WithQueue(1, 10*time.Millisecond),
+	))(blockingHandler(entered, release))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	}()
+	<-entered
+	before := shedCount(t, "timeout", ShedTimeout)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+	if count := shedCount(t, "timeout", ShedTimeout) - before; count != 1 {
+		t.Errorf("Expected 1 timed out request, got %v", count)
+	}
+
+	close(release)
+	wg.Wait()
+}
+
+func TestConcurrencyLimitSkipsInfrastructure(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	blocking := blockingHandler(entered, release)
+	handler := b.ConcurrencyLimit(NewConcurrencyConfig(
+		WithLimiterName("infra"), WithMaxInFlight(1), WithQueue(0, time.Second),
+	))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/orders" {
+			blocking.ServeHTTP(w, r)
+		}
+	}))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	}()
+	<-entered
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected health checks to bypass the limit, got %d", w.Code)
+	}
+
+	close(release)
+	wg.Wait()
+}
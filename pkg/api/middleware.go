@@ -1,40 +1,79 @@
 package api
 
 import (
+	"container/list"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
 	"github.com/go-chi/cors"
-	"golang.org/x/time/rate"
 )
 
 type contextKey string
 
 // RateLimiterConfig holds configuration for rate limiting
 type RateLimiterConfig struct {
+	// Algorithm is how requests are counted; the default is TokenBucket
+	Algorithm Algorithm
+	// RequestsPerSecond is the average rate allowed
 	RequestsPerSecond float64
-	Burst             int
-	Window            time.Duration
+	// Burst is how many requests a token bucket allows at once
+	Burst int
+	// Window is the period window algorithms count over, allowing RequestsPerSecond × Window requests
+	// in each; it has no effect on TokenBucket
+	Window time.Duration
+	// MaxKeys caps how many clients are tracked, forgetting the least recently seen; zero is unlimited
+	MaxKeys int
+	// IdleTimeout forgets clients not seen for this long; zero keeps them until MaxKeys is reached
+	IdleTimeout time.Duration
+	// CleanupInterval is how often idle clients are swept
+	CleanupInterval time.Duration
+	// ExemptPaths bypass the limiter, on top of the Base's infrastructure routes; a path ending in
+	// "/*" matches everything beneath it
+	ExemptPaths []string
+	// ExemptNetworks are client CIDRs or addresses that bypass the limiter, such as internal dashboards
+	ExemptNetworks []string
+	// ExemptAPIKeys are X-API-Key values that bypass the limiter
+	ExemptAPIKeys []string
+	// KeyFunc extracts the client a request is counted against for RateLimit, e.g. KeyByIP or a
+	// CombineKeys of several; an empty key means the request isn't limited
+	KeyFunc func(r *http.Request) string
+	// Clock refills buckets and sets reset times; nil means the system clock
+	Clock clock.Clock
 }
 
 // DefaultRateLimiterConfig provides sensible defaults
 func DefaultRateLimiterConfig() *RateLimiterConfig {
 	return &RateLimiterConfig{
+		Algorithm:         TokenBucket,
 		RequestsPerSecond: 10.0,
 		Burst:             20,
 		Window:            1 * time.Minute,
+		MaxKeys:           100000,
+		IdleTimeout:       10 * time.Minute,
+		CleanupInterval:   1 * time.Minute,
+		Clock:             clock.Real(),
 	}
 }
 
 // RateLimitOption is a functional option for configuring rate limiting
 type RateLimitOption func(*RateLimiterConfig)
 
+// WithAlgorithm sets how requests are counted
+func WithAlgorithm(algorithm Algorithm) RateLimitOption {
+	return func(config *RateLimiterConfig) {
+		config.Algorithm = algorithm
+	}
+}
+
 // WithRequestsPerSecond sets the requests per second limit
 func WithRequestsPerSecond(rps float64) RateLimitOption {
 	return func(config *RateLimiterConfig) {
@@ -49,13 +88,69 @@ func WithBurst(burst int) RateLimitOption {
 	}
 }
 
-// WithWindow sets the time window for rate limiting
+// WithWindow sets the period window algorithms count requests over
 func WithWindow(window time.Duration) RateLimitOption {
 	return func(config *RateLimiterConfig) {
 		config.Window = window
 	}
 }
 
+// WithMaxKeys sets how many clients are tracked before the least recently seen are forgotten
+func WithMaxKeys(maxKeys int) RateLimitOption {
+	return func(config *RateLimiterConfig) {
+		config.MaxKeys = maxKeys
+	}
+}
+
+// WithIdleTimeout sets how long a client goes unseen before its bucket is forgotten
+func WithIdleTimeout(timeout time.Duration) RateLimitOption {
+	return func(config *RateLimiterConfig) {
+		config.IdleTimeout = timeout
+	}
+}
+
+// WithCleanupInterval sets how often idle clients are swept
+func WithCleanupInterval(interval time.Duration) RateLimitOption {
+	return func(config *RateLimiterConfig) {
+		config.CleanupInterval = interval
+	}
+}
+
+// WithExemptPaths sets paths that bypass the limiter
+func WithExemptPaths(paths ...string) RateLimitOption {
+	return func(config *RateLimiterConfig) {
+		config.ExemptPaths = paths
+	}
+}
+
+// WithExemptNetworks sets client networks that bypass the limiter, as CIDRs or single addresses
+func WithExemptNetworks(networks ...string) RateLimitOption {
+	return func(config *RateLimiterConfig) {
+		config.ExemptNetworks = networks
+	}
+}
+
+// WithExemptAPIKeys sets X-API-Key values that bypass the limiter
+func WithExemptAPIKeys(keys ...string) RateLimitOption {
+	return func(config *RateLimiterConfig) {
+		config.ExemptAPIKeys = keys
+	}
+}
+
+// WithKeyFunc sets how RateLimit identifies the client a request is counted against
+func WithKeyFunc(keyFunc func(r *http.Request) string) RateLimitOption {
+	return func(config *RateLimiterConfig) {
+		config.KeyFunc = keyFunc
+	}
+}
+
+// WithClock sets the clock buckets refill by, e.g. a fake clock in tests
+func WithClock(c clock.Clock) RateLimitOption {
+	return func(config *RateLimiterConfig) {
+		config.Clock = c
+	}
+}
+
 // NewRateLimiterConfig creates a new rate limiter config with options
 func NewRateLimiterConfig(options ...RateLimitOption) *RateLimiterConfig {
 	config := DefaultRateLimiterConfig()
@@ -65,203 +160,210 @@ func NewRateLimiterConfig(options ...RateLimitOption) *RateLimiterConfig {
 	return config
 }
 
-// rateLimiter holds rate limiting state
+// rateLimiter holds a bucket per key, evicting keys that haven't been seen for IdleTimeout and the
+// least recently seen ones beyond MaxKeys. A background goroutine sweeps idle keys until Close.
 type rateLimiter struct {
-	limiters map[string]*rate.Limiter
-	mu       sync.RWMutex
-	config   *RateLimiterConfig
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently seen first
+	config  *RateLimiterConfig
+	clock   clock.Clock
+	exempt  *rateLimitExemptions
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-// newRateLimiter creates a new rate limiter instance
+// limiterEntry is a key's limiter and when it was last used
+type limiterEntry struct {
+	key      string
+	limiter  keyLimiter
+	lastSeen time.Time
+}
+
+// newRateLimiter creates a rate limiter and starts sweeping idle keys
 func newRateLimiter(config *RateLimiterConfig) *rateLimiter {
-	return &rateLimiter{
-		limiters: make(map[string]*rate.Limiter),
-		config:   config,
+	if config == nil {
+		config = DefaultRateLimiterConfig()
+	}
+	rl := &rateLimiter{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		config:  config,
+		clock:   clock.OrReal(config.Clock),
+		exempt:  newRateLimitExemptions(config),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
 	}
+	go rl.sweep()
+	return rl
 }
 
 // getLimiter returns or creates a rate limiter for the given key
-func (rl *rateLimiter) getLimiter(key string) *rate.Limiter {
+func (rl *rateLimiter) getLimiter(key string) keyLimiter {
+	return rl.getLimiterWith(key, rl.config.RequestsPerSecond, rl.config.Burst)
+}
+
+// getLimiterWith returns or creates a rate limiter for the given key with its own limit, updating
+// the limit of an existing limiter if it has changed
+func (rl *rateLimiter) getLimiterWith(key string, rps float64, burst int) keyLimiter {
+	now := rl.clock.Now()
+
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	limiter, exists := rl.limiters[key]
-	if !exists {
-		limiter = rate.NewLimiter(rate.Limit(rl.config.RequestsPerSecond), rl.config.Burst)
-		rl.limiters[key] = limiter
+	if elem, exists := rl.entries[key]; exists {
+		entry := elem.Value.(*limiterEntry)
+		entry.lastSeen = now
+		rl.order.MoveToFront(elem)
+		entry.limiter.setRate(rps, burst)
+		return entry.limiter
 	}
 
+	limiter := newKeyLimiter(rl.config, rps, burst, now)
+	rl.put(key, limiter, now)
 	return limiter
 }
 
-// cleanup removes old limiters to prevent memory leaks
-func (rl *rateLimiter) cleanup() {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+// put adds a key's bucket as the most recently seen, evicting the least recently seen keys
+// beyond MaxKeys; the caller holds mu
+func (rl *rateLimiter) put(key string, limiter keyLimiter, now time.Time) {
+	if elem, exists := rl.entries[key]; exists {
+		rl.order.Remove(elem)
+	}
+	rl.entries[key] = rl.order.PushFront(&limiterEntry{key: key, limiter: limiter, lastSeen: now})
 
-	// Simple cleanup - in production you might want more sophisticated cleanup
-	if len(rl.limiters) > 1000 {
-		rl.limiters = make(map[string]*rate.Limiter)
+	for rl.config.MaxKeys > 0 && rl.order.Len() > rl.config.MaxKeys {
+		rl.remove(rl.order.Back())
 	}
 }
 
-// RateLimitByIP creates middleware that rate limits by IP address
-func (b *Base) RateLimitByIP(config *RateLimiterConfig) func(next http.Handler) http.Handler {
-	if config == nil {
-		config = DefaultRateLimiterConfig()
-	}
+// remove deletes a key's bucket; the caller holds mu
+func (rl *rateLimiter) remove(elem *list.Element) {
+	rl.order.Remove(elem)
+	delete(rl.entries, elem.Value.(*limiterEntry).key)
+}
 
-	limiter := newRateLimiter(config)
+// size returns the number of keys currently tracked
+func (rl *rateLimiter) size() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
 
-	// Start cleanup goroutine
-	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-		for range ticker.C {
-			limiter.cleanup()
-		}
-	}()
+	return len(rl.entries)
+}
 
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Get client IP
-			clientIP := getClientIP(r)
-
-			// Get rate limiter for this IP
-			ipLimiter := limiter.getLimiter(clientIP)
-
-			// Check if request is allowed
-			if !ipLimiter.Allow() {
-				log.Printf("### 🚫 Rate limit exceeded for IP: %s", clientIP)
-				w.Header().Set("Content-Type", "application/json")
-				w.Header().Set("X-RateLimit-Limit", "10")
-				w.Header().Set("X-RateLimit-Remaining", "0")
-				w.Header().Set("X-RateLimit-Reset", time.Now().Add(time.Second).Format(time.RFC3339))
-				w.WriteHeader(http.StatusTooManyRequests)
-				if err := json.NewEncoder(w).Encode(map[string]string{
-					"error": "Rate limit exceeded. Please try again later.",
-				}); err != nil {
-					log.Printf("### 🚫 Error encoding rate limit response: %v", err)
-				}
-				return
-			}
+// cleanup removes the limiters of keys not seen for IdleTimeout, returning how many were removed.
+// A key idle that long has its allowance back, so forgetting it doesn't change anything.
+func (rl *rateLimiter) cleanup() int {
+	if rl.config.IdleTimeout <= 0 {
+		return 0
+	}
+	cutoff := rl.clock.Now().Add(-rl.config.IdleTimeout)
 
-			// Add rate limit headers
-			w.Header().Set("X-RateLimit-Limit", "10")
-			w.Header().Set("X-RateLimit-Remaining", "9") // Simplified
-			w.Header().Set("X-RateLimit-Reset", time.Now().Add(time.Second).Format(time.RFC3339))
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
 
-			next.ServeHTTP(w, r)
-		})
+	removed := 0
+	for elem := rl.order.Back(); elem != nil; elem = rl.order.Back() {
+		if elem.Value.(*limiterEntry).lastSeen.After(cutoff) {
+			break
+		}
+		rl.remove(elem)
+		removed++
 	}
+	return removed
 }
 
-// RateLimitByToken creates middleware that rate limits by JWT token or API key
-func (b *Base) RateLimitByToken(config *RateLimiterConfig) func(next http.Handler) http.Handler {
-	if config == nil {
-		config = DefaultRateLimiterConfig()
-	}
+// sweep runs cleanup every CleanupInterval until Close
+func (rl *rateLimiter) sweep() {
+	defer close(rl.done)
 
-	limiter := newRateLimiter(config)
-
-	// Start cleanup goroutine
-	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-		for range ticker.C {
-			limiter.cleanup()
+	interval := rl.config.CleanupInterval
+	if interval <= 0 {
+		interval = DefaultRateLimiterConfig().CleanupInterval
+	}
+	ticker := rl.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rl.stop:
+			return
+		case <-ticker.C():
+			rl.cleanup()
 		}
-	}()
+	}
+}
 
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Get token from Authorization header
-			token := getTokenFromRequest(r)
-			if token == "" {
-				// No token provided, continue without rate limiting
-				next.ServeHTTP(w, r)
-				return
-			}
+// Close stops sweeping idle keys and waits for the goroutine to exit. The limiter still works
+// afterwards, so requests in flight during shutdown are limited as before.
+func (rl *rateLimiter) Close() {
+	rl.closeOnce.Do(func() { close(rl.stop) })
+	<-rl.done
+}
 
-			// Get rate limiter for this token
-			tokenLimiter := limiter.getLimiter(token)
-
-			// Check if request is allowed
-			if !tokenLimiter.Allow() {
-				log.Printf("### 🚫 Rate limit exceeded for token: %s", maskToken(token))
-				w.Header().Set("Content-Type", "application/json")
-				w.Header().Set("X-RateLimit-Limit", "10")
-				w.Header().Set("X-RateLimit-Remaining", "0")
-				w.Header().Set("X-RateLimit-Reset", time.Now().Add(time.Second).Format(time.RFC3339))
-				w.WriteHeader(http.StatusTooManyRequests)
-				if err := json.NewEncoder(w).Encode(map[string]string{
-					"error": "Rate limit exceeded. Please try again later.",
-				}); err != nil {
-					log.Printf("### 🚫 Error encoding rate limit response: %v", err)
-				}
-				return
-			}
+// RateLimit creates middleware that rate limits by the key config.KeyFunc extracts from each
+// request, the client IP by default. Requests with an empty key are not limited.
+func (b *Base) RateLimit(config *RateLimiterConfig) func(next http.Handler) http.Handler {
+	return b.rateLimit("key", config, nil)
+}
 
-			// Add rate limit headers
-			w.Header().Set("X-RateLimit-Limit", "10")
-			w.Header().Set("X-RateLimit-Remaining", "9") // Simplified
-			w.Header().Set("X-RateLimit-Reset", time.Now().Add(time.Second).Format(time.RFC3339))
+// RateLimitByIP creates middleware that rate limits by IP address
+func (b *Base) RateLimitByIP(config *RateLimiterConfig) func(next http.Handler) http.Handler {
+	return b.rateLimit("ip", config, KeyByIP)
+}
 
-			next.ServeHTTP(w, r)
-		})
-	}
+// RateLimitByToken creates middleware that rate limits by JWT token or API key
+func (b *Base) RateLimitByToken(config *RateLimiterConfig) func(next http.Handler) http.Handler {
+	return b.rateLimit("token", config, KeyByToken)
 }
 
 // RateLimitByUserID creates middleware that rate limits by user ID from JWT
 func (b *Base) RateLimitByUserID(config *RateLimiterConfig) func(next http.Handler) http.Handler {
+	return b.rateLimit("user", config, KeyByUserID)
+}
+
+// rateLimit creates rate limiting middleware keyed by keyFunc, or config.KeyFunc when it is nil.
+// kind names the limiter in diagnostics and persisted state.
+func (b *Base) rateLimit(
+	kind string, config *RateLimiterConfig, keyFunc func(r *http.Request) string,
+) func(next http.Handler) http.Handler {
 	if config == nil {
 		config = DefaultRateLimiterConfig()
 	}
+	if keyFunc == nil {
+		keyFunc = config.KeyFunc
+	}
+	if keyFunc == nil {
+		keyFunc = KeyByIP
+	}
 
 	limiter := newRateLimiter(config)
-
-	// Start cleanup goroutine
-	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-		for range ticker.C {
-			limiter.cleanup()
-		}
-	}()
+	b.trackLimiter(kind, limiter)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Extract user ID from JWT
-			userID := getUserIDFromJWT(r)
-			if userID == "" {
-				// No user ID found, continue without rate limiting
+			if b.isInfrastructure(r) || limiter.exempt.exempt(r) {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			// Get rate limiter for this user
-			userLimiter := limiter.getLimiter("user:" + userID)
-
-			// Check if request is allowed
-			if !userLimiter.Allow() {
-				log.Printf("### 🚫 Rate limit exceeded for user: %s", userID)
-				w.Header().Set("Content-Type", "application/json")
-				w.Header().Set("X-RateLimit-Limit", "10")
-				w.Header().Set("X-RateLimit-Remaining", "0")
-				w.Header().Set("X-RateLimit-Reset", time.Now().Add(time.Second).Format(time.RFC3339))
-				w.WriteHeader(http.StatusTooManyRequests)
-				if err := json.NewEncoder(w).Encode(map[string]string{
-					"error": "Rate limit exceeded. Please try again later.",
-				}); err != nil {
-					log.Printf("### 🚫 Error encoding rate limit response: %v", err)
-				}
+			key := keyFunc(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			// Add rate limit headers
-			w.Header().Set("X-RateLimit-Limit", "10")
-			w.Header().Set("X-RateLimit-Remaining", "9") // Simplified
-			w.Header().Set("X-RateLimit-Reset", time.Now().Add(time.Second).Format(time.RFC3339))
+			keyLimiter := limiter.getLimiter(key)
+			now := limiter.clock.Now()
+			allowed := keyLimiter.allow(now)
+			setRateLimitHeaders(w, keyLimiter, now)
+			if !allowed {
+				log.Printf("### 🚫 Rate limit exceeded for %s", key)
+				writeRateLimitExceeded(w)
+				return
+			}
 
 			next.ServeHTTP(w, r)
 		})
@@ -270,30 +372,27 @@ func (b *Base) RateLimitByUserID(config *RateLimiterConfig) func(next http.Handl
 
 // Helper functions
 
-func getClientIP(r *http.Request) string {
-	// Check for forwarded headers first
-	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
-		if commaIdx := strings.Index(ip, ","); commaIdx != -1 {
-			return strings.TrimSpace(ip[:commaIdx])
-		}
-		return strings.TrimSpace(ip)
-	}
-
-	if ip := r.Header.Get("X-Real-IP"); ip != "" {
-		return strings.TrimSpace(ip)
-	}
+// setRateLimitHeaders reports a client's limit, what is left of it, and when it is fully restored
+func setRateLimitHeaders(w http.ResponseWriter, limiter keyLimiter, now time.Time) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limiter.limit()))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(limiter.remaining(now)))
+	w.Header().Set("X-RateLimit-Reset", limiter.reset(now).Format(time.RFC3339))
+}
 
-	if ip := r.Header.Get("X-Client-IP"); ip != "" {
-		return strings.TrimSpace(ip)
+// writeRateLimitExceeded sends the 429 response
+func writeRateLimitExceeded(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	if err := json.NewEncoder(w).Encode(map[string]string{
+		"error": "Rate limit exceeded. Please try again later.",
+	}); err != nil {
+		log.Printf("### 🚫 Error encoding rate limit response: %v", err)
 	}
+}
 
-	// Fall back to remote address
-	ip := r.RemoteAddr
-	if colonIdx := strings.LastIndex(ip, ":"); colonIdx != -1 {
-		ip = ip[:colonIdx]
-	}
-	return ip
+// getClientIP returns the client IP, honoring forwarded headers only from trusted proxies
+func getClientIP(r *http.Request) string {
+	return ClientIP(r)
 }
 
 func getTokenFromRequest(r *http.Request) string {
@@ -315,6 +414,11 @@ func getTokenFromRequest(r *http.Request) string {
 }
 
 func getUserIDFromJWT(r *http.Request) string {
+	// Claims checked by an auth.Validator are preferred over decoding the unverified token
+	if userID, ok := auth.GetUserIDFromContext(r.Context()); ok {
+		return userID
+	}
+
 	token := getTokenFromRequest(r)
 	if token == "" {
 		return ""
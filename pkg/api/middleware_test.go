@@ -5,6 +5,8 @@ import (
 	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
 )
 
 func TestRateLimitByIP(t *testing.T) {
@@ -59,6 +61,69 @@ func TestRateLimitByIP(t *testing.T) {
 	}
 }
 
+func TestRateLimitWithClock(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	clk := clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	config := NewRateLimiterConfig(WithRequestsPerSecond(1), WithBurst(1), WithClock(clk))
+	handler := base.RateLimitByIP(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the first request to pass, got %d", rec.Code)
+	}
+	rec := serve()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the second request to be limited, got %d", rec.Code)
+	}
+	if reset := rec.Header().Get("X-RateLimit-Reset"); reset != "2024-03-01T12:00:01Z" {
+		t.Errorf("Expected the reset time from the clock, got %q", reset)
+	}
+
+	clk.Advance(time.Second)
+	if rec := serve(); rec.Code != http.StatusOK {
+		t.Errorf("Expected the bucket to refill as the clock moves, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitKeyFunc(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	config := NewRateLimiterConfig(WithBurst(1), WithKeyFunc(KeyByAPIKey))
+	handler := base.RateLimit(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(apiKey string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		apiKey string
+		want   int
+	}{
+		{"key-1", http.StatusOK},
+		{"key-1", http.StatusTooManyRequests},
+		{"key-2", http.StatusOK},
+		{"", http.StatusOK},
+		{"", http.StatusOK},
+	}
+	for i, tt := range tests {
+		if got := serve(tt.apiKey); got != tt.want {
+			t.Errorf("Request %d with key %q: expected %d, got %d", i, tt.apiKey, tt.want, got)
+		}
+	}
+}
+
 func TestRateLimitByToken(t *testing.T) {
 	base := NewBase("test", "1.0.0", "test", true)
 
@@ -354,16 +419,64 @@ func TestRateLimiterCleanup(t *testing.T) {
 	limiter.getLimiter("ip2")
 	limiter.getLimiter("ip3")
 
-	if len(limiter.limiters) != 3 {
-		t.Errorf("Expected 3 limiters, got %d", len(limiter.limiters))
+	if limiter.size() != 3 {
+		t.Errorf("Expected 3 limiters, got %d", limiter.size())
 	}
 
 	// Run cleanup
 	limiter.cleanup()
 
 	// Should still have 3 limiters since they're recent
-	if len(limiter.limiters) != 3 {
-		t.Errorf("Expected 3 limiters after cleanup, got %d", len(limiter.limiters))
+	if limiter.size() != 3 {
+		t.Errorf("Expected 3 limiters after cleanup, got %d", limiter.size())
+	}
+}
+
+func TestRateLimiterEviction(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	limiter := newRateLimiter(NewRateLimiterConfig(WithMaxKeys(3), WithIdleTimeout(10*time.Minute),
+		WithCleanupInterval(time.Minute), WithClock(clk)))
+	defer limiter.Close()
+
+	for _, key := range []string{"ip1", "ip2", "ip3"} {
+		limiter.getLimiter(key)
+		clk.Advance(time.Minute)
+	}
+	// Seeing ip1 again makes ip2 the least recently seen
+	limiter.getLimiter("ip1")
+	limiter.getLimiter("ip4")
+
+	if limiter.size() != 3 {
+		t.Fatalf("Expected MaxKeys to cap the limiter at 3 keys, got %d", limiter.size())
+	}
+	if _, ok := limiter.entries["ip2"]; ok {
+		t.Error("Expected the least recently seen key to be evicted")
+	}
+
+	// ip3 was last seen at 12:02, ip1 and ip4 at 12:03
+	clk.Set(time.Date(2024, 3, 1, 12, 12, 30, 0, time.UTC))
+	if removed := limiter.cleanup(); removed != 1 || limiter.size() != 2 {
+		t.Errorf("Expected only the key idle for 10 minutes to be swept, removed %d of %d", removed, removed+limiter.size())
+	}
+
+	clk.Advance(time.Hour)
+	deadline := time.Now().Add(time.Second)
+	for limiter.size() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the background sweep to remove idle keys")
+		}
+		clk.Advance(time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRateLimiterClose(t *testing.T) {
+	limiter := newRateLimiter(nil)
+	limiter.Close()
+	limiter.Close()
+
+	if !limiter.getLimiter("ip1").allow(time.Now()) {
+		t.Error("Expected a closed limiter to keep limiting")
 	}
 }
 
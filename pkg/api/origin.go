@@ -0,0 +1,219 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/cors"
+	"golang.org/x/net/publicsuffix"
+)
+
+// OriginConfig holds configuration for origin validation
+type OriginConfig struct {
+	// Schemes that origins may use; patterns without a scheme default to the first
+	Schemes []string
+	// AllowLocalhost permits http://localhost and loopback origins on any port, for development
+	AllowLocalhost bool
+}
+
+// DefaultOriginConfig provides secure defaults: HTTPS only, no localhost
+func DefaultOriginConfig() *OriginConfig {
+	return &OriginConfig{
+		Schemes:        []string{"https"},
+		AllowLocalhost: false,
+	}
+}
+
+// OriginOption is a functional option for configuring origin validation
+type OriginOption func(*OriginConfig)
+
+// WithOriginSchemes sets the schemes origins may use
+func WithOriginSchemes(schemes ...string) OriginOption {
+	return func(config *OriginConfig) {
+		config.Schemes = schemes
+	}
+}
+
+// WithLocalhostOrigins permits localhost and loopback origins over HTTP or HTTPS
+func WithLocalhostOrigins(allow bool) OriginOption {
+	return func(config *OriginConfig) {
+		config.AllowLocalhost = allow
+	}
+}
+
+// NewOriginConfig creates a new origin config with options
+func NewOriginConfig(options ...OriginOption) *OriginConfig {
+	config := DefaultOriginConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// originPattern is a parsed allowed origin
+type originPattern struct {
+	scheme   string
+	host     string // for wildcards, the domain the subdomains belong to
+	port     string
+	wildcard bool
+}
+
+// OriginPolicy decides which browser origins are trusted
+type OriginPolicy struct {
+	config   *OriginConfig
+	patterns []originPattern
+}
+
+// NewOriginPolicy parses allowed origins. Each pattern is an exact origin such as
+// "https://app.example.com" or a wildcard such as "https://*.example.com", which matches any
+// subdomain of example.com but not example.com itself. Wildcards directly under a public
+// suffix ("*.com", "*.co.uk", "*.github.io") are rejected because they would trust domains
+// owned by anyone.
+func NewOriginPolicy(patterns []string, options ...OriginOption) (*OriginPolicy, error) {
+	config := NewOriginConfig(options...)
+	if len(config.Schemes) == 0 {
+		return nil, fmt.Errorf("at least one origin scheme is required")
+	}
+
+	policy := &OriginPolicy{config: config}
+	for _, raw := range patterns {
+		p, err := parseOriginPattern(raw, config)
+		if err != nil {
+			return nil, err
+		}
+		policy.patterns = append(policy.patterns, p)
+	}
+
+	return policy, nil
+}
+
+// parseOriginPattern validates and normalizes a single allowed origin
+func parseOriginPattern(raw string, config *OriginConfig) (originPattern, error) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	if !strings.Contains(value, "://") {
+		value = config.Schemes[0] + "://" + value
+	}
+
+	scheme, hostPort, _ := strings.Cut(value, "://")
+	if !slices.Contains(config.Schemes, scheme) {
+		return originPattern{}, fmt.Errorf("origin %q: scheme %s is not allowed", raw, scheme)
+	}
+	if strings.ContainsAny(hostPort, "/?#@") {
+		return originPattern{}, fmt.Errorf("origin %q must not contain a path, query, or credentials", raw)
+	}
+
+	host, port := splitOriginHost(hostPort)
+	p := originPattern{scheme: scheme, host: host, port: port}
+
+	if strings.HasPrefix(host, "*.") {
+		p.wildcard = true
+		p.host = strings.TrimPrefix(host, "*.")
+		if err := ValidateCookieDomain(p.host); err != nil {
+			return originPattern{}, fmt.Errorf("origin %q: %w", raw, err)
+		}
+	}
+
+	if p.host == "" || strings.Contains(p.host, "*") {
+		return originPattern{}, fmt.Errorf("origin %q: wildcards are only allowed as the leftmost label", raw)
+	}
+
+	return p, nil
+}
+
+// Allowed reports whether a request Origin header value is trusted
+func (op *OriginPolicy) Allowed(origin string) bool {
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Host == "" || u.Path != "" || u.User != nil {
+		return false
+	}
+
+	host, port := splitOriginHost(u.Host)
+
+	if op.config.AllowLocalhost && isLocalhost(host) && (u.Scheme == "http" || u.Scheme == "https") {
+		return true
+	}
+
+	if !slices.Contains(op.config.Schemes, u.Scheme) {
+		return false
+	}
+
+	for _, p := range op.patterns {
+		if p.matches(u.Scheme, host, port) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p originPattern) matches(scheme, host, port string) bool {
+	if scheme != p.scheme || port != p.port {
+		return false
+	}
+	if p.wildcard {
+		return strings.HasSuffix(host, "."+p.host)
+	}
+	return host == p.host
+}
+
+// splitOriginHost separates host and port, dropping ports that are the scheme default
+func splitOriginHost(hostPort string) (string, string) {
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return strings.Trim(hostPort, "[]"), ""
+	}
+	if port == "443" || port == "80" {
+		port = ""
+	}
+	return host, port
+}
+
+func isLocalhost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ValidateCookieDomain rejects domains that are public suffixes, such as "com", "co.uk", or
+// "github.io". Setting a cookie or trusting a wildcard on such a domain would share it with
+// sites owned by anyone.
+func ValidateCookieDomain(domain string) error {
+	domain = strings.TrimPrefix(strings.ToLower(domain), ".")
+	if domain == "" {
+		return fmt.Errorf("domain cannot be empty")
+	}
+	if net.ParseIP(domain) != nil {
+		return nil
+	}
+
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	if suffix == domain {
+		return fmt.Errorf("%s is a public suffix", domain)
+	}
+
+	return nil
+}
+
+// CORSMiddleware returns CORS middleware that only trusts origins allowed by the policy
+func (b *Base) CORSMiddleware(policy *OriginPolicy) func(next http.Handler) http.Handler {
+	log.Printf("### 🎭 API: configured CORS with %d allowed origin pattern(s)", len(policy.patterns))
+
+	c := cors.New(cors.Options{
+		AllowOriginFunc: func(r *http.Request, origin string) bool {
+			return policy.Allowed(origin)
+		},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowCredentials: true,
+		MaxAge:           300,
+	})
+
+	return c.Handler
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewOriginPolicyRejectsUnsafePatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr bool
+	}{
+		{"exact", "https://app.example.com", false},
+		{"no scheme", "app.example.com", false},
+		{"wildcard", "https://*.example.com", false},
+		{"wildcard under multi-label suffix", "*.example.co.uk", false},
+		{"wildcard on tld", "*.com", true},
+		{"wildcard on multi-label suffix", "*.co.uk", true},
+		{"wildcard on private suffix", "*.github.io", true},
+		{"insecure scheme", "http://app.example.com", true},
+		{"path", "https://app.example.com/login", true},
+		{"inner wildcard", "https://app.*.example.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewOriginPolicy([]string{tt.pattern})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewOriginPolicy(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOriginPolicyAllowed(t *testing.T) {
+	policy, err := NewOriginPolicy([]string{
+		"https://app.example.com",
+		"https://*.example.org",
+		"https://admin.example.net:8443",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.example.com", true},
+		{"https://APP.example.com", true},
+		{"https://app.example.com:443", true},
+		{"http://app.example.com", false},
+		{"https://evil.example.com", false},
+		{"https://app.example.com.evil.com", false},
+		{"https://a.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://evilexample.org", false},
+		{"https://admin.example.net:8443", true},
+		{"https://admin.example.net", false},
+		{"http://localhost:3000", false},
+		{"null", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if got := policy.Allowed(tt.origin); got != tt.allowed {
+				t.Errorf("Allowed(%q) = %v, want %v", tt.origin, got, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestOriginPolicyOptions(t *testing.T) {
+	policy, err := NewOriginPolicy([]string{"http://app.internal"},
+		WithOriginSchemes("https", "http"), WithLocalhostOrigins(true))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, origin := range []string{"http://app.internal", "http://localhost:3000", "http://127.0.0.1:8080"} {
+		if !policy.Allowed(origin) {
+			t.Errorf("Expected %s to be allowed", origin)
+		}
+	}
+
+	if _, err := NewOriginPolicy(nil, WithOriginSchemes()); err == nil {
+		t.Error("Expected error when no schemes are allowed")
+	}
+}
+
+func TestValidateCookieDomain(t *testing.T) {
+	tests := []struct {
+		domain  string
+		wantErr bool
+	}{
+		{"example.com", false},
+		{".example.com", false},
+		{"app.example.co.uk", false},
+		{"com", true},
+		{"co.uk", true},
+		{"github.io", true},
+		{"127.0.0.1", false},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			if err := ValidateCookieDomain(tt.domain); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCookieDomain(%q) error = %v, wantErr %v", tt.domain, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCORSMiddlewareUsesPolicy(t *testing.T) {
+	policy, err := NewOriginPolicy([]string{"https://*.example.com"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b := NewBase("test", "1.0", "", true)
+	handler := b.CORSMiddleware(policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		origin string
+		want   string
+	}{
+		{"https://app.example.com", "https://app.example.com"},
+		{"https://evil.com", ""},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Origin", tt.origin)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+			t.Errorf("Origin %s: expected Access-Control-Allow-Origin %q, got %q", tt.origin, tt.want, got)
+		}
+	}
+}
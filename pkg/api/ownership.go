@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// httpErrorsTotal counts error responses by route and owning team, exposed by AddMetricsEndpoint
+var httpErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_errors_total",
+	Help: "Total number of HTTP error responses by route, status code, and owning team",
+}, []string{"route", "code", "team"})
+
+const ownershipContextKey contextKey = "ownership"
+
+// Ownership identifies who is responsible for a route, so alerts reach the right people
+type Ownership struct {
+	Team    string `json:"team"`
+	Owner   string `json:"owner,omitempty"`
+	OnCall  string `json:"onCall,omitempty"`
+	Runbook string `json:"runbook,omitempty"`
+}
+
+// ownershipSlot carries the owner of the matched route back out to the error metrics middleware
+type ownershipSlot struct {
+	ownership atomic.Pointer[Ownership]
+}
+
+// ownedHandler is the handler Owner returns, which lets route introspection find a route's owner
+type ownedHandler struct {
+	ownership Ownership
+	next      http.Handler
+}
+
+func (h *ownedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if slot, ok := r.Context().Value(ownershipContextKey).(*ownershipSlot); ok {
+		slot.ownership.Store(&h.ownership)
+	} else {
+		slot := &ownershipSlot{}
+		slot.ownership.Store(&h.ownership)
+		r = r.WithContext(context.WithValue(r.Context(), ownershipContextKey, slot))
+	}
+
+	h.next.ServeHTTP(w, r)
+}
+
+// Owner creates middleware that marks routes or route groups as owned by a team. The owner is listed
+// by the routes endpoint, labels the http_errors_total metric, and is available to handlers from
+// OwnershipFromContext. When groups are nested the innermost owner applies.
+func (b *Base) Owner(ownership Ownership) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return &ownedHandler{ownership: ownership, next: next}
+	}
+}
+
+// OwnershipFromContext returns the owner of the route serving a request
+func OwnershipFromContext(ctx context.Context) (Ownership, bool) {
+	slot, ok := ctx.Value(ownershipContextKey).(*ownershipSlot)
+	if !ok {
+		return Ownership{}, false
+	}
+	if ownership := slot.ownership.Load(); ownership != nil {
+		return *ownership, true
+	}
+	return Ownership{}, false
+}
+
+// countErrors increments http_errors_total for responses of 400 and above, labelled with the owning
+// team, and counts every response for RuntimeStats
+func (b *Base) countErrors(next http.Handler) http.Handler {
+	b.initOnce.Do(b.init)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slot := &ownershipSlot{}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), ownershipContextKey, slot)))
+		b.stats.record(ww.Status())
+
+		if ww.Status() < http.StatusBadRequest {
+			return
+		}
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		team := ""
+		if ownership := slot.ownership.Load(); ownership != nil {
+			team = ownership.Team
+		}
+		httpErrorsTotal.WithLabelValues(route, strconv.Itoa(ww.Status()), team).Inc()
+	})
+}
+
+// RouteInfo describes a route registered on a router
+type RouteInfo struct {
+	Method  string     `json:"method"`
+	Pattern string     `json:"pattern"`
+	Class   RouteClass `json:"class"`
+	Owner   *Ownership `json:"owner,omitempty"`
+	// Middleware names the middleware applied to the route, outermost first, by the function that
+	// created it, e.g. "middleware.RequestID" or "api.(*Base).Recoverer"
+	Middleware []string `json:"middleware,omitempty"`
+}
+
+// closureSuffix matches the suffixes the compiler gives closures, inlined or not, and method values
+var closureSuffix = regexp.MustCompile(`(\.func\d+|\.\d+)+$|-fm$`)
+
+// middlewareName names a middleware function by its package and function, without the import path
+func middlewareName(mw func(http.Handler) http.Handler) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(mw).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+	name := fn.Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	return closureSuffix.ReplaceAllString(name, "")
+}
+
+// ownershipProbe is passed to a route's middleware to find the one added by Owner
+var ownershipProbe = http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+// RouteTable lists the routes registered on router with their class, owner, and middleware, sorted by pattern.
+// Finding the owner applies each route's middleware to a probe handler, which is harmless for
+// middleware that only does work when serving a request.
+func (b *Base) RouteTable(router chi.Routes) ([]RouteInfo, error) {
+	var routes []RouteInfo
+
+	err := chi.Walk(router, func(method, pattern string, _ http.Handler,
+		middlewares ...func(http.Handler) http.Handler) error {
+		info := RouteInfo{Method: method, Pattern: pattern, Class: b.Routes().Classify(pattern)}
+		for _, mw := range middlewares {
+			info.Middleware = append(info.Middleware, middlewareName(mw))
+			if owned, ok := mw(ownershipProbe).(*ownedHandler); ok {
+				info.Owner = &owned.ownership
+			}
+		}
+		routes = append(routes, info)
+		return nil
+	})
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	return routes, err
+}
+
+// AddRoutesEndpoint serves the route table of router as JSON. Pass the root router, and mount the
+// endpoint behind authentication if the route list shouldn't be public.
+func (b *Base) AddRoutesEndpoint(r chi.Router, path string) {
+	log.Printf("### 🗺️ API: routes endpoint at: %s", "/"+path)
+
+	r.Get("/"+path, func(w http.ResponseWriter, req *http.Request) {
+		routes, err := b.RouteTable(r)
+		if err != nil {
+			b.HandleError(w, req, err)
+			return
+		}
+
+		b.ReturnJSON(w, routes)
+	})
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func errorCount(t *testing.T, route, code, team string) float64 {
+	t.Helper()
+
+	var metric dto.Metric
+	if err := httpErrorsTotal.WithLabelValues(route, code, team).Write(&metric); err != nil {
+		t.Fatalf("Failed to read metric: %v", err)
+	}
+	return metric.GetCounter().GetValue()
+}
+
+func newOwnedRouter(t *testing.T, b *Base) chi.Router {
+	payments := Ownership{Team: "payments", OnCall: "payments-primary", Runbook: "https://runbooks/payments"}
+
+	router := chi.NewRouter()
+	b.AddMetricsEndpoint(router, "metrics")
+	router.Group(func(r chi.Router) {
+		r.Use(b.Owner(payments))
+		r.Get("/payments/{id}", func(w http.ResponseWriter, r *http.Request) {
+			ownership, ok := OwnershipFromContext(r.Context())
+			if !ok || ownership.Team != "payments" {
+				t.Errorf("Expected payments ownership in context, got %+v", ownership)
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		})
+		r.With(b.Owner(Ownership{Team: "refunds"})).Post("/payments/{id}/refund", func(w http.ResponseWriter,
+			r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+	})
+	router.Get("/version", func(w http.ResponseWriter, r *http.Request) {})
+	b.AddRoutesEndpoint(router, "routes")
+
+	return router
+}
+
+func TestOwnershipErrorMetrics(t *testing.T) {
+	router := newOwnedRouter(t, NewBase("test", "1.0", "", true))
+
+	before := errorCount(t, "/payments/{id}", "502", "payments")
+	beforeNested := errorCount(t, "/payments/{id}/refund", "500", "refunds")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/payments/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/payments/1/refund", nil))
+
+	if got := errorCount(t, "/payments/{id}", "502", "payments") - before; got != 1 {
+		t.Errorf("Expected one error labelled with the owning team, got %v", got)
+	}
+	if got := errorCount(t, "/payments/{id}/refund", "500", "refunds") - beforeNested; got != 1 {
+		t.Errorf("Expected the innermost owner to apply, got %v", got)
+	}
+}
+
+func TestAddRoutesEndpoint(t *testing.T) {
+	router := newOwnedRouter(t, NewBase("test", "1.0", "", true))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/routes", nil))
+
+	var routes []RouteInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &routes); err != nil {
+		t.Fatalf("Failed to decode routes: %v", err)
+	}
+
+	byRoute := make(map[string]RouteInfo)
+	for _, route := range routes {
+		byRoute[route.Method+" "+route.Pattern] = route
+	}
+
+	tests := []struct {
+		route     string
+		wantTeam  string
+		wantClass RouteClass
+	}{
+		{"GET /payments/{id}", "payments", RouteClassAPI},
+		{"POST /payments/{id}/refund", "refunds", RouteClassAPI},
+		{"GET /version", "", RouteClassAPI},
+		{"GET /metrics", "", RouteClassInfrastructure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.route, func(t *testing.T) {
+			route, ok := byRoute[tt.route]
+			if !ok {
+				t.Fatalf("Expected %s to be listed, got %+v", tt.route, routes)
+			}
+			if route.Class != tt.wantClass {
+				t.Errorf("Expected class %s, got %s", tt.wantClass, route.Class)
+			}
+			team := ""
+			if route.Owner != nil {
+				team = route.Owner.Team
+			}
+			if team != tt.wantTeam {
+				t.Errorf("Expected team %q, got %q", tt.wantTeam, team)
+			}
+		})
+	}
+
+	if owner := byRoute["GET /payments/{id}"].Owner; owner == nil || owner.OnCall != "payments-primary" {
+		t.Errorf("Expected on-call metadata to be listed, got %+v", owner)
+	}
+
+	middleware := byRoute["POST /payments/{id}/refund"].Middleware
+	want := []string{"api.(*Base).SkipInfrastructure", "api.(*Base).SkipInfrastructure",
+		"api.(*Base).SkipInfrastructure", "api.(*Base).Owner", "api.(*Base).Owner"}
+	if !slices.Equal(middleware, want) {
+		t.Errorf("Expected middleware %v, got %v", want, middleware)
+	}
+}
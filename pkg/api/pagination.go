@@ -0,0 +1,228 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// PageConfig holds bounds for pagination parameters
+type PageConfig struct {
+	DefaultLimit int
+	MaxLimit     int
+	// MaxOffset bounds offset pagination, which gets slower the deeper it goes
+	MaxOffset int
+}
+
+// DefaultPageConfig provides sensible defaults
+func DefaultPageConfig() *PageConfig {
+	return &PageConfig{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+		MaxOffset:    10000,
+	}
+}
+
+// PageOption is a functional option for configuring pagination
+type PageOption func(*PageConfig)
+
+// WithDefaultLimit sets the page size used when limit is not given
+func WithDefaultLimit(limit int) PageOption {
+	return func(config *PageConfig) {
+		config.DefaultLimit = limit
+	}
+}
+
+// WithMaxLimit sets the largest page size a client may request
+func WithMaxLimit(limit int) PageOption {
+	return func(config *PageConfig) {
+		config.MaxLimit = limit
+	}
+}
+
+// WithMaxOffset sets the largest offset a client may request
+func WithMaxOffset(offset int) PageOption {
+	return func(config *PageConfig) {
+		config.MaxOffset = offset
+	}
+}
+
+// NewPageConfig creates a new page config with options
+func NewPageConfig(options ...PageOption) *PageConfig {
+	config := DefaultPageConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// PageParams are the pagination parameters of a list request
+type PageParams struct {
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// ParsePage reads limit, offset, and cursor query parameters within the configured bounds.
+// Offset and cursor cannot be combined. Invalid values are returned as a *QueryError.
+func ParsePage(r *http.Request, options ...PageOption) (PageParams, error) {
+	config := NewPageConfig(options...)
+	query := r.URL.Query()
+	params := PageParams{Limit: config.DefaultLimit, Cursor: query.Get("cursor")}
+	queryErr := &QueryError{}
+
+	addErr := func(field, format string, args ...interface{}) {
+		queryErr.Errors = append(queryErr.Errors, problem.FieldError{
+			Field: field, Code: problem.CodeInvalid, Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > config.MaxLimit {
+			addErr("limit", "must be an integer between 1 and %d", config.MaxLimit)
+		}
+		params.Limit = limit
+	}
+
+	if raw := query.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 || offset > config.MaxOffset {
+			addErr("offset", "must be an integer between 0 and %d", config.MaxOffset)
+		}
+		params.Offset = offset
+	}
+
+	if params.Cursor != "" && query.Get("offset") != "" {
+		addErr("cursor", "cannot be combined with offset")
+	}
+
+	if len(queryErr.Errors) > 0 {
+		return PageParams{}, queryErr
+	}
+
+	return params, nil
+}
+
+// PageResult is the envelope for a page of list results. When returned with ReturnJSON,
+// the navigation links are also sent in a Link header.
+type PageResult[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset,omitempty"`
+
+	links string
+}
+
+// LinkHeader returns the value of the Link header for the page
+func (p *PageResult[T]) LinkHeader() string {
+	return p.links
+}
+
+// linker is implemented by response bodies that carry a Link header
+type linker interface {
+	LinkHeader() string
+}
+
+// NewOffsetPage builds a page for offset pagination, linking first, prev, next, and last pages
+func NewOffsetPage[T any](r *http.Request, items []T, params PageParams, total int64) *PageResult[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	page := &PageResult[T]{Items: items, Total: &total, Limit: params.Limit, Offset: params.Offset}
+
+	var links []string
+	addLink := func(rel string, offset int) {
+		links = append(links, pageLink(r, rel, map[string]string{
+			"limit": strconv.Itoa(params.Limit), "offset": strconv.Itoa(offset), "cursor": "",
+		}))
+	}
+
+	addLink("first", 0)
+	if params.Offset > 0 {
+		addLink("prev", max(params.Offset-params.Limit, 0))
+	}
+	if int64(params.Offset+params.Limit) < total {
+		addLink("next", params.Offset+params.Limit)
+	}
+	if params.Limit > 0 && total > 0 {
+		addLink("last", int((total-1)/int64(params.Limit))*params.Limit)
+	}
+	page.links = strings.Join(links, ", ")
+
+	return page
+}
+
+// NewCursorPage builds a page for cursor (keyset) pagination. Query limit+1 rows: if more than
+// limit items are given, the extra item is dropped and cursorFor(last item) becomes the next cursor.
+// A limit below 1 is treated as 1.
+func NewCursorPage[T any](r *http.Request, items []T, limit int, cursorFor func(last T) string) *PageResult[T] {
+	if items == nil {
+		items = []T{}
+	}
+	limit = max(limit, 1)
+
+	page := &PageResult[T]{Items: items, Limit: limit}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = cursorFor(page.Items[limit-1])
+		page.links = pageLink(r, "next", map[string]string{
+			"limit": strconv.Itoa(limit), "cursor": page.NextCursor, "offset": "",
+		})
+	}
+
+	return page
+}
+
+// pageLink builds a Link header entry from the request URL with the given query overrides
+func pageLink(r *http.Request, rel string, overrides map[string]string) string {
+	u := url.URL{Path: r.URL.Path}
+	query := r.URL.Query()
+	for key, value := range overrides {
+		if value == "" {
+			query.Del(key)
+		} else {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+}
+
+// EncodeCursor encodes keyset values, such as the sort columns of the last row, as an opaque cursor
+func EncodeCursor(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// invalidCursor reports a cursor that could not be decoded
+func invalidCursor() *QueryError {
+	return &QueryError{Errors: []problem.FieldError{
+		{Field: "cursor", Code: problem.CodeInvalid, Message: "is not a valid cursor"},
+	}}
+}
+
+// DecodeCursor decodes a cursor produced by EncodeCursor into v
+func DecodeCursor(cursor string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return invalidCursor()
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return invalidCursor()
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    PageParams
+		wantErr bool
+	}{
+		{"defaults", "", PageParams{Limit: 20}, false},
+		{"limit and offset", "limit=50&offset=100", PageParams{Limit: 50, Offset: 100}, false},
+		{"cursor", "cursor=abc&limit=10", PageParams{Limit: 10, Cursor: "abc"}, false},
+		{"limit too large", "limit=1000", PageParams{}, true},
+		{"limit zero", "limit=0", PageParams{}, true},
+		{"negative offset", "offset=-1", PageParams{}, true},
+		{"offset too deep", "offset=20000", PageParams{}, true},
+		{"not a number", "limit=ten", PageParams{}, true},
+		{"cursor with offset", "cursor=abc&offset=10", PageParams{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/items?"+tt.query, nil)
+			got, err := ParsePage(r)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var queryErr *QueryError
+				if !errors.As(err, &queryErr) {
+					t.Errorf("Expected *QueryError, got %T", err)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParsePage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/items?limit=500", nil)
+	if _, err := ParsePage(r, WithMaxLimit(500)); err != nil {
+		t.Errorf("Expected custom max limit to be honoured, got %v", err)
+	}
+}
+
+func TestNewOffsetPage(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/items?limit=10&offset=20&sort=name", nil)
+	page := NewOffsetPage(r, []string{"a", "b"}, PageParams{Limit: 10, Offset: 20}, 45)
+
+	links := page.LinkHeader()
+	for _, want := range []string{
+		`</items?limit=10&offset=0&sort=name>; rel="first"`,
+		`</items?limit=10&offset=10&sort=name>; rel="prev"`,
+		`</items?limit=10&offset=30&sort=name>; rel="next"`,
+		`</items?limit=10&offset=40&sort=name>; rel="last"`,
+	} {
+		if !strings.Contains(links, want) {
+			t.Errorf("Expected Link header to contain %s, got %s", want, links)
+		}
+	}
+
+	last := NewOffsetPage(r, []string{"a"}, PageParams{Limit: 10, Offset: 40}, 45)
+	if strings.Contains(last.LinkHeader(), `rel="next"`) {
+		t.Errorf("Expected no next link on the last page, got %s", last.LinkHeader())
+	}
+}
+
+func TestNewCursorPage(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/items?limit=2", nil)
+	cursorFor := func(last int) string { return strconv.Itoa(last) }
+
+	page := NewCursorPage(r, []int{1, 2, 3}, 2, cursorFor)
+	if len(page.Items) != 2 || page.NextCursor != "2" {
+		t.Errorf("Expected 2 items and next cursor 2, got %v and %q", page.Items, page.NextCursor)
+	}
+	if page.LinkHeader() != `</items?cursor=2&limit=2>; rel="next"` {
+		t.Errorf("Unexpected Link header: %s", page.LinkHeader())
+	}
+
+	final := NewCursorPage(r, []int{1}, 2, cursorFor)
+	if final.NextCursor != "" || final.LinkHeader() != "" {
+		t.Errorf("Expected no next cursor on the final page, got %q", final.NextCursor)
+	}
+
+	empty := NewCursorPage[int](r, nil, 2, cursorFor)
+	data, _ := json.Marshal(empty)
+	if string(data) != `{"items":[],"limit":2}` {
+		t.Errorf("Unexpected JSON for empty page: %s", data)
+	}
+
+	for _, limit := range []int{0, -1} {
+		clamped := NewCursorPage(r, []int{1, 2}, limit, cursorFor)
+		if clamped.Limit != 1 || len(clamped.Items) != 1 || clamped.NextCursor != "1" {
+			t.Errorf("Expected limit %d to be clamped to 1, got %+v", limit, clamped)
+		}
+	}
+}
+
+func TestReturnJSONSetsPageLinks(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	r := httptest.NewRequest(http.MethodGet, "/items", nil)
+	w := httptest.NewRecorder()
+
+	b.ReturnJSON(w, NewOffsetPage(r, []string{"a"}, PageParams{Limit: 1}, 3))
+
+	if !strings.Contains(w.Header().Get("Link"), `rel="next"`) {
+		t.Errorf("Expected Link header, got %q", w.Header().Get("Link"))
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if body["total"] != float64(3) {
+		t.Errorf("Expected total of 3, got %v", body["total"])
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	type key struct {
+		CreatedAt string `json:"createdAt"`
+		ID        int    `json:"id"`
+	}
+
+	cursor, err := EncodeCursor(key{CreatedAt: "2024-01-01T00:00:00Z", ID: 7})
+	if err != nil {
+		t.Fatalf("EncodeCursor() error = %v", err)
+	}
+
+	var got key
+	if err := DecodeCursor(cursor, &got); err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if got.ID != 7 || got.CreatedAt != "2024-01-01T00:00:00Z" {
+		t.Errorf("Unexpected decoded cursor: %+v", got)
+	}
+
+	var queryErr *QueryError
+	if err := DecodeCursor("!!!", &got); !errors.As(err, &queryErr) {
+		t.Errorf("Expected *QueryError for invalid cursor, got %v", err)
+	}
+}
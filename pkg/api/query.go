@@ -0,0 +1,263 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// QueryError aggregates every query parameter that failed to bind or validate
+type QueryError struct {
+	Errors []problem.FieldError
+}
+
+func (e *QueryError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		messages = append(messages, fe.Field+": "+fe.Message)
+	}
+	return "invalid query parameters: " + strings.Join(messages, "; ")
+}
+
+// Problem converts the error into a 400 problem+json response listing each invalid parameter
+func (e *QueryError) Problem(instance string) *problem.Problem {
+	p := problem.New("invalid-query", "Invalid query parameters", http.StatusBadRequest,
+		fmt.Sprintf("%d query parameter(s) are invalid", len(e.Errors)), instance)
+	p.Errors = e.Errors
+	return p
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// ParseQuery binds URL query parameters onto the struct pointed to by dst using field tags:
+//
+//	query:"name"       parameter name (fields without the tag are ignored)
+//	default:"value"    used when the parameter is absent
+//	required:"true"    the parameter must be present
+//	min:"n" max:"n"    inclusive numeric range
+//	enum:"a|b|c"       allowed values
+//
+// Supported field types are strings, bools, ints, uints, floats, time.Time (RFC 3339 or
+// 2006-01-02), time.Duration, pointers to these, and slices of these. Slices accept repeated
+// parameters or comma-separated values. All failures are collected into a *QueryError.
+func ParseQuery(r *http.Request, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ParseQuery requires a pointer to a struct, got %T", dst)
+	}
+
+	values := r.URL.Query()
+	elem := rv.Elem()
+	queryErr := &QueryError{}
+
+	for i := 0; i < elem.NumField(); i++ {
+		field := elem.Type().Field(i)
+		name := field.Tag.Get("query")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		if err := bindQueryField(elem.Field(i), field, name, values); err != nil {
+			queryErr.Errors = append(queryErr.Errors, problem.FieldError{
+				Field: name, Code: problem.CodeInvalid, Message: err.Error(),
+			})
+		}
+	}
+
+	if len(queryErr.Errors) > 0 {
+		return queryErr
+	}
+
+	return nil
+}
+
+// bindQueryField resolves the raw values for a field, applying defaults and the required check
+func bindQueryField(fv reflect.Value, field reflect.StructField, name string, values url.Values) error {
+	raw, present := values[name]
+	if !present || len(raw) == 0 {
+		if def, ok := field.Tag.Lookup("default"); ok {
+			raw = []string{def}
+		} else if field.Tag.Get("required") == "true" {
+			return fmt.Errorf("is required")
+		} else {
+			return nil
+		}
+	}
+
+	if fv.Kind() == reflect.Slice {
+		return bindQuerySlice(fv, field, splitQueryValues(raw))
+	}
+
+	return setQueryValue(fv, field, raw[len(raw)-1])
+}
+
+// bindQuerySlice converts every value and assigns the resulting slice
+func bindQuerySlice(fv reflect.Value, field reflect.StructField, raw []string) error {
+	slice := reflect.MakeSlice(fv.Type(), len(raw), len(raw))
+	for i, s := range raw {
+		if err := setQueryValue(slice.Index(i), field, s); err != nil {
+			return fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	fv.Set(slice)
+	return nil
+}
+
+// splitQueryValues flattens repeated and comma-separated values
+func splitQueryValues(raw []string) []string {
+	var out []string
+	for _, r := range raw {
+		for _, part := range strings.Split(r, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// setQueryValue converts a single value, validates it, and stores it in fv
+func setQueryValue(fv reflect.Value, field reflect.StructField, s string) error {
+	if fv.Kind() == reflect.Ptr {
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setQueryValue(ptr.Elem(), field, s); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	}
+
+	if enum := field.Tag.Get("enum"); enum != "" && !slices.Contains(strings.Split(enum, "|"), s) {
+		return fmt.Errorf("must be one of %s", strings.ReplaceAll(enum, "|", ", "))
+	}
+
+	if err := convertQueryValue(fv, s); err != nil {
+		return err
+	}
+
+	return checkQueryRange(fv, field)
+}
+
+// convertQueryValue parses s according to the type of fv
+func convertQueryValue(fv reflect.Value, s string) error {
+	switch fv.Type() {
+	case timeType:
+		t, err := parseQueryTime(s)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("must be a duration such as 30s or 5m")
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+		return nil
+	case reflect.Bool:
+		return setQueryBool(fv, s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return setQueryInt(fv, s)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return setQueryUint(fv, s)
+	case reflect.Float32, reflect.Float64:
+		return setQueryFloat(fv, s)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+}
+
+func setQueryBool(fv reflect.Value, s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("must be a boolean")
+	}
+	fv.SetBool(b)
+	return nil
+}
+
+func setQueryInt(fv reflect.Value, s string) error {
+	n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+	if err != nil {
+		return fmt.Errorf("must be an integer")
+	}
+	fv.SetInt(n)
+	return nil
+}
+
+func setQueryUint(fv reflect.Value, s string) error {
+	n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+	if err != nil {
+		return fmt.Errorf("must be a non-negative integer")
+	}
+	fv.SetUint(n)
+	return nil
+}
+
+func setQueryFloat(fv reflect.Value, s string) error {
+	f, err := strconv.ParseFloat(s, fv.Type().Bits())
+	if err != nil {
+		return fmt.Errorf("must be a number")
+	}
+	fv.SetFloat(f)
+	return nil
+}
+
+// parseQueryTime accepts RFC 3339 timestamps and plain dates
+func parseQueryTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("must be an RFC 3339 timestamp or a date (YYYY-MM-DD)")
+}
+
+// checkQueryRange enforces the min and max tags on numeric values
+func checkQueryRange(fv reflect.Value, field reflect.StructField) error {
+	var n float64
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if fv.Type() == durationType {
+			return nil
+		}
+		n = float64(fv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(fv.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = fv.Float()
+	default:
+		return nil
+	}
+
+	if minTag := field.Tag.Get("min"); minTag != "" {
+		if limit, err := strconv.ParseFloat(minTag, 64); err == nil && n < limit {
+			return fmt.Errorf("must be at least %s", minTag)
+		}
+	}
+	if maxTag := field.Tag.Get("max"); maxTag != "" {
+		if limit, err := strconv.ParseFloat(maxTag, 64); err == nil && n > limit {
+			return fmt.Errorf("must be at most %s", maxTag)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type listParams struct {
+	Limit   int           `query:"limit" default:"20" min:"1" max:"100"`
+	Sort    string        `query:"sort" default:"asc" enum:"asc|desc"`
+	Query   string        `query:"q" required:"true"`
+	Active  *bool         `query:"active"`
+	Since   time.Time     `query:"since"`
+	Timeout time.Duration `query:"timeout"`
+	Tags    []string      `query:"tag"`
+	IDs     []uint        `query:"id" max:"1000"`
+	Score   float64       `query:"score" min:"0" max:"1"`
+	Ignored string
+}
+
+func TestParseQuery(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet,
+		"/items?q=shoes&limit=50&sort=desc&active=true&since=2024-01-02&timeout=5s&tag=a,b&tag=c&id=1&id=2&score=0.5",
+		nil)
+
+	var params listParams
+	if err := ParseQuery(r, &params); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if params.Query != "shoes" || params.Limit != 50 || params.Sort != "desc" {
+		t.Errorf("Unexpected scalar values: %+v", params)
+	}
+	if params.Active == nil || !*params.Active {
+		t.Error("Expected active to be bound to true")
+	}
+	if !params.Since.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected since: %v", params.Since)
+	}
+	if params.Timeout != 5*time.Second {
+		t.Errorf("Expected timeout 5s, got %v", params.Timeout)
+	}
+	if len(params.Tags) != 3 || params.Tags[2] != "c" {
+		t.Errorf("Expected tags [a b c], got %v", params.Tags)
+	}
+	if len(params.IDs) != 2 || params.IDs[1] != 2 {
+		t.Errorf("Expected ids [1 2], got %v", params.IDs)
+	}
+	if params.Score != 0.5 {
+		t.Errorf("Expected score 0.5, got %v", params.Score)
+	}
+}
+
+func TestParseQueryDefaults(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/items?q=x", nil)
+
+	var params listParams
+	if err := ParseQuery(r, &params); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if params.Limit != 20 || params.Sort != "asc" {
+		t.Errorf("Expected defaults limit=20 sort=asc, got limit=%d sort=%s", params.Limit, params.Sort)
+	}
+	if params.Active != nil {
+		t.Error("Expected absent pointer parameter to stay nil")
+	}
+}
+
+func TestParseQueryAggregatesErrors(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet,
+		"/items?limit=500&sort=sideways&active=maybe&since=yesterday&id=1&id=x&score=2", nil)
+
+	var params listParams
+	err := ParseQuery(r, &params)
+
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) {
+		t.Fatalf("Expected QueryError, got %v", err)
+	}
+
+	expected := map[string]string{
+		"limit":  "must be at most 100",
+		"sort":   "must be one of asc, desc",
+		"q":      "is required",
+		"active": "must be a boolean",
+		"since":  "must be an RFC 3339 timestamp or a date (YYYY-MM-DD)",
+		"id":     "item 1: must be a non-negative integer",
+		"score":  "must be at most 1",
+	}
+	if len(queryErr.Errors) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), queryErr.Errors)
+	}
+	for _, fe := range queryErr.Errors {
+		if expected[fe.Field] != fe.Message {
+			t.Errorf("Field %s: expected %q, got %q", fe.Field, expected[fe.Field], fe.Message)
+		}
+	}
+}
+
+func TestQueryErrorProblem(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/items?limit=0", nil)
+
+	var params listParams
+	err := ParseQuery(r, &params)
+
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) {
+		t.Fatalf("Expected QueryError, got %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	queryErr.Problem(r.URL.Path).Send(w)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Expected problem+json content type, got %s", ct)
+	}
+
+	var body struct {
+		Errors []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	if len(body.Errors) != 2 {
+		t.Errorf("Expected 2 field errors (limit, q), got %+v", body.Errors)
+	}
+}
+
+func TestParseQueryInvalidDestination(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	var notStruct int
+	if err := ParseQuery(r, &notStruct); err == nil {
+		t.Error("Expected error for non-struct destination")
+	}
+	if err := ParseQuery(r, listParams{}); err == nil {
+		t.Error("Expected error for non-pointer destination")
+	}
+}
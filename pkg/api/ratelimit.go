@@ -0,0 +1,296 @@
+package api
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Algorithm selects how a rate limiter counts a client's requests
+type Algorithm string
+
+const (
+	// TokenBucket refills Burst tokens at RequestsPerSecond, allowing short bursts; the default
+	TokenBucket Algorithm = "tokenBucket"
+	// FixedWindow counts requests in consecutive windows, resetting at each boundary. It is the
+	// cheapest, but a client can send up to twice the limit across a boundary.
+	FixedWindow Algorithm = "fixedWindow"
+	// SlidingWindowLog keeps a timestamp per request and counts those within the last Window. It is
+	// exact, at the cost of memory proportional to the limit.
+	SlidingWindowLog Algorithm = "slidingWindowLog"
+	// SlidingWindowCounter weights the previous window's count by how much of it still overlaps the
+	// last Window, approximating a sliding window with two counters
+	SlidingWindowCounter Algorithm = "slidingWindowCounter"
+)
+
+// keyLimiter limits one client's requests. Implementations are safe for concurrent use.
+type keyLimiter interface {
+	// allow records a request at now and reports whether it is within the limit
+	allow(now time.Time) bool
+	// limit returns the number of requests allowed at once
+	limit() int
+	// remaining returns how many more requests are allowed at now
+	remaining(now time.Time) int
+	// reset returns when the client's full allowance is restored
+	reset(now time.Time) time.Time
+	// setRate changes the limit in place, keeping the requests already counted
+	setRate(rps float64, burst int)
+}
+
+// newKeyLimiter creates a limiter for one client with the configured algorithm
+func newKeyLimiter(config *RateLimiterConfig, rps float64, burst int, now time.Time) keyLimiter {
+	window := config.Window
+	if window <= 0 {
+		window = DefaultRateLimiterConfig().Window
+	}
+
+	switch config.Algorithm {
+	case FixedWindow:
+		return &fixedWindow{max: windowLimit(rps, window), window: window, start: now}
+	case SlidingWindowLog:
+		return &slidingWindowLog{max: windowLimit(rps, window), window: window}
+	case SlidingWindowCounter:
+		return &slidingWindowCounter{max: windowLimit(rps, window), window: window, start: now}
+	default:
+		return &tokenBucket{limiter: rate.NewLimiter(rate.Limit(rps), burst)}
+	}
+}
+
+// windowLimit is how many requests a window allows at rps, so the average rate is the same for
+// every algorithm
+func windowLimit(rps float64, window time.Duration) int {
+	return max(1, int(math.Round(rps*window.Seconds())))
+}
+
+// tokenBucket is the TokenBucket algorithm
+type tokenBucket struct {
+	limiter *rate.Limiter
+}
+
+func (b *tokenBucket) allow(now time.Time) bool {
+	return b.limiter.AllowN(now, 1)
+}
+
+func (b *tokenBucket) limit() int {
+	return b.limiter.Burst()
+}
+
+func (b *tokenBucket) remaining(now time.Time) int {
+	return max(0, int(b.limiter.TokensAt(now)))
+}
+
+func (b *tokenBucket) reset(now time.Time) time.Time {
+	missing := float64(b.limiter.Burst()) - b.limiter.TokensAt(now)
+	if missing <= 0 || b.limiter.Limit() <= 0 {
+		return now
+	}
+	return now.Add(time.Duration(missing / float64(b.limiter.Limit()) * float64(time.Second)))
+}
+
+func (b *tokenBucket) setRate(rps float64, burst int) {
+	if b.limiter.Limit() != rate.Limit(rps) || b.limiter.Burst() != burst {
+		b.limiter.SetLimit(rate.Limit(rps))
+		b.limiter.SetBurst(burst)
+	}
+}
+
+// fixedWindow is the FixedWindow algorithm
+type fixedWindow struct {
+	mu     sync.Mutex
+	max    int
+	window time.Duration
+	start  time.Time
+	count  int
+}
+
+// advance moves to the window containing now; the caller holds mu
+func (w *fixedWindow) advance(now time.Time) {
+	if elapsed := now.Sub(w.start); elapsed >= w.window {
+		w.start = w.start.Add(elapsed.Truncate(w.window))
+		w.count = 0
+	}
+}
+
+func (w *fixedWindow) allow(now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.advance(now)
+	if w.count >= w.max {
+		return false
+	}
+	w.count++
+	return true
+}
+
+func (w *fixedWindow) limit() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.max
+}
+
+func (w *fixedWindow) remaining(now time.Time) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.advance(now)
+	return max(0, w.max-w.count)
+}
+
+func (w *fixedWindow) reset(now time.Time) time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.advance(now)
+	if w.count == 0 {
+		return now
+	}
+	return w.start.Add(w.window)
+}
+
+func (w *fixedWindow) setRate(rps float64, _ int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.max = windowLimit(rps, w.window)
+}
+
+// slidingWindowLog is the SlidingWindowLog algorithm
+type slidingWindowLog struct {
+	mu     sync.Mutex
+	max    int
+	window time.Duration
+	times  []time.Time // oldest first
+}
+
+// trim drops requests that have left the window; the caller holds mu
+func (l *slidingWindowLog) trim(now time.Time) {
+	cutoff := now.Add(-l.window)
+	i := 0
+	for i < len(l.times) && !l.times[i].After(cutoff) {
+		i++
+	}
+	l.times = l.times[i:]
+}
+
+func (l *slidingWindowLog) allow(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.trim(now)
+	if len(l.times) >= l.max {
+		return false
+	}
+	l.times = append(l.times, now)
+	return true
+}
+
+func (l *slidingWindowLog) limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.max
+}
+
+func (l *slidingWindowLog) remaining(now time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.trim(now)
+	return max(0, l.max-len(l.times))
+}
+
+func (l *slidingWindowLog) reset(now time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.trim(now)
+	if len(l.times) == 0 {
+		return now
+	}
+	return l.times[len(l.times)-1].Add(l.window)
+}
+
+func (l *slidingWindowLog) setRate(rps float64, _ int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.max = windowLimit(rps, l.window)
+}
+
+// slidingWindowCounter is the SlidingWindowCounter algorithm
+type slidingWindowCounter struct {
+	mu       sync.Mutex
+	max      int
+	window   time.Duration
+	start    time.Time // of the current window
+	previous int
+	current  int
+}
+
+// advance moves to the window containing now; the caller holds mu
+func (c *slidingWindowCounter) advance(now time.Time) {
+	elapsed := now.Sub(c.start)
+	if elapsed < c.window {
+		return
+	}
+	if elapsed < 2*c.window {
+		c.previous = c.current
+	} else {
+		c.previous = 0
+	}
+	c.current = 0
+	c.start = c.start.Add(elapsed.Truncate(c.window))
+}
+
+// estimate is the weighted count of requests in the window ending at now; the caller holds mu
+func (c *slidingWindowCounter) estimate(now time.Time) float64 {
+	overlap := 1 - float64(now.Sub(c.start))/float64(c.window)
+	return float64(c.previous)*overlap + float64(c.current)
+}
+
+func (c *slidingWindowCounter) allow(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(now)
+	if c.estimate(now)+1 > float64(c.max) {
+		return false
+	}
+	c.current++
+	return true
+}
+
+func (c *slidingWindowCounter) limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.max
+}
+
+func (c *slidingWindowCounter) remaining(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(now)
+	return max(0, int(float64(c.max)-c.estimate(now)))
+}
+
+func (c *slidingWindowCounter) reset(now time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(now)
+	if c.current == 0 && c.previous == 0 {
+		return now
+	}
+	if c.current == 0 {
+		// Only the previous window's requests remain, and they have left by the end of this one
+		return c.start.Add(c.window)
+	}
+	return c.start.Add(2 * c.window)
+}
+
+func (c *slidingWindowCounter) setRate(rps float64, _ int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.max = windowLimit(rps, c.window)
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyLimiterAlgorithms(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) time.Time { return start.Add(offset) }
+
+	// Each allows 10 requests per 10-second window, or a burst of 10 refilling at 1 per second
+	tests := []struct {
+		algorithm Algorithm
+		requests  []time.Duration
+		allowed   int
+	}{
+		{TokenBucket, repeat(0, 12), 10},
+		{TokenBucket, append(repeat(0, 10), 3*time.Second, 3*time.Second, 3*time.Second, 3*time.Second), 13},
+		{FixedWindow, repeat(0, 12), 10},
+		// The full limit again straight after the boundary
+		{FixedWindow, append(repeat(9*time.Second, 10), repeat(10*time.Second, 10)...), 20},
+		{SlidingWindowLog, repeat(0, 12), 10},
+		// Requests at 9s still count until 19s
+		{SlidingWindowLog, append(repeat(9*time.Second, 10), repeat(10*time.Second, 10)...), 10},
+		{SlidingWindowLog, append(repeat(9*time.Second, 10), repeat(19*time.Second+time.Millisecond, 10)...), 20},
+		{SlidingWindowCounter, repeat(0, 12), 10},
+		// Halfway into the next window, half of the previous window's 10 still count
+		{SlidingWindowCounter, append(repeat(0, 10), repeat(15*time.Second, 10)...), 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.algorithm), func(t *testing.T) {
+			config := NewRateLimiterConfig(WithAlgorithm(tt.algorithm), WithRequestsPerSecond(1), WithBurst(10),
+				WithWindow(10*time.Second))
+			limiter := newKeyLimiter(config, config.RequestsPerSecond, config.Burst, start)
+
+			allowed := 0
+			for _, offset := range tt.requests {
+				if limiter.allow(at(offset)) {
+					allowed++
+				}
+			}
+			if allowed != tt.allowed {
+				t.Errorf("Expected %d requests allowed, got %d", tt.allowed, allowed)
+			}
+			if limiter.limit() != 10 {
+				t.Errorf("Expected a limit of 10, got %d", limiter.limit())
+			}
+		})
+	}
+}
+
+func TestKeyLimiterHeaders(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		algorithm Algorithm
+		remaining int
+		reset     time.Time
+	}{
+		{TokenBucket, 9, start.Add(3 * time.Second)},
+		{FixedWindow, 7, start.Add(10 * time.Second)},
+		{SlidingWindowLog, 7, start.Add(12 * time.Second)},
+		{SlidingWindowCounter, 7, start.Add(20 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.algorithm), func(t *testing.T) {
+			config := NewRateLimiterConfig(WithAlgorithm(tt.algorithm), WithRequestsPerSecond(1), WithBurst(10),
+				WithWindow(10*time.Second))
+			limiter := newKeyLimiter(config, config.RequestsPerSecond, config.Burst, start)
+			if !limiter.reset(start).Equal(start) {
+				t.Errorf("Expected an unused limiter to be reset already, got %s", limiter.reset(start))
+			}
+
+			for i := range 3 {
+				limiter.allow(start.Add(time.Duration(i) * time.Second))
+			}
+			now := start.Add(2 * time.Second)
+
+			if got := limiter.remaining(now); got != tt.remaining {
+				t.Errorf("Expected %d remaining, got %d", tt.remaining, got)
+			}
+			if got := limiter.reset(now); !got.Equal(tt.reset) {
+				t.Errorf("Expected a reset at %s, got %s", tt.reset, got)
+			}
+		})
+	}
+}
+
+func TestKeyLimiterSetRate(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	config := NewRateLimiterConfig(WithAlgorithm(FixedWindow), WithRequestsPerSecond(1), WithWindow(10*time.Second))
+	limiter := newKeyLimiter(config, 1, 0, start)
+
+	for range 10 {
+		limiter.allow(start)
+	}
+	limiter.setRate(2, 0)
+	if limiter.limit() != 20 || limiter.remaining(start) != 10 {
+		t.Errorf("Expected the raised limit to keep the count, got limit %d with %d remaining",
+			limiter.limit(), limiter.remaining(start))
+	}
+}
+
+// repeat returns n copies of offset
+func repeat(offset time.Duration, n int) []time.Duration {
+	offsets := make([]time.Duration, n)
+	for i := range offsets {
+		offsets[i] = offset
+	}
+	return offsets
+}
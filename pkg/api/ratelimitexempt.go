@@ -0,0 +1,61 @@
+package api
+
+import (
+	"crypto/sha256"
+	"log"
+	"net/http"
+	"net/netip"
+)
+
+// rateLimitExemptions decides which requests bypass a rate limiter, checked before any bucket is used
+type rateLimitExemptions struct {
+	paths    *RouteClassifier
+	networks []netip.Prefix
+	apiKeys  map[[sha256.Size]byte]bool
+}
+
+// newRateLimitExemptions compiles the exemptions in config. Invalid networks are logged and
+// ignored, so a typo exempts nothing rather than disabling the limiter.
+func newRateLimitExemptions(config *RateLimiterConfig) *rateLimitExemptions {
+	e := &rateLimitExemptions{
+		paths:   NewRouteClassifier(config.ExemptPaths...),
+		apiKeys: make(map[[sha256.Size]byte]bool, len(config.ExemptAPIKeys)),
+	}
+	for _, raw := range config.ExemptNetworks {
+		prefix, err := parsePrefix(raw, "exempt network")
+		if err != nil {
+			log.Printf("### 🚫 Rate limit: ignoring %v", err)
+			continue
+		}
+		e.networks = append(e.networks, prefix)
+	}
+	for _, key := range config.ExemptAPIKeys {
+		e.apiKeys[sha256.Sum256([]byte(key))] = true
+	}
+	return e
+}
+
+// exempt reports whether a request bypasses the limiter
+func (e *rateLimitExemptions) exempt(r *http.Request) bool {
+	if e.paths.IsInfrastructure(r.URL.Path) {
+		return true
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" && e.apiKeys[sha256.Sum256([]byte(key))] {
+		return true
+	}
+	if len(e.networks) == 0 {
+		return false
+	}
+
+	addr, err := netip.ParseAddr(getClientIP(r))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range e.networks {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
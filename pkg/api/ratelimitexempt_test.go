@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimitExemptions(t *testing.T) {
+	config := NewRateLimiterConfig(
+		WithBurst(1),
+		WithExemptPaths("/internal/*", "/ping"),
+		WithExemptNetworks("10.20.0.0/16", "192.0.2.1", "not-a-network"),
+		WithExemptAPIKeys("dashboard-key"),
+	)
+
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		apiKey     string
+		exempt     bool
+	}{
+		{"exempt path", "/ping", "203.0.113.7:1234", "", true},
+		{"path beneath an exempt prefix", "/internal/stats", "203.0.113.7:1234", "", true},
+		{"infrastructure route", "/health", "203.0.113.7:1234", "", true},
+		{"internal network", "/orders", "10.20.3.4:1234", "", true},
+		{"exempt address", "/orders", "192.0.2.1:1234", "", true},
+		{"exempt API key", "/orders", "203.0.113.7:1234", "dashboard-key", true},
+		{"other API key", "/orders", "203.0.113.7:1234", "customer-key", false},
+		{"other client", "/orders", "203.0.113.7:1234", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := NewBase("test", "1.0.0", "test", true)
+			handler := base.RateLimit(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			codes := make([]int, 2)
+			for i := range codes {
+				req := httptest.NewRequest("GET", tt.path, nil)
+				req.RemoteAddr = tt.remoteAddr
+				if tt.apiKey != "" {
+					req.Header.Set("X-API-Key", tt.apiKey)
+				}
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				codes[i] = rec.Code
+			}
+
+			limited := codes[1] == http.StatusTooManyRequests
+			if limited == tt.exempt {
+				t.Errorf("Expected exempt %v, got statuses %v", tt.exempt, codes)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/tenant"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRateLimitKeys(t *testing.T) {
+	request := func(headers map[string]string, tenantID string) *http.Request {
+		req := httptest.NewRequest("GET", "/orders/42", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		if tenantID != "" {
+			req = req.WithContext(tenant.WithID(req.Context(), tenantID))
+		}
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		keyFunc func(*http.Request) string
+		req     *http.Request
+		want    string
+	}{
+		{"ip", KeyByIP, request(nil, ""), "203.0.113.7"},
+		{"token", KeyByToken, request(map[string]string{"Authorization": "Bearer abc"}, ""), "token:" + hashKey("abc")},
+		{"no token", KeyByToken, request(nil, ""), ""},
+		{"api key", KeyByAPIKey, request(map[string]string{"X-API-Key": "k1"}, ""), "apiKey:" + hashKey("k1")},
+		{"no api key", KeyByAPIKey, request(nil, ""), ""},
+		{"tenant", KeyByTenant, request(nil, "acme"), "tenant:acme"},
+		{"no tenant", KeyByTenant, request(nil, ""), ""},
+		{"route without chi", KeyByRoute, request(nil, ""), "route:GET /orders/42"},
+		{"tenant and ip", CombineKeys(KeyByTenant, KeyByIP), request(nil, "acme"), "tenant:acme|203.0.113.7"},
+		{"combined with a missing key", CombineKeys(KeyByTenant, KeyByIP), request(nil, ""), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.keyFunc(tt.req); got != tt.want {
+				t.Errorf("Expected key %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestKeyByRoutePattern(t *testing.T) {
+	var key string
+	router := chi.NewRouter()
+	router.With(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key = KeyByRoute(r)
+			next.ServeHTTP(w, r)
+		})
+	}).Get("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/42", nil))
+	if key != "route:GET /orders/{id}" {
+		t.Errorf("Expected the route pattern, got %q", key)
+	}
+}
+
+func TestKeyByTokenHidesToken(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer secret-token-value")
+
+	if key := KeyByToken(req); strings.Contains(key, "secret") {
+		t.Errorf("Expected the token to be hashed, got %q", key)
+	}
+}
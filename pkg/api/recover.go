@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"github.com/Okja-Engineering/go-service-kit/pkg/report"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// panicsTotal counts recovered panics, exposed by AddMetricsEndpoint
+var panicsTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "http_panics_recovered_total",
+	Help: "Total number of panics recovered while serving HTTP requests",
+})
+
+// RecovererConfig holds configuration for panic recovery
+type RecovererConfig struct {
+	// Logger receives the panic value and stack trace
+	Logger problem.Logger
+	// Counter is incremented for every recovered panic
+	Counter prometheus.Counter
+	// Reporter receives the panic with the request attached; nil uses report.Default()
+	Reporter report.ErrorReporter
+}
+
+// DefaultRecovererConfig provides sensible defaults
+func DefaultRecovererConfig() *RecovererConfig {
+	return &RecovererConfig{
+		Logger:  &problem.DefaultLogger{},
+		Counter: panicsTotal,
+	}
+}
+
+// RecovererOption is a functional option for configuring panic recovery
+type RecovererOption func(*RecovererConfig)
+
+// WithRecovererLogger sets the logger that receives panic stack traces
+func WithRecovererLogger(logger problem.Logger) RecovererOption {
+	return func(config *RecovererConfig) {
+		config.Logger = logger
+	}
+}
+
+// WithPanicCounter sets the counter incremented for each recovered panic
+func WithPanicCounter(counter prometheus.Counter) RecovererOption {
+	return func(config *RecovererConfig) {
+		config.Counter = counter
+	}
+}
+
+// WithPanicReporter sets the reporter that receives recovered panics
+func WithPanicReporter(reporter report.ErrorReporter) RecovererOption {
+	return func(config *RecovererConfig) {
+		config.Reporter = reporter
+	}
+}
+
+// NewRecovererConfig creates a new recoverer config with options
+func NewRecovererConfig(options ...RecovererOption) *RecovererConfig {
+	config := DefaultRecovererConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Recoverer creates middleware that recovers from panics in later handlers. The panic and its
+// stack are logged and reported, the panic counter is incremented, and the client receives a problem+json 500
+// carrying the request ID (set by chi's RequestID middleware) so the failure can be traced in logs.
+func (b *Base) Recoverer(config *RecovererConfig) func(next http.Handler) http.Handler {
+	if config == nil {
+		config = DefaultRecovererConfig()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				// ErrAbortHandler is how handlers deliberately abort a response; let net/http handle it
+				if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(rec)
+				}
+
+				requestID, stack := middleware.GetReqID(r.Context()), debug.Stack()
+				config.Logger.Printf("### 💥 API: panic serving %s %s (request %s): %v\n%s",
+					r.Method, r.URL.Path, requestID, rec, stack)
+				if config.Counter != nil {
+					config.Counter.Inc()
+				}
+				report.OrDefault(config.Reporter).Report(r.Context(), report.Event{
+					Err:     fmt.Errorf("panic: %v", rec),
+					Request: r,
+					Stack:   stack,
+					Tags:    map[string]string{"request_id": requestID},
+				})
+
+				// A hijacked or upgraded connection has no response to write
+				if r.Header.Get("Connection") == "Upgrade" {
+					return
+				}
+
+				p := problem.New("internal-error", "Internal Server Error", http.StatusInternalServerError,
+					"An unexpected error occurred", r.URL.Path)
+				if requestID != "" {
+					p.WithExtension("requestId", requestID)
+				}
+				p.Respond(w, r)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/report"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+type bufferLogger struct {
+	buf bytes.Buffer
+}
+
+func (l *bufferLogger) Printf(format string, v ...interface{}) {
+	fmt.Fprintf(&l.buf, format, v...)
+}
+
+func TestRecoverer(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	logger := &bufferLogger{}
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_panics_total"})
+
+	handler := middleware.RequestID(b.Recoverer(NewRecovererConfig(
+		WithRecovererLogger(logger),
+		WithPanicCounter(counter),
+	))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	r.Header.Set("X-Request-Id", "req-123")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("Expected problem+json, got %s", w.Header().Get("Content-Type"))
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if body["requestId"] != "req-123" || body["instance"] != "/orders" {
+		t.Errorf("Expected request ID and instance in problem, got %v", body)
+	}
+
+	logged := logger.buf.String()
+	for _, want := range []string{"boom", "req-123", "goroutine"} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("Expected log to contain %q, got %s", want, logged)
+		}
+	}
+
+	var metric dto.Metric
+	if err := counter.Write(&metric); err != nil {
+		t.Fatalf("Failed to read counter: %v", err)
+	}
+	if metric.GetCounter().GetValue() != 1 {
+		t.Errorf("Expected panic counter of 1, got %v", metric.GetCounter().GetValue())
+	}
+}
+
+func TestRecovererReports(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	var events []report.Event
+	reporter := report.ReporterFunc(func(_ context.Context, event report.Event) {
+		events = append(events, event)
+	})
+
+	handler := middleware.RequestID(b.Recoverer(NewRecovererConfig(
+		WithRecovererLogger(&bufferLogger{}),
+		WithPanicCounter(prometheus.NewCounter(prometheus.CounterOpts{Name: "test_reported_panics_total"})),
+		WithPanicReporter(reporter),
+	))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	r.Header.Set("X-Request-Id", "req-123")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if len(events) != 1 {
+		t.Fatalf("Expected one reported panic, got %d", len(events))
+	}
+	event := events[0]
+	if event.Err.Error() != "panic: boom" || event.Tags["request_id"] != "req-123" {
+		t.Errorf("Expected the panic and request ID, got %+v", event)
+	}
+	if event.Request == nil || event.Request.URL.Path != "/orders" || !bytes.Contains(event.Stack, []byte("goroutine")) {
+		t.Errorf("Expected the request and stack, got %+v", event)
+	}
+}
+
+func TestRecovererPassesThrough(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	handler := b.Recoverer(NewRecovererConfig(WithRecovererLogger(&bufferLogger{})))
+
+	w := httptest.NewRecorder()
+	handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("Expected handler response to pass through, got %d", w.Code)
+	}
+
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("Expected ErrAbortHandler to be re-panicked, got %v", rec)
+		}
+	}()
+	handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
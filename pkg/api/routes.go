@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// RouteClass is a coarse classification of a route for cross-cutting concerns
+type RouteClass string
+
+const (
+	// RouteClassInfrastructure is probe and scrape traffic, such as health checks and metrics
+	RouteClassInfrastructure RouteClass = "infrastructure"
+	// RouteClassAPI is everything else
+	RouteClassAPI RouteClass = "api"
+)
+
+// DefaultInfrastructureRoutes are the paths treated as infrastructure unless configured otherwise
+var DefaultInfrastructureRoutes = []string{
+	"/health", "/healthz", "/livez", "/readyz", "/startupz", "/metrics", "/status",
+}
+
+// RouteClassifier decides which routes are infrastructure. It is the single configuration point
+// for excluding such traffic from request metrics, rate limiting, and access logs.
+type RouteClassifier struct {
+	mu       sync.RWMutex
+	exact    map[string]bool
+	prefixes []string
+}
+
+// NewRouteClassifier creates a classifier for the given infrastructure paths. A path ending in "/*"
+// matches everything beneath it, e.g. "/debug/*".
+func NewRouteClassifier(paths ...string) *RouteClassifier {
+	rc := &RouteClassifier{exact: make(map[string]bool)}
+	rc.Add(paths...)
+	return rc
+}
+
+// Add marks more paths as infrastructure
+func (rc *RouteClassifier) Add(paths ...string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for _, path := range paths {
+		if prefix, ok := strings.CutSuffix(path, "/*"); ok {
+			rc.prefixes = append(rc.prefixes, prefix+"/")
+		} else {
+			rc.exact[path] = true
+		}
+	}
+}
+
+// Classify returns the class of a request path
+func (rc *RouteClassifier) Classify(path string) RouteClass {
+	if rc.IsInfrastructure(path) {
+		return RouteClassInfrastructure
+	}
+	return RouteClassAPI
+}
+
+// IsInfrastructure reports whether a request path is infrastructure traffic
+func (rc *RouteClassifier) IsInfrastructure(path string) bool {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	path = strings.TrimSuffix(path, "/")
+	if rc.exact[path] {
+		return true
+	}
+	for _, prefix := range rc.prefixes {
+		if strings.HasPrefix(path+"/", prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldFilter implements logging.URLFilter, so access logs can skip infrastructure traffic
+func (rc *RouteClassifier) ShouldFilter(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return rc.IsInfrastructure(u.Path)
+}
+
+// Routes returns the route classifier shared by the Base's middleware. Paths registered with
+// AddHealthEndpoint, AddMetricsEndpoint, and similar are added to it automatically.
+func (b *Base) Routes() *RouteClassifier {
+	b.initOnce.Do(b.init)
+	return b.routes
+}
+
+// isInfrastructure reports whether a request is infrastructure traffic
+func (b *Base) isInfrastructure(r *http.Request) bool {
+	return b.Routes().IsInfrastructure(r.URL.Path)
+}
+
+// SkipInfrastructure wraps middleware so that infrastructure requests bypass it
+func (b *Base) SkipInfrastructure(mw func(next http.Handler) http.Handler) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if b.isInfrastructure(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRouteClassifier(t *testing.T) {
+	rc := NewRouteClassifier("/metrics", "/debug/*")
+
+	tests := []struct {
+		path string
+		want RouteClass
+	}{
+		{"/metrics", RouteClassInfrastructure},
+		{"/metrics/", RouteClassInfrastructure},
+		{"/metricsx", RouteClassAPI},
+		{"/debug", RouteClassInfrastructure},
+		{"/debug/pprof/heap", RouteClassInfrastructure},
+		{"/debugger", RouteClassAPI},
+		{"/orders", RouteClassAPI},
+		{"/", RouteClassAPI},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := rc.Classify(tt.path); got != tt.want {
+				t.Errorf("Classify(%q) = %s, want %s", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if !rc.ShouldFilter("/metrics?format=text") || rc.ShouldFilter("/orders?page=2") {
+		t.Error("Expected ShouldFilter to match on path only")
+	}
+}
+
+func TestBaseRoutesExcludeInfrastructure(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	router := chi.NewRouter()
+	router.Use(b.RateLimitByIP(NewRateLimiterConfig(WithRequestsPerSecond(0.001), WithBurst(1))))
+	b.AddHealthEndpoint(router, "ping")
+	router.Get("/orders", func(w http.ResponseWriter, r *http.Request) {})
+
+	if b.Routes().Classify("/ping") != RouteClassInfrastructure {
+		t.Error("Expected registered health endpoint to be classified as infrastructure")
+	}
+
+	serve := func(path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	for range 3 {
+		if code := serve("/ping"); code != http.StatusOK {
+			t.Errorf("Expected health checks to bypass rate limiting, got %d", code)
+		}
+	}
+	if code := serve("/orders"); code != http.StatusOK {
+		t.Errorf("Expected first API request to pass, got %d", code)
+	}
+	if code := serve("/orders"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected second API request to be limited, got %d", code)
+	}
+}
+
+func TestSkipInfrastructure(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+
+	var calls int
+	counting := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			next.ServeHTTP(w, r)
+		})
+	}
+	handler := b.SkipInfrastructure(counting)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, path := range []string{"/healthz", "/metrics", "/orders"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected only the API request to reach the middleware, got %d", calls)
+	}
+}
@@ -0,0 +1,369 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Process exit codes derived from the outcome of a graceful shutdown
+const (
+	ExitOK              = 0
+	ExitServerError     = 1
+	ExitShutdownTimeout = 2
+	ExitHookFailure     = 3
+	ExitStartupFailure  = 4
+)
+
+// ShutdownHook is run during graceful shutdown, after the server stops accepting requests, or while
+// traffic drains when registered with OnDrain. The Stop and Shutdown methods of this module's workers,
+// schedulers, and servers have this signature, so they can be registered directly.
+type ShutdownHook func(ctx context.Context) error
+
+// HookResult records the outcome of a single shutdown hook
+type HookResult struct {
+	Name       string `json:"name"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ShutdownReport summarizes a graceful shutdown for logs and orchestrators
+type ShutdownReport struct {
+	Reason          string       `json:"reason"`
+	StartedAt       time.Time    `json:"startedAt"`
+	DurationMs      int64        `json:"durationMs"`
+	RequestsDrained int64        `json:"requestsDrained"`
+	RequestsAborted int64        `json:"requestsAborted"`
+	TimedOut        bool         `json:"timedOut"`
+	DrainMs         int64        `json:"drainMs,omitempty"`
+	DrainHooks      []HookResult `json:"drainHooks,omitempty"`
+	ServerError     string       `json:"serverError,omitempty"`
+	StartupError    string       `json:"startupError,omitempty"`
+	Hooks           []HookResult `json:"hooks"`
+	ExitCode        int          `json:"exitCode"`
+}
+
+// HookFailures returns the number of drain and shutdown hooks that returned an error
+func (r *ShutdownReport) HookFailures() int {
+	failures := 0
+	for _, hooks := range [][]HookResult{r.DrainHooks, r.Hooks} {
+		for _, h := range hooks {
+			if h.Error != "" {
+				failures++
+			}
+		}
+	}
+	return failures
+}
+
+// exitCode maps the report outcome to a process exit code.
+// Server errors take precedence over startup failures, then drain timeouts, then hook failures.
+func (r *ShutdownReport) exitCode() int {
+	switch {
+	case r.ServerError != "":
+		return ExitServerError
+	case r.StartupError != "":
+		return ExitStartupFailure
+	case r.TimedOut:
+		return ExitShutdownTimeout
+	case r.HookFailures() > 0:
+		return ExitHookFailure
+	default:
+		return ExitOK
+	}
+}
+
+// ShutdownConfig holds configuration for graceful shutdown
+type ShutdownConfig struct {
+	// Timeout bounds how long in-flight requests are given to drain
+	Timeout time.Duration
+	// DrainDelay is how long readiness fails before the listener closes, so load balancers stop
+	// sending traffic first. It should exceed the load balancer's health check interval.
+	DrainDelay time.Duration
+	// HookTimeout bounds each individual shutdown hook
+	HookTimeout time.Duration
+	// Signals that trigger a graceful shutdown
+	Signals []os.Signal
+}
+
+// DefaultShutdownConfig provides sensible defaults
+func DefaultShutdownConfig() *ShutdownConfig {
+	return &ShutdownConfig{
+		Timeout:     30 * time.Second,
+		HookTimeout: 10 * time.Second,
+		Signals:     []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+}
+
+// ShutdownOption is a functional option for configuring graceful shutdown
+type ShutdownOption func(*ShutdownConfig)
+
+// WithShutdownTimeout sets how long in-flight requests are given to drain
+func WithShutdownTimeout(timeout time.Duration) ShutdownOption {
+	return func(config *ShutdownConfig) {
+		config.Timeout = timeout
+	}
+}
+
+// WithDrainDelay sets how long readiness fails before the listener closes
+func WithDrainDelay(delay time.Duration) ShutdownOption {
+	return func(config *ShutdownConfig) {
+		config.DrainDelay = delay
+	}
+}
+
+// WithHookTimeout sets the timeout applied to each shutdown hook
+func WithHookTimeout(timeout time.Duration) ShutdownOption {
+	return func(config *ShutdownConfig) {
+		config.HookTimeout = timeout
+	}
+}
+
+// WithShutdownSignals sets the signals that trigger a graceful shutdown
+func WithShutdownSignals(signals ...os.Signal) ShutdownOption {
+	return func(config *ShutdownConfig) {
+		config.Signals = signals
+	}
+}
+
+// NewShutdownConfig creates a new shutdown config with options
+func NewShutdownConfig(options ...ShutdownOption) *ShutdownConfig {
+	config := DefaultShutdownConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// namedHook pairs a shutdown hook with its name
+type namedHook struct {
+	name string
+	hook ShutdownHook
+}
+
+// lifecycle holds graceful shutdown state for a Base
+type lifecycle struct {
+	mu       sync.Mutex
+	config   *ShutdownConfig
+	hooks    []namedHook
+	drains   []namedHook
+	inFlight atomic.Int64
+
+	// draining is set when shutdown starts, failing readiness while traffic moves elsewhere
+	draining atomic.Bool
+
+	// stopping is closed when shutdown starts, so long-lived connections can end
+	stopping chan struct{}
+	stopOnce sync.Once
+}
+
+// lifecycle returns the Base lifecycle state, creating it on first use
+func (b *Base) lifecycle() *lifecycle {
+	b.initOnce.Do(b.init)
+	return b.life
+}
+
+// ShuttingDown is closed when graceful shutdown starts. Long-lived handlers such as event streams
+// should return when it closes, since the server waits for them before running shutdown hooks.
+func (b *Base) ShuttingDown() <-chan struct{} {
+	return b.lifecycle().stopping
+}
+
+// Draining reports whether graceful shutdown has started. Ready is false while draining.
+func (b *Base) Draining() bool {
+	return b.lifecycle().draining.Load()
+}
+
+// ConfigureShutdown applies graceful shutdown options
+func (b *Base) ConfigureShutdown(options ...ShutdownOption) {
+	lc := b.lifecycle()
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	for _, option := range options {
+		option(lc.config)
+	}
+}
+
+// OnShutdown registers a hook to run during graceful shutdown.
+// Hooks run in reverse registration order, like deferred calls.
+func (b *Base) OnShutdown(name string, hook ShutdownHook) {
+	lc := b.lifecycle()
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	lc.hooks = append(lc.hooks, namedHook{name: name, hook: hook})
+}
+
+// OnDrain registers a hook to run as soon as shutdown starts, while readiness fails and the server
+// still answers requests. Use it to stop taking new work, such as a queue worker claiming jobs; close
+// what in-flight requests still need, such as the database, with OnShutdown. Drain hooks run in
+// reverse registration order, at the same time as the drain delay.
+func (b *Base) OnDrain(name string, hook ShutdownHook) {
+	lc := b.lifecycle()
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	lc.drains = append(lc.drains, namedHook{name: name, hook: hook})
+}
+
+// Serve runs the server until a shutdown signal is received, then shuts down gracefully
+func (b *Base) Serve(srv *http.Server) *ShutdownReport {
+	lc := b.lifecycle()
+	lc.mu.Lock()
+	signals := lc.config.Signals
+	lc.mu.Unlock()
+
+	ctx, stop := signal.NotifyContext(context.Background(), signals...)
+	defer stop()
+
+	return b.ServeContext(ctx, srv)
+}
+
+// ServeContext runs the server until ctx is done, then shuts down gracefully
+func (b *Base) ServeContext(ctx context.Context, srv *http.Server) *ShutdownReport {
+	return b.serve(ctx, srv, srv.ListenAndServe)
+}
+
+// serve wraps the handler to track in-flight requests, runs startup hooks once listening, and
+// drives the shutdown sequence
+func (b *Base) serve(ctx context.Context, srv *http.Server, listen func() error) *ShutdownReport {
+	lc := b.lifecycle()
+	srv.Handler = lc.track(b.startupGate(srv.Handler))
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- listen()
+	}()
+
+	startupErr := make(chan error, 1)
+	go func() {
+		if err := b.RunStartup(ctx); err != nil && ctx.Err() == nil {
+			startupErr <- err
+		}
+	}()
+
+	report := &ShutdownReport{}
+	waitForStop(ctx, serverErr, startupErr, report)
+
+	b.shutdown(srv, report)
+	b.logShutdownReport(report)
+
+	return report
+}
+
+// waitForStop blocks until the server fails, a startup hook fails, or ctx is done, recording why
+func waitForStop(ctx context.Context, serverErr, startupErr <-chan error, report *ShutdownReport) {
+	select {
+	case err := <-serverErr:
+		report.Reason = "server error"
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			report.ServerError = err.Error()
+		}
+	case err := <-startupErr:
+		report.Reason = "startup failed"
+		report.StartupError = err.Error()
+	case <-ctx.Done():
+		report.Reason = "shutdown requested"
+		if cause := context.Cause(ctx); cause != nil && !errors.Is(cause, context.Canceled) {
+			report.Reason = cause.Error()
+		}
+	}
+}
+
+// shutdown fails readiness and runs drain hooks, then drains requests and runs shutdown hooks,
+// filling in the report
+func (b *Base) shutdown(srv *http.Server, report *ShutdownReport) {
+	lc := b.lifecycle()
+	lc.mu.Lock()
+	config := *lc.config
+	hooks := slices.Clone(lc.hooks)
+	drains := slices.Clone(lc.drains)
+	lc.mu.Unlock()
+
+	report.StartedAt = time.Now()
+	lc.draining.Store(true)
+	b.drain(drains, config, report)
+
+	lc.stopOnce.Do(func() { close(lc.stopping) })
+	inFlightAtStart := lc.inFlight.Load()
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	err := srv.Shutdown(ctx)
+	cancel()
+
+	if err != nil {
+		report.TimedOut = errors.Is(err, context.DeadlineExceeded)
+		report.RequestsAborted = lc.inFlight.Load()
+		_ = srv.Close()
+	}
+	report.RequestsDrained = max(inFlightAtStart-report.RequestsAborted, 0)
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		report.Hooks = append(report.Hooks, runHook(hooks[i], config.HookTimeout))
+	}
+
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+	report.ExitCode = report.exitCode()
+}
+
+// drain runs the drain hooks, then waits out what remains of the drain delay before the listener closes
+func (b *Base) drain(drains []namedHook, config ShutdownConfig, report *ShutdownReport) {
+	if config.DrainDelay > 0 {
+		log.Printf("### 🚦 API: draining, readiness failing for %s before the server stops", config.DrainDelay)
+	}
+	delay := time.NewTimer(config.DrainDelay)
+	defer delay.Stop()
+
+	for i := len(drains) - 1; i >= 0; i-- {
+		report.DrainHooks = append(report.DrainHooks, runHook(drains[i], config.HookTimeout))
+	}
+
+	<-delay.C
+	report.DrainMs = time.Since(report.StartedAt).Milliseconds()
+}
+
+// runHook runs a single shutdown hook with its own timeout
+func runHook(h namedHook, timeout time.Duration) HookResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := h.hook(ctx)
+	result := HookResult{Name: h.name, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// logShutdownReport writes the report as a single JSON log line
+func (b *Base) logShutdownReport(report *ShutdownReport) {
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		log.Printf("### 🛑 %s API: shutdown complete, exit code %d", b.ServiceName, report.ExitCode)
+		return
+	}
+
+	log.Printf("### 🛑 %s API: shutdown report: %s", b.ServiceName, reportBytes)
+}
+
+// track counts in-flight requests so the shutdown report can tell drained from aborted
+func (lc *lifecycle) track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lc.inFlight.Add(1)
+		defer lc.inFlight.Add(-1)
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestShutdownReportExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		report   ShutdownReport
+		expected int
+	}{
+		{"clean", ShutdownReport{}, ExitOK},
+		{"server error", ShutdownReport{ServerError: "bind failed", TimedOut: true}, ExitServerError},
+		{"startup failure", ShutdownReport{StartupError: "db", TimedOut: true}, ExitStartupFailure},
+		{"timeout", ShutdownReport{TimedOut: true, Hooks: []HookResult{{Error: "x"}}}, ExitShutdownTimeout},
+		{"hook failure", ShutdownReport{Hooks: []HookResult{{Name: "db"}, {Name: "cache", Error: "x"}}}, ExitHookFailure},
+		{"drain hook failure", ShutdownReport{DrainHooks: []HookResult{{Name: "queue", Error: "x"}}}, ExitHookFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := tt.report.exitCode(); code != tt.expected {
+				t.Errorf("Expected exit code %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestNewShutdownConfig(t *testing.T) {
+	config := NewShutdownConfig(WithShutdownTimeout(5*time.Second), WithHookTimeout(time.Second))
+
+	if config.Timeout != 5*time.Second {
+		t.Errorf("Expected timeout 5s, got %v", config.Timeout)
+	}
+
+	if config.HookTimeout != time.Second {
+		t.Errorf("Expected hook timeout 1s, got %v", config.HookTimeout)
+	}
+
+	if len(config.Signals) != 2 {
+		t.Errorf("Expected 2 default signals, got %d", len(config.Signals))
+	}
+}
+
+// startTestServer serves on a random local port using the Base shutdown machinery
+func startTestServer(t *testing.T, b *Base, handler http.Handler) (string, context.CancelFunc, <-chan *ShutdownReport) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: time.Second}
+	reports := make(chan *ShutdownReport, 1)
+
+	go func() {
+		reports <- b.serve(ctx, srv, func() error { return srv.Serve(ln) })
+	}()
+
+	return "http://" + ln.Addr().String(), cancel, reports
+}
+
+func TestServeContextRunsHooksInReverseOrder(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+
+	var order []string
+	base.OnShutdown("database", func(ctx context.Context) error {
+		order = append(order, "database")
+		return nil
+	})
+	base.OnShutdown("jobs", func(ctx context.Context) error {
+		order = append(order, "jobs")
+		return errors.New("jobs did not stop")
+	})
+
+	_, cancel, reports := startTestServer(t, base, http.NotFoundHandler())
+	cancel()
+	report := <-reports
+
+	if len(order) != 2 || order[0] != "jobs" || order[1] != "database" {
+		t.Errorf("Expected hooks in reverse order, got %v", order)
+	}
+
+	if report.HookFailures() != 1 {
+		t.Errorf("Expected 1 hook failure, got %d", report.HookFailures())
+	}
+
+	if report.ExitCode != ExitHookFailure {
+		t.Errorf("Expected exit code %d, got %d", ExitHookFailure, report.ExitCode)
+	}
+}
+
+func TestServeContextDrainsRequests(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	url, cancel, reports := startTestServer(t, base, handler)
+
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	<-started
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	report := <-reports
+
+	if report.RequestsDrained != 1 || report.RequestsAborted != 0 {
+		t.Errorf("Expected 1 drained and 0 aborted, got %d and %d", report.RequestsDrained, report.RequestsAborted)
+	}
+
+	if report.ExitCode != ExitOK {
+		t.Errorf("Expected exit code %d, got %d", ExitOK, report.ExitCode)
+	}
+}
+
+func TestServeContextTimeout(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	base.ConfigureShutdown(WithShutdownTimeout(50 * time.Millisecond))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	url, cancel, reports := startTestServer(t, base, handler)
+
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	<-started
+	cancel()
+	report := <-reports
+
+	if !report.TimedOut {
+		t.Error("Expected shutdown to time out")
+	}
+
+	if report.RequestsAborted != 1 {
+		t.Errorf("Expected 1 aborted request, got %d", report.RequestsAborted)
+	}
+
+	if report.ExitCode != ExitShutdownTimeout {
+		t.Errorf("Expected exit code %d, got %d", ExitShutdownTimeout, report.ExitCode)
+	}
+}
+
+func TestServeContextServerError(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	srv := &http.Server{Handler: http.NotFoundHandler(), ReadHeaderTimeout: time.Second}
+
+	report := base.serve(context.Background(), srv, func() error { return errors.New("address in use") })
+
+	if report.ServerError == "" {
+		t.Error("Expected server error to be reported")
+	}
+
+	if report.ExitCode != ExitServerError {
+		t.Errorf("Expected exit code %d, got %d", ExitServerError, report.ExitCode)
+	}
+}
+
+func TestServeContextDrainFailsReadinessFirst(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	base.ConfigureShutdown(WithDrainDelay(100 * time.Millisecond))
+
+	router := chi.NewRouter()
+	base.AddReadinessEndpoint(router, "ready")
+	url, cancel, reports := startTestServer(t, base, router)
+
+	readiness := func() (int, string) {
+		resp, err := http.Get(url + "/ready")
+		if err != nil {
+			return 0, err.Error()
+		}
+		defer resp.Body.Close()
+		var body map[string]interface{}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		reason, _ := body["reason"].(string)
+		return resp.StatusCode, reason
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !base.Ready() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status, _ := readiness(); status != http.StatusOK {
+		t.Fatalf("Expected ready before shutdown, got %d", status)
+	}
+
+	var order []string
+	var drainStatus int
+	var drainReason string
+	base.OnShutdown("database", func(ctx context.Context) error {
+		order = append(order, "database")
+		return nil
+	})
+	base.OnDrain("queue", func(ctx context.Context) error {
+		order = append(order, "queue")
+		drainStatus, drainReason = readiness()
+		return nil
+	})
+
+	cancel()
+	report := <-reports
+
+	if drainStatus != http.StatusServiceUnavailable || drainReason != "shutting down" {
+		t.Errorf("Expected readiness 503 while draining, got %d %q", drainStatus, drainReason)
+	}
+	if len(order) != 2 || order[0] != "queue" || order[1] != "database" {
+		t.Errorf("Expected drain hooks before shutdown hooks, got %v", order)
+	}
+	if len(report.DrainHooks) != 1 || report.DrainMs < 100 {
+		t.Errorf("Expected drain hook and delay in report, got %+v", report)
+	}
+	if !base.Draining() || base.Ready() {
+		t.Error("Expected service to stay draining and not ready after shutdown")
+	}
+}
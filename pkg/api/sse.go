@@ -0,0 +1,259 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrStreamClosed is returned when sending on an event stream after it has ended
+var ErrStreamClosed = errors.New("event stream closed")
+
+// SSEConfig holds configuration for server-sent event streams
+type SSEConfig struct {
+	// Heartbeat is how often a comment is sent to keep idle connections open through proxies; zero disables it
+	Heartbeat time.Duration
+	// Retry tells clients how long to wait before reconnecting; zero leaves it to the client
+	Retry time.Duration
+}
+
+// DefaultSSEConfig provides sensible defaults
+func DefaultSSEConfig() *SSEConfig {
+	return &SSEConfig{
+		Heartbeat: 15 * time.Second,
+	}
+}
+
+// SSEOption is a functional option for configuring event streams
+type SSEOption func(*SSEConfig)
+
+// WithHeartbeat sets how often a keep-alive comment is sent
+func WithHeartbeat(interval time.Duration) SSEOption {
+	return func(config *SSEConfig) {
+		config.Heartbeat = interval
+	}
+}
+
+// WithRetry sets the reconnection delay sent to clients
+func WithRetry(retry time.Duration) SSEOption {
+	return func(config *SSEConfig) {
+		config.Retry = retry
+	}
+}
+
+// NewSSEConfig creates a new event stream config with options
+func NewSSEConfig(options ...SSEOption) *SSEConfig {
+	config := DefaultSSEConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Event is a single server-sent event. Data is encoded as JSON, except strings and byte slices
+// which are sent as they are.
+type Event struct {
+	ID    string
+	Event string
+	Data  interface{}
+}
+
+// EventStream writes server-sent events to a client until it disconnects or the server shuts down
+type EventStream struct {
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewEventStream starts an event stream on w, sending the headers straight away. It fails if the
+// writer can't flush, for example behind middleware that buffers responses such as Timeout or ETag.
+func (b *Base) NewEventStream(w http.ResponseWriter, r *http.Request, config *SSEConfig) (*EventStream, error) {
+	if config == nil {
+		config = DefaultSSEConfig()
+	}
+
+	if !canFlush(w) {
+		return nil, errors.New("response does not support streaming")
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	stream := &EventStream{w: w, rc: http.NewResponseController(w), ctx: ctx, cancel: cancel}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Stops nginx buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	preamble := ": stream opened\n\n"
+	if config.Retry > 0 {
+		preamble = "retry: " + strconv.FormatInt(config.Retry.Milliseconds(), 10) + "\n\n"
+	}
+	if err := stream.write(preamble); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to start event stream: %w", err)
+	}
+
+	go stream.keepAlive(config.Heartbeat, b.lifecycle().stopping)
+
+	return stream, nil
+}
+
+// Context is done when the client disconnects, the stream is closed, or the server starts shutting down
+func (s *EventStream) Context() context.Context {
+	return s.ctx
+}
+
+// Done is closed when the stream ends, see Context
+func (s *EventStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Send sends an event with the given name and JSON-encoded data
+func (s *EventStream) Send(event string, data interface{}) error {
+	return s.SendEvent(Event{Event: event, Data: data})
+}
+
+// SendEvent sends an event, returning an error once the client has gone
+func (s *EventStream) SendEvent(event Event) error {
+	var data []byte
+	switch v := event.Data.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+
+	var buf strings.Builder
+	if event.ID != "" {
+		buf.WriteString("id: " + sanitizeSSEField(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		buf.WriteString("event: " + sanitizeSSEField(event.Event) + "\n")
+	}
+	// Multi-line data is split over several data fields, which clients join back with newlines
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(bytes.TrimSuffix(line, []byte("\r")))
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+
+	return s.write(buf.String())
+}
+
+// Comment sends a comment line, which clients ignore
+func (s *EventStream) Comment(text string) error {
+	return s.write(": " + sanitizeSSEField(text) + "\n\n")
+}
+
+// Close ends the stream and stops its heartbeat. Handlers using NewEventStream must call it before
+// returning, after which nothing more is written to the response.
+func (s *EventStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cancel()
+}
+
+// write sends raw stream data and flushes it, recording the first failure
+func (s *EventStream) write(data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	if s.ctx.Err() != nil {
+		return ErrStreamClosed
+	}
+
+	if _, err := s.w.Write([]byte(data)); err != nil {
+		s.err = err
+	} else if err := s.rc.Flush(); err != nil {
+		s.err = err
+	}
+	if s.err != nil {
+		s.cancel()
+	}
+
+	return s.err
+}
+
+// keepAlive sends heartbeats and closes the stream when the server starts shutting down
+func (s *EventStream) keepAlive(interval time.Duration, stopping <-chan struct{}) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-stopping:
+			s.cancel()
+			return
+		case <-tick:
+			_ = s.write(": heartbeat\n\n")
+		}
+	}
+}
+
+// canFlush reports whether w, or a writer it wraps, can flush
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		if _, ok := w.(http.Flusher); ok {
+			return true
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return false
+		}
+		w = unwrapper.Unwrap()
+	}
+}
+
+// sanitizeSSEField stops a value breaking out of its field onto a new line
+func sanitizeSSEField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+}
+
+// SSE creates a handler that opens an event stream and passes it to fn, which should send events until
+// stream.Done() is closed. The status has already been sent by then, so an error fn returns is sent to
+// the client as an "error" event.
+func (b *Base) SSE(fn func(stream *EventStream, r *http.Request) error, options ...SSEOption) http.HandlerFunc {
+	config := NewSSEConfig(options...)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		stream, err := b.NewEventStream(w, r, config)
+		if err != nil {
+			b.HandleError(w, r, err)
+			return
+		}
+		defer stream.Close()
+
+		if err := fn(stream, r); err != nil && !errors.Is(err, ErrStreamClosed) && stream.ctx.Err() == nil {
+			_ = stream.Send("error", map[string]string{"error": err.Error()})
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSESendsEvents(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	srv := httptest.NewServer(b.SSE(func(stream *EventStream, r *http.Request) error {
+		if err := stream.Send("progress", map[string]int{"percent": 50}); err != nil {
+			return err
+		}
+		return stream.SendEvent(Event{ID: "7", Event: "log", Data: "line one\nline two"})
+	}, WithRetry(3*time.Second)))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %q", got)
+	}
+	if got := resp.Header.Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Expected no-cache, got %q", got)
+	}
+
+	want := "retry: 3000\n\n" +
+		"event: progress\ndata: {\"percent\":50}\n\n" +
+		"id: 7\nevent: log\ndata: line one\ndata: line two\n\n"
+	if string(body) != want {
+		t.Errorf("Unexpected stream:\n%q\nwant:\n%q", body, want)
+	}
+}
+
+func TestSSEErrorEvent(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	srv := httptest.NewServer(b.SSE(func(stream *EventStream, r *http.Request) error {
+		return errors.New("report failed")
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+
+	if !strings.Contains(string(body), "event: error\ndata: {\"error\":\"report failed\"}\n\n") {
+		t.Errorf("Expected an error event, got %q", body)
+	}
+}
+
+func TestSSEHeartbeatAndDisconnect(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	ended := make(chan error, 1)
+	srv := httptest.NewServer(b.SSE(func(stream *EventStream, r *http.Request) error {
+		select {
+		case <-stream.Done():
+		case <-time.After(2 * time.Second):
+		}
+		ended <- stream.Comment("too late")
+		return nil
+	}, WithHeartbeat(10*time.Millisecond)))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if scanner.Text() == ": heartbeat" {
+			break
+		}
+	}
+	_ = resp.Body.Close()
+
+	select {
+	case err := <-ended:
+		if err == nil {
+			t.Error("Expected writes after the client disconnected to fail")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the handler to see the client disconnect")
+	}
+}
+
+func TestSSEEndsOnShutdown(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	ended := make(chan struct{})
+	srv := httptest.NewServer(b.SSE(func(stream *EventStream, r *http.Request) error {
+		<-stream.Done()
+		close(ended)
+		return nil
+	}, WithHeartbeat(0)))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	lc := b.lifecycle()
+	lc.stopOnce.Do(func() { close(lc.stopping) })
+
+	select {
+	case <-ended:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the stream to end when shutdown starts")
+	}
+	select {
+	case <-b.ShuttingDown():
+	default:
+		t.Error("Expected ShuttingDown to be closed")
+	}
+}
+
+// plainWriter is a ResponseWriter that can't flush
+type plainWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *plainWriter) Header() http.Header         { return w.header }
+func (w *plainWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *plainWriter) WriteHeader(status int)      { w.status = status }
+
+func TestSSERequiresFlusher(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+
+	if _, err := b.NewEventStream(&plainWriter{header: make(http.Header)}, r, nil); err == nil {
+		t.Error("Expected an error for a writer that can't flush")
+	}
+
+	w := &plainWriter{header: make(http.Header)}
+	b.SSE(func(stream *EventStream, r *http.Request) error {
+		t.Error("Expected the handler not to run")
+		return nil
+	}).ServeHTTP(w, r)
+	if w.status != http.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d", w.status)
+	}
+}
+
+func TestSanitizeSSEField(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"progress", "progress"},
+		{"a\nb", "a b"},
+		{"a\r\nevent: spoofed", "a event: spoofed"},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeSSEField(tt.input); got != tt.want {
+			t.Errorf("sanitizeSSEField(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"github.com/go-chi/chi/v5"
+)
+
+// StartupHook is run before the service takes traffic, e.g. to connect to a database or warm a cache
+type StartupHook func(ctx context.Context) error
+
+// Startup hook states reported by StartupStatus
+const (
+	StartupPending = "pending"
+	StartupRunning = "running"
+	StartupDone    = "done"
+	StartupFailed  = "failed"
+)
+
+// StartupConfig holds configuration for a startup hook
+type StartupConfig struct {
+	// Timeout bounds the hook; it fails when the timeout passes
+	Timeout time.Duration
+}
+
+// DefaultStartupConfig provides sensible defaults
+func DefaultStartupConfig() *StartupConfig {
+	return &StartupConfig{
+		Timeout: 30 * time.Second,
+	}
+}
+
+// StartupOption is a functional option for configuring a startup hook
+type StartupOption func(*StartupConfig)
+
+// WithStartupTimeout sets the timeout applied to the hook
+func WithStartupTimeout(timeout time.Duration) StartupOption {
+	return func(config *StartupConfig) {
+		config.Timeout = timeout
+	}
+}
+
+// NewStartupConfig creates a new startup hook config with options
+func NewStartupConfig(options ...StartupOption) *StartupConfig {
+	config := DefaultStartupConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// StartupHookStatus is the progress of a single startup hook
+type StartupHookStatus struct {
+	Name       string `json:"name"`
+	State      string `json:"state"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// StartupStatus is the progress of every startup hook, in execution order
+type StartupStatus struct {
+	Started   bool                `json:"started"`
+	Completed int                 `json:"completed"`
+	Total     int                 `json:"total"`
+	Hooks     []StartupHookStatus `json:"hooks"`
+}
+
+// startupHook is a registered hook with its progress
+type startupHook struct {
+	hook   StartupHook
+	config *StartupConfig
+	status StartupHookStatus
+}
+
+// startupRegistry holds the startup hooks registered on a Base
+type startupRegistry struct {
+	mu    sync.RWMutex
+	hooks []*startupHook
+
+	// runMu serializes RunStartup calls
+	runMu sync.Mutex
+}
+
+// OnStartup registers a hook to run before the service takes traffic. Hooks run one at a time in
+// registration order, each with its own timeout, and the first failure stops startup.
+func (b *Base) OnStartup(name string, hook StartupHook, options ...StartupOption) {
+	b.initOnce.Do(b.init)
+
+	b.startup.mu.Lock()
+	defer b.startup.mu.Unlock()
+
+	b.startup.hooks = append(b.startup.hooks, &startupHook{
+		hook:   hook,
+		config: NewStartupConfig(options...),
+		status: StartupHookStatus{Name: name, State: StartupPending},
+	})
+}
+
+// RunStartup runs every hook that has not yet completed, in order, stopping at the first failure.
+// Serve calls it once the server is listening; call it directly when serving some other way.
+func (b *Base) RunStartup(ctx context.Context) error {
+	b.initOnce.Do(b.init)
+
+	b.startup.runMu.Lock()
+	defer b.startup.runMu.Unlock()
+
+	b.startup.mu.RLock()
+	hooks := make([]*startupHook, len(b.startup.hooks))
+	copy(hooks, b.startup.hooks)
+	b.startup.mu.RUnlock()
+
+	for _, h := range hooks {
+		if b.startup.state(h) == StartupDone {
+			continue
+		}
+		if err := b.startup.run(ctx, h); err != nil {
+			return fmt.Errorf("startup hook %s failed: %w", h.status.Name, err)
+		}
+	}
+
+	if len(hooks) > 0 {
+		log.Printf("### 🌅 %s API: startup complete, %d hook(s) run", b.ServiceName, len(hooks))
+	}
+	return nil
+}
+
+// run executes a single hook with its timeout, recording its progress
+func (s *startupRegistry) run(ctx context.Context, h *startupHook) error {
+	s.update(h, StartupRunning, 0, nil)
+
+	ctx, cancel := context.WithTimeout(ctx, h.config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := h.hook(ctx)
+	duration := time.Since(start)
+
+	if err != nil {
+		s.update(h, StartupFailed, duration, err)
+		return err
+	}
+	s.update(h, StartupDone, duration, nil)
+	return nil
+}
+
+// update records the progress of a hook
+func (s *startupRegistry) update(h *startupHook, state string, duration time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h.status.State = state
+	h.status.DurationMs = duration.Milliseconds()
+	h.status.Error = ""
+	if err != nil {
+		h.status.Error = err.Error()
+	}
+}
+
+// state returns the current state of a hook
+func (s *startupRegistry) state(h *startupHook) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return h.status.State
+}
+
+// StartupStatus returns the progress of every startup hook
+func (b *Base) StartupStatus() StartupStatus {
+	b.initOnce.Do(b.init)
+
+	b.startup.mu.RLock()
+	defer b.startup.mu.RUnlock()
+
+	status := StartupStatus{Total: len(b.startup.hooks), Hooks: make([]StartupHookStatus, 0, len(b.startup.hooks))}
+	for _, h := range b.startup.hooks {
+		status.Hooks = append(status.Hooks, h.status)
+		if h.status.State == StartupDone {
+			status.Completed++
+		}
+	}
+	status.Started = status.Completed == status.Total
+
+	return status
+}
+
+// Started reports whether every startup hook has completed. A Base without hooks is always started.
+func (b *Base) Started() bool {
+	return b.StartupStatus().Started
+}
+
+// AddStartupEndpoint adds an endpoint for Kubernetes startup probes, returning 200 once Started, or
+// 503 otherwise, with the progress of each hook in the body
+func (b *Base) AddStartupEndpoint(r chi.Router, path string) {
+	log.Printf("### 🌅 API: startup endpoint at: %s", "/"+path)
+	b.Routes().Add("/" + path)
+
+	r.HandleFunc("/"+path, func(w http.ResponseWriter, r *http.Request) {
+		status := b.StartupStatus()
+
+		code := http.StatusOK
+		if !status.Started {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, status)
+	})
+}
+
+// startupGate answers requests to application routes with 503 until startup completes, while
+// infrastructure routes such as probes are served throughout
+func (b *Base) startupGate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !b.isInfrastructure(r) && !b.Started() {
+			w.Header().Set("Retry-After", "1")
+			problem.New("starting", "Service Starting", http.StatusServiceUnavailable,
+				"The service is starting up; retry shortly", r.URL.Path).Respond(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNewStartupConfig(t *testing.T) {
+	if config := NewStartupConfig(); config.Timeout != 30*time.Second {
+		t.Errorf("Expected default timeout 30s, got %v", config.Timeout)
+	}
+	if config := NewStartupConfig(WithStartupTimeout(time.Second)); config.Timeout != time.Second {
+		t.Errorf("Expected timeout 1s, got %v", config.Timeout)
+	}
+}
+
+func TestRunStartup(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	if !base.Started() {
+		t.Error("Expected a Base without hooks to be started")
+	}
+
+	var order []string
+	failCache := true
+	base.OnStartup("database", func(ctx context.Context) error {
+		order = append(order, "database")
+		return nil
+	})
+	base.OnStartup("cache", func(ctx context.Context) error {
+		order = append(order, "cache")
+		if failCache {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}, WithStartupTimeout(10*time.Millisecond))
+	base.OnStartup("migrations", func(ctx context.Context) error {
+		order = append(order, "migrations")
+		return nil
+	})
+
+	err := base.RunStartup(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "cache") {
+		t.Fatalf("Expected the cache hook to time out, got %v", err)
+	}
+
+	status := base.StartupStatus()
+	if status.Started || status.Completed != 1 || status.Total != 3 || base.Ready() {
+		t.Errorf("Expected startup to stop at the failed hook, got %+v", status)
+	}
+	states := []string{status.Hooks[0].State, status.Hooks[1].State, status.Hooks[2].State}
+	if strings.Join(states, ",") != "done,failed,pending" || status.Hooks[1].Error == "" {
+		t.Errorf("Unexpected hook states: %+v", status.Hooks)
+	}
+
+	failCache = false
+	if err := base.RunStartup(context.Background()); err != nil {
+		t.Fatalf("Expected startup to succeed on retry, got %v", err)
+	}
+	if strings.Join(order, ",") != "database,cache,cache,migrations" {
+		t.Errorf("Expected completed hooks not to run again, got %v", order)
+	}
+	if !base.Started() || !base.Ready() {
+		t.Error("Expected the service to be started and ready")
+	}
+}
+
+func TestStartupEndpoint(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	router := chi.NewRouter()
+	base.AddStartupEndpoint(router, "startupz")
+	base.OnStartup("cache", func(ctx context.Context) error { return nil })
+
+	tests := []struct {
+		name        string
+		run         bool
+		wantCode    int
+		wantStarted bool
+	}{
+		{"pending", false, http.StatusServiceUnavailable, false},
+		{"complete", true, http.StatusOK, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.run {
+				if err := base.RunStartup(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/startupz", nil))
+			if w.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, w.Code)
+			}
+
+			var status StartupStatus
+			if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if status.Started != tt.wantStarted || len(status.Hooks) != 1 || status.Hooks[0].Name != "cache" {
+				t.Errorf("Unexpected startup status: %+v", status)
+			}
+		})
+	}
+}
+
+func TestServeRunsStartupHooks(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	release := make(chan struct{})
+	base.OnStartup("warmup", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	router := chi.NewRouter()
+	base.AddStartupEndpoint(router, "startupz")
+	router.Get("/orders", func(w http.ResponseWriter, r *http.Request) { base.ReturnOKJSON(w) })
+
+	url, cancel, reports := startTestServer(t, base, router)
+	get := func(path string) int {
+		resp, err := http.Get(url + path)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := get("/startupz"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the startup probe to report 503 while starting, got %d", code)
+	}
+	if code := get("/orders"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected application routes to be held back while starting, got %d", code)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for !base.Started() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if code := get("/orders"); code != http.StatusOK {
+		t.Errorf("Expected application routes to be served once started, got %d", code)
+	}
+
+	cancel()
+	if report := <-reports; report.ExitCode != ExitOK {
+		t.Errorf("Expected a clean exit, got %+v", report)
+	}
+}
+
+func TestServeStartupFailure(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	base.OnStartup("database", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	_, cancel, reports := startTestServer(t, base, http.NotFoundHandler())
+	defer cancel()
+
+	report := <-reports
+	if report.ExitCode != ExitStartupFailure || !strings.Contains(report.StartupError, "connection refused") {
+		t.Errorf("Expected a startup failure to stop the server, got %+v", report)
+	}
+}
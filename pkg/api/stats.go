@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// processStart approximates when the process started, as package variables are set before main runs
+var processStart = time.Now()
+
+// uptimeSeconds reports how long the service has been running, exposed by AddMetricsEndpoint
+var uptimeSeconds = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+	Name: "service_uptime_seconds",
+	Help: "Seconds since the service process started",
+}, func() float64 {
+	return time.Since(processStart).Seconds()
+})
+
+// serviceStats counts the requests served, for the status endpoint
+type serviceStats struct {
+	requests     atomic.Int64
+	clientErrors atomic.Int64
+	serverErrors atomic.Int64
+}
+
+// record counts a response by its status
+func (s *serviceStats) record(status int) {
+	s.requests.Add(1)
+	switch {
+	case status >= http.StatusInternalServerError:
+		s.serverErrors.Add(1)
+	case status >= http.StatusBadRequest:
+		s.clientErrors.Add(1)
+	}
+}
+
+// RuntimeStats is a snapshot of the service's own resource use and traffic
+type RuntimeStats struct {
+	StartTime time.Time `json:"startTime"`
+	// Requests, ClientErrors, and ServerErrors are counted by the middleware AddMetricsEndpoint
+	// installs, and exclude infrastructure routes
+	Requests     int64  `json:"requests"`
+	ClientErrors int64  `json:"clientErrors"`
+	ServerErrors int64  `json:"serverErrors"`
+	Goroutines   int    `json:"goroutines"`
+	HeapBytes    uint64 `json:"heapBytes"`
+	SysBytes     uint64 `json:"sysBytes"`
+	GCCycles     uint32 `json:"gcCycles"`
+}
+
+// StartTime returns when the service process started
+func (b *Base) StartTime() time.Time {
+	return processStart
+}
+
+// Uptime returns how long the service process has been running, rather than the machine
+func (b *Base) Uptime() time.Duration {
+	return time.Since(processStart)
+}
+
+// RuntimeStats returns the request counts and a snapshot of goroutine and memory use
+func (b *Base) RuntimeStats() RuntimeStats {
+	b.initOnce.Do(b.init)
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return RuntimeStats{
+		StartTime:    processStart,
+		Requests:     b.stats.requests.Load(),
+		ClientErrors: b.stats.clientErrors.Load(),
+		ServerErrors: b.stats.serverErrors.Load(),
+		Goroutines:   runtime.NumGoroutine(),
+		HeapBytes:    mem.HeapAlloc,
+		SysBytes:     mem.Sys,
+		GCCycles:     mem.NumGC,
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestRuntimeStats(t *testing.T) {
+	base := NewBase("TestService", "1.0.0", "test-build", true)
+	router := chi.NewRouter()
+	base.AddMetricsEndpoint(router, "metrics")
+	base.AddStatusEndpoint(router, "status")
+	router.Get("/orders", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	for _, path := range []string{"/orders", "/orders", "/fail", "/missing", "/metrics"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/status", nil))
+	var status Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+
+	stats := status.Stats
+	if stats.Requests != 4 || stats.ClientErrors != 1 || stats.ServerErrors != 1 {
+		t.Errorf("Expected 4 requests with one client and one server error, infrastructure excluded, got %+v", stats)
+	}
+	if stats.Goroutines == 0 || stats.HeapBytes == 0 || stats.SysBytes == 0 {
+		t.Errorf("Expected goroutine and memory stats, got %+v", stats)
+	}
+	if !stats.StartTime.Equal(base.StartTime()) || stats.StartTime.After(time.Now()) {
+		t.Errorf("Expected the process start time, got %s", stats.StartTime)
+	}
+	if uptime, err := time.ParseDuration(status.Uptime); err != nil || uptime > base.Uptime().Round(time.Second) {
+		t.Errorf("Expected the service uptime, got %q", status.Uptime)
+	}
+}
+
+func TestUptimeMetric(t *testing.T) {
+	var metric dto.Metric
+	if err := uptimeSeconds.Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+	if got := metric.GetGauge().GetValue(); got <= 0 || got > NewBase("", "", "", true).Uptime().Seconds() {
+		t.Errorf("Expected the uptime in seconds, got %f", got)
+	}
+}
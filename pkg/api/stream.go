@@ -0,0 +1,216 @@
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// JSONItems produces the elements of a streamed JSON array, passing each to yield, and stops when
+// yield returns an error because the client has gone
+type JSONItems func(yield func(item interface{}) error) error
+
+// CSVRows produces the records of a streamed CSV file, passing each to yield, and stops when yield
+// returns an error because the client has gone
+type CSVRows func(yield func(record []string) error) error
+
+// StreamConfig holds configuration for streamed responses
+type StreamConfig struct {
+	// FlushEvery is how many items are written between flushes to the client
+	FlushEvery int
+	// Filename, when set, asks browsers to download the response as an attachment
+	Filename string
+}
+
+// DefaultStreamConfig provides sensible defaults
+func DefaultStreamConfig() *StreamConfig {
+	return &StreamConfig{
+		FlushEvery: 100,
+	}
+}
+
+// StreamOption is a functional option for configuring streamed responses
+type StreamOption func(*StreamConfig)
+
+// WithFlushEvery sets how many items are written between flushes
+func WithFlushEvery(n int) StreamOption {
+	return func(config *StreamConfig) {
+		config.FlushEvery = n
+	}
+}
+
+// WithAttachment sends the response as a download with the given filename
+func WithAttachment(filename string) StreamOption {
+	return func(config *StreamConfig) {
+		config.Filename = filename
+	}
+}
+
+// NewStreamConfig creates a new stream config with options
+func NewStreamConfig(options ...StreamOption) *StreamConfig {
+	config := DefaultStreamConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// streamWriter writes a response incrementally, holding back the headers until the first item so
+// that an early failure can still be answered with a problem
+type streamWriter struct {
+	w           http.ResponseWriter
+	r           *http.Request
+	rc          *http.ResponseController
+	config      *StreamConfig
+	contentType string
+	// preamble is written after the headers, such as the opening bracket of a JSON array
+	preamble func()
+	started  bool
+	count    int
+}
+
+func newStreamWriter(w http.ResponseWriter, r *http.Request, contentType string,
+	options []StreamOption) *streamWriter {
+	return &streamWriter{
+		w:           w,
+		r:           r,
+		rc:          http.NewResponseController(w),
+		config:      NewStreamConfig(options...),
+		contentType: contentType,
+	}
+}
+
+// start sends the headers
+func (s *streamWriter) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+
+	s.w.Header().Set("Content-Type", s.contentType)
+	// Stops nginx buffering the stream
+	s.w.Header().Set("X-Accel-Buffering", "no")
+	if s.config.Filename != "" {
+		s.w.Header().Set("Content-Disposition",
+			mime.FormatMediaType("attachment", map[string]string{"filename": s.config.Filename}))
+	}
+	s.w.WriteHeader(http.StatusOK)
+	s.preamble()
+}
+
+// next is called before each item, returning an error once the client has gone, and reports
+// whether a flush is due
+func (s *streamWriter) next() (bool, error) {
+	if err := s.r.Context().Err(); err != nil {
+		return false, err
+	}
+	s.start()
+	s.count++
+	return s.config.FlushEvery > 0 && s.count%s.config.FlushEvery == 0, nil
+}
+
+// flush sends buffered output to the client, where the writer supports it
+func (s *streamWriter) flush() error {
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// fail answers with a problem if nothing has been sent yet; otherwise the response is left
+// incomplete, which is all that can be done once the status is sent
+func (s *streamWriter) fail(b *Base, err error) error {
+	if !s.started {
+		b.HandleError(s.w, s.r, err)
+	}
+	return err
+}
+
+// StreamJSONArray writes the items produced by items as a JSON array, encoding and flushing them as
+// they arrive, so an export never holds the whole result set in memory. If items fails before
+// producing anything the client receives a problem; after that the array is left unterminated so
+// the client sees invalid JSON rather than a silently truncated export. The error is returned in
+// both cases, for logging.
+func (b *Base) StreamJSONArray(w http.ResponseWriter, r *http.Request, items JSONItems,
+	options ...StreamOption) error {
+	s := newStreamWriter(w, r, "application/json", options)
+	buf := bufio.NewWriter(w)
+	s.preamble = func() { _ = buf.WriteByte('[') }
+
+	err := items(func(item interface{}) error {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to encode item: %w", err)
+		}
+
+		flush, err := s.next()
+		if err != nil {
+			return err
+		}
+		if s.count > 1 {
+			_ = buf.WriteByte(',')
+		}
+		if _, err := buf.Write(data); err != nil {
+			return err
+		}
+		if flush {
+			return flushAll(buf.Flush(), s)
+		}
+		return nil
+	})
+	if err != nil {
+		return s.fail(b, err)
+	}
+
+	s.start()
+	_ = buf.WriteByte(']')
+	return flushAll(buf.Flush(), s)
+}
+
+// StreamCSV writes a header row and then the records produced by rows as text/csv, flushing as they
+// arrive. Errors are handled as in StreamJSONArray, except that a CSV stream cut short can't be
+// marked as incomplete, so the client sees fewer rows.
+func (b *Base) StreamCSV(w http.ResponseWriter, r *http.Request, header []string, rows CSVRows,
+	options ...StreamOption) error {
+	s := newStreamWriter(w, r, "text/csv; charset=utf-8", options)
+	cw := csv.NewWriter(w)
+	s.preamble = func() {
+		if len(header) > 0 {
+			_ = cw.Write(header)
+		}
+	}
+
+	err := rows(func(record []string) error {
+		flush, err := s.next()
+		if err != nil {
+			return err
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+		if flush {
+			cw.Flush()
+			return flushAll(cw.Error(), s)
+		}
+		return nil
+	})
+	if err != nil {
+		return s.fail(b, err)
+	}
+
+	s.start()
+	cw.Flush()
+	return flushAll(cw.Error(), s)
+}
+
+// flushAll flushes the stream once its buffer has been flushed without error
+func flushAll(err error, s *streamWriter) error {
+	if err != nil {
+		return err
+	}
+	return s.flush()
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type exportedOrder struct {
+	ID    int    `json:"id"`
+	Total string `json:"total"`
+}
+
+func orderItems(n int, failAfter int) JSONItems {
+	return func(yield func(item interface{}) error) error {
+		for i := range n {
+			if i == failAfter {
+				return errors.New("connection reset")
+			}
+			if err := yield(exportedOrder{ID: i + 1, Total: "9.99"}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestStreamJSONArray(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+
+	tests := []struct {
+		name     string
+		items    JSONItems
+		wantCode int
+		wantBody string
+		wantErr  bool
+	}{
+		{"items", orderItems(3, -1), http.StatusOK,
+			`[{"id":1,"total":"9.99"},{"id":2,"total":"9.99"},{"id":3,"total":"9.99"}]`, false},
+		{"empty", orderItems(0, -1), http.StatusOK, `[]`, false},
+		{"fails before first item", orderItems(3, 0), http.StatusInternalServerError, "", true},
+		{"fails part way", orderItems(3, 2), http.StatusOK, `[{"id":1,"total":"9.99"},{"id":2,"total":"9.99"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			err := b.StreamJSONArray(w, httptest.NewRequest(http.MethodGet, "/orders/export", nil), tt.items,
+				WithFlushEvery(2))
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if w.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, w.Code)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("Expected body %s, got %s", tt.wantBody, w.Body.String())
+			}
+			if tt.wantCode == http.StatusOK && w.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("Unexpected Content-Type %q", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestStreamJSONArrayFlushes(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	w := httptest.NewRecorder()
+
+	items := func(yield func(item interface{}) error) error {
+		for i := range 4 {
+			if err := yield(i); err != nil {
+				return err
+			}
+			if i == 1 && (!w.Flushed || w.Body.String() != "[0,1") {
+				t.Errorf("Expected the first two items to be flushed, got %q", w.Body.String())
+			}
+		}
+		return nil
+	}
+
+	if err := b.StreamJSONArray(w, httptest.NewRequest(http.MethodGet, "/", nil), items, WithFlushEvery(2)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if w.Body.String() != "[0,1,2,3]" {
+		t.Errorf("Unexpected body %q", w.Body.String())
+	}
+}
+
+func TestStreamJSONArrayClientGone(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+	produced := 0
+	items := func(yield func(item interface{}) error) error {
+		for i := range 100 {
+			if i == 3 {
+				cancel()
+			}
+			if err := yield(i); err != nil {
+				return err
+			}
+			produced++
+		}
+		return nil
+	}
+
+	err := b.StreamJSONArray(httptest.NewRecorder(), r, items)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if produced != 3 {
+		t.Errorf("Expected production to stop once the client left, produced %d", produced)
+	}
+}
+
+func TestStreamCSV(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+
+	rows := func(yield func(record []string) error) error {
+		for _, record := range [][]string{{"1", "Ann"}, {"2", "Smith, Bob"}} {
+			if err := yield(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	err := b.StreamCSV(w, httptest.NewRequest(http.MethodGet, "/customers.csv", nil), []string{"id", "name"}, rows,
+		WithAttachment("customers.csv"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if w.Body.String() != "id,name\n1,Ann\n2,\"Smith, Bob\"\n" {
+		t.Errorf("Unexpected body %q", w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Errorf("Unexpected Content-Type %q", w.Header().Get("Content-Type"))
+	}
+	if w.Header().Get("Content-Disposition") != `attachment; filename=customers.csv` {
+		t.Errorf("Unexpected Content-Disposition %q", w.Header().Get("Content-Disposition"))
+	}
+}
+
+func TestStreamCSVEmpty(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	w := httptest.NewRecorder()
+
+	err := b.StreamCSV(w, httptest.NewRequest(http.MethodGet, "/", nil), []string{"id", "name"},
+		func(yield func(record []string) error) error { return nil })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if w.Code != http.StatusOK || w.Body.String() != "id,name\n" {
+		t.Errorf("Expected just the header row, got %d %q", w.Code, w.Body.String())
+	}
+}
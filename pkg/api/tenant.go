@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"github.com/Okja-Engineering/go-service-kit/pkg/tenant"
+)
+
+// TenantConfig holds configuration for resolving the caller's tenant
+type TenantConfig struct {
+	// Claim is the JWT claim holding the tenant ID, read from claims verified by an auth.Validator
+	Claim string
+	// Header carries the tenant ID when there is no claim. Any client can send it, so set it only when a
+	// trusted gateway sets the header and strips it from client requests; empty, the default, disables it.
+	Header string
+	// Required rejects requests without a tenant with 400
+	Required bool
+}
+
+// DefaultTenantConfig provides sensible defaults
+func DefaultTenantConfig() *TenantConfig {
+	return &TenantConfig{
+		Claim:    "tenant_id",
+		Header:   "",
+		Required: false,
+	}
+}
+
+// TenantOption is a functional option for configuring tenant resolution
+type TenantOption func(*TenantConfig)
+
+// WithTenantClaim sets the JWT claim holding the tenant ID
+func WithTenantClaim(claim string) TenantOption {
+	return func(config *TenantConfig) {
+		config.Claim = claim
+	}
+}
+
+// WithTenantHeader trusts the header to carry the tenant ID when there is no claim, such as X-Tenant-ID
+// set by a gateway; only use it when clients can't send the header themselves
+func WithTenantHeader(header string) TenantOption {
+	return func(config *TenantConfig) {
+		config.Header = header
+	}
+}
+
+// WithTenantRequired rejects requests without a tenant
+func WithTenantRequired(required bool) TenantOption {
+	return func(config *TenantConfig) {
+		config.Required = required
+	}
+}
+
+// NewTenantConfig creates a new tenant config with options
+func NewTenantConfig(options ...TenantOption) *TenantConfig {
+	config := DefaultTenantConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// TenantMiddleware resolves the caller's tenant and stores it with tenant.WithID, where
+// TenantFromContext, database query stats and repositories, events, and logging find it. A verified
+// JWT claim takes precedence over the header, so a caller cannot switch tenants by sending a header.
+// Place it before the logging middleware so request logs carry the tenant.
+func (b *Base) TenantMiddleware(config *TenantConfig) func(next http.Handler) http.Handler {
+	if config == nil {
+		config = DefaultTenantConfig()
+	}
+
+	log.Printf("### 🤖 API: tenant resolution from claim %q and header %q", config.Claim, config.Header)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID := resolveTenant(r, config)
+			if tenantID == "" {
+				if config.Required && !b.isInfrastructure(r) {
+					problem.New("tenant-required", "Tenant Required", http.StatusBadRequest,
+						"The request does not identify a tenant", r.URL.Path).Respond(w, r)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(tenant.WithID(r.Context(), tenantID)))
+		})
+	}
+}
+
+// resolveTenant reads the tenant from verified claims, then the header
+func resolveTenant(r *http.Request, config *TenantConfig) string {
+	if claims, ok := auth.GetClaimsFromContext(r.Context()); ok && config.Claim != "" {
+		if tenantID, _ := claims[config.Claim].(string); tenantID != "" {
+			return tenantID
+		}
+	}
+	if config.Header != "" {
+		return r.Header.Get(config.Header)
+	}
+	return ""
+}
+
+// TenantFromContext returns the tenant stored by TenantMiddleware, as tenant.FromContext
+func TenantFromContext(ctx context.Context) (string, bool) {
+	return tenant.FromContext(ctx)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/Okja-Engineering/go-service-kit/pkg/tenant"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// withClaims stores claims as a validator would
+func withClaims(r *http.Request, claims jwt.MapClaims) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), auth.JWTClaimsKey, claims))
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+
+	tests := []struct {
+		name       string
+		config     *TenantConfig
+		claims     jwt.MapClaims
+		header     string
+		wantTenant string
+		wantStatus int
+	}{
+		{"claim", nil, jwt.MapClaims{"tenant_id": "acme"}, "", "acme", http.StatusOK},
+		{"claim wins over header", nil, jwt.MapClaims{"tenant_id": "acme"}, "globex", "acme", http.StatusOK},
+		{"header ignored by default", nil, nil, "globex", "", http.StatusOK},
+		{"trusted header", NewTenantConfig(WithTenantHeader("X-Tenant-ID")), nil, "globex", "globex",
+			http.StatusOK},
+		{"claim wins over trusted header", NewTenantConfig(WithTenantHeader("X-Tenant-ID")),
+			jwt.MapClaims{"tenant_id": "acme"}, "globex", "acme", http.StatusOK},
+		{"custom claim", NewTenantConfig(WithTenantClaim("org")), jwt.MapClaims{"org": "initech"}, "", "initech",
+			http.StatusOK},
+		{"none", nil, nil, "", "", http.StatusOK},
+		{"required", NewTenantConfig(WithTenantRequired(true)), nil, "", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotTenant string
+			handler := base.TenantMiddleware(tt.config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotTenant, _ = TenantFromContext(r.Context())
+				if tenant.ID(r.Context()) != gotTenant {
+					t.Errorf("Expected the tenant to be shared through the tenant package, got %q",
+						tenant.ID(r.Context()))
+				}
+			}))
+
+			req := httptest.NewRequest("GET", "/orders", nil)
+			if tt.claims != nil {
+				req = withClaims(req, tt.claims)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if gotTenant != tt.wantTenant {
+				t.Errorf("Expected tenant %q, got %q", tt.wantTenant, gotTenant)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"container/list"
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
+)
+
+// TenantLimit is a tenant's rate limit, overriding the middleware's default
+type TenantLimit struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	Burst             int     `json:"burst"`
+}
+
+// TenantLimitStore looks up per-tenant rate limit overrides, e.g. from a plans table. ok is false
+// for tenants on the default limit.
+type TenantLimitStore interface {
+	TenantLimit(ctx context.Context, tenantID string) (limit TenantLimit, ok bool, err error)
+}
+
+// StaticTenantLimits is a TenantLimitStore backed by a fixed map
+type StaticTenantLimits map[string]TenantLimit
+
+// TenantLimit returns the tenant's entry in the map
+func (s StaticTenantLimits) TenantLimit(_ context.Context, tenantID string) (TenantLimit, bool, error) {
+	limit, ok := s[tenantID]
+	return limit, ok, nil
+}
+
+// tenantOverrideTTL is how long a tenant's override is cached before the store is asked again
+const tenantOverrideTTL = time.Minute
+
+// cachedTenantLimit is a store answer and when it expires
+type cachedTenantLimit struct {
+	tenantID string
+	limit    TenantLimit
+	ok       bool
+	expires  time.Time
+}
+
+// tenantLimits caches the overrides from a TenantLimitStore, forgetting the least recently used
+// tenants beyond maxEntries
+type tenantLimits struct {
+	store      TenantLimitStore
+	clock      clock.Clock
+	maxEntries int
+
+	mu    sync.Mutex
+	cache map[string]*list.Element
+	order *list.List // most recently used first
+}
+
+// newTenantLimits creates a cache of the overrides from store; maxEntries of zero is unlimited
+func newTenantLimits(store TenantLimitStore, c clock.Clock, maxEntries int) *tenantLimits {
+	return &tenantLimits{
+		store:      store,
+		clock:      c,
+		maxEntries: maxEntries,
+		cache:      make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// get returns a tenant's cached answer if it hasn't expired
+func (t *tenantLimits) get(tenantID string, now time.Time) (cachedTenantLimit, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elem, found := t.cache[tenantID]
+	if !found {
+		return cachedTenantLimit{}, false
+	}
+	cached := elem.Value.(*cachedTenantLimit)
+	if now.After(cached.expires) {
+		t.order.Remove(elem)
+		delete(t.cache, tenantID)
+		return cachedTenantLimit{}, false
+	}
+	t.order.MoveToFront(elem)
+	return *cached, true
+}
+
+// put caches a tenant's answer, evicting the least recently used tenants beyond maxEntries
+func (t *tenantLimits) put(cached cachedTenantLimit) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if elem, found := t.cache[cached.tenantID]; found {
+		t.order.Remove(elem)
+	}
+	t.cache[cached.tenantID] = t.order.PushFront(&cached)
+
+	for t.maxEntries > 0 && t.order.Len() > t.maxEntries {
+		oldest := t.order.Back()
+		t.order.Remove(oldest)
+		delete(t.cache, oldest.Value.(*cachedTenantLimit).tenantID)
+	}
+}
+
+// lookup returns a tenant's limit: its override, or the default when it has none or the store fails
+func (t *tenantLimits) lookup(ctx context.Context, tenantID string, fallback TenantLimit) TenantLimit {
+	if t.store == nil {
+		return fallback
+	}
+
+	now := t.clock.Now()
+	cached, found := t.get(tenantID, now)
+	if !found {
+		limit, ok, err := t.store.TenantLimit(ctx, tenantID)
+		if err != nil {
+			log.Printf("### 🚫 Rate limit override lookup failed for tenant %s: %v", tenantID, err)
+			return fallback
+		}
+
+		cached = cachedTenantLimit{tenantID: tenantID, limit: limit, ok: ok, expires: now.Add(tenantOverrideTTL)}
+		t.put(cached)
+	}
+
+	if !cached.ok {
+		return fallback
+	}
+	return cached.limit
+}
+
+// RateLimitByTenant creates middleware that rate limits by the tenant resolved by TenantMiddleware,
+// so every user of a tenant shares its quota. Overrides from store, which may be nil, give
+// individual tenants their own limits and are cached for a minute, for at most MaxKeys tenants.
+// Requests without a tenant are not limited.
+func (b *Base) RateLimitByTenant(
+	config *RateLimiterConfig, store TenantLimitStore,
+) func(next http.Handler) http.Handler {
+	if config == nil {
+		config = DefaultRateLimiterConfig()
+	}
+
+	limiter := newRateLimiter(config)
+	b.trackLimiter("tenant", limiter)
+	overrides := newTenantLimits(store, limiter.clock, config.MaxKeys)
+	fallback := TenantLimit{RequestsPerSecond: config.RequestsPerSecond, Burst: config.Burst}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID, _ := TenantFromContext(r.Context())
+			if tenantID == "" || b.isInfrastructure(r) || limiter.exempt.exempt(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			limit := overrides.lookup(r.Context(), tenantID, fallback)
+			tenantLimiter := limiter.getLimiterWith("tenant:"+tenantID, limit.RequestsPerSecond, limit.Burst)
+
+			now := limiter.clock.Now()
+			allowed := tenantLimiter.allow(now)
+			setRateLimitHeaders(w, tenantLimiter, now)
+			if !allowed {
+				log.Printf("### 🚫 Rate limit exceeded for tenant: %s", tenantID)
+				writeRateLimitExceeded(w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
+)
+
+// failingTenantStore fails every lookup
+type failingTenantStore struct{}
+
+func (failingTenantStore) TenantLimit(context.Context, string) (TenantLimit, bool, error) {
+	return TenantLimit{}, false, errors.New("store unavailable")
+}
+
+func TestRateLimitByTenant(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	store := StaticTenantLimits{"enterprise": {RequestsPerSecond: 1, Burst: 3}}
+	config := NewRateLimiterConfig(WithRequestsPerSecond(1), WithBurst(1))
+
+	tenants := NewTenantConfig(WithTenantHeader("X-Tenant-ID"))
+	handler := base.TenantMiddleware(tenants)(base.RateLimitByTenant(config, store)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	send := func(tenantID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/orders", nil)
+		if tenantID != "" {
+			req.Header.Set("X-Tenant-ID", tenantID)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name     string
+		tenantID string
+		allowed  int
+	}{
+		{"default limit", "trial", 1},
+		{"override", "enterprise", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < tt.allowed; i++ {
+				if rec := send(tt.tenantID); rec.Code != http.StatusOK {
+					t.Fatalf("Expected request %d to be allowed, got %d", i+1, rec.Code)
+				}
+			}
+
+			rec := send(tt.tenantID)
+			if rec.Code != http.StatusTooManyRequests {
+				t.Errorf("Expected 429 after %d requests, got %d", tt.allowed, rec.Code)
+			}
+			if rec.Header().Get("X-RateLimit-Remaining") != "0" {
+				t.Errorf("Expected no remaining requests, got %q", rec.Header().Get("X-RateLimit-Remaining"))
+			}
+		})
+	}
+
+	for i := 0; i < 3; i++ {
+		if rec := send(""); rec.Code != http.StatusOK {
+			t.Fatalf("Expected requests without a tenant not to be limited, got %d", rec.Code)
+		}
+	}
+}
+
+func TestRateLimitByTenantStoreFailure(t *testing.T) {
+	base := NewBase("test", "1.0.0", "test", true)
+	tenants := NewTenantConfig(WithTenantHeader("X-Tenant-ID"))
+	handler := base.TenantMiddleware(tenants)(base.RateLimitByTenant(
+		NewRateLimiterConfig(WithRequestsPerSecond(1), WithBurst(2)), failingTenantStore{})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	req := httptest.NewRequest("GET", "/orders", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "2" {
+		t.Errorf("Expected the default limit when the store fails, got %d with limit %q",
+			rec.Code, rec.Header().Get("X-RateLimit-Limit"))
+	}
+}
+
+func TestTenantLimitsCache(t *testing.T) {
+	calls := 0
+	store := tenantLimitStoreFunc(func(context.Context, string) (TenantLimit, bool, error) {
+		calls++
+		return TenantLimit{RequestsPerSecond: 5, Burst: 10}, true, nil
+	})
+	clk := clock.NewFake(time.Now())
+	limits := newTenantLimits(store, clk, 2)
+
+	for range 3 {
+		if limit := limits.lookup(context.Background(), "acme", TenantLimit{}); limit.Burst != 10 {
+			t.Fatalf("Expected the override, got %+v", limit)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the override to be cached, got %d store calls", calls)
+	}
+
+	clk.Advance(tenantOverrideTTL + time.Second)
+	limits.lookup(context.Background(), "acme", TenantLimit{})
+	if calls != 2 {
+		t.Errorf("Expected the store to be asked again once the cache expired, got %d store calls", calls)
+	}
+
+	limits.lookup(context.Background(), "globex", TenantLimit{})
+	limits.lookup(context.Background(), "acme", TenantLimit{})
+	limits.lookup(context.Background(), "initech", TenantLimit{})
+	if len(limits.cache) != 2 || limits.cache["globex"] != nil {
+		t.Errorf("Expected the least recently used tenant to be evicted, got %d cached", len(limits.cache))
+	}
+	if calls != 4 {
+		t.Errorf("Expected acme to stay cached, got %d store calls", calls)
+	}
+}
+
+// tenantLimitStoreFunc adapts a function to TenantLimitStore
+type tenantLimitStoreFunc func(ctx context.Context, tenantID string) (TenantLimit, bool, error)
+
+func (f tenantLimitStoreFunc) TenantLimit(ctx context.Context, tenantID string) (TenantLimit, bool, error) {
+	return f(ctx, tenantID)
+}
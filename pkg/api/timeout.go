@@ -0,0 +1,177 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// TimeoutConfig holds configuration for per-request deadlines
+type TimeoutConfig struct {
+	// Timeout is how long a handler may take before the client receives an error
+	Timeout time.Duration
+	// Status is sent on expiry, 503 Service Unavailable or 408 Request Timeout
+	Status int
+}
+
+// DefaultTimeoutConfig provides sensible defaults
+func DefaultTimeoutConfig() *TimeoutConfig {
+	return &TimeoutConfig{
+		Timeout: 30 * time.Second,
+		Status:  http.StatusServiceUnavailable,
+	}
+}
+
+// TimeoutOption is a functional option for configuring request deadlines
+type TimeoutOption func(*TimeoutConfig)
+
+// WithRequestTimeout sets how long a handler may take
+func WithRequestTimeout(timeout time.Duration) TimeoutOption {
+	return func(config *TimeoutConfig) {
+		config.Timeout = timeout
+	}
+}
+
+// WithTimeoutStatus sets the status sent on expiry, e.g. http.StatusRequestTimeout
+func WithTimeoutStatus(status int) TimeoutOption {
+	return func(config *TimeoutConfig) {
+		config.Status = status
+	}
+}
+
+// NewTimeoutConfig creates a new timeout config with options
+func NewTimeoutConfig(options ...TimeoutOption) *TimeoutConfig {
+	config := DefaultTimeoutConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Timeout creates middleware that gives each request a deadline. Handlers see it on the request
+// context; if they haven't finished when it expires the client receives a problem+json error and
+// anything written afterwards is discarded. Responses are buffered, so don't use it on streaming routes.
+func (b *Base) Timeout(config *TimeoutConfig) func(next http.Handler) http.Handler {
+	if config == nil {
+		config = DefaultTimeoutConfig()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), config.Timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+
+			go func() {
+				defer func() {
+					if rec := recover(); rec != nil {
+						panicked <- rec
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case rec := <-panicked:
+				// Re-panic on the serving goroutine so Recoverer and net/http see it
+				panic(rec)
+			case <-done:
+				tw.flushTo(w)
+			case <-ctx.Done():
+				tw.expire()
+				problem.New("request-timeout", http.StatusText(config.Status), config.Status,
+					fmt.Sprintf("The request did not complete within %s", config.Timeout), r.URL.Path).Respond(w, r)
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a handler's response until it completes or its deadline expires
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
+
+// expire discards the buffered response, later writes fail with http.ErrHandlerTimeout
+func (tw *timeoutWriter) expire() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.timedOut = true
+}
+
+// flushTo copies the buffered response to the real writer
+func (tw *timeoutWriter) flushTo(w http.ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	for name, values := range tw.header {
+		w.Header()[name] = values
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	w.WriteHeader(tw.status)
+	_, _ = w.Write(tw.buf.Bytes())
+}
+
+// MaxBodySize creates middleware that limits request bodies to limit bytes. Requests that declare a
+// larger Content-Length are rejected with a 413 problem straight away; otherwise reads fail once the
+// limit is passed, which DecodeJSON and HandleError also turn into a 413.
+func (b *Base) MaxBodySize(limit int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				problem.New("body-too-large", "Request Body Too Large", http.StatusRequestEntityTooLarge,
+					fmt.Sprintf("request body must not be larger than %d bytes", limit), r.URL.Path).Respond(w, r)
+				return
+			}
+
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+
+	tests := []struct {
+		name       string
+		config     *TimeoutConfig
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:   "completes in time",
+			config: NewTimeoutConfig(WithRequestTimeout(time.Second)),
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Test", "yes")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("created"))
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   "created",
+		},
+		{
+			name:   "expires with 503",
+			config: NewTimeoutConfig(WithRequestTimeout(10 * time.Millisecond)),
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				_, _ = w.Write([]byte("too late"))
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `"type":"request-timeout"`,
+		},
+		{
+			name: "expires with 408",
+			config: NewTimeoutConfig(WithRequestTimeout(10*time.Millisecond),
+				WithTimeoutStatus(http.StatusRequestTimeout)),
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(50 * time.Millisecond)
+			},
+			wantStatus: http.StatusRequestTimeout,
+			wantBody:   `"status":408`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			b.Timeout(tt.config)(tt.handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("Expected body to contain %q, got %q", tt.wantBody, w.Body.String())
+			}
+			if strings.Contains(w.Body.String(), "too late") {
+				t.Error("Expected writes after expiry to be discarded")
+			}
+		})
+	}
+}
+
+func TestTimeoutDiscardsLateWrites(t *testing.T) {
+	tw := &timeoutWriter{header: make(http.Header)}
+	tw.expire()
+
+	if _, err := tw.Write([]byte("late")); !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("Expected ErrHandlerTimeout, got %v", err)
+	}
+}
+
+func TestTimeoutPropagatesPanics(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	handler := b.Recoverer(NewRecovererConfig(WithRecovererLogger(&bufferLogger{})))(
+		b.Timeout(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected panic to reach Recoverer, got %d", w.Code)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	handler := b.MaxBodySize(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			b.HandleError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		wantStatus    int
+	}{
+		{"within limit", "small", 5, http.StatusNoContent},
+		{"declared too large", strings.Repeat("x", 20), 20, http.StatusRequestEntityTooLarge},
+		{"streamed too large", strings.Repeat("x", 20), -1, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(tt.body))
+			r.ContentLength = tt.contentLength
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus == http.StatusRequestEntityTooLarge &&
+				w.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("Expected problem+json, got %q", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
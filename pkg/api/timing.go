@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timing is a named duration reported in the Server-Timing header
+type Timing struct {
+	Name        string
+	Description string
+	Duration    time.Duration
+}
+
+// timings collects the durations recorded while serving a request
+type timings struct {
+	mu      sync.Mutex
+	entries []Timing
+}
+
+type timingsContextKey struct{}
+
+// RecordTiming adds a duration to the request's Server-Timing breakdown. Durations recorded
+// under the same name are summed. It is a no-op when the ResponseTime middleware is not in use.
+func RecordTiming(ctx context.Context, name, description string, d time.Duration) {
+	t, ok := ctx.Value(timingsContextKey{}).(*timings)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.entries {
+		if t.entries[i].Name == name {
+			t.entries[i].Duration += d
+			return
+		}
+	}
+	t.entries = append(t.entries, Timing{Name: name, Description: description, Duration: d})
+}
+
+// StartTiming starts timing a step and returns a function that records it when called:
+//
+//	defer api.StartTiming(r.Context(), "db", "Database")()
+func StartTiming(ctx context.Context, name, description string) func() {
+	start := time.Now()
+	return func() {
+		RecordTiming(ctx, name, description, time.Since(start))
+	}
+}
+
+// snapshot returns a copy of the recorded timings
+func (t *timings) snapshot() []Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Timing(nil), t.entries...)
+}
+
+// ResponseTime returns middleware that sets X-Response-Time on every response. When a request
+// takes longer than budget, a Server-Timing header is added with the durations recorded via
+// RecordTiming and the total, so clients can see where the time went. A zero budget always
+// includes Server-Timing.
+//
+// Headers are written when the handler first writes the response, so the durations cover the
+// work done before the response started.
+func ResponseTime(budget time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t := &timings{}
+			tw := &timingWriter{ResponseWriter: w, start: time.Now(), budget: budget, timings: t}
+
+			next.ServeHTTP(tw, r.WithContext(context.WithValue(r.Context(), timingsContextKey{}, t)))
+
+			// Handlers that never write still get the headers
+			tw.writeTimingHeaders()
+		})
+	}
+}
+
+// timingWriter sets timing headers just before the response header is written
+type timingWriter struct {
+	http.ResponseWriter
+	start   time.Time
+	budget  time.Duration
+	timings *timings
+	written bool
+}
+
+func (tw *timingWriter) WriteHeader(statusCode int) {
+	tw.writeTimingHeaders()
+	tw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (tw *timingWriter) Write(b []byte) (int, error) {
+	tw.writeTimingHeaders()
+	return tw.ResponseWriter.Write(b)
+}
+
+// Flush supports streaming handlers when the underlying writer can flush
+func (tw *timingWriter) Flush() {
+	tw.writeTimingHeaders()
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (tw *timingWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+func (tw *timingWriter) writeTimingHeaders() {
+	if tw.written {
+		return
+	}
+	tw.written = true
+
+	elapsed := time.Since(tw.start)
+	tw.Header().Set("X-Response-Time", formatMillis(elapsed)+"ms")
+
+	if elapsed >= tw.budget {
+		tw.Header().Set("Server-Timing", serverTiming(tw.timings.snapshot(), elapsed))
+	}
+}
+
+// serverTiming formats timings as a Server-Timing header value
+func serverTiming(entries []Timing, total time.Duration) string {
+	parts := make([]string, 0, len(entries)+1)
+	for _, e := range entries {
+		part := fmt.Sprintf("%s;dur=%s", e.Name, formatMillis(e.Duration))
+		if e.Description != "" {
+			part += fmt.Sprintf(";desc=%q", e.Description)
+		}
+		parts = append(parts, part)
+	}
+	parts = append(parts, "total;dur="+formatMillis(total))
+
+	return strings.Join(parts, ", ")
+}
+
+// formatMillis formats a duration in milliseconds with microsecond precision
+func formatMillis(d time.Duration) string {
+	return fmt.Sprintf("%.3f", float64(d.Microseconds())/1000)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseTimeHeader(t *testing.T) {
+	handler := ResponseTime(time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !regexp.MustCompile(`^\d+\.\d{3}ms$`).MatchString(w.Header().Get("X-Response-Time")) {
+		t.Errorf("Unexpected X-Response-Time: %q", w.Header().Get("X-Response-Time"))
+	}
+	if w.Header().Get("Server-Timing") != "" {
+		t.Error("Expected no Server-Timing within budget")
+	}
+}
+
+func TestResponseTimeServerTimingOverBudget(t *testing.T) {
+	handler := ResponseTime(time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		done := StartTiming(r.Context(), "db", "Database")
+		time.Sleep(2 * time.Millisecond)
+		done()
+		RecordTiming(r.Context(), "db", "Database", time.Millisecond)
+		RecordTiming(r.Context(), "render", "", 500*time.Microsecond)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", w.Code)
+	}
+
+	header := w.Header().Get("Server-Timing")
+	parts := strings.Split(header, ", ")
+	if len(parts) != 3 {
+		t.Fatalf("Expected db, render, and total entries, got %q", header)
+	}
+	if !strings.HasPrefix(parts[0], "db;dur=") || !strings.HasSuffix(parts[0], `;desc="Database"`) {
+		t.Errorf("Unexpected db entry: %q", parts[0])
+	}
+	if parts[1] != "render;dur=0.500" {
+		t.Errorf("Unexpected render entry: %q", parts[1])
+	}
+	if !strings.HasPrefix(parts[2], "total;dur=") {
+		t.Errorf("Unexpected total entry: %q", parts[2])
+	}
+}
+
+func TestResponseTimeHandlerWithoutWrite(t *testing.T) {
+	handler := ResponseTime(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Header().Get("X-Response-Time") == "" || w.Header().Get("Server-Timing") == "" {
+		t.Error("Expected timing headers even when the handler does not write")
+	}
+}
+
+func TestRecordTimingWithoutMiddleware(t *testing.T) {
+	// Must not panic when no collector is present
+	RecordTiming(context.Background(), "db", "", time.Millisecond)
+	StartTiming(context.Background(), "db", "")()
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// ErrBodyTooLarge is returned by body transformers whose output would exceed the size limit
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// BodyTransformer rewrites a request body before handlers see it. It may update r.Header, for
+// example the Content-Type, and returns the body unchanged when the request doesn't apply.
+type BodyTransformer func(r *http.Request, body []byte) ([]byte, error)
+
+// BodyTransformConfig holds configuration for request body transformation
+type BodyTransformConfig struct {
+	// MaxBodySize limits the body as received and after each transformation
+	MaxBodySize int64
+	// Transformers run in order
+	Transformers []BodyTransformer
+	// ContentTypeRewrites maps legacy media types to the ones handlers expect, applied first
+	ContentTypeRewrites map[string]string
+}
+
+// DefaultBodyTransformConfig provides sensible defaults with no transformers
+func DefaultBodyTransformConfig() *BodyTransformConfig {
+	return &BodyTransformConfig{
+		MaxBodySize:         1 << 20,
+		ContentTypeRewrites: make(map[string]string),
+	}
+}
+
+// BodyTransformOption is a functional option for configuring body transformation
+type BodyTransformOption func(*BodyTransformConfig)
+
+// WithTransformMaxBodySize sets the body size limit
+func WithTransformMaxBodySize(size int64) BodyTransformOption {
+	return func(config *BodyTransformConfig) {
+		config.MaxBodySize = size
+	}
+}
+
+// WithBodyTransformers appends transformers to the chain
+func WithBodyTransformers(transformers ...BodyTransformer) BodyTransformOption {
+	return func(config *BodyTransformConfig) {
+		config.Transformers = append(config.Transformers, transformers...)
+	}
+}
+
+// WithContentTypeRewrite replaces the media type from with to, e.g. "text/json" with "application/json"
+func WithContentTypeRewrite(from, to string) BodyTransformOption {
+	return func(config *BodyTransformConfig) {
+		config.ContentTypeRewrites[strings.ToLower(from)] = to
+	}
+}
+
+// NewBodyTransformConfig creates a new body transform config with options
+func NewBodyTransformConfig(options ...BodyTransformOption) *BodyTransformConfig {
+	config := DefaultBodyTransformConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// TransformBody creates middleware that reads the request body within the size limit and runs it
+// through the configured transformers. Apply it to a route group to preprocess bodies for legacy
+// clients. Oversized bodies are rejected with 413 and failed transformations with 400.
+func (b *Base) TransformBody(config *BodyTransformConfig) func(next http.Handler) http.Handler {
+	if config == nil {
+		config = DefaultBodyTransformConfig()
+	}
+
+	log.Printf("### 🤖 API: body transformation with %d transformer(s)", len(config.Transformers))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r = r.Clone(r.Context())
+			rewriteContentType(r, config.ContentTypeRewrites)
+
+			body, err := transformBody(r, config)
+			if err != nil {
+				sendTransformError(w, r, err)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// transformBody reads the body and runs the transformers, enforcing the size limit at each step
+func transformBody(r *http.Request, config *BodyTransformConfig) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, config.MaxBodySize+1))
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	for i := 0; ; i++ {
+		if int64(len(body)) > config.MaxBodySize {
+			return nil, ErrBodyTooLarge
+		}
+		if i == len(config.Transformers) {
+			return body, nil
+		}
+		if body, err = config.Transformers[i](r, body); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func rewriteContentType(r *http.Request, rewrites map[string]string) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return
+	}
+	if to, ok := rewrites[mediaType]; ok {
+		r.Header.Set("Content-Type", mime.FormatMediaType(to, params))
+	}
+}
+
+func sendTransformError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrBodyTooLarge) {
+		problem.New("body-too-large", "Request Body Too Large", http.StatusRequestEntityTooLarge,
+			err.Error(), r.URL.Path).Respond(w, r)
+		return
+	}
+	problem.New("invalid-body", "Invalid Request Body", http.StatusBadRequest, err.Error(), r.URL.Path).Respond(w, r)
+}
+
+// GunzipBody decompresses bodies sent with Content-Encoding: gzip, limiting the decompressed size
+func GunzipBody(maxSize int64) BodyTransformer {
+	return func(r *http.Request, body []byte) ([]byte, error) {
+		if !strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+			return body, nil
+		}
+
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer zr.Close()
+
+		out, err := io.ReadAll(io.LimitReader(zr, maxSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		if int64(len(out)) > maxSize {
+			return nil, ErrBodyTooLarge
+		}
+
+		r.Header.Del("Content-Encoding")
+		return out, nil
+	}
+}
+
+// StripBOM removes a leading UTF-8 byte order mark, which JSON decoders reject
+func StripBOM() BodyTransformer {
+	return func(r *http.Request, body []byte) ([]byte, error) {
+		return bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")), nil
+	}
+}
+
+// XMLToJSON converts XML bodies to JSON for handlers that only speak JSON. The root element becomes
+// the single key of an object; attributes are prefixed with "@", mixed text is kept under "#text",
+// repeated elements become arrays, and elements with only text become strings.
+func XMLToJSON() BodyTransformer {
+	return func(r *http.Request, body []byte) ([]byte, error) {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/xml" && mediaType != "text/xml" && !strings.HasSuffix(mediaType, "+xml") {
+			return body, nil
+		}
+
+		root, err := parseXMLTree(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML body: %w", err)
+		}
+
+		out, err := json.Marshal(map[string]interface{}{root.name: root.value()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert XML body: %w", err)
+		}
+
+		r.Header.Set("Content-Type", "application/json")
+		return out, nil
+	}
+}
+
+// xmlNode is an element of a parsed XML document
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     strings.Builder
+}
+
+// parseXMLTree parses a document into a tree of elements, returning the root
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*xmlNode
+	var root *xmlNode
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local, attrs: t.Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+	return root, nil
+}
+
+// value converts an element to its JSON representation
+func (n *xmlNode) value() interface{} {
+	text := strings.TrimSpace(n.text.String())
+	if len(n.attrs) == 0 && len(n.children) == 0 {
+		return text
+	}
+
+	obj := make(map[string]interface{})
+	for _, attr := range n.attrs {
+		obj["@"+attr.Name.Local] = attr.Value
+	}
+	for _, child := range n.children {
+		value := child.value()
+		switch existing := obj[child.name].(type) {
+		case nil:
+			obj[child.name] = value
+		case []interface{}:
+			obj[child.name] = append(existing, value)
+		default:
+			obj[child.name] = []interface{}{existing, value}
+		}
+	}
+	if text != "" {
+		obj["#text"] = text
+	}
+
+	return obj
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipBytes(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		t.Fatalf("Failed to gzip: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestTransformBody(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+
+	tests := []struct {
+		name            string
+		options         []BodyTransformOption
+		body            []byte
+		headers         map[string]string
+		wantStatus      int
+		wantBody        string
+		wantContentType string
+	}{
+		{
+			name:            "gzip",
+			options:         []BodyTransformOption{WithBodyTransformers(GunzipBody(1 << 20))},
+			body:            gzipBytes(t, `{"a":1}`),
+			headers:         map[string]string{"Content-Encoding": "gzip", "Content-Type": "application/json"},
+			wantStatus:      http.StatusOK,
+			wantBody:        `{"a":1}`,
+			wantContentType: "application/json",
+		},
+		{
+			name:       "gzip bomb",
+			options:    []BodyTransformOption{WithBodyTransformers(GunzipBody(100))},
+			body:       gzipBytes(t, strings.Repeat("a", 1000)),
+			headers:    map[string]string{"Content-Encoding": "gzip"},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "invalid gzip",
+			options:    []BodyTransformOption{WithBodyTransformers(GunzipBody(100))},
+			body:       []byte("not gzip"),
+			headers:    map[string]string{"Content-Encoding": "gzip"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "raw body too large",
+			options:    []BodyTransformOption{WithTransformMaxBodySize(4)},
+			body:       []byte("too large"),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "bom",
+			options:    []BodyTransformOption{WithBodyTransformers(StripBOM())},
+			body:       []byte("\xef\xbb\xbf{}"),
+			wantStatus: http.StatusOK,
+			wantBody:   "{}",
+		},
+		{
+			name: "xml",
+			options: []BodyTransformOption{
+				WithBodyTransformers(XMLToJSON()),
+				WithContentTypeRewrite("text/xml", "application/xml"),
+			},
+			body:            []byte(`<order id="7"><item>a</item><item>b</item><note>rush</note></order>`),
+			headers:         map[string]string{"Content-Type": "text/xml; charset=utf-8"},
+			wantStatus:      http.StatusOK,
+			wantBody:        `{"order":{"@id":"7","item":["a","b"],"note":"rush"}}`,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "content type rewrite",
+			options:         []BodyTransformOption{WithContentTypeRewrite("text/json", "application/json")},
+			body:            []byte("{}"),
+			headers:         map[string]string{"Content-Type": "text/json; charset=utf-8"},
+			wantStatus:      http.StatusOK,
+			wantBody:        "{}",
+			wantContentType: "application/json; charset=utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody, gotContentType string
+			handler := b.TransformBody(NewBodyTransformConfig(tt.options...))(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					data, _ := io.ReadAll(r.Body)
+					gotBody, gotContentType = string(data), r.Header.Get("Content-Type")
+					if r.ContentLength != int64(len(data)) {
+						t.Errorf("Expected ContentLength %d, got %d", len(data), r.ContentLength)
+					}
+				}))
+
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if gotBody != tt.wantBody {
+				t.Errorf("Expected body %s, got %s", tt.wantBody, gotBody)
+			}
+			if tt.wantContentType != "" && gotContentType != tt.wantContentType {
+				t.Errorf("Expected Content-Type %s, got %s", tt.wantContentType, gotContentType)
+			}
+		})
+	}
+}
+
+func TestXMLToJSONLeavesOtherBodies(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("Content-Type", "application/json")
+
+	out, err := XMLToJSON()(r, []byte(`{"a":1}`))
+	if err != nil || string(out) != `{"a":1}` {
+		t.Errorf("Expected JSON body to be unchanged, got %s, %v", out, err)
+	}
+
+	r.Header.Set("Content-Type", "application/xml")
+	if _, err := XMLToJSON()(r, []byte(`<a><b></a>`)); err == nil {
+		t.Error("Expected error for malformed XML")
+	}
+}
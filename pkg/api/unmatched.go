@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"github.com/go-chi/chi/v5"
+)
+
+// routeMethods are the methods checked when listing what a path allows
+var routeMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace,
+}
+
+// UseProblemHandlers makes router answer unmatched paths and methods with problem+json, rather than
+// chi's plain text, so every service returns the same error shape. Sub-routers inherit them.
+func (b *Base) UseProblemHandlers(router chi.Router) {
+	router.NotFound(b.NotFound)
+	router.MethodNotAllowed(b.MethodNotAllowed)
+}
+
+// NotFound responds with a problem+json 404
+func (b *Base) NotFound(w http.ResponseWriter, r *http.Request) {
+	problem.New("not-found", "Not Found", http.StatusNotFound,
+		fmt.Sprintf("No route matches %s", r.URL.Path), r.URL.Path).Respond(w, r)
+}
+
+// MethodNotAllowed responds with a problem+json 405, listing the methods the path does allow in the
+// Allow header
+func (b *Base) MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	if allowed := allowedMethods(r); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+	}
+	problem.New("method-not-allowed", "Method Not Allowed", http.StatusMethodNotAllowed,
+		fmt.Sprintf("%s is not allowed for %s", r.Method, r.URL.Path), r.URL.Path).Respond(w, r)
+}
+
+// allowedMethods finds the methods routed for the request's path. chi knows them when it calls a
+// custom MethodNotAllowed handler, but does not pass them on.
+func allowedMethods(r *http.Request) []string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return nil
+	}
+
+	// Routes is the top-level router, so it is matched against the whole path, even in a sub-router
+	path := r.URL.Path
+	if r.URL.RawPath != "" {
+		path = r.URL.RawPath
+	}
+
+	var allowed []string
+	for _, method := range routeMethods {
+		if rctx.Routes.Match(chi.NewRouteContext(), method, path) {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
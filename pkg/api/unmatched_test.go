@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestUseProblemHandlers(t *testing.T) {
+	base := NewBase("test", "1.0", "", true)
+	router := chi.NewRouter()
+	base.UseProblemHandlers(router)
+	router.Get("/orders", func(w http.ResponseWriter, r *http.Request) {})
+	router.Post("/orders", func(w http.ResponseWriter, r *http.Request) {})
+	router.Route("/admin", func(r chi.Router) {
+		r.Delete("/cache", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantType   string
+		wantAllow  string
+	}{
+		{"unknown path", "GET", "/missing", http.StatusNotFound, "not-found", ""},
+		{"wrong method", "DELETE", "/orders", http.StatusMethodNotAllowed, "method-not-allowed", "GET, POST"},
+		{"sub-router path", "GET", "/admin/missing", http.StatusNotFound, "not-found", ""},
+		{"sub-router method", "GET", "/admin/cache", http.StatusMethodNotAllowed, "method-not-allowed", "DELETE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if rec.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("Expected problem+json, got %s", rec.Header().Get("Content-Type"))
+			}
+			if rec.Header().Get("Allow") != tt.wantAllow {
+				t.Errorf("Expected Allow %q, got %q", tt.wantAllow, rec.Header().Get("Allow"))
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["type"] != tt.wantType ||
+				body["instance"] != tt.path {
+				t.Errorf("Expected a %s problem for %s, got %s", tt.wantType, tt.path, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"github.com/Okja-Engineering/go-service-kit/pkg/storage"
+)
+
+const uploadKey contextKey = "upload"
+
+// maxFilenameLength keeps sanitized filenames within common filesystem limits
+const maxFilenameLength = 200
+
+// UploadConfig holds configuration for multipart file uploads
+type UploadConfig struct {
+	// MaxFileSize is the largest file accepted
+	MaxFileSize int64
+	// MaxFiles is the most files accepted in one request
+	MaxFiles int
+	// MaxFormSize bounds the total size of the non-file form values
+	MaxFormSize int64
+	// AllowedTypes are the content types accepted, detected from each file's content; an entry like "image/*"
+	// accepts a whole type. Empty accepts any type.
+	AllowedTypes []string
+	// Fields are the form fields that may carry files; empty accepts files in any field
+	Fields []string
+	// KeyFunc chooses the storage key for a file from its sanitized name; by default a random prefix under
+	// "uploads/" keeps names from colliding
+	KeyFunc func(r *http.Request, filename string) string
+}
+
+// DefaultUploadConfig provides sensible defaults that accept common image types and PDFs
+func DefaultUploadConfig() *UploadConfig {
+	return &UploadConfig{
+		MaxFileSize:  10 << 20,
+		MaxFiles:     10,
+		MaxFormSize:  1 << 20,
+		AllowedTypes: []string{"image/jpeg", "image/png", "image/gif", "image/webp", "application/pdf"},
+		KeyFunc:      defaultUploadKey,
+	}
+}
+
+// UploadOption is a functional option for configuring uploads
+type UploadOption func(*UploadConfig)
+
+// WithMaxFileSize sets the largest file accepted
+func WithMaxFileSize(size int64) UploadOption {
+	return func(config *UploadConfig) {
+		config.MaxFileSize = size
+	}
+}
+
+// WithMaxFiles sets the most files accepted in one request
+func WithMaxFiles(count int) UploadOption {
+	return func(config *UploadConfig) {
+		config.MaxFiles = count
+	}
+}
+
+// WithMaxFormSize sets the total size allowed for non-file form values
+func WithMaxFormSize(size int64) UploadOption {
+	return func(config *UploadConfig) {
+		config.MaxFormSize = size
+	}
+}
+
+// WithAllowedTypes replaces the accepted content types; pass none to accept any type
+func WithAllowedTypes(types ...string) UploadOption {
+	return func(config *UploadConfig) {
+		config.AllowedTypes = types
+	}
+}
+
+// WithUploadFields sets the form fields that may carry files
+func WithUploadFields(fields ...string) UploadOption {
+	return func(config *UploadConfig) {
+		config.Fields = fields
+	}
+}
+
+// WithUploadKeyFunc sets how storage keys are chosen
+func WithUploadKeyFunc(fn func(r *http.Request, filename string) string) UploadOption {
+	return func(config *UploadConfig) {
+		config.KeyFunc = fn
+	}
+}
+
+// NewUploadConfig creates a new upload config with options
+func NewUploadConfig(options ...UploadOption) *UploadConfig {
+	config := DefaultUploadConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+func defaultUploadKey(_ *http.Request, filename string) string {
+	prefix := make([]byte, 16)
+	_, _ = rand.Read(prefix)
+	return "uploads/" + hex.EncodeToString(prefix) + "/" + filename
+}
+
+// UploadedFile describes a file stored by the Upload middleware
+type UploadedFile struct {
+	// Field is the form field the file was sent in
+	Field string `json:"field"`
+	// Filename is the sanitized name the client gave the file
+	Filename    string `json:"filename"`
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType"`
+}
+
+// UploadResult holds the files and form values of a multipart upload
+type UploadResult struct {
+	Files  []UploadedFile `json:"files"`
+	Values url.Values     `json:"values,omitempty"`
+}
+
+// File returns the first file sent in field
+func (u *UploadResult) File(field string) (UploadedFile, bool) {
+	for _, file := range u.Files {
+		if file.Field == field {
+			return file, true
+		}
+	}
+	return UploadedFile{}, false
+}
+
+// UploadFromContext returns the result stored by the Upload middleware
+func UploadFromContext(ctx context.Context) (*UploadResult, bool) {
+	result, ok := ctx.Value(uploadKey).(*UploadResult)
+	return result, ok
+}
+
+// uploadError is a rejected upload, answered with a problem response
+type uploadError struct {
+	problemType string
+	title       string
+	status      int
+	detail      string
+}
+
+func (e *uploadError) Error() string {
+	return e.detail
+}
+
+// Upload creates middleware that streams the files of a multipart/form-data request to store,
+// checking each against the size limits and allowed types, and passes the result to the handler
+// through UploadFromContext. Files are never buffered in full. If a request is rejected, files
+// already stored for it are deleted; once the handler runs, they are its responsibility.
+func (b *Base) Upload(store storage.Blob, config *UploadConfig) func(next http.Handler) http.Handler {
+	if config == nil {
+		config = DefaultUploadConfig()
+	}
+
+	log.Printf("### 🤖 API: file uploads up to %d bytes, allowing %v", config.MaxFileSize, config.AllowedTypes)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reader, err := r.MultipartReader()
+			if err != nil {
+				problem.New("unsupported-media-type", "Unsupported Media Type", http.StatusUnsupportedMediaType,
+					"Expected a multipart/form-data request", r.URL.Path).Respond(w, r)
+				return
+			}
+
+			result, err := receiveUpload(r, reader, store, config)
+			if err != nil {
+				discardUpload(r.Context(), store, result)
+				respondUploadError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), uploadKey, result)))
+		})
+	}
+}
+
+// receiveUpload reads every part of the request, storing files and collecting form values. The result
+// holds the files stored so far even when it fails.
+func receiveUpload(r *http.Request, reader *multipart.Reader, store storage.Blob,
+	config *UploadConfig) (*UploadResult, error) {
+	result := &UploadResult{Values: url.Values{}}
+	formRemaining := config.MaxFormSize
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return result, &uploadError{"invalid-multipart", "Invalid Multipart Body", http.StatusBadRequest,
+				"The multipart body could not be read"}
+		}
+
+		if part.FileName() == "" {
+			formRemaining, err = readFormValue(part, result.Values, formRemaining)
+		} else {
+			err = storeFile(r, part, store, config, result)
+		}
+		_ = part.Close()
+		if err != nil {
+			return result, err
+		}
+	}
+}
+
+// readFormValue adds a non-file part to values, returning how much of the form size limit remains
+func readFormValue(part *multipart.Part, values url.Values, remaining int64) (int64, error) {
+	value, err := io.ReadAll(io.LimitReader(part, remaining+1))
+	if err != nil {
+		return remaining, &uploadError{"invalid-multipart", "Invalid Multipart Body", http.StatusBadRequest,
+			"The multipart body could not be read"}
+	}
+	remaining -= int64(len(value))
+	if remaining < 0 {
+		return remaining, &uploadError{"form-too-large", "Form Too Large", http.StatusRequestEntityTooLarge,
+			"The form values are too large"}
+	}
+	values.Add(part.FormName(), string(value))
+	return remaining, nil
+}
+
+// storeFile checks a file part against the config and streams it to store
+func storeFile(r *http.Request, part *multipart.Part, store storage.Blob, config *UploadConfig,
+	result *UploadResult) error {
+	field := part.FormName()
+	if len(config.Fields) > 0 && !slices.Contains(config.Fields, field) {
+		return &uploadError{"unexpected-file", "Unexpected File", http.StatusBadRequest,
+			fmt.Sprintf("Files are not accepted in the %q field", field)}
+	}
+	if len(result.Files) >= config.MaxFiles {
+		return &uploadError{"too-many-files", "Too Many Files", http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("At most %d files can be uploaded at once", config.MaxFiles)}
+	}
+
+	filename := SanitizeFilename(part.FileName())
+	head := make([]byte, 512)
+	n, err := io.ReadFull(part, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return &uploadError{"invalid-multipart", "Invalid Multipart Body", http.StatusBadRequest,
+			"The multipart body could not be read"}
+	}
+	head = head[:n]
+
+	// The client's Content-Type and file extension are ignored; only what the content looks like is trusted
+	contentType := http.DetectContentType(head)
+	if !allowedType(contentType, config.AllowedTypes) {
+		return &uploadError{"unsupported-file-type", "Unsupported File Type", http.StatusUnsupportedMediaType,
+			fmt.Sprintf("%s is a %s file, which is not accepted", filename, contentType)}
+	}
+
+	object, err := store.Put(r.Context(), config.KeyFunc(r, filename), io.MultiReader(bytes.NewReader(head), part),
+		storage.WithContentType(contentType), storage.WithLimit(config.MaxFileSize))
+	if errors.Is(err, storage.ErrTooLarge) {
+		return &uploadError{"file-too-large", "File Too Large", http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("%s is larger than the limit of %d bytes", filename, config.MaxFileSize)}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store %s: %w", filename, err)
+	}
+
+	result.Files = append(result.Files, UploadedFile{
+		Field:       field,
+		Filename:    filename,
+		Key:         object.Key,
+		Size:        object.Size,
+		ContentType: contentType,
+	})
+	return nil
+}
+
+// allowedType matches a content type, ignoring parameters, against exact types and "type/*" wildcards
+func allowedType(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	major, _, _ := strings.Cut(mediaType, "/")
+
+	for _, a := range allowed {
+		if strings.EqualFold(a, mediaType) || strings.EqualFold(a, major+"/*") {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeFilename makes a client-supplied filename safe to store and display: directories are
+// dropped, control and reserved characters are replaced, leading dots are removed so the file isn't
+// hidden, and long names are shortened keeping their extension. An unusable name becomes "file".
+func SanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r), strings.ContainsRune(`<>:"/\|?*`, r):
+			return '_'
+		case unicode.IsSpace(r):
+			return ' '
+		}
+		return r
+	}, name)
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+	name = strings.TrimRight(name, ". ")
+
+	if len(name) > maxFilenameLength {
+		ext := path.Ext(name)
+		if len(ext) > 20 {
+			ext = ""
+		}
+		name = truncateUTF8(strings.TrimSuffix(name, ext), maxFilenameLength-len(ext)) + ext
+	}
+	if strings.Trim(name, "_ ") == "" {
+		return "file"
+	}
+	return name
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	for len(s) > n {
+		_, size := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-size]
+	}
+	return s
+}
+
+// discardUpload deletes the files stored for a rejected request
+func discardUpload(ctx context.Context, store storage.Blob, result *UploadResult) {
+	for _, file := range result.Files {
+		if err := store.Delete(context.WithoutCancel(ctx), file.Key); err != nil {
+			log.Printf("### ⚠️ API: failed to delete rejected upload %s: %v", file.Key, err)
+		}
+	}
+}
+
+func respondUploadError(w http.ResponseWriter, r *http.Request, err error) {
+	var rejected *uploadError
+	if errors.As(err, &rejected) {
+		problem.New(rejected.problemType, rejected.title, rejected.status, rejected.detail, r.URL.Path).Respond(w, r)
+		return
+	}
+
+	log.Printf("### 💥 API: upload to %s failed: %v", r.URL.Path, err)
+	problem.Wrap(http.StatusInternalServerError, "upload", r.URL.Path, err).Respond(w, r)
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/storage"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type uploadPart struct {
+	field    string
+	filename string
+	content  []byte
+}
+
+func multipartRequest(t *testing.T, parts ...uploadPart) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range parts {
+		var err error
+		if part.filename == "" {
+			err = writer.WriteField(part.field, string(part.content))
+		} else {
+			var w io.Writer
+			w, err = writer.CreateFormFile(part.field, part.filename)
+			if err == nil {
+				_, err = w.Write(part.content)
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func serveUpload(t *testing.T, config *UploadConfig, req *http.Request) (*httptest.ResponseRecorder,
+	*UploadResult, storage.Blob) {
+	t.Helper()
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result *UploadResult
+	handler := NewBase("test", "1.0", "", true).Upload(store, config)(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			result, _ = UploadFromContext(r.Context())
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, result, store
+}
+
+func TestNewUploadConfig(t *testing.T) {
+	config := NewUploadConfig()
+	if config.MaxFileSize != 10<<20 || config.MaxFiles != 10 || len(config.AllowedTypes) == 0 {
+		t.Errorf("Unexpected defaults: %+v", config)
+	}
+
+	config = NewUploadConfig(
+		WithMaxFileSize(100),
+		WithMaxFiles(2),
+		WithMaxFormSize(50),
+		WithAllowedTypes("text/*"),
+		WithUploadFields("avatar"),
+		WithUploadKeyFunc(func(r *http.Request, filename string) string { return filename }),
+	)
+	if config.MaxFileSize != 100 || config.MaxFiles != 2 || config.MaxFormSize != 50 {
+		t.Errorf("Expected limits to be overridden, got %+v", config)
+	}
+	if len(config.AllowedTypes) != 1 || len(config.Fields) != 1 || config.KeyFunc(nil, "a") != "a" {
+		t.Errorf("Expected types, fields, and key func to be overridden, got %+v", config)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	req := multipartRequest(t,
+		uploadPart{field: "title", content: []byte("Holiday")},
+		uploadPart{field: "photo", filename: "../../beach.png", content: pngHeader},
+	)
+
+	rec, result, store := serveUpload(t, nil, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected the handler to run, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if result == nil || len(result.Files) != 1 || result.Values.Get("title") != "Holiday" {
+		t.Fatalf("Expected one file and the title, got %+v", result)
+	}
+
+	file, ok := result.File("photo")
+	if !ok || file.Filename != "beach.png" || file.ContentType != "image/png" || file.Size != int64(len(pngHeader)) {
+		t.Errorf("Unexpected file: %+v", file)
+	}
+	if !strings.HasPrefix(file.Key, "uploads/") || !strings.HasSuffix(file.Key, "/beach.png") {
+		t.Errorf("Expected a random key under uploads/, got %s", file.Key)
+	}
+	if _, err := store.Stat(context.Background(), file.Key); err != nil {
+		t.Errorf("Expected the file to be stored, got %v", err)
+	}
+	if _, ok := result.File("missing"); ok {
+		t.Error("Expected no file for an unknown field")
+	}
+}
+
+func TestUploadRejected(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     *UploadConfig
+		parts      []uploadPart
+		wantStatus int
+		wantType   string
+	}{
+		{
+			name:       "disallowed type",
+			config:     NewUploadConfig(),
+			parts:      []uploadPart{{field: "photo", filename: "evil.png", content: []byte("<html><script>")}},
+			wantStatus: http.StatusUnsupportedMediaType,
+			wantType:   "unsupported-file-type",
+		},
+		{
+			name:       "binary named as an image",
+			config:     NewUploadConfig(),
+			parts:      []uploadPart{{field: "photo", filename: "x.png", content: []byte{0x00, 0x01, 0x02, 0xff}}},
+			wantStatus: http.StatusUnsupportedMediaType,
+			wantType:   "unsupported-file-type",
+		},
+		{
+			name:       "file too large",
+			config:     NewUploadConfig(WithMaxFileSize(10)),
+			parts:      []uploadPart{{field: "photo", filename: "a.png", content: pngHeader}},
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantType:   "file-too-large",
+		},
+		{
+			name:   "too many files",
+			config: NewUploadConfig(WithMaxFiles(1)),
+			parts: []uploadPart{
+				{field: "photo", filename: "a.png", content: pngHeader},
+				{field: "photo", filename: "b.png", content: pngHeader},
+			},
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantType:   "too-many-files",
+		},
+		{
+			name:       "unexpected field",
+			config:     NewUploadConfig(WithUploadFields("avatar")),
+			parts:      []uploadPart{{field: "photo", filename: "a.png", content: pngHeader}},
+			wantStatus: http.StatusBadRequest,
+			wantType:   "unexpected-file",
+		},
+		{
+			name:       "form too large",
+			config:     NewUploadConfig(WithMaxFormSize(5)),
+			parts:      []uploadPart{{field: "title", content: []byte("Holiday")}},
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantType:   "form-too-large",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, result, store := serveUpload(t, tt.config, multipartRequest(t, tt.parts...))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if result != nil {
+				t.Error("Expected the handler not to run")
+			}
+
+			var p struct {
+				Type string `json:"type"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil || !strings.HasSuffix(p.Type, tt.wantType) {
+				t.Errorf("Expected a %s problem, got %s", tt.wantType, rec.Body.String())
+			}
+			if objects, _ := store.List(context.Background(), ""); len(objects) != 0 {
+				t.Errorf("Expected rejected uploads to be deleted, got %+v", objects)
+			}
+		})
+	}
+}
+
+func TestUploadNotMultipart(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	if rec, _, _ := serveUpload(t, nil, req); rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected 415, got %d", rec.Code)
+	}
+}
+
+func TestAllowedType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		allowed     []string
+		want        bool
+	}{
+		{"image/png", []string{"image/png"}, true},
+		{"image/png", []string{"image/*"}, true},
+		{"text/plain; charset=utf-8", []string{"text/plain"}, true},
+		{"text/html; charset=utf-8", []string{"image/*", "text/plain"}, false},
+		{"application/octet-stream", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if got := allowedType(tt.contentType, tt.allowed); got != tt.want {
+				t.Errorf("allowedType(%q, %v) = %v, want %v", tt.contentType, tt.allowed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"photo.png", "photo.png"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Users\me\report.pdf`, "report.pdf"},
+		{".htaccess", "htaccess"},
+		{"in\x00va\nlid?.txt", "in_va_lid_.txt"},
+		{"résumé final.pdf", "résumé final.pdf"},
+		{"trailing. ", "trailing"},
+		{"", "file"},
+		{"..", "file"},
+		{"???", "file"},
+		{strings.Repeat("a", 300) + ".png", strings.Repeat("a", 196) + ".png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeFilename(tt.name); got != tt.want {
+				t.Errorf("SanitizeFilename(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
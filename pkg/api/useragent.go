@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// UAClass is a coarse classification of the client making a request
+type UAClass string
+
+const (
+	UAClassHealthCheck UAClass = "health-check"
+	UAClassBot         UAClass = "bot"
+	UAClassBrowser     UAClass = "browser"
+	UAClassOther       UAClass = "other"
+)
+
+const uaClassKey contextKey = "uaClass"
+
+// UserAgentConfig holds configuration for user-agent classification
+type UserAgentConfig struct {
+	// Case-insensitive substrings matched against the User-Agent header, checked in this order
+	HealthCheckPatterns []string
+	BotPatterns         []string
+	BrowserPatterns     []string
+	// ResponseHeader, if set, echoes the class on the response for debugging
+	ResponseHeader string
+	// RateLimits applies a per-IP rate limit to requests of a class
+	RateLimits map[UAClass]*RateLimiterConfig
+	// Blocked classes are rejected with 403
+	Blocked []UAClass
+}
+
+// DefaultUserAgentConfig provides sensible defaults that only tag requests
+func DefaultUserAgentConfig() *UserAgentConfig {
+	return &UserAgentConfig{
+		HealthCheckPatterns: []string{
+			"kube-probe", "elb-healthchecker", "googlehc", "health-check", "healthcheck", "uptimerobot", "pingdom",
+		},
+		BotPatterns: []string{
+			"bot", "crawl", "spider", "slurp", "facebookexternalhit", "preview", "headlesschrome",
+		},
+		BrowserPatterns: []string{"mozilla/"},
+		RateLimits:      make(map[UAClass]*RateLimiterConfig),
+	}
+}
+
+// UserAgentOption is a functional option for configuring user-agent classification
+type UserAgentOption func(*UserAgentConfig)
+
+// WithHealthCheckPatterns replaces the patterns identifying health checkers
+func WithHealthCheckPatterns(patterns ...string) UserAgentOption {
+	return func(config *UserAgentConfig) {
+		config.HealthCheckPatterns = patterns
+	}
+}
+
+// WithBotPatterns replaces the patterns identifying bots and crawlers
+func WithBotPatterns(patterns ...string) UserAgentOption {
+	return func(config *UserAgentConfig) {
+		config.BotPatterns = patterns
+	}
+}
+
+// WithBrowserPatterns replaces the patterns identifying browsers
+func WithBrowserPatterns(patterns ...string) UserAgentOption {
+	return func(config *UserAgentConfig) {
+		config.BrowserPatterns = patterns
+	}
+}
+
+// WithUAClassHeader echoes the detected class in the named response header
+func WithUAClassHeader(header string) UserAgentOption {
+	return func(config *UserAgentConfig) {
+		config.ResponseHeader = header
+	}
+}
+
+// WithClassRateLimit rate limits requests of a class per client IP
+func WithClassRateLimit(class UAClass, limit *RateLimiterConfig) UserAgentOption {
+	return func(config *UserAgentConfig) {
+		config.RateLimits[class] = limit
+	}
+}
+
+// WithBlockedClasses rejects requests of the given classes with 403
+func WithBlockedClasses(classes ...UAClass) UserAgentOption {
+	return func(config *UserAgentConfig) {
+		config.Blocked = classes
+	}
+}
+
+// NewUserAgentConfig creates a new user-agent config with options
+func NewUserAgentConfig(options ...UserAgentOption) *UserAgentConfig {
+	config := DefaultUserAgentConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Classify returns the class of a User-Agent header value
+func (c *UserAgentConfig) Classify(userAgent string) UAClass {
+	ua := strings.ToLower(userAgent)
+
+	switch {
+	case ua == "":
+		return UAClassOther
+	case containsAny(ua, c.HealthCheckPatterns):
+		return UAClassHealthCheck
+	case containsAny(ua, c.BotPatterns):
+		return UAClassBot
+	case containsAny(ua, c.BrowserPatterns):
+		return UAClassBrowser
+	default:
+		return UAClassOther
+	}
+}
+
+func containsAny(s string, patterns []string) bool {
+	for _, p := range patterns {
+		if p != "" && strings.Contains(s, strings.ToLower(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// UAClassFromContext returns the class assigned by the UserAgentFilter middleware
+func UAClassFromContext(ctx context.Context) (UAClass, bool) {
+	class, ok := ctx.Value(uaClassKey).(UAClass)
+	return class, ok
+}
+
+// UserAgentFilter creates middleware that classifies requests by User-Agent, stores the class
+// in the request context, and optionally blocks or rate limits classes
+func (b *Base) UserAgentFilter(config *UserAgentConfig) func(next http.Handler) http.Handler {
+	if config == nil {
+		config = DefaultUserAgentConfig()
+	}
+
+	limiters := make(map[UAClass]*rateLimiter, len(config.RateLimits))
+	for class, limit := range config.RateLimits {
+		limiters[class] = newRateLimiter(limit)
+		b.trackLimiter("userAgent:"+string(class), limiters[class])
+	}
+
+	log.Printf("### 🤖 API: user-agent filter with %d rate limited and %d blocked class(es)",
+		len(limiters), len(config.Blocked))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			class := config.Classify(r.UserAgent())
+
+			if config.ResponseHeader != "" {
+				w.Header().Set(config.ResponseHeader, string(class))
+			}
+
+			if slices.Contains(config.Blocked, class) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			if limiter, ok := limiters[class]; ok && !b.isInfrastructure(r) && !limiter.exempt.exempt(r) {
+				if !limiter.getLimiter(getClientIP(r)).allow(limiter.clock.Now()) {
+					log.Printf("### 🚫 Rate limit exceeded for %s client: %s", class, getClientIP(r))
+					http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), uaClassKey, class)))
+		})
+	}
+}
+
+// RobotsRule is a group of directives in robots.txt
+type RobotsRule struct {
+	UserAgent  string
+	Allow      []string
+	Disallow   []string
+	CrawlDelay int
+}
+
+// RobotsConfig holds the content served at /robots.txt
+type RobotsConfig struct {
+	Rules    []RobotsRule
+	Sitemaps []string
+}
+
+// DefaultRobotsConfig disallows all crawling, which suits API services
+func DefaultRobotsConfig() *RobotsConfig {
+	return &RobotsConfig{
+		Rules: []RobotsRule{{UserAgent: "*", Disallow: []string{"/"}}},
+	}
+}
+
+// RobotsOption is a functional option for configuring robots.txt
+type RobotsOption func(*RobotsConfig)
+
+// WithRobotsRules replaces the robots.txt rules
+func WithRobotsRules(rules ...RobotsRule) RobotsOption {
+	return func(config *RobotsConfig) {
+		config.Rules = rules
+	}
+}
+
+// WithSitemap adds a sitemap URL to robots.txt
+func WithSitemap(url string) RobotsOption {
+	return func(config *RobotsConfig) {
+		config.Sitemaps = append(config.Sitemaps, url)
+	}
+}
+
+// NewRobotsConfig creates a new robots config with options
+func NewRobotsConfig(options ...RobotsOption) *RobotsConfig {
+	config := DefaultRobotsConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// String renders the config in robots.txt format
+func (c *RobotsConfig) String() string {
+	var sb strings.Builder
+
+	for i, rule := range c.Rules {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "User-agent: %s\n", rule.UserAgent)
+		for _, path := range rule.Allow {
+			fmt.Fprintf(&sb, "Allow: %s\n", path)
+		}
+		for _, path := range rule.Disallow {
+			fmt.Fprintf(&sb, "Disallow: %s\n", path)
+		}
+		if rule.CrawlDelay > 0 {
+			fmt.Fprintf(&sb, "Crawl-delay: %d\n", rule.CrawlDelay)
+		}
+	}
+
+	if len(c.Sitemaps) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, sitemap := range c.Sitemaps {
+		fmt.Fprintf(&sb, "Sitemap: %s\n", sitemap)
+	}
+
+	return sb.String()
+}
+
+// AddRobotsEndpoint serves robots.txt; by default all crawling is disallowed
+func (b *Base) AddRobotsEndpoint(r chi.Router, options ...RobotsOption) {
+	log.Printf("### 🤖 API: robots endpoint at: %s", "/robots.txt")
+
+	body := NewRobotsConfig(options...).String()
+
+	r.Get("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		b.ReturnText(w, body)
+	})
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestClassifyUserAgent(t *testing.T) {
+	config := DefaultUserAgentConfig()
+
+	tests := []struct {
+		ua   string
+		want UAClass
+	}{
+		{"kube-probe/1.29", UAClassHealthCheck},
+		{"ELB-HealthChecker/2.0", UAClassHealthCheck},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", UAClassBot},
+		{"facebookexternalhit/1.1", UAClassBot},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1.15 Safari/605.1.15", UAClassBrowser},
+		{"curl/8.4.0", UAClassOther},
+		{"", UAClassOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ua, func(t *testing.T) {
+			if got := config.Classify(tt.ua); got != tt.want {
+				t.Errorf("Classify(%q) = %s, want %s", tt.ua, got, tt.want)
+			}
+		})
+	}
+
+	custom := NewUserAgentConfig(WithBotPatterns("curl"))
+	if custom.Classify("curl/8.4.0") != UAClassBot {
+		t.Error("Expected custom bot pattern to match")
+	}
+}
+
+func TestUserAgentFilterTagsRequests(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+
+	var got UAClass
+	handler := b.UserAgentFilter(NewUserAgentConfig(WithUAClassHeader("X-Client-Class")))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, _ = UAClassFromContext(r.Context())
+		}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("User-Agent", "kube-probe/1.29")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if got != UAClassHealthCheck {
+		t.Errorf("Expected health-check class in context, got %s", got)
+	}
+	if w.Header().Get("X-Client-Class") != "health-check" {
+		t.Errorf("Expected class header, got %q", w.Header().Get("X-Client-Class"))
+	}
+}
+
+func TestUserAgentFilterBlocksAndLimits(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+	config := NewUserAgentConfig(
+		WithBlockedClasses(UAClassBot),
+		WithClassRateLimit(UAClassOther, NewRateLimiterConfig(WithRequestsPerSecond(0.001), WithBurst(1))),
+	)
+	handler := b.UserAgentFilter(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(ua string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("User-Agent", ua)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := serve("Googlebot/2.1"); code != http.StatusForbidden {
+		t.Errorf("Expected bots to be blocked, got %d", code)
+	}
+	if code := serve("curl/8.4.0"); code != http.StatusOK {
+		t.Errorf("Expected first other request to pass, got %d", code)
+	}
+	if code := serve("curl/8.4.0"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected second other request to be limited, got %d", code)
+	}
+	if code := serve("Mozilla/5.0 Safari"); code != http.StatusOK {
+		t.Errorf("Expected browsers to be unaffected, got %d", code)
+	}
+}
+
+func TestAddRobotsEndpoint(t *testing.T) {
+	b := NewBase("test", "1.0", "", true)
+
+	tests := []struct {
+		name    string
+		options []RobotsOption
+		want    string
+	}{
+		{"default", nil, "User-agent: *\nDisallow: /\n"},
+		{"custom", []RobotsOption{
+			WithRobotsRules(
+				RobotsRule{UserAgent: "*", Allow: []string{"/docs"}, Disallow: []string{"/api"}, CrawlDelay: 10},
+				RobotsRule{UserAgent: "GPTBot", Disallow: []string{"/"}},
+			),
+			WithSitemap("https://example.com/sitemap.xml"),
+		}, strings.Join([]string{
+			"User-agent: *", "Allow: /docs", "Disallow: /api", "Crawl-delay: 10", "",
+			"User-agent: GPTBot", "Disallow: /", "",
+			"Sitemap: https://example.com/sitemap.xml", "",
+		}, "\n")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := chi.NewRouter()
+			b.AddRobotsEndpoint(router, tt.options...)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d", w.Code)
+			}
+			if w.Body.String() != tt.want {
+				t.Errorf("Unexpected robots.txt:\n%s\nwant:\n%s", w.Body.String(), tt.want)
+			}
+			if w.Header().Get("Content-Type") != "text/plain" {
+				t.Errorf("Expected text/plain, got %s", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
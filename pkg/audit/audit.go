@@ -0,0 +1,311 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/api"
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/Okja-Engineering/go-service-kit/pkg/redact"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Entry is the audit record of one request
+type Entry struct {
+	Time         time.Time `json:"time"`
+	RequestID    string    `json:"requestId,omitempty"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	Route        string    `json:"route,omitempty"`
+	UserID       string    `json:"userId,omitempty"`
+	TenantID     string    `json:"tenantId,omitempty"`
+	ClientIP     string    `json:"clientIp,omitempty"`
+	Status       int       `json:"status"`
+	LatencyMs    int64     `json:"latencyMs"`
+	RequestBody  string    `json:"requestBody,omitempty"`
+	ResponseBody string    `json:"responseBody,omitempty"`
+}
+
+// Sink stores audit entries, e.g. in a log, a database table, or an event stream
+type Sink interface {
+	Write(ctx context.Context, entry Entry) error
+}
+
+// SinkFunc adapts a function to a Sink
+type SinkFunc func(ctx context.Context, entry Entry) error
+
+// Write calls f
+func (f SinkFunc) Write(ctx context.Context, entry Entry) error {
+	return f(ctx, entry)
+}
+
+// Logger defines the interface for reporting sink failures
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Config holds configuration for audit logging
+type Config struct {
+	// Methods are the HTTP methods audited; empty audits every method
+	Methods []string
+	// SampleRate is the fraction of successful requests audited; failed requests (4xx and 5xx) are always audited
+	SampleRate float64
+	// Bodies records request and response bodies, with Redactor applied
+	Bodies bool
+	// MaxBodySize caps the bytes recorded of each body
+	MaxBodySize int
+	// Redactor removes credentials and personal data from recorded bodies
+	Redactor *redact.Redactor
+	// UserFunc and TenantFunc identify the caller; by default from the "sub" and "tenant_id" JWT claims
+	UserFunc   func(r *http.Request) string
+	TenantFunc func(r *http.Request) string
+	// Timeout bounds each write to the sink
+	Timeout time.Duration
+	// Logger receives sink failures, which never fail the request
+	Logger Logger
+}
+
+// DefaultConfig provides sensible defaults
+func DefaultConfig() *Config {
+	return &Config{
+		SampleRate:  1,
+		MaxBodySize: 16 << 10,
+		Redactor:    redact.Default(),
+		UserFunc:    claimFunc("sub"),
+		TenantFunc:  claimFunc("tenant_id"),
+		Timeout:     5 * time.Second,
+		Logger:      log.Default(),
+	}
+}
+
+// Option is a functional option for configuring audit logging
+type Option func(*Config)
+
+// WithMethods audits only the given methods, e.g. the ones that change state
+func WithMethods(methods ...string) Option {
+	return func(config *Config) {
+		config.Methods = methods
+	}
+}
+
+// WithSampleRate sets the fraction of successful requests audited, between 0 and 1
+func WithSampleRate(rate float64) Option {
+	return func(config *Config) {
+		config.SampleRate = rate
+	}
+}
+
+// WithBodies records request and response bodies up to maxSize bytes each
+func WithBodies(maxSize int) Option {
+	return func(config *Config) {
+		config.Bodies = true
+		config.MaxBodySize = maxSize
+	}
+}
+
+// WithRedactor sets the redactor applied to recorded bodies
+func WithRedactor(redactor *redact.Redactor) Option {
+	return func(config *Config) {
+		config.Redactor = redactor
+	}
+}
+
+// WithRedactFields adds JSON field names whose values are redacted from recorded bodies
+func WithRedactFields(fields ...string) Option {
+	return func(config *Config) {
+		config.Redactor = config.Redactor.With(redact.WithFields(fields...))
+	}
+}
+
+// WithUserFunc sets how the caller's user ID is found
+func WithUserFunc(fn func(r *http.Request) string) Option {
+	return func(config *Config) {
+		config.UserFunc = fn
+	}
+}
+
+// WithTenantFunc sets how the caller's tenant ID is found
+func WithTenantFunc(fn func(r *http.Request) string) Option {
+	return func(config *Config) {
+		config.TenantFunc = fn
+	}
+}
+
+// WithTimeout sets the timeout for each write to the sink
+func WithTimeout(timeout time.Duration) Option {
+	return func(config *Config) {
+		config.Timeout = timeout
+	}
+}
+
+// WithLogger sets the logger for sink failures
+func WithLogger(logger Logger) Option {
+	return func(config *Config) {
+		config.Logger = logger
+	}
+}
+
+// NewConfig creates a new audit config with options
+func NewConfig(options ...Option) *Config {
+	config := DefaultConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// AuditLogger records requests to a Sink
+type AuditLogger struct {
+	sink   Sink
+	config *Config
+}
+
+// New creates an audit logger writing to sink
+func New(sink Sink, options ...Option) *AuditLogger {
+	return &AuditLogger{sink: sink, config: NewConfig(options...)}
+}
+
+// Middleware records an entry for each audited request once the handler has returned. Place it after
+// authentication so the user and tenant are known.
+func (a *AuditLogger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(a.config.Methods) > 0 && !slices.Contains(a.config.Methods, r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		ww, requestBody, responseBody := a.capture(w, r)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status < http.StatusBadRequest && !a.sampled() {
+			return
+		}
+
+		entry := a.entry(r, status, time.Since(start))
+		if a.config.Bodies {
+			entry.RequestBody = a.redactBody(requestBody)
+			entry.ResponseBody = a.redactBody(responseBody)
+		}
+		a.write(r.Context(), entry)
+	})
+}
+
+// capture wraps the response writer to record the status, and tees both bodies into capped buffers
+// when bodies are recorded
+func (a *AuditLogger) capture(w http.ResponseWriter, r *http.Request) (middleware.WrapResponseWriter,
+	*cappedBuffer, *cappedBuffer) {
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	if !a.config.Bodies {
+		return ww, nil, nil
+	}
+
+	requestBody := &cappedBuffer{limit: a.config.MaxBodySize}
+	responseBody := &cappedBuffer{limit: a.config.MaxBodySize}
+	if r.Body != nil {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(r.Body, requestBody), r.Body}
+	}
+	ww.Tee(responseBody)
+
+	return ww, requestBody, responseBody
+}
+
+// sampled decides whether a successful request is audited
+func (a *AuditLogger) sampled() bool {
+	return a.config.SampleRate >= 1 || rand.Float64() < a.config.SampleRate
+}
+
+// entry builds the audit record of a request
+func (a *AuditLogger) entry(r *http.Request, status int, latency time.Duration) Entry {
+	entry := Entry{
+		Time:      time.Now().UTC(),
+		RequestID: middleware.GetReqID(r.Context()),
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		ClientIP:  api.ClientIP(r),
+		Status:    status,
+		LatencyMs: latency.Milliseconds(),
+	}
+	if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil {
+		entry.Route = routeCtx.RoutePattern()
+	}
+	if a.config.UserFunc != nil {
+		entry.UserID = a.config.UserFunc(r)
+	}
+	if a.config.TenantFunc != nil {
+		entry.TenantID = a.config.TenantFunc(r)
+	}
+	return entry
+}
+
+// write sends an entry to the sink, outliving the request's cancellation but not the timeout
+func (a *AuditLogger) write(ctx context.Context, entry Entry) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), a.config.Timeout)
+	defer cancel()
+
+	if err := a.sink.Write(ctx, entry); err != nil {
+		a.config.Logger.Printf("### ⚠️ Audit: failed to record %s %s: %v", entry.Method, entry.Path, err)
+	}
+}
+
+// redactBody returns a captured body with sensitive JSON fields redacted. Bodies that are not JSON
+// cannot be redacted field by field, so only their size is recorded.
+func (a *AuditLogger) redactBody(body *cappedBuffer) string {
+	if body.size == 0 {
+		return ""
+	}
+
+	if !body.truncated {
+		if redactedBody, err := a.config.Redactor.JSON(body.Bytes()); err == nil {
+			return string(redactedBody)
+		}
+	}
+	return fmt.Sprintf("[%d byte body omitted: not JSON or over the size limit]", body.size)
+}
+
+// cappedBuffer keeps the first limit bytes written to it, counting the rest
+type cappedBuffer struct {
+	bytes.Buffer
+	limit     int
+	size      int
+	truncated bool
+}
+
+// Write never fails, so capturing cannot disturb the request
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.size += len(p)
+	keep := p
+	if room := b.limit - b.Len(); room < len(p) {
+		b.truncated = true
+		keep = p[:max(room, 0)]
+	}
+	_, _ = b.Buffer.Write(keep)
+	return len(p), nil
+}
+
+// claimFunc returns a function reading a string JWT claim set by the auth middleware
+func claimFunc(claim string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		claims, ok := auth.GetClaimsFromContext(r.Context())
+		if !ok {
+			return ""
+		}
+		value, _ := claims[claim].(string)
+		return value
+	}
+}
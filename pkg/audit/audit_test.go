@@ -0,0 +1,172 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/Okja-Engineering/go-service-kit/pkg/redact"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// memorySink keeps entries for inspection
+type memorySink struct {
+	mu      sync.Mutex
+	entries []Entry
+	err     error
+}
+
+func (s *memorySink) Write(_ context.Context, entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return s.err
+}
+
+// bufferLogger collects log lines
+type bufferLogger struct {
+	buf bytes.Buffer
+}
+
+func (l *bufferLogger) Printf(format string, v ...interface{}) {
+	fmt.Fprintf(&l.buf, format+"\n", v...)
+}
+
+// withClaims stands in for the auth middleware
+func withClaims(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := jwt.MapClaims{"sub": "user-1", "tenant_id": "acme"}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), auth.JWTClaimsKey, claims)))
+	})
+}
+
+func newAuditRouter(sink Sink, options ...Option) chi.Router {
+	r := chi.NewRouter()
+	r.Use(withClaims)
+	r.Use(New(sink, options...).Middleware)
+	r.Post("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "fail") {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"42","apiKey":"k-123"}`))
+	})
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("plain text"))
+	})
+	return r
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if config.SampleRate != 1 || config.Bodies || config.Timeout != 5*time.Second || config.UserFunc == nil {
+		t.Errorf("Unexpected defaults: %+v", config)
+	}
+
+	config = NewConfig(WithMethods("POST"), WithSampleRate(0.1), WithBodies(1024), WithRedactFields("dob"),
+		WithTimeout(time.Second))
+	if len(config.Methods) != 1 || config.SampleRate != 0.1 || !config.Bodies || config.MaxBodySize != 1024 {
+		t.Errorf("Expected options to be applied, got %+v", config)
+	}
+	if !config.Redactor.Sensitive("dob") || redact.Default().Sensitive("dob") || config.Timeout != time.Second {
+		t.Error("Expected a copy of the default redaction rules to be extended")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	sink := &memorySink{}
+	router := newAuditRouter(sink, WithBodies(1024), WithRedactFields("dob"))
+
+	req := httptest.NewRequest("POST", "/users/42", strings.NewReader(`{"name":"Ann","password":"p","dob":"1990"}`))
+	req.RemoteAddr = "198.51.100.4:5555"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(sink.entries) != 1 {
+		t.Fatalf("Expected one entry, got %d", len(sink.entries))
+	}
+	entry := sink.entries[0]
+	if entry.Method != "POST" || entry.Path != "/users/42" || entry.Route != "/users/{id}" || entry.Status != 200 {
+		t.Errorf("Unexpected request details: %+v", entry)
+	}
+	if entry.UserID != "user-1" || entry.TenantID != "acme" || entry.ClientIP != "198.51.100.4" {
+		t.Errorf("Expected the caller to be identified, got %+v", entry)
+	}
+	if entry.RequestBody != `{"dob":"[REDACTED]","name":"Ann","password":"[REDACTED]"}` {
+		t.Errorf("Unexpected request body: %s", entry.RequestBody)
+	}
+	if entry.ResponseBody != `{"apiKey":"[REDACTED]","id":"42"}` {
+		t.Errorf("Unexpected response body: %s", entry.ResponseBody)
+	}
+}
+
+func TestMiddlewareFiltering(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     []Option
+		method      string
+		body        string
+		wantEntries int
+	}{
+		{"all methods", nil, "GET", "", 1},
+		{"method not audited", []Option{WithMethods("POST")}, "GET", "", 0},
+		{"success not sampled", []Option{WithSampleRate(0)}, "POST", `{}`, 0},
+		{"failure always audited", []Option{WithSampleRate(0)}, "POST", `{"x":"fail"}`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &memorySink{}
+			router := newAuditRouter(sink, tt.options...)
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, "/users/42",
+				strings.NewReader(tt.body)))
+			if len(sink.entries) != tt.wantEntries {
+				t.Errorf("Expected %d entries, got %d", tt.wantEntries, len(sink.entries))
+			}
+		})
+	}
+}
+
+func TestMiddlewareBodies(t *testing.T) {
+	sink := &memorySink{}
+	router := newAuditRouter(sink, WithBodies(8))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users/42",
+		strings.NewReader(`{"name":"a long name"}`)))
+	entry := sink.entries[0]
+	if !strings.Contains(entry.RequestBody, "22 byte body omitted") {
+		t.Errorf("Expected an oversized body to be omitted, got %s", entry.RequestBody)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	entry = sink.entries[1]
+	if entry.RequestBody != "" || !strings.Contains(entry.ResponseBody, "10 byte body omitted") {
+		t.Errorf("Expected a plain text body to be omitted, got %q and %q", entry.RequestBody, entry.ResponseBody)
+	}
+}
+
+func TestMiddlewareSinkFailure(t *testing.T) {
+	logger := &bufferLogger{}
+	sink := &memorySink{err: errors.New("disk full")}
+	router := newAuditRouter(sink, WithLogger(logger))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/users/42", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the request to succeed despite the sink, got %d", rec.Code)
+	}
+	if !strings.Contains(logger.buf.String(), "failed to record GET /users/42: disk full") {
+		t.Errorf("Expected the failure to be logged, got %q", logger.buf.String())
+	}
+}
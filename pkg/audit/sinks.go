@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+	"github.com/Okja-Engineering/go-service-kit/pkg/events"
+	"github.com/lib/pq"
+)
+
+// LogSink writes each entry to logger as one JSON line, for collection with the service's other logs
+func LogSink(logger Logger) Sink {
+	return SinkFunc(func(_ context.Context, entry Entry) error {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit entry: %w", err)
+		}
+		logger.Printf("### 📝 Audit: %s", line)
+		return nil
+	})
+}
+
+// EventSink publishes each entry to topic as an "audit.request" event
+func EventSink(publisher events.Publisher, topic string) Sink {
+	return SinkFunc(func(ctx context.Context, entry Entry) error {
+		_, err := events.Publish(ctx, publisher, topic, "audit.request", entry)
+		return err
+	})
+}
+
+// SQLSink inserts each entry as a row of a database table
+type SQLSink struct {
+	db    database.Database
+	table string
+}
+
+// NewSQLSink creates a sink writing to table; create the table with Migration
+func NewSQLSink(db database.Database, table string) *SQLSink {
+	return &SQLSink{db: db, table: table}
+}
+
+// Migration returns the migration that creates the audit table, for use with database.Migrate
+func (s *SQLSink) Migration(version int64) database.Migration {
+	table := pq.QuoteIdentifier(s.table)
+	index := pq.QuoteIdentifier(s.table + "_tenant_time")
+
+	up := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id BIGSERIAL PRIMARY KEY,
+	time TIMESTAMPTZ NOT NULL,
+	request_id TEXT NOT NULL DEFAULT '',
+	method TEXT NOT NULL,
+	path TEXT NOT NULL,
+	route TEXT NOT NULL DEFAULT '',
+	user_id TEXT NOT NULL DEFAULT '',
+	tenant_id TEXT NOT NULL DEFAULT '',
+	client_ip TEXT NOT NULL DEFAULT '',
+	status INT NOT NULL,
+	latency_ms BIGINT NOT NULL,
+	request_body TEXT,
+	response_body TEXT
+);
+CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (tenant_id, time);`, table, index)
+
+	return database.Migration{
+		Version: version,
+		Name:    "create " + s.table,
+		Up:      up,
+		Down:    "DROP TABLE IF EXISTS " + table,
+	}
+}
+
+// Write inserts an entry
+func (s *SQLSink) Write(ctx context.Context, entry Entry) error {
+	db := s.db.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection is closed")
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (time, request_id, method, path, route, user_id, tenant_id, client_ip,
+	status, latency_ms, request_body, response_body) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		pq.QuoteIdentifier(s.table))
+	_, err := db.ExecContext(ctx, query, entry.Time, entry.RequestID, entry.Method, entry.Path, entry.Route,
+		entry.UserID, entry.TenantID, entry.ClientIP, entry.Status, entry.LatencyMs, nullable(entry.RequestBody),
+		nullable(entry.ResponseBody))
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// nullable maps an empty body to NULL
+func nullable(body string) interface{} {
+	if body == "" {
+		return nil
+	}
+	return body
+}
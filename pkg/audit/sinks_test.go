@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+	"github.com/Okja-Engineering/go-service-kit/pkg/events"
+)
+
+// recordingPublisher keeps published messages
+type recordingPublisher struct {
+	topic string
+	msg   *events.Message
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, topic string, msg *events.Message) error {
+	p.topic, p.msg = topic, msg
+	return nil
+}
+
+func TestLogSink(t *testing.T) {
+	logger := &bufferLogger{}
+	entry := Entry{Time: time.Unix(0, 0).UTC(), Method: "DELETE", Path: "/users/42", Status: 204}
+
+	if err := LogSink(logger).Write(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	want := `### 📝 Audit: {"time":"1970-01-01T00:00:00Z","method":"DELETE","path":"/users/42","status":204,` +
+		`"latencyMs":0}`
+	if got := strings.TrimSpace(logger.buf.String()); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestEventSink(t *testing.T) {
+	publisher := &recordingPublisher{}
+	entry := Entry{Method: "POST", Path: "/orders", UserID: "user-1"}
+
+	if err := EventSink(publisher, "audit").Write(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	if publisher.topic != "audit" || publisher.msg.Type != "audit.request" {
+		t.Fatalf("Expected an audit.request event on audit, got %s and %+v", publisher.topic, publisher.msg)
+	}
+
+	var decoded Entry
+	if err := publisher.msg.Decode(&decoded); err != nil || decoded.UserID != "user-1" {
+		t.Errorf("Expected the entry as the event data, got %+v and %v", decoded, err)
+	}
+}
+
+func TestSQLSink(t *testing.T) {
+	sink := NewSQLSink(database.NewPostgreSQL(database.NewConfig()), "audit_log")
+
+	migration := sink.Migration(7)
+	for _, want := range []string{`CREATE TABLE IF NOT EXISTS "audit_log"`, "tenant_id TEXT", "latency_ms BIGINT",
+		`"audit_log_tenant_time"`} {
+		if !strings.Contains(migration.Up, want) {
+			t.Errorf("Expected migration to contain %q, got:\n%s", want, migration.Up)
+		}
+	}
+	if migration.Version != 7 || migration.Down != `DROP TABLE IF EXISTS "audit_log"` {
+		t.Errorf("Unexpected migration: %+v", migration)
+	}
+
+	err := sink.Write(context.Background(), Entry{Method: "GET"})
+	if err == nil || errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a connection error, got %v", err)
+	}
+}
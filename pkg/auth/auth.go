@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,7 +11,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/MicahParks/keyfunc/v2"
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -24,22 +25,30 @@ const (
 
 // JWTValidator provides hardened JWT validation with comprehensive security checks
 type JWTValidator struct {
-	clientID        string
-	scope           string
-	jwks            *keyfunc.JWKS
-	allowedAlgs     []string
-	tokenCache      map[string]*CachedToken
-	tokenCacheMutex sync.RWMutex
-	cacheTTL        time.Duration
-	revokedTokens   map[string]time.Time
-	revokedMutex    sync.RWMutex
+	clientID      string
+	scope         string
+	keys          *jwksSource
+	allowedAlgs   []string
+	tokenCache    tokenLRU // keyed by tokenKey
+	cacheTTL      time.Duration
+	leeway        time.Duration
+	revokedTokens map[string]time.Time // keyed by tokenKey
+	revokedMutex  sync.RWMutex
+	stopEviction  context.CancelFunc
+	clock         clock.Clock
 }
 
+// issuedAtSkew is how far in the future an iat claim may be, on top of any leeway
+const issuedAtSkew = 5 * time.Minute
+
+// revocationRetention is how long revocations are kept, after which revoked tokens have expired anyway
+const revocationRetention = 24 * time.Hour
+
 // CachedToken represents a cached validated token
 type CachedToken struct {
-	Claims    jwt.MapClaims
-	ExpiresAt time.Time
-	Validated time.Time
+	Claims    jwt.MapClaims `json:"claims"`
+	ExpiresAt time.Time     `json:"expiresAt"`
+	Validated time.Time     `json:"validated"`
 }
 
 // ValidationResult provides detailed validation information
@@ -58,14 +67,35 @@ type JWTConfig struct {
 	AllowedAlgs     []string
 	CacheTTL        time.Duration
 	RefreshInterval time.Duration
+	// Leeway tolerates clock skew with the token issuer in exp, nbf, and iat checks
+	Leeway time.Duration
+	// CacheMaxEntries limits how many validated tokens are cached, evicting the least recently used
+	CacheMaxEntries int
+	// CacheEvictionInterval is how often expired tokens are removed from the cache
+	CacheEvictionInterval time.Duration
+	// LazyJWKS fetches the JWKS in the background instead of failing startup when it's unreachable.
+	// Tokens are rejected until it has been fetched, unless JWKSCacheFile holds a previous copy.
+	LazyJWKS bool
+	// JWKSRetryInterval is the initial delay between background fetch attempts, doubling up to 5 minutes
+	JWKSRetryInterval time.Duration
+	// JWKSCacheFile is where each fetched JWKS is saved, and loaded from when the JWKS URL is unreachable
+	JWKSCacheFile string
+	// StaticJWKS is a JWKS document used instead of fetching one, for air-gapped environments
+	StaticJWKS json.RawMessage
+	// Clock is used for expiry checks and the token cache; nil means the system clock
+	Clock clock.Clock
 }
 
 // DefaultJWTConfig provides secure defaults
 func DefaultJWTConfig() *JWTConfig {
 	return &JWTConfig{
-		AllowedAlgs:     []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"},
-		CacheTTL:        5 * time.Minute,
-		RefreshInterval: 1 * time.Hour,
+		AllowedAlgs:           []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"},
+		CacheTTL:              5 * time.Minute,
+		RefreshInterval:       1 * time.Hour,
+		CacheMaxEntries:       10000,
+		CacheEvictionInterval: 1 * time.Minute,
+		JWKSRetryInterval:     5 * time.Second,
+		Clock:                 clock.Real(),
 	}
 }
 
@@ -79,34 +109,41 @@ func NewJWTValidator(config *JWTConfig) (*JWTValidator, error) {
 	if config.ClientID == "" {
 		return nil, fmt.Errorf("client ID is required")
 	}
-	if config.JWKSURL == "" {
+	if config.JWKSURL == "" && len(config.StaticJWKS) == 0 {
 		return nil, fmt.Errorf("JWKS URL is required")
 	}
 
-	// Fetch JWKS
-	jwks, err := keyfunc.Get(config.JWKSURL, keyfunc.Options{
-		RefreshInterval: config.RefreshInterval,
-		RefreshErrorHandler: func(err error) {
-			log.Printf("### 🔐 Auth: JWKS refresh error: %v", err)
-		},
-		RefreshUnknownKID: true,
-	})
-
+	keys, err := newJWKSSource(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+		return nil, err
 	}
 
-	log.Printf("### 🔐 Auth: JWT validation enabled with JWKS from %s", config.JWKSURL)
+	defaults := DefaultJWTConfig()
+	maxEntries := config.CacheMaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaults.CacheMaxEntries
+	}
+	evictionInterval := config.CacheEvictionInterval
+	if evictionInterval <= 0 {
+		evictionInterval = defaults.CacheEvictionInterval
+	}
 
-	return &JWTValidator{
+	ctx, stop := context.WithCancel(context.Background())
+	v := &JWTValidator{
 		clientID:      config.ClientID,
 		scope:         config.Scope,
-		jwks:          jwks,
+		keys:          keys,
 		allowedAlgs:   config.AllowedAlgs,
-		tokenCache:    make(map[string]*CachedToken),
+		tokenCache:    tokenLRU{maxEntries: maxEntries},
 		cacheTTL:      config.CacheTTL,
+		leeway:        config.Leeway,
 		revokedTokens: make(map[string]time.Time),
-	}, nil
+		stopEviction:  stop,
+		clock:         clock.OrReal(config.Clock),
+	}
+	go v.evictLoop(ctx, evictionInterval)
+
+	return v, nil
 }
 
 // Middleware returns a middleware function that validates JWT tokens
@@ -169,7 +206,15 @@ func (v *JWTValidator) ValidateRequest(r *http.Request) ValidationResult {
 	}
 
 	// Parse and validate token
-	token, err := jwt.Parse(tokenString, v.jwks.Keyfunc, jwt.WithValidMethods(v.allowedAlgs))
+	token, err := jwt.Parse(tokenString, v.keys.Keyfunc, jwt.WithValidMethods(v.allowedAlgs),
+		jwt.WithLeeway(v.leeway), jwt.WithTimeFunc(v.now))
+	if errors.Is(err, ErrJWKSUnavailable) {
+		return ValidationResult{
+			Valid:     false,
+			ErrorCode: "JWKS_UNAVAILABLE",
+			Error:     "Signing keys are not available yet",
+		}
+	}
 	if err != nil {
 		return ValidationResult{
 			Valid:     false,
@@ -229,14 +274,19 @@ func (v *JWTValidator) validateClaims(claims jwt.MapClaims) error {
 	return nil
 }
 
-// validateTimeClaims validates time-based claims (exp, iat, nbf)
+// now returns the time on the validator's clock, or the system time when it has none
+func (v *JWTValidator) now() time.Time {
+	return clock.OrReal(v.clock).Now()
+}
+
+// validateTimeClaims validates time-based claims (exp, iat, nbf), allowing for the configured leeway
 func (v *JWTValidator) validateTimeClaims(claims jwt.MapClaims) error {
-	now := time.Now()
+	now := v.now()
 
 	// Check expiration
 	if exp, ok := claims["exp"]; ok {
 		if expTime, ok := exp.(float64); ok {
-			if time.Unix(int64(expTime), 0).Before(now) {
+			if time.Unix(int64(expTime), 0).Add(v.leeway).Before(now) {
 				return fmt.Errorf("token has expired")
 			}
 		}
@@ -246,7 +296,7 @@ func (v *JWTValidator) validateTimeClaims(claims jwt.MapClaims) error {
 	if iat, ok := claims["iat"]; ok {
 		if iatTime, ok := iat.(float64); ok {
 			issuedAt := time.Unix(int64(iatTime), 0)
-			if issuedAt.After(now.Add(5 * time.Minute)) {
+			if issuedAt.After(now.Add(issuedAtSkew + v.leeway)) {
 				return fmt.Errorf("token issued in the future")
 			}
 		}
@@ -255,7 +305,7 @@ func (v *JWTValidator) validateTimeClaims(claims jwt.MapClaims) error {
 	// Check not before time
 	if nbf, ok := claims["nbf"]; ok {
 		if nbfTime, ok := nbf.(float64); ok {
-			if time.Unix(int64(nbfTime), 0).After(now) {
+			if time.Unix(int64(nbfTime), 0).After(now.Add(v.leeway)) {
 				return fmt.Errorf("token not yet valid")
 			}
 		}
@@ -326,9 +376,6 @@ func (v *JWTValidator) sendUnauthorizedResponse(w http.ResponseWriter, errorCode
 
 // cacheToken caches a validated token
 func (v *JWTValidator) cacheToken(tokenString string, claims jwt.MapClaims) {
-	v.tokenCacheMutex.Lock()
-	defer v.tokenCacheMutex.Unlock()
-
 	// Extract expiration time
 	var expiresAt time.Time
 	if exp, ok := claims["exp"]; ok {
@@ -337,34 +384,16 @@ func (v *JWTValidator) cacheToken(tokenString string, claims jwt.MapClaims) {
 		}
 	}
 
-	v.tokenCache[tokenString] = &CachedToken{
+	v.tokenCache.set(tokenKey(tokenString), &CachedToken{
 		Claims:    claims,
 		ExpiresAt: expiresAt,
-		Validated: time.Now(),
-	}
+		Validated: v.now(),
+	})
 }
 
 // getCachedToken retrieves a cached token if it's still valid
 func (v *JWTValidator) getCachedToken(tokenString string) *CachedToken {
-	v.tokenCacheMutex.RLock()
-	defer v.tokenCacheMutex.RUnlock()
-
-	cached, exists := v.tokenCache[tokenString]
-	if !exists {
-		return nil
-	}
-
-	// Check if cache entry is still valid
-	if time.Now().After(cached.Validated.Add(v.cacheTTL)) {
-		return nil
-	}
-
-	// Check if token has expired
-	if !cached.ExpiresAt.IsZero() && time.Now().After(cached.ExpiresAt) {
-		return nil
-	}
-
-	return cached
+	return v.tokenCache.get(tokenKey(tokenString), v.cacheEntryValid)
 }
 
 // isTokenRevoked checks if a token has been revoked
@@ -372,16 +401,17 @@ func (v *JWTValidator) isTokenRevoked(tokenString string) bool {
 	v.revokedMutex.RLock()
 	defer v.revokedMutex.RUnlock()
 
-	revokedAt, exists := v.revokedTokens[tokenString]
+	key := tokenKey(tokenString)
+	revokedAt, exists := v.revokedTokens[key]
 	if !exists {
 		return false
 	}
 
-	// Clean up old revoked tokens (older than 24 hours)
-	if time.Since(revokedAt) > 24*time.Hour {
+	// Clean up old revoked tokens
+	if v.now().Sub(revokedAt) > revocationRetention {
 		v.revokedMutex.RUnlock()
 		v.revokedMutex.Lock()
-		delete(v.revokedTokens, tokenString)
+		delete(v.revokedTokens, key)
 		v.revokedMutex.Unlock()
 		v.revokedMutex.RLock()
 		return false
@@ -390,11 +420,25 @@ func (v *JWTValidator) isTokenRevoked(tokenString string) bool {
 	return true
 }
 
+// JWKSReady reports whether signing keys are loaded, and whether they came from the JWKS URL rather than
+// a cache file or static JSON. It suits a readiness check when LazyJWKS is set.
+func (v *JWTValidator) JWKSReady() (loaded, remote bool) {
+	return v.keys.ready()
+}
+
+// Close stops fetching and refreshing the JWKS and evicting expired tokens in the background
+func (v *JWTValidator) Close() {
+	v.keys.close()
+	if v.stopEviction != nil {
+		v.stopEviction()
+	}
+}
+
 // RevokeToken marks a token as revoked
 func (v *JWTValidator) RevokeToken(tokenString string) {
 	v.revokedMutex.Lock()
 	defer v.revokedMutex.Unlock()
-	v.revokedTokens[tokenString] = time.Now()
+	v.revokedTokens[tokenKey(tokenString)] = v.now()
 }
 
 // GetClaimsFromContext extracts JWT claims from request context
@@ -421,8 +465,11 @@ func GetUserIDFromContext(ctx context.Context) (string, bool) {
 	return "", false
 }
 
-// PassthroughValidator for testing/development
-type PassthroughValidator struct{}
+// PassthroughValidator for testing/development. It accepts every request, adding Claims to the
+// request context when set, so handlers that read the user from claims still work.
+type PassthroughValidator struct {
+	Claims jwt.MapClaims
+}
 
 func NewPassthroughValidator() *PassthroughValidator {
 	return &PassthroughValidator{}
@@ -430,14 +477,41 @@ func NewPassthroughValidator() *PassthroughValidator {
 
 func (v *PassthroughValidator) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, v.withClaims(r))
 	})
 }
 
 func (v *PassthroughValidator) Protect(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, v.withClaims(r))
+	}
+}
+
+// ValidateRequest accepts every request, returning Claims
+func (v *PassthroughValidator) ValidateRequest(r *http.Request) ValidationResult {
+	return ValidationResult{Valid: true, Claims: v.Claims}
+}
+
+func (v *PassthroughValidator) withClaims(r *http.Request) *http.Request {
+	if v.Claims == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), JWTClaimsKey, v.Claims))
+}
+
+// NewValidator returns a JWTValidator, or a PassthroughValidator when enabled is false, so auth can be
+// switched off by configuration in development
+func NewValidator(enabled bool, config *JWTConfig) (Validator, error) {
+	if !enabled {
+		log.Printf("### 🔐 Auth: JWT validation disabled, all requests are allowed")
+		return NewPassthroughValidator(), nil
+	}
+
+	validator, err := NewJWTValidator(config)
+	if err != nil {
+		return nil, err
 	}
+	return validator, nil
 }
 
 // Error types for better error handling
@@ -500,13 +574,19 @@ func Compose(handlers ...func(http.HandlerFunc) http.HandlerFunc) func(http.Hand
 	}
 }
 
-// Validator interface defines the contract for JWT validation
+// Validator interface defines the contract for JWT validation. JWTValidator and PassthroughValidator
+// implement it, so code can hold a Validator and swap in a passthrough or a mock for development and tests.
 type Validator interface {
 	Middleware(next http.Handler) http.Handler
 	Protect(next http.HandlerFunc) http.HandlerFunc
 	ValidateRequest(r *http.Request) ValidationResult
 }
 
+var (
+	_ Validator = (*JWTValidator)(nil)
+	_ Validator = (*PassthroughValidator)(nil)
+)
+
 // TokenExtractor interface for flexible token extraction
 type TokenExtractor interface {
 	ExtractToken(r *http.Request) string
@@ -93,6 +93,39 @@ func TestPassthroughValidator(t *testing.T) {
 	}
 }
 
+func TestPassthroughValidatorClaims(t *testing.T) {
+	var validator Validator = &PassthroughValidator{Claims: jwt.MapClaims{"sub": "dev-user"}}
+
+	result := validator.ValidateRequest(httptest.NewRequest("GET", "/test", nil))
+	if !result.Valid || result.Claims["sub"] != "dev-user" {
+		t.Errorf("Expected valid result with claims, got %+v", result)
+	}
+
+	var userID string
+	handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ = GetUserIDFromContext(r.Context())
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+
+	if userID != "dev-user" {
+		t.Errorf("Expected user ID from passthrough claims, got %q", userID)
+	}
+}
+
+func TestNewValidator(t *testing.T) {
+	validator, err := NewValidator(false, nil)
+	if err != nil {
+		t.Fatalf("Expected no error when disabled, got %v", err)
+	}
+	if _, ok := validator.(*PassthroughValidator); !ok {
+		t.Errorf("Expected passthrough validator when disabled, got %T", validator)
+	}
+
+	if _, err := NewValidator(true, &JWTConfig{}); err == nil {
+		t.Error("Expected error for missing JWT config when enabled")
+	}
+}
+
 func TestExtractToken(t *testing.T) {
 	validator := &JWTValidator{}
 
@@ -226,6 +259,77 @@ func TestValidateClaims(t *testing.T) {
 	}
 }
 
+func TestValidateTimeClaimsLeeway(t *testing.T) {
+	now := time.Now()
+	at := func(offset time.Duration) float64 { return float64(now.Add(offset).Unix()) }
+
+	tests := []struct {
+		name        string
+		leeway      time.Duration
+		claims      jwt.MapClaims
+		expectError bool
+	}{
+		{name: "expired without leeway", claims: jwt.MapClaims{"exp": at(-10 * time.Second)}, expectError: true},
+		{name: "expired within leeway", leeway: 30 * time.Second, claims: jwt.MapClaims{"exp": at(-10 * time.Second)}},
+		{
+			name:        "expired beyond leeway",
+			leeway:      30 * time.Second,
+			claims:      jwt.MapClaims{"exp": at(-time.Minute)},
+			expectError: true,
+		},
+		{name: "not yet valid without leeway", claims: jwt.MapClaims{"nbf": at(10 * time.Second)}, expectError: true},
+		{name: "not yet valid within leeway", leeway: 30 * time.Second, claims: jwt.MapClaims{"nbf": at(10 * time.Second)}},
+		{name: "issued in future within skew", claims: jwt.MapClaims{"iat": at(4 * time.Minute)}},
+		{name: "issued in future beyond skew", claims: jwt.MapClaims{"iat": at(6 * time.Minute)}, expectError: true},
+		{name: "issued in future within leeway", leeway: 2 * time.Minute, claims: jwt.MapClaims{"iat": at(6 * time.Minute)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &JWTValidator{leeway: tt.leeway}
+			err := validator.validateTimeClaims(tt.claims)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateRequestLeeway(t *testing.T) {
+	key := newTestKey(t)
+	request := func() *http.Request {
+		return key.requestWithClaims(t, jwt.MapClaims{
+			"aud": "test-client",
+			"exp": time.Now().Add(-10 * time.Second).Unix(),
+		})
+	}
+
+	config := testJWTConfig("")
+	config.StaticJWKS = key.jwks
+
+	strict, err := NewJWTValidator(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer strict.Close()
+	if result := strict.ValidateRequest(request()); result.Valid {
+		t.Error("Expected recently expired token to be rejected without leeway")
+	}
+
+	config.Leeway = 30 * time.Second
+	tolerant, err := NewJWTValidator(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tolerant.Close()
+	if result := tolerant.ValidateRequest(request()); !result.Valid {
+		t.Errorf("Expected recently expired token to be accepted with leeway, got %s", result.Error)
+	}
+}
+
 func TestTokenRevocation(t *testing.T) {
 	validator := &JWTValidator{
 		revokedTokens: make(map[string]time.Time),
@@ -422,8 +526,7 @@ func TestJWTValidatorValidateRequest(t *testing.T) {
 // Test caching functionality
 func TestTokenCaching(t *testing.T) {
 	validator := &JWTValidator{
-		tokenCache: make(map[string]*CachedToken),
-		cacheTTL:   5 * time.Minute,
+		cacheTTL: 5 * time.Minute,
 	}
 
 	token := "test-token"
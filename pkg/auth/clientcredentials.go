@@ -0,0 +1,309 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token is an access token obtained from an OAuth2 token endpoint
+type Token struct {
+	AccessToken string
+	TokenType   string
+	ExpiresAt   time.Time
+}
+
+// TokenError is an error response from a token endpoint
+type TokenError struct {
+	StatusCode  int
+	Code        string
+	Description string
+}
+
+func (e *TokenError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("token endpoint returned %d %s: %s", e.StatusCode, e.Code, e.Description)
+	}
+	return fmt.Sprintf("token endpoint returned %d %s", e.StatusCode, e.Code)
+}
+
+// ClientCredentialsConfig holds configuration for the OAuth2 client-credentials grant
+type ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// Audience is sent as the audience parameter, which some providers require to pick the API
+	Audience string
+	// EarlyRefresh is how long before expiry a token is replaced, so requests in flight don't carry
+	// a token that expires on the way
+	EarlyRefresh time.Duration
+	// CredentialsInBody sends the client ID and secret as form parameters instead of basic auth
+	CredentialsInBody bool
+	HTTPClient        *http.Client
+}
+
+// DefaultClientCredentialsConfig provides sensible defaults
+func DefaultClientCredentialsConfig() *ClientCredentialsConfig {
+	return &ClientCredentialsConfig{
+		EarlyRefresh: 1 * time.Minute,
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ClientCredentialsOption is a functional option for configuring a token source
+type ClientCredentialsOption func(*ClientCredentialsConfig)
+
+// WithScopes sets the scopes requested with each token
+func WithScopes(scopes ...string) ClientCredentialsOption {
+	return func(config *ClientCredentialsConfig) {
+		config.Scopes = scopes
+	}
+}
+
+// WithAudience sets the audience requested with each token
+func WithAudience(audience string) ClientCredentialsOption {
+	return func(config *ClientCredentialsConfig) {
+		config.Audience = audience
+	}
+}
+
+// WithEarlyRefresh sets how long before expiry tokens are replaced
+func WithEarlyRefresh(early time.Duration) ClientCredentialsOption {
+	return func(config *ClientCredentialsConfig) {
+		config.EarlyRefresh = early
+	}
+}
+
+// WithCredentialsInBody sends client credentials as form parameters instead of basic auth
+func WithCredentialsInBody(inBody bool) ClientCredentialsOption {
+	return func(config *ClientCredentialsConfig) {
+		config.CredentialsInBody = inBody
+	}
+}
+
+// WithTokenHTTPClient sets the HTTP client used to call the token endpoint
+func WithTokenHTTPClient(client *http.Client) ClientCredentialsOption {
+	return func(config *ClientCredentialsConfig) {
+		config.HTTPClient = client
+	}
+}
+
+// NewClientCredentialsConfig creates a new client-credentials config with options
+func NewClientCredentialsConfig(tokenURL, clientID, clientSecret string,
+	options ...ClientCredentialsOption) *ClientCredentialsConfig {
+	config := DefaultClientCredentialsConfig()
+	config.TokenURL = tokenURL
+	config.ClientID = clientID
+	config.ClientSecret = clientSecret
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// TokenSource obtains access tokens with the client-credentials grant, caching each one until shortly
+// before it expires. It is safe for concurrent use.
+type TokenSource struct {
+	config *ClientCredentialsConfig
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// NewTokenSource creates a client-credentials token source
+func NewTokenSource(tokenURL, clientID, clientSecret string, options ...ClientCredentialsOption) *TokenSource {
+	return &TokenSource{config: NewClientCredentialsConfig(tokenURL, clientID, clientSecret, options...)}
+}
+
+// Token returns the cached token, fetching a new one when it is missing or due for refresh. If a refresh
+// fails while the cached token is still valid, the cached token is returned.
+func (s *TokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.token != nil && now.Before(s.token.ExpiresAt.Add(-s.config.EarlyRefresh)) {
+		return s.token, nil
+	}
+
+	token, err := s.fetch(ctx)
+	if err != nil {
+		if s.token != nil && now.Before(s.token.ExpiresAt) {
+			log.Printf("### 🔐 Auth: token refresh failed, using current token until it expires: %v", err)
+			return s.token, nil
+		}
+		return nil, err
+	}
+
+	s.token = token
+	return token, nil
+}
+
+// Invalidate discards the cached token, so the next call to Token fetches a new one
+func (s *TokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = nil
+}
+
+// tokenResponse is the JSON body returned by a token endpoint, whose field names RFC 6749 defines
+//
+//nolint:tagliatelle
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// fetch requests a new token from the token endpoint
+func (s *TokenSource) fetch(ctx context.Context) (*Token, error) {
+	req, err := s.tokenRequest(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+
+	resp, err := s.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	return parseTokenResponse(resp)
+}
+
+// tokenRequest builds a client-credentials grant request
+func (s *TokenSource) tokenRequest(ctx context.Context) (*http.Request, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	if s.config.Audience != "" {
+		form.Set("audience", s.config.Audience)
+	}
+	if s.config.CredentialsInBody {
+		form.Set("client_id", s.config.ClientID)
+		form.Set("client_secret", s.config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !s.config.CredentialsInBody {
+		// RFC 6749 requires the credentials to be form-encoded before basic auth encoding
+		req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	}
+	return req, nil
+}
+
+// parseTokenResponse reads a token, or a TokenError, from a token endpoint response
+func parseTokenResponse(resp *http.Response) (*Token, error) {
+	var body tokenResponse
+	err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body)
+
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, &TokenError{StatusCode: resp.StatusCode, Code: body.Error, Description: body.ErrorDescription}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if body.AccessToken == "" {
+		return nil, errors.New("token response has no access_token")
+	}
+
+	token := &Token{AccessToken: body.AccessToken, TokenType: body.TokenType}
+	if token.TokenType == "" || strings.EqualFold(token.TokenType, "bearer") {
+		token.TokenType = "Bearer"
+	}
+	// A token without expires_in is assumed to last an hour, so it is still refreshed periodically
+	expiresIn := time.Duration(body.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = time.Hour
+	}
+	token.ExpiresAt = time.Now().Add(expiresIn)
+
+	return token, nil
+}
+
+// Transport is an http.RoundTripper that adds a token from Source to each request. When a response is
+// 401 Unauthorized, the token is discarded and the request retried once with a new one, if its body can
+// be replayed.
+type Transport struct {
+	Source *TokenSource
+	// Base is the transport requests are sent with; nil uses http.DefaultTransport
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.send(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	retry, ok := replayable(req)
+	if !ok {
+		return resp, nil
+	}
+
+	t.Source.Invalidate()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	_ = resp.Body.Close()
+	return t.send(retry)
+}
+
+// send clones req, as a RoundTripper must not modify it, and adds the Authorization header
+func (t *Transport) send(req *http.Request) (*http.Response, error) {
+	token, err := t.Source.Token(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+
+	clone := req.Clone(req.Context())
+	clone.Header.Set("Authorization", token.TokenType+" "+token.AccessToken)
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(clone)
+}
+
+// replayable returns a copy of req with a fresh body, if it has none or can recreate it
+func replayable(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, true
+}
+
+// Client returns an HTTP client that authenticates its requests with tokens from s
+func (s *TokenSource) Client() *http.Client {
+	return &http.Client{Transport: &Transport{Source: s}}
+}
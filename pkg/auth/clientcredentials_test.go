@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenRequestInfo is what a token server saw in a token request
+type tokenRequestInfo struct {
+	form       url.Values
+	user, pass string
+	basicAuth  bool
+}
+
+// tokenServer issues numbered tokens, recording the last token request
+type tokenServer struct {
+	*httptest.Server
+	issued    atomic.Int32
+	expiresIn int
+	fail      atomic.Bool
+
+	mu   sync.Mutex
+	last tokenRequestInfo
+}
+
+func newTokenServer(t *testing.T, expiresIn int) *tokenServer {
+	t.Helper()
+	s := &tokenServer{expiresIn: expiresIn}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		user, pass, ok := r.BasicAuth()
+		s.mu.Lock()
+		s.last = tokenRequestInfo{form: r.PostForm, user: user, pass: pass, basicAuth: ok}
+		s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if s.fail.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"bad secret"}`))
+			return
+		}
+		n := s.issued.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "bearer",
+			"expires_in":   s.expiresIn,
+		})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// lastRequest returns the most recent token request
+func (s *tokenServer) lastRequest() tokenRequestInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+func TestTokenSourceCaching(t *testing.T) {
+	server := newTokenServer(t, 3600)
+	source := NewTokenSource(server.URL, "client", "secret")
+
+	for range 3 {
+		token, err := source.Token(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if token.AccessToken != "token-1" || token.TokenType != "Bearer" {
+			t.Errorf("Expected cached Bearer token-1, got %+v", token)
+		}
+	}
+	if got := server.issued.Load(); got != 1 {
+		t.Errorf("Expected 1 token request, got %d", got)
+	}
+
+	source.Invalidate()
+	if token, _ := source.Token(context.Background()); token == nil || token.AccessToken != "token-2" {
+		t.Errorf("Expected a new token after Invalidate, got %+v", token)
+	}
+}
+
+func TestTokenSourceEarlyRefresh(t *testing.T) {
+	// Tokens last 30s, inside the 1m early refresh window, so every call fetches
+	server := newTokenServer(t, 30)
+	source := NewTokenSource(server.URL, "client", "secret")
+
+	first, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.AccessToken == second.AccessToken {
+		t.Error("Expected token to be refreshed early")
+	}
+
+	// When the refresh fails, the still-valid token keeps being used
+	server.fail.Store(true)
+	third, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Expected current token after failed refresh, got %v", err)
+	}
+	if third.AccessToken != second.AccessToken {
+		t.Errorf("Expected %s, got %s", second.AccessToken, third.AccessToken)
+	}
+}
+
+func TestTokenSourceError(t *testing.T) {
+	server := newTokenServer(t, 3600)
+	server.fail.Store(true)
+	source := NewTokenSource(server.URL, "client", "wrong")
+
+	_, err := source.Token(context.Background())
+	var tokenErr *TokenError
+	if !errors.As(err, &tokenErr) {
+		t.Fatalf("Expected TokenError, got %v", err)
+	}
+	if tokenErr.StatusCode != http.StatusUnauthorized || tokenErr.Code != "invalid_client" {
+		t.Errorf("Expected 401 invalid_client, got %+v", tokenErr)
+	}
+}
+
+func TestTokenSourceRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []ClientCredentialsOption
+		check   func(t *testing.T, r tokenRequestInfo)
+	}{
+		{
+			name:    "basic auth",
+			options: []ClientCredentialsOption{WithScopes("read", "write"), WithAudience("https://api")},
+			check: func(t *testing.T, r tokenRequestInfo) {
+				t.Helper()
+				if !r.basicAuth || r.user != "my+client" || r.pass != "s%3Acret" {
+					t.Errorf("Expected form-encoded basic auth, got %q %q", r.user, r.pass)
+				}
+				if r.form.Get("client_id") != "" {
+					t.Error("Expected no client_id in body with basic auth")
+				}
+				if r.form.Get("scope") != "read write" || r.form.Get("audience") != "https://api" {
+					t.Errorf("Expected scope and audience, got %v", r.form)
+				}
+			},
+		},
+		{
+			name:    "credentials in body",
+			options: []ClientCredentialsOption{WithCredentialsInBody(true)},
+			check: func(t *testing.T, r tokenRequestInfo) {
+				t.Helper()
+				if r.basicAuth {
+					t.Error("Expected no basic auth with credentials in body")
+				}
+				if r.form.Get("client_id") != "my client" || r.form.Get("client_secret") != "s:cret" {
+					t.Errorf("Expected credentials in body, got %v", r.form)
+				}
+				if r.form.Has("scope") {
+					t.Error("Expected no scope when none configured")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTokenServer(t, 3600)
+			source := NewTokenSource(server.URL, "my client", "s:cret", tt.options...)
+			if _, err := source.Token(context.Background()); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			r := server.lastRequest()
+			if r.form.Get("grant_type") != "client_credentials" {
+				t.Errorf("Expected client_credentials grant, got %q", r.form.Get("grant_type"))
+			}
+			tt.check(t, r)
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	tokens := newTokenServer(t, 3600)
+	source := NewTokenSource(tokens.URL, "client", "secret")
+
+	// The API rejects token-1, as if it had been revoked, and accepts later ones
+	var calls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if body, _ := io.ReadAll(r.Body); string(body) != "payload" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer api.Close()
+
+	resp, err := source.Client().Post(api.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected retry with a new token to succeed, got %d", resp.StatusCode)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("Expected 2 API calls, got %d", got)
+	}
+	if got := tokens.issued.Load(); got != 2 {
+		t.Errorf("Expected 2 tokens issued, got %d", got)
+	}
+}
+
+func TestTransportTokenError(t *testing.T) {
+	tokens := newTokenServer(t, 3600)
+	tokens.fail.Store(true)
+	client := &http.Client{
+		Transport: &Transport{Source: NewTokenSource(tokens.URL, "client", "secret")},
+		Timeout:   time.Second,
+	}
+
+	if _, err := client.Get(tokens.URL); err == nil {
+		t.Error("Expected error when no token can be obtained")
+	}
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/MicahParks/keyfunc/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrJWKSUnavailable is returned when validating a token before any JWKS has been loaded
+var ErrJWKSUnavailable = errors.New("JWKS not available yet")
+
+// maxJWKSRetryInterval caps the backoff between JWKS fetch attempts
+const maxJWKSRetryInterval = 5 * time.Minute
+
+// jwksSource supplies signing keys from a remote JWKS, a cached copy on disk, or static JSON. When the
+// remote JWKS can't be fetched it keeps retrying in the background, serving cached keys meanwhile.
+type jwksSource struct {
+	url             string
+	refreshInterval time.Duration
+	retryInterval   time.Duration
+	cacheFile       string
+
+	mu     sync.RWMutex
+	jwks   *keyfunc.JWKS
+	remote bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newJWKSSource loads keys as configured. It only fails when the JWKS is fetched eagerly, the fetch fails,
+// and there is no usable cache file.
+func newJWKSSource(config *JWTConfig) (*jwksSource, error) {
+	if len(config.StaticJWKS) > 0 {
+		jwks, err := keyfunc.NewJSON(config.StaticJWKS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse static JWKS: %w", err)
+		}
+		log.Printf("### 🔐 Auth: JWT validation enabled with %d static key(s)", jwks.Len())
+		return &jwksSource{jwks: jwks}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &jwksSource{
+		url:             config.JWKSURL,
+		refreshInterval: config.RefreshInterval,
+		retryInterval:   config.JWKSRetryInterval,
+		cacheFile:       config.JWKSCacheFile,
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+	if s.retryInterval <= 0 {
+		s.retryInterval = DefaultJWTConfig().JWKSRetryInterval
+	}
+
+	if !config.LazyJWKS {
+		err := s.fetch()
+		if err == nil {
+			log.Printf("### 🔐 Auth: JWT validation enabled with JWKS from %s", s.url)
+			return s, nil
+		}
+		if !s.loadCacheFile() {
+			cancel()
+			return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+		}
+		log.Printf("### 🔐 Auth: JWKS fetch from %s failed, using cached keys until it succeeds: %v", s.url, err)
+	} else if s.loadCacheFile() {
+		log.Printf("### 🔐 Auth: using cached JWKS until %s is fetched", s.url)
+	}
+
+	go s.retry(!config.LazyJWKS)
+	return s, nil
+}
+
+// Keyfunc looks up the key for a token in the current JWKS
+func (s *jwksSource) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if s == nil {
+		return nil, ErrJWKSUnavailable
+	}
+
+	s.mu.RLock()
+	jwks := s.jwks
+	s.mu.RUnlock()
+
+	if jwks == nil {
+		return nil, ErrJWKSUnavailable
+	}
+	return jwks.Keyfunc(token)
+}
+
+// ready reports whether any keys are loaded, and whether they came from the remote JWKS
+func (s *jwksSource) ready() (loaded, remote bool) {
+	if s == nil {
+		return false, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.jwks != nil, s.remote
+}
+
+// fetch gets the remote JWKS, which then refreshes itself in the background
+func (s *jwksSource) fetch() error {
+	jwks, err := keyfunc.Get(s.url, keyfunc.Options{
+		Ctx:             s.ctx,
+		RefreshInterval: s.refreshInterval,
+		RefreshErrorHandler: func(err error) {
+			log.Printf("### 🔐 Auth: JWKS refresh error: %v", err)
+		},
+		RefreshUnknownKID: true,
+		ResponseExtractor: s.extract,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.jwks, s.remote = jwks, true
+	s.mu.Unlock()
+	return nil
+}
+
+// extract reads a JWKS response, saving a copy to the cache file so it can be used if a later start
+// can't reach the JWKS URL
+func (s *jwksSource) extract(ctx context.Context, resp *http.Response) (json.RawMessage, error) {
+	raw, err := keyfunc.ResponseExtractorStatusOK(ctx, resp)
+	if err != nil || s.cacheFile == "" || !json.Valid(raw) {
+		return raw, err
+	}
+
+	if err := writeFileAtomic(s.cacheFile, raw); err != nil {
+		log.Printf("### 🔐 Auth: failed to write JWKS cache file: %v", err)
+	}
+	return raw, nil
+}
+
+// loadCacheFile loads keys saved by a previous fetch, reporting whether it succeeded
+func (s *jwksSource) loadCacheFile() bool {
+	if s.cacheFile == "" {
+		return false
+	}
+
+	raw, err := os.ReadFile(s.cacheFile)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("### 🔐 Auth: failed to read JWKS cache file: %v", err)
+		}
+		return false
+	}
+
+	jwks, err := keyfunc.NewJSON(raw)
+	if err != nil {
+		log.Printf("### 🔐 Auth: ignoring invalid JWKS cache file: %v", err)
+		return false
+	}
+
+	s.mu.Lock()
+	s.jwks = jwks
+	s.mu.Unlock()
+	return true
+}
+
+// retry fetches the remote JWKS with backoff until it succeeds or the source is closed. The first
+// attempt is immediate unless a fetch has just failed.
+func (s *jwksSource) retry(justFailed bool) {
+	delay := s.retryInterval
+	if !justFailed {
+		delay = 0
+	}
+	for {
+		timer := time.NewTimer(delay)
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		err := s.fetch()
+		if err == nil {
+			log.Printf("### 🔐 Auth: JWT validation enabled with JWKS from %s", s.url)
+			return
+		}
+
+		delay = min(max(delay*2, s.retryInterval), maxJWKSRetryInterval)
+		log.Printf("### 🔐 Auth: JWKS fetch from %s failed, retrying in %s: %v", s.url, delay, err)
+	}
+}
+
+// close stops background fetching and refreshing
+func (s *jwksSource) close() {
+	if s == nil || s.cancel == nil {
+		return
+	}
+	s.cancel()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.jwks != nil {
+		s.jwks.EndBackground()
+	}
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place, so readers never see a
+// partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testKey is a signing key and the JWKS publishing it
+type testKey struct {
+	private *rsa.PrivateKey
+	jwks    json.RawMessage
+}
+
+func newTestKey(t *testing.T) *testKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	jwks, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(private.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(private.E)).Bytes()),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode JWKS: %v", err)
+	}
+
+	return &testKey{private: private, jwks: jwks}
+}
+
+// request returns a request carrying a valid token signed by the key
+func (k *testKey) request(t *testing.T) *http.Request {
+	t.Helper()
+	return k.requestWithClaims(t, jwt.MapClaims{
+		"sub": "user-1",
+		"aud": "test-client",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+}
+
+// requestWithClaims returns a request carrying a token with claims signed by the key
+func (k *testKey) requestWithClaims(t *testing.T, claims jwt.MapClaims) *http.Request {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+
+	signed, err := token.SignedString(k.private)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+signed)
+	return req
+}
+
+// jwksServer serves the key's JWKS once up is true
+func jwksServer(t *testing.T, key *testKey, up *atomic.Bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(key.jwks)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testJWTConfig(url string) *JWTConfig {
+	config := DefaultJWTConfig()
+	config.ClientID = "test-client"
+	config.JWKSURL = url
+	config.JWKSRetryInterval = 10 * time.Millisecond
+	return config
+}
+
+func TestStaticJWKS(t *testing.T) {
+	key := newTestKey(t)
+	config := testJWTConfig("")
+	config.StaticJWKS = key.jwks
+
+	validator, err := NewJWTValidator(config)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer validator.Close()
+
+	if result := validator.ValidateRequest(key.request(t)); !result.Valid {
+		t.Errorf("Expected token to be valid with static JWKS, got %s", result.Error)
+	}
+	if loaded, remote := validator.JWKSReady(); !loaded || remote {
+		t.Errorf("Expected static keys loaded, got loaded=%v remote=%v", loaded, remote)
+	}
+
+	config.StaticJWKS = json.RawMessage(`{"keys": "nope"}`)
+	if _, err := NewJWTValidator(config); err == nil {
+		t.Error("Expected error for invalid static JWKS")
+	}
+}
+
+func TestLazyJWKS(t *testing.T) {
+	key := newTestKey(t)
+	var up atomic.Bool
+	server := jwksServer(t, key, &up)
+
+	config := testJWTConfig(server.URL)
+	config.LazyJWKS = true
+
+	validator, err := NewJWTValidator(config)
+	if err != nil {
+		t.Fatalf("Expected lazy validator to start with JWKS down, got %v", err)
+	}
+	defer validator.Close()
+
+	result := validator.ValidateRequest(key.request(t))
+	if result.Valid || result.ErrorCode != "JWKS_UNAVAILABLE" {
+		t.Errorf("Expected JWKS_UNAVAILABLE before fetch, got %+v", result)
+	}
+
+	up.Store(true)
+	deadline := time.Now().Add(2 * time.Second)
+	for loaded, _ := validator.JWKSReady(); !loaded; loaded, _ = validator.JWKSReady() {
+		if time.Now().After(deadline) {
+			t.Fatal("JWKS was not fetched after the server came up")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if result := validator.ValidateRequest(key.request(t)); !result.Valid {
+		t.Errorf("Expected token to be valid once JWKS fetched, got %s", result.Error)
+	}
+}
+
+func TestJWKSCacheFile(t *testing.T) {
+	key := newTestKey(t)
+	var up atomic.Bool
+	up.Store(true)
+	server := jwksServer(t, key, &up)
+
+	config := testJWTConfig(server.URL)
+	config.JWKSCacheFile = filepath.Join(t.TempDir(), "jwks.json")
+
+	validator, err := NewJWTValidator(config)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	validator.Close()
+
+	saved, err := os.ReadFile(config.JWKSCacheFile)
+	if err != nil || !json.Valid(saved) {
+		t.Fatalf("Expected JWKS to be saved to the cache file, got %q, %v", saved, err)
+	}
+
+	// With the JWKS URL down, a restart uses the cached copy instead of failing
+	up.Store(false)
+	validator, err = NewJWTValidator(config)
+	if err != nil {
+		t.Fatalf("Expected fallback to cache file, got %v", err)
+	}
+	defer validator.Close()
+
+	if result := validator.ValidateRequest(key.request(t)); !result.Valid {
+		t.Errorf("Expected token to be valid with cached JWKS, got %s", result.Error)
+	}
+	if loaded, remote := validator.JWKSReady(); !loaded || remote {
+		t.Errorf("Expected cached keys, got loaded=%v remote=%v", loaded, remote)
+	}
+}
+
+func TestJWKSUnreachableWithoutFallback(t *testing.T) {
+	var up atomic.Bool
+	server := jwksServer(t, newTestKey(t), &up)
+
+	config := testJWTConfig(server.URL)
+	config.JWKSCacheFile = filepath.Join(t.TempDir(), "missing.json")
+
+	if _, err := NewJWTValidator(config); err == nil {
+		t.Error("Expected error when JWKS is unreachable and there is no cache file")
+	}
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/state"
+)
+
+// cacheSnapshotKey is the state key the token cache is saved under
+const cacheSnapshotKey = "jwt-cache"
+
+// ErrUnsignedCacheSnapshot is returned by SaveCache and LoadCache when the state config has no signing
+// key. Restored tokens are trusted without checking their signature again, so an unsigned snapshot
+// would let anyone who can write to the store forge claims.
+var ErrUnsignedCacheSnapshot = errors.New("token cache snapshots require a state signing key")
+
+// cacheSnapshot is the persisted form of the token cache and revocations. Tokens are stored as
+// hashes, so a snapshot never contains a usable credential.
+type cacheSnapshot struct {
+	Tokens  map[string]*CachedToken `json:"tokens"`
+	Revoked map[string]time.Time    `json:"revoked"`
+}
+
+// tokenKey hashes a token for use as a cache key
+func tokenKey(tokenString string) string {
+	sum := sha256.Sum256([]byte(tokenString))
+	return hex.EncodeToString(sum[:])
+}
+
+// SaveCache persists validated tokens and revocations, typically from a shutdown hook, so a restarted
+// instance doesn't revalidate every token at once. config must have a signing key.
+func (v *JWTValidator) SaveCache(ctx context.Context, config *state.Config) error {
+	if config.SigningKey == nil {
+		return ErrUnsignedCacheSnapshot
+	}
+	snapshot := cacheSnapshot{
+		Tokens:  v.tokenCache.snapshot(v.cacheEntryValid),
+		Revoked: make(map[string]time.Time),
+	}
+
+	v.revokedMutex.RLock()
+	for key, revokedAt := range v.revokedTokens {
+		snapshot.Revoked[key] = revokedAt
+	}
+	v.revokedMutex.RUnlock()
+
+	if err := config.Save(ctx, cacheSnapshotKey, snapshot); err != nil {
+		return err
+	}
+
+	log.Printf("### 🔐 Auth: saved %d cached tokens and %d revocations", len(snapshot.Tokens), len(snapshot.Revoked))
+	return nil
+}
+
+// LoadCache restores a snapshot saved by SaveCache. Entries past their cache TTL or token expiry are
+// skipped, and a missing snapshot is not an error. config must have the signing key the snapshot was
+// saved with; a snapshot with an invalid signature is rejected with state.ErrInvalidSignature.
+func (v *JWTValidator) LoadCache(ctx context.Context, config *state.Config) error {
+	if config.SigningKey == nil {
+		return ErrUnsignedCacheSnapshot
+	}
+	var snapshot cacheSnapshot
+	if err := config.Load(ctx, cacheSnapshotKey, &snapshot); err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	restored := 0
+	for key, cached := range snapshot.Tokens {
+		if v.cacheEntryValid(cached) {
+			v.tokenCache.set(key, cached)
+			restored++
+		}
+	}
+
+	v.revokedMutex.Lock()
+	for key, revokedAt := range snapshot.Revoked {
+		v.revokedTokens[key] = revokedAt
+	}
+	v.revokedMutex.Unlock()
+
+	log.Printf("### 🔐 Auth: restored %d cached tokens and %d revocations", restored, len(snapshot.Revoked))
+	return nil
+}
+
+// cacheEntryValid reports whether a cache entry is within its TTL and its token hasn't expired
+func (v *JWTValidator) cacheEntryValid(cached *CachedToken) bool {
+	now := v.now()
+	if cached == nil || now.After(cached.Validated.Add(v.cacheTTL)) {
+		return false
+	}
+	return cached.ExpiresAt.IsZero() || now.Before(cached.ExpiresAt.Add(v.leeway))
+}
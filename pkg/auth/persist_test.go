@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/state"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testSigningKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newCacheValidator() *JWTValidator {
+	return &JWTValidator{
+		cacheTTL:      5 * time.Minute,
+		revokedTokens: make(map[string]time.Time),
+	}
+}
+
+func TestSaveLoadCache(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "state")
+	config := state.NewConfig(state.WithStore(state.NewFileStore(dir)), state.WithSigningKey(testSigningKey))
+
+	old := newCacheValidator()
+	old.cacheToken("valid-token", jwt.MapClaims{"sub": "user123", "aud": []interface{}{"api"}})
+	old.cacheToken("expired-token", jwt.MapClaims{"sub": "gone", "exp": float64(time.Now().Add(-time.Minute).Unix())})
+	old.RevokeToken("revoked-token")
+
+	if err := old.SaveCache(ctx, config); err != nil {
+		t.Fatalf("SaveCache failed: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for _, file := range files {
+		content, _ := os.ReadFile(file)
+		if strings.Contains(string(content), "valid-token") {
+			t.Error("Expected snapshot not to contain raw tokens")
+		}
+	}
+
+	restarted := newCacheValidator()
+	if err := restarted.LoadCache(ctx, config); err != nil {
+		t.Fatalf("LoadCache failed: %v", err)
+	}
+
+	if cached := restarted.getCachedToken("valid-token"); cached == nil || cached.Claims["sub"] != "user123" {
+		t.Errorf("Expected valid token to be restored, got %+v", cached)
+	}
+	if restarted.getCachedToken("expired-token") != nil {
+		t.Error("Expected expired token not to be restored")
+	}
+	if !restarted.isTokenRevoked("revoked-token") {
+		t.Error("Expected revocation to be restored")
+	}
+}
+
+func TestLoadCacheWithoutSnapshot(t *testing.T) {
+	store := state.NewFileStore(filepath.Join(t.TempDir(), "state"))
+	config := state.NewConfig(state.WithStore(store), state.WithSigningKey(testSigningKey))
+
+	if err := newCacheValidator().LoadCache(context.Background(), config); err != nil {
+		t.Errorf("Expected missing snapshot to be ignored, got %v", err)
+	}
+}
+
+func TestCacheSnapshotMustBeSigned(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "state")
+	unsigned := state.NewConfig(state.WithStore(state.NewFileStore(dir)))
+	v := newCacheValidator()
+
+	if err := v.SaveCache(ctx, unsigned); !errors.Is(err, ErrUnsignedCacheSnapshot) {
+		t.Errorf("Expected SaveCache to require a signing key, got %v", err)
+	}
+	if err := v.LoadCache(ctx, unsigned); !errors.Is(err, ErrUnsignedCacheSnapshot) {
+		t.Errorf("Expected LoadCache to require a signing key, got %v", err)
+	}
+
+	// A snapshot forged without the key is rejected
+	forged := cacheSnapshot{Tokens: map[string]*CachedToken{
+		tokenKey("any-token"): {Claims: jwt.MapClaims{"sub": "admin"}, Validated: time.Now()},
+	}}
+	_ = state.NewConfig(state.WithStore(state.NewFileStore(dir)), state.WithSigningKey([]byte("other-key"))).
+		Save(ctx, cacheSnapshotKey, forged)
+	signed := state.NewConfig(state.WithStore(state.NewFileStore(dir)), state.WithSigningKey(testSigningKey))
+	if err := v.LoadCache(ctx, signed); !errors.Is(err, state.ErrInvalidSignature) {
+		t.Errorf("Expected a forged snapshot to be rejected, got %v", err)
+	}
+	if v.getCachedToken("any-token") != nil {
+		t.Error("Expected no forged token in the cache")
+	}
+}
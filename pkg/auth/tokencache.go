@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// tokenCacheRequests counts token cache lookups by result: hit or miss
+	tokenCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_token_cache_requests_total",
+		Help: "Total number of validated token cache lookups by result",
+	}, []string{"result"})
+
+	// tokenCacheEvictions counts entries removed by reason: capacity or expired
+	tokenCacheEvictions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_token_cache_evictions_total",
+		Help: "Total number of validated tokens evicted from the cache by reason",
+	}, []string{"reason"})
+)
+
+// tokenLRU is a least-recently-used cache of validated tokens, keyed by tokenKey. The zero value is
+// an unbounded cache; maxEntries limits it.
+type tokenLRU struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+type tokenEntry struct {
+	key   string
+	token *CachedToken
+}
+
+func (c *tokenLRU) init() {
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+		c.order = list.New()
+	}
+}
+
+// get returns the entry for key if valid reports it usable, removing it otherwise
+func (c *tokenLRU) get(key string, valid func(*CachedToken) bool) *CachedToken {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		tokenCacheRequests.WithLabelValues("miss").Inc()
+		return nil
+	}
+
+	entry := elem.Value.(*tokenEntry)
+	if !valid(entry.token) {
+		c.remove(elem)
+		tokenCacheEvictions.WithLabelValues("expired").Inc()
+		tokenCacheRequests.WithLabelValues("miss").Inc()
+		return nil
+	}
+
+	c.order.MoveToFront(elem)
+	tokenCacheRequests.WithLabelValues("hit").Inc()
+	return entry.token
+}
+
+// set adds or replaces an entry, evicting the least recently used ones beyond maxEntries
+func (c *tokenLRU) set(key string, token *CachedToken) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*tokenEntry).token = token
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&tokenEntry{key: key, token: token})
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+		tokenCacheEvictions.WithLabelValues("capacity").Inc()
+	}
+}
+
+// removeInvalid deletes every entry valid rejects, returning how many were removed
+func (c *tokenLRU) removeInvalid(valid func(*CachedToken) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+
+	removed := 0
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if !valid(elem.Value.(*tokenEntry).token) {
+			c.remove(elem)
+			removed++
+		}
+		elem = next
+	}
+
+	tokenCacheEvictions.WithLabelValues("expired").Add(float64(removed))
+	return removed
+}
+
+// snapshot copies the entries valid accepts
+func (c *tokenLRU) snapshot(valid func(*CachedToken) bool) map[string]*CachedToken {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tokens := make(map[string]*CachedToken, len(c.entries))
+	for key, elem := range c.entries {
+		if token := elem.Value.(*tokenEntry).token; valid(token) {
+			tokens[key] = token
+		}
+	}
+	return tokens
+}
+
+// len returns the number of entries, including expired ones not yet evicted
+func (c *tokenLRU) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *tokenLRU) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*tokenEntry).key)
+}
+
+// evictLoop periodically removes expired tokens and old revocations until ctx is done
+func (v *JWTValidator) evictLoop(ctx context.Context, interval time.Duration) {
+	ticker := clock.OrReal(v.clock).NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			v.evictExpired()
+		}
+	}
+}
+
+// evictExpired removes cache entries past their TTL or expiry, and revocations older than a day, by
+// which time the tokens they refer to have expired
+func (v *JWTValidator) evictExpired() {
+	v.tokenCache.removeInvalid(v.cacheEntryValid)
+
+	now := v.now()
+	v.revokedMutex.Lock()
+	defer v.revokedMutex.Unlock()
+	for key, revokedAt := range v.revokedTokens {
+		if now.Sub(revokedAt) > revocationRetention {
+			delete(v.revokedTokens, key)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func counterValue(t *testing.T, vec *prometheus.CounterVec, label string) float64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := vec.WithLabelValues(label).Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetCounter().GetValue()
+}
+
+func TestTokenLRUMaxEntries(t *testing.T) {
+	validator := &JWTValidator{tokenCache: tokenLRU{maxEntries: 3}, cacheTTL: time.Minute}
+	evictions := counterValue(t, tokenCacheEvictions, "capacity")
+
+	for i := range 3 {
+		validator.cacheToken(fmt.Sprintf("token-%d", i), jwt.MapClaims{"sub": i})
+	}
+	// Using token-0 makes token-1 the least recently used
+	if validator.getCachedToken("token-0") == nil {
+		t.Fatal("Expected token-0 to be cached")
+	}
+	validator.cacheToken("token-3", jwt.MapClaims{"sub": 3})
+
+	if got := validator.tokenCache.len(); got != 3 {
+		t.Errorf("Expected 3 entries, got %d", got)
+	}
+	if validator.getCachedToken("token-1") != nil {
+		t.Error("Expected least recently used token to be evicted")
+	}
+	for _, token := range []string{"token-0", "token-2", "token-3"} {
+		if validator.getCachedToken(token) == nil {
+			t.Errorf("Expected %s to be cached", token)
+		}
+	}
+	if got := counterValue(t, tokenCacheEvictions, "capacity") - evictions; got != 1 {
+		t.Errorf("Expected 1 capacity eviction, got %v", got)
+	}
+}
+
+func TestTokenCacheMetrics(t *testing.T) {
+	validator := &JWTValidator{cacheTTL: time.Minute}
+	hits := counterValue(t, tokenCacheRequests, "hit")
+	misses := counterValue(t, tokenCacheRequests, "miss")
+
+	validator.cacheToken("token", jwt.MapClaims{"sub": "user"})
+	validator.getCachedToken("token")
+	validator.getCachedToken("token")
+	validator.getCachedToken("other")
+
+	if got := counterValue(t, tokenCacheRequests, "hit") - hits; got != 2 {
+		t.Errorf("Expected 2 hits, got %v", got)
+	}
+	if got := counterValue(t, tokenCacheRequests, "miss") - misses; got != 1 {
+		t.Errorf("Expected 1 miss, got %v", got)
+	}
+}
+
+func TestEvictExpired(t *testing.T) {
+	validator := newCacheValidator()
+	validator.cacheToken("live", jwt.MapClaims{"exp": float64(time.Now().Add(time.Hour).Unix())})
+	validator.cacheToken("expired", jwt.MapClaims{"exp": float64(time.Now().Add(-time.Minute).Unix())})
+	validator.RevokeToken("recent")
+	validator.revokedTokens[tokenKey("old")] = time.Now().Add(-25 * time.Hour)
+
+	validator.evictExpired()
+
+	if got := validator.tokenCache.len(); got != 1 {
+		t.Errorf("Expected only the live token to remain, got %d entries", got)
+	}
+	if _, ok := validator.revokedTokens[tokenKey("old")]; ok {
+		t.Error("Expected old revocation to be removed")
+	}
+	if !validator.isTokenRevoked("recent") {
+		t.Error("Expected recent revocation to be kept")
+	}
+}
+
+func TestEvictLoop(t *testing.T) {
+	validator := newCacheValidator()
+	validator.cacheToken("expired", jwt.MapClaims{"exp": float64(time.Now().Add(-time.Minute).Unix())})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go validator.evictLoop(ctx, 5*time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for validator.tokenCache.len() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected expired token to be evicted in the background")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTokenCacheClock(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	validator := newCacheValidator()
+	validator.clock = clk
+	validator.cacheToken("token", jwt.MapClaims{"exp": float64(clk.Now().Add(time.Hour).Unix())})
+	validator.RevokeToken("revoked")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go validator.evictLoop(ctx, time.Minute)
+	clk.BlockUntil(1)
+
+	clk.Advance(4 * time.Minute)
+	if validator.getCachedToken("token") == nil {
+		t.Fatal("Expected the token to be cached within its TTL")
+	}
+
+	clk.Advance(25 * time.Hour)
+	if validator.isTokenRevoked("revoked") {
+		t.Error("Expected the revocation to lapse after a day")
+	}
+	deadline := time.Now().Add(time.Second)
+	for validator.tokenCache.len() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the eviction loop to remove the token as the clock moved")
+		}
+		clk.Advance(time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/state"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+)
+
+// cacheRequestsTotal counts lookups by cache name and result, hit or miss
+var cacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cache_requests_total",
+	Help: "Total number of cache lookups by cache and result",
+}, []string{"cache", "result"})
+
+// ErrNotFound is returned by Get when a key is missing or has expired
+var ErrNotFound = errors.New("cache entry not found")
+
+// Loader fetches a value on a cache miss
+type Loader[T any] func(ctx context.Context) (T, error)
+
+// Cache stores values of type T by key
+type Cache[T any] interface {
+	// Get returns the value stored under key, or ErrNotFound
+	Get(ctx context.Context, key string) (T, error)
+	// Set stores a value; a zero ttl uses the cache's default
+	Set(ctx context.Context, key string, value T, ttl time.Duration) error
+	// Delete removes a key, which is not an error if it is missing
+	Delete(ctx context.Context, key string) error
+	// GetOrLoad returns the cached value, or calls load and caches its result. Concurrent misses
+	// for the same key share a single call to load, and cache errors fall back to loading.
+	GetOrLoad(ctx context.Context, key string, load Loader[T]) (T, error)
+}
+
+// Config holds configuration shared by the cache implementations
+type Config struct {
+	// Name labels the cache_requests_total metric
+	Name string
+	// TTL is how long entries live when Set is given no ttl; zero keeps them until evicted
+	TTL time.Duration
+	// MaxEntries bounds the in-memory cache, evicting the least recently used entries; zero is unbounded
+	MaxEntries int
+	// Prefix namespaces Redis keys, e.g. with the service name
+	Prefix string
+	// Codec serializes values for Redis
+	Codec state.Codec
+}
+
+// DefaultConfig provides sensible defaults
+func DefaultConfig() *Config {
+	return &Config{
+		Name:       "default",
+		TTL:        5 * time.Minute,
+		MaxEntries: 10000,
+		Codec:      state.JSONCodec{},
+	}
+}
+
+// Option is a functional option for configuring a cache
+type Option func(*Config)
+
+// WithName sets the name used in metrics
+func WithName(name string) Option {
+	return func(config *Config) {
+		config.Name = name
+	}
+}
+
+// WithTTL sets the default entry lifetime
+func WithTTL(ttl time.Duration) Option {
+	return func(config *Config) {
+		config.TTL = ttl
+	}
+}
+
+// WithMaxEntries bounds the in-memory cache
+func WithMaxEntries(max int) Option {
+	return func(config *Config) {
+		config.MaxEntries = max
+	}
+}
+
+// WithPrefix namespaces Redis keys
+func WithPrefix(prefix string) Option {
+	return func(config *Config) {
+		config.Prefix = prefix
+	}
+}
+
+// WithCodec sets how values are serialized for Redis
+func WithCodec(codec state.Codec) Option {
+	return func(config *Config) {
+		config.Codec = codec
+	}
+}
+
+// NewConfig creates a new cache config with options
+func NewConfig(options ...Option) *Config {
+	config := DefaultConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// ttl returns the lifetime of an entry set with the given ttl
+func (c *Config) ttl(ttl time.Duration) time.Duration {
+	if ttl > 0 {
+		return ttl
+	}
+	return c.TTL
+}
+
+// record counts a lookup
+func (c *Config) record(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequestsTotal.WithLabelValues(c.Name, result).Inc()
+}
+
+// getOrLoad implements GetOrLoad on top of Get and Set. A cache that can't be read or written falls
+// back to calling load, so an outage slows requests down instead of failing them. The load keeps
+// running if the caller that started it gives up, so other callers waiting on it still get a value.
+func getOrLoad[T any](ctx context.Context, c Cache[T], group *singleflight.Group, key string,
+	load Loader[T]) (T, error) {
+	if value, err := c.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	results := group.DoChan(key, func() (interface{}, error) {
+		loadCtx := context.WithoutCancel(ctx)
+		loaded, err := load(loadCtx)
+		if err == nil {
+			_ = c.Set(loadCtx, key, loaded, 0)
+		}
+		return loaded, err
+	})
+
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	case result := <-results:
+		value, _ := result.Val.(T)
+		return value, result.Err
+	}
+}
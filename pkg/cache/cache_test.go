@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/state"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func requestCount(t *testing.T, name, result string) float64 {
+	t.Helper()
+
+	var metric dto.Metric
+	if err := cacheRequestsTotal.WithLabelValues(name, result).Write(&metric); err != nil {
+		t.Fatalf("Failed to read metric: %v", err)
+	}
+	return metric.GetCounter().GetValue()
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if config.Name != "default" || config.TTL != 5*time.Minute || config.MaxEntries != 10000 {
+		t.Errorf("Unexpected defaults: %+v", config)
+	}
+	if _, ok := config.Codec.(state.JSONCodec); !ok {
+		t.Errorf("Expected JSON codec by default, got %T", config.Codec)
+	}
+
+	config = NewConfig(WithName("users"), WithTTL(time.Minute), WithMaxEntries(5), WithPrefix("orders"),
+		WithCodec(state.GobCodec{}))
+	if config.Name != "users" || config.TTL != time.Minute || config.MaxEntries != 5 || config.Prefix != "orders" {
+		t.Errorf("Expected options to apply, got %+v", config)
+	}
+	if _, ok := config.Codec.(state.GobCodec); !ok {
+		t.Errorf("Expected gob codec, got %T", config.Codec)
+	}
+}
+
+func TestConfigTTL(t *testing.T) {
+	config := NewConfig(WithTTL(time.Minute))
+
+	if got := config.ttl(0); got != time.Minute {
+		t.Errorf("Expected the default TTL for zero, got %s", got)
+	}
+	if got := config.ttl(time.Second); got != time.Second {
+		t.Errorf("Expected an explicit TTL to win, got %s", got)
+	}
+}
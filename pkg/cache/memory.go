@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// memoryEntry is a value in the in-memory cache's recency list
+type memoryEntry[T any] struct {
+	key       string
+	value     T
+	expiresAt time.Time
+}
+
+// Memory is an in-process cache with per-entry TTLs and least recently used eviction
+type Memory[T any] struct {
+	config *Config
+	group  singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	recency *list.List
+}
+
+// NewMemory creates an in-memory cache
+func NewMemory[T any](options ...Option) *Memory[T] {
+	return &Memory[T]{
+		config:  NewConfig(options...),
+		entries: make(map[string]*list.Element),
+		recency: list.New(),
+	}
+}
+
+// Get returns the value stored under key, or ErrNotFound
+func (m *Memory[T]) Get(_ context.Context, key string) (T, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if ok {
+		entry := element.Value.(*memoryEntry[T])
+		if entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt) {
+			m.recency.MoveToFront(element)
+			m.config.record(true)
+			return entry.value, nil
+		}
+		m.remove(element)
+	}
+
+	m.config.record(false)
+	var zero T
+	return zero, ErrNotFound
+}
+
+// Set stores a value, evicting the least recently used entries beyond MaxEntries
+func (m *Memory[T]) Set(_ context.Context, key string, value T, ttl time.Duration) error {
+	var expiresAt time.Time
+	if ttl = m.config.ttl(ttl); ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*memoryEntry[T])
+		entry.value, entry.expiresAt = value, expiresAt
+		m.recency.MoveToFront(element)
+		return nil
+	}
+
+	m.entries[key] = m.recency.PushFront(&memoryEntry[T]{key: key, value: value, expiresAt: expiresAt})
+	for m.config.MaxEntries > 0 && m.recency.Len() > m.config.MaxEntries {
+		m.remove(m.recency.Back())
+	}
+	return nil
+}
+
+// Delete removes a key
+func (m *Memory[T]) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+	return nil
+}
+
+// GetOrLoad returns the cached value, or loads and caches it once for all concurrent callers
+func (m *Memory[T]) GetOrLoad(ctx context.Context, key string, load Loader[T]) (T, error) {
+	return getOrLoad[T](ctx, m, &m.group, key, load)
+}
+
+// Len returns the number of entries, including expired ones not yet removed
+func (m *Memory[T]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.recency.Len()
+}
+
+// Purge removes every entry
+func (m *Memory[T]) Purge() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = make(map[string]*list.Element)
+	m.recency.Init()
+}
+
+func (m *Memory[T]) remove(element *list.Element) {
+	m.recency.Remove(element)
+	delete(m.entries, element.Value.(*memoryEntry[T]).key)
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryGetSetDelete(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory[string](WithName("memory-basic"))
+
+	if _, err := m.Get(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	_ = m.Set(ctx, "a", "apple", 0)
+	if got, err := m.Get(ctx, "a"); err != nil || got != "apple" {
+		t.Errorf("Expected apple, got %q, %v", got, err)
+	}
+
+	_ = m.Set(ctx, "a", "avocado", 0)
+	if got, _ := m.Get(ctx, "a"); got != "avocado" {
+		t.Errorf("Expected overwrite, got %q", got)
+	}
+
+	_ = m.Delete(ctx, "a")
+	if _, err := m.Get(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected deleted key to be missing, got %v", err)
+	}
+
+	if hits, misses := requestCount(t, "memory-basic", "hit"), requestCount(t, "memory-basic", "miss"); hits != 2 ||
+		misses != 2 {
+		t.Errorf("Expected 2 hits and 2 misses, got %v and %v", hits, misses)
+	}
+}
+
+func TestMemoryTTL(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory[int](WithTTL(0))
+
+	_ = m.Set(ctx, "short", 1, 10*time.Millisecond)
+	_ = m.Set(ctx, "forever", 2, 0)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := m.Get(ctx, "short"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected expired entry to be missing, got %v", err)
+	}
+	if got, err := m.Get(ctx, "forever"); err != nil || got != 2 {
+		t.Errorf("Expected entry without TTL to stay, got %d, %v", got, err)
+	}
+	if m.Len() != 1 {
+		t.Errorf("Expected expired entry to be removed, got %d entries", m.Len())
+	}
+}
+
+func TestMemoryEviction(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory[int](WithMaxEntries(2))
+
+	_ = m.Set(ctx, "a", 1, 0)
+	_ = m.Set(ctx, "b", 2, 0)
+	_, _ = m.Get(ctx, "a")
+	_ = m.Set(ctx, "c", 3, 0)
+
+	if _, err := m.Get(ctx, "b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected least recently used entry to be evicted, got %v", err)
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, err := m.Get(ctx, key); err != nil {
+			t.Errorf("Expected %s to remain, got %v", key, err)
+		}
+	}
+
+	m.Purge()
+	if m.Len() != 0 {
+		t.Errorf("Expected purge to empty the cache, got %d", m.Len())
+	}
+}
+
+func TestMemoryGetOrLoadSingleflight(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory[string]()
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (string, error) {
+		loads.Add(1)
+		<-release
+		return "loaded", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan string, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := m.GetOrLoad(ctx, "user:1", load)
+			if err != nil {
+				t.Errorf("GetOrLoad failed: %v", err)
+			}
+			results <- value
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if loads.Load() != 1 {
+		t.Errorf("Expected a single load, got %d", loads.Load())
+	}
+	for value := range results {
+		if value != "loaded" {
+			t.Errorf("Expected every caller to get the loaded value, got %q", value)
+		}
+	}
+	if got, err := m.Get(ctx, "user:1"); err != nil || got != "loaded" {
+		t.Errorf("Expected loaded value to be cached, got %q, %v", got, err)
+	}
+}
+
+func TestMemoryGetOrLoadError(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory[string]()
+	boom := errors.New("database down")
+
+	if _, err := m.GetOrLoad(ctx, "k", func(ctx context.Context) (string, error) { return "", boom }); !errors.Is(err,
+		boom) {
+		t.Errorf("Expected the load error, got %v", err)
+	}
+	if m.Len() != 0 {
+		t.Error("Expected failed loads not to be cached")
+	}
+}
+
+func TestMemoryGetOrLoadCallerCancelled(t *testing.T) {
+	m := NewMemory[string]()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := m.GetOrLoad(ctx, "slow", func(ctx context.Context) (string, error) {
+			time.Sleep(50 * time.Millisecond)
+			return "eventually", nil
+		}); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	}()
+
+	cancel()
+	<-done
+
+	// The load carries on and fills the cache for later callers
+	deadline := time.Now().Add(time.Second)
+	for m.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got, err := m.Get(context.Background(), "slow"); err != nil || got != "eventually" {
+		t.Errorf("Expected the abandoned load to be cached, got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Response is an HTTP response stored by Middleware
+type Response struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"storedAt"`
+}
+
+// ResponseConfig holds configuration for response caching
+type ResponseConfig struct {
+	// TTL is how long responses are kept when they don't set max-age; zero uses the cache's default
+	TTL time.Duration
+	// Vary lists request headers that select between different responses for the same URL
+	Vary []string
+	// MaxBodySize is the largest body stored; larger responses pass through uncached
+	MaxBodySize int
+	// Statuses are the response codes that are stored
+	Statuses []int
+}
+
+// DefaultResponseConfig provides sensible defaults
+func DefaultResponseConfig() *ResponseConfig {
+	return &ResponseConfig{
+		MaxBodySize: 1 << 20,
+		Statuses:    []int{http.StatusOK},
+	}
+}
+
+// ResponseOption is a functional option for configuring response caching
+type ResponseOption func(*ResponseConfig)
+
+// WithResponseTTL sets how long responses without max-age are kept
+func WithResponseTTL(ttl time.Duration) ResponseOption {
+	return func(config *ResponseConfig) {
+		config.TTL = ttl
+	}
+}
+
+// WithVary adds request headers that select between different responses, e.g. Accept-Language
+func WithVary(headers ...string) ResponseOption {
+	return func(config *ResponseConfig) {
+		config.Vary = append(config.Vary, headers...)
+	}
+}
+
+// WithMaxCachedBody sets the largest body stored
+func WithMaxCachedBody(size int) ResponseOption {
+	return func(config *ResponseConfig) {
+		config.MaxBodySize = size
+	}
+}
+
+// WithCacheStatuses sets the response codes that are stored
+func WithCacheStatuses(statuses ...int) ResponseOption {
+	return func(config *ResponseConfig) {
+		config.Statuses = statuses
+	}
+}
+
+// NewResponseConfig creates a new response caching config with options
+func NewResponseConfig(options ...ResponseOption) *ResponseConfig {
+	config := DefaultResponseConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Middleware caches GET and HEAD responses in c, keyed by method, path and query, and the Vary headers.
+// Responses marked no-store, private, or no-cache, or that set cookies, aren't stored, and requests with
+// an Authorization header bypass the cache unless Authorization is one of the Vary headers. A request
+// sent with Cache-Control: no-cache skips the lookup and refreshes the entry. Responses carry an
+// X-Cache header of HIT or MISS.
+func Middleware(c Cache[Response], options ...ResponseOption) func(next http.Handler) http.Handler {
+	config := NewResponseConfig(options...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !config.cacheableRequest(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := ResponseKey(r, config.Vary...)
+			if !headerHasDirective(r.Header, "no-cache") {
+				if cached, err := c.Get(r.Context(), key); err == nil {
+					writeCached(w, r, &cached)
+					return
+				}
+			}
+
+			w.Header().Set("X-Cache", "MISS")
+			cw := &captureWriter{ResponseWriter: w, max: config.MaxBodySize}
+			next.ServeHTTP(cw, r)
+
+			if ttl, ok := config.storable(cw); ok {
+				_ = c.Set(r.Context(), key, Response{
+					Status:   cw.status,
+					Header:   w.Header().Clone(),
+					Body:     bytes.Clone(cw.buf.Bytes()),
+					StoredAt: time.Now(),
+				}, ttl)
+			}
+		})
+	}
+}
+
+// ResponseKey builds the cache key for a request, so handlers can invalidate entries with Delete
+func ResponseKey(r *http.Request, vary ...string) string {
+	var key strings.Builder
+	key.WriteString(r.Method + " " + r.URL.RequestURI())
+	for _, name := range vary {
+		key.WriteString("\n" + http.CanonicalHeaderKey(name) + ": " + strings.Join(r.Header.Values(name), ","))
+	}
+
+	sum := sha256.Sum256([]byte(key.String()))
+	return "http:" + hex.EncodeToString(sum[:16])
+}
+
+// cacheableRequest reports whether a request may be answered from the cache
+func (config *ResponseConfig) cacheableRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if headerHasDirective(r.Header, "no-store") {
+		return false
+	}
+	if r.Header.Get("Authorization") != "" {
+		return slices.ContainsFunc(config.Vary, func(name string) bool {
+			return strings.EqualFold(name, "Authorization")
+		})
+	}
+	return true
+}
+
+// storable decides whether a response is stored, and for how long
+func (config *ResponseConfig) storable(cw *captureWriter) (time.Duration, bool) {
+	header := cw.Header()
+	switch {
+	case cw.overflow, cw.status == 0:
+		return 0, false
+	case !slices.Contains(config.Statuses, cw.status):
+		return 0, false
+	case header.Get("Set-Cookie") != "" || strings.HasPrefix(header.Get("Content-Type"), "text/event-stream"):
+		return 0, false
+	case headerHasDirective(header, "no-store"), headerHasDirective(header, "private"),
+		headerHasDirective(header, "no-cache"):
+		return 0, false
+	}
+
+	if maxAge, ok := cacheControlMaxAge(header); ok {
+		return maxAge, maxAge > 0
+	}
+	return config.TTL, true
+}
+
+// writeCached replays a stored response
+func writeCached(w http.ResponseWriter, r *http.Request, cached *Response) {
+	for name, values := range cached.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("X-Cache", "HIT")
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(cached.StoredAt).Seconds())))
+	w.WriteHeader(cached.Status)
+
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(cached.Body)
+	}
+}
+
+// headerHasDirective reports whether the Cache-Control header contains a directive
+func headerHasDirective(header http.Header, directive string) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if strings.EqualFold(name, directive) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cacheControlMaxAge reads s-maxage, or else max-age, from a response's Cache-Control header
+func cacheControlMaxAge(header http.Header) (time.Duration, bool) {
+	var maxAge time.Duration
+	found := false
+
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+			seconds, err := strconv.Atoi(strings.Trim(arg, `"`))
+			if err != nil {
+				continue
+			}
+			switch strings.ToLower(name) {
+			case "s-maxage":
+				return time.Duration(seconds) * time.Second, true
+			case "max-age":
+				maxAge, found = time.Duration(seconds)*time.Second, true
+			}
+		}
+	}
+
+	return maxAge, found
+}
+
+// captureWriter passes a response through while keeping a copy of it
+type captureWriter struct {
+	http.ResponseWriter
+	status   int
+	buf      bytes.Buffer
+	max      int
+	overflow bool
+}
+
+func (cw *captureWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *captureWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.overflow {
+		if cw.buf.Len()+len(p) > cw.max {
+			cw.overflow = true
+			cw.buf.Reset()
+		} else {
+			cw.buf.Write(p)
+		}
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *captureWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingHandler numbers its responses so tests can tell cached ones apart
+func countingHandler(calls *atomic.Int32, header http.Header, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		for name, values := range header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(status)
+		_, _ = fmt.Fprintf(w, "response %d for %s", n, r.Header.Get("Accept-Language"))
+	})
+}
+
+func serve(handler http.Handler, method, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddlewareCachesResponses(t *testing.T) {
+	var calls atomic.Int32
+	handler := Middleware(NewMemory[Response]())(countingHandler(&calls,
+		http.Header{"Content-Type": {"text/plain"}}, http.StatusOK))
+
+	first := serve(handler, http.MethodGet, "/products?page=1", nil)
+	second := serve(handler, http.MethodGet, "/products?page=1", nil)
+	other := serve(handler, http.MethodGet, "/products?page=2", nil)
+
+	if first.Header().Get("X-Cache") != "MISS" || second.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("Expected MISS then HIT, got %q and %q", first.Header().Get("X-Cache"),
+			second.Header().Get("X-Cache"))
+	}
+	if second.Body.String() != first.Body.String() || second.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("Expected the cached response replayed, got %q", second.Body.String())
+	}
+	if second.Header().Get("Age") == "" {
+		t.Error("Expected an Age header on hits")
+	}
+	if other.Header().Get("X-Cache") != "MISS" || calls.Load() != 2 {
+		t.Errorf("Expected a different query to miss, got %d handler calls", calls.Load())
+	}
+
+	head := serve(handler, http.MethodHead, "/products?page=1", nil)
+	if head.Header().Get("X-Cache") != "MISS" {
+		t.Error("Expected HEAD to be cached separately from GET")
+	}
+}
+
+func TestMiddlewareVary(t *testing.T) {
+	var calls atomic.Int32
+	handler := Middleware(NewMemory[Response](), WithVary("Accept-Language"))(countingHandler(&calls, nil,
+		http.StatusOK))
+
+	english := serve(handler, http.MethodGet, "/", http.Header{"Accept-Language": {"en"}})
+	french := serve(handler, http.MethodGet, "/", http.Header{"Accept-Language": {"fr"}})
+	englishAgain := serve(handler, http.MethodGet, "/", http.Header{"Accept-Language": {"en"}})
+
+	if french.Header().Get("X-Cache") != "MISS" {
+		t.Error("Expected a different Vary header value to miss")
+	}
+	if englishAgain.Header().Get("X-Cache") != "HIT" || englishAgain.Body.String() != english.Body.String() {
+		t.Errorf("Expected the English response from cache, got %q", englishAgain.Body.String())
+	}
+}
+
+func TestMiddlewareSkipsUncacheable(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		requestHeader  http.Header
+		responseHeader http.Header
+		status         int
+	}{
+		{"post", http.MethodPost, nil, nil, http.StatusOK},
+		{"authorization", http.MethodGet, http.Header{"Authorization": {"Bearer x"}}, nil, http.StatusOK},
+		{"request no-store", http.MethodGet, http.Header{"Cache-Control": {"no-store"}}, nil, http.StatusOK},
+		{"server error", http.MethodGet, nil, nil, http.StatusInternalServerError},
+		{"private", http.MethodGet, nil, http.Header{"Cache-Control": {"private, max-age=60"}}, http.StatusOK},
+		{"no-store", http.MethodGet, nil, http.Header{"Cache-Control": {"no-store"}}, http.StatusOK},
+		{"set-cookie", http.MethodGet, nil, http.Header{"Set-Cookie": {"session=1"}}, http.StatusOK},
+		{"max-age zero", http.MethodGet, nil, http.Header{"Cache-Control": {"max-age=0"}}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			store := NewMemory[Response]()
+			handler := Middleware(store)(countingHandler(&calls, tt.responseHeader, tt.status))
+
+			serve(handler, tt.method, "/items", tt.requestHeader)
+			serve(handler, tt.method, "/items", tt.requestHeader)
+
+			if calls.Load() != 2 || store.Len() != 0 {
+				t.Errorf("Expected the response not to be cached, got %d calls and %d entries", calls.Load(),
+					store.Len())
+			}
+		})
+	}
+}
+
+func TestMiddlewareRequestNoCacheRefreshes(t *testing.T) {
+	var calls atomic.Int32
+	handler := Middleware(NewMemory[Response]())(countingHandler(&calls, nil, http.StatusOK))
+
+	serve(handler, http.MethodGet, "/items", nil)
+	refreshed := serve(handler, http.MethodGet, "/items", http.Header{"Cache-Control": {"no-cache"}})
+	cached := serve(handler, http.MethodGet, "/items", nil)
+
+	if refreshed.Header().Get("X-Cache") != "MISS" || cached.Body.String() != refreshed.Body.String() {
+		t.Errorf("Expected no-cache to refresh the entry, got %q then %q", refreshed.Body.String(),
+			cached.Body.String())
+	}
+}
+
+func TestMiddlewareMaxAgeAndBodyLimit(t *testing.T) {
+	store := NewMemory[Response]()
+	var calls atomic.Int32
+	handler := Middleware(store, WithMaxCachedBody(10))(countingHandler(&calls, nil, http.StatusOK))
+
+	serve(handler, http.MethodGet, "/large", nil)
+	if store.Len() != 0 {
+		t.Error("Expected bodies over the limit not to be cached")
+	}
+
+	short := Middleware(store)(countingHandler(&calls, http.Header{"Cache-Control": {"public, max-age=1"}},
+		http.StatusOK))
+	r := httptest.NewRequest(http.MethodGet, "/short", nil)
+	short.ServeHTTP(httptest.NewRecorder(), r)
+
+	cached, err := store.Get(context.Background(), ResponseKey(r))
+	if err != nil {
+		t.Fatalf("Expected the response to be cached under ResponseKey, got %v", err)
+	}
+	if time.Since(cached.StoredAt) > time.Second || cached.Status != http.StatusOK {
+		t.Errorf("Unexpected cached response: %+v", cached)
+	}
+}
+
+func TestCacheControlMaxAge(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		found bool
+	}{
+		{"public, max-age=60", time.Minute, true},
+		{"max-age=60, s-maxage=120", 2 * time.Minute, true},
+		{`max-age="30"`, 30 * time.Second, true},
+		{"public", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, found := cacheControlMaxAge(http.Header{"Cache-Control": {tt.value}})
+		if got != tt.want || found != tt.found {
+			t.Errorf("cacheControlMaxAge(%q) = %s, %v, want %s, %v", tt.value, got, found, tt.want, tt.found)
+		}
+	}
+}
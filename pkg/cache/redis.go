@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// Redis is a cache shared between instances, with values serialized by the configured codec
+type Redis[T any] struct {
+	client redis.UniversalClient
+	config *Config
+	group  singleflight.Group
+}
+
+// NewRedis creates a cache backed by Redis. MaxEntries doesn't apply; configure Redis' own eviction policy.
+func NewRedis[T any](client redis.UniversalClient, options ...Option) *Redis[T] {
+	return &Redis[T]{client: client, config: NewConfig(options...)}
+}
+
+// Get returns the value stored under key, or ErrNotFound
+func (r *Redis[T]) Get(ctx context.Context, key string) (T, error) {
+	var value T
+
+	data, err := r.client.Get(ctx, r.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		r.config.record(false)
+		return value, ErrNotFound
+	}
+	if err != nil {
+		return value, fmt.Errorf("failed to read cache entry %s: %w", key, err)
+	}
+
+	if err := r.config.Codec.Unmarshal(data, &value); err != nil {
+		return value, fmt.Errorf("failed to decode cache entry %s: %w", key, err)
+	}
+	r.config.record(true)
+	return value, nil
+}
+
+// Set stores a value, letting Redis expire it
+func (r *Redis[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	data, err := r.config.Codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry %s: %w", key, err)
+	}
+
+	if err := r.client.Set(ctx, r.key(key), data, r.config.ttl(ttl)).Err(); err != nil {
+		return fmt.Errorf("failed to write cache entry %s: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes a key
+func (r *Redis[T]) Delete(ctx context.Context, key string) error {
+	if err := r.client.Del(ctx, r.key(key)).Err(); err != nil {
+		return fmt.Errorf("failed to delete cache entry %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetOrLoad returns the cached value, or loads and caches it. Concurrent misses within this instance
+// share a load; other instances may load the same key at the same time.
+func (r *Redis[T]) GetOrLoad(ctx context.Context, key string, load Loader[T]) (T, error) {
+	return getOrLoad[T](ctx, r, &r.group, key, load)
+}
+
+func (r *Redis[T]) key(key string) string {
+	if r.config.Prefix == "" {
+		return "cache:" + key
+	}
+	return r.config.Prefix + ":cache:" + key
+}
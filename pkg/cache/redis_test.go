@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// unreachableClient points at a port where nothing listens so commands fail fast
+func unreachableClient(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestRedisKey(t *testing.T) {
+	client := unreachableClient(t)
+
+	if got := NewRedis[string](client, WithPrefix("orders")).key("user:1"); got != "orders:cache:user:1" {
+		t.Errorf("Expected prefixed key, got %s", got)
+	}
+	if got := NewRedis[string](client).key("user:1"); got != "cache:user:1" {
+		t.Errorf("Expected default namespace, got %s", got)
+	}
+}
+
+func TestRedisUnreachable(t *testing.T) {
+	ctx := context.Background()
+	r := NewRedis[string](unreachableClient(t))
+
+	if _, err := r.Get(ctx, "k"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a connection error rather than a miss, got %v", err)
+	}
+	if err := r.Set(ctx, "k", "v", 0); err == nil {
+		t.Error("Expected Set to fail")
+	}
+	if err := r.Delete(ctx, "k"); err == nil {
+		t.Error("Expected Delete to fail")
+	}
+
+	value, err := r.GetOrLoad(ctx, "k", func(ctx context.Context) (string, error) { return "fresh", nil })
+	if err != nil || value != "fresh" {
+		t.Errorf("Expected GetOrLoad to fall back to loading, got %q, %v", value, err)
+	}
+}
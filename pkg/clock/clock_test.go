@@ -0,0 +1,39 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReal(t *testing.T) {
+	c := Real()
+
+	if since := time.Since(c.Now()); since < 0 || since > time.Second {
+		t.Errorf("Expected the system time, got %s off", since)
+	}
+
+	select {
+	case <-c.After(time.Millisecond):
+	case <-time.After(time.Second):
+		t.Fatal("Expected After to fire")
+	}
+
+	ticker := c.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	select {
+	case <-ticker.C():
+	case <-time.After(time.Second):
+		t.Fatal("Expected the ticker to tick")
+	}
+}
+
+func TestOrReal(t *testing.T) {
+	if _, ok := OrReal(nil).(realClock); !ok {
+		t.Error("Expected nil to become the system clock")
+	}
+
+	fake := NewFake(time.Now())
+	if OrReal(fake) != Clock(fake) {
+		t.Error("Expected a clock to be kept")
+	}
+}
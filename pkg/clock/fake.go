@@ -0,0 +1,157 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that only moves when told to. Channels from After and tickers fire as Advance or
+// Set moves the time past them, so tests of expiry and scheduling run instantly and deterministically.
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter is a pending After channel, or a ticker when period is set
+type fakeWaiter struct {
+	at     time.Time
+	period time.Duration
+	c      chan time.Time
+}
+
+// NewFake returns a fake clock set to start
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the fake time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After returns a channel that receives the fake time once it has moved d past now
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c := make(chan time.Time, 1)
+	if d <= 0 {
+		c <- f.now
+		return c
+	}
+	f.add(&fakeWaiter{at: f.now.Add(d), c: c})
+	return c
+}
+
+// NewTicker returns a ticker that ticks each time the fake time moves past another multiple of d
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &fakeWaiter{at: f.now.Add(d), period: d, c: make(chan time.Time, 1)}
+	f.add(w)
+	return &fakeTicker{clock: f, waiter: w}
+}
+
+// Advance moves the time forward by d, firing everything due on the way
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.moveTo(f.now.Add(d))
+}
+
+// Set moves the time forward to t, firing everything due by then; a t before now is ignored
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.moveTo(t)
+}
+
+// Waiters returns how many After channels and tickers are waiting for the time to move
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil waits until n After channels and tickers are waiting, so a test can be sure a
+// goroutine is parked on the clock before advancing it
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// add registers a waiter; the caller holds mu
+func (f *Fake) add(w *fakeWaiter) {
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+}
+
+// remove unregisters a waiter; the caller holds mu
+func (f *Fake) remove(w *fakeWaiter) {
+	for i, waiter := range f.waiters {
+		if waiter == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// moveTo sets the time and fires waiters in the order they fall due; the caller holds mu
+func (f *Fake) moveTo(t time.Time) {
+	if t.After(f.now) {
+		f.now = t
+	}
+
+	sort.SliceStable(f.waiters, func(i, k int) bool { return f.waiters[i].at.Before(f.waiters[k].at) })
+	remaining := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			remaining = append(remaining, w)
+			continue
+		}
+
+		// Like time.Ticker, a slow reader gets one tick rather than a backlog
+		select {
+		case w.c <- w.at:
+		default:
+		}
+		if w.period > 0 {
+			for !w.at.After(f.now) {
+				w.at = w.at.Add(w.period)
+			}
+			remaining = append(remaining, w)
+		}
+	}
+	f.waiters = remaining
+}
+
+// fakeTicker is a ticker driven by a Fake
+type fakeTicker struct {
+	clock  *Fake
+	waiter *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.waiter.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.clock.remove(t.waiter)
+}
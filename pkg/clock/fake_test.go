@@ -0,0 +1,114 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestFakeAfter(t *testing.T) {
+	tests := []struct {
+		name    string
+		wait    time.Duration
+		advance time.Duration
+		fired   bool
+	}{
+		{"not yet due", time.Minute, 59 * time.Second, false},
+		{"exactly due", time.Minute, time.Minute, true},
+		{"overdue", time.Minute, time.Hour, true},
+		{"no wait", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewFake(start)
+			ch := c.After(tt.wait)
+			c.Advance(tt.advance)
+
+			select {
+			case at := <-ch:
+				if !tt.fired {
+					t.Fatalf("Expected no fire, got %s", at)
+				}
+				if want := start.Add(tt.wait); !at.Equal(want) {
+					t.Errorf("Expected to receive %s, got %s", want, at)
+				}
+			default:
+				if tt.fired {
+					t.Fatal("Expected After to fire")
+				}
+			}
+		})
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	c := NewFake(start)
+	ticker := c.NewTicker(time.Second)
+
+	c.Advance(time.Second)
+	if at := <-ticker.C(); !at.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected the first tick at 1s, got %s", at)
+	}
+
+	// A reader that falls behind gets one tick, and the schedule keeps its phase
+	c.Advance(5 * time.Second)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Error("Expected missed ticks to be dropped")
+	default:
+	}
+	c.Advance(time.Second)
+	if at := <-ticker.C(); !at.Equal(start.Add(7 * time.Second)) {
+		t.Errorf("Expected a tick at 7s, got %s", at)
+	}
+
+	ticker.Stop()
+	c.Advance(time.Hour)
+	select {
+	case <-ticker.C():
+		t.Error("Expected no ticks after Stop")
+	default:
+	}
+	if c.Waiters() != 0 {
+		t.Errorf("Expected no waiters, got %d", c.Waiters())
+	}
+}
+
+func TestFakeSet(t *testing.T) {
+	c := NewFake(start)
+	ch := c.After(time.Hour)
+
+	c.Set(start.Add(-time.Hour))
+	if !c.Now().Equal(start) {
+		t.Errorf("Expected Set not to move time backwards, got %s", c.Now())
+	}
+
+	c.Set(start.Add(2 * time.Hour))
+	select {
+	case <-ch:
+	default:
+		t.Fatal("Expected After to fire")
+	}
+	if !c.Now().Equal(start.Add(2 * time.Hour)) {
+		t.Errorf("Expected the new time, got %s", c.Now())
+	}
+}
+
+func TestFakeBlockUntil(t *testing.T) {
+	c := NewFake(start)
+	done := make(chan time.Time)
+	go func() {
+		done <- <-c.After(time.Minute)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the goroutine to wake")
+	}
+}
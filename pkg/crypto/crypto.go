@@ -271,54 +271,9 @@ func GenerateRefreshTokenWithLength(length int) (string, error) {
 	return GenerateSecureTokenWithLength(length)
 }
 
-// checkPasswordLength validates the minimum password length
-func checkPasswordLength(password string) error {
-	if len(password) < 8 {
-		return fmt.Errorf("password must be at least 8 characters long")
-	}
-	return nil
-}
-
-// analyzePasswordCharacters analyzes password for required character types
-func analyzePasswordCharacters(password string) (hasLower, hasUpper, hasDigit, hasSymbol bool) {
-	for _, char := range password {
-		switch {
-		case char >= 'a' && char <= 'z':
-			hasLower = true
-		case char >= 'A' && char <= 'Z':
-			hasUpper = true
-		case char >= '0' && char <= '9':
-			hasDigit = true
-		case strings.ContainsRune(symbols, char):
-			hasSymbol = true
-		}
-	}
-	return
-}
-
-// validateCharacterRequirements checks if password meets character type requirements
-func validateCharacterRequirements(hasLower, hasUpper, hasDigit, hasSymbol bool) error {
-	if !hasLower {
-		return fmt.Errorf("password must contain at least one lowercase letter")
-	}
-	if !hasUpper {
-		return fmt.Errorf("password must contain at least one uppercase letter")
-	}
-	if !hasDigit {
-		return fmt.Errorf("password must contain at least one digit")
-	}
-	if !hasSymbol {
-		return fmt.Errorf("password must contain at least one special character")
-	}
-	return nil
-}
-
-// ValidatePasswordStrength checks if a password meets minimum security requirements
+// ValidatePasswordStrength checks if a password meets minimum security requirements: at least 8
+// characters with lowercase and uppercase letters, a digit and a special character. Use a
+// PasswordPolicy for other rules.
 func ValidatePasswordStrength(password string) error {
-	if err := checkPasswordLength(password); err != nil {
-		return err
-	}
-
-	hasLower, hasUpper, hasDigit, hasSymbol := analyzePasswordCharacters(password)
-	return validateCharacterRequirements(hasLower, hasUpper, hasDigit, hasSymbol)
+	return DefaultPasswordPolicy().Validate(password)
 }
@@ -402,6 +402,21 @@ func TestValidatePasswordStrength(t *testing.T) {
 		password string
 		wantErr  bool
 	}{
+		{
+			name:     "space is not a symbol",
+			password: "Password1 ",
+			wantErr:  true,
+		},
+		{
+			name:     "tab is not a symbol",
+			password: "Password1\t",
+			wantErr:  true,
+		},
+		{
+			name:     "control character is not a symbol",
+			password: "Password1\x00",
+			wantErr:  true,
+		},
 		{
 			name:     "strong password",
 			password: "MySecurePass123!",
@@ -0,0 +1,26 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SignHMAC returns the hex-encoded HMAC-SHA256 of message with secret
+func SignHMAC(secret, message []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyHMAC reports whether signature is the hex-encoded HMAC-SHA256 of message with secret. The
+// comparison takes constant time.
+func VerifyHMAC(secret, message []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(message)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
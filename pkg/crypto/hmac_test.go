@@ -0,0 +1,40 @@
+package crypto
+
+import "testing"
+
+func TestSignHMAC(t *testing.T) {
+	// RFC 4231 test case 2
+	got := SignHMAC([]byte("Jefe"), []byte("what do ya want for nothing?"))
+	want := "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got != want {
+		t.Errorf("SignHMAC() = %s, want %s", got, want)
+	}
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	secret := []byte("secret")
+	message := []byte(`{"id":"evt_1"}`)
+	signature := SignHMAC(secret, message)
+
+	tests := []struct {
+		name      string
+		secret    []byte
+		message   []byte
+		signature string
+		want      bool
+	}{
+		{"valid", secret, message, signature, true},
+		{"wrong secret", []byte("other"), message, signature, false},
+		{"tampered message", secret, []byte(`{"id":"evt_2"}`), signature, false},
+		{"not hex", secret, message, "zz", false},
+		{"empty", secret, message, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyHMAC(tt.secret, tt.message, tt.signature); got != tt.want {
+				t.Errorf("VerifyHMAC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
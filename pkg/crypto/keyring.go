@@ -0,0 +1,151 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownKey is returned by Open for values sealed with a key the keyring does not hold
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// ErrInvalidCiphertext is returned by Open for values that were not sealed by a Keyring or were tampered with
+var ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+// sealedPrefix starts every sealed value, identifying its format
+const sealedPrefix = "v1:"
+
+// Key is an AES key with the ID recorded alongside the values it seals
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Keyring encrypts with AES-GCM. The first key seals new values; every key opens values sealed with it,
+// so keys can be rotated by adding a new key first and re-sealing old values before removing their key.
+type Keyring struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewKeyring creates a keyring from keys, newest first. Secrets must be 16, 24, or 32 bytes, and IDs
+// must be unique, non-empty, and free of colons.
+func NewKeyring(keys ...Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one encryption key is required")
+	}
+
+	k := &Keyring{primary: keys[0].ID, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" || strings.Contains(key.ID, ":") {
+			return nil, fmt.Errorf("invalid encryption key ID %q", key.ID)
+		}
+		if _, exists := k.aeads[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate encryption key ID %q", key.ID)
+		}
+		block, err := aes.NewCipher(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", key.ID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", key.ID, err)
+		}
+		k.aeads[key.ID] = aead
+	}
+	return k, nil
+}
+
+// ParseKeyring creates a keyring from a comma-separated list of id:base64-secret pairs, newest first,
+// such as an environment variable holding "2024-06:q83v...,2023-11:Zm9v..."
+func ParseKeyring(spec string) (*Keyring, error) {
+	var keys []Key
+	for _, pair := range strings.Split(spec, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			return nil, fmt.Errorf("encryption key %q must have the form id:base64-secret", id)
+		}
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q is not valid base64: %w", id, err)
+		}
+		keys = append(keys, Key{ID: id, Secret: secret})
+	}
+	return NewKeyring(keys...)
+}
+
+// PrimaryKeyID returns the ID of the key that seals new values
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary
+}
+
+// PrimaryPrefix returns the prefix of every value sealed with the primary key, e.g. to find values that
+// need rotation in SQL with NOT LIKE
+func (k *Keyring) PrimaryPrefix() string {
+	return sealedPrefix + k.primary + ":"
+}
+
+// Seal encrypts and authenticates plaintext with the primary key. The result is text of the form
+// v1:<key ID>:<base64>, safe to store in a text column. additionalData, which may be nil, is
+// authenticated but not stored, and must be passed to Open again, e.g. to bind a value to its row.
+func (k *Keyring) Seal(plaintext, additionalData []byte) (string, error) {
+	aead := k.aeads[k.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, additionalData)
+	return k.PrimaryPrefix() + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value sealed with any key of the keyring
+func (k *Keyring) Open(sealed string, additionalData []byte) ([]byte, error) {
+	id, encoded, err := splitSealed(sealed)
+	if err != nil {
+		return nil, err
+	}
+	aead, ok := k.aeads[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+
+	raw, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	plaintext, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
+
+// NeedsRotation reports whether a sealed value was sealed with a key other than the primary key
+func (k *Keyring) NeedsRotation(sealed string) bool {
+	id, _, err := splitSealed(sealed)
+	return err == nil && id != k.primary
+}
+
+// SealedKeyID returns the ID of the key a value was sealed with
+func SealedKeyID(sealed string) (string, error) {
+	id, _, err := splitSealed(sealed)
+	return id, err
+}
+
+// splitSealed separates a sealed value into its key ID and encoded ciphertext
+func splitSealed(sealed string) (string, string, error) {
+	rest, ok := strings.CutPrefix(sealed, sealedPrefix)
+	if !ok {
+		return "", "", ErrInvalidCiphertext
+	}
+	id, encoded, ok := strings.Cut(rest, ":")
+	if !ok || id == "" {
+		return "", "", ErrInvalidCiphertext
+	}
+	return id, encoded, nil
+}
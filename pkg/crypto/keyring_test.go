@@ -0,0 +1,114 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(id string, fill byte) Key {
+	return Key{ID: id, Secret: bytes.Repeat([]byte{fill}, 32)}
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []Key
+		wantErr bool
+	}{
+		{"valid", []Key{testKey("new", 1), testKey("old", 2)}, false},
+		{"no keys", nil, true},
+		{"empty ID", []Key{testKey("", 1)}, true},
+		{"colon in ID", []Key{testKey("a:b", 1)}, true},
+		{"duplicate ID", []Key{testKey("a", 1), testKey("a", 2)}, true},
+		{"bad length", []Key{{ID: "a", Secret: []byte("short")}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeyring(tt.keys...); (err != nil) != tt.wantErr {
+				t.Errorf("NewKeyring() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyringSealOpen(t *testing.T) {
+	k, _ := NewKeyring(testKey("k1", 1))
+
+	sealed, err := k.Seal([]byte("jane@example.com"), []byte("user:7"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(sealed, "v1:k1:") || strings.Contains(sealed, "jane") {
+		t.Errorf("Unexpected sealed value %s", sealed)
+	}
+	if again, _ := k.Seal([]byte("jane@example.com"), []byte("user:7")); again == sealed {
+		t.Error("Expected a fresh nonce for each seal")
+	}
+
+	plaintext, err := k.Open(sealed, []byte("user:7"))
+	if err != nil || string(plaintext) != "jane@example.com" {
+		t.Errorf("Expected the plaintext back, got %q %v", plaintext, err)
+	}
+
+	if _, err := k.Open(sealed, []byte("user:8")); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("Expected ErrInvalidCiphertext for other additional data, got %v", err)
+	}
+	tampered := sealed[:len(sealed)-2] + "AA"
+	if _, err := k.Open(tampered, []byte("user:7")); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("Expected ErrInvalidCiphertext for a tampered value, got %v", err)
+	}
+	if _, err := k.Open("plaintext", nil); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("Expected ErrInvalidCiphertext for an unsealed value, got %v", err)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	old, _ := NewKeyring(testKey("2023", 1))
+	sealed, _ := old.Seal([]byte("secret"), nil)
+
+	rotated, _ := NewKeyring(testKey("2024", 2), testKey("2023", 1))
+	if plaintext, err := rotated.Open(sealed, nil); err != nil || string(plaintext) != "secret" {
+		t.Errorf("Expected old values to open after rotation, got %q %v", plaintext, err)
+	}
+	if !rotated.NeedsRotation(sealed) {
+		t.Error("Expected a value sealed with an old key to need rotation")
+	}
+
+	resealed, _ := rotated.Seal([]byte("secret"), nil)
+	if rotated.NeedsRotation(resealed) {
+		t.Error("Expected a value sealed with the primary key not to need rotation")
+	}
+	if id, _ := SealedKeyID(resealed); id != "2024" || rotated.PrimaryKeyID() != "2024" {
+		t.Errorf("Expected the primary key 2024, got %s", id)
+	}
+	if !strings.HasPrefix(resealed, rotated.PrimaryPrefix()) || strings.HasPrefix(sealed, rotated.PrimaryPrefix()) {
+		t.Errorf("Expected only values sealed with the primary key to have prefix %s", rotated.PrimaryPrefix())
+	}
+
+	retired, _ := NewKeyring(testKey("2024", 2))
+	if _, err := retired.Open(sealed, nil); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey once the old key is removed, got %v", err)
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+
+	k, err := ParseKeyring("new:" + secret + ", old:" + secret)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if k.PrimaryKeyID() != "new" {
+		t.Errorf("Expected the first key to be primary, got %s", k.PrimaryKeyID())
+	}
+
+	for _, spec := range []string{"", "new", "new:not-base64!"} {
+		if _, err := ParseKeyring(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
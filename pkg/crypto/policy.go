@@ -0,0 +1,293 @@
+package crypto
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password policy rules, reported in PasswordViolation.Rule
+const (
+	RuleMinLength = "minLength"
+	RuleMaxLength = "maxLength"
+	RuleLowercase = "lowercase"
+	RuleUppercase = "uppercase"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RuleBanned    = "banned"
+	RuleRepeated  = "repeated"
+	RuleStrength  = "strength"
+)
+
+// CharClass is a class of characters a policy can require
+type CharClass int
+
+const (
+	ClassLower CharClass = 1 << iota
+	ClassUpper
+	ClassDigit
+	// ClassSymbol is punctuation or a symbol; spaces and control characters are not symbols
+	ClassSymbol
+)
+
+// CommonPasswords is a short list of the most used passwords, for use with WithBannedPasswords
+var CommonPasswords = []string{
+	"123456", "123456789", "12345678", "password", "qwerty123", "qwerty1", "111111", "12345", "secret",
+	"123123", "1234567890", "1234567", "000000", "qwerty", "abc123", "password1", "iloveyou", "11111111",
+	"dragon", "monkey", "letmein", "welcome", "admin", "football", "sunshine", "princess", "passw0rd",
+	"Password1!", "P@ssw0rd", "changeme",
+}
+
+// PasswordViolation is one rule a password breaks
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a password breaks
+type PasswordPolicyError struct {
+	Violations []PasswordViolation `json:"violations"`
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// PasswordPolicy holds the rules passwords are checked against
+type PasswordPolicy struct {
+	MinLength int
+	// MaxLength of zero allows any length. bcrypt ignores bytes after the 72nd.
+	MaxLength       int
+	RequiredClasses CharClass
+	// BannedPasswords are rejected regardless of case
+	BannedPasswords map[string]struct{}
+	// MaxRepeated limits runs of the same character, such as "aaaa"; zero allows any
+	MaxRepeated int
+	// MinScore is the lowest PasswordScore accepted, from 0 to 4; zero disables the check
+	MinScore int
+}
+
+// DefaultPasswordPolicy requires at least 8 characters from all four classes, the rules
+// ValidatePasswordStrength applies
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:       8,
+		RequiredClasses: ClassLower | ClassUpper | ClassDigit | ClassSymbol,
+	}
+}
+
+// PasswordPolicyOption is a functional option for configuring a password policy
+type PasswordPolicyOption func(*PasswordPolicy)
+
+// WithMinLength sets the minimum length in characters
+func WithMinLength(length int) PasswordPolicyOption {
+	return func(policy *PasswordPolicy) {
+		policy.MinLength = length
+	}
+}
+
+// WithMaxLength sets the maximum length in characters
+func WithMaxLength(length int) PasswordPolicyOption {
+	return func(policy *PasswordPolicy) {
+		policy.MaxLength = length
+	}
+}
+
+// WithRequiredClasses sets the character classes a password must contain; none requires no classes
+func WithRequiredClasses(classes ...CharClass) PasswordPolicyOption {
+	return func(policy *PasswordPolicy) {
+		policy.RequiredClasses = 0
+		for _, class := range classes {
+			policy.RequiredClasses |= class
+		}
+	}
+}
+
+// WithBannedPasswords adds passwords that are always rejected, such as CommonPasswords
+func WithBannedPasswords(passwords ...string) PasswordPolicyOption {
+	return func(policy *PasswordPolicy) {
+		if policy.BannedPasswords == nil {
+			policy.BannedPasswords = make(map[string]struct{}, len(passwords))
+		}
+		for _, password := range passwords {
+			policy.BannedPasswords[strings.ToLower(password)] = struct{}{}
+		}
+	}
+}
+
+// WithMaxRepeated sets the longest allowed run of the same character
+func WithMaxRepeated(max int) PasswordPolicyOption {
+	return func(policy *PasswordPolicy) {
+		policy.MaxRepeated = max
+	}
+}
+
+// WithMinScore sets the lowest PasswordScore accepted
+func WithMinScore(score int) PasswordPolicyOption {
+	return func(policy *PasswordPolicy) {
+		policy.MinScore = score
+	}
+}
+
+// NewPasswordPolicy creates a new password policy with options
+func NewPasswordPolicy(options ...PasswordPolicyOption) *PasswordPolicy {
+	policy := DefaultPasswordPolicy()
+	for _, option := range options {
+		option(policy)
+	}
+	return policy
+}
+
+// Check returns every rule the password breaks, or nil if it meets the policy
+func (p *PasswordPolicy) Check(password string) []PasswordViolation {
+	var violations []PasswordViolation
+	add := func(rule, format string, args ...interface{}) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		add(RuleMinLength, "password must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		add(RuleMaxLength, "password must be at most %d characters long", p.MaxLength)
+	}
+
+	violations = append(violations, p.checkClasses(password)...)
+
+	if _, banned := p.BannedPasswords[strings.ToLower(password)]; banned {
+		add(RuleBanned, "password is too common")
+	}
+	if p.MaxRepeated > 0 && longestRun(password) > p.MaxRepeated {
+		add(RuleRepeated, "password must not repeat a character more than %d times in a row", p.MaxRepeated)
+	}
+	if p.MinScore > 0 && PasswordScore(password) < p.MinScore {
+		add(RuleStrength, "password is too easy to guess")
+	}
+
+	return violations
+}
+
+// checkClasses reports the required character classes the password is missing
+func (p *PasswordPolicy) checkClasses(password string) []PasswordViolation {
+	missing := p.RequiredClasses &^ passwordClasses(password)
+
+	var violations []PasswordViolation
+	for _, class := range []struct {
+		class   CharClass
+		rule    string
+		message string
+	}{
+		{ClassLower, RuleLowercase, "password must contain at least one lowercase letter"},
+		{ClassUpper, RuleUppercase, "password must contain at least one uppercase letter"},
+		{ClassDigit, RuleDigit, "password must contain at least one digit"},
+		{ClassSymbol, RuleSymbol, "password must contain at least one special character"},
+	} {
+		if missing&class.class != 0 {
+			violations = append(violations, PasswordViolation{Rule: class.rule, Message: class.message})
+		}
+	}
+	return violations
+}
+
+// Validate returns a *PasswordPolicyError listing every rule the password breaks, or nil if it meets the
+// policy
+func (p *PasswordPolicy) Validate(password string) error {
+	if violations := p.Check(password); len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// passwordClasses returns the character classes present in password
+func passwordClasses(password string) CharClass {
+	var classes CharClass
+	for _, char := range password {
+		switch {
+		case unicode.IsLower(char):
+			classes |= ClassLower
+		case unicode.IsUpper(char):
+			classes |= ClassUpper
+		case unicode.IsDigit(char):
+			classes |= ClassDigit
+		case unicode.IsPunct(char) || unicode.IsSymbol(char):
+			classes |= ClassSymbol
+		}
+	}
+	return classes
+}
+
+// longestRun returns the length of the longest run of one repeated character
+func longestRun(password string) int {
+	longest, run := 0, 0
+	var prev rune
+	for i, char := range []rune(password) {
+		if i > 0 && char == prev {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+		prev = char
+	}
+	return longest
+}
+
+// PasswordEntropy estimates a password's entropy in bits from the size of the character classes it uses.
+// Characters that repeat or continue a sequence from the one before, as in "aaa" or "1234", add nothing.
+func PasswordEntropy(password string) float64 {
+	classes := passwordClasses(password)
+	pool := 0
+	for _, class := range []struct {
+		class CharClass
+		size  int
+	}{{ClassLower, 26}, {ClassUpper, 26}, {ClassDigit, 10}, {ClassSymbol, 33}} {
+		if classes&class.class != 0 {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		// Letters outside upper and lower case, such as CJK characters
+		pool = 26
+	}
+
+	chars := []rune(password)
+	effective := 0
+	for i, char := range chars {
+		if i > 0 && abs(int(char)-int(chars[i-1])) <= 1 {
+			continue
+		}
+		effective++
+	}
+	return float64(effective) * math.Log2(float64(pool))
+}
+
+// PasswordScore rates a password from 0 (very weak) to 4 (very strong) by its estimated entropy
+func PasswordScore(password string) int {
+	bits := PasswordEntropy(password)
+	switch {
+	case bits < 28:
+		return 0
+	case bits < 36:
+		return 1
+	case bits < 60:
+		return 2
+	case bits < 80:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,126 @@
+package crypto
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewPasswordPolicy(t *testing.T) {
+	policy := NewPasswordPolicy(
+		WithMinLength(12),
+		WithMaxLength(64),
+		WithRequiredClasses(ClassLower, ClassDigit),
+		WithBannedPasswords("Hunter2"),
+		WithMaxRepeated(3),
+		WithMinScore(2),
+	)
+
+	if policy.MinLength != 12 || policy.MaxLength != 64 || policy.MaxRepeated != 3 || policy.MinScore != 2 {
+		t.Errorf("Expected options to apply, got %+v", policy)
+	}
+	if policy.RequiredClasses != ClassLower|ClassDigit {
+		t.Errorf("Expected lower and digit classes, got %b", policy.RequiredClasses)
+	}
+	if _, ok := policy.BannedPasswords["hunter2"]; !ok {
+		t.Error("Expected banned password stored in lower case")
+	}
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := NewPasswordPolicy(
+		WithMaxLength(20),
+		WithBannedPasswords(CommonPasswords...),
+		WithMaxRepeated(3),
+	)
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"valid", "MySecurePass123!", nil},
+		{"unicode symbol", "Grüße-2024-Köln", nil},
+		{"too short", "Ab1!", []string{RuleMinLength}},
+		{"too long", "MySecurePass123!MySecurePass123!", []string{RuleMaxLength}},
+		{"missing classes", "lowercaseonly", []string{RuleUppercase, RuleDigit, RuleSymbol}},
+		{"banned in any case", "p@SSW0RD", []string{RuleBanned}},
+		{"repeated", "Passssword1!", []string{RuleRepeated}},
+		{"everything", "aaaa", []string{RuleMinLength, RuleUppercase, RuleDigit, RuleSymbol, RuleRepeated}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := policy.Check(tt.password)
+
+			var rules []string
+			for _, violation := range violations {
+				rules = append(rules, violation.Rule)
+				if violation.Message == "" {
+					t.Errorf("Expected a message for rule %s", violation.Rule)
+				}
+			}
+			if strings.Join(rules, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected violations %v, got %v", tt.want, rules)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := NewPasswordPolicy(WithMinLength(10))
+
+	if err := policy.Validate("MySecurePass123!"); err != nil {
+		t.Errorf("Expected valid password, got %v", err)
+	}
+
+	err := policy.Validate("short")
+	var policyErr *PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("Expected PasswordPolicyError, got %v", err)
+	}
+	if len(policyErr.Violations) != 4 {
+		t.Errorf("Expected 4 violations, got %+v", policyErr.Violations)
+	}
+	if !strings.HasPrefix(err.Error(), "password must be at least 10 characters long; ") {
+		t.Errorf("Expected messages joined, got %q", err.Error())
+	}
+
+	body, err := json.Marshal(policyErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `{"rule":"minLength","message":"password must be at least 10 characters long"}`) {
+		t.Errorf("Expected violations to encode for API responses, got %s", body)
+	}
+}
+
+func TestPasswordScore(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"aaaaaaaaaaaa", 0},
+		{"abcdefghijkl", 0},
+		{"123456789", 0},
+		{"sunshine", 1},
+		{"MySecurePass123!", 4},
+		{"correct horse battery staple", 4},
+	}
+
+	for _, tt := range tests {
+		if got := PasswordScore(tt.password); got != tt.want {
+			t.Errorf("PasswordScore(%q) = %d (%.1f bits), want %d", tt.password, got, PasswordEntropy(tt.password),
+				tt.want)
+		}
+	}
+
+	policy := NewPasswordPolicy(WithRequiredClasses(), WithMinScore(3))
+	if violations := policy.Check("abcdefghijklmnop"); len(violations) != 1 || violations[0].Rule != RuleStrength {
+		t.Errorf("Expected a strength violation for a sequence, got %+v", violations)
+	}
+	if violations := policy.Check("correct horse battery staple"); len(violations) != 0 {
+		t.Errorf("Expected a passphrase to pass, got %+v", violations)
+	}
+}
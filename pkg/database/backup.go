@@ -0,0 +1,405 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"github.com/Okja-Engineering/go-service-kit/pkg/storage"
+)
+
+// BackupProgress reports how far a backup, restore, or tenant export has got
+type BackupProgress struct {
+	// Table is the table being exported or imported; empty for pg_dump and pg_restore
+	Table string `json:"table,omitempty"`
+	// Rows counts the rows exported or imported so far
+	Rows int64 `json:"rows,omitempty"`
+	// Bytes counts the bytes written or read so far
+	Bytes int64 `json:"bytes"`
+	// Done is set on the final report of a successful run
+	Done bool `json:"done"`
+}
+
+// ProgressFunc receives progress reports; it is called from the goroutine doing the work, so it should return quickly
+type ProgressFunc func(progress BackupProgress)
+
+// BackupConfig holds configuration for backups, restores, and tenant exports
+type BackupConfig struct {
+	// PgDumpPath and PgRestorePath locate the PostgreSQL client tools, looked up on PATH by default
+	PgDumpPath    string
+	PgRestorePath string
+	// Tables limits pg_dump to these tables; empty dumps the whole database
+	Tables []string
+	// Clean drops objects before restoring them, so a restore replaces what is there
+	Clean bool
+	// Progress, if set, is called as data is streamed
+	Progress ProgressFunc
+	// ProgressInterval is the number of bytes streamed between progress reports
+	ProgressInterval int64
+}
+
+// DefaultBackupConfig provides sensible defaults
+func DefaultBackupConfig() *BackupConfig {
+	return &BackupConfig{
+		PgDumpPath:       "pg_dump",
+		PgRestorePath:    "pg_restore",
+		Clean:            true,
+		ProgressInterval: 1 << 20, // 1 MiB
+	}
+}
+
+// BackupOption is a functional option for configuring backups
+type BackupOption func(*BackupConfig)
+
+// WithPgDumpPath sets the pg_dump executable
+func WithPgDumpPath(path string) BackupOption {
+	return func(config *BackupConfig) {
+		config.PgDumpPath = path
+	}
+}
+
+// WithPgRestorePath sets the pg_restore executable
+func WithPgRestorePath(path string) BackupOption {
+	return func(config *BackupConfig) {
+		config.PgRestorePath = path
+	}
+}
+
+// WithBackupTables limits a backup to the given tables
+func WithBackupTables(tables ...string) BackupOption {
+	return func(config *BackupConfig) {
+		config.Tables = tables
+	}
+}
+
+// WithRestoreClean sets whether a restore drops objects before recreating them
+func WithRestoreClean(clean bool) BackupOption {
+	return func(config *BackupConfig) {
+		config.Clean = clean
+	}
+}
+
+// WithBackupProgress sets a callback for progress reports, made every interval bytes
+func WithBackupProgress(fn ProgressFunc, interval int64) BackupOption {
+	return func(config *BackupConfig) {
+		config.Progress = fn
+		if interval > 0 {
+			config.ProgressInterval = interval
+		}
+	}
+}
+
+// NewBackupConfig creates a new backup config with options
+func NewBackupConfig(options ...BackupOption) *BackupConfig {
+	config := DefaultBackupConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// report sends a progress report if a callback is set
+func (c *BackupConfig) report(progress BackupProgress) {
+	if c.Progress != nil {
+		c.Progress(progress)
+	}
+}
+
+// Backup streams a pg_dump of the database to w in the custom archive format, which Restore reads
+func (p *PostgreSQL) Backup(ctx context.Context, w io.Writer, options ...BackupOption) error {
+	config := NewBackupConfig(options...)
+
+	args := []string{"--format=custom", "--no-owner", "--no-privileges"}
+	for _, table := range config.Tables {
+		args = append(args, "--table="+table)
+	}
+
+	counter := &progressCounter{config: config}
+	cmd := p.pgCommand(ctx, config.PgDumpPath, args)
+	cmd.Stdout = io.MultiWriter(w, counter)
+	if err := runPgCommand(cmd); err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+
+	config.report(BackupProgress{Bytes: counter.bytes, Done: true})
+	log.Printf("### 🗄️ Database: Backed up %s (%d bytes)", p.config.Database, counter.bytes)
+	return nil
+}
+
+// Restore loads an archive written by Backup with pg_restore, in a single transaction
+func (p *PostgreSQL) Restore(ctx context.Context, r io.Reader, options ...BackupOption) error {
+	config := NewBackupConfig(options...)
+
+	args := []string{"--dbname=" + p.config.Database, "--no-owner", "--no-privileges", "--single-transaction",
+		"--exit-on-error"}
+	if config.Clean {
+		args = append(args, "--clean", "--if-exists")
+	}
+
+	counter := &progressCounter{config: config}
+	cmd := p.pgCommand(ctx, config.PgRestorePath, args)
+	cmd.Stdin = io.TeeReader(r, counter)
+	if err := runPgCommand(cmd); err != nil {
+		return fmt.Errorf("restore failed: %w", err)
+	}
+
+	config.report(BackupProgress{Bytes: counter.bytes, Done: true})
+	log.Printf("### 🗄️ Database: Restored %s (%d bytes)", p.config.Database, counter.bytes)
+	return nil
+}
+
+// BackupTo streams a backup to key in store, without buffering it in memory or on disk
+func (p *PostgreSQL) BackupTo(ctx context.Context, store storage.Blob, key string,
+	options ...BackupOption) (*storage.Object, error) {
+	return streamTo(ctx, store, key, "application/octet-stream", func(w io.Writer) error {
+		return p.Backup(ctx, w, options...)
+	})
+}
+
+// RestoreFrom restores the backup stored at key in store
+func (p *PostgreSQL) RestoreFrom(ctx context.Context, store storage.Blob, key string, options ...BackupOption) error {
+	body, _, err := store.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("restore failed: %w", err)
+	}
+	defer body.Close()
+
+	return p.Restore(ctx, body, options...)
+}
+
+// ExportTenantTo streams a tenant's export, as written by ExportTenant, to key in store, e.g. to answer a
+// data portability request
+func (tm *TenantManager) ExportTenantTo(ctx context.Context, tenantID string, store storage.Blob, key string,
+	options ...BackupOption) (*storage.Object, error) {
+	return streamTo(ctx, store, key, "application/x-ndjson", func(w io.Writer) error {
+		return tm.ExportTenant(ctx, tenantID, w, options...)
+	})
+}
+
+// ImportTenant loads rows written by ExportTenant for a tenant, in one transaction. Lines for tables not
+// in Tables are rejected, and in shared schema mode each row's tenant column is set to tenantID, so an
+// import can never write another tenant's rows. Progress is reported after each table.
+func (tm *TenantManager) ImportTenant(ctx context.Context, tenantID string, r io.Reader,
+	options ...BackupOption) error {
+	config := NewBackupConfig(options...)
+	return tm.inTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		if err := tm.scope(ctx, tx, tenantID); err != nil {
+			return err
+		}
+		return tm.importRows(ctx, tx, tenantID, json.NewDecoder(r), config)
+	})
+}
+
+// importRows inserts each line of an export, reporting progress as each table is finished
+func (tm *TenantManager) importRows(ctx context.Context, tx *sql.Tx, tenantID string, dec *json.Decoder,
+	config *BackupConfig) error {
+	progress := BackupProgress{}
+	for {
+		var line exportLine
+		if err := dec.Decode(&line); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("tenant %s: invalid export: %w", tenantID, err)
+		}
+
+		if line.Table != progress.Table && progress.Table != "" {
+			config.report(progress)
+			progress.Rows = 0
+		}
+		progress.Table = line.Table
+
+		if err := tm.importRow(ctx, tx, tenantID, line); err != nil {
+			return err
+		}
+		progress.Rows++
+		progress.Bytes = dec.InputOffset()
+	}
+
+	if progress.Table != "" {
+		config.report(progress)
+	}
+	config.report(BackupProgress{Bytes: dec.InputOffset(), Done: true})
+	return nil
+}
+
+// importRow inserts one exported row into its table
+func (tm *TenantManager) importRow(ctx context.Context, tx *sql.Tx, tenantID string, line exportLine) error {
+	if !slices.Contains(tm.config.Tables, line.Table) {
+		return fmt.Errorf("tenant %s: %s is not a tenant table", tenantID, line.Table)
+	}
+
+	row := []byte(line.Row)
+	if tm.config.SchemaFunc == nil {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(row, &fields); err != nil {
+			return fmt.Errorf("tenant %s: invalid %s row: %w", tenantID, line.Table, err)
+		}
+		fields[tm.config.TenantColumn] = tenantID
+		row, _ = json.Marshal(fields)
+	}
+
+	table := quoteColumn(line.Table)
+	query := fmt.Sprintf("INSERT INTO %s SELECT * FROM json_populate_record(NULL::%s, $1::json)", table, table)
+	if _, err := tx.ExecContext(ctx, query, string(row)); err != nil {
+		return fmt.Errorf("tenant %s: failed to import %s: %w", tenantID, line.Table, err)
+	}
+	return nil
+}
+
+// BackupResult is the response of the backup and export admin endpoints
+type BackupResult struct {
+	Object *storage.Object `json:"object"`
+	// URL downloads the object without credentials for an hour, when the store can presign
+	URL string `json:"url,omitempty"`
+}
+
+// BackupHandler backs the database up to store under backups/ when POSTed to, responding with the
+// stored object. Mount it on the admin router so it sits behind the admin middleware:
+//
+//	base.AddAdminEndpoints(router, "admin", api.WithAdminAction("backup", db.BackupHandler(store)))
+func (p *PostgreSQL) BackupHandler(store storage.Blob, options ...BackupOption) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := fmt.Sprintf("backups/%s-%s.dump", url.PathEscape(p.config.Database), backupTimestamp())
+		object, err := p.BackupTo(r.Context(), store, key, options...)
+		if err != nil {
+			problem.Wrap(http.StatusInternalServerError, "backup-failed", r.URL.Path, err).Respond(w, r)
+			return
+		}
+		writeBackupResult(w, BackupResult{Object: object})
+	}
+}
+
+// ExportHandler exports the tenant named by ?tenantID= to store under exports/ when POSTed to,
+// responding with the stored object and a download URL:
+//
+//	base.AddAdminEndpoints(router, "admin", api.WithAdminAction("tenant-export", tenants.ExportHandler(store)))
+func (tm *TenantManager) ExportHandler(store storage.Blob, options ...BackupOption) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.URL.Query().Get("tenantID")
+		if tenantID == "" {
+			problem.New("invalid-query", "Invalid query parameters", http.StatusBadRequest,
+				"tenantID is required", r.URL.Path).Respond(w, r)
+			return
+		}
+		if _, err := tm.GetTenant(r.Context(), tenantID); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrTenantNotFound) {
+				status = http.StatusNotFound
+			}
+			problem.Wrap(status, "tenant-export-failed", r.URL.Path, err).Respond(w, r)
+			return
+		}
+
+		key := fmt.Sprintf("exports/%s-%s.jsonl", url.PathEscape(tenantID), backupTimestamp())
+		object, err := tm.ExportTenantTo(r.Context(), tenantID, store, key, options...)
+		if err != nil {
+			problem.Wrap(http.StatusInternalServerError, "tenant-export-failed", r.URL.Path, err).Respond(w, r)
+			return
+		}
+
+		result := BackupResult{Object: object}
+		result.URL, _ = store.PresignGet(r.Context(), key, time.Hour)
+		writeBackupResult(w, result)
+	}
+}
+
+// backupTimestamp names backups so they sort by time
+func backupTimestamp() string {
+	return time.Now().UTC().Format("20060102T150405Z")
+}
+
+// writeBackupResult responds 201 with a backup result
+func writeBackupResult(w http.ResponseWriter, result BackupResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// pgCommand builds a PostgreSQL client command connecting with the configured credentials. The password
+// is passed in the environment so it doesn't show in the process list.
+func (p *PostgreSQL) pgCommand(ctx context.Context, path string, args []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = append(os.Environ(),
+		"PGHOST="+p.config.Host,
+		"PGPORT="+strconv.Itoa(p.config.Port),
+		"PGUSER="+p.config.User,
+		"PGPASSWORD="+p.config.Password,
+		"PGDATABASE="+p.config.Database,
+		"PGSSLMODE="+p.config.SSLMode,
+		"PGCONNECT_TIMEOUT="+strconv.Itoa(max(int(p.config.ConnectTimeout.Seconds()), 1)),
+	)
+	return cmd
+}
+
+// runPgCommand runs a client command, including what it wrote to stderr in any error
+func runPgCommand(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", cmd.Args[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return nil
+}
+
+// streamTo writes to key in store whatever write produces, through a pipe
+func streamTo(ctx context.Context, store storage.Blob, key, contentType string,
+	write func(w io.Writer) error) (*storage.Object, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(write(pw))
+	}()
+
+	object, err := store.Put(ctx, key, pr, storage.WithContentType(contentType))
+	// Unblock the writer if Put gave up before reading everything
+	_ = pr.CloseWithError(io.ErrClosedPipe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	return object, nil
+}
+
+// progressCounter counts bytes streamed, reporting progress every interval
+type progressCounter struct {
+	config *BackupConfig
+	bytes  int64
+	next   int64
+}
+
+func (c *progressCounter) Write(b []byte) (int, error) {
+	c.bytes += int64(len(b))
+	if c.bytes >= c.next {
+		c.config.report(BackupProgress{Bytes: c.bytes})
+		c.next = c.bytes + c.config.ProgressInterval
+	}
+	return len(b), nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
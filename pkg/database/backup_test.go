@@ -0,0 +1,259 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/storage"
+)
+
+// fakeTool writes a shell script standing in for pg_dump or pg_restore
+func fakeTool(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o700); err != nil {
+		t.Fatalf("Failed to write fake tool: %v", err)
+	}
+	return path
+}
+
+func newLocalStore(t *testing.T) *storage.Local {
+	t.Helper()
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	return store
+}
+
+func TestNewBackupConfig(t *testing.T) {
+	progress := func(BackupProgress) {}
+	config := NewBackupConfig(
+		WithPgDumpPath("/usr/lib/postgresql/16/bin/pg_dump"),
+		WithBackupTables("orders"),
+		WithRestoreClean(false),
+		WithBackupProgress(progress, 0),
+	)
+
+	if config.PgDumpPath != "/usr/lib/postgresql/16/bin/pg_dump" || config.PgRestorePath != "pg_restore" {
+		t.Errorf("Unexpected tool paths %s and %s", config.PgDumpPath, config.PgRestorePath)
+	}
+	if len(config.Tables) != 1 || config.Clean || config.Progress == nil {
+		t.Errorf("Unexpected config %+v", config)
+	}
+	if config.ProgressInterval != 1<<20 {
+		t.Errorf("Expected a zero interval to keep the default, got %d", config.ProgressInterval)
+	}
+}
+
+func TestBackup(t *testing.T) {
+	p := NewPostgreSQL(NewConfig(WithDatabase("orders"), WithPassword("hunter2")))
+	dump := fakeTool(t, `echo "$PGDATABASE $PGPASSWORD $*"`)
+
+	var reports []BackupProgress
+	var out bytes.Buffer
+	err := p.Backup(context.Background(), &out, WithPgDumpPath(dump), WithBackupTables("orders"),
+		WithBackupProgress(func(progress BackupProgress) { reports = append(reports, progress) }, 1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := "orders hunter2 --format=custom --no-owner --no-privileges --table=orders\n"
+	if out.String() != want {
+		t.Errorf("Expected pg_dump output %q, got %q", want, out.String())
+	}
+	last := reports[len(reports)-1]
+	if !last.Done || last.Bytes != int64(len(want)) {
+		t.Errorf("Expected a final progress report of %d bytes, got %+v", len(want), last)
+	}
+}
+
+func TestBackupFailure(t *testing.T) {
+	p := NewPostgreSQL(NewConfig())
+	dump := fakeTool(t, `echo "connection refused" >&2; exit 1`)
+
+	err := p.Backup(context.Background(), io.Discard, WithPgDumpPath(dump))
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("Expected the error to include stderr, got %v", err)
+	}
+}
+
+func TestBackupToAndRestoreFrom(t *testing.T) {
+	p := NewPostgreSQL(NewConfig(WithDatabase("orders")))
+	store := newLocalStore(t)
+	restored := filepath.Join(t.TempDir(), "restored")
+	t.Setenv("RESTORED", restored)
+
+	dump := fakeTool(t, `printf 'PGDMP archive'`)
+	restore := fakeTool(t, `echo "$*" > "$RESTORED.args"; cat > "$RESTORED"`)
+
+	object, err := p.BackupTo(context.Background(), store, "backups/orders.dump", WithPgDumpPath(dump))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if object.Key != "backups/orders.dump" || object.Size != int64(len("PGDMP archive")) {
+		t.Errorf("Unexpected object %+v", object)
+	}
+
+	if err := p.RestoreFrom(context.Background(), store, "backups/orders.dump", WithPgRestorePath(restore)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(restored); string(data) != "PGDMP archive" {
+		t.Errorf("Expected the archive to be piped to pg_restore, got %q", data)
+	}
+	if args, _ := os.ReadFile(restored + ".args"); !strings.Contains(string(args), "--dbname=orders") ||
+		!strings.Contains(string(args), "--clean") {
+		t.Errorf("Unexpected pg_restore arguments %q", args)
+	}
+
+	if err := p.RestoreFrom(context.Background(), store, "backups/missing.dump"); err == nil {
+		t.Error("Expected an error for a missing backup")
+	}
+}
+
+func TestExportTenantTo(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("row_to_json", []string{"row_to_json"}, []driver.Value{`{"id":7}`}, []driver.Value{`{"id":8}`})
+	tm := NewTenantManager(p, WithTenantTables("orders", "order_items"))
+	store := newLocalStore(t)
+
+	var reports []BackupProgress
+	object, err := tm.ExportTenantTo(context.Background(), "acme", store, "exports/acme.jsonl",
+		WithBackupProgress(func(progress BackupProgress) { reports = append(reports, progress) }, 0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if object.ContentType != "application/x-ndjson" {
+		t.Errorf("Expected an NDJSON object, got %s", object.ContentType)
+	}
+
+	body, _, err := store.Get(context.Background(), "exports/acme.jsonl")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(body)
+	if lines := strings.Count(string(data), "\n"); lines != 4 {
+		t.Errorf("Expected 4 exported rows, got %d:\n%s", lines, data)
+	}
+
+	if len(reports) != 3 || reports[0].Table != "orders" || reports[0].Rows != 2 || !reports[2].Done ||
+		reports[2].Bytes != int64(len(data)) {
+		t.Errorf("Unexpected progress reports %+v", reports)
+	}
+}
+
+func TestImportTenant(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	tm := NewTenantManager(p, WithTenantTables("orders", "order_items"))
+
+	export := `{"table":"orders","row":{"id":7,"tenant_id":"other"}}` + "\n" +
+		`{"table":"orders","row":{"id":8}}` + "\n" +
+		`{"table":"order_items","row":{"id":1}}` + "\n"
+
+	var reports []BackupProgress
+	err := tm.ImportTenant(context.Background(), "acme", strings.NewReader(export),
+		WithBackupProgress(func(progress BackupProgress) { reports = append(reports, progress) }, 0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var inserts []fakeCall
+	for _, call := range fd.recorded() {
+		if strings.HasPrefix(call.query, "INSERT") {
+			inserts = append(inserts, call)
+		}
+	}
+	if len(inserts) != 3 {
+		t.Fatalf("Expected 3 inserts, got %d", len(inserts))
+	}
+	want := `INSERT INTO "orders" SELECT * FROM json_populate_record(NULL::"orders", $1::json)`
+	if inserts[0].query != want {
+		t.Errorf("Expected %s, got %s", want, inserts[0].query)
+	}
+	var row map[string]interface{}
+	_ = json.Unmarshal([]byte(inserts[0].args[0].(string)), &row)
+	if row["tenant_id"] != "acme" {
+		t.Errorf("Expected the tenant column to be set to the importing tenant, got %v", row)
+	}
+
+	if len(reports) != 3 || reports[0].Rows != 2 || reports[1].Table != "order_items" || !reports[2].Done {
+		t.Errorf("Unexpected progress reports %+v", reports)
+	}
+
+	bad := `{"table":"users","row":{"id":1}}`
+	if err := tm.ImportTenant(context.Background(), "acme", strings.NewReader(bad)); err == nil {
+		t.Error("Expected rows of tables outside Tables to be rejected")
+	}
+	if err := tm.ImportTenant(context.Background(), "acme", strings.NewReader("not json")); err == nil {
+		t.Error("Expected an error for a malformed export")
+	}
+}
+
+func TestBackupHandler(t *testing.T) {
+	p := NewPostgreSQL(NewConfig(WithDatabase("orders")))
+	store := newLocalStore(t)
+
+	handler := p.BackupHandler(store, WithPgDumpPath(fakeTool(t, `printf 'PGDMP'`)))
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/admin/backup", nil))
+
+	var result BackupResult
+	_ = json.Unmarshal(rec.Body.Bytes(), &result)
+	if rec.Code != http.StatusCreated || result.Object == nil || !strings.HasPrefix(result.Object.Key, "backups/orders-") {
+		t.Errorf("Expected the stored backup, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	failing := p.BackupHandler(store, WithPgDumpPath(fakeTool(t, `exit 1`)))
+	rec = httptest.NewRecorder()
+	failing(rec, httptest.NewRequest(http.MethodPost, "/admin/backup", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 for a failed backup, got %d", rec.Code)
+	}
+}
+
+func TestExportHandler(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on(`FROM "tenants"`, []string{"id", "name", "status", "created_at", "updated_at"},
+		[]driver.Value{"acme", "Acme Corp", "active", time.Now(), time.Now()})
+	fd.on("row_to_json", []string{"row_to_json"}, []driver.Value{`{"id":7}`})
+	tm := NewTenantManager(p, WithTenantTables("orders"))
+	handler := tm.ExportHandler(newLocalStore(t))
+
+	tests := []struct {
+		name     string
+		target   string
+		expected int
+	}{
+		{"exported", "/admin/tenant-export?tenantID=acme", http.StatusCreated},
+		{"missing tenant ID", "/admin/tenant-export", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodPost, tt.target, nil))
+			if rec.Code != tt.expected {
+				t.Errorf("Expected %d, got %d %s", tt.expected, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	missing, _ := newFakePostgreSQL(t)
+	rec := httptest.NewRecorder()
+	NewTenantManager(missing).ExportHandler(newLocalStore(t))(rec,
+		httptest.NewRequest(http.MethodPost, "/admin/tenant-export?tenantID=ghost", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown tenant, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,348 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
+	"github.com/lib/pq"
+)
+
+type actorContextKey struct{}
+
+// WithActor returns a context whose writes built by Conventions are attributed to userID
+func WithActor(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, userID)
+}
+
+// ActorFromContext returns the user ID set with WithActor
+func ActorFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(actorContextKey{}).(string)
+	return userID
+}
+
+// ConventionsConfig names the audit and soft delete columns. An empty name turns that column off.
+type ConventionsConfig struct {
+	CreatedAtColumn string
+	UpdatedAtColumn string
+	DeletedAtColumn string
+	CreatedByColumn string
+	UpdatedByColumn string
+	// TenantColumn is set on insert from the context's tenant in shared schema deployments
+	TenantColumn string
+	// Actor returns the ID of the user making a change; defaults to ActorFromContext
+	Actor func(ctx context.Context) string
+	// Tenant returns the tenant making a change; defaults to TenantFromContext
+	Tenant func(ctx context.Context) string
+	Clock  clock.Clock
+}
+
+// DefaultConventionsConfig provides sensible defaults
+func DefaultConventionsConfig() *ConventionsConfig {
+	return &ConventionsConfig{
+		CreatedAtColumn: "created_at",
+		UpdatedAtColumn: "updated_at",
+		DeletedAtColumn: "deleted_at",
+		CreatedByColumn: "created_by",
+		UpdatedByColumn: "updated_by",
+		TenantColumn:    "tenant_id",
+		Actor:           ActorFromContext,
+		Tenant:          TenantFromContext,
+		Clock:           clock.Real(),
+	}
+}
+
+// ConventionsOption is a functional option for configuring Conventions
+type ConventionsOption func(*ConventionsConfig)
+
+// WithTimestampColumns sets the columns holding the creation and last update times
+func WithTimestampColumns(createdAt, updatedAt string) ConventionsOption {
+	return func(config *ConventionsConfig) {
+		config.CreatedAtColumn = createdAt
+		config.UpdatedAtColumn = updatedAt
+	}
+}
+
+// WithActorColumns sets the columns holding the users who created and last updated a row
+func WithActorColumns(createdBy, updatedBy string) ConventionsOption {
+	return func(config *ConventionsConfig) {
+		config.CreatedByColumn = createdBy
+		config.UpdatedByColumn = updatedBy
+	}
+}
+
+// WithDeletedAtColumn sets the soft delete column; an empty name turns soft deletes off
+func WithDeletedAtColumn(column string) ConventionsOption {
+	return func(config *ConventionsConfig) {
+		config.DeletedAtColumn = column
+	}
+}
+
+// WithAuditTenantColumn sets the tenant column filled in on insert
+func WithAuditTenantColumn(column string) ConventionsOption {
+	return func(config *ConventionsConfig) {
+		config.TenantColumn = column
+	}
+}
+
+// WithActorFunc sets how the acting user is read from the context, e.g. from the auth claims
+func WithActorFunc(fn func(ctx context.Context) string) ConventionsOption {
+	return func(config *ConventionsConfig) {
+		config.Actor = fn
+	}
+}
+
+// WithTenantFunc sets how the acting tenant is read from the context, e.g. api.TenantFromContext
+func WithTenantFunc(fn func(ctx context.Context) string) ConventionsOption {
+	return func(config *ConventionsConfig) {
+		config.Tenant = fn
+	}
+}
+
+// WithConventionsClock sets the clock used for timestamps
+func WithConventionsClock(c clock.Clock) ConventionsOption {
+	return func(config *ConventionsConfig) {
+		config.Clock = c
+	}
+}
+
+// NewConventionsConfig creates a new conventions config with options
+func NewConventionsConfig(options ...ConventionsOption) *ConventionsConfig {
+	config := DefaultConventionsConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Conventions builds statements that keep audit columns up to date and hide soft-deleted rows, so
+// every service built on the kit treats created_at, updated_at, created_by, and deleted_at the same way
+type Conventions struct {
+	config *ConventionsConfig
+}
+
+// NewConventions creates conventions with options
+func NewConventions(options ...ConventionsOption) *Conventions {
+	return &Conventions{config: NewConventionsConfig(options...)}
+}
+
+// NotDeleted adds a filter excluding soft-deleted rows to a query that has no GROUP BY, ORDER BY, or
+// LIMIT, so apply it before Paginate or Keyset.Apply. An existing top-level WHERE condition is
+// parenthesized first, so its OR clauses cannot bypass the filter. For joins, pass the table names or
+// aliases whose deleted rows should be hidden:
+//
+//	conventions.NotDeleted("SELECT * FROM orders o JOIN customers c ON c.id = o.customer_id", "o", "c")
+//	// ... WHERE "o"."deleted_at" IS NULL AND "c"."deleted_at" IS NULL
+func (c *Conventions) NotDeleted(query string, tables ...string) string {
+	if c.config.DeletedAtColumn == "" {
+		return query
+	}
+
+	filter := c.notDeletedFilter(tables)
+	query = strings.TrimSpace(query)
+	idx := whereIndex(query)
+	if idx < 0 {
+		return query + " WHERE " + filter
+	}
+	return query[:idx+5] + " (" + strings.TrimSpace(query[idx+5:]) + ") AND " + filter
+}
+
+// notDeletedFilter is the condition matching rows of tables that are not soft-deleted
+func (c *Conventions) notDeletedFilter(tables []string) string {
+	column := pq.QuoteIdentifier(c.config.DeletedAtColumn)
+	if len(tables) == 0 {
+		return column + " IS NULL"
+	}
+
+	conditions := make([]string, len(tables))
+	for i, table := range tables {
+		conditions[i] = quoteColumn(table) + "." + column + " IS NULL"
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// BuildInsert builds an INSERT of the non-zero fields of values, a struct mapped to columns as for
+// ScanStruct, so zero fields take the column defaults; use pointer fields to insert zero values. The
+// timestamp, user, and tenant columns are set from the clock and ctx, overriding the struct's fields:
+//
+//	query, args, err := conventions.BuildInsert(ctx, "orders", order)
+//	// INSERT INTO "orders" ("total", "created_at", "updated_at", "created_by", ...) VALUES ($1, $2, ...)
+func (c *Conventions) BuildInsert(ctx context.Context, table string, values interface{}) (string, []interface{},
+	error) {
+	return buildInsert(table, values, c.insertColumns(ctx))
+}
+
+// buildInsert builds BuildInsert's statement, setting the fixed columns in place of the struct's fields
+func buildInsert(table string, values interface{}, fixed []columnValue) (string, []interface{}, error) {
+	v := reflect.ValueOf(values)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("insert values must be a struct, got %T", values)
+	}
+
+	skip := make(map[string]bool, len(fixed))
+	for _, cv := range fixed {
+		skip[cv.column] = true
+	}
+
+	var columns, placeholders []string
+	var args []interface{}
+	add := func(column string, value interface{}) {
+		args = append(args, value)
+		columns = append(columns, pq.QuoteIdentifier(column))
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+	for _, f := range fieldsOf(v.Type()) {
+		if field := v.FieldByIndex(f.index); !field.IsZero() && !skip[f.column] {
+			add(f.column, field.Interface())
+		}
+	}
+	for _, cv := range fixed {
+		add(cv.column, cv.value)
+	}
+	if len(columns) == 0 {
+		return "", nil, fmt.Errorf("insert into %s has no fields to set", table)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteColumn(table), strings.Join(columns, ", "),
+		strings.Join(placeholders, ", "))
+	return query, args, nil
+}
+
+// BuildUpdate is BuildUpdate for tables following the conventions: it also sets the update time and
+// user, never changes the creation, tenant, or soft delete columns, and leaves soft-deleted rows alone
+func (c *Conventions) BuildUpdate(ctx context.Context, table string, values interface{}, where string,
+	whereArgs ...interface{}) (string, []interface{}, error) {
+	return buildUpdate(table, values, c.updateColumns(ctx), c.protected(), c.whereNotDeleted(where), whereArgs)
+}
+
+// protected returns the convention columns an update never takes from the struct's fields
+func (c *Conventions) protected() map[string]bool {
+	skip := make(map[string]bool)
+	for _, column := range []string{c.config.CreatedAtColumn, c.config.UpdatedAtColumn, c.config.DeletedAtColumn,
+		c.config.CreatedByColumn, c.config.UpdatedByColumn, c.config.TenantColumn} {
+		if column != "" {
+			skip[column] = true
+		}
+	}
+	return skip
+}
+
+// BuildSoftDelete builds an UPDATE marking the rows matched by where as deleted. The where clause
+// uses $1, $2, ... for whereArgs; rows that are already deleted keep their deletion time.
+func (c *Conventions) BuildSoftDelete(ctx context.Context, table, where string, whereArgs ...interface{}) (string,
+	[]interface{}, error) {
+	if c.config.DeletedAtColumn == "" {
+		return "", nil, fmt.Errorf("soft delete of %s requires a deleted_at column", table)
+	}
+	fixed := append([]columnValue{{c.config.DeletedAtColumn, c.config.Clock.Now()}}, c.updateColumns(ctx)...)
+	return c.buildSet(table, fixed, c.whereNotDeleted(where), whereArgs)
+}
+
+// BuildRestore builds an UPDATE undoing the soft delete of the rows matched by where
+func (c *Conventions) BuildRestore(ctx context.Context, table, where string, whereArgs ...interface{}) (string,
+	[]interface{}, error) {
+	if c.config.DeletedAtColumn == "" {
+		return "", nil, fmt.Errorf("restore of %s requires a deleted_at column", table)
+	}
+	if strings.TrimSpace(where) == "" {
+		return "", nil, fmt.Errorf("update of %s requires a where clause", table)
+	}
+	fixed := append([]columnValue{{c.config.DeletedAtColumn, nil}}, c.updateColumns(ctx)...)
+	where = fmt.Sprintf("(%s) AND %s IS NOT NULL", where, pq.QuoteIdentifier(c.config.DeletedAtColumn))
+	return c.buildSet(table, fixed, where, whereArgs)
+}
+
+// buildSet builds an UPDATE of table setting only the fixed columns
+func (c *Conventions) buildSet(table string, fixed []columnValue, where string, whereArgs []interface{}) (string,
+	[]interface{}, error) {
+	if strings.TrimSpace(where) == "" {
+		return "", nil, fmt.Errorf("update of %s requires a where clause", table)
+	}
+
+	args := append([]interface{}(nil), whereArgs...)
+	set := make([]string, len(fixed))
+	for i, cv := range fixed {
+		args = append(args, cv.value)
+		set[i] = pq.QuoteIdentifier(cv.column) + " = $" + strconv.Itoa(len(args))
+	}
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s", quoteColumn(table), strings.Join(set, ", "), where), args, nil
+}
+
+// whereNotDeleted restricts a where clause to rows that are not soft-deleted
+func (c *Conventions) whereNotDeleted(where string) string {
+	if c.config.DeletedAtColumn == "" || strings.TrimSpace(where) == "" {
+		return where
+	}
+	return fmt.Sprintf("(%s) AND %s", where, c.notDeletedFilter(nil))
+}
+
+// insertColumns returns the convention columns set on insert
+func (c *Conventions) insertColumns(ctx context.Context) []columnValue {
+	now := c.config.Clock.Now()
+	user := c.config.Actor(ctx)
+	var columns []columnValue
+	add := func(column string, value interface{}, ok bool) {
+		if column != "" && ok {
+			columns = append(columns, columnValue{column, value})
+		}
+	}
+
+	add(c.config.CreatedAtColumn, now, true)
+	add(c.config.UpdatedAtColumn, now, true)
+	add(c.config.CreatedByColumn, user, user != "")
+	add(c.config.UpdatedByColumn, user, user != "")
+	tenant := c.config.Tenant(ctx)
+	add(c.config.TenantColumn, tenant, tenant != "")
+	return columns
+}
+
+// updateColumns returns the convention columns set on every update
+func (c *Conventions) updateColumns(ctx context.Context) []columnValue {
+	var columns []columnValue
+	if c.config.UpdatedAtColumn != "" {
+		columns = append(columns, columnValue{c.config.UpdatedAtColumn, c.config.Clock.Now()})
+	}
+	if user := c.config.Actor(ctx); c.config.UpdatedByColumn != "" && user != "" {
+		columns = append(columns, columnValue{c.config.UpdatedByColumn, user})
+	}
+	return columns
+}
+
+// Migration returns a migration adding the configured timestamp, user, and soft delete columns to
+// table. The tenant column is not added; tenant-owned tables are expected to have it from the start.
+//
+//	migrations = append(migrations, conventions.Migration(7, "orders"))
+func (c *Conventions) Migration(version int64, table string) Migration {
+	type column struct{ name, definition string }
+	columns := []column{
+		{c.config.CreatedAtColumn, "timestamptz NOT NULL DEFAULT now()"},
+		{c.config.UpdatedAtColumn, "timestamptz NOT NULL DEFAULT now()"},
+		{c.config.CreatedByColumn, "text"},
+		{c.config.UpdatedByColumn, "text"},
+		{c.config.DeletedAtColumn, "timestamptz"},
+	}
+
+	quoted := quoteColumn(table)
+	var up, down []string
+	for _, col := range columns {
+		if col.name == "" {
+			continue
+		}
+		name := pq.QuoteIdentifier(col.name)
+		up = append(up, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s;", quoted, name, col.definition))
+		down = append([]string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;", quoted, name)}, down...)
+	}
+
+	return Migration{
+		Version: version,
+		Name:    "add_conventions_to_" + strings.ReplaceAll(table, ".", "_"),
+		Up:      strings.Join(up, "\n"),
+		Down:    strings.Join(down, "\n"),
+	}
+}
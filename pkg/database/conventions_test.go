@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
+)
+
+type testOrder struct {
+	ID        int64     `db:"id"`
+	Total     int       `db:"total"`
+	CreatedAt time.Time `db:"created_at"`
+	TenantID  string    `db:"tenant_id"`
+}
+
+func newTestConventions(options ...ConventionsOption) (*Conventions, time.Time) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	options = append([]ConventionsOption{WithConventionsClock(clock.NewFake(now))}, options...)
+	return NewConventions(options...), now
+}
+
+func TestNotDeleted(t *testing.T) {
+	c, _ := newTestConventions()
+
+	tests := []struct {
+		name     string
+		query    string
+		tables   []string
+		expected string
+	}{
+		{"no where", "SELECT * FROM orders", nil, `SELECT * FROM orders WHERE "deleted_at" IS NULL`},
+		{
+			"where with or", "SELECT * FROM orders WHERE a = $1 OR b = $2", nil,
+			`SELECT * FROM orders WHERE (a = $1 OR b = $2) AND "deleted_at" IS NULL`,
+		},
+		{
+			"subquery where", "SELECT * FROM orders WHERE id IN (SELECT id FROM x WHERE y)", nil,
+			`SELECT * FROM orders WHERE (id IN (SELECT id FROM x WHERE y)) AND "deleted_at" IS NULL`,
+		},
+		{
+			"join", "SELECT * FROM orders o JOIN customers c ON c.id = o.customer_id", []string{"o", "c"},
+			`SELECT * FROM orders o JOIN customers c ON c.id = o.customer_id ` +
+				`WHERE "o"."deleted_at" IS NULL AND "c"."deleted_at" IS NULL`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.NotDeleted(tt.query, tt.tables...); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	off := NewConventions(WithDeletedAtColumn(""))
+	if got := off.NotDeleted("SELECT 1"); got != "SELECT 1" {
+		t.Errorf("Expected no filter without a deleted_at column, got %s", got)
+	}
+}
+
+func TestConventionsBuildInsert(t *testing.T) {
+	c, now := newTestConventions()
+	ctx := WithTenant(WithActor(context.Background(), "user-1"), "acme")
+
+	query, args, err := c.BuildInsert(ctx, "orders", testOrder{Total: 42, TenantID: "other"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `INSERT INTO "orders" ("total", "created_at", "updated_at", "created_by", "updated_by", "tenant_id") ` +
+		`VALUES ($1, $2, $3, $4, $5, $6)`
+	if query != want {
+		t.Errorf("Expected %s, got %s", want, query)
+	}
+	if wantArgs := []interface{}{42, now, now, "user-1", "user-1", "acme"}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("Expected args %v, got %v", wantArgs, args)
+	}
+
+	query, _, err = c.BuildInsert(context.Background(), "orders", &testOrder{Total: 1, TenantID: "acme"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(query, "created_by") || !strings.Contains(query, "tenant_id") {
+		t.Errorf("Expected the struct's tenant and no user without context values, got %s", query)
+	}
+
+	if _, _, err := c.BuildInsert(ctx, "orders", 1); err == nil {
+		t.Error("Expected an error for non-struct values")
+	}
+}
+
+func TestConventionsBuildUpdate(t *testing.T) {
+	c, now := newTestConventions(WithActorFunc(func(context.Context) string { return "user-2" }))
+
+	patch := testOrder{Total: 50, CreatedAt: now.Add(-time.Hour), TenantID: "other"}
+	query, args, err := c.BuildUpdate(context.Background(), "orders", patch, "id = $1", 7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `UPDATE "orders" SET "total" = $2, "updated_at" = $3, "updated_by" = $4 ` +
+		`WHERE (id = $1) AND "deleted_at" IS NULL`
+	if query != want {
+		t.Errorf("Expected %s, got %s", want, query)
+	}
+	if wantArgs := []interface{}{7, 50, now, "user-2"}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("Expected args %v, got %v", wantArgs, args)
+	}
+
+	if _, _, err := c.BuildUpdate(context.Background(), "orders", testOrder{TenantID: "x"}, "id = $1", 7); err == nil {
+		t.Error("Expected an error when only protected columns are set")
+	}
+}
+
+func TestBuildSoftDeleteAndRestore(t *testing.T) {
+	c, now := newTestConventions(WithActorColumns("created_by", ""))
+	ctx := WithActor(context.Background(), "user-1")
+
+	query, args, err := c.BuildSoftDelete(ctx, "orders", "id = $1", 7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := `UPDATE "orders" SET "deleted_at" = $2, "updated_at" = $3 WHERE (id = $1) AND "deleted_at" IS NULL`
+	if query != want {
+		t.Errorf("Expected %s, got %s", want, query)
+	}
+	if wantArgs := []interface{}{7, now, now}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("Expected args %v, got %v", wantArgs, args)
+	}
+
+	query, args, err = c.BuildRestore(ctx, "orders", "id = $1", 7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want = `UPDATE "orders" SET "deleted_at" = $2, "updated_at" = $3 WHERE (id = $1) AND "deleted_at" IS NOT NULL`
+	if query != want || args[1] != nil {
+		t.Errorf("Expected %s clearing deleted_at, got %s %v", want, query, args)
+	}
+
+	if _, _, err := c.BuildSoftDelete(ctx, "orders", " "); err == nil {
+		t.Error("Expected an error without a where clause")
+	}
+	if _, _, err := NewConventions(WithDeletedAtColumn("")).BuildSoftDelete(ctx, "orders", "id = 1"); err == nil {
+		t.Error("Expected an error without a deleted_at column")
+	}
+}
+
+func TestConventionsMigration(t *testing.T) {
+	m := NewConventions(WithActorColumns("", "")).Migration(7, "billing.orders")
+
+	if m.Version != 7 || m.Name != "add_conventions_to_billing_orders" {
+		t.Errorf("Unexpected migration %d %s", m.Version, m.Name)
+	}
+	wantUp := `ALTER TABLE "billing"."orders" ADD COLUMN IF NOT EXISTS "created_at" timestamptz NOT NULL DEFAULT now();
+ALTER TABLE "billing"."orders" ADD COLUMN IF NOT EXISTS "updated_at" timestamptz NOT NULL DEFAULT now();
+ALTER TABLE "billing"."orders" ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;`
+	if m.Up != wantUp {
+		t.Errorf("Expected up:\n%s\ngot:\n%s", wantUp, m.Up)
+	}
+	if !strings.HasPrefix(m.Down, `ALTER TABLE "billing"."orders" DROP COLUMN IF EXISTS "deleted_at";`) {
+		t.Errorf("Expected columns to be dropped in reverse order, got:\n%s", m.Down)
+	}
+}
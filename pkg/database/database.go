@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -25,6 +26,21 @@ type Database interface {
 	ClearTenantContext(ctx context.Context) error
 }
 
+// Querier is a Database that runs statements under a context, as the Repository and Collect need
+type Querier interface {
+	Database
+	ForEachRow(ctx context.Context, query string, args []interface{}, fn RowFunc) error
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Migrator defines the contract for applying schema migrations and seed data
+type Migrator interface {
+	Migrate(ctx context.Context, migrations []Migration, options ...MigrationOption) error
+	Plan(ctx context.Context, migrations []Migration, options ...MigrationOption) ([]Migration, error)
+	MigrationStatus(ctx context.Context, migrations []Migration, options ...MigrationOption) ([]MigrationStatus, error)
+	Seed(ctx context.Context, seeds []Seed, options ...SeedOption) error
+}
+
 // ConnectionStats provides information about database connections
 type ConnectionStats struct {
 	OpenConnections   int
@@ -34,6 +50,11 @@ type ConnectionStats struct {
 	WaitDuration      time.Duration
 	MaxIdleClosed     int64
 	MaxLifetimeClosed int64
+
+	// Health monitor state
+	State               ConnectionState
+	ConsecutiveFailures int64
+	Reconnects          int64
 }
 
 // TenantContext holds tenant-specific information for RLS multitenancy
@@ -59,6 +80,29 @@ type Config struct {
 
 	// RLS Multitenancy configuration
 	RLSContextVarName string // Default: "app.current_tenant_id"
+
+	// LISTEN/NOTIFY configuration
+	ListenMinReconnectInterval time.Duration
+	ListenMaxReconnectInterval time.Duration
+	NotificationBufferSize     int
+
+	// Health monitor configuration; the monitor is disabled when HealthCheckInterval is zero
+	HealthCheckInterval time.Duration
+	ReconnectThreshold  int
+	OnStateChange       StateChangeFunc
+
+	// Statements taking at least SlowQueryThreshold count as slow in the tenant query stats
+	SlowQueryThreshold time.Duration
+
+	// Slow query logging; disabled when SlowQueryLogThreshold is zero. At most one entry is logged
+	// per SlowQueryLogInterval, and SlowQueryLogger defaults to the request's logging.FromContext.
+	SlowQueryLogThreshold time.Duration
+	SlowQueryLogInterval  time.Duration
+	SlowQueryExplain      bool
+	SlowQueryLogger       *slog.Logger
+
+	// Hooks around every ForEachRow and Exec, in the order they run
+	QueryHooks []QueryHook
 }
 
 // DefaultConfig returns a secure default configuration
@@ -79,6 +123,20 @@ func DefaultConfig() *Config {
 
 		// RLS Multitenancy defaults
 		RLSContextVarName: "app.current_tenant_id",
+
+		// LISTEN/NOTIFY defaults
+		ListenMinReconnectInterval: 1 * time.Second,
+		ListenMaxReconnectInterval: 1 * time.Minute,
+		NotificationBufferSize:     64,
+
+		// Health monitor defaults
+		HealthCheckInterval: 0,
+		ReconnectThreshold:  3,
+
+		// Query stats and slow query logging defaults
+		SlowQueryThreshold:    100 * time.Millisecond,
+		SlowQueryLogThreshold: 0,
+		SlowQueryLogInterval:  10 * time.Second,
 	}
 }
 
@@ -176,6 +234,75 @@ func WithRLSContextVarName(varName string) Option {
 	}
 }
 
+// WithListenReconnectInterval sets the backoff bounds used when a LISTEN connection drops
+func WithListenReconnectInterval(minInterval, maxInterval time.Duration) Option {
+	return func(c *Config) {
+		c.ListenMinReconnectInterval = minInterval
+		c.ListenMaxReconnectInterval = maxInterval
+	}
+}
+
+// WithNotificationBufferSize sets the buffer size of subscription channels
+func WithNotificationBufferSize(size int) Option {
+	return func(c *Config) {
+		c.NotificationBufferSize = size
+	}
+}
+
+// WithHealthMonitor enables a background monitor that pings the database every interval and
+// re-establishes the pool after threshold consecutive failures
+func WithHealthMonitor(interval time.Duration, threshold int) Option {
+	return func(c *Config) {
+		c.HealthCheckInterval = interval
+		c.ReconnectThreshold = threshold
+	}
+}
+
+// WithStateChangeHandler sets a callback invoked on every connection state transition
+func WithStateChangeHandler(fn StateChangeFunc) Option {
+	return func(c *Config) {
+		c.OnStateChange = fn
+	}
+}
+
+// WithSlowQueryThreshold sets the duration from which statements count as slow in the tenant query stats
+func WithSlowQueryThreshold(threshold time.Duration) Option {
+	return func(c *Config) {
+		c.SlowQueryThreshold = threshold
+	}
+}
+
+// WithSlowQueryLog logs statements that take longer than threshold, with their EXPLAIN plan when
+// explain is set. The plan is fetched with ANALYZE off, so the statement is not run again.
+func WithSlowQueryLog(threshold time.Duration, explain bool) Option {
+	return func(c *Config) {
+		c.SlowQueryLogThreshold = threshold
+		c.SlowQueryExplain = explain
+	}
+}
+
+// WithSlowQueryLogInterval sets the minimum time between slow query log entries; zero logs every slow query
+func WithSlowQueryLogInterval(interval time.Duration) Option {
+	return func(c *Config) {
+		c.SlowQueryLogInterval = interval
+	}
+}
+
+// WithSlowQueryLogger sets the structured logger used for slow query entries
+func WithSlowQueryLogger(logger *slog.Logger) Option {
+	return func(c *Config) {
+		c.SlowQueryLogger = logger
+	}
+}
+
+// WithQueryHook adds hooks around every ForEachRow and Exec. BeforeQuery hooks run in the order they
+// were added and AfterQuery hooks in reverse, like middleware, so the first hook wraps all the others.
+func WithQueryHook(hooks ...QueryHook) Option {
+	return func(c *Config) {
+		c.QueryHooks = append(c.QueryHooks, hooks...)
+	}
+}
+
 // NewConfig creates a new configuration with the provided options
 func NewConfig(options ...Option) *Config {
 	config := DefaultConfig()
@@ -187,10 +314,14 @@ func NewConfig(options ...Option) *Config {
 
 // PostgreSQL implementation
 type PostgreSQL struct {
-	config *Config
-	db     *sql.DB
-	mu     sync.RWMutex
-	closed bool
+	config  *Config
+	db      *sql.DB
+	mu      sync.RWMutex
+	closed  bool
+	monitor monitorState
+	slowLog slowQueryLog
+
+	queryStats queryStats
 }
 
 // NewPostgreSQL creates a new PostgreSQL database instance
@@ -202,6 +333,19 @@ func NewPostgreSQL(config *Config) *PostgreSQL {
 
 // Connect establishes a connection to the PostgreSQL database
 func (p *PostgreSQL) Connect() error {
+	if err := p.connect(); err != nil {
+		p.setState(StateDisconnected, err)
+		return err
+	}
+
+	p.setState(StateConnected, nil)
+	p.startMonitor()
+
+	return nil
+}
+
+// connect opens the pool under the instance lock
+func (p *PostgreSQL) connect() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -209,21 +353,35 @@ func (p *PostgreSQL) Connect() error {
 		return fmt.Errorf("database connection is closed")
 	}
 
+	db, err := p.openPool(context.Background())
+	if err != nil {
+		return err
+	}
+
+	p.db = db
+	log.Printf("### 🗄️ Database: Connected to PostgreSQL at %s:%d/%s",
+		p.config.Host, p.config.Port, p.config.Database)
+
+	return nil
+}
+
+// openPool opens and pings a new connection pool
+func (p *PostgreSQL) openPool(ctx context.Context) (*sql.DB, error) {
 	dsn := p.buildDSN()
 
 	// Create connection with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), p.config.ConnectTimeout)
+	ctx, cancel := context.WithTimeout(ctx, p.config.ConnectTimeout)
 	defer cancel()
 
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
-		return fmt.Errorf("failed to open database connection: %w", err)
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
 
 	// Test the connection
 	if err := db.PingContext(ctx); err != nil {
 		db.Close()
-		return fmt.Errorf("failed to ping database: %w", err)
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	// Configure connection pool
@@ -232,15 +390,14 @@ func (p *PostgreSQL) Connect() error {
 	db.SetConnMaxLifetime(p.config.ConnMaxLifetime)
 	db.SetConnMaxIdleTime(p.config.ConnMaxIdleTime)
 
-	p.db = db
-	log.Printf("### 🗄️ Database: Connected to PostgreSQL at %s:%d/%s",
-		p.config.Host, p.config.Port, p.config.Database)
-
-	return nil
+	return db, nil
 }
 
 // Close closes the database connection
 func (p *PostgreSQL) Close() error {
+	p.stopMonitor()
+	defer p.setState(StateDisconnected, nil)
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -257,6 +414,22 @@ func (p *PostgreSQL) Close() error {
 	return nil
 }
 
+// Shutdown closes the database once queries in progress finish, or returns when ctx is done. Register
+// it with Base.OnShutdown rather than OnDrain, so requests still in flight can finish their queries.
+func (p *PostgreSQL) Shutdown(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Close()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("database queries still running at shutdown: %w", ctx.Err())
+	}
+}
+
 // GetDB returns the underlying sql.DB instance
 func (p *PostgreSQL) GetDB() *sql.DB {
 	p.mu.RLock()
@@ -289,7 +462,11 @@ func (p *PostgreSQL) GetStats() ConnectionStats {
 	defer p.mu.RUnlock()
 
 	if p.db == nil {
-		return ConnectionStats{}
+		return ConnectionStats{
+			State:               p.State(),
+			ConsecutiveFailures: p.monitor.failures.Load(),
+			Reconnects:          p.monitor.reconnects.Load(),
+		}
 	}
 
 	stats := p.db.Stats()
@@ -301,6 +478,10 @@ func (p *PostgreSQL) GetStats() ConnectionStats {
 		WaitDuration:      stats.WaitDuration,
 		MaxIdleClosed:     stats.MaxIdleClosed,
 		MaxLifetimeClosed: stats.MaxLifetimeClosed,
+
+		State:               p.State(),
+		ConsecutiveFailures: p.monitor.failures.Load(),
+		Reconnects:          p.monitor.reconnects.Load(),
 	}
 }
 
@@ -128,6 +128,20 @@ func TestPostgreSQLGetDB(t *testing.T) {
 	}
 }
 
+func TestPostgreSQLShutdown(t *testing.T) {
+	p, _ := newFakePostgreSQL(t)
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !p.closed {
+		t.Error("Expected database to be closed")
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected second shutdown to be a no-op, got %v", err)
+	}
+}
+
 func TestPostgreSQLHealthCheck(t *testing.T) {
 	db := &PostgreSQL{}
 
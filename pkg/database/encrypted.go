@@ -0,0 +1,298 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/crypto"
+	"github.com/lib/pq"
+)
+
+// likeEscaper escapes the LIKE wildcards in a literal prefix
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// columnKeyring seals and opens encrypted column values
+var columnKeyring atomic.Pointer[crypto.Keyring]
+
+// SetColumnKeyring sets the keyring that EncryptedString and EncryptedJSON values are sealed and opened
+// with. Call it at startup, before any encrypted column is read or written.
+func SetColumnKeyring(keyring *crypto.Keyring) {
+	columnKeyring.Store(keyring)
+}
+
+// ErrNoColumnKeyring is returned when an encrypted column is used before SetColumnKeyring
+var ErrNoColumnKeyring = errors.New("no column keyring set")
+
+// sealColumn seals plaintext with the column keyring
+func sealColumn(plaintext []byte) (string, error) {
+	keyring := columnKeyring.Load()
+	if keyring == nil {
+		return "", ErrNoColumnKeyring
+	}
+	return keyring.Seal(plaintext, nil)
+}
+
+// openColumn opens a scanned column value with the column keyring
+func openColumn(src interface{}) ([]byte, error) {
+	keyring := columnKeyring.Load()
+	if keyring == nil {
+		return nil, ErrNoColumnKeyring
+	}
+
+	var sealed string
+	switch v := src.(type) {
+	case string:
+		sealed = v
+	case []byte:
+		sealed = string(v)
+	default:
+		return nil, fmt.Errorf("cannot scan %T into an encrypted column", src)
+	}
+
+	plaintext, err := keyring.Open(sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt column: %w", err)
+	}
+	return plaintext, nil
+}
+
+// ColumnBinding returns the additional data that binds a sealed value to one cell: its table, column, and
+// row key. Keys are compared by their fmt %v form, so the integer 7 and the string "7" bind alike.
+func ColumnBinding(table, column string, rowKey interface{}) []byte {
+	return []byte(fmt.Sprintf("%s\x00%s\x00%v", table, column, rowKey))
+}
+
+// SealCell seals plaintext for one cell with the column keyring, bound to its table, column, and row key,
+// so the value fails to open if it is copied to another row or column. Use it instead of EncryptedString
+// when a value must not be movable between rows, and open it with OpenCell.
+func SealCell(table, column string, rowKey interface{}, plaintext []byte) (string, error) {
+	keyring := columnKeyring.Load()
+	if keyring == nil {
+		return "", ErrNoColumnKeyring
+	}
+	return keyring.Seal(plaintext, ColumnBinding(table, column, rowKey))
+}
+
+// OpenCell opens a value sealed by SealCell for the same table, column, and row key
+func OpenCell(table, column string, rowKey interface{}, sealed string) ([]byte, error) {
+	keyring := columnKeyring.Load()
+	if keyring == nil {
+		return nil, ErrNoColumnKeyring
+	}
+	plaintext, err := keyring.Open(sealed, ColumnBinding(table, column, rowKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s.%s: %w", table, column, err)
+	}
+	return plaintext, nil
+}
+
+// EncryptedString is a string stored encrypted in a text column, e.g. for PII. Values are sealed with
+// the column keyring when written and opened when scanned. Use *EncryptedString for nullable columns.
+// Encrypted columns can only be compared for presence; store a crypto.HashToken of the value alongside
+// it if lookups by value are needed.
+type EncryptedString string
+
+// Value seals the string
+func (s EncryptedString) Value() (driver.Value, error) {
+	return sealColumn([]byte(s))
+}
+
+// Scan opens a sealed value; NULL scans as the empty string
+func (s *EncryptedString) Scan(src interface{}) error {
+	if src == nil {
+		*s = ""
+		return nil
+	}
+	plaintext, err := openColumn(src)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// String returns the plaintext
+func (s EncryptedString) String() string {
+	return string(s)
+}
+
+// EncryptedJSON holds a value stored as encrypted JSON in a text column, for structured PII such as an
+// address. NULL scans as the zero value.
+//
+//	type Customer struct {
+//		ID      int64                           `db:"id"`
+//		Email   database.EncryptedString        `db:"email"`
+//		Address database.EncryptedJSON[Address] `db:"address"`
+//	}
+type EncryptedJSON[T any] struct {
+	Data T
+}
+
+// Value seals the JSON encoding of Data
+func (j EncryptedJSON[T]) Value() (driver.Value, error) {
+	data, err := json.Marshal(j.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode encrypted column: %w", err)
+	}
+	return sealColumn(data)
+}
+
+// Scan opens a sealed value and decodes it into Data
+func (j *EncryptedJSON[T]) Scan(src interface{}) error {
+	var zero T
+	j.Data = zero
+	if src == nil {
+		return nil
+	}
+
+	plaintext, err := openColumn(src)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(plaintext, &j.Data); err != nil {
+		return fmt.Errorf("failed to decode encrypted column: %w", err)
+	}
+	return nil
+}
+
+// RotationConfig holds configuration for RotateEncryptedColumn
+type RotationConfig struct {
+	// BatchSize is the number of rows read per query
+	BatchSize int
+	// BindRows rotates values sealed with SealCell, which are bound to their table, column, and row key
+	BindRows bool
+}
+
+// DefaultRotationConfig returns the default rotation configuration
+func DefaultRotationConfig() *RotationConfig {
+	return &RotationConfig{BatchSize: 500}
+}
+
+// RotationOption is a functional option for configuring RotateEncryptedColumn
+type RotationOption func(*RotationConfig)
+
+// WithRotationBatchSize sets the number of rows read per query
+func WithRotationBatchSize(size int) RotationOption {
+	return func(c *RotationConfig) {
+		c.BatchSize = size
+	}
+}
+
+// WithRowBinding rotates values sealed with SealCell, opening and re-sealing each bound to its row
+func WithRowBinding() RotationOption {
+	return func(c *RotationConfig) {
+		c.BindRows = true
+	}
+}
+
+// NewRotationConfig creates a new rotation configuration with the provided options
+func NewRotationConfig(options ...RotationOption) *RotationConfig {
+	config := DefaultRotationConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// RotateEncryptedColumn re-seals with the primary key every value of column that was sealed with an
+// older key, and returns how many values it re-sealed. Run it after adding a new key first in the
+// keyring; once it finishes, the old key can be removed. It pages through the table in keyColumn order,
+// so every row is visited once however many values it re-seals. Each value is only replaced if it has
+// not changed since it was read, so it is safe to run while the service is writing. Pass table as it is
+// given to SealCell when rotating bound values.
+func (p *PostgreSQL) RotateEncryptedColumn(ctx context.Context, table, keyColumn, column string,
+	options ...RotationOption) (int64, error) {
+	keyring := columnKeyring.Load()
+	if keyring == nil {
+		return 0, ErrNoColumnKeyring
+	}
+	config := NewRotationConfig(options...)
+
+	key, value := pq.QuoteIdentifier(keyColumn), pq.QuoteIdentifier(column)
+	selectQuery := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s IS NOT NULL AND %s NOT LIKE $1",
+		key, value, quoteColumn(table), value, value)
+	firstQuery := fmt.Sprintf("%s ORDER BY %s LIMIT %d", selectQuery, key, config.BatchSize)
+	nextQuery := fmt.Sprintf("%s AND %s > $2 ORDER BY %s LIMIT %d", selectQuery, key, key, config.BatchSize)
+	updateQuery := fmt.Sprintf("UPDATE %s SET %s = $1 WHERE %s = $2 AND %s = $3",
+		quoteColumn(table), value, key, value)
+	current := likeEscaper.Replace(keyring.PrimaryPrefix()) + "%"
+
+	var total int64
+	query, args := firstQuery, []interface{}{current}
+	for {
+		batch, err := p.unrotatedRows(ctx, query, args)
+		if err != nil {
+			return total, fmt.Errorf("failed to read %s.%s: %w", table, column, err)
+		}
+
+		for _, row := range batch {
+			var binding []byte
+			if config.BindRows {
+				binding = ColumnBinding(table, column, row.key)
+			}
+			n, err := p.resealRow(ctx, keyring, updateQuery, row, binding)
+			if err != nil {
+				return total, fmt.Errorf("failed to re-encrypt %s.%s for %v: %w", table, column, row.key, err)
+			}
+			total += n
+		}
+
+		if len(batch) < config.BatchSize {
+			break
+		}
+		query, args = nextQuery, []interface{}{current, batch[len(batch)-1].key}
+	}
+
+	log.Printf("### 🗄️ Database: Re-encrypted %d value(s) of %s.%s with key %s", total, table, column,
+		keyring.PrimaryKeyID())
+	return total, nil
+}
+
+// sealedRow is an encrypted value and the key of its row
+type sealedRow struct {
+	key    interface{}
+	sealed string
+}
+
+// unrotatedRows reads a page of values sealed with an older key
+func (p *PostgreSQL) unrotatedRows(ctx context.Context, query string, args []interface{}) ([]sealedRow, error) {
+	var batch []sealedRow
+	err := p.ForEachRow(ctx, query, args, func(rows *sql.Rows) error {
+		var row sealedRow
+		if err := rows.Scan(&row.key, &row.sealed); err != nil {
+			return err
+		}
+		if b, ok := row.key.([]byte); ok {
+			row.key = string(b)
+		}
+		batch = append(batch, row)
+		return nil
+	})
+	return batch, err
+}
+
+// resealRow seals a row's value with the primary key, unless it changed since it was read. binding is the
+// additional data the value is sealed with, nil for unbound values.
+func (p *PostgreSQL) resealRow(ctx context.Context, keyring *crypto.Keyring, query string, row sealedRow,
+	binding []byte) (int64, error) {
+	plaintext, err := keyring.Open(row.sealed, binding)
+	if err != nil {
+		return 0, err
+	}
+	resealed, err := keyring.Seal(plaintext, binding)
+	if err != nil {
+		return 0, err
+	}
+	result, err := p.Exec(ctx, query, resealed, row.key, row.sealed)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/crypto"
+)
+
+// useColumnKeyring sets a column keyring with the given key IDs, newest first, for the test
+func useColumnKeyring(t *testing.T, ids ...string) *crypto.Keyring {
+	t.Helper()
+	keys := make([]crypto.Key, len(ids))
+	for i, id := range ids {
+		keys[i] = crypto.Key{ID: id, Secret: bytes.Repeat([]byte(id[:1]), 32)}
+	}
+	keyring, err := crypto.NewKeyring(keys...)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+	SetColumnKeyring(keyring)
+	t.Cleanup(func() { SetColumnKeyring(nil) })
+	return keyring
+}
+
+type testAddress struct {
+	City string `json:"city"`
+}
+
+type testCustomer struct {
+	ID      int64                      `db:"id"`
+	Email   EncryptedString            `db:"email"`
+	Phone   *EncryptedString           `db:"phone"`
+	Address EncryptedJSON[testAddress] `db:"address"`
+}
+
+func TestEncryptedString(t *testing.T) {
+	useColumnKeyring(t, "k1")
+
+	value, err := EncryptedString("jane@example.com").Value()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sealed := value.(string)
+	if !strings.HasPrefix(sealed, "v1:k1:") || strings.Contains(sealed, "jane") {
+		t.Errorf("Expected a sealed value, got %s", sealed)
+	}
+
+	var s EncryptedString
+	if err := s.Scan([]byte(sealed)); err != nil || s != "jane@example.com" {
+		t.Errorf("Expected the plaintext back, got %q %v", s, err)
+	}
+	if err := s.Scan(nil); err != nil || s != "" {
+		t.Errorf("Expected NULL to scan as empty, got %q %v", s, err)
+	}
+	if err := s.Scan("jane@example.com"); !errors.Is(err, crypto.ErrInvalidCiphertext) {
+		t.Errorf("Expected plaintext in the column to be rejected, got %v", err)
+	}
+}
+
+func TestEncryptedColumnsWithoutKeyring(t *testing.T) {
+	SetColumnKeyring(nil)
+	if _, err := EncryptedString("x").Value(); !errors.Is(err, ErrNoColumnKeyring) {
+		t.Errorf("Expected ErrNoColumnKeyring, got %v", err)
+	}
+	var j EncryptedJSON[testAddress]
+	if err := j.Scan("v1:k1:abc"); !errors.Is(err, ErrNoColumnKeyring) {
+		t.Errorf("Expected ErrNoColumnKeyring, got %v", err)
+	}
+}
+
+func TestEncryptedColumnsScanStruct(t *testing.T) {
+	useColumnKeyring(t, "k1")
+	email, _ := EncryptedString("jane@example.com").Value()
+	address, _ := EncryptedJSON[testAddress]{Data: testAddress{City: "Lisbon"}}.Value()
+
+	p, fd := newFakePostgreSQL(t)
+	fd.on("customers", []string{"id", "email", "phone", "address"}, []driver.Value{int64(1), email, nil, address})
+
+	customers, err := Collect(context.Background(), p, "SELECT * FROM customers", nil, ScanInto[testCustomer])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	c := customers[0]
+	if c.Email != "jane@example.com" || c.Phone != nil || c.Address.Data.City != "Lisbon" {
+		t.Errorf("Unexpected customer %+v", c)
+	}
+
+	if _, err := p.Exec(context.Background(), "INSERT INTO customers (phone) VALUES ($1)", c.Phone); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if args := fd.recorded()[1].args; args[0] != nil {
+		t.Errorf("Expected a nil *EncryptedString to be written as NULL, got %v", args[0])
+	}
+}
+
+func TestRotateEncryptedColumn(t *testing.T) {
+	old := useColumnKeyring(t, "a-old")
+	sealed, _ := old.Seal([]byte("jane@example.com"), nil)
+	keyring := useColumnKeyring(t, "b_new", "a-old")
+
+	p, fd := newFakePostgreSQL(t)
+	fd.on("SELECT", []string{"id", "email"}, []driver.Value{[]byte("c1"), sealed})
+	fd.on("UPDATE", nil, []driver.Value{})
+
+	n, err := p.RotateEncryptedColumn(context.Background(), "customers", "id", "email")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 re-encrypted value, got %d", n)
+	}
+
+	calls := fd.recorded()
+	if calls[0].args[0] != `v1:b\_new:%` {
+		t.Errorf("Expected the primary key prefix to be escaped, got %v", calls[0].args[0])
+	}
+	update := calls[1]
+	want := `UPDATE "customers" SET "email" = $1 WHERE "id" = $2 AND "email" = $3`
+	if update.query != want || update.args[1] != "c1" || update.args[2] != sealed {
+		t.Errorf("Expected %s guarded by the old value, got %s %v", want, update.query, update.args)
+	}
+	if resealed := update.args[0].(string); keyring.NeedsRotation(resealed) {
+		t.Errorf("Expected the value to be sealed with the primary key, got %s", resealed)
+	}
+}
+
+func TestRotateEncryptedColumnPagesByKey(t *testing.T) {
+	useColumnKeyring(t, "a-old")
+	first, _ := SealCell("customers", "email", "c1", []byte("jane@example.com"))
+	second, _ := SealCell("customers", "email", "c2", []byte("john@example.com"))
+	useColumnKeyring(t, "b-new", "a-old")
+
+	p, fd := newFakePostgreSQL(t)
+	fd.on("> $2", []string{"id", "email"})
+	fd.on("SELECT", []string{"id", "email"}, []driver.Value{[]byte("c1"), first},
+		[]driver.Value{[]byte("c2"), second})
+	fd.on("UPDATE", nil, []driver.Value{})
+
+	n, err := p.RotateEncryptedColumn(context.Background(), "customers", "id", "email",
+		WithRotationBatchSize(2), WithRowBinding())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 re-encrypted values, got %d", n)
+	}
+
+	var pages []fakeCall
+	for _, call := range fd.recorded() {
+		if strings.HasPrefix(call.query, "SELECT") {
+			pages = append(pages, call)
+		}
+	}
+	if len(pages) != 2 || !strings.HasSuffix(pages[0].query, `ORDER BY "id" LIMIT 2`) {
+		t.Fatalf("Expected two pages in key order, got %+v", pages)
+	}
+	if !strings.Contains(pages[1].query, `"id" > $2`) || pages[1].args[1] != "c2" {
+		t.Errorf("Expected the second page to start after the last key, got %s %v", pages[1].query, pages[1].args)
+	}
+
+	resealed := fd.recorded()[1].args[0].(string)
+	if plaintext, err := OpenCell("customers", "email", "c1", resealed); err != nil ||
+		string(plaintext) != "jane@example.com" {
+		t.Errorf("Expected the value to stay bound to its row, got %q: %v", plaintext, err)
+	}
+}
+
+func TestSealCell(t *testing.T) {
+	useColumnKeyring(t, "a-key")
+
+	sealed, err := SealCell("customers", "email", int64(7), []byte("jane@example.com"))
+	if err != nil {
+		t.Fatalf("Failed to seal: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		table   string
+		column  string
+		rowKey  interface{}
+		wantErr bool
+	}{
+		{"same cell", "customers", "email", int64(7), false},
+		{"string key", "customers", "email", "7", false},
+		{"other row", "customers", "email", int64(8), true},
+		{"other column", "customers", "phone", int64(7), true},
+		{"other table", "suppliers", "email", int64(7), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plaintext, err := OpenCell(tt.table, tt.column, tt.rowKey, sealed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && string(plaintext) != "jane@example.com" {
+				t.Errorf("Expected the plaintext back, got %q", plaintext)
+			}
+		})
+	}
+}
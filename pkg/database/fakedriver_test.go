@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeResult is the scripted answer to queries containing a substring
+type fakeResult struct {
+	match   string
+	columns []string
+	rows    [][]driver.Value
+	err     error
+	// hang makes the statement wait for its context to end before failing with err, like a
+	// statement PostgreSQL cancels
+	hang bool
+}
+
+// fakeCall is a statement the fake driver received
+type fakeCall struct {
+	query string
+	args  []driver.Value
+}
+
+// fakeDriver answers queries from scripted results and records every statement, so row handling
+// can be tested without PostgreSQL
+type fakeDriver struct {
+	mu      sync.Mutex
+	results []fakeResult
+	calls   []fakeCall
+}
+
+// newFakePostgreSQL returns a PostgreSQL whose pool is backed by a fake driver
+func newFakePostgreSQL(t *testing.T, options ...Option) (*PostgreSQL, *fakeDriver) {
+	t.Helper()
+
+	fd := &fakeDriver{}
+	p := NewPostgreSQL(NewConfig(options...))
+	p.db = sql.OpenDB(fd)
+	t.Cleanup(func() { _ = p.db.Close() })
+	return p, fd
+}
+
+// on scripts the result of queries containing match
+func (fd *fakeDriver) on(match string, columns []string, rows ...[]driver.Value) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.results = append(fd.results, fakeResult{match: match, columns: columns, rows: rows})
+}
+
+// fail scripts an error for queries containing match
+func (fd *fakeDriver) fail(match string, err error) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.results = append(fd.results, fakeResult{match: match, err: err})
+}
+
+// hang scripts queries containing match to run until cancelled, then fail with err
+func (fd *fakeDriver) hang(match string, err error) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.results = append(fd.results, fakeResult{match: match, err: err, hang: true})
+}
+
+// recorded returns the statements received so far
+func (fd *fakeDriver) recorded() []fakeCall {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	return append([]fakeCall(nil), fd.calls...)
+}
+
+func (fd *fakeDriver) answer(query string, args []driver.NamedValue) fakeResult {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	fd.calls = append(fd.calls, fakeCall{query: query, args: values})
+
+	for _, result := range fd.results {
+		if strings.Contains(query, result.match) {
+			return result
+		}
+	}
+	return fakeResult{}
+}
+
+func (fd *fakeDriver) Connect(context.Context) (driver.Conn, error) { return &fakeConn{fd: fd}, nil }
+func (fd *fakeDriver) Driver() driver.Driver                        { return fd }
+func (fd *fakeDriver) Open(string) (driver.Conn, error)             { return &fakeConn{fd: fd}, nil }
+
+type fakeConn struct {
+	fd *fakeDriver
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake driver does not prepare statements")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{fd: c.fd}, nil }
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result := c.fd.answer(query, args)
+	if result.hang {
+		<-ctx.Done()
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: result.columns, rows: result.rows}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result := c.fd.answer(query, args)
+	if result.hang {
+		<-ctx.Done()
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+	return driver.RowsAffected(len(result.rows)), nil
+}
+
+// fakeTx records COMMIT and ROLLBACK as statements
+type fakeTx struct {
+	fd *fakeDriver
+}
+
+func (tx fakeTx) Commit() error {
+	tx.fd.answer("COMMIT", nil)
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.fd.answer("ROLLBACK", nil)
+	return nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
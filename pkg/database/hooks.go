@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// StatementKind tells queries run with ForEachRow from statements run with Exec
+type StatementKind string
+
+const (
+	// StatementQuery statements return rows, via ForEachRow
+	StatementQuery StatementKind = "query"
+	// StatementExec statements return a result, via Exec
+	StatementExec StatementKind = "exec"
+)
+
+// QueryEvent describes a statement passing through the query hooks
+type QueryEvent struct {
+	Kind  StatementKind
+	Query string
+	Args  []interface{}
+	Start time.Time
+
+	// Duration and Err are set once the statement has finished
+	Duration time.Duration
+	Err      error
+}
+
+// QueryHook observes or changes the statements run with ForEachRow and Exec, e.g. for tracing,
+// custom metrics, query rewriting, or assertions in tests. BeforeQuery may rewrite event.Query and
+// event.Args, return a context for the statement and the hooks after it, such as one carrying a
+// tracing span, or return an error to stop the statement. AfterQuery is called once the statement
+// finishes, with the context its BeforeQuery returned.
+type QueryHook interface {
+	BeforeQuery(ctx context.Context, event *QueryEvent) (context.Context, error)
+	AfterQuery(ctx context.Context, event *QueryEvent)
+}
+
+// QueryHookFuncs adapts functions to a QueryHook; either may be nil
+type QueryHookFuncs struct {
+	Before func(ctx context.Context, event *QueryEvent) (context.Context, error)
+	After  func(ctx context.Context, event *QueryEvent)
+}
+
+// BeforeQuery calls Before, if set
+func (h QueryHookFuncs) BeforeQuery(ctx context.Context, event *QueryEvent) (context.Context, error) {
+	if h.Before == nil {
+		return ctx, nil
+	}
+	return h.Before(ctx, event)
+}
+
+// AfterQuery calls After, if set
+func (h QueryHookFuncs) AfterQuery(ctx context.Context, event *QueryEvent) {
+	if h.After != nil {
+		h.After(ctx, event)
+	}
+}
+
+// runStatement runs a statement through the query hooks and records it in the stats and slow query
+// log. If a hook stops the statement, the hooks before it still see AfterQuery with the error.
+func (p *PostgreSQL) runStatement(ctx context.Context, event *QueryEvent, run func(ctx context.Context) error) error {
+	event.Start = time.Now()
+	hooks := p.config.QueryHooks
+	contexts, err := beforeQuery(ctx, hooks, event)
+	if err == nil {
+		if len(contexts) > 0 {
+			ctx = contexts[len(contexts)-1]
+		}
+		err = run(ctx)
+		event.Duration = time.Since(event.Start)
+		p.observeQuery(ctx, event.Query, event.Args, event.Duration, err)
+	} else {
+		event.Duration = time.Since(event.Start)
+	}
+
+	event.Err = err
+	for i := len(contexts) - 1; i >= 0; i-- {
+		hooks[i].AfterQuery(contexts[i], event)
+	}
+	return err
+}
+
+// beforeQuery calls BeforeQuery on each hook until one fails, returning the context each returned
+func beforeQuery(ctx context.Context, hooks []QueryHook, event *QueryEvent) ([]context.Context, error) {
+	contexts := make([]context.Context, 0, len(hooks))
+	for _, hook := range hooks {
+		hookCtx, err := hook.BeforeQuery(ctx, event)
+		if err != nil {
+			return contexts, fmt.Errorf("query stopped by hook: %w", err)
+		}
+		if hookCtx != nil {
+			ctx = hookCtx
+		}
+		contexts = append(contexts, ctx)
+	}
+	return contexts, nil
+}
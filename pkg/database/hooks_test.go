@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type hookKey string
+
+// recordingHook appends its name and the statement to trace on each call
+func recordingHook(name string, trace *[]string) QueryHook {
+	return QueryHookFuncs{
+		Before: func(ctx context.Context, event *QueryEvent) (context.Context, error) {
+			*trace = append(*trace, "before "+name)
+			return context.WithValue(ctx, hookKey(name), true), nil
+		},
+		After: func(ctx context.Context, event *QueryEvent) {
+			if ctx.Value(hookKey(name)) == nil {
+				*trace = append(*trace, "missing context "+name)
+			}
+			*trace = append(*trace, "after "+name)
+		},
+	}
+}
+
+func TestQueryHookOrder(t *testing.T) {
+	var trace []string
+	p, fd := newFakePostgreSQL(t, WithQueryHook(recordingHook("outer", &trace)),
+		WithQueryHook(recordingHook("inner", &trace)))
+	fd.on("SELECT", []string{"n"}, []driver.Value{int64(1)})
+
+	err := p.ForEachRow(context.Background(), "SELECT 1", nil, func(rows *sql.Rows) error { return nil })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []string{"before outer", "before inner", "after inner", "after outer"}
+	if !reflect.DeepEqual(trace, want) {
+		t.Errorf("Expected %v, got %v", want, trace)
+	}
+}
+
+func TestQueryHookEvent(t *testing.T) {
+	var events []QueryEvent
+	hook := QueryHookFuncs{
+		Before: func(ctx context.Context, event *QueryEvent) (context.Context, error) {
+			event.Query = "/* app=orders */ " + event.Query
+			return ctx, nil
+		},
+		After: func(_ context.Context, event *QueryEvent) { events = append(events, *event) },
+	}
+	p, fd := newFakePostgreSQL(t, WithQueryHook(hook))
+	fd.fail("DELETE", errors.New("permission denied"))
+	fd.on("UPDATE", nil, []driver.Value{})
+
+	if _, err := p.Exec(context.Background(), "UPDATE orders SET paid = $1", true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := p.Exec(context.Background(), "DELETE FROM orders"); err == nil {
+		t.Fatal("Expected the scripted error")
+	}
+
+	if got := fd.recorded()[0].query; got != "/* app=orders */ UPDATE orders SET paid = $1" {
+		t.Errorf("Expected the rewritten query to run, got %s", got)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	first, second := events[0], events[1]
+	if first.Kind != StatementExec || first.Args[0] != true || first.Err != nil || first.Start.IsZero() {
+		t.Errorf("Unexpected event %+v", first)
+	}
+	if second.Err == nil || !strings.Contains(second.Err.Error(), "permission denied") {
+		t.Errorf("Expected the statement error in the event, got %v", second.Err)
+	}
+}
+
+func TestQueryHookStopsStatement(t *testing.T) {
+	var trace []string
+	errWrite := errors.New("writes are not allowed in this test")
+	readOnly := QueryHookFuncs{Before: func(ctx context.Context, event *QueryEvent) (context.Context, error) {
+		if event.Kind == StatementExec {
+			return ctx, errWrite
+		}
+		return ctx, nil
+	}}
+	p, fd := newFakePostgreSQL(t, WithQueryHook(recordingHook("outer", &trace), readOnly))
+
+	_, err := p.Exec(context.Background(), "DELETE FROM orders")
+	if !errors.Is(err, errWrite) {
+		t.Errorf("Expected the hook error, got %v", err)
+	}
+	if calls := fd.recorded(); len(calls) != 0 {
+		t.Errorf("Expected no statement to run, got %+v", calls)
+	}
+	if want := []string{"before outer", "after outer"}; !reflect.DeepEqual(trace, want) {
+		t.Errorf("Expected %v, got %v", want, trace)
+	}
+}
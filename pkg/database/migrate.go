@@ -0,0 +1,500 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrChecksumMismatch is returned when an applied migration no longer matches its source
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+// ErrMigrationLockTimeout is returned when another instance holds the migration lock for too long
+var ErrMigrationLockTimeout = errors.New("timed out waiting for migration lock")
+
+// DefaultMigrationLockKey is the pg_advisory_lock key used to serialize migrations across replicas
+const DefaultMigrationLockKey int64 = 0x676f736b6d6967 // "goskmig"
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum returns the SHA-256 checksum of the migration's up SQL
+func (m Migration) Checksum() string {
+	hash := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(hash[:])
+}
+
+// AppliedMigration records a migration that has been applied to the database
+type AppliedMigration struct {
+	Version   int64     `json:"version"`
+	Name      string    `json:"name"`
+	Checksum  string    `json:"checksum"`
+	AppliedAt time.Time `json:"appliedAt"`
+}
+
+// MigrationConfig holds configuration for running migrations
+type MigrationConfig struct {
+	TableName   string
+	DryRun      bool
+	LockKey     int64
+	LockTimeout time.Duration
+}
+
+// DefaultMigrationConfig returns the default migration configuration
+func DefaultMigrationConfig() *MigrationConfig {
+	return &MigrationConfig{
+		TableName:   "schema_migrations",
+		DryRun:      false,
+		LockKey:     DefaultMigrationLockKey,
+		LockTimeout: 1 * time.Minute,
+	}
+}
+
+// MigrationOption is a functional option for configuring migrations
+type MigrationOption func(*MigrationConfig)
+
+// WithMigrationsTable sets the table used to track applied migrations
+func WithMigrationsTable(name string) MigrationOption {
+	return func(c *MigrationConfig) {
+		c.TableName = name
+	}
+}
+
+// WithDryRun validates pending migrations in a transaction that is always rolled back
+func WithDryRun(dryRun bool) MigrationOption {
+	return func(c *MigrationConfig) {
+		c.DryRun = dryRun
+	}
+}
+
+// WithMigrationLockKey sets the advisory lock key; services sharing a database need distinct keys
+func WithMigrationLockKey(key int64) MigrationOption {
+	return func(c *MigrationConfig) {
+		c.LockKey = key
+	}
+}
+
+// WithMigrationLockTimeout sets how long to wait for another instance to finish migrating
+func WithMigrationLockTimeout(timeout time.Duration) MigrationOption {
+	return func(c *MigrationConfig) {
+		c.LockTimeout = timeout
+	}
+}
+
+// NewMigrationConfig creates a new migration configuration with the provided options
+func NewMigrationConfig(options ...MigrationOption) *MigrationConfig {
+	config := DefaultMigrationConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Migrate applies all pending migrations in version order, each in its own transaction.
+// It fails with ErrChecksumMismatch if a previously applied migration has been modified.
+// The run holds a PostgreSQL advisory lock, so when several replicas start at once only
+// one applies migrations while the others wait and then find nothing pending.
+func (p *PostgreSQL) Migrate(ctx context.Context, migrations []Migration, options ...MigrationOption) error {
+	config := NewMigrationConfig(options...)
+
+	db, err := p.openDB()
+	if err != nil {
+		return err
+	}
+
+	sorted, err := sortMigrations(migrations)
+	if err != nil {
+		return err
+	}
+
+	if config.DryRun {
+		return dryRunMigrations(ctx, db, sorted, config)
+	}
+
+	unlock, err := acquireMigrationLock(ctx, db, config)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return runMigrations(ctx, db, sorted, config)
+}
+
+// runMigrations applies pending migrations; the caller must hold the migration lock
+func runMigrations(ctx context.Context, db *sql.DB, sorted []Migration, config *MigrationConfig) error {
+	if err := ensureMigrationsTable(ctx, db, config.TableName); err != nil {
+		return err
+	}
+
+	pending, err := planMigrations(ctx, db, sorted, config.TableName)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		if err := applyMigration(ctx, db, m, config.TableName); err != nil {
+			return err
+		}
+		log.Printf("### 🗄️ Database: Applied migration %d (%s)", m.Version, m.Name)
+	}
+
+	return nil
+}
+
+// acquireMigrationLock takes a session-level advisory lock on a dedicated connection,
+// returning a function that releases it
+func acquireMigrationLock(ctx context.Context, db *sql.DB, config *MigrationConfig) (func(), error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection for migration lock: %w", err)
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, config.LockTimeout)
+	defer cancel()
+
+	if _, err := conn.ExecContext(lockCtx, `SELECT pg_advisory_lock($1)`, config.LockKey); err != nil {
+		_ = conn.Close()
+		if errors.Is(lockCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w after %v", ErrMigrationLockTimeout, config.LockTimeout)
+		}
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	return func() {
+		// Use a fresh context so the lock is released even if ctx was cancelled
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if _, err := conn.ExecContext(unlockCtx, `SELECT pg_advisory_unlock($1)`, config.LockKey); err != nil {
+			log.Printf("### 🗄️ Database: Failed to release migration lock: %v", err)
+		}
+		_ = conn.Close()
+	}, nil
+}
+
+// Plan returns the migrations that Migrate would apply, without changing the database
+func (p *PostgreSQL) Plan(
+	ctx context.Context, migrations []Migration, options ...MigrationOption,
+) ([]Migration, error) {
+	config := NewMigrationConfig(options...)
+
+	db, err := p.openDB()
+	if err != nil {
+		return nil, err
+	}
+
+	sorted, err := sortMigrations(migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	return planMigrations(ctx, db, sorted, config.TableName)
+}
+
+// AppliedMigrations returns the migrations recorded in the tracking table
+func (p *PostgreSQL) AppliedMigrations(ctx context.Context, options ...MigrationOption) ([]AppliedMigration, error) {
+	config := NewMigrationConfig(options...)
+
+	db, err := p.openDB()
+	if err != nil {
+		return nil, err
+	}
+
+	return loadAppliedMigrations(ctx, db, config.TableName)
+}
+
+// LoadMigrations reads migrations from files named <version>_<name>.up.sql and
+// <version>_<name>.down.sql in dir. Down files are optional.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		version, name, direction, err := parseMigrationFilename(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	return sortMigrations(migrations)
+}
+
+// parseMigrationFilename splits a file name such as 0001_create_users.up.sql
+func parseMigrationFilename(filename string) (int64, string, string, error) {
+	base := strings.TrimSuffix(filename, ".sql")
+
+	var direction string
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		direction = "up"
+	case strings.HasSuffix(base, ".down"):
+		direction = "down"
+	default:
+		return 0, "", "", fmt.Errorf("migration %s must end in .up.sql or .down.sql", filename)
+	}
+	base = strings.TrimSuffix(base, "."+direction)
+
+	versionStr, name, _ := strings.Cut(base, "_")
+	version, err := strconv.ParseInt(versionStr, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("migration %s must start with a positive version number", filename)
+	}
+
+	return version, name, direction, nil
+}
+
+// openDB returns the connection pool or an error if the database is not connected
+func (p *PostgreSQL) openDB() (*sql.DB, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed || p.db == nil {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+
+	return p.db, nil
+}
+
+// sortMigrations returns a copy of migrations ordered by version, rejecting invalid versions
+func sortMigrations(migrations []Migration) ([]Migration, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migration %q has invalid version %d", m.Name, m.Version)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", m.Version)
+		}
+	}
+
+	return sorted, nil
+}
+
+// verifyChecksums ensures every applied migration still matches its source
+func verifyChecksums(applied []AppliedMigration, migrations []Migration) error {
+	byVersion := make(map[int64]Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	for _, a := range applied {
+		m, ok := byVersion[a.Version]
+		if !ok {
+			continue
+		}
+		if m.Checksum() != a.Checksum {
+			return fmt.Errorf("%w: version %d (%s) was modified after being applied", ErrChecksumMismatch, a.Version, a.Name)
+		}
+	}
+
+	return nil
+}
+
+// pendingMigrations returns the sorted migrations that have not been applied
+func pendingMigrations(applied []AppliedMigration, migrations []Migration) []Migration {
+	done := make(map[int64]bool, len(applied))
+	for _, a := range applied {
+		done[a.Version] = true
+	}
+
+	pending := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+
+	return pending
+}
+
+// planMigrations verifies checksums and returns the pending migrations
+func planMigrations(ctx context.Context, q queryer, migrations []Migration, table string) ([]Migration, error) {
+	applied, err := loadAppliedMigrations(ctx, q, table)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyChecksums(applied, migrations); err != nil {
+		return nil, err
+	}
+
+	return pendingMigrations(applied, migrations), nil
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// ensureMigrationsTable creates the tracking table if it does not exist
+func ensureMigrationsTable(ctx context.Context, q queryer, table string) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`, pq.QuoteIdentifier(table))
+
+	if _, err := q.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	return nil
+}
+
+// tableExists reports whether a table exists, naming it as quoted in the DDL so case and dots are kept
+func tableExists(ctx context.Context, q queryer, table string) (bool, error) {
+	var exists bool
+	err := q.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, pq.QuoteIdentifier(table)).Scan(&exists)
+	return exists, err
+}
+
+// loadAppliedMigrations reads the tracking table, treating a missing table as empty
+func loadAppliedMigrations(ctx context.Context, q queryer, table string) ([]AppliedMigration, error) {
+	exists, err := tableExists(ctx, q, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check migrations table: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	query := fmt.Sprintf(`SELECT version, name, checksum, applied_at FROM %s ORDER BY version`,
+		pq.QuoteIdentifier(table))
+
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied = append(applied, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load applied migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// applyMigration runs a migration and records it in a single transaction
+func applyMigration(ctx context.Context, db *sql.DB, m Migration, table string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.Version, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := execMigration(ctx, tx, m, table); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.Version, err)
+	}
+
+	return nil
+}
+
+// execMigration runs the migration SQL and inserts its tracking row
+func execMigration(ctx context.Context, q queryer, m Migration, table string) error {
+	if _, err := q.ExecContext(ctx, m.Up); err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+	}
+
+	insert := fmt.Sprintf(`INSERT INTO %s (version, name, checksum) VALUES ($1, $2, $3)`,
+		pq.QuoteIdentifier(table))
+	if _, err := q.ExecContext(ctx, insert, m.Version, m.Name, m.Checksum()); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+
+	return nil
+}
+
+// dryRunMigrations applies pending migrations in one transaction and rolls it back
+func dryRunMigrations(ctx context.Context, db *sql.DB, migrations []Migration, config *MigrationConfig) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin dry run: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := ensureMigrationsTable(ctx, tx, config.TableName); err != nil {
+		return err
+	}
+
+	pending, err := planMigrations(ctx, tx, migrations, config.TableName)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		if err := execMigration(ctx, tx, m, config.TableName); err != nil {
+			return err
+		}
+		log.Printf("### 🗄️ Database: Dry run validated migration %d (%s)", m.Version, m.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+)
+
+// migrationVersionLayout formats the timestamp versions of created migrations
+const migrationVersionLayout = "20060102150405"
+
+// CreateMigration writes empty up and down files for a new migration named name to dir, creating dir
+// if needed, and returns their paths. The version is the current UTC time, such as
+// 20240601093000_add_orders.up.sql, or one more than the newest version in dir if that is later, so
+// versions keep increasing. Call it from a make target or a subcommand of the service:
+//
+//	up, down, err := database.CreateMigration("migrations", "add orders")
+func CreateMigration(dir, name string) (string, string, error) {
+	return createMigration(dir, name, time.Now().UTC())
+}
+
+// createMigration writes the migration files for a version based on now
+func createMigration(dir, name string, now time.Time) (string, string, error) {
+	slug := migrationSlug(name)
+	if slug == "" {
+		return "", "", fmt.Errorf("migration name %q has no letters or digits", name)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+
+	version, _ := strconv.ParseInt(now.Format(migrationVersionLayout), 10, 64)
+	latest, err := latestMigrationVersion(dir)
+	if err != nil {
+		return "", "", err
+	}
+	version = max(version, latest+1)
+
+	base := filepath.Join(dir, fmt.Sprintf("%d_%s", version, slug))
+	up, down := base+".up.sql", base+".down.sql"
+	header := fmt.Sprintf("-- Migration %d: %s\n-- Created %s\n\n", version, name, now.Format(time.RFC3339))
+	if err := writeNewFile(up, header); err != nil {
+		return "", "", err
+	}
+	if err := writeNewFile(down, header+"-- Revert the changes of the up migration\n\n"); err != nil {
+		return "", "", err
+	}
+	return up, down, nil
+}
+
+// migrationSlug turns a name such as "Add orders" into add_orders
+func migrationSlug(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, "_")
+}
+
+// latestMigrationVersion returns the highest version of the migration files in dir
+func latestMigrationVersion(dir string) (int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	var latest int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		if version, _, _, err := parseMigrationFilename(entry.Name()); err == nil {
+			latest = max(latest, version)
+		}
+	}
+	return latest, nil
+}
+
+// writeNewFile writes content to a file that must not exist yet
+func writeNewFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create migration file: %w", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write migration file: %w", err)
+	}
+	return f.Close()
+}
+
+// MigrationState describes where a migration stands against the database
+type MigrationState string
+
+const (
+	// MigrationApplied migrations are recorded and match their source
+	MigrationApplied MigrationState = "applied"
+	// MigrationPending migrations have not been applied yet
+	MigrationPending MigrationState = "pending"
+	// MigrationModified migrations were applied but their source has changed since
+	MigrationModified MigrationState = "modified"
+	// MigrationMissing migrations were applied but are not in the given set, e.g. from another branch
+	MigrationMissing MigrationState = "missing"
+)
+
+// MigrationStatus reports the state of one migration version
+type MigrationStatus struct {
+	Version   int64          `json:"version"`
+	Name      string         `json:"name"`
+	State     MigrationState `json:"state"`
+	AppliedAt *time.Time     `json:"appliedAt,omitempty"`
+}
+
+// MigrationStatus compares migrations with the tracking table and returns the status of every version,
+// in version order. Unlike Plan, it does not fail on modified migrations; it reports them.
+func (p *PostgreSQL) MigrationStatus(ctx context.Context, migrations []Migration,
+	options ...MigrationOption) ([]MigrationStatus, error) {
+	applied, err := p.AppliedMigrations(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return migrationStatuses(applied, migrations), nil
+}
+
+// migrationStatuses merges applied and known migrations into statuses sorted by version
+func migrationStatuses(applied []AppliedMigration, migrations []Migration) []MigrationStatus {
+	byVersion := make(map[int64]*MigrationStatus, len(migrations)+len(applied))
+	for _, m := range migrations {
+		byVersion[m.Version] = &MigrationStatus{Version: m.Version, Name: m.Name, State: MigrationPending}
+	}
+
+	checksums := make(map[int64]string, len(migrations))
+	for _, m := range migrations {
+		checksums[m.Version] = m.Checksum()
+	}
+
+	for _, a := range applied {
+		appliedAt := a.AppliedAt
+		status, known := byVersion[a.Version]
+		switch {
+		case !known:
+			status = &MigrationStatus{Version: a.Version, Name: a.Name, State: MigrationMissing}
+			byVersion[a.Version] = status
+		case checksums[a.Version] != a.Checksum:
+			status.State = MigrationModified
+		default:
+			status.State = MigrationApplied
+		}
+		status.AppliedAt = &appliedAt
+	}
+
+	statuses := make([]MigrationStatus, 0, len(byVersion))
+	for _, status := range byVersion {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses
+}
+
+// WriteMigrationStatus writes statuses as an aligned table, for printing from a command:
+//
+//	VERSION         NAME          STATE    APPLIED AT
+//	20240601093000  add_orders    applied  2024-06-01T09:31:12Z
+//	20240612150000  add_invoices  pending
+func WriteMigrationStatus(w io.Writer, statuses []MigrationStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, s := range statuses {
+		appliedAt := ""
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.Version, s.Name, s.State, appliedAt)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateMigration(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "migrations")
+	now := time.Date(2024, 6, 1, 9, 30, 0, 0, time.UTC)
+
+	up, down, err := createMigration(dir, "Add orders!", now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if filepath.Base(up) != "20240601093000_add_orders.up.sql" ||
+		filepath.Base(down) != "20240601093000_add_orders.down.sql" {
+		t.Errorf("Unexpected file names %s and %s", up, down)
+	}
+
+	// A second migration in the same second still gets a later version
+	up2, _, err := createMigration(dir, "add invoices", now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if filepath.Base(up2) != "20240601093001_add_invoices.up.sql" {
+		t.Errorf("Expected the version after the newest, got %s", up2)
+	}
+
+	migrations, err := LoadMigrations(os.DirFS(dir), ".")
+	if err != nil {
+		t.Fatalf("Expected created migrations to load, got %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Name != "add_orders" || migrations[0].Down == "" {
+		t.Errorf("Unexpected migrations %+v", migrations)
+	}
+
+	if _, _, err := createMigration(dir, "!!!", now); err == nil {
+		t.Error("Expected an error for a name without letters or digits")
+	}
+}
+
+func TestMigrationStatuses(t *testing.T) {
+	appliedAt := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	migrations := []Migration{
+		{Version: 1, Name: "create_users", Up: "CREATE TABLE users (id int)"},
+		{Version: 2, Name: "add_email", Up: "ALTER TABLE users ADD email text"},
+		{Version: 4, Name: "add_orders", Up: "CREATE TABLE orders (id int)"},
+	}
+	applied := []AppliedMigration{
+		{Version: 1, Name: "create_users", Checksum: migrations[0].Checksum(), AppliedAt: appliedAt},
+		{Version: 2, Name: "add_email", Checksum: "stale", AppliedAt: appliedAt},
+		{Version: 3, Name: "from_branch", Checksum: "x", AppliedAt: appliedAt},
+	}
+
+	statuses := migrationStatuses(applied, migrations)
+	want := []MigrationState{MigrationApplied, MigrationModified, MigrationMissing, MigrationPending}
+	if len(statuses) != len(want) {
+		t.Fatalf("Expected %d statuses, got %+v", len(want), statuses)
+	}
+	for i, state := range want {
+		if statuses[i].Version != int64(i+1) || statuses[i].State != state {
+			t.Errorf("Expected version %d to be %s, got %+v", i+1, state, statuses[i])
+		}
+	}
+	if statuses[0].AppliedAt == nil || !statuses[0].AppliedAt.Equal(appliedAt) || statuses[3].AppliedAt != nil {
+		t.Errorf("Unexpected applied times %v and %v", statuses[0].AppliedAt, statuses[3].AppliedAt)
+	}
+}
+
+func TestPostgreSQLMigrationStatus(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	migrations := []Migration{{Version: 1, Name: "create_users", Up: "CREATE TABLE users (id int)"}}
+	fd.on("to_regclass", []string{"exists"}, []driver.Value{true})
+	fd.on("SELECT version", []string{"version", "name", "checksum", "applied_at"},
+		[]driver.Value{int64(1), "create_users", migrations[0].Checksum(), time.Now()})
+
+	statuses, err := p.MigrationStatus(context.Background(), migrations)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].State != MigrationApplied {
+		t.Errorf("Expected the migration to be applied, got %+v", statuses)
+	}
+}
+
+func TestMockMigrationStatus(t *testing.T) {
+	mock := NewMock()
+	ctx := context.Background()
+	migrations := []Migration{{Version: 1, Name: "a", Up: "SELECT 1"}}
+	_ = mock.Migrate(ctx, migrations)
+
+	migrations = append(migrations, Migration{Version: 2, Name: "b", Up: "SELECT 2"})
+	statuses, _ := mock.MigrationStatus(ctx, migrations)
+	if len(statuses) != 2 || statuses[0].State != MigrationApplied || statuses[1].State != MigrationPending {
+		t.Errorf("Unexpected statuses %+v", statuses)
+	}
+}
+
+func TestWriteMigrationStatus(t *testing.T) {
+	appliedAt := time.Date(2024, 6, 1, 9, 31, 12, 0, time.UTC)
+	var buf bytes.Buffer
+	err := WriteMigrationStatus(&buf, []MigrationStatus{
+		{Version: 20240601093000, Name: "add_orders", State: MigrationApplied, AppliedAt: &appliedAt},
+		{Version: 20240612150000, Name: "add_invoices", State: MigrationPending},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "VERSION") ||
+		!strings.HasSuffix(lines[1], "applied  2024-06-01T09:31:12Z") ||
+		!strings.HasSuffix(lines[2], "pending") {
+		t.Errorf("Unexpected table:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestMigrationChecksum(t *testing.T) {
+	m1 := Migration{Version: 1, Name: "create_users", Up: "CREATE TABLE users (id INT)"}
+	m2 := Migration{Version: 1, Name: "create_users", Up: "CREATE TABLE users (id BIGINT)"}
+
+	if m1.Checksum() == "" {
+		t.Error("Expected non-empty checksum")
+	}
+
+	if m1.Checksum() != m1.Checksum() {
+		t.Error("Expected checksum to be deterministic")
+	}
+
+	if m1.Checksum() == m2.Checksum() {
+		t.Error("Expected different SQL to produce different checksums")
+	}
+}
+
+func TestMigrationConfig(t *testing.T) {
+	config := DefaultMigrationConfig()
+	if config.TableName != "schema_migrations" {
+		t.Errorf("Expected default table 'schema_migrations', got '%s'", config.TableName)
+	}
+	if config.DryRun {
+		t.Error("Expected DryRun to be false by default")
+	}
+
+	config = NewMigrationConfig(WithMigrationsTable("custom_migrations"), WithDryRun(true))
+	if config.TableName != "custom_migrations" {
+		t.Errorf("Expected table 'custom_migrations', got '%s'", config.TableName)
+	}
+	if !config.DryRun {
+		t.Error("Expected DryRun to be true")
+	}
+}
+
+func TestMigrationLockConfig(t *testing.T) {
+	config := DefaultMigrationConfig()
+	if config.LockKey != DefaultMigrationLockKey {
+		t.Errorf("Expected default lock key %d, got %d", DefaultMigrationLockKey, config.LockKey)
+	}
+	if config.LockTimeout != time.Minute {
+		t.Errorf("Expected default lock timeout 1m, got %v", config.LockTimeout)
+	}
+
+	config = NewMigrationConfig(WithMigrationLockKey(42), WithMigrationLockTimeout(5*time.Second))
+	if config.LockKey != 42 {
+		t.Errorf("Expected lock key 42, got %d", config.LockKey)
+	}
+	if config.LockTimeout != 5*time.Second {
+		t.Errorf("Expected lock timeout 5s, got %v", config.LockTimeout)
+	}
+}
+
+func TestSortMigrations(t *testing.T) {
+	sorted, err := sortMigrations([]Migration{
+		{Version: 3, Name: "c"},
+		{Version: 1, Name: "a"},
+		{Version: 2, Name: "b"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for i, m := range sorted {
+		if m.Version != int64(i+1) {
+			t.Errorf("Expected version %d at position %d, got %d", i+1, i, m.Version)
+		}
+	}
+
+	if _, err := sortMigrations([]Migration{{Version: 1}, {Version: 1}}); err == nil {
+		t.Error("Expected error for duplicate versions")
+	}
+
+	if _, err := sortMigrations([]Migration{{Version: 0}}); err == nil {
+		t.Error("Expected error for invalid version")
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "a", Up: "SELECT 1"},
+		{Version: 2, Name: "b", Up: "SELECT 2"},
+	}
+
+	applied := []AppliedMigration{{Version: 1, Name: "a", Checksum: migrations[0].Checksum()}}
+	if err := verifyChecksums(applied, migrations); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	applied[0].Checksum = "changed"
+	err := verifyChecksums(applied, migrations)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+	applied := []AppliedMigration{{Version: 1}, {Version: 3}}
+
+	pending := pendingMigrations(applied, migrations)
+	if len(pending) != 1 || pending[0].Version != 2 {
+		t.Errorf("Expected only version 2 pending, got %v", pending)
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD email TEXT")},
+		"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INT)")},
+		"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users")},
+		"migrations/README.md":                  {Data: []byte("ignored")},
+	}
+
+	migrations, err := LoadMigrations(fsys, "migrations")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+	}
+
+	if migrations[0].Version != 1 || migrations[0].Name != "create_users" {
+		t.Errorf("Unexpected first migration: %+v", migrations[0])
+	}
+
+	if migrations[0].Down != "DROP TABLE users" {
+		t.Errorf("Expected down SQL to be loaded, got '%s'", migrations[0].Down)
+	}
+
+	if migrations[1].Version != 2 || migrations[1].Name != "add_email" {
+		t.Errorf("Unexpected second migration: %+v", migrations[1])
+	}
+}
+
+func TestLoadMigrationsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"missing direction", fstest.MapFS{"m/0001_a.sql": {Data: []byte("SELECT 1")}}},
+		{"missing version", fstest.MapFS{"m/abc_a.up.sql": {Data: []byte("SELECT 1")}}},
+		{"down only", fstest.MapFS{"m/0001_a.down.sql": {Data: []byte("SELECT 1")}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadMigrations(tt.fsys, "m"); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestPostgreSQLMigrateNotConnected(t *testing.T) {
+	db := NewPostgreSQL(DefaultConfig())
+	ctx := context.Background()
+
+	if err := db.Migrate(ctx, []Migration{{Version: 1, Up: "SELECT 1"}}); err == nil {
+		t.Error("Expected error when db is nil")
+	}
+
+	if _, err := db.Plan(ctx, []Migration{{Version: 1, Up: "SELECT 1"}}); err == nil {
+		t.Error("Expected error when db is nil")
+	}
+
+	if _, err := db.AppliedMigrations(ctx); err == nil {
+		t.Error("Expected error when db is nil")
+	}
+}
+
+func TestLoadAppliedMigrationsQuotesTable(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("to_regclass", []string{"exists"}, []driver.Value{true})
+	fd.on("SELECT version", []string{"version", "name", "checksum", "applied_at"},
+		[]driver.Value{int64(1), "create_users", "abc", time.Now()})
+
+	applied, err := loadAppliedMigrations(context.Background(), p.db, "SchemaMigrations")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(applied) != 1 {
+		t.Errorf("Expected the applied migration to be read, got %+v", applied)
+	}
+
+	calls := fd.recorded()
+	if calls[0].args[0] != `"SchemaMigrations"` {
+		t.Errorf("Expected the table to be checked as quoted in the DDL, got %v", calls[0].args[0])
+	}
+	if want := `FROM "SchemaMigrations"`; !strings.Contains(calls[1].query, want) {
+		t.Errorf("Expected the query to read %s, got %s", want, calls[1].query)
+	}
+}
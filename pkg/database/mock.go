@@ -0,0 +1,476 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockCall is a call received by a Mock. Statements run through ForEachRow, Exec, or GetDB are
+// recorded with Method "Query" or "Exec"; transactions record "Begin", "Commit", and "Rollback".
+type MockCall struct {
+	Method   string
+	Query    string
+	Args     []interface{}
+	TenantID string
+}
+
+// mockResult is the scripted answer to statements containing a substring
+type mockResult struct {
+	match        string
+	columns      []string
+	rows         [][]driver.Value
+	rowsAffected int64
+	err          error
+}
+
+// Mock is an in-memory Database for tests. Statements are answered from scripted results, other
+// methods succeed unless told to fail, and every call is recorded for assertions.
+type Mock struct {
+	mu       sync.Mutex
+	pool     *sql.DB
+	closed   bool
+	tenantID string
+	results  []mockResult
+	failures map[string]error
+	calls    []MockCall
+
+	migrations map[string][]AppliedMigration
+	seeds      map[string]map[string]string
+}
+
+// NewMock creates a connected mock database
+func NewMock() *Mock {
+	m := &Mock{
+		failures:   make(map[string]error),
+		migrations: make(map[string][]AppliedMigration),
+		seeds:      make(map[string]map[string]string),
+	}
+	m.pool = sql.OpenDB(mockConnector{m})
+	return m
+}
+
+// OnQuery scripts the rows returned to queries containing match. The first matching script wins,
+// and queries without one return no rows.
+func (m *Mock) OnQuery(match string, columns []string, rows ...[]interface{}) *Mock {
+	values := make([][]driver.Value, len(rows))
+	for i, row := range rows {
+		values[i] = make([]driver.Value, len(row))
+		for j, v := range row {
+			values[i][j] = v
+		}
+	}
+	return m.script(mockResult{match: match, columns: columns, rows: values})
+}
+
+// OnExec scripts the rows affected by statements containing match
+func (m *Mock) OnExec(match string, rowsAffected int64) *Mock {
+	return m.script(mockResult{match: match, rowsAffected: rowsAffected})
+}
+
+// OnError scripts an error for statements containing match
+func (m *Mock) OnError(match string, err error) *Mock {
+	return m.script(mockResult{match: match, err: err})
+}
+
+// Fail makes calls to a method of the Database interface, such as "HealthCheck", "Migrate", or
+// "SetTenantContext", return err; a nil err makes them succeed again
+func (m *Mock) Fail(method string, err error) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		delete(m.failures, method)
+	} else {
+		m.failures[method] = err
+	}
+	return m
+}
+
+// Calls returns the calls received so far
+func (m *Mock) Calls() []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockCall(nil), m.calls...)
+}
+
+// CallsTo returns the calls received so far to one method
+func (m *Mock) CallsTo(method string) []MockCall {
+	var calls []MockCall
+	for _, call := range m.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// TenantID returns the tenant set by SetTenantContext, empty once it is cleared
+func (m *Mock) TenantID() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.tenantID
+}
+
+// AppliedMigrations returns the migrations applied by Migrate, in the order they were applied
+func (m *Mock) AppliedMigrations(_ context.Context, options ...MigrationOption) ([]AppliedMigration, error) {
+	config := NewMigrationConfig(options...)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]AppliedMigration(nil), m.migrations[config.TableName]...), nil
+}
+
+// MigrationStatus reports migrations against those applied by Migrate
+func (m *Mock) MigrationStatus(ctx context.Context, migrations []Migration,
+	options ...MigrationOption) ([]MigrationStatus, error) {
+	applied, _ := m.AppliedMigrations(ctx, options...)
+	return migrationStatuses(applied, migrations), nil
+}
+
+// Reset forgets recorded calls, applied migrations and seeds, and the tenant; scripts are kept
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+	m.tenantID = ""
+	m.migrations = make(map[string][]AppliedMigration)
+	m.seeds = make(map[string]map[string]string)
+}
+
+// Connect reopens a closed mock
+func (m *Mock) Connect() error {
+	if err := m.record(MockCall{Method: "Connect"}); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = false
+	return nil
+}
+
+// Close closes the mock; statements then fail until it is reconnected
+func (m *Mock) Close() error {
+	if err := m.record(MockCall{Method: "Close"}); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
+
+// Reconnect reopens the mock
+func (m *Mock) Reconnect() error {
+	if err := m.record(MockCall{Method: "Reconnect"}); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = false
+	return nil
+}
+
+// GetDB returns a pool backed by the mock's scripts, or nil once closed
+func (m *Mock) GetDB() *sql.DB {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil
+	}
+	return m.pool
+}
+
+// HealthCheck fails when the mock is closed or told to fail
+func (m *Mock) HealthCheck() error {
+	if err := m.record(MockCall{Method: "HealthCheck"}); err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	if m.GetDB() == nil {
+		return fmt.Errorf("database connection is closed")
+	}
+	return nil
+}
+
+// GetStats reports the mock as connected, or disconnected once closed
+func (m *Mock) GetStats() ConnectionStats {
+	if m.GetDB() == nil {
+		return ConnectionStats{State: StateDisconnected}
+	}
+	return ConnectionStats{OpenConnections: 1, Idle: 1, State: StateConnected}
+}
+
+// ForEachRow runs a query against the scripts and calls fn for each row
+func (m *Mock) ForEachRow(ctx context.Context, query string, args []interface{}, fn RowFunc) error {
+	db := m.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection is closed")
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+	return nil
+}
+
+// Exec runs a statement against the scripts
+func (m *Mock) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db := m.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("statement failed: %w", err)
+	}
+	return result, nil
+}
+
+// SetTenantContext records the tenant, which TenantID then returns
+func (m *Mock) SetTenantContext(_ context.Context, tenantID string) error {
+	if tenantID == "" {
+		return fmt.Errorf("tenant ID cannot be empty")
+	}
+	if err := m.record(MockCall{Method: "SetTenantContext", TenantID: tenantID}); err != nil {
+		return fmt.Errorf("failed to set tenant context: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tenantID = tenantID
+	return nil
+}
+
+// ClearTenantContext clears the tenant
+func (m *Mock) ClearTenantContext(_ context.Context) error {
+	if err := m.record(MockCall{Method: "ClearTenantContext"}); err != nil {
+		return fmt.Errorf("failed to clear tenant context: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tenantID = ""
+	return nil
+}
+
+// Migrate records the pending migrations as applied without running their SQL. Checksums are
+// verified as they are against PostgreSQL, and dry runs apply nothing.
+func (m *Mock) Migrate(ctx context.Context, migrations []Migration, options ...MigrationOption) error {
+	config := NewMigrationConfig(options...)
+
+	if err := m.record(MockCall{Method: "Migrate"}); err != nil {
+		return err
+	}
+	pending, err := m.plan(ctx, migrations, options...)
+	if err != nil {
+		return err
+	}
+	if config.DryRun {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, migration := range pending {
+		m.migrations[config.TableName] = append(m.migrations[config.TableName], AppliedMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Checksum:  migration.Checksum(),
+			AppliedAt: time.Now(),
+		})
+	}
+	return nil
+}
+
+// Plan returns the migrations Migrate would apply
+func (m *Mock) Plan(ctx context.Context, migrations []Migration, options ...MigrationOption) ([]Migration, error) {
+	if err := m.record(MockCall{Method: "Plan"}); err != nil {
+		return nil, err
+	}
+	return m.plan(ctx, migrations, options...)
+}
+
+// plan returns the sorted migrations that have not been applied
+func (m *Mock) plan(ctx context.Context, migrations []Migration, options ...MigrationOption) ([]Migration, error) {
+	sorted, err := sortMigrations(migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, _ := m.AppliedMigrations(ctx, options...)
+	if err := verifyChecksums(applied, sorted); err != nil {
+		return nil, err
+	}
+	return pendingMigrations(applied, sorted), nil
+}
+
+// Seed runs the pending code seeds in transactions against the scripts and records every pending
+// seed as applied; SQL seeds are recorded without running their SQL
+func (m *Mock) Seed(ctx context.Context, seeds []Seed, options ...SeedOption) error {
+	config := NewSeedConfig(options...)
+
+	if err := validateSeeds(seeds); err != nil {
+		return err
+	}
+	if err := m.record(MockCall{Method: "Seed"}); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	applied := m.seeds[config.TableName]
+	if applied == nil {
+		applied = make(map[string]string)
+		m.seeds[config.TableName] = applied
+	}
+	pending, err := pendingSeeds(seeds, applied, config.Environment)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range pending {
+		if s.Func != nil {
+			if err := m.runSeedFunc(ctx, s); err != nil {
+				return err
+			}
+		}
+		m.mu.Lock()
+		applied[s.Name] = s.Checksum()
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// runSeedFunc runs a code seed in a transaction
+func (m *Mock) runSeedFunc(ctx context.Context, s Seed) error {
+	tx, err := m.pool.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin seed %s: %w", s.Name, err)
+	}
+	if err := s.Func(ctx, tx); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("seed %s failed: %w", s.Name, err)
+	}
+	return tx.Commit()
+}
+
+// script adds a scripted result
+func (m *Mock) script(result mockResult) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results = append(m.results, result)
+	return m
+}
+
+// record records a call and returns the failure scripted for its method
+func (m *Mock) record(call MockCall) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, call)
+	return m.failures[call.Method]
+}
+
+// answer records a statement and returns its scripted result
+func (m *Mock) answer(method, query string, args []driver.NamedValue) mockResult {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	if err := m.record(MockCall{Method: method, Query: query, Args: values}); err != nil {
+		return mockResult{err: err}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, result := range m.results {
+		if strings.Contains(query, result.match) {
+			return result
+		}
+	}
+	return mockResult{}
+}
+
+// mockConnector opens connections answered by a Mock
+type mockConnector struct {
+	m *Mock
+}
+
+func (c mockConnector) Connect(context.Context) (driver.Conn, error) { return mockConn(c), nil }
+func (c mockConnector) Driver() driver.Driver                        { return c }
+func (c mockConnector) Open(string) (driver.Conn, error)             { return mockConn(c), nil }
+
+type mockConn struct {
+	m *Mock
+}
+
+func (c mockConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("mock database does not prepare statements")
+}
+func (c mockConn) Close() error { return nil }
+
+func (c mockConn) Begin() (driver.Tx, error) {
+	if err := c.m.record(MockCall{Method: "Begin"}); err != nil {
+		return nil, err
+	}
+	return mockTx(c), nil
+}
+
+func (c mockConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result := c.m.answer("Query", query, args)
+	if result.err != nil {
+		return nil, result.err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &mockRows{columns: result.columns, rows: result.rows}, nil
+}
+
+func (c mockConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result := c.m.answer("Exec", query, args)
+	if result.err != nil {
+		return nil, result.err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(result.rowsAffected), nil
+}
+
+type mockTx struct {
+	m *Mock
+}
+
+func (tx mockTx) Commit() error   { return tx.m.record(MockCall{Method: "Commit"}) }
+func (tx mockTx) Rollback() error { return tx.m.record(MockCall{Method: "Rollback"}) }
+
+type mockRows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *mockRows) Columns() []string { return r.columns }
+func (r *mockRows) Close() error      { return nil }
+
+func (r *mockRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// The mock and PostgreSQL implement every database interface
+var (
+	_ Querier  = (*Mock)(nil)
+	_ Migrator = (*Mock)(nil)
+	_ Querier  = (*PostgreSQL)(nil)
+	_ Migrator = (*PostgreSQL)(nil)
+)
+
+func TestMockQueries(t *testing.T) {
+	var db Querier = NewMock().
+		OnQuery("FROM accounts", []string{"id", "name"}, []interface{}{int64(1), "acme"}, []interface{}{int64(2), "globex"}).
+		OnExec("UPDATE accounts", 2).
+		OnError("DELETE", errors.New("permission denied"))
+	ctx := context.Background()
+
+	accounts, err := Collect(ctx, db, "SELECT id, name FROM accounts WHERE active = $1", []interface{}{true},
+		func(rows *sql.Rows) (string, error) {
+			var id int64
+			var name string
+			err := rows.Scan(&id, &name)
+			return name, err
+		})
+	if err != nil || len(accounts) != 2 || accounts[1] != "globex" {
+		t.Fatalf("Expected the scripted rows, got %v, %v", accounts, err)
+	}
+
+	result, err := db.Exec(ctx, "UPDATE accounts SET active = false")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected != 2 {
+		t.Errorf("Expected 2 rows affected, got %d", affected)
+	}
+
+	if _, err := db.Exec(ctx, "DELETE FROM users"); err == nil {
+		t.Error("Expected the scripted error")
+	}
+
+	calls := db.(*Mock).CallsTo("Query")
+	if len(calls) != 1 || calls[0].Args[0] != true {
+		t.Errorf("Expected the query and its args to be recorded, got %+v", calls)
+	}
+}
+
+func TestMockTenantContext(t *testing.T) {
+	mock := NewMock()
+	ctx := context.Background()
+
+	if err := mock.SetTenantContext(ctx, "acme"); err != nil || mock.TenantID() != "acme" {
+		t.Fatalf("Expected tenant acme, got %q, %v", mock.TenantID(), err)
+	}
+	if err := mock.ClearTenantContext(ctx); err != nil || mock.TenantID() != "" {
+		t.Errorf("Expected the tenant to be cleared, got %q, %v", mock.TenantID(), err)
+	}
+
+	mock.Fail("SetTenantContext", errors.New("rls disabled"))
+	if err := mock.SetTenantContext(ctx, "globex"); err == nil {
+		t.Error("Expected the scripted failure")
+	}
+	if calls := mock.CallsTo("SetTenantContext"); len(calls) != 2 || calls[1].TenantID != "globex" {
+		t.Errorf("Expected both calls to be recorded, got %+v", calls)
+	}
+}
+
+func TestMockHealth(t *testing.T) {
+	mock := NewMock()
+
+	if err := mock.HealthCheck(); err != nil {
+		t.Errorf("Expected a healthy mock, got %v", err)
+	}
+
+	mock.Fail("HealthCheck", errors.New("connection refused"))
+	if err := mock.HealthCheck(); err == nil {
+		t.Error("Expected the scripted failure")
+	}
+	mock.Fail("HealthCheck", nil)
+
+	_ = mock.Close()
+	if err := mock.HealthCheck(); err == nil || mock.GetStats().State != StateDisconnected {
+		t.Error("Expected a closed mock to be unhealthy")
+	}
+	if _, err := mock.Exec(context.Background(), "SELECT 1"); err == nil {
+		t.Error("Expected statements to fail once closed")
+	}
+
+	_ = mock.Reconnect()
+	if err := mock.HealthCheck(); err != nil || mock.GetStats().State != StateConnected {
+		t.Errorf("Expected a reconnected mock to be healthy, got %v", err)
+	}
+}
+
+func TestMockMigrate(t *testing.T) {
+	mock := NewMock()
+	ctx := context.Background()
+	migrations := []Migration{
+		{Version: 2, Name: "add_email", Up: "ALTER TABLE users ADD email TEXT"},
+		{Version: 1, Name: "create_users", Up: "CREATE TABLE users (id BIGINT)"},
+	}
+
+	if err := mock.Migrate(ctx, migrations[1:], WithDryRun(true)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pending, _ := mock.Plan(ctx, migrations); len(pending) != 2 {
+		t.Fatalf("Expected a dry run to apply nothing, got %d pending", len(pending))
+	}
+
+	if err := mock.Migrate(ctx, migrations); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	applied, _ := mock.AppliedMigrations(ctx)
+	if len(applied) != 2 || applied[0].Version != 1 {
+		t.Fatalf("Expected both migrations in version order, got %+v", applied)
+	}
+
+	migrations[0].Up = "ALTER TABLE users ADD email VARCHAR(255)"
+	if err := mock.Migrate(ctx, migrations); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+
+	mock.Fail("Migrate", errors.New("lock timeout"))
+	if err := mock.Migrate(ctx, migrations); err == nil || err.Error() != "lock timeout" {
+		t.Errorf("Expected the scripted failure, got %v", err)
+	}
+}
+
+func TestMockSeed(t *testing.T) {
+	mock := NewMock()
+	ctx := context.Background()
+	runs := 0
+	seeds := []Seed{
+		{Name: "plans", SQL: "INSERT INTO plans VALUES ('free')"},
+		{Name: "demo", Environments: []string{"development"}, Func: func(ctx context.Context, tx *sql.Tx) error {
+			runs++
+			_, err := tx.ExecContext(ctx, "INSERT INTO users VALUES ('demo')")
+			return err
+		}},
+	}
+
+	for range 2 {
+		if err := mock.Seed(ctx, seeds, WithSeedEnvironment("development")); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if runs != 1 {
+		t.Errorf("Expected the code seed to run once, ran %d times", runs)
+	}
+	if len(mock.CallsTo("Commit")) != 1 || len(mock.CallsTo("Exec")) != 1 {
+		t.Errorf("Expected the code seed to run in a transaction, got %+v", mock.Calls())
+	}
+
+	mock.Reset()
+	if len(mock.Calls()) != 0 {
+		t.Errorf("Expected Reset to forget calls")
+	}
+}
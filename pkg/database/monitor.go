@@ -0,0 +1,212 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnectionState describes the health of the connection pool
+type ConnectionState int
+
+const (
+	// StateDisconnected means there is no usable pool
+	StateDisconnected ConnectionState = iota
+	// StateConnected means the last health check succeeded
+	StateConnected
+	// StateDegraded means recent health checks failed but the reconnect threshold has not been reached
+	StateDegraded
+	// StateReconnecting means a new pool is being established
+	StateReconnecting
+)
+
+// String returns the name of the state
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateDegraded:
+		return "degraded"
+	case StateReconnecting:
+		return "reconnecting"
+	default:
+		return "disconnected"
+	}
+}
+
+// StateChangeFunc is called on every connection state transition.
+// err is the failure that caused the transition, if any.
+type StateChangeFunc func(from, to ConnectionState, err error)
+
+// monitorState holds health monitor and reconnect state for a PostgreSQL instance
+type monitorState struct {
+	mu     sync.Mutex
+	state  ConnectionState
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	reconnectMu sync.Mutex
+	failures    atomic.Int64
+	reconnects  atomic.Int64
+}
+
+// State returns the current connection state
+func (p *PostgreSQL) State() ConnectionState {
+	p.monitor.mu.Lock()
+	defer p.monitor.mu.Unlock()
+	return p.monitor.state
+}
+
+// Reconnect replaces the connection pool with a freshly established one. Queries already
+// running on the old pool are allowed to finish before it is closed, so callers should
+// fetch the pool with GetDB per operation rather than caching it.
+func (p *PostgreSQL) Reconnect() error {
+	return p.reconnect(context.Background())
+}
+
+// reconnect opens a new pool and swaps it in, draining the old pool in the background
+func (p *PostgreSQL) reconnect(ctx context.Context) error {
+	p.monitor.reconnectMu.Lock()
+	defer p.monitor.reconnectMu.Unlock()
+
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return fmt.Errorf("database connection is closed")
+	}
+
+	p.setState(StateReconnecting, nil)
+
+	db, err := p.openPool(ctx)
+	if err != nil {
+		p.setState(StateDisconnected, err)
+		return fmt.Errorf("failed to reconnect: %w", err)
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		db.Close()
+		return fmt.Errorf("database connection is closed")
+	}
+	old := p.db
+	p.db = db
+	p.mu.Unlock()
+
+	p.monitor.failures.Store(0)
+	p.monitor.reconnects.Add(1)
+	p.setState(StateConnected, nil)
+	log.Printf("### 🗄️ Database: Reconnected to PostgreSQL at %s:%d/%s",
+		p.config.Host, p.config.Port, p.config.Database)
+
+	if old != nil {
+		go drainPool(old)
+	}
+
+	return nil
+}
+
+// drainPool closes a replaced pool; Close waits for queries already in progress to finish
+func drainPool(db *sql.DB) {
+	if err := db.Close(); err != nil {
+		log.Printf("### 🗄️ Database: Failed to close previous pool: %v", err)
+	}
+}
+
+// setState records a state transition and notifies the state change handler
+func (p *PostgreSQL) setState(to ConnectionState, err error) {
+	p.monitor.mu.Lock()
+	from := p.monitor.state
+	p.monitor.state = to
+	p.monitor.mu.Unlock()
+
+	if from == to {
+		return
+	}
+
+	if err != nil {
+		log.Printf("### 🗄️ Database: State %s -> %s: %v", from, to, err)
+	} else {
+		log.Printf("### 🗄️ Database: State %s -> %s", from, to)
+	}
+
+	if p.config.OnStateChange != nil {
+		p.config.OnStateChange(from, to, err)
+	}
+}
+
+// startMonitor launches the background health monitor if it is enabled and not running
+func (p *PostgreSQL) startMonitor() {
+	if p.config.HealthCheckInterval <= 0 {
+		return
+	}
+
+	p.monitor.mu.Lock()
+	defer p.monitor.mu.Unlock()
+
+	if p.monitor.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	p.monitor.cancel = cancel
+	p.monitor.done = done
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(p.config.HealthCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.checkHealth(ctx)
+			}
+		}
+	}()
+}
+
+// stopMonitor stops the background health monitor and waits for it to exit
+func (p *PostgreSQL) stopMonitor() {
+	p.monitor.mu.Lock()
+	cancel, done := p.monitor.cancel, p.monitor.done
+	p.monitor.cancel, p.monitor.done = nil, nil
+	p.monitor.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// checkHealth runs one monitor iteration, reconnecting once the failure threshold is reached
+func (p *PostgreSQL) checkHealth(ctx context.Context) {
+	err := p.HealthCheck()
+	if err == nil {
+		p.monitor.failures.Store(0)
+		p.setState(StateConnected, nil)
+		return
+	}
+
+	failures := p.monitor.failures.Add(1)
+	if p.State() == StateConnected {
+		p.setState(StateDegraded, err)
+	}
+
+	if failures < int64(p.config.ReconnectThreshold) {
+		return
+	}
+
+	if err := p.reconnect(ctx); err != nil {
+		log.Printf("### 🗄️ Database: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+)
+
+// unreachableConfig points at a port where nothing listens so pings fail fast
+func unreachableConfig(options ...Option) *Config {
+	options = append([]Option{
+		WithHost("127.0.0.1"),
+		WithPort(1),
+		WithSSLMode("disable"),
+		WithConnectTimeout(time.Second),
+		WithQueryTimeout(time.Second),
+	}, options...)
+	return NewConfig(options...)
+}
+
+type transitionRecorder struct {
+	mu          sync.Mutex
+	transitions []string
+}
+
+func (r *transitionRecorder) record(from, to ConnectionState, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transitions = append(r.transitions, from.String()+"->"+to.String())
+}
+
+func (r *transitionRecorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.transitions...)
+}
+
+func TestConnectionStateString(t *testing.T) {
+	tests := []struct {
+		state    ConnectionState
+		expected string
+	}{
+		{StateDisconnected, "disconnected"},
+		{StateConnected, "connected"},
+		{StateDegraded, "degraded"},
+		{StateReconnecting, "reconnecting"},
+	}
+
+	for _, tt := range tests {
+		if tt.state.String() != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, tt.state.String())
+		}
+	}
+}
+
+func TestHealthMonitorConfig(t *testing.T) {
+	config := DefaultConfig()
+	if config.HealthCheckInterval != 0 {
+		t.Error("Expected health monitor to be disabled by default")
+	}
+	if config.ReconnectThreshold != 3 {
+		t.Errorf("Expected default reconnect threshold 3, got %d", config.ReconnectThreshold)
+	}
+
+	called := false
+	config = NewConfig(
+		WithHealthMonitor(5*time.Second, 2),
+		WithStateChangeHandler(func(from, to ConnectionState, err error) { called = true }),
+	)
+	if config.HealthCheckInterval != 5*time.Second || config.ReconnectThreshold != 2 {
+		t.Errorf("Unexpected monitor config: %v, %d", config.HealthCheckInterval, config.ReconnectThreshold)
+	}
+	config.OnStateChange(StateConnected, StateDegraded, nil)
+	if !called {
+		t.Error("Expected state change handler to be set")
+	}
+}
+
+func TestCheckHealthReconnectsAfterThreshold(t *testing.T) {
+	recorder := &transitionRecorder{}
+	p := NewPostgreSQL(unreachableConfig(WithHealthMonitor(time.Hour, 3), WithStateChangeHandler(recorder.record)))
+
+	db, err := sql.Open("postgres", p.buildDSN())
+	if err != nil {
+		t.Fatalf("Failed to open pool: %v", err)
+	}
+	p.db = db
+	p.monitor.state = StateConnected
+
+	for i := 0; i < 3; i++ {
+		p.checkHealth(context.Background())
+	}
+
+	expected := []string{"connected->degraded", "degraded->reconnecting", "reconnecting->disconnected"}
+	got := recorder.list()
+	if len(got) != len(expected) {
+		t.Fatalf("Expected transitions %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected transition %s, got %s", expected[i], got[i])
+		}
+	}
+
+	stats := p.GetStats()
+	if stats.State != StateDisconnected {
+		t.Errorf("Expected state disconnected, got %s", stats.State)
+	}
+	if stats.ConsecutiveFailures != 3 {
+		t.Errorf("Expected 3 consecutive failures, got %d", stats.ConsecutiveFailures)
+	}
+	if stats.Reconnects != 0 {
+		t.Errorf("Expected no successful reconnects, got %d", stats.Reconnects)
+	}
+}
+
+func TestReconnectClosed(t *testing.T) {
+	p := NewPostgreSQL(unreachableConfig())
+	p.closed = true
+
+	if err := p.Reconnect(); err == nil {
+		t.Error("Expected error reconnecting a closed database")
+	}
+}
+
+func TestMonitorStartStop(t *testing.T) {
+	p := NewPostgreSQL(unreachableConfig(WithHealthMonitor(10*time.Millisecond, 100)))
+
+	db, err := sql.Open("postgres", p.buildDSN())
+	if err != nil {
+		t.Fatalf("Failed to open pool: %v", err)
+	}
+	p.db = db
+
+	p.startMonitor()
+	p.startMonitor() // second start is a no-op
+
+	deadline := time.Now().Add(5 * time.Second)
+	for p.GetStats().ConsecutiveFailures == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if p.GetStats().ConsecutiveFailures == 0 {
+		t.Error("Expected monitor to record failures")
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Unexpected close error: %v", err)
+	}
+	if p.State() != StateDisconnected {
+		t.Errorf("Expected disconnected after close, got %s", p.State())
+	}
+
+	failures := p.GetStats().ConsecutiveFailures
+	time.Sleep(50 * time.Millisecond)
+	if p.GetStats().ConsecutiveFailures != failures {
+		t.Error("Expected monitor to stop after close")
+	}
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Named rewrites a query with :name parameters into PostgreSQL's numbered placeholders and returns
+// the matching args. params is a map[string]interface{} or a struct, whose fields are named by
+// their db tags. A parameter used twice shares one placeholder, and slices expand into a
+// placeholder list for IN clauses:
+//
+//	Named("SELECT * FROM users WHERE org = :org AND id IN (:ids)", map[string]interface{}{
+//		"org": "acme", "ids": []int{1, 2},
+//	})
+//	// SELECT * FROM users WHERE org = $1 AND id IN ($2, $3)
+//
+// Type casts (::text), quoted strings, and quoted identifiers are left alone.
+func Named(query string, params interface{}) (string, []interface{}, error) {
+	values, err := paramsOf(params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	n := &namedQuery{values: values, placeholders: make(map[string]string)}
+	if err := n.rewrite(query); err != nil {
+		return "", nil, err
+	}
+	return n.sb.String(), n.args, nil
+}
+
+// namedQuery accumulates a rewritten query and its args
+type namedQuery struct {
+	values       map[string]interface{}
+	placeholders map[string]string
+	sb           strings.Builder
+	args         []interface{}
+}
+
+// rewrite copies query, replacing each :name with its placeholders
+func (n *namedQuery) rewrite(query string) error {
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := quotedEnd(query, i)
+			n.sb.WriteString(query[i:end])
+			i = end - 1
+		case strings.HasPrefix(query[i:], "::"):
+			n.sb.WriteString("::")
+			i++
+		case c == ':' && nameEnd(query, i+1) > i+1:
+			end := nameEnd(query, i+1)
+			if err := n.bind(query[i+1 : end]); err != nil {
+				return err
+			}
+			i = end - 1
+		default:
+			n.sb.WriteByte(c)
+		}
+	}
+	return nil
+}
+
+// bind writes the placeholders for a parameter, adding its value to the args the first time
+func (n *namedQuery) bind(name string) error {
+	if placeholder, ok := n.placeholders[name]; ok {
+		n.sb.WriteString(placeholder)
+		return nil
+	}
+
+	value, ok := n.values[name]
+	if !ok {
+		return fmt.Errorf("missing value for parameter :%s", name)
+	}
+
+	items, isList := listItems(value)
+	if !isList {
+		items = []interface{}{value}
+	} else if len(items) == 0 {
+		return fmt.Errorf("parameter :%s is an empty list", name)
+	}
+
+	placeholders := make([]string, len(items))
+	for i, item := range items {
+		n.args = append(n.args, item)
+		placeholders[i] = "$" + strconv.Itoa(len(n.args))
+	}
+
+	placeholder := strings.Join(placeholders, ", ")
+	n.placeholders[name] = placeholder
+	n.sb.WriteString(placeholder)
+	return nil
+}
+
+// quotedEnd returns the index after the quoted string or identifier starting at start, treating a
+// doubled quote as an escaped one
+func quotedEnd(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		if query[i] != quote {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(query)
+}
+
+// nameEnd returns the index after the parameter name starting at start, or start if there is none
+func nameEnd(query string, start int) int {
+	end := start
+	for end < len(query) {
+		c := query[end]
+		isLetter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !isLetter && (end == start || c < '0' || c > '9') {
+			break
+		}
+		end++
+	}
+	return end
+}
+
+// listItems returns the elements of a slice or array to expand into a placeholder list. Byte
+// slices and values that encode themselves, such as pq.Array, are single values.
+func listItems(value interface{}) ([]interface{}, bool) {
+	if _, ok := value.(driver.Valuer); ok {
+		return nil, false
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, true
+}
+
+// paramsOf returns named parameter values from a map or a struct with db tags
+func paramsOf(params interface{}) (map[string]interface{}, error) {
+	if values, ok := params.(map[string]interface{}); ok {
+		return values, nil
+	}
+
+	v := reflect.ValueOf(params)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("named parameters must be a map[string]interface{} or a struct, got %T", params)
+	}
+
+	values := make(map[string]interface{})
+	for _, f := range fieldsOf(v.Type()) {
+		values[f.column] = v.FieldByIndex(f.index).Interface()
+	}
+	return values, nil
+}
+
+// QueryNamed runs a query with :name parameters through ForEachRow
+func (p *PostgreSQL) QueryNamed(ctx context.Context, query string, params interface{}, fn RowFunc) error {
+	query, args, err := Named(query, params)
+	if err != nil {
+		return err
+	}
+	return p.ForEachRow(ctx, query, args, fn)
+}
+
+// ExecNamed runs a statement with :name parameters, with the configured QueryTimeout
+func (p *PostgreSQL) ExecNamed(ctx context.Context, query string, params interface{}) (sql.Result, error) {
+	query, args, err := Named(query, params)
+	if err != nil {
+		return nil, err
+	}
+	return p.Exec(ctx, query, args...)
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestNamed(t *testing.T) {
+	type filter struct {
+		Org    string `db:"org"`
+		Status string
+		Skip   string `db:"-"`
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		params    interface{}
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "map",
+			query:     "SELECT * FROM users WHERE org = :org AND name = :name",
+			params:    map[string]interface{}{"org": "acme", "name": "Ann"},
+			wantQuery: "SELECT * FROM users WHERE org = $1 AND name = $2",
+			wantArgs:  []interface{}{"acme", "Ann"},
+		},
+		{
+			name:      "repeated parameter",
+			query:     "SELECT * FROM users WHERE org = :org OR parent_org = :org",
+			params:    map[string]interface{}{"org": "acme"},
+			wantQuery: "SELECT * FROM users WHERE org = $1 OR parent_org = $1",
+			wantArgs:  []interface{}{"acme"},
+		},
+		{
+			name:      "IN expansion",
+			query:     "SELECT * FROM users WHERE id IN (:ids) AND org = :org",
+			params:    map[string]interface{}{"ids": []int{4, 5, 6}, "org": "acme"},
+			wantQuery: "SELECT * FROM users WHERE id IN ($1, $2, $3) AND org = $4",
+			wantArgs:  []interface{}{4, 5, 6, "acme"},
+		},
+		{
+			name:      "casts, strings, and identifiers",
+			query:     `SELECT ':skip', "a:b", created_at::date FROM users WHERE org = :org`,
+			params:    map[string]interface{}{"org": "acme"},
+			wantQuery: `SELECT ':skip', "a:b", created_at::date FROM users WHERE org = $1`,
+			wantArgs:  []interface{}{"acme"},
+		},
+		{
+			name:      "struct",
+			query:     "SELECT * FROM users WHERE org = :org AND status = :status",
+			params:    filter{Org: "acme", Status: "active"},
+			wantQuery: "SELECT * FROM users WHERE org = $1 AND status = $2",
+			wantArgs:  []interface{}{"acme", "active"},
+		},
+		{
+			name:      "bytes and arrays are single values",
+			query:     "UPDATE files SET data = :data WHERE tag = ANY(:tags)",
+			params:    map[string]interface{}{"data": []byte("x"), "tags": pq.StringArray{"a", "b"}},
+			wantQuery: "UPDATE files SET data = $1 WHERE tag = ANY($2)",
+			wantArgs:  []interface{}{[]byte("x"), pq.StringArray{"a", "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := Named(tt.query, tt.params)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if query != tt.wantQuery {
+				t.Errorf("Expected query %q, got %q", tt.wantQuery, query)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
+
+func TestNamedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		params interface{}
+	}{
+		{"missing parameter", "SELECT * FROM users WHERE org = :org", map[string]interface{}{}},
+		{"empty list", "SELECT * FROM users WHERE id IN (:ids)", map[string]interface{}{"ids": []int{}}},
+		{"unsupported params", "SELECT 1", 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Named(tt.query, tt.params); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestQueryNamedAndExecNamed(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("FROM users", []string{"id", "name"}, []driver.Value{int64(1), "Ann"})
+
+	var names []string
+	err := p.QueryNamed(context.Background(), "SELECT id, name FROM users WHERE id IN (:ids)",
+		map[string]interface{}{"ids": []int64{1, 2}}, func(rows *sql.Rows) error {
+			u, err := ScanInto[testUser](rows)
+			names = append(names, u.Name)
+			return err
+		})
+	if err != nil || len(names) != 1 || names[0] != "Ann" {
+		t.Fatalf("Unexpected result %v, %v", names, err)
+	}
+
+	if _, err := p.ExecNamed(context.Background(), "DELETE FROM users WHERE id = :id",
+		map[string]interface{}{"id": int64(3)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := fd.recorded()
+	if len(calls) != 2 || calls[0].query != "SELECT id, name FROM users WHERE id IN ($1, $2)" ||
+		calls[1].query != "DELETE FROM users WHERE id = $1" || calls[1].args[0] != int64(3) {
+		t.Errorf("Unexpected statements %+v", calls)
+	}
+}
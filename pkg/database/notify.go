@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Notification is a message received on a PostgreSQL LISTEN channel
+type Notification struct {
+	Channel    string    `json:"channel"`
+	Payload    string    `json:"payload"`
+	ReceivedAt time.Time `json:"receivedAt"`
+}
+
+// Notifier defines the contract for LISTEN/NOTIFY based event propagation
+type Notifier interface {
+	Subscribe(ctx context.Context, channel string) (<-chan Notification, error)
+	Notify(ctx context.Context, channel, payload string) error
+}
+
+// Subscribe listens on the given channel and delivers notifications until ctx is cancelled.
+// A dedicated connection is used, which is re-established with exponential backoff
+// (between ListenMinReconnectInterval and ListenMaxReconnectInterval) if it drops.
+// Notifications sent while the connection is down are lost, so consumers should treat
+// them as hints and re-read state where correctness matters.
+func (p *PostgreSQL) Subscribe(ctx context.Context, channel string) (<-chan Notification, error) {
+	p.mu.RLock()
+	closed := p.closed || p.db == nil
+	p.mu.RUnlock()
+
+	if closed {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+
+	if channel == "" {
+		return nil, fmt.Errorf("channel cannot be empty")
+	}
+
+	listener := pq.NewListener(p.buildDSN(),
+		p.config.ListenMinReconnectInterval, p.config.ListenMaxReconnectInterval,
+		func(event pq.ListenerEventType, err error) {
+			logListenerEvent(channel, event, err)
+		})
+
+	// Listen blocks until the listener is connected, so honour ctx while waiting
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- listener.Listen(channel)
+	}()
+
+	select {
+	case err := <-listenErr:
+		if err != nil {
+			_ = listener.Close()
+			return nil, fmt.Errorf("failed to listen on channel %s: %w", channel, err)
+		}
+	case <-ctx.Done():
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to listen on channel %s: %w", channel, ctx.Err())
+	}
+
+	out := make(chan Notification, p.config.NotificationBufferSize)
+	go forwardNotifications(ctx, listener, out)
+
+	log.Printf("### 🗄️ Database: Listening for notifications on channel %s", channel)
+	return out, nil
+}
+
+// Notify sends a notification with the given payload on a channel
+func (p *PostgreSQL) Notify(ctx context.Context, channel, payload string) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed || p.db == nil {
+		return fmt.Errorf("database connection is closed")
+	}
+
+	if channel == "" {
+		return fmt.Errorf("channel cannot be empty")
+	}
+
+	if _, err := p.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, channel, payload); err != nil {
+		return fmt.Errorf("failed to notify channel %s: %w", channel, err)
+	}
+
+	return nil
+}
+
+// forwardNotifications copies notifications from the listener until ctx is cancelled
+func forwardNotifications(ctx context.Context, listener *pq.Listener, out chan<- Notification) {
+	defer close(out)
+	defer listener.Close()
+
+	// Ping periodically so dead connections are detected even on quiet channels
+	ticker := time.NewTicker(90 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			// A nil notification signals a reconnect, there is nothing to deliver
+			if n == nil {
+				continue
+			}
+
+			select {
+			case out <- Notification{Channel: n.Channel, Payload: n.Extra, ReceivedAt: time.Now()}:
+			case <-ctx.Done():
+				return
+			}
+		case <-ticker.C:
+			go func() {
+				_ = listener.Ping()
+			}()
+		}
+	}
+}
+
+// logListenerEvent logs connection state changes of a listener
+func logListenerEvent(channel string, event pq.ListenerEventType, err error) {
+	switch event {
+	case pq.ListenerEventDisconnected:
+		log.Printf("### 🗄️ Database: Listener on %s disconnected: %v", channel, err)
+	case pq.ListenerEventReconnected:
+		log.Printf("### 🗄️ Database: Listener on %s reconnected", channel)
+	case pq.ListenerEventConnectionAttemptFailed:
+		log.Printf("### 🗄️ Database: Listener on %s failed to connect: %v", channel, err)
+	}
+}
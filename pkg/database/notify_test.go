@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPostgreSQLImplementsNotifier(t *testing.T) {
+	var _ Notifier = (*PostgreSQL)(nil)
+}
+
+func TestNotifyConfigDefaults(t *testing.T) {
+	config := DefaultConfig()
+
+	if config.ListenMinReconnectInterval != time.Second {
+		t.Errorf("Expected ListenMinReconnectInterval 1s, got %v", config.ListenMinReconnectInterval)
+	}
+
+	if config.ListenMaxReconnectInterval != time.Minute {
+		t.Errorf("Expected ListenMaxReconnectInterval 1m, got %v", config.ListenMaxReconnectInterval)
+	}
+
+	if config.NotificationBufferSize != 64 {
+		t.Errorf("Expected NotificationBufferSize 64, got %d", config.NotificationBufferSize)
+	}
+}
+
+func TestNotifyConfigOptions(t *testing.T) {
+	config := NewConfig(
+		WithListenReconnectInterval(2*time.Second, 30*time.Second),
+		WithNotificationBufferSize(8),
+	)
+
+	if config.ListenMinReconnectInterval != 2*time.Second {
+		t.Errorf("Expected ListenMinReconnectInterval 2s, got %v", config.ListenMinReconnectInterval)
+	}
+
+	if config.ListenMaxReconnectInterval != 30*time.Second {
+		t.Errorf("Expected ListenMaxReconnectInterval 30s, got %v", config.ListenMaxReconnectInterval)
+	}
+
+	if config.NotificationBufferSize != 8 {
+		t.Errorf("Expected NotificationBufferSize 8, got %d", config.NotificationBufferSize)
+	}
+}
+
+func TestPostgreSQLSubscribe(t *testing.T) {
+	db := NewPostgreSQL(DefaultConfig())
+
+	// Test when db is nil
+	ch, err := db.Subscribe(context.Background(), "events")
+	if err == nil {
+		t.Error("Expected error when db is nil")
+	}
+
+	if ch != nil {
+		t.Error("Expected nil channel on error")
+	}
+}
+
+func TestPostgreSQLNotify(t *testing.T) {
+	db := NewPostgreSQL(DefaultConfig())
+
+	// Test when db is nil
+	if err := db.Notify(context.Background(), "events", "payload"); err == nil {
+		t.Error("Expected error when db is nil")
+	}
+
+	// Test when closed
+	db.closed = true
+	if err := db.Notify(context.Background(), "events", "payload"); err == nil {
+		t.Error("Expected error when db is closed")
+	}
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Paginate appends LIMIT and OFFSET to a query, numbering the placeholders after the existing args.
+// The result can be passed straight to QueryContext.
+func Paginate(query string, limit, offset int, args ...interface{}) (string, []interface{}) {
+	n := len(args)
+	query = fmt.Sprintf("%s LIMIT $%d OFFSET $%d", strings.TrimSpace(query), n+1, n+2)
+	return query, append(args, limit, offset)
+}
+
+// Keyset describes a page of keyset (seek) pagination. Unlike OFFSET, the cost of fetching a
+// page does not grow with its depth.
+type Keyset struct {
+	// Columns the results are ordered by; together they must be unique, e.g. created_at, id
+	Columns []string
+	// After holds the column values of the last row of the previous page; empty for the first page
+	After []interface{}
+	// Desc orders all columns descending
+	Desc bool
+	// Limit is the number of rows to fetch; fetch one more than the page size to detect a next page
+	Limit int
+}
+
+// Apply appends the keyset condition, ORDER BY, and LIMIT to a query that has neither ORDER BY nor
+// LIMIT. If the query has a top-level WHERE clause the condition is joined with AND.
+func (k Keyset) Apply(query string, args ...interface{}) (string, []interface{}, error) {
+	if len(k.Columns) == 0 {
+		return "", nil, fmt.Errorf("keyset pagination requires at least one column")
+	}
+	if len(k.After) != 0 && len(k.After) != len(k.Columns) {
+		return "", nil, fmt.Errorf("keyset has %d column(s) but %d cursor value(s)", len(k.Columns), len(k.After))
+	}
+
+	columns := make([]string, len(k.Columns))
+	for i, column := range k.Columns {
+		columns[i] = quoteColumn(column)
+	}
+
+	direction, op := "ASC", ">"
+	if k.Desc {
+		direction, op = "DESC", "<"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSpace(query))
+
+	if len(k.After) > 0 {
+		placeholders := make([]string, len(k.After))
+		for i := range k.After {
+			placeholders[i] = fmt.Sprintf("$%d", len(args)+i+1)
+		}
+		args = append(args, k.After...)
+
+		keyword := " WHERE "
+		if hasWhere(query) {
+			keyword = " AND "
+		}
+		fmt.Fprintf(&sb, "%s(%s) %s (%s)", keyword, strings.Join(columns, ", "), op, strings.Join(placeholders, ", "))
+	}
+
+	ordered := make([]string, len(columns))
+	for i, column := range columns {
+		ordered[i] = column + " " + direction
+	}
+	fmt.Fprintf(&sb, " ORDER BY %s LIMIT $%d", strings.Join(ordered, ", "), len(args)+1)
+
+	return sb.String(), append(args, k.Limit), nil
+}
+
+// quoteColumn quotes each part of a possibly table-qualified column name
+func quoteColumn(column string) string {
+	parts := strings.Split(column, ".")
+	for i, part := range parts {
+		parts[i] = pq.QuoteIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// hasWhere reports whether a query has a WHERE keyword outside parentheses and quotes
+func hasWhere(query string) bool {
+	return whereIndex(query) >= 0
+}
+
+// whereIndex returns the position of the first WHERE keyword outside parentheses and quotes, or -1
+func whereIndex(query string) int {
+	depth := 0
+	inQuote := false
+	upper := strings.ToUpper(query)
+
+	for i := 0; i < len(upper); i++ {
+		switch c := upper[i]; {
+		case c == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(upper[i:], "WHERE") && isWordBoundary(upper, i, i+5):
+			return i
+		}
+	}
+
+	return -1
+}
+
+func isWordBoundary(s string, start, end int) bool {
+	isWord := func(c byte) bool {
+		return c == '_' || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+	}
+	return (start == 0 || !isWord(s[start-1])) && (end >= len(s) || !isWord(s[end]))
+}
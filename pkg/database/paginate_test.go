@@ -0,0 +1,89 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	query, args := Paginate("SELECT * FROM items WHERE owner = $1", 20, 40, "alice")
+
+	if query != "SELECT * FROM items WHERE owner = $1 LIMIT $2 OFFSET $3" {
+		t.Errorf("Unexpected query: %s", query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"alice", 20, 40}) {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
+
+func TestKeysetApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		args      []interface{}
+		keyset    Keyset
+		wantQuery string
+		wantArgs  []interface{}
+		wantErr   bool
+	}{
+		{
+			name:      "first page",
+			query:     "SELECT * FROM items",
+			keyset:    Keyset{Columns: []string{"created_at", "id"}, Limit: 11},
+			wantQuery: `SELECT * FROM items ORDER BY "created_at" ASC, "id" ASC LIMIT $1`,
+			wantArgs:  []interface{}{11},
+		},
+		{
+			name:      "next page",
+			query:     "SELECT * FROM items",
+			keyset:    Keyset{Columns: []string{"created_at", "id"}, After: []interface{}{"2024-01-01", 7}, Limit: 11},
+			wantQuery: `SELECT * FROM items WHERE ("created_at", "id") > ($1, $2) ORDER BY "created_at" ASC, "id" ASC LIMIT $3`,
+			wantArgs:  []interface{}{"2024-01-01", 7, 11},
+		},
+		{
+			name:      "existing where and descending",
+			query:     "SELECT * FROM items i WHERE i.owner = $1",
+			args:      []interface{}{"alice"},
+			keyset:    Keyset{Columns: []string{"i.id"}, After: []interface{}{7}, Desc: true, Limit: 5},
+			wantQuery: `SELECT * FROM items i WHERE i.owner = $1 AND ("i"."id") < ($2) ORDER BY "i"."id" DESC LIMIT $3`,
+			wantArgs:  []interface{}{"alice", 7, 5},
+		},
+		{
+			name:      "where only in subquery",
+			query:     "SELECT * FROM (SELECT * FROM items WHERE archived) t",
+			keyset:    Keyset{Columns: []string{"id"}, After: []interface{}{7}, Limit: 5},
+			wantQuery: `SELECT * FROM (SELECT * FROM items WHERE archived) t WHERE ("id") > ($1) ORDER BY "id" ASC LIMIT $2`,
+			wantArgs:  []interface{}{7, 5},
+		},
+		{
+			name:    "no columns",
+			query:   "SELECT * FROM items",
+			keyset:  Keyset{Limit: 5},
+			wantErr: true,
+		},
+		{
+			name:    "cursor mismatch",
+			query:   "SELECT * FROM items",
+			keyset:  Keyset{Columns: []string{"created_at", "id"}, After: []interface{}{7}, Limit: 5},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := tt.keyset.Apply(tt.query, tt.args...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if query != tt.wantQuery {
+				t.Errorf("Unexpected query:\n%s\nwant:\n%s", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Unexpected args: %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/tenant"
+)
+
+// WithTenant returns a context whose queries are counted in tenantID's query stats, as tenant.WithID
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return tenant.WithID(ctx, tenantID)
+}
+
+// TenantFromContext returns the tenant ID set with WithTenant, tenant.WithID, or api.TenantMiddleware
+func TenantFromContext(ctx context.Context) string {
+	return tenant.ID(ctx)
+}
+
+// TenantQueryStats summarises the statements run for a tenant
+type TenantQueryStats struct {
+	TenantID      string
+	Queries       int64
+	Errors        int64
+	SlowQueries   int64
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+	LastQueryAt   time.Time
+}
+
+// AverageDuration returns the mean statement duration
+func (s TenantQueryStats) AverageDuration() time.Duration {
+	if s.Queries == 0 {
+		return 0
+	}
+	return s.TotalDuration / time.Duration(s.Queries)
+}
+
+// tenantQueryStatsJSON is the wire form of TenantQueryStats, with durations in milliseconds
+type tenantQueryStatsJSON struct {
+	TenantID        string    `json:"tenantID"`
+	Queries         int64     `json:"queries"`
+	Errors          int64     `json:"errors"`
+	SlowQueries     int64     `json:"slowQueries"`
+	TotalDurationMs float64   `json:"totalDurationMs"`
+	AvgDurationMs   float64   `json:"avgDurationMs"`
+	MaxDurationMs   float64   `json:"maxDurationMs"`
+	LastQueryAt     time.Time `json:"lastQueryAt"`
+}
+
+// MarshalJSON encodes the stats with durations in milliseconds
+func (s TenantQueryStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tenantQueryStatsJSON{
+		TenantID:        s.TenantID,
+		Queries:         s.Queries,
+		Errors:          s.Errors,
+		SlowQueries:     s.SlowQueries,
+		TotalDurationMs: milliseconds(s.TotalDuration),
+		AvgDurationMs:   milliseconds(s.AverageDuration()),
+		MaxDurationMs:   milliseconds(s.MaxDuration),
+		LastQueryAt:     s.LastQueryAt,
+	})
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// queryStats holds the per-tenant query stats of a PostgreSQL instance
+type queryStats struct {
+	mu      sync.Mutex
+	tenants map[string]*TenantQueryStats
+}
+
+// record adds a statement to a tenant's stats
+func (q *queryStats) record(tenantID string, elapsed, slowThreshold time.Duration, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.tenants == nil {
+		q.tenants = make(map[string]*TenantQueryStats)
+	}
+	stats, ok := q.tenants[tenantID]
+	if !ok {
+		stats = &TenantQueryStats{TenantID: tenantID}
+		q.tenants[tenantID] = stats
+	}
+
+	stats.Queries++
+	stats.TotalDuration += elapsed
+	stats.MaxDuration = max(stats.MaxDuration, elapsed)
+	stats.LastQueryAt = time.Now()
+	if err != nil {
+		stats.Errors++
+	}
+	if slowThreshold > 0 && elapsed >= slowThreshold {
+		stats.SlowQueries++
+	}
+}
+
+// recordQuery counts a statement in the stats of the tenant set on ctx with WithTenant. Statements
+// without a tenant are not counted.
+func (p *PostgreSQL) recordQuery(ctx context.Context, elapsed time.Duration, err error) {
+	tenantID := TenantFromContext(ctx)
+	if tenantID == "" {
+		return
+	}
+	p.queryStats.record(tenantID, elapsed, p.config.SlowQueryThreshold, err)
+}
+
+// GetTenantQueryStats returns the query stats of one tenant
+func (p *PostgreSQL) GetTenantQueryStats(tenantID string) (TenantQueryStats, bool) {
+	p.queryStats.mu.Lock()
+	defer p.queryStats.mu.Unlock()
+
+	stats, ok := p.queryStats.tenants[tenantID]
+	if !ok {
+		return TenantQueryStats{TenantID: tenantID}, false
+	}
+	return *stats, true
+}
+
+// GetAllTenantQueryStats returns a snapshot of the query stats of every tenant, keyed by tenant ID
+func (p *PostgreSQL) GetAllTenantQueryStats() map[string]TenantQueryStats {
+	p.queryStats.mu.Lock()
+	defer p.queryStats.mu.Unlock()
+
+	snapshot := make(map[string]TenantQueryStats, len(p.queryStats.tenants))
+	for tenantID, stats := range p.queryStats.tenants {
+		snapshot[tenantID] = *stats
+	}
+	return snapshot
+}
+
+// ResetTenantQueryStats discards the query stats of a tenant
+func (p *PostgreSQL) ResetTenantQueryStats(tenantID string) {
+	p.queryStats.mu.Lock()
+	defer p.queryStats.mu.Unlock()
+	delete(p.queryStats.tenants, tenantID)
+}
+
+// QueryStatsHandler serves a JSON snapshot of the per-tenant query stats, or of one tenant's with
+// ?tenantID=, for mounting on an admin router:
+//
+//	base.AddAdminEndpoints(router, "admin", api.WithAdminEndpoint("querystats", db.QueryStatsHandler()))
+func (p *PostgreSQL) QueryStatsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body interface{} = p.GetAllTenantQueryStats()
+		if tenantID := r.URL.Query().Get("tenantID"); tenantID != "" {
+			body, _ = p.GetTenantQueryStats(tenantID)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTenantQueryStats(t *testing.T) {
+	p, fd := newFakePostgreSQL(t, WithSlowQueryThreshold(time.Nanosecond))
+	fd.fail("DELETE", errors.New("permission denied"))
+
+	acme := WithTenant(context.Background(), "acme")
+	for _, query := range []string{"UPDATE orders SET paid = true", "DELETE FROM orders"} {
+		_, _ = p.Exec(acme, query)
+	}
+	_, _ = p.Exec(WithTenant(context.Background(), "globex"), "UPDATE orders SET paid = true")
+	_, _ = p.Exec(context.Background(), "UPDATE settings SET value = 1")
+
+	all := p.GetAllTenantQueryStats()
+	if len(all) != 2 {
+		t.Fatalf("Expected stats for 2 tenants, got %v", all)
+	}
+
+	stats := all["acme"]
+	if stats.TenantID != "acme" || stats.Queries != 2 || stats.Errors != 1 || stats.SlowQueries != 2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if stats.MaxDuration <= 0 || stats.TotalDuration < stats.MaxDuration || stats.LastQueryAt.IsZero() {
+		t.Errorf("Expected durations to be recorded, got %+v", stats)
+	}
+
+	p.ResetTenantQueryStats("acme")
+	if _, ok := p.GetTenantQueryStats("acme"); ok {
+		t.Error("Expected the tenant's stats to be reset")
+	}
+	if stats, ok := p.GetTenantQueryStats("globex"); !ok || stats.Queries != 1 {
+		t.Errorf("Expected other tenants to be kept, got %+v", stats)
+	}
+}
+
+func TestSlowQueryThreshold(t *testing.T) {
+	p, _ := newFakePostgreSQL(t)
+	if p.config.SlowQueryThreshold != 100*time.Millisecond {
+		t.Errorf("Expected a 100ms default threshold, got %v", p.config.SlowQueryThreshold)
+	}
+
+	_, _ = p.Exec(WithTenant(context.Background(), "acme"), "SELECT 1")
+	if stats, _ := p.GetTenantQueryStats("acme"); stats.Queries != 1 || stats.SlowQueries != 0 {
+		t.Errorf("Expected a fast query, got %+v", stats)
+	}
+}
+
+func TestQueryStatsHandler(t *testing.T) {
+	p, _ := newFakePostgreSQL(t)
+	_, _ = p.Exec(WithTenant(context.Background(), "acme"), "SELECT 1")
+
+	rec := httptest.NewRecorder()
+	p.QueryStatsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/admin/querystats", nil))
+
+	var all map[string]map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &all); err != nil {
+		t.Fatalf("Invalid JSON %s: %v", rec.Body.String(), err)
+	}
+	if all["acme"]["queries"] != float64(1) || all["acme"]["avgDurationMs"] == nil {
+		t.Errorf("Unexpected snapshot %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	p.QueryStatsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/admin/querystats?tenantID=globex", nil))
+
+	var one map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &one); err != nil {
+		t.Fatalf("Invalid JSON %s: %v", rec.Body.String(), err)
+	}
+	if one["tenantID"] != "globex" || one["queries"] != float64(0) {
+		t.Errorf("Expected empty stats for an unknown tenant, got %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,389 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ErrNotFound is returned, wrapped, when a repository has no row for a key. It matches sql.ErrNoRows
+// too, which problem.DefaultMapper maps to 404 Not Found.
+var ErrNotFound = fmt.Errorf("record not found: %w", sql.ErrNoRows)
+
+// ErrNoTenant is returned by tenant-scoped repositories when the context carries no tenant
+var ErrNoTenant = errors.New("no tenant in context")
+
+// RepositoryConfig holds configuration for a Repository
+type RepositoryConfig struct {
+	// KeyColumn identifies a row for Get, Update, and Delete
+	KeyColumn string
+	// TenantColumn restricts every statement to the context's tenant; empty turns scoping off
+	TenantColumn string
+	// Tenant returns the tenant of a request; defaults to TenantFromContext
+	Tenant func(ctx context.Context) string
+	// Conventions, when set, fill in audit columns, hide soft-deleted rows, and make Delete a soft delete
+	Conventions *Conventions
+}
+
+// DefaultRepositoryConfig provides sensible defaults
+func DefaultRepositoryConfig() *RepositoryConfig {
+	return &RepositoryConfig{
+		KeyColumn: "id",
+		Tenant:    TenantFromContext,
+	}
+}
+
+// RepositoryOption is a functional option for configuring a Repository
+type RepositoryOption func(*RepositoryConfig)
+
+// WithKeyColumn sets the column identifying a row
+func WithKeyColumn(column string) RepositoryOption {
+	return func(config *RepositoryConfig) {
+		config.KeyColumn = column
+	}
+}
+
+// WithTenantScope restricts every statement to rows whose column holds the context's tenant, and
+// sets it on insert. Statements fail with ErrNoTenant when the context has no tenant.
+func WithTenantScope(column string) RepositoryOption {
+	return func(config *RepositoryConfig) {
+		config.TenantColumn = column
+	}
+}
+
+// WithRepositoryTenantFunc sets how the tenant is read from the context, e.g. api.TenantFromContext
+func WithRepositoryTenantFunc(fn func(ctx context.Context) string) RepositoryOption {
+	return func(config *RepositoryConfig) {
+		config.Tenant = fn
+	}
+}
+
+// WithConventions applies audit column and soft delete conventions to the repository's statements
+func WithConventions(conventions *Conventions) RepositoryOption {
+	return func(config *RepositoryConfig) {
+		config.Conventions = conventions
+	}
+}
+
+// NewRepositoryConfig creates a new repository config with options
+func NewRepositoryConfig(options ...RepositoryOption) *RepositoryConfig {
+	config := DefaultRepositoryConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Repository runs the CRUD statements of a table whose rows map to T, a struct mapped to columns as
+// for ScanStruct. Queries it cannot express can use Table, Columns, and ScanInto[T] directly.
+type Repository[T any] struct {
+	db         Querier
+	table      string
+	config     *RepositoryConfig
+	columns    map[string]bool
+	selectList string
+}
+
+// NewRepository creates a repository for table. T must be a struct; NewRepository panics otherwise.
+//
+//	orders := database.NewRepository[Order](db, "orders", database.WithTenantScope("tenant_id"))
+func NewRepository[T any](db Querier, table string, options ...RepositoryOption) *Repository[T] {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("database.NewRepository: row type must be a struct, got %s", t))
+	}
+
+	fields := fieldsOf(t)
+	columns := make(map[string]bool, len(fields))
+	quoted := make([]string, len(fields))
+	for i, f := range fields {
+		columns[f.column] = true
+		quoted[i] = pq.QuoteIdentifier(f.column)
+	}
+
+	return &Repository[T]{
+		db:         db,
+		table:      table,
+		config:     NewRepositoryConfig(options...),
+		columns:    columns,
+		selectList: strings.Join(quoted, ", "),
+	}
+}
+
+// Table returns the quoted table name, for custom queries
+func (r *Repository[T]) Table() string {
+	return quoteColumn(r.table)
+}
+
+// Columns returns the quoted columns of T, in field order, for custom queries scanned with ScanInto[T]
+func (r *Repository[T]) Columns() string {
+	return r.selectList
+}
+
+// ListOptions filter, order, and page the rows returned by List
+type ListOptions struct {
+	// Filter matches columns to values: slices match any of their items, and nil matches NULL
+	Filter map[string]interface{}
+	// Where is an extra condition with :name parameters from Params, for what Filter cannot express
+	Where  string
+	Params map[string]interface{}
+	// OrderBy lists columns to sort by, prefixed with - for descending; defaults to the key column
+	OrderBy []string
+	// Limit and Offset page the results; a zero Limit returns every row
+	Limit  int
+	Offset int
+}
+
+// Get returns the row with the given key, or an error wrapping ErrNotFound
+func (r *Repository[T]) Get(ctx context.Context, key interface{}) (T, error) {
+	where, args, err := r.scope(ctx, []string{r.keyCondition(1)}, []interface{}{key})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s", r.selectList, r.Table(), where)
+	return r.one(ctx, query, args, key)
+}
+
+// List returns the rows matching opts
+func (r *Repository[T]) List(ctx context.Context, opts ListOptions) ([]T, error) {
+	where, args, err := r.conditions(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	order, err := r.orderBy(opts.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s", r.selectList, r.Table(), where, order)
+	if opts.Limit > 0 {
+		query, args = Paginate(query, opts.Limit, opts.Offset, args...)
+	}
+	return Collect(ctx, r.db, query, args, ScanInto[T])
+}
+
+// Count returns the number of rows matching the filters of opts, ignoring its order and paging,
+// e.g. for the total of an offset page
+func (r *Repository[T]) Count(ctx context.Context, opts ListOptions) (int64, error) {
+	where, args, err := r.conditions(ctx, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	query := fmt.Sprintf("SELECT count(*) FROM %s WHERE %s", r.Table(), where)
+	err = r.db.ForEachRow(ctx, query, args, func(rows *sql.Rows) error {
+		return rows.Scan(&count)
+	})
+	return count, err
+}
+
+// Create inserts the non-zero fields of item, as BuildInsert does, and returns the stored row with
+// the values filled in by the database, such as a generated key
+func (r *Repository[T]) Create(ctx context.Context, item T) (T, error) {
+	var fixed []columnValue
+	if c := r.config.Conventions; c != nil {
+		fixed = c.insertColumns(ctx)
+	}
+	if r.config.TenantColumn != "" {
+		tenant, err := r.tenant(ctx)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		fixed = slices.DeleteFunc(fixed, func(cv columnValue) bool { return cv.column == r.config.TenantColumn })
+		fixed = append(fixed, columnValue{r.config.TenantColumn, tenant})
+	}
+
+	query, args, err := buildInsert(r.table, item, fixed)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return r.one(ctx, query+" RETURNING "+r.selectList, args, nil)
+}
+
+// Update sets the non-zero fields of values, T or a patch struct, on the row with the given key, as
+// BuildUpdate does, and returns the updated row. The key and tenant columns are never changed.
+func (r *Repository[T]) Update(ctx context.Context, key interface{}, values interface{}) (T, error) {
+	var zero T
+	where, args, err := r.scope(ctx, []string{r.keyCondition(1)}, []interface{}{key})
+	if err != nil {
+		return zero, err
+	}
+
+	skip := map[string]bool{r.config.KeyColumn: true, r.config.TenantColumn: true}
+	var fixed []columnValue
+	if c := r.config.Conventions; c != nil {
+		for column := range c.protected() {
+			skip[column] = true
+		}
+		fixed = c.updateColumns(ctx)
+	}
+
+	query, args, err := buildUpdate(r.table, values, fixed, skip, where, args)
+	if err != nil {
+		return zero, err
+	}
+	return r.one(ctx, query+" RETURNING "+r.selectList, args, key)
+}
+
+// Delete removes the row with the given key, returning an error wrapping ErrNotFound when there is
+// none. With conventions that have a deleted_at column, the row is soft-deleted instead.
+func (r *Repository[T]) Delete(ctx context.Context, key interface{}) error {
+	where, args, err := r.scope(ctx, []string{r.keyCondition(1)}, []interface{}{key})
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", r.Table(), where)
+	if c := r.config.Conventions; c != nil && c.config.DeletedAtColumn != "" {
+		fixed := append([]columnValue{{c.config.DeletedAtColumn, c.config.Clock.Now()}}, c.updateColumns(ctx)...)
+		if query, args, err = c.buildSet(r.table, fixed, where, args); err != nil {
+			return err
+		}
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("%s %v: %w", r.table, key, ErrNotFound)
+	}
+	return nil
+}
+
+// one runs a query expected to return a single row
+func (r *Repository[T]) one(ctx context.Context, query string, args []interface{}, key interface{}) (T, error) {
+	var zero T
+	items, err := Collect(ctx, r.db, query, args, ScanInto[T])
+	if err != nil {
+		return zero, err
+	}
+	if len(items) == 0 {
+		return zero, fmt.Errorf("%s %v: %w", r.table, key, ErrNotFound)
+	}
+	return items[0], nil
+}
+
+// keyCondition matches the key column against placeholder n
+func (r *Repository[T]) keyCondition(n int) string {
+	return pq.QuoteIdentifier(r.config.KeyColumn) + " = $" + strconv.Itoa(n)
+}
+
+// tenant returns the context's tenant, or ErrNoTenant
+func (r *Repository[T]) tenant(ctx context.Context) (string, error) {
+	tenant := r.config.Tenant(ctx)
+	if tenant == "" {
+		return "", fmt.Errorf("%s is tenant-scoped: %w", r.table, ErrNoTenant)
+	}
+	return tenant, nil
+}
+
+// scope adds the tenant and soft delete conditions and joins the conditions into a where clause
+func (r *Repository[T]) scope(ctx context.Context, conditions []string, args []interface{}) (string,
+	[]interface{}, error) {
+	if r.config.TenantColumn != "" {
+		tenant, err := r.tenant(ctx)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, tenant)
+		conditions = append(conditions, pq.QuoteIdentifier(r.config.TenantColumn)+" = $"+strconv.Itoa(len(args)))
+	}
+	if c := r.config.Conventions; c != nil && c.config.DeletedAtColumn != "" {
+		conditions = append(conditions, c.notDeletedFilter(nil))
+	}
+
+	if len(conditions) == 0 {
+		return "TRUE", args, nil
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// conditions builds the scoped where clause for the filters of opts
+func (r *Repository[T]) conditions(ctx context.Context, opts ListOptions) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	if opts.Where != "" {
+		params := opts.Params
+		if params == nil {
+			params = map[string]interface{}{}
+		}
+		where, named, err := Named(opts.Where, params)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions, args = append(conditions, "("+where+")"), named
+	}
+
+	columns := make([]string, 0, len(opts.Filter))
+	for column := range opts.Filter {
+		columns = append(columns, column)
+	}
+	slices.Sort(columns)
+	for _, column := range columns {
+		if !r.columns[column] {
+			return "", nil, fmt.Errorf("cannot filter %s by unknown column %s", r.table, column)
+		}
+		var condition string
+		condition, args = filterCondition(column, opts.Filter[column], args)
+		conditions = append(conditions, condition)
+	}
+
+	return r.scope(ctx, conditions, args)
+}
+
+// filterCondition matches column against value, appending the value to args
+func filterCondition(column string, value interface{}, args []interface{}) (string, []interface{}) {
+	quoted := pq.QuoteIdentifier(column)
+	if value == nil {
+		return quoted + " IS NULL", args
+	}
+
+	items, ok := listItems(value)
+	if !ok {
+		args = append(args, value)
+		return quoted + " = $" + strconv.Itoa(len(args)), args
+	}
+	if len(items) == 0 {
+		return "FALSE", args
+	}
+
+	placeholders := make([]string, len(items))
+	for i, item := range items {
+		args = append(args, item)
+		placeholders[i] = "$" + strconv.Itoa(len(args))
+	}
+	return quoted + " IN (" + strings.Join(placeholders, ", ") + ")", args
+}
+
+// orderBy builds the ORDER BY list, accepting only columns of T
+func (r *Repository[T]) orderBy(columns []string) (string, error) {
+	if len(columns) == 0 {
+		return pq.QuoteIdentifier(r.config.KeyColumn), nil
+	}
+
+	ordered := make([]string, len(columns))
+	for i, column := range columns {
+		direction := ""
+		if name, desc := strings.CutPrefix(column, "-"); desc {
+			column, direction = name, " DESC"
+		}
+		if !r.columns[column] {
+			return "", fmt.Errorf("cannot order %s by unknown column %s", r.table, column)
+		}
+		ordered[i] = pq.QuoteIdentifier(column) + direction
+	}
+	return strings.Join(ordered, ", "), nil
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testItem struct {
+	ID       int64  `db:"id"`
+	Name     string `db:"name"`
+	Status   string `db:"status"`
+	TenantID string `db:"tenant_id"`
+}
+
+var testItemColumns = []string{"id", "name", "status", "tenant_id"}
+
+func TestNewRepositoryPanicsForNonStruct(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a non-struct row type")
+		}
+	}()
+	NewRepository[string](NewPostgreSQL(NewConfig()), "items")
+}
+
+func TestRepositoryGet(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("SELECT", testItemColumns, []driver.Value{int64(7), "Widget", "active", "acme"})
+	items := NewRepository[testItem](p, "items", WithTenantScope("tenant_id"))
+
+	item, err := items.Get(WithTenant(context.Background(), "acme"), 7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if item.Name != "Widget" {
+		t.Errorf("Expected Widget, got %+v", item)
+	}
+
+	call := fd.recorded()[0]
+	want := `SELECT "id", "name", "status", "tenant_id" FROM "items" WHERE "id" = $1 AND "tenant_id" = $2`
+	if call.query != want || !reflect.DeepEqual(call.args, []driver.Value{int64(7), "acme"}) {
+		t.Errorf("Expected %s [7 acme], got %s %v", want, call.query, call.args)
+	}
+
+	if _, err := items.Get(context.Background(), 7); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant without a tenant, got %v", err)
+	}
+
+	empty, _ := newFakePostgreSQL(t)
+	_, err = NewRepository[testItem](empty, "items").Get(context.Background(), 8)
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected ErrNotFound matching sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestRepositoryList(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("SELECT", testItemColumns,
+		[]driver.Value{int64(1), "a", "active", "acme"}, []driver.Value{int64(2), "b", "active", "acme"})
+	conventions := NewConventions(WithTimestampColumns("", ""), WithActorColumns("", ""))
+	items := NewRepository[testItem](p, "items", WithTenantScope("tenant_id"), WithConventions(conventions))
+
+	list, err := items.List(WithTenant(context.Background(), "acme"), ListOptions{
+		Filter:  map[string]interface{}{"status": []string{"active", "pending"}, "name": nil},
+		Where:   "id > :min",
+		Params:  map[string]interface{}{"min": 0},
+		OrderBy: []string{"-name", "id"},
+		Limit:   10,
+		Offset:  20,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(list) != 2 {
+		t.Errorf("Expected 2 items, got %d", len(list))
+	}
+
+	call := fd.recorded()[0]
+	want := `SELECT "id", "name", "status", "tenant_id" FROM "items" WHERE (id > $1) AND "name" IS NULL AND ` +
+		`"status" IN ($2, $3) AND "tenant_id" = $4 AND "deleted_at" IS NULL ORDER BY "name" DESC, "id" ` +
+		`LIMIT $5 OFFSET $6`
+	if call.query != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, call.query)
+	}
+	if wantArgs := []driver.Value{int64(0), "active", "pending", "acme", int64(10), int64(20)}; !reflect.DeepEqual(
+		call.args, wantArgs) {
+		t.Errorf("Expected args %v, got %v", wantArgs, call.args)
+	}
+
+	ctx := WithTenant(context.Background(), "acme")
+	if _, err := items.List(ctx, ListOptions{Filter: map[string]interface{}{"secret": 1}}); err == nil {
+		t.Error("Expected an error filtering by an unknown column")
+	}
+	if _, err := items.List(ctx, ListOptions{OrderBy: []string{"name; DROP TABLE items"}}); err == nil {
+		t.Error("Expected an error ordering by an unknown column")
+	}
+}
+
+func TestRepositoryCount(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("count(*)", []string{"count"}, []driver.Value{int64(42)})
+	items := NewRepository[testItem](p, "items")
+
+	count, err := items.Count(context.Background(), ListOptions{Filter: map[string]interface{}{"status": "active"}})
+	if err != nil || count != 42 {
+		t.Fatalf("Expected 42, got %d %v", count, err)
+	}
+	if want := `SELECT count(*) FROM "items" WHERE "status" = $1`; fd.recorded()[0].query != want {
+		t.Errorf("Expected %s, got %s", want, fd.recorded()[0].query)
+	}
+}
+
+func TestRepositoryCreate(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("INSERT", testItemColumns, []driver.Value{int64(9), "Widget", "active", "acme"})
+	items := NewRepository[testItem](p, "items", WithTenantScope("tenant_id"))
+
+	created, err := items.Create(WithTenant(context.Background(), "acme"),
+		testItem{Name: "Widget", Status: "active", TenantID: "other"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if created.ID != 9 {
+		t.Errorf("Expected the generated key, got %+v", created)
+	}
+
+	call := fd.recorded()[0]
+	want := `INSERT INTO "items" ("name", "status", "tenant_id") VALUES ($1, $2, $3) ` +
+		`RETURNING "id", "name", "status", "tenant_id"`
+	if call.query != want || call.args[2] != "acme" {
+		t.Errorf("Expected %s with the context's tenant, got %s %v", want, call.query, call.args)
+	}
+
+	if _, err := items.Create(context.Background(), testItem{Name: "x"}); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant without a tenant, got %v", err)
+	}
+}
+
+func TestRepositoryUpdate(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("UPDATE", testItemColumns, []driver.Value{int64(7), "Renamed", "active", "acme"})
+	items := NewRepository[testItem](p, "items", WithTenantScope("tenant_id"))
+	ctx := WithTenant(context.Background(), "acme")
+
+	updated, err := items.Update(ctx, 7, testItem{ID: 99, Name: "Renamed", TenantID: "other"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated.Name != "Renamed" {
+		t.Errorf("Expected the updated row, got %+v", updated)
+	}
+
+	want := `UPDATE "items" SET "name" = $3 WHERE "id" = $1 AND "tenant_id" = $2 ` +
+		`RETURNING "id", "name", "status", "tenant_id"`
+	if call := fd.recorded()[0]; call.query != want {
+		t.Errorf("Expected %s, got %s", want, call.query)
+	}
+
+	empty, _ := newFakePostgreSQL(t)
+	_, err = NewRepository[testItem](empty, "items").Update(ctx, 8, testItem{Name: "x"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRepositoryDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []RepositoryOption
+		expected string
+	}{
+		{"hard delete", nil, `DELETE FROM "items" WHERE "id" = $1`},
+		{
+			"soft delete",
+			[]RepositoryOption{WithConventions(NewConventions(WithTimestampColumns("", "")))},
+			`UPDATE "items" SET "deleted_at" = $2 WHERE "id" = $1 AND "deleted_at" IS NULL`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, fd := newFakePostgreSQL(t)
+			fd.on(`"items"`, nil, []driver.Value{})
+			items := NewRepository[testItem](p, "items", tt.options...)
+
+			if err := items.Delete(context.Background(), 7); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if call := fd.recorded()[0]; call.query != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, call.query)
+			}
+		})
+	}
+
+	empty, _ := newFakePostgreSQL(t)
+	if err := NewRepository[testItem](empty, "items").Delete(context.Background(), 7); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQueryTimeout is returned, wrapped, when a statement runs longer than the configured QueryTimeout.
+// It matches context.DeadlineExceeded too, like a caller deadline that passes first.
+var ErrQueryTimeout = fmt.Errorf("query timeout exceeded: %w", context.DeadlineExceeded)
+
+// RowFunc is called for each row of a query; it should scan the row and not keep rows
+type RowFunc func(rows *sql.Rows) error
+
+// ForEachRow runs a query with the configured QueryTimeout, or ctx's deadline if sooner, and calls fn
+// for each row, closing the rows however iteration ends. An error from fn stops iteration and is
+// returned unwrapped.
+func (p *PostgreSQL) ForEachRow(ctx context.Context, query string, args []interface{}, fn RowFunc) error {
+	event := &QueryEvent{Kind: StatementQuery, Query: query, Args: args}
+	return p.runStatement(ctx, event, func(ctx context.Context) error {
+		return p.forEachRow(ctx, event.Query, event.Args, fn)
+	})
+}
+
+// forEachRow runs a query and iterates its rows
+func (p *PostgreSQL) forEachRow(ctx context.Context, query string, args []interface{}, fn RowFunc) error {
+	db := p.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection is closed")
+	}
+
+	ctx, cancel := p.queryContext(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return queryError(ctx, "query failed", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return queryError(ctx, "failed to read rows", err)
+	}
+
+	return nil
+}
+
+// Exec runs a statement with the configured QueryTimeout, or ctx's deadline if sooner
+func (p *PostgreSQL) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	event := &QueryEvent{Kind: StatementExec, Query: query, Args: args}
+	var result sql.Result
+	err := p.runStatement(ctx, event, func(ctx context.Context) error {
+		var err error
+		result, err = p.exec(ctx, event.Query, event.Args)
+		return err
+	})
+	return result, err
+}
+
+// exec runs a statement
+func (p *PostgreSQL) exec(ctx context.Context, query string, args []interface{}) (sql.Result, error) {
+	db := p.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+
+	ctx, cancel := p.queryContext(ctx)
+	defer cancel()
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryError(ctx, "statement failed", err)
+	}
+	return result, nil
+}
+
+// observeQuery records a finished statement in the tenant query stats and the slow query log
+func (p *PostgreSQL) observeQuery(ctx context.Context, query string, args []interface{}, elapsed time.Duration,
+	err error) {
+	p.recordQuery(ctx, elapsed, err)
+	p.logSlowQuery(ctx, query, args, elapsed, err)
+}
+
+// queryContext applies the configured QueryTimeout to ctx. A sooner deadline on ctx, such as an
+// incoming request's, wins, and when ctx ends the driver cancels the statement on the server, so a
+// client giving up doesn't leave a query holding a pooled connection.
+func (p *PostgreSQL) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.config.QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, p.config.QueryTimeout, ErrQueryTimeout)
+}
+
+// queryError wraps a failed statement's error with why its context ended, if it did. The driver
+// reports a cancelled statement with its own error, which would otherwise hide that the caller gave
+// up (context.Canceled), its deadline passed (context.DeadlineExceeded), or QueryTimeout ran out
+// (ErrQueryTimeout).
+func queryError(ctx context.Context, msg string, err error) error {
+	cause := context.Cause(ctx)
+	switch {
+	case cause == nil || errors.Is(err, cause):
+		return fmt.Errorf("%s: %w", msg, err)
+	case errors.Is(err, ctx.Err()):
+		// database/sql saw the context end first and returned its error, which the cause refines
+		return fmt.Errorf("%s: %w", msg, cause)
+	default:
+		return fmt.Errorf("%s: %w: %w", msg, err, cause)
+	}
+}
+
+// Collect runs a query with ForEachRow and returns each row converted by scan
+func Collect[T any](ctx context.Context, db Querier, query string, args []interface{},
+	scan func(rows *sql.Rows) (T, error)) ([]T, error) {
+	var results []T
+	err := db.ForEachRow(ctx, query, args, func(rows *sql.Rows) error {
+		item, err := scan(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		results = append(results, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testUser struct {
+	ID   int64
+	Name string
+}
+
+func scanTestUser(rows *sql.Rows) (testUser, error) {
+	var u testUser
+	err := rows.Scan(&u.ID, &u.Name)
+	return u, err
+}
+
+func TestForEachRow(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("FROM users", []string{"id", "name"}, []driver.Value{int64(1), "Ann"}, []driver.Value{int64(2), "Bob"})
+
+	var names []string
+	err := p.ForEachRow(context.Background(), "SELECT id, name FROM users WHERE active = $1", []interface{}{true},
+		func(rows *sql.Rows) error {
+			u, err := scanTestUser(rows)
+			names = append(names, u.Name)
+			return err
+		})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(names) != 2 || names[0] != "Ann" || names[1] != "Bob" {
+		t.Errorf("Expected both rows, got %v", names)
+	}
+	if calls := fd.recorded(); len(calls) != 1 || calls[0].args[0] != true {
+		t.Errorf("Expected the query to be sent with its args, got %+v", calls)
+	}
+}
+
+func TestForEachRowStops(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("FROM users", []string{"id", "name"}, []driver.Value{int64(1), "Ann"}, []driver.Value{int64(2), "Bob"})
+
+	stop := errors.New("stop")
+	visited := 0
+	err := p.ForEachRow(context.Background(), "SELECT id, name FROM users", nil, func(rows *sql.Rows) error {
+		visited++
+		return stop
+	})
+
+	if !errors.Is(err, stop) || visited != 1 {
+		t.Errorf("Expected iteration to stop with fn's error, got %v after %d row(s)", err, visited)
+	}
+}
+
+func TestForEachRowErrors(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.fail("FROM missing", errors.New(`relation "missing" does not exist`))
+
+	err := p.ForEachRow(context.Background(), "SELECT * FROM missing", nil, func(*sql.Rows) error { return nil })
+	if err == nil {
+		t.Error("Expected the query error")
+	}
+
+	closed := NewPostgreSQL(NewConfig())
+	if err := closed.ForEachRow(context.Background(), "SELECT 1", nil, func(*sql.Rows) error { return nil }); err == nil {
+		t.Error("Expected an error without a connection")
+	}
+}
+
+func TestForEachRowTimeout(t *testing.T) {
+	p, _ := newFakePostgreSQL(t, WithQueryTimeout(time.Nanosecond))
+	time.Sleep(time.Millisecond)
+
+	err := p.ForEachRow(context.Background(), "SELECT 1", nil, func(*sql.Rows) error { return nil })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the query timeout to apply, got %v", err)
+	}
+}
+
+func TestQueryDeadlinePropagation(t *testing.T) {
+	cancelled := errors.New("pq: canceling statement due to user request")
+
+	tests := []struct {
+		name           string
+		ctx            func() (context.Context, context.CancelFunc)
+		expected       error
+		isQueryTimeout bool
+	}{
+		{
+			name:           "query timeout",
+			ctx:            func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			expected:       context.DeadlineExceeded,
+			isQueryTimeout: true,
+		},
+		{
+			name: "sooner request deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			expected: context.DeadlineExceeded,
+		},
+		{
+			name: "client gone",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(10*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			expected: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout := 50 * time.Millisecond
+			if !tt.isQueryTimeout {
+				timeout = time.Minute
+			}
+			p, fd := newFakePostgreSQL(t, WithQueryTimeout(timeout))
+			fd.hang("pg_sleep", cancelled)
+
+			statements := []func(ctx context.Context) error{
+				func(ctx context.Context) error {
+					return p.ForEachRow(ctx, "SELECT pg_sleep(60)", nil, func(*sql.Rows) error { return nil })
+				},
+				func(ctx context.Context) error {
+					_, err := p.Exec(ctx, "SELECT pg_sleep(60)")
+					return err
+				},
+			}
+
+			for _, statement := range statements {
+				ctx, cancel := tt.ctx()
+				start := time.Now()
+				err := statement(ctx)
+				elapsed := time.Since(start)
+				cancel()
+
+				if !errors.Is(err, tt.expected) || !errors.Is(err, cancelled) {
+					t.Errorf("Expected the driver error and %v, got %v", tt.expected, err)
+				}
+				if errors.Is(err, ErrQueryTimeout) != tt.isQueryTimeout {
+					t.Errorf("Expected ErrQueryTimeout to match: %v, got %v", tt.isQueryTimeout, err)
+				}
+				if elapsed > time.Second {
+					t.Errorf("Expected the statement to be cancelled promptly, took %v", elapsed)
+				}
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("FROM users", []string{"id", "name"}, []driver.Value{int64(1), "Ann"}, []driver.Value{int64(2), "Bob"})
+	fd.on("FROM bad", []string{"id"}, []driver.Value{"not-a-number"})
+
+	users, err := Collect(context.Background(), p, "SELECT id, name FROM users", nil, scanTestUser)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(users) != 2 || users[1] != (testUser{ID: 2, Name: "Bob"}) {
+		t.Errorf("Unexpected users %+v", users)
+	}
+
+	ids, err := Collect(context.Background(), p, "SELECT id FROM bad", nil, func(rows *sql.Rows) (int64, error) {
+		var id int64
+		return id, rows.Scan(&id)
+	})
+	if err == nil || ids != nil {
+		t.Errorf("Expected a scan error and no results, got %v, %v", ids, err)
+	}
+}
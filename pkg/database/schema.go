@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// TableSpec declares a table the service expects, with the columns and indexes it relies on
+type TableSpec struct {
+	// Name is the table, optionally schema-qualified; unqualified tables are looked up in current_schema()
+	Name    string
+	Columns []string
+	// Indexes are index names, checked because a missing index shows up as slow queries, not errors
+	Indexes []string
+}
+
+// TableSpecFor declares table with the columns T maps to, as for ScanStruct
+func TableSpecFor[T any](table string) TableSpec {
+	var columns []string
+	if t := reflect.TypeFor[T](); t.Kind() == reflect.Struct {
+		for _, f := range fieldsOf(t) {
+			columns = append(columns, f.column)
+		}
+	}
+	return TableSpec{Name: table, Columns: columns}
+}
+
+// TableSpec declares the repository's table with the columns of T
+func (r *Repository[T]) TableSpec() TableSpec {
+	return TableSpecFor[T](r.table)
+}
+
+// SchemaError lists every difference between the expected tables and the database
+type SchemaError struct {
+	Problems []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("database schema does not match: %d problem(s): %s", len(e.Problems),
+		strings.Join(e.Problems, "; "))
+}
+
+// VerifySchema checks that every table exists with its columns and indexes, returning a *SchemaError
+// listing all that are missing, so a service fails at startup rather than at the first request that
+// touches a missing column. Columns and indexes the spec does not mention are ignored.
+func (p *PostgreSQL) VerifySchema(ctx context.Context, tables ...TableSpec) error {
+	schemaErr := &SchemaError{}
+	for _, table := range tables {
+		problems, err := p.verifyTable(ctx, table)
+		if err != nil {
+			return fmt.Errorf("failed to verify table %s: %w", table.Name, err)
+		}
+		schemaErr.Problems = append(schemaErr.Problems, problems...)
+	}
+
+	if len(schemaErr.Problems) > 0 {
+		return schemaErr
+	}
+	return nil
+}
+
+// SchemaCheck returns VerifySchema as a startup hook:
+//
+//	base.OnStartup("schema", db.SchemaCheck(orders.TableSpec(), customers.TableSpec()))
+func (p *PostgreSQL) SchemaCheck(tables ...TableSpec) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return p.VerifySchema(ctx, tables...)
+	}
+}
+
+// verifyTable returns the problems of one table
+func (p *PostgreSQL) verifyTable(ctx context.Context, table TableSpec) ([]string, error) {
+	schema, name, qualified := strings.Cut(table.Name, ".")
+	if !qualified {
+		schema, name = "", table.Name
+	}
+
+	columns, err := p.catalogNames(ctx, `SELECT column_name FROM information_schema.columns
+		WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND table_name = $2`, schema, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return []string{fmt.Sprintf("table %s does not exist", table.Name)}, nil
+	}
+
+	var problems []string
+	for _, column := range table.Columns {
+		if !columns[column] {
+			problems = append(problems, fmt.Sprintf("table %s has no column %s", table.Name, column))
+		}
+	}
+
+	if len(table.Indexes) == 0 {
+		return problems, nil
+	}
+	indexes, err := p.catalogNames(ctx, `SELECT indexname FROM pg_indexes
+		WHERE schemaname = COALESCE(NULLIF($1, ''), current_schema()) AND tablename = $2`, schema, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range table.Indexes {
+		if !indexes[index] {
+			problems = append(problems, fmt.Sprintf("table %s has no index %s", table.Name, index))
+		}
+	}
+	return problems, nil
+}
+
+// catalogNames returns the set of names a catalog query returns
+func (p *PostgreSQL) catalogNames(ctx context.Context, query string, args ...interface{}) (map[string]bool,
+	error) {
+	names := make(map[string]bool)
+	err := p.ForEachRow(ctx, query, args, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		names[name] = true
+		return nil
+	})
+	return names, err
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTableSpecFor(t *testing.T) {
+	type order struct {
+		ID         int64  `db:"id"`
+		CustomerID string `db:"customer_id"`
+		TotalCents int64
+		Notes      string `db:"-"`
+	}
+
+	spec := TableSpecFor[order]("orders")
+	want := []string{"id", "customer_id", "total_cents"}
+	if spec.Name != "orders" || !reflect.DeepEqual(spec.Columns, want) {
+		t.Errorf("Expected orders with columns %v, got %+v", want, spec)
+	}
+}
+
+func TestVerifySchema(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("table_name = $2", []string{"column_name"},
+		[]driver.Value{"id"}, []driver.Value{"customer_id"}, []driver.Value{"created_at"})
+	fd.on("pg_indexes", []string{"indexname"}, []driver.Value{"orders_pkey"})
+
+	err := p.VerifySchema(context.Background(), TableSpec{
+		Name:    "billing.orders",
+		Columns: []string{"id", "customer_id", "total_cents"},
+		Indexes: []string{"orders_pkey", "orders_customer_id_idx"},
+	})
+
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Expected a SchemaError, got %v", err)
+	}
+	want := []string{
+		"table billing.orders has no column total_cents",
+		"table billing.orders has no index orders_customer_id_idx",
+	}
+	if !reflect.DeepEqual(schemaErr.Problems, want) {
+		t.Errorf("Expected problems %v, got %v", want, schemaErr.Problems)
+	}
+
+	calls := fd.recorded()
+	if calls[0].args[0] != "billing" || calls[0].args[1] != "orders" {
+		t.Errorf("Expected the schema and table to be split, got %v", calls[0].args)
+	}
+}
+
+func TestVerifySchemaMissingTable(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("information_schema", []string{"column_name"})
+
+	err := p.SchemaCheck(TableSpec{Name: "orders", Columns: []string{"id"}, Indexes: []string{"orders_pkey"}})(
+		context.Background())
+	if err == nil || !strings.Contains(err.Error(), "table orders does not exist") {
+		t.Errorf("Expected the missing table to be reported, got %v", err)
+	}
+	if calls := fd.recorded(); len(calls) != 1 || calls[0].args[0] != "" {
+		t.Errorf("Expected one query in the current schema, got %+v", calls)
+	}
+}
+
+func TestVerifySchemaMatches(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("information_schema", []string{"column_name"}, []driver.Value{"id"}, []driver.Value{"extra"})
+
+	if err := p.VerifySchema(context.Background(), TableSpec{Name: "orders", Columns: []string{"id"}}); err != nil {
+		t.Errorf("Expected the schema to match, got %v", err)
+	}
+
+	p, fd = newFakePostgreSQL(t)
+	fd.fail("information_schema", errors.New("connection refused"))
+	if err := p.VerifySchema(context.Background(), TableSpec{Name: "orders"}); err == nil ||
+		errors.As(err, new(*SchemaError)) {
+		t.Errorf("Expected a query error, got %v", err)
+	}
+}
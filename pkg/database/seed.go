@@ -0,0 +1,260 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Seed bootstraps reference data. Seeds are tracked by name and run once, unless Repeatable
+// is set, in which case they run again whenever their SQL changes and must be idempotent
+// (for example INSERT ... ON CONFLICT DO UPDATE).
+type Seed struct {
+	Name string
+	// Environments restricts the seed to these environments; empty means every environment
+	Environments []string
+	// SQL is executed when Func is nil
+	SQL string
+	// Func runs the seed in code inside the seed transaction
+	Func func(ctx context.Context, tx *sql.Tx) error
+	// Repeatable seeds are re-run whenever their SQL changes
+	Repeatable bool
+}
+
+// Checksum returns the SHA-256 checksum of the seed SQL, or of the name for code seeds
+func (s Seed) Checksum() string {
+	content := s.SQL
+	if s.Func != nil {
+		content = "func:" + s.Name
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// runsIn reports whether the seed is enabled for an environment
+func (s Seed) runsIn(environment string) bool {
+	return len(s.Environments) == 0 || slices.Contains(s.Environments, environment)
+}
+
+// SeedConfig holds configuration for running seeds
+type SeedConfig struct {
+	// TableName tracks which seeds have been applied
+	TableName string
+	// Environment selects the seed set; seeds restricted to other environments are skipped
+	Environment string
+	// LockTimeout bounds how long to wait for another instance running migrations or seeds
+	LockTimeout time.Duration
+	// LockKey is the migration advisory lock key; set it to the key given to WithMigrationLockKey
+	LockKey int64
+}
+
+// DefaultSeedConfig returns the default seed configuration
+func DefaultSeedConfig() *SeedConfig {
+	return &SeedConfig{
+		TableName:   "schema_seeds",
+		Environment: "",
+		LockTimeout: time.Minute,
+		LockKey:     DefaultMigrationLockKey,
+	}
+}
+
+// SeedOption is a functional option for configuring seeds
+type SeedOption func(*SeedConfig)
+
+// WithSeedsTable sets the table used to track applied seeds
+func WithSeedsTable(name string) SeedOption {
+	return func(c *SeedConfig) {
+		c.TableName = name
+	}
+}
+
+// WithSeedEnvironment sets the environment used to select seeds
+func WithSeedEnvironment(environment string) SeedOption {
+	return func(c *SeedConfig) {
+		c.Environment = environment
+	}
+}
+
+// WithSeedLockTimeout sets how long to wait for the migration lock
+func WithSeedLockTimeout(timeout time.Duration) SeedOption {
+	return func(c *SeedConfig) {
+		c.LockTimeout = timeout
+	}
+}
+
+// WithSeedLockKey sets the advisory lock key, which must match the key Migrate is given with
+// WithMigrationLockKey
+func WithSeedLockKey(key int64) SeedOption {
+	return func(c *SeedConfig) {
+		c.LockKey = key
+	}
+}
+
+// NewSeedConfig creates a new seed configuration with the provided options
+func NewSeedConfig(options ...SeedOption) *SeedConfig {
+	config := DefaultSeedConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Seed applies the seeds enabled for the configured environment, in order, each in its own
+// transaction. Seeds share the migration advisory lock so they never interleave with Migrate.
+func (p *PostgreSQL) Seed(ctx context.Context, seeds []Seed, options ...SeedOption) error {
+	config := NewSeedConfig(options...)
+
+	db, err := p.openDB()
+	if err != nil {
+		return err
+	}
+
+	if err := validateSeeds(seeds); err != nil {
+		return err
+	}
+
+	unlock, err := acquireMigrationLock(ctx, db, NewMigrationConfig(
+		WithMigrationLockTimeout(config.LockTimeout), WithMigrationLockKey(config.LockKey)))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := ensureSeedsTable(ctx, db, config.TableName); err != nil {
+		return err
+	}
+
+	applied, err := loadAppliedSeeds(ctx, db, config.TableName)
+	if err != nil {
+		return err
+	}
+
+	pending, err := pendingSeeds(seeds, applied, config.Environment)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range pending {
+		if err := applySeed(ctx, db, s, config.TableName); err != nil {
+			return err
+		}
+		log.Printf("### 🗄️ Database: Applied seed %s", s.Name)
+	}
+
+	return nil
+}
+
+// validateSeeds rejects unnamed, empty, or duplicate seeds
+func validateSeeds(seeds []Seed) error {
+	seen := make(map[string]bool, len(seeds))
+	for _, s := range seeds {
+		if s.Name == "" {
+			return fmt.Errorf("seed name cannot be empty")
+		}
+		if s.SQL == "" && s.Func == nil {
+			return fmt.Errorf("seed %s has neither SQL nor Func", s.Name)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("duplicate seed name %s", s.Name)
+		}
+		seen[s.Name] = true
+	}
+	return nil
+}
+
+// pendingSeeds returns the seeds that should run, given the checksums already applied.
+// A run-once seed whose SQL has changed since it was applied is an error.
+func pendingSeeds(seeds []Seed, applied map[string]string, environment string) ([]Seed, error) {
+	var pending []Seed
+	for _, s := range seeds {
+		if !s.runsIn(environment) {
+			continue
+		}
+
+		checksum, ok := applied[s.Name]
+		switch {
+		case !ok:
+			pending = append(pending, s)
+		case checksum == s.Checksum():
+			continue
+		case s.Repeatable:
+			pending = append(pending, s)
+		default:
+			return nil, fmt.Errorf("%w: seed %s", ErrChecksumMismatch, s.Name)
+		}
+	}
+	return pending, nil
+}
+
+// ensureSeedsTable creates the seed tracking table if it does not exist
+func ensureSeedsTable(ctx context.Context, q queryer, table string) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		name TEXT PRIMARY KEY,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`, pq.QuoteIdentifier(table))
+
+	if _, err := q.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create seeds table: %w", err)
+	}
+
+	return nil
+}
+
+// loadAppliedSeeds returns the checksum of every applied seed keyed by name
+func loadAppliedSeeds(ctx context.Context, q queryer, table string) (map[string]string, error) {
+	rows, err := q.QueryContext(ctx, fmt.Sprintf(`SELECT name, checksum FROM %s`, pq.QuoteIdentifier(table)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load applied seeds: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]string)
+	for rows.Next() {
+		var name, checksum string
+		if err := rows.Scan(&name, &checksum); err != nil {
+			return nil, fmt.Errorf("failed to scan applied seed: %w", err)
+		}
+		applied[name] = checksum
+	}
+
+	return applied, rows.Err()
+}
+
+// applySeed runs a seed and records its checksum in a single transaction
+func applySeed(ctx context.Context, db *sql.DB, s Seed, table string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin seed %s: %w", s.Name, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if s.Func != nil {
+		err = s.Func(ctx, tx)
+	} else {
+		_, err = tx.ExecContext(ctx, s.SQL)
+	}
+	if err != nil {
+		return fmt.Errorf("seed %s failed: %w", s.Name, err)
+	}
+
+	upsert := fmt.Sprintf(`INSERT INTO %s (name, checksum) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET checksum = EXCLUDED.checksum, applied_at = now()`,
+		pq.QuoteIdentifier(table))
+	if _, err := tx.ExecContext(ctx, upsert, s.Name, s.Checksum()); err != nil {
+		return fmt.Errorf("failed to record seed %s: %w", s.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit seed %s: %w", s.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSeedConfig(t *testing.T) {
+	config := DefaultSeedConfig()
+	if config.TableName != "schema_seeds" {
+		t.Errorf("Expected default table 'schema_seeds', got '%s'", config.TableName)
+	}
+	if config.Environment != "" {
+		t.Errorf("Expected empty default environment, got '%s'", config.Environment)
+	}
+
+	if config.LockKey != DefaultMigrationLockKey {
+		t.Errorf("Expected the default migration lock key, got %d", config.LockKey)
+	}
+
+	config = NewSeedConfig(WithSeedsTable("seeds"), WithSeedEnvironment("dev"), WithSeedLockTimeout(time.Second),
+		WithSeedLockKey(42))
+	if config.TableName != "seeds" || config.Environment != "dev" || config.LockTimeout != time.Second ||
+		config.LockKey != 42 {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+}
+
+func TestValidateSeeds(t *testing.T) {
+	noop := func(ctx context.Context, tx *sql.Tx) error { return nil }
+
+	tests := []struct {
+		name    string
+		seeds   []Seed
+		wantErr bool
+	}{
+		{"valid", []Seed{{Name: "a", SQL: "SELECT 1"}, {Name: "b", Func: noop}}, false},
+		{"empty name", []Seed{{SQL: "SELECT 1"}}, true},
+		{"no body", []Seed{{Name: "a"}}, true},
+		{"duplicate", []Seed{{Name: "a", SQL: "SELECT 1"}, {Name: "a", SQL: "SELECT 2"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSeeds(tt.seeds); (err != nil) != tt.wantErr {
+				t.Errorf("validateSeeds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPendingSeeds(t *testing.T) {
+	countries := Seed{Name: "countries", SQL: "INSERT INTO countries VALUES ('NZ')"}
+	demoUsers := Seed{Name: "demo_users", SQL: "INSERT INTO users VALUES ('demo')", Environments: []string{"dev"}}
+	plans := Seed{Name: "plans", SQL: "INSERT INTO plans VALUES ('pro') ON CONFLICT DO NOTHING", Repeatable: true}
+
+	pending, err := pendingSeeds([]Seed{countries, demoUsers, plans}, map[string]string{}, "prod")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Name != "countries" || pending[1].Name != "plans" {
+		t.Errorf("Expected environment gating to skip demo_users, got %+v", pending)
+	}
+
+	applied := map[string]string{
+		"countries": countries.Checksum(),
+		"plans":     "stale",
+	}
+	pending, err = pendingSeeds([]Seed{countries, demoUsers, plans}, applied, "dev")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Name != "demo_users" || pending[1].Name != "plans" {
+		t.Errorf("Expected demo_users and changed repeatable plans, got %+v", pending)
+	}
+
+	applied["countries"] = "stale"
+	if _, err := pendingSeeds([]Seed{countries}, applied, "dev"); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch for changed run-once seed, got %v", err)
+	}
+}
+
+func TestPostgreSQLSeedNotConnected(t *testing.T) {
+	db := NewPostgreSQL(DefaultConfig())
+
+	if err := db.Seed(context.Background(), []Seed{{Name: "a", SQL: "SELECT 1"}}); err == nil {
+		t.Error("Expected error when not connected")
+	}
+}
+
+func TestPostgreSQLSeedLockKey(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+
+	if err := p.Seed(context.Background(), nil, WithSeedLockKey(42)); err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+
+	var locks int
+	for _, call := range fd.recorded() {
+		if strings.Contains(call.query, "pg_advisory") {
+			locks++
+			if call.args[0] != int64(42) {
+				t.Errorf("Expected the configured lock key, got %s with %v", call.query, call.args)
+			}
+		}
+	}
+	if locks != 2 {
+		t.Errorf("Expected the lock to be taken and released, got %d lock statements", locks)
+	}
+}
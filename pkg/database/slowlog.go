@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/logging"
+)
+
+// slowQueryLog rate-limits slow query log entries
+type slowQueryLog struct {
+	mu         sync.Mutex
+	last       time.Time
+	suppressed int
+}
+
+// allow reports whether an entry may be logged now, and how many were suppressed since the last one
+func (s *slowQueryLog) allow(now time.Time, interval time.Duration) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if interval > 0 && !s.last.IsZero() && now.Sub(s.last) < interval {
+		s.suppressed++
+		return false, 0
+	}
+
+	suppressed := s.suppressed
+	s.last = now
+	s.suppressed = 0
+	return true, suppressed
+}
+
+// logSlowQuery logs a statement that took longer than SlowQueryLogThreshold
+func (p *PostgreSQL) logSlowQuery(ctx context.Context, query string, args []interface{}, elapsed time.Duration,
+	err error) {
+	threshold := p.config.SlowQueryLogThreshold
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+
+	ok, suppressed := p.slowLog.allow(time.Now(), p.config.SlowQueryLogInterval)
+	if !ok {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("query", query),
+		slog.Duration("duration", elapsed),
+		slog.Duration("threshold", threshold),
+	}
+	if suppressed > 0 {
+		attrs = append(attrs, slog.Int("suppressed", suppressed))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if p.config.SlowQueryExplain {
+		attrs = append(attrs, p.explain(ctx, query, args))
+	}
+
+	logger := p.config.SlowQueryLogger
+	if logger == nil {
+		logger = logging.FromContext(ctx)
+	}
+	logger.LogAttrs(ctx, slog.LevelWarn, "slow query", attrs...)
+}
+
+// explain returns the plan of a query, without running it, as a log attribute
+func (p *PostgreSQL) explain(ctx context.Context, query string, args []interface{}) slog.Attr {
+	db := p.GetDB()
+	if db == nil {
+		return slog.String("planError", "database connection is closed")
+	}
+
+	// The statement has already finished, so the plan is fetched even if the caller's context is done
+	ctx, cancel := p.queryContext(context.WithoutCancel(ctx))
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, "EXPLAIN (ANALYZE false) "+query, args...)
+	if err != nil {
+		return slog.String("planError", err.Error())
+	}
+	defer rows.Close()
+
+	plan, err := readPlan(rows)
+	if err != nil {
+		return slog.String("planError", err.Error())
+	}
+	return slog.String("plan", plan)
+}
+
+// readPlan joins the lines of an EXPLAIN result
+func readPlan(rows *sql.Rows) (string, error) {
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), rows.Err()
+}
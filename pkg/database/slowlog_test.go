@@ -0,0 +1,136 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/logging"
+)
+
+// slowLogEntries decodes the JSON log lines written to buf
+func slowLogEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	var buf bytes.Buffer
+	p, fd := newFakePostgreSQL(t,
+		WithSlowQueryLog(time.Nanosecond, true),
+		WithSlowQueryLogInterval(0),
+		WithSlowQueryLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	fd.on("EXPLAIN", []string{"QUERY PLAN"},
+		[]driver.Value{"Seq Scan on users"}, []driver.Value{"  Filter: (org = $1)"})
+	fd.fail("DELETE", errors.New("permission denied"))
+
+	if err := p.ForEachRow(context.Background(), "SELECT id FROM users WHERE org = $1", []interface{}{"acme"},
+		func(*sql.Rows) error { return nil }); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := p.Exec(context.Background(), "DELETE FROM users"); err == nil {
+		t.Fatal("Expected the statement error")
+	}
+
+	entries := slowLogEntries(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 slow query entries, got %d: %s", len(entries), buf.String())
+	}
+
+	first := entries[0]
+	if first["msg"] != "slow query" || first["level"] != "WARN" ||
+		first["query"] != "SELECT id FROM users WHERE org = $1" {
+		t.Errorf("Unexpected entry %v", first)
+	}
+	if first["plan"] != "Seq Scan on users\n  Filter: (org = $1)" {
+		t.Errorf("Expected the plan to be logged, got %v", first["plan"])
+	}
+	if entries[1]["error"] == nil {
+		t.Errorf("Expected the statement error to be logged, got %v", entries[1])
+	}
+
+	calls := fd.recorded()
+	if calls[1].query != "EXPLAIN (ANALYZE false) SELECT id FROM users WHERE org = $1" || calls[1].args[0] != "acme" {
+		t.Errorf("Expected EXPLAIN with the query's args, got %+v", calls[1])
+	}
+}
+
+func TestSlowQueryLogThreshold(t *testing.T) {
+	var buf bytes.Buffer
+	p, fd := newFakePostgreSQL(t,
+		WithSlowQueryLog(time.Hour, true),
+		WithSlowQueryLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	if _, err := p.Exec(context.Background(), "UPDATE users SET active = true"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected fast statements not to be logged, got %s", buf.String())
+	}
+	if calls := fd.recorded(); len(calls) != 1 {
+		t.Errorf("Expected no EXPLAIN for fast statements, got %+v", calls)
+	}
+}
+
+func TestSlowQueryLogRateLimit(t *testing.T) {
+	var buf bytes.Buffer
+	p, _ := newFakePostgreSQL(t,
+		WithSlowQueryLog(time.Nanosecond, false),
+		WithSlowQueryLogInterval(time.Hour),
+		WithSlowQueryLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	for range 3 {
+		if _, err := p.Exec(context.Background(), "UPDATE users SET active = true"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if entries := slowLogEntries(t, &buf); len(entries) != 1 || entries[0]["plan"] != nil {
+		t.Fatalf("Expected one entry without a plan, got %s", buf.String())
+	}
+
+	// Once the interval has passed, the next entry reports what was suppressed
+	p.slowLog.last = time.Now().Add(-2 * time.Hour)
+	if _, err := p.Exec(context.Background(), "UPDATE users SET active = true"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	entries := slowLogEntries(t, &buf)
+	if len(entries) != 2 || entries[1]["suppressed"] != float64(2) {
+		t.Errorf("Expected the second entry to report 2 suppressed, got %s", buf.String())
+	}
+}
+
+func TestSlowQueryLogContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	p, _ := newFakePostgreSQL(t, WithSlowQueryLog(time.Nanosecond, false))
+
+	logger := slog.New(slog.NewJSONHandler(&buf, nil)).With("requestId", "req-1")
+	ctx := logging.NewContext(context.Background(), logger)
+	if _, err := p.Exec(ctx, "UPDATE users SET active = true"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries := slowLogEntries(t, &buf)
+	if len(entries) != 1 || entries[0]["requestId"] != "req-1" {
+		t.Errorf("Expected the entry on the request's logger, got %s", buf.String())
+	}
+}
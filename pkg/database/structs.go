@@ -0,0 +1,167 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+// structField is a struct field mapped to a column
+type structField struct {
+	column string
+	index  []int
+}
+
+// structFields caches the column mapping of each struct type
+var structFields sync.Map
+
+// fieldsOf maps the exported fields of a struct type to columns: the db tag names the column, "-"
+// skips the field, and untagged fields use the snake_case field name. Fields of embedded structs
+// are included as if they were declared on the outer struct.
+func fieldsOf(t reflect.Type) []structField {
+	if cached, ok := structFields.Load(t); ok {
+		return cached.([]structField)
+	}
+
+	var fields []structField
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("db")
+		switch {
+		case f.Anonymous && f.Type.Kind() == reflect.Struct && tag == "":
+			for _, inner := range fieldsOf(f.Type) {
+				fields = append(fields, structField{column: inner.column, index: append([]int{i}, inner.index...)})
+			}
+			continue
+		case tag == "-" || !f.IsExported():
+			continue
+		}
+
+		column, _, _ := strings.Cut(tag, ",")
+		if column == "" {
+			column = snakeCase(f.Name)
+		}
+		fields = append(fields, structField{column: column, index: []int{i}})
+	}
+
+	structFields.Store(t, fields)
+	return fields
+}
+
+// snakeCase converts a Go field name such as UserID to user_id
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
+// ScanStruct scans the current row into the struct dst points to, matching each column to the field
+// with that db tag, or the field whose snake_case name it is. Every column must have a field.
+func ScanStruct(rows *sql.Rows, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("scan destination must be a pointer to a struct, got %T", dst)
+	}
+	v = v.Elem()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	byColumn := make(map[string][]int)
+	for _, f := range fieldsOf(v.Type()) {
+		byColumn[f.column] = f.index
+	}
+
+	targets := make([]interface{}, len(columns))
+	for i, column := range columns {
+		index, ok := byColumn[column]
+		if !ok {
+			return fmt.Errorf("no field of %s for column %s", v.Type(), column)
+		}
+		targets[i] = v.FieldByIndex(index).Addr().Interface()
+	}
+
+	return rows.Scan(targets...)
+}
+
+// ScanInto scans the current row into a new T with ScanStruct, for use with Collect:
+//
+//	users, err := database.Collect(ctx, db, "SELECT id, name FROM users", nil, database.ScanInto[User])
+func ScanInto[T any](rows *sql.Rows) (T, error) {
+	var item T
+	err := ScanStruct(rows, &item)
+	return item, err
+}
+
+// BuildUpdate builds an UPDATE of table that sets only the non-zero fields of values, a struct
+// mapped to columns as for ScanStruct, so a partial update leaves the other columns alone. Pointer
+// fields are set whenever they are non-nil, which allows a column to be set to its zero value. The
+// where clause uses $1, $2, ... for whereArgs, and the SET placeholders are numbered after them:
+//
+//	query, args, err := database.BuildUpdate("users", patch, "id = $1", id)
+//	// UPDATE "users" SET "name" = $2, "email" = $3 WHERE id = $1
+func BuildUpdate(table string, values interface{}, where string, whereArgs ...interface{}) (string, []interface{},
+	error) {
+	return buildUpdate(table, values, nil, nil, where, whereArgs)
+}
+
+// columnValue is a column set by a builder regardless of the struct's fields
+type columnValue struct {
+	column string
+	value  interface{}
+}
+
+// buildUpdate builds BuildUpdate's statement, ignoring the fields for columns in skip and setting
+// the fixed columns after the fields of values
+func buildUpdate(table string, values interface{}, fixed []columnValue, skip map[string]bool, where string,
+	whereArgs []interface{}) (string, []interface{}, error) {
+	v := reflect.ValueOf(values)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("update values must be a struct, got %T", values)
+	}
+	if strings.TrimSpace(where) == "" {
+		return "", nil, fmt.Errorf("update of %s requires a where clause", table)
+	}
+
+	args := append([]interface{}(nil), whereArgs...)
+	var set []string
+	for _, f := range fieldsOf(v.Type()) {
+		field := v.FieldByIndex(f.index)
+		if field.IsZero() || skip[f.column] {
+			continue
+		}
+		args = append(args, field.Interface())
+		set = append(set, pq.QuoteIdentifier(f.column)+" = $"+strconv.Itoa(len(args)))
+	}
+	if len(set) == 0 {
+		return "", nil, fmt.Errorf("update of %s has no fields to set", table)
+	}
+	for _, c := range fixed {
+		args = append(args, c.value)
+		set = append(set, pq.QuoteIdentifier(c.column)+" = $"+strconv.Itoa(len(args)))
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", quoteColumn(table), strings.Join(set, ", "), where)
+	return query, args, nil
+}
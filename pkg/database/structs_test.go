@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type auditFields struct {
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type testAccount struct {
+	ID      int64 `db:"id"`
+	OwnerID string
+	Email   *string `db:"email"`
+	Plan    string  `db:"plan,omitempty"`
+	Secret  string  `db:"-"`
+	auditFields
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"Name":      "name",
+		"OwnerID":   "owner_id",
+		"HTTPCode":  "http_code",
+		"CreatedAt": "created_at",
+	}
+	for in, want := range tests {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFieldsOf(t *testing.T) {
+	var columns []string
+	for _, f := range fieldsOf(reflect.TypeOf(testAccount{})) {
+		columns = append(columns, f.column)
+	}
+
+	want := []string{"id", "owner_id", "email", "plan", "created_at"}
+	if !reflect.DeepEqual(columns, want) {
+		t.Errorf("Expected columns %v, got %v", want, columns)
+	}
+}
+
+func TestScanInto(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	fd.on("FROM accounts", []string{"id", "owner_id", "email", "created_at"},
+		[]driver.Value{int64(7), "u-1", "ann@example.com", created},
+		[]driver.Value{int64(8), "u-2", nil, created})
+	fd.on("FROM extra", []string{"id", "unknown"}, []driver.Value{int64(1), "x"})
+
+	accounts, err := Collect(context.Background(), p, "SELECT * FROM accounts", nil, ScanInto[testAccount])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(accounts) != 2 {
+		t.Fatalf("Expected 2 accounts, got %d", len(accounts))
+	}
+	first := accounts[0]
+	if first.ID != 7 || first.OwnerID != "u-1" || *first.Email != "ann@example.com" ||
+		!first.CreatedAt.Equal(created) || accounts[1].Email != nil {
+		t.Errorf("Unexpected accounts %+v", accounts)
+	}
+
+	if _, err := Collect(context.Background(), p, "SELECT * FROM extra", nil, ScanInto[testAccount]); err == nil {
+		t.Error("Expected an error for a column without a field")
+	}
+}
+
+func TestBuildUpdate(t *testing.T) {
+	empty := ""
+	query, args, err := BuildUpdate("app.accounts", testAccount{OwnerID: "u-2", Email: &empty}, "id = $1", int64(7))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	wantQuery := `UPDATE "app"."accounts" SET "owner_id" = $2, "email" = $3 WHERE id = $1`
+	if query != wantQuery {
+		t.Errorf("Expected %s, got %s", wantQuery, query)
+	}
+	if len(args) != 3 || args[0] != int64(7) || args[1] != "u-2" || *(args[2].(*string)) != "" {
+		t.Errorf("Unexpected args %v", args)
+	}
+
+	if _, _, err := BuildUpdate("accounts", testAccount{}, "id = $1", 7); err == nil {
+		t.Error("Expected an error with no fields to set")
+	}
+	if _, _, err := BuildUpdate("accounts", testAccount{Plan: "pro"}, ""); err == nil {
+		t.Error("Expected an error without a where clause")
+	}
+}
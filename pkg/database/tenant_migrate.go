@@ -0,0 +1,348 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lib/pq"
+)
+
+// tenantLockClass namespaces per-tenant advisory locks from other advisory lock users
+const tenantLockClass int32 = 0x676f736b // "gosk"
+
+// TenantMigrationConfig holds configuration for per-tenant migrations
+type TenantMigrationConfig struct {
+	// TableName tracks applied versions per tenant
+	TableName string
+	// Concurrency limits how many tenants MigrateAllTenants migrates at once
+	Concurrency int
+	// SchemaFunc maps a tenant to its schema in schema-per-tenant mode.
+	// When nil, migrations run in the shared schema with the RLS tenant context set.
+	SchemaFunc func(tenantID string) string
+}
+
+// DefaultTenantMigrationConfig returns the default per-tenant migration configuration
+func DefaultTenantMigrationConfig() *TenantMigrationConfig {
+	return &TenantMigrationConfig{
+		TableName:   "tenant_schema_migrations",
+		Concurrency: 4,
+		SchemaFunc:  nil,
+	}
+}
+
+// TenantMigrationOption is a functional option for configuring per-tenant migrations
+type TenantMigrationOption func(*TenantMigrationConfig)
+
+// WithTenantMigrationsTable sets the table used to track per-tenant versions
+func WithTenantMigrationsTable(name string) TenantMigrationOption {
+	return func(c *TenantMigrationConfig) {
+		c.TableName = name
+	}
+}
+
+// WithTenantConcurrency sets how many tenants are migrated at once
+func WithTenantConcurrency(concurrency int) TenantMigrationOption {
+	return func(c *TenantMigrationConfig) {
+		c.Concurrency = concurrency
+	}
+}
+
+// WithTenantSchema enables schema-per-tenant mode using fn to name each tenant's schema
+func WithTenantSchema(fn func(tenantID string) string) TenantMigrationOption {
+	return func(c *TenantMigrationConfig) {
+		c.SchemaFunc = fn
+	}
+}
+
+// NewTenantMigrationConfig creates a new per-tenant migration configuration with options
+func NewTenantMigrationConfig(options ...TenantMigrationOption) *TenantMigrationConfig {
+	config := DefaultTenantMigrationConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// TenantMigrationError reports the tenants that failed to migrate
+type TenantMigrationError struct {
+	Failures map[string]error
+}
+
+func (e *TenantMigrationError) Error() string {
+	tenants := make([]string, 0, len(e.Failures))
+	for tenantID := range e.Failures {
+		tenants = append(tenants, tenantID)
+	}
+	sort.Strings(tenants)
+
+	messages := make([]string, 0, len(tenants))
+	for _, tenantID := range tenants {
+		messages = append(messages, fmt.Sprintf("%s: %v", tenantID, e.Failures[tenantID]))
+	}
+
+	return fmt.Sprintf("%d tenant(s) failed to migrate: %s", len(tenants), strings.Join(messages, "; "))
+}
+
+// TenantMigrations applies versioned migrations to tenant-owned schema objects,
+// tracking the applied version of each tenant independently
+type TenantMigrations struct {
+	db         *PostgreSQL
+	migrations []Migration
+	config     *TenantMigrationConfig
+}
+
+// NewTenantMigrations creates a per-tenant migration runner
+func NewTenantMigrations(
+	db *PostgreSQL, migrations []Migration, options ...TenantMigrationOption,
+) (*TenantMigrations, error) {
+	sorted, err := sortMigrations(migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	config := NewTenantMigrationConfig(options...)
+	if config.Concurrency < 1 {
+		config.Concurrency = 1
+	}
+
+	return &TenantMigrations{db: db, migrations: sorted, config: config}, nil
+}
+
+// Plan returns the migrations that MigrateTenant would apply for a tenant, without changing the
+// database
+func (tm *TenantMigrations) Plan(ctx context.Context, tenantID string) ([]Migration, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+
+	db, err := tm.db.openDB()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := tm.applied(ctx, db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyChecksums(applied, tm.migrations); err != nil {
+		return nil, fmt.Errorf("tenant %s: %w", tenantID, err)
+	}
+
+	return pendingMigrations(applied, tm.migrations), nil
+}
+
+// MigrateTenant applies pending migrations for a single tenant, each in its own transaction
+func (tm *TenantMigrations) MigrateTenant(ctx context.Context, tenantID string) error {
+	pending, err := tm.Plan(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	db, err := tm.db.openDB()
+	if err != nil {
+		return err
+	}
+
+	if len(pending) > 0 {
+		if err := tm.ensureTable(ctx, db); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range pending {
+		if err := tm.apply(ctx, db, tenantID, m); err != nil {
+			return err
+		}
+	}
+
+	if len(pending) > 0 {
+		log.Printf("### 🗄️ Database: Applied %d migration(s) for tenant %s", len(pending), tenantID)
+	}
+
+	return nil
+}
+
+// MigrateAllTenants migrates every tenant with bounded concurrency. A failing tenant does
+// not stop the others; failures are returned together as a *TenantMigrationError.
+func (tm *TenantMigrations) MigrateAllTenants(ctx context.Context, tenantIDs []string) error {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failures = make(map[string]error)
+		slots    = make(chan struct{}, tm.config.Concurrency)
+	)
+
+	for _, tenantID := range tenantIDs {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			failures[tenantID] = ctx.Err()
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(tenantID string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if err := tm.MigrateTenant(ctx, tenantID); err != nil {
+				mu.Lock()
+				failures[tenantID] = err
+				mu.Unlock()
+			}
+		}(tenantID)
+	}
+
+	wg.Wait()
+
+	if len(failures) > 0 {
+		return &TenantMigrationError{Failures: failures}
+	}
+
+	return nil
+}
+
+// ensureTable creates the per-tenant tracking table if it does not exist
+func (tm *TenantMigrations) ensureTable(ctx context.Context, q queryer) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		tenant_id TEXT NOT NULL,
+		version BIGINT NOT NULL,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (tenant_id, version)
+	)`, pq.QuoteIdentifier(tm.config.TableName))
+
+	if _, err := q.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create tenant migrations table: %w", err)
+	}
+
+	return nil
+}
+
+// applied returns the migrations recorded for a tenant, treating a missing table as empty
+func (tm *TenantMigrations) applied(ctx context.Context, q queryer, tenantID string) ([]AppliedMigration, error) {
+	exists, err := tableExists(ctx, q, tm.config.TableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check tenant migrations table: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	query := fmt.Sprintf(`SELECT version, name, checksum, applied_at FROM %s WHERE tenant_id = $1 ORDER BY version`,
+		pq.QuoteIdentifier(tm.config.TableName))
+
+	rows, err := q.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations for tenant %s: %w", tenantID, err)
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration for tenant %s: %w", tenantID, err)
+		}
+		applied = append(applied, a)
+	}
+
+	return applied, rows.Err()
+}
+
+// apply runs one migration for a tenant under a per-tenant transaction lock
+func (tm *TenantMigrations) apply(ctx context.Context, db *sql.DB, tenantID string, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("tenant %s: failed to begin migration %d: %w", tenantID, m.Version, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Serialize concurrent runs for the same tenant, then re-check under the lock
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2))`,
+		tenantLockClass, tenantID); err != nil {
+		return fmt.Errorf("tenant %s: failed to acquire migration lock: %w", tenantID, err)
+	}
+
+	var exists bool
+	check := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE tenant_id = $1 AND version = $2)`,
+		pq.QuoteIdentifier(tm.config.TableName))
+	if err := tx.QueryRowContext(ctx, check, tenantID, m.Version).Scan(&exists); err != nil {
+		return fmt.Errorf("tenant %s: failed to check migration %d: %w", tenantID, m.Version, err)
+	}
+	if exists {
+		return nil
+	}
+
+	if err := tm.scopeToTenant(ctx, tx, tenantID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+		return fmt.Errorf("tenant %s: migration %d (%s) failed: %w", tenantID, m.Version, m.Name, err)
+	}
+
+	insert := fmt.Sprintf(`INSERT INTO %s (tenant_id, version, name, checksum) VALUES ($1, $2, $3, $4)`,
+		pq.QuoteIdentifier(tm.config.TableName))
+	if _, err := tx.ExecContext(ctx, insert, tenantID, m.Version, m.Name, m.Checksum()); err != nil {
+		return fmt.Errorf("tenant %s: failed to record migration %d: %w", tenantID, m.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("tenant %s: failed to commit migration %d: %w", tenantID, m.Version, err)
+	}
+
+	return nil
+}
+
+// scopeToTenant sets the RLS tenant context and, in schema-per-tenant mode, the search path
+// for the remainder of the transaction
+func (tm *TenantMigrations) scopeToTenant(ctx context.Context, q queryer, tenantID string) error {
+	return scopeToTenant(ctx, q, tm.db.config.RLSContextVarName, tm.config.SchemaFunc, tenantID)
+}
+
+// scopeToTenant sets the RLS context variable to tenantID and, when schemaFunc is set, creates the
+// tenant's schema and puts it first on the search path, for the remainder of the transaction. public
+// stays on the path, so shared tables such as the tracking table and tenant registry still resolve.
+func scopeToTenant(ctx context.Context, q queryer, rlsVarName string, schemaFunc func(string) string,
+	tenantID string) error {
+	if err := setTenantContext(ctx, q, rlsVarName, tenantID); err != nil {
+		return err
+	}
+
+	if schemaFunc == nil {
+		return nil
+	}
+
+	schema := schemaFunc(tenantID)
+	if _, err := q.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+pq.QuoteIdentifier(schema)); err != nil {
+		return fmt.Errorf("tenant %s: failed to create schema: %w", tenantID, err)
+	}
+	return setTenantSearchPath(ctx, q, schema, tenantID)
+}
+
+// setTenantContext sets the RLS context variable to tenantID for the remainder of the transaction
+func setTenantContext(ctx context.Context, q queryer, rlsVarName, tenantID string) error {
+	if _, err := q.ExecContext(ctx, `SELECT set_config($1, $2, true)`, rlsVarName, tenantID); err != nil {
+		return fmt.Errorf("tenant %s: failed to set tenant context: %w", tenantID, err)
+	}
+	return nil
+}
+
+// setTenantSearchPath puts schema first on the search path, followed by public, for the remainder of
+// the transaction
+func setTenantSearchPath(ctx context.Context, q queryer, schema, tenantID string) error {
+	if _, err := q.ExecContext(ctx, "SET LOCAL search_path TO "+pq.QuoteIdentifier(schema)+", public"); err != nil {
+		return fmt.Errorf("tenant %s: failed to set search path: %w", tenantID, err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTenantMigrationConfig(t *testing.T) {
+	config := DefaultTenantMigrationConfig()
+	if config.TableName != "tenant_schema_migrations" {
+		t.Errorf("Expected default table 'tenant_schema_migrations', got '%s'", config.TableName)
+	}
+	if config.Concurrency != 4 {
+		t.Errorf("Expected default concurrency 4, got %d", config.Concurrency)
+	}
+	if config.SchemaFunc != nil {
+		t.Error("Expected shared schema mode by default")
+	}
+
+	config = NewTenantMigrationConfig(
+		WithTenantMigrationsTable("custom_tenant_migrations"),
+		WithTenantConcurrency(8),
+		WithTenantSchema(func(tenantID string) string { return "tenant_" + tenantID }),
+	)
+	if config.TableName != "custom_tenant_migrations" {
+		t.Errorf("Expected table 'custom_tenant_migrations', got '%s'", config.TableName)
+	}
+	if config.Concurrency != 8 {
+		t.Errorf("Expected concurrency 8, got %d", config.Concurrency)
+	}
+	if config.SchemaFunc == nil || config.SchemaFunc("acme") != "tenant_acme" {
+		t.Error("Expected schema func to map tenant to schema")
+	}
+}
+
+func TestNewTenantMigrations(t *testing.T) {
+	db := NewPostgreSQL(DefaultConfig())
+
+	_, err := NewTenantMigrations(db, []Migration{
+		{Version: 1, Name: "a", Up: "SELECT 1"},
+		{Version: 1, Name: "b", Up: "SELECT 2"},
+	})
+	if err == nil {
+		t.Error("Expected error for duplicate versions")
+	}
+
+	tm, err := NewTenantMigrations(db, []Migration{
+		{Version: 2, Name: "b", Up: "SELECT 2"},
+		{Version: 1, Name: "a", Up: "SELECT 1"},
+	}, WithTenantConcurrency(0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tm.migrations[0].Version != 1 {
+		t.Error("Expected migrations to be sorted by version")
+	}
+	if tm.config.Concurrency != 1 {
+		t.Errorf("Expected concurrency to be clamped to 1, got %d", tm.config.Concurrency)
+	}
+}
+
+func TestMigrateTenantNotConnected(t *testing.T) {
+	tm, err := NewTenantMigrations(NewPostgreSQL(DefaultConfig()), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := tm.MigrateTenant(context.Background(), ""); err == nil {
+		t.Error("Expected error for empty tenant ID")
+	}
+
+	if err := tm.MigrateTenant(context.Background(), "acme"); err == nil {
+		t.Error("Expected error when not connected")
+	}
+}
+
+func TestMigrateAllTenantsIsolatesFailures(t *testing.T) {
+	tm, err := NewTenantMigrations(NewPostgreSQL(DefaultConfig()), nil, WithTenantConcurrency(2))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	err = tm.MigrateAllTenants(context.Background(), []string{"acme", "globex", "initech"})
+
+	var tenantErr *TenantMigrationError
+	if !errors.As(err, &tenantErr) {
+		t.Fatalf("Expected TenantMigrationError, got %v", err)
+	}
+	if len(tenantErr.Failures) != 3 {
+		t.Errorf("Expected every tenant to be attempted and fail, got %d failures", len(tenantErr.Failures))
+	}
+	if !strings.HasPrefix(err.Error(), "3 tenant(s) failed to migrate: acme:") {
+		t.Errorf("Expected sorted failure summary, got %q", err.Error())
+	}
+
+	if err := tm.MigrateAllTenants(context.Background(), nil); err != nil {
+		t.Errorf("Expected no error for no tenants, got %v", err)
+	}
+}
+
+func TestMigrateTenantSchemaStatements(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("to_regclass", []string{"exists"}, []driver.Value{false})
+	fd.on("SELECT EXISTS", []string{"exists"}, []driver.Value{false})
+	tm, err := NewTenantMigrations(p, []Migration{{Version: 1, Name: "orders", Up: "CREATE TABLE orders (id int)"}},
+		WithTenantSchema(func(tenantID string) string { return "tenant_" + tenantID }))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := tm.MigrateTenant(context.Background(), "acme"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var statements []string
+	for _, call := range fd.recorded()[2:] {
+		statements = append(statements, call.query)
+	}
+	want := []string{
+		`SELECT pg_advisory_xact_lock($1, hashtext($2))`,
+		`SELECT EXISTS (SELECT 1 FROM "tenant_schema_migrations" WHERE tenant_id = $1 AND version = $2)`,
+		`SELECT set_config($1, $2, true)`,
+		`CREATE SCHEMA IF NOT EXISTS "tenant_acme"`,
+		`SET LOCAL search_path TO "tenant_acme", public`,
+		`CREATE TABLE orders (id int)`,
+		`INSERT INTO "tenant_schema_migrations" (tenant_id, version, name, checksum) VALUES ($1, $2, $3, $4)`,
+		`COMMIT`,
+	}
+	if !reflect.DeepEqual(statements, want) {
+		t.Errorf("Expected statements\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(statements, "\n"))
+	}
+}
+
+func TestTenantPlanWithoutTable(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on("to_regclass", []string{"exists"}, []driver.Value{false})
+	tm, err := NewTenantMigrations(p, []Migration{{Version: 1, Name: "orders", Up: "CREATE TABLE orders (id int)"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pending, err := tm.Plan(context.Background(), "acme")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(pending) != 1 {
+		t.Errorf("Expected every migration to be pending, got %+v", pending)
+	}
+
+	calls := fd.recorded()
+	if len(calls) != 1 || calls[0].args[0] != `"tenant_schema_migrations"` {
+		t.Errorf("Expected only the table check, got %+v", calls)
+	}
+}
@@ -0,0 +1,495 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrTenantNotFound is returned for a tenant that is not in the registry
+var ErrTenantNotFound = errors.New("tenant not found")
+
+// ErrTenantExists is returned when creating a tenant whose ID is already registered
+var ErrTenantExists = errors.New("tenant already exists")
+
+// TenantStatus is the lifecycle state of a tenant
+type TenantStatus string
+
+const (
+	// TenantActive tenants are in normal use
+	TenantActive TenantStatus = "active"
+	// TenantSuspended tenants are kept but should be refused service
+	TenantSuspended TenantStatus = "suspended"
+)
+
+// TenantEvent identifies a lifecycle change that hooks can attach to
+type TenantEvent string
+
+const (
+	// EventTenantCreated hooks run after the registry row is inserted and the tenant's schema exists
+	EventTenantCreated TenantEvent = "created"
+	// EventTenantSuspended hooks run after the tenant is marked suspended
+	EventTenantSuspended TenantEvent = "suspended"
+	// EventTenantResumed hooks run after a suspended tenant is made active again
+	EventTenantResumed TenantEvent = "resumed"
+	// EventTenantDeleted hooks run before the tenant's data is exported and purged
+	EventTenantDeleted TenantEvent = "deleted"
+)
+
+// Tenant is a row of the tenant registry
+type Tenant struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Status    TenantStatus `json:"status"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+// TenantHook attaches provisioning logic to a lifecycle event. It runs in the event's transaction
+// with the tenant context set, and in schema-per-tenant mode the search path, so returning an
+// error rolls the change back.
+type TenantHook func(ctx context.Context, tx *sql.Tx, tenant Tenant) error
+
+// TenantManagerConfig holds configuration for tenant lifecycle management
+type TenantManagerConfig struct {
+	// TableName is the tenant registry
+	TableName string
+	// SchemaFunc maps a tenant to its schema in schema-per-tenant mode, where the schema is created
+	// with the tenant and dropped with it. When nil, tenants share a schema under RLS.
+	SchemaFunc func(tenantID string) string
+	// Tables hold tenant-owned rows, in dependency order, and are exported and purged on delete.
+	// In shared schema mode their rows are selected by TenantColumn.
+	Tables       []string
+	TenantColumn string
+	// Hooks run on each lifecycle event, in the order they were added
+	Hooks map[TenantEvent][]TenantHook
+}
+
+// DefaultTenantManagerConfig returns the default tenant lifecycle configuration
+func DefaultTenantManagerConfig() *TenantManagerConfig {
+	return &TenantManagerConfig{
+		TableName:    "tenants",
+		TenantColumn: "tenant_id",
+		Hooks:        make(map[TenantEvent][]TenantHook),
+	}
+}
+
+// TenantManagerOption is a functional option for configuring tenant lifecycle management
+type TenantManagerOption func(*TenantManagerConfig)
+
+// WithTenantRegistryTable sets the tenant registry table
+func WithTenantRegistryTable(name string) TenantManagerOption {
+	return func(c *TenantManagerConfig) {
+		c.TableName = name
+	}
+}
+
+// WithSchemaPerTenant enables schema-per-tenant mode using fn to name each tenant's schema
+func WithSchemaPerTenant(fn func(tenantID string) string) TenantManagerOption {
+	return func(c *TenantManagerConfig) {
+		c.SchemaFunc = fn
+	}
+}
+
+// WithTenantTables sets the tenant-owned tables exported and purged on delete, in dependency
+// order: tables are purged in reverse, so rows referencing others go first
+func WithTenantTables(tables ...string) TenantManagerOption {
+	return func(c *TenantManagerConfig) {
+		c.Tables = tables
+	}
+}
+
+// WithTenantColumn sets the column holding the tenant ID in shared schema mode
+func WithTenantColumn(column string) TenantManagerOption {
+	return func(c *TenantManagerConfig) {
+		c.TenantColumn = column
+	}
+}
+
+// WithTenantHook adds a hook run on a lifecycle event
+func WithTenantHook(event TenantEvent, hook TenantHook) TenantManagerOption {
+	return func(c *TenantManagerConfig) {
+		c.Hooks[event] = append(c.Hooks[event], hook)
+	}
+}
+
+// NewTenantManagerConfig creates a new tenant lifecycle configuration with options
+func NewTenantManagerConfig(options ...TenantManagerOption) *TenantManagerConfig {
+	config := DefaultTenantManagerConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// TenantManager creates, suspends, lists, and deletes tenants, keeping a registry of them
+type TenantManager struct {
+	db     *PostgreSQL
+	config *TenantManagerConfig
+}
+
+// NewTenantManager creates a tenant lifecycle manager
+func NewTenantManager(db *PostgreSQL, options ...TenantManagerOption) *TenantManager {
+	return &TenantManager{db: db, config: NewTenantManagerConfig(options...)}
+}
+
+// CreateTenant registers a tenant and, in schema-per-tenant mode, creates its schema, then runs the
+// EventTenantCreated hooks, all in one transaction
+func (tm *TenantManager) CreateTenant(ctx context.Context, tenantID, name string) (Tenant, error) {
+	tenant := Tenant{ID: tenantID, Name: name}
+	err := tm.inTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		insert := fmt.Sprintf(`INSERT INTO %s (id, name) VALUES ($1, $2) RETURNING status, created_at, updated_at`,
+			tm.table())
+		err := tx.QueryRowContext(ctx, insert, tenantID, name).
+			Scan(&tenant.Status, &tenant.CreatedAt, &tenant.UpdatedAt)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("tenant %s: %w", tenantID, ErrTenantExists)
+		}
+		if err != nil {
+			return fmt.Errorf("tenant %s: failed to register: %w", tenantID, err)
+		}
+
+		if err := tm.scope(ctx, tx, tenantID); err != nil {
+			return err
+		}
+		return tm.runHooks(ctx, tx, EventTenantCreated, tenant)
+	})
+	if err != nil {
+		return Tenant{}, err
+	}
+
+	log.Printf("### 🗄️ Database: Created tenant %s", tenantID)
+	return tenant, nil
+}
+
+// SuspendTenant marks a tenant suspended and runs the EventTenantSuspended hooks
+func (tm *TenantManager) SuspendTenant(ctx context.Context, tenantID string) error {
+	return tm.setStatus(ctx, tenantID, TenantSuspended, EventTenantSuspended)
+}
+
+// ResumeTenant makes a suspended tenant active again and runs the EventTenantResumed hooks
+func (tm *TenantManager) ResumeTenant(ctx context.Context, tenantID string) error {
+	return tm.setStatus(ctx, tenantID, TenantActive, EventTenantResumed)
+}
+
+// setStatus changes a tenant's status and runs the event's hooks in the same transaction
+func (tm *TenantManager) setStatus(ctx context.Context, tenantID string, status TenantStatus,
+	event TenantEvent) error {
+	err := tm.inTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		update := fmt.Sprintf(`UPDATE %s SET status = $2, updated_at = now() WHERE id = $1
+			RETURNING id, name, status, created_at, updated_at`, tm.table())
+		tenant, err := scanTenant(tx.QueryRowContext(ctx, update, tenantID, status))
+		if err != nil {
+			return fmt.Errorf("tenant %s: failed to set status: %w", tenantID, err)
+		}
+
+		if err := tm.scope(ctx, tx, tenantID); err != nil {
+			return err
+		}
+		return tm.runHooks(ctx, tx, event, tenant)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("### 🗄️ Database: Tenant %s is now %s", tenantID, status)
+	return nil
+}
+
+// GetTenant returns a tenant from the registry
+func (tm *TenantManager) GetTenant(ctx context.Context, tenantID string) (Tenant, error) {
+	db, err := tm.db.openDB()
+	if err != nil {
+		return Tenant{}, err
+	}
+
+	query := fmt.Sprintf(`SELECT id, name, status, created_at, updated_at FROM %s WHERE id = $1`, tm.table())
+	tenant, err := scanTenant(db.QueryRowContext(ctx, query, tenantID))
+	if err != nil {
+		return Tenant{}, fmt.Errorf("tenant %s: %w", tenantID, err)
+	}
+	return tenant, nil
+}
+
+// TenantListOptions selects a page of tenants
+type TenantListOptions struct {
+	// Status limits the page to tenants in one state; empty lists all
+	Status TenantStatus
+	// After is the Next cursor of the previous page; empty for the first page
+	After string
+	// Limit is the page size; it defaults to 50
+	Limit int
+}
+
+// TenantList is a page of tenants, ordered by ID
+type TenantList struct {
+	Tenants []Tenant `json:"tenants"`
+	// Next is the cursor of the following page, empty on the last page
+	Next string `json:"next,omitempty"`
+}
+
+// ListTenants returns a page of tenants using keyset pagination on the tenant ID
+func (tm *TenantManager) ListTenants(ctx context.Context, opts TenantListOptions) (TenantList, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := fmt.Sprintf(`SELECT id, name, status, created_at, updated_at FROM %s`, tm.table())
+	var args []interface{}
+	if opts.Status != "" {
+		query += ` WHERE status = $1`
+		args = append(args, opts.Status)
+	}
+
+	keyset := Keyset{Columns: []string{"id"}, Limit: limit + 1}
+	if opts.After != "" {
+		keyset.After = []interface{}{opts.After}
+	}
+	query, args, err := keyset.Apply(query, args...)
+	if err != nil {
+		return TenantList{}, err
+	}
+
+	tenants, err := Collect(ctx, tm.db, query, args, func(rows *sql.Rows) (Tenant, error) {
+		return scanTenant(rows)
+	})
+	if err != nil {
+		return TenantList{}, fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	list := TenantList{Tenants: tenants}
+	if len(tenants) > limit {
+		list.Tenants = tenants[:limit]
+		list.Next = tenants[limit-1].ID
+	}
+	return list, nil
+}
+
+// DeleteTenant removes the tenant from the registry, runs the EventTenantDeleted hooks, exports the
+// tenant's data to export if it is not nil, and purges the data, all in one transaction. In
+// schema-per-tenant mode the schema is dropped; otherwise rows are deleted from Tables.
+func (tm *TenantManager) DeleteTenant(ctx context.Context, tenantID string, export io.Writer) error {
+	err := tm.inTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		remove := fmt.Sprintf(`DELETE FROM %s WHERE id = $1 RETURNING id, name, status, created_at, updated_at`,
+			tm.registryTable())
+		tenant, err := scanTenant(tx.QueryRowContext(ctx, remove, tenantID))
+		if err != nil {
+			return fmt.Errorf("tenant %s: %w", tenantID, err)
+		}
+
+		// The schema is about to be dropped, so it is not created if it is missing
+		if err := setTenantContext(ctx, tx, tm.db.config.RLSContextVarName, tenantID); err != nil {
+			return err
+		}
+		if tm.config.SchemaFunc != nil {
+			if err := setTenantSearchPath(ctx, tx, tm.config.SchemaFunc(tenantID), tenantID); err != nil {
+				return err
+			}
+		}
+		if err := tm.runHooks(ctx, tx, EventTenantDeleted, tenant); err != nil {
+			return err
+		}
+		if export != nil {
+			if err := tm.export(ctx, tx, tenantID, export, NewBackupConfig()); err != nil {
+				return err
+			}
+		}
+		return tm.purge(ctx, tx, tenantID)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("### 🗄️ Database: Deleted tenant %s", tenantID)
+	return nil
+}
+
+// ExportTenant writes the rows of Tables owned by a tenant to w as JSON lines of the form
+// {"table":"orders","row":{...}}, reporting progress after each table with WithBackupProgress
+func (tm *TenantManager) ExportTenant(ctx context.Context, tenantID string, w io.Writer,
+	options ...BackupOption) error {
+	config := NewBackupConfig(options...)
+	return tm.inTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		if err := tm.scope(ctx, tx, tenantID); err != nil {
+			return err
+		}
+		return tm.export(ctx, tx, tenantID, w, config)
+	})
+}
+
+// EnsureRegistry creates the tenant registry table if it does not exist
+func (tm *TenantManager) EnsureRegistry(ctx context.Context) error {
+	db, err := tm.db.openDB()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'active',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`, tm.table())
+
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create tenant registry: %w", err)
+	}
+	return nil
+}
+
+// inTenantTx runs fn in a transaction, committing if it succeeds
+func (tm *TenantManager) inTenantTx(ctx context.Context, tenantID string, fn func(tx *sql.Tx) error) error {
+	if tenantID == "" {
+		return fmt.Errorf("tenant ID cannot be empty")
+	}
+
+	db, err := tm.db.openDB()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("tenant %s: failed to begin transaction: %w", tenantID, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("tenant %s: failed to commit: %w", tenantID, err)
+	}
+	return nil
+}
+
+// scope sets the tenant context, and in schema-per-tenant mode the search path, on tx
+func (tm *TenantManager) scope(ctx context.Context, tx *sql.Tx, tenantID string) error {
+	return scopeToTenant(ctx, tx, tm.db.config.RLSContextVarName, tm.config.SchemaFunc, tenantID)
+}
+
+// runHooks runs the hooks of an event, stopping at the first error
+func (tm *TenantManager) runHooks(ctx context.Context, tx *sql.Tx, event TenantEvent, tenant Tenant) error {
+	for _, hook := range tm.config.Hooks[event] {
+		if err := hook(ctx, tx, tenant); err != nil {
+			return fmt.Errorf("tenant %s: %s hook failed: %w", tenant.ID, event, err)
+		}
+	}
+	return nil
+}
+
+// export writes the tenant's rows of each table as JSON lines
+func (tm *TenantManager) export(ctx context.Context, tx *sql.Tx, tenantID string, w io.Writer,
+	config *BackupConfig) error {
+	counter := &countingWriter{w: w}
+	enc := json.NewEncoder(counter)
+	for _, table := range tm.config.Tables {
+		query, args := tm.tenantRows("SELECT row_to_json(t)::text FROM %s t", table, tenantID)
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("tenant %s: failed to export %s: %w", tenantID, table, err)
+		}
+
+		count, err := exportRows(rows, table, enc)
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("tenant %s: failed to export %s: %w", tenantID, table, err)
+		}
+		config.report(BackupProgress{Table: table, Rows: count, Bytes: counter.n})
+	}
+	config.report(BackupProgress{Bytes: counter.n, Done: true})
+	return nil
+}
+
+// exportLine is a line of a tenant export
+type exportLine struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// exportRows encodes each JSON row of a table, returning how many there were
+func exportRows(rows *sql.Rows, table string, enc *json.Encoder) (int64, error) {
+	var count int64
+	for rows.Next() {
+		var row string
+		if err := rows.Scan(&row); err != nil {
+			return count, err
+		}
+		if err := enc.Encode(exportLine{Table: table, Row: json.RawMessage(row)}); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// purge removes the tenant's data: its schema, or its rows of each table in reverse order
+func (tm *TenantManager) purge(ctx context.Context, tx *sql.Tx, tenantID string) error {
+	if tm.config.SchemaFunc != nil {
+		schema := pq.QuoteIdentifier(tm.config.SchemaFunc(tenantID))
+		if _, err := tx.ExecContext(ctx, "DROP SCHEMA IF EXISTS "+schema+" CASCADE"); err != nil {
+			return fmt.Errorf("tenant %s: failed to drop schema: %w", tenantID, err)
+		}
+		return nil
+	}
+
+	for _, table := range slices.Backward(tm.config.Tables) {
+		query, args := tm.tenantRows("DELETE FROM %s t", table, tenantID)
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("tenant %s: failed to purge %s: %w", tenantID, table, err)
+		}
+	}
+	return nil
+}
+
+// tenantRows formats a statement over a table aliased t, limited to the tenant's rows in shared
+// schema mode
+func (tm *TenantManager) tenantRows(format, table, tenantID string) (string, []interface{}) {
+	query := fmt.Sprintf(format, quoteColumn(table))
+	if tm.config.SchemaFunc != nil {
+		return query, nil
+	}
+	return query + " WHERE t." + pq.QuoteIdentifier(tm.config.TenantColumn) + " = $1", []interface{}{tenantID}
+}
+
+// table returns the quoted registry table
+func (tm *TenantManager) table() string {
+	return quoteColumn(tm.config.TableName)
+}
+
+// registryTable returns the quoted registry table, qualified with public in schema-per-tenant mode
+// when it has no schema, so it resolves however the search path is set
+func (tm *TenantManager) registryTable() string {
+	if tm.config.SchemaFunc != nil && !strings.Contains(tm.config.TableName, ".") {
+		return quoteColumn("public." + tm.config.TableName)
+	}
+	return tm.table()
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTenant scans a registry row, mapping no rows to ErrTenantNotFound
+func scanTenant(row rowScanner) (Tenant, error) {
+	var t Tenant
+	err := row.Scan(&t.ID, &t.Name, &t.Status, &t.CreatedAt, &t.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Tenant{}, ErrTenantNotFound
+	}
+	return t, err
+}
@@ -0,0 +1,237 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// statements returns the recorded statements, without whitespace differences
+func statements(fd *fakeDriver) []string {
+	var queries []string
+	for _, call := range fd.recorded() {
+		queries = append(queries, strings.Join(strings.Fields(call.query), " "))
+	}
+	return queries
+}
+
+func TestTenantManagerConfig(t *testing.T) {
+	config := DefaultTenantManagerConfig()
+	if config.TableName != "tenants" || config.TenantColumn != "tenant_id" || config.SchemaFunc != nil {
+		t.Errorf("Unexpected defaults %+v", config)
+	}
+
+	hook := func(context.Context, *sql.Tx, Tenant) error { return nil }
+	config = NewTenantManagerConfig(
+		WithTenantRegistryTable("app.tenants"),
+		WithSchemaPerTenant(func(tenantID string) string { return "tenant_" + tenantID }),
+		WithTenantTables("orders", "order_items"),
+		WithTenantColumn("org_id"),
+		WithTenantHook(EventTenantCreated, hook),
+		WithTenantHook(EventTenantCreated, hook),
+	)
+	if config.TableName != "app.tenants" || config.TenantColumn != "org_id" || len(config.Tables) != 2 {
+		t.Errorf("Unexpected config %+v", config)
+	}
+	if config.SchemaFunc("acme") != "tenant_acme" || len(config.Hooks[EventTenantCreated]) != 2 {
+		t.Error("Expected the schema func and both hooks to be set")
+	}
+}
+
+func TestCreateTenant(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	now := time.Now().UTC()
+	fd.on(`INSERT INTO "tenants"`, []string{"status", "created_at", "updated_at"}, []driver.Value{"active", now, now})
+
+	var hooked Tenant
+	provision := func(ctx context.Context, tx *sql.Tx, tenant Tenant) error {
+		hooked = tenant
+		_, err := tx.ExecContext(ctx, "INSERT INTO settings (tenant_id) VALUES ($1)", tenant.ID)
+		return err
+	}
+	tm := NewTenantManager(p, WithTenantHook(EventTenantCreated, provision))
+
+	tenant, err := tm.CreateTenant(context.Background(), "acme", "Acme Corp")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tenant.ID != "acme" || tenant.Name != "Acme Corp" || tenant.Status != TenantActive || !tenant.CreatedAt.Equal(now) {
+		t.Errorf("Unexpected tenant %+v", tenant)
+	}
+	if hooked.ID != "acme" {
+		t.Errorf("Expected the hook to see the tenant, got %+v", hooked)
+	}
+
+	want := []string{
+		`INSERT INTO "tenants" (id, name) VALUES ($1, $2) RETURNING status, created_at, updated_at`,
+		`SELECT set_config($1, $2, true)`,
+		`INSERT INTO settings (tenant_id) VALUES ($1)`,
+		`COMMIT`,
+	}
+	if got := statements(fd); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected statements\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestCreateTenantErrors(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.fail(`INSERT INTO "tenants"`, &pq.Error{Code: "23505"})
+	tm := NewTenantManager(p)
+
+	if _, err := tm.CreateTenant(context.Background(), "acme", ""); !errors.Is(err, ErrTenantExists) {
+		t.Errorf("Expected ErrTenantExists, got %v", err)
+	}
+	if _, err := tm.CreateTenant(context.Background(), "", ""); err == nil {
+		t.Error("Expected an error for an empty tenant ID")
+	}
+
+	p, fd = newFakePostgreSQL(t)
+	fd.on(`INSERT INTO "tenants"`, []string{"status", "created_at", "updated_at"},
+		[]driver.Value{"active", time.Now(), time.Now()})
+	failing := errors.New("provisioning failed")
+	tm = NewTenantManager(p, WithTenantHook(EventTenantCreated, func(context.Context, *sql.Tx, Tenant) error {
+		return failing
+	}))
+
+	if _, err := tm.CreateTenant(context.Background(), "acme", ""); !errors.Is(err, failing) {
+		t.Errorf("Expected the hook error, got %v", err)
+	}
+	if got := statements(fd); got[len(got)-1] != "ROLLBACK" {
+		t.Errorf("Expected the transaction to roll back, got %v", got)
+	}
+}
+
+func TestSuspendAndResumeTenant(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on(`UPDATE "tenants" SET status`, []string{"id", "name", "status", "created_at", "updated_at"},
+		[]driver.Value{"acme", "Acme Corp", "suspended", time.Now(), time.Now()})
+
+	var events []TenantEvent
+	record := func(event TenantEvent) TenantHook {
+		return func(context.Context, *sql.Tx, Tenant) error {
+			events = append(events, event)
+			return nil
+		}
+	}
+	tm := NewTenantManager(p,
+		WithTenantHook(EventTenantSuspended, record(EventTenantSuspended)),
+		WithTenantHook(EventTenantResumed, record(EventTenantResumed)))
+
+	if err := tm.SuspendTenant(context.Background(), "acme"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := tm.ResumeTenant(context.Background(), "acme"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) != 2 || events[0] != EventTenantSuspended || events[1] != EventTenantResumed {
+		t.Errorf("Expected suspend and resume hooks, got %v", events)
+	}
+	if calls := fd.recorded(); calls[0].args[1] != "suspended" {
+		t.Errorf("Expected the suspended status to be set, got %v", calls[0].args)
+	}
+
+	missing, _ := newFakePostgreSQL(t)
+	if err := NewTenantManager(missing).SuspendTenant(context.Background(), "ghost"); !errors.Is(err, ErrTenantNotFound) {
+		t.Errorf("Expected ErrTenantNotFound, got %v", err)
+	}
+}
+
+func TestListTenants(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	row := func(id string) []driver.Value { return []driver.Value{id, "", "active", time.Now(), time.Now()} }
+	fd.on(`FROM "tenants"`, []string{"id", "name", "status", "created_at", "updated_at"}, row("a"), row("b"), row("c"))
+	tm := NewTenantManager(p)
+
+	list, err := tm.ListTenants(context.Background(), TenantListOptions{Status: TenantActive, After: "0", Limit: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(list.Tenants) != 2 || list.Next != "b" {
+		t.Errorf("Expected a page of 2 with a next cursor, got %+v", list)
+	}
+
+	calls := fd.recorded()
+	wantQuery := `SELECT id, name, status, created_at, updated_at FROM "tenants" WHERE status = $1 AND ("id") > ($2) ` +
+		`ORDER BY "id" ASC LIMIT $3`
+	if calls[0].query != wantQuery || calls[0].args[2] != int64(3) {
+		t.Errorf("Unexpected query %q with %v", calls[0].query, calls[0].args)
+	}
+}
+
+func TestDeleteTenant(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on(`DELETE FROM "tenants"`, []string{"id", "name", "status", "created_at", "updated_at"},
+		[]driver.Value{"acme", "Acme Corp", "active", time.Now(), time.Now()})
+	fd.on("row_to_json", []string{"row_to_json"}, []driver.Value{`{"id":7}`})
+
+	deleted := false
+	tm := NewTenantManager(p, WithTenantTables("orders", "order_items"),
+		WithTenantHook(EventTenantDeleted, func(context.Context, *sql.Tx, Tenant) error {
+			deleted = true
+			return nil
+		}))
+
+	var export bytes.Buffer
+	if err := tm.DeleteTenant(context.Background(), "acme", &export); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !deleted {
+		t.Error("Expected the delete hook to run")
+	}
+
+	wantExport := `{"table":"orders","row":{"id":7}}` + "\n" + `{"table":"order_items","row":{"id":7}}` + "\n"
+	if export.String() != wantExport {
+		t.Errorf("Expected export\n%s\ngot\n%s", wantExport, export.String())
+	}
+
+	got := statements(fd)
+	if got[0] != `DELETE FROM "tenants" WHERE id = $1 RETURNING id, name, status, created_at, updated_at` {
+		t.Errorf("Expected the tenant to be unregistered first, got %s", got[0])
+	}
+	want := []string{
+		`DELETE FROM "order_items" t WHERE t."tenant_id" = $1`,
+		`DELETE FROM "orders" t WHERE t."tenant_id" = $1`,
+		`COMMIT`,
+	}
+	if tail := got[len(got)-len(want):]; strings.Join(tail, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected the purge to end with\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestDeleteTenantSchema(t *testing.T) {
+	p, fd := newFakePostgreSQL(t)
+	fd.on(`DELETE FROM "public"."tenants"`, []string{"id", "name", "status", "created_at", "updated_at"},
+		[]driver.Value{"acme", "", "active", time.Now(), time.Now()})
+	tm := NewTenantManager(p, WithTenantTables("orders"),
+		WithSchemaPerTenant(func(tenantID string) string { return "tenant_" + tenantID }))
+
+	if err := tm.DeleteTenant(context.Background(), "acme", nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got := statements(fd)
+	want := []string{
+		`DELETE FROM "public"."tenants" WHERE id = $1 RETURNING id, name, status, created_at, updated_at`,
+		`SELECT set_config($1, $2, true)`,
+		`SET LOCAL search_path TO "tenant_acme", public`,
+		`DROP SCHEMA IF EXISTS "tenant_acme" CASCADE`,
+		`COMMIT`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected statements\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	missing, _ := newFakePostgreSQL(t)
+	err := NewTenantManager(missing).DeleteTenant(context.Background(), "ghost", nil)
+	if !errors.Is(err, ErrTenantNotFound) {
+		t.Errorf("Expected ErrTenantNotFound, got %v", err)
+	}
+}
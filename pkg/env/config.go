@@ -0,0 +1,498 @@
+package env
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Okja-Engineering/go-service-kit/pkg/validate"
+	"gopkg.in/yaml.v3"
+)
+
+// Logger is the logging interface used when reloading configuration
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// LoaderConfig holds configuration for loading a typed config
+type LoaderConfig struct {
+	// Path is the config file; its extension picks the format: .yaml, .yml, .json, or .toml
+	Path string
+	// Optional allows the file to be missing, leaving defaults and environment variables
+	Optional bool
+	// EnvPrefix is prepended to environment variable names, e.g. APP gives APP_DATABASE_HOST
+	EnvPrefix string
+	// Provider supplies environment variables for overlays and ${VAR} expansion
+	Provider EnvironmentProvider
+	// Expand replaces ${VAR} and ${VAR:-default} in the file before it is parsed
+	Expand bool
+	// ReloadSignals trigger a reload in WatchSignals
+	ReloadSignals []os.Signal
+	Logger        Logger
+}
+
+// DefaultLoaderConfig provides sensible defaults
+func DefaultLoaderConfig() *LoaderConfig {
+	return &LoaderConfig{
+		Provider:      &DefaultEnvironmentProvider{},
+		Expand:        true,
+		ReloadSignals: []os.Signal{syscall.SIGHUP},
+		Logger:        log.Default(),
+	}
+}
+
+// LoaderOption is a functional option for loading a typed config
+type LoaderOption func(*LoaderConfig)
+
+// WithConfigFile sets the config file to load
+func WithConfigFile(path string) LoaderOption {
+	return func(config *LoaderConfig) {
+		config.Path = path
+	}
+}
+
+// WithOptionalFile allows the config file to be missing
+func WithOptionalFile(optional bool) LoaderOption {
+	return func(config *LoaderConfig) {
+		config.Optional = optional
+	}
+}
+
+// WithEnvPrefix sets the prefix of environment variable overlays
+func WithEnvPrefix(prefix string) LoaderOption {
+	return func(config *LoaderConfig) {
+		config.EnvPrefix = prefix
+	}
+}
+
+// WithConfigProvider sets where environment variables are read from
+func WithConfigProvider(provider EnvironmentProvider) LoaderOption {
+	return func(config *LoaderConfig) {
+		config.Provider = provider
+	}
+}
+
+// WithExpansion enables/disables ${VAR} expansion in the config file
+func WithExpansion(expand bool) LoaderOption {
+	return func(config *LoaderConfig) {
+		config.Expand = expand
+	}
+}
+
+// WithReloadSignals sets the signals that trigger a reload in WatchSignals
+func WithReloadSignals(signals ...os.Signal) LoaderOption {
+	return func(config *LoaderConfig) {
+		config.ReloadSignals = signals
+	}
+}
+
+// WithLoaderLogger sets the logger for reloads
+func WithLoaderLogger(logger Logger) LoaderOption {
+	return func(config *LoaderConfig) {
+		config.Logger = logger
+	}
+}
+
+// NewLoaderConfig creates a new loader config with options
+func NewLoaderConfig(options ...LoaderOption) *LoaderConfig {
+	config := DefaultLoaderConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Loader holds a typed config built from field defaults, a config file, and environment variables,
+// in increasing order of precedence. It can be reloaded while the service runs.
+type Loader[T any] struct {
+	config  *LoaderConfig
+	current atomic.Pointer[T]
+
+	mu       sync.Mutex
+	onReload []func(old, updated T)
+}
+
+// Load builds a config of type T. Fields start from their `default` tags, are overwritten by the config
+// file, then by environment variables, and the result is checked with validate.Struct. Environment
+// variable names are the prefix and the field path in upper snake case, e.g. APP_DATABASE_MAX_CONNS,
+// or an `env` tag on the field.
+func Load[T any](options ...LoaderOption) (*Loader[T], error) {
+	l := &Loader[T]{config: NewLoaderConfig(options...)}
+
+	cfg, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+	l.current.Store(cfg)
+
+	return l, nil
+}
+
+// Get returns the current config. It is a copy, so changes to it aren't seen by other callers, but
+// slices and maps are shared and must not be modified.
+func (l *Loader[T]) Get() T {
+	return *l.current.Load()
+}
+
+// OnReload registers fn to run after a successful reload with the old and new config
+func (l *Loader[T]) OnReload(fn func(old, updated T)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onReload = append(l.onReload, fn)
+}
+
+// Reload loads the config again. If loading fails the current config is kept and the error returned.
+func (l *Loader[T]) Reload() error {
+	cfg, err := l.load()
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	hooks := append([]func(old, updated T){}, l.onReload...)
+	old := l.current.Swap(cfg)
+	l.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(*old, *cfg)
+	}
+	return nil
+}
+
+// WatchSignals reloads the config whenever a reload signal (SIGHUP by default) arrives, until ctx is done.
+// Failed reloads are logged and the previous config stays in place.
+func (l *Loader[T]) WatchSignals(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, l.config.ReloadSignals...)
+
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signals:
+				if err := l.Reload(); err != nil {
+					l.config.Logger.Printf("### ⚙️ Config: reload on %s failed, keeping previous config: %v", sig, err)
+					continue
+				}
+				l.config.Logger.Printf("### ⚙️ Config: reloaded on %s", sig)
+			}
+		}
+	}()
+}
+
+// load builds a fresh config from defaults, the file, and the environment
+func (l *Loader[T]) load() (*T, error) {
+	cfg := new(T)
+	root := reflect.ValueOf(cfg).Elem()
+	if root.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config type %T must be a struct", *cfg)
+	}
+
+	if err := applyDefaults(root); err != nil {
+		return nil, err
+	}
+	if err := l.loadFile(cfg); err != nil {
+		return nil, err
+	}
+	if err := applyEnv(root, l.config.EnvPrefix, l.config.Provider); err != nil {
+		return nil, err
+	}
+	if err := validate.Struct(cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// loadFile decodes the config file into cfg, rejecting keys that don't match a field
+func (l *Loader[T]) loadFile(cfg *T) error {
+	path := l.config.Path
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && l.config.Optional {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if l.config.Expand {
+		if data, err = expandVars(data, l.config.Provider); err != nil {
+			return fmt.Errorf("failed to expand %s: %w", path, err)
+		}
+	}
+
+	if err := decodeFile(path, data, cfg); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// decodeFile parses data in the format given by the file extension
+func decodeFile(path string, data []byte, v interface{}) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		return decoder.Decode(v)
+	case ".toml":
+		meta, err := toml.Decode(string(data), v)
+		if err != nil {
+			return err
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("unknown field %q", undecoded[0].String())
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported config format %q", filepath.Ext(path))
+	}
+}
+
+// varPattern matches ${VAR} and ${VAR:-default}
+var varPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandVars replaces variable references, failing on variables that are unset and have no default
+func expandVars(data []byte, provider EnvironmentProvider) ([]byte, error) {
+	var missing []string
+
+	expanded := varPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		groups := varPattern.FindSubmatch(match)
+		if value, ok := provider.Lookup(string(groups[1])); ok {
+			return []byte(value)
+		}
+		if groups[2] != nil {
+			return groups[3]
+		}
+		missing = append(missing, string(groups[1]))
+		return match
+	})
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("undefined variables: %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// applyDefaults sets fields from their `default` tags
+func applyDefaults(v reflect.Value) error {
+	return walkFields(v, "", func(field reflect.Value, sf reflect.StructField, _ string) error {
+		value, ok := sf.Tag.Lookup("default")
+		if !ok {
+			return nil
+		}
+		if err := setFromString(field, value); err != nil {
+			return fmt.Errorf("invalid default for %s: %w", sf.Name, err)
+		}
+		return nil
+	})
+}
+
+// applyEnv overwrites fields that have a matching environment variable
+func applyEnv(v reflect.Value, prefix string, provider EnvironmentProvider) error {
+	return walkFields(v, prefix, func(field reflect.Value, _ reflect.StructField, name string) error {
+		value, ok := provider.Lookup(name)
+		if !ok {
+			return nil
+		}
+		if err := setFromString(field, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// walkFields calls fn for each settable leaf field with its environment variable name, descending into
+// nested structs and non-nil struct pointers
+func walkFields(v reflect.Value, prefix string, fn func(reflect.Value, reflect.StructField, string) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := envName(sf)
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		if err := walkField(v.Field(i), sf, name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkField calls fn on a leaf field or walks into a nested struct
+func walkField(field reflect.Value, sf reflect.StructField, name string,
+	fn func(reflect.Value, reflect.StructField, string) error) error {
+	if isLeaf(field) {
+		return fn(field, sf, name)
+	}
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() || field.Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		field = field.Elem()
+	}
+	return walkFields(field, name, fn)
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isLeaf reports whether a field is set from a single string rather than walked into
+func isLeaf(field reflect.Value) bool {
+	if reflect.PointerTo(field.Type()).Implements(textUnmarshalerType) {
+		return true
+	}
+	return field.Kind() != reflect.Struct && field.Kind() != reflect.Ptr
+}
+
+// envName returns the variable name segment for a field: its env tag, or its file key or name in upper snake case
+func envName(sf reflect.StructField) string {
+	if name := sf.Tag.Get("env"); name != "" {
+		return name
+	}
+	for _, key := range []string{"yaml", "json", "toml"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(key), ","); name != "" && name != "-" {
+			return upperSnake(name)
+		}
+	}
+	return upperSnake(sf.Name)
+}
+
+// upperSnake converts camelCase, PascalCase, and kebab-case to UPPER_SNAKE_CASE
+func upperSnake(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if r == '-' || r == '.' {
+			r = '_'
+		}
+		if i > 0 && startsWord(runes, i) {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// startsWord reports whether the upper case rune at i begins a new word, as in maxConns or HTTPTimeout
+func startsWord(runes []rune, i int) bool {
+	if !unicode.IsUpper(runes[i]) {
+		return false
+	}
+	if unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) {
+		return true
+	}
+	return unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+}
+
+// setFromString parses value into a field of a supported kind
+func setFromString(field reflect.Value, value string) error {
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		return setSlice(field, value)
+	default:
+		return setNumber(field, value)
+	}
+	return nil
+}
+
+// setNumber parses value into an integer or float field
+func setNumber(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// setSlice parses a comma-separated list
+func setSlice(field reflect.Value, value string) error {
+	parts := strings.Split(value, ",")
+	if value == "" {
+		parts = nil
+	}
+
+	slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+	for i, part := range parts {
+		if err := setFromString(slice.Index(i), strings.TrimSpace(part)); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
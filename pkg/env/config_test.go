@@ -0,0 +1,367 @@
+package env
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type testDatabaseConfig struct {
+	Host     string        `yaml:"host" json:"host" toml:"host" default:"localhost"`
+	MaxConns int           `yaml:"maxConns" json:"maxConns" toml:"maxConns" default:"10"`
+	Timeout  time.Duration `yaml:"timeout" json:"timeout" toml:"timeout" default:"5s"`
+}
+
+type testConfig struct {
+	Name     string             `yaml:"name" json:"name" toml:"name" validate:"required"`
+	Port     int                `yaml:"port" json:"port" toml:"port" default:"8080" validate:"min=1,max=65535"`
+	Debug    bool               `yaml:"debug" json:"debug" toml:"debug"`
+	Tags     []string           `yaml:"tags" json:"tags" toml:"tags"`
+	Secret   string             `yaml:"secret" json:"secret" toml:"secret" env:"SERVICE_SECRET"`
+	Database testDatabaseConfig `yaml:"database" json:"database" toml:"database"`
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func mockEnv(values map[string]string) LoaderOption {
+	return WithConfigProvider(&MockEnvironmentProvider{values: values})
+}
+
+func TestLoadFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `name: orders
+port: 9000
+tags: [a, b]
+database:
+  host: db.internal
+  timeout: 2s
+`,
+		},
+		{
+			name: "json",
+			file: "config.json",
+			content: `{"name": "orders", "port": 9000, "tags": ["a", "b"],
+				"database": {"host": "db.internal", "timeout": 2000000000}}`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			content: `name = "orders"
+port = 9000
+tags = ["a", "b"]
+
+[database]
+host = "db.internal"
+timeout = "2s"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, err := Load[testConfig](WithConfigFile(writeConfigFile(t, tt.file, tt.content)), mockEnv(nil))
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			cfg := loader.Get()
+			if cfg.Name != "orders" || cfg.Port != 9000 {
+				t.Errorf("got name %q port %d, want orders 9000", cfg.Name, cfg.Port)
+			}
+			if strings.Join(cfg.Tags, ",") != "a,b" {
+				t.Errorf("got tags %v, want [a b]", cfg.Tags)
+			}
+			if cfg.Database.Host != "db.internal" || cfg.Database.Timeout != 2*time.Second {
+				t.Errorf("got database %+v", cfg.Database)
+			}
+			if cfg.Database.MaxConns != 10 {
+				t.Errorf("expected default MaxConns 10, got %d", cfg.Database.MaxConns)
+			}
+		})
+	}
+}
+
+func TestLoadEnvOverlay(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "name: orders\nport: 9000\n")
+
+	loader, err := Load[testConfig](
+		WithConfigFile(path),
+		WithEnvPrefix("APP"),
+		mockEnv(map[string]string{
+			"APP_PORT":               "9100",
+			"APP_DEBUG":              "true",
+			"APP_TAGS":               "x, y",
+			"APP_DATABASE_MAX_CONNS": "25",
+			"APP_DATABASE_TIMEOUT":   "1m",
+			"APP_SERVICE_SECRET":     "s3cret",
+			"PORT":                   "1",
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	cfg := loader.Get()
+	if cfg.Name != "orders" {
+		t.Errorf("expected file value to be kept, got %q", cfg.Name)
+	}
+	if cfg.Port != 9100 || !cfg.Debug {
+		t.Errorf("expected env to override file, got port %d debug %v", cfg.Port, cfg.Debug)
+	}
+	if strings.Join(cfg.Tags, ",") != "x,y" {
+		t.Errorf("got tags %v, want [x y]", cfg.Tags)
+	}
+	if cfg.Database.MaxConns != 25 || cfg.Database.Timeout != time.Minute {
+		t.Errorf("got database %+v", cfg.Database)
+	}
+	if cfg.Secret != "s3cret" {
+		t.Errorf("expected env tag to be used, got %q", cfg.Secret)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+		options []LoaderOption
+		wantErr string
+	}{
+		{
+			name:    "missing file",
+			options: []LoaderOption{WithConfigFile("/nonexistent/config.yaml")},
+			wantErr: "failed to read config file",
+		},
+		{
+			name:    "unknown yaml field",
+			file:    "config.yaml",
+			content: "name: orders\nprot: 9000\n",
+			wantErr: "prot",
+		},
+		{
+			name:    "unknown json field",
+			file:    "config.json",
+			content: `{"name": "orders", "prot": 9000}`,
+			wantErr: "prot",
+		},
+		{
+			name:    "unknown toml field",
+			file:    "config.toml",
+			content: "name = \"orders\"\nprot = 9000\n",
+			wantErr: "prot",
+		},
+		{
+			name:    "unsupported format",
+			file:    "config.ini",
+			content: "name=orders",
+			wantErr: "unsupported config format",
+		},
+		{
+			name:    "invalid env value",
+			file:    "config.yaml",
+			content: "name: orders\n",
+			env:     map[string]string{"PORT": "eighty"},
+			wantErr: "invalid value for PORT",
+		},
+		{
+			name:    "undefined variable",
+			file:    "config.yaml",
+			content: "name: ${SERVICE_NAME}\n",
+			wantErr: "undefined variables: SERVICE_NAME",
+		},
+		{
+			name:    "validation",
+			file:    "config.yaml",
+			content: "port: 9000\n",
+			wantErr: "invalid config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]LoaderOption{mockEnv(tt.env)}, tt.options...)
+			if tt.file != "" {
+				options = append(options, WithConfigFile(writeConfigFile(t, tt.file, tt.content)))
+			}
+
+			_, err := Load[testConfig](options...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadOptionalFile(t *testing.T) {
+	loader, err := Load[testConfig](
+		WithConfigFile(filepath.Join(t.TempDir(), "missing.yaml")),
+		WithOptionalFile(true),
+		mockEnv(map[string]string{"NAME": "orders"}),
+	)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg := loader.Get(); cfg.Name != "orders" || cfg.Port != 8080 {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestExpandVars(t *testing.T) {
+	provider := &MockEnvironmentProvider{values: map[string]string{"HOST": "db", "EMPTY": ""}}
+
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "host: ${HOST}", want: "host: db"},
+		{input: "host: ${MISSING:-fallback}", want: "host: fallback"},
+		{input: "host: ${HOST:-fallback}", want: "host: db"},
+		{input: "host: ${EMPTY}", want: "host: "},
+		{input: "cost: $5 and $HOST", want: "cost: $5 and $HOST"},
+		{input: "host: ${MISSING}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := expandVars([]byte(tt.input), provider)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandVars() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("expandVars() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadExpansionDisabled(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "name: ${SERVICE_NAME}\n")
+
+	loader, err := Load[testConfig](WithConfigFile(path), WithExpansion(false), mockEnv(nil))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := loader.Get().Name; got != "${SERVICE_NAME}" {
+		t.Errorf("expected name to be left as is, got %q", got)
+	}
+}
+
+func TestUpperSnake(t *testing.T) {
+	tests := map[string]string{
+		"maxConns":    "MAX_CONNS",
+		"MaxConns":    "MAX_CONNS",
+		"HTTPTimeout": "HTTP_TIMEOUT",
+		"userID":      "USER_ID",
+		"read-only":   "READ_ONLY",
+		"port":        "PORT",
+		"max_conns":   "MAX_CONNS",
+	}
+
+	for input, want := range tests {
+		if got := upperSnake(input); got != want {
+			t.Errorf("upperSnake(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestLoaderReload(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "name: orders\nport: 9000\n")
+
+	loader, err := Load[testConfig](WithConfigFile(path), mockEnv(nil))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var oldPort, newPort int
+	loader.OnReload(func(old, updated testConfig) {
+		oldPort, newPort = old.Port, updated.Port
+	})
+
+	if err := os.WriteFile(path, []byte("name: orders\nport: 9001\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if loader.Get().Port != 9001 || oldPort != 9000 || newPort != 9001 {
+		t.Errorf("got port %d, hook saw %d -> %d", loader.Get().Port, oldPort, newPort)
+	}
+
+	// An invalid file keeps the previous config
+	if err := os.WriteFile(path, []byte("name: orders\nport: 70000\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Reload(); err == nil {
+		t.Error("expected reload of invalid config to fail")
+	}
+	if loader.Get().Port != 9001 {
+		t.Errorf("expected previous config to be kept, got port %d", loader.Get().Port)
+	}
+}
+
+type syncLogger struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (l *syncLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logs = append(l.logs, format)
+}
+
+func TestLoaderWatchSignals(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "name: orders\nport: 9000\n")
+
+	loader, err := Load[testConfig](WithConfigFile(path), WithReloadSignals(syscall.SIGUSR1),
+		WithLoaderLogger(&syncLogger{}), mockEnv(nil))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	reloaded := make(chan int, 1)
+	loader.OnReload(func(_, updated testConfig) {
+		reloaded <- updated.Port
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	loader.WatchSignals(ctx)
+
+	if err := os.WriteFile(path, []byte("name: orders\nport: 9002\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case port := <-reloaded:
+		if port != 9002 {
+			t.Errorf("expected reloaded port 9002, got %d", port)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("config was not reloaded on signal")
+	}
+}
@@ -0,0 +1,314 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/internal/background"
+	"github.com/Okja-Engineering/go-service-kit/internal/retry"
+	"github.com/Okja-Engineering/go-service-kit/pkg/crypto"
+	"github.com/Okja-Engineering/go-service-kit/pkg/tenant"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// eventsProcessedTotal counts handled messages by outcome: success, retry, dropped, or invalid
+	eventsProcessedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_processed_total",
+		Help: "Total number of consumed event messages by outcome",
+	}, []string{"topic", "result"})
+
+	// eventsHandlerDuration observes how long handlers take
+	eventsHandlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "events_handler_duration_seconds",
+		Help:    "Duration of event message handlers",
+		Buckets: prometheus.ExponentialBuckets(0.005, 4, 10),
+	}, []string{"topic"})
+)
+
+var (
+	// ErrClosed is returned when publishing or subscribing after Close
+	ErrClosed = errors.New("events client closed")
+	// ErrInvalidMessage is returned when a received message isn't a valid envelope
+	ErrInvalidMessage = errors.New("invalid event message")
+)
+
+// Message is the JSON envelope every event is published in
+type Message struct {
+	// ID is unique per event and repeated on redelivery, so handlers can skip duplicates
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Source is the service that published the event
+	Source string    `json:"source,omitempty"`
+	Time   time.Time `json:"time"`
+	// Key orders events: brokers that partition deliver events with the same key in order
+	Key      string `json:"key,omitempty"`
+	TenantID string `json:"tenantId,omitempty"`
+	TraceID  string `json:"traceId,omitempty"`
+	// Attempt is the delivery attempt, starting at 1. It is set by subscribers and isn't published.
+	Attempt int             `json:"-"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// NewMessage creates a message with a new ID, encoding data as JSON. The tenant and trace IDs are taken
+// from ctx, so events published while handling a request or another event carry them along.
+func NewMessage(ctx context.Context, eventType string, data interface{}) (*Message, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event data: %w", err)
+	}
+
+	id, err := crypto.GenerateSecureTokenWithLength(16)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		ID:       "msg_" + id,
+		Type:     eventType,
+		Time:     time.Now().UTC(),
+		TenantID: TenantFromContext(ctx),
+		TraceID:  TraceFromContext(ctx),
+		Data:     raw,
+	}, nil
+}
+
+// Decode unmarshals the message data into v
+func (m *Message) Decode(v interface{}) error {
+	if err := json.Unmarshal(m.Data, v); err != nil {
+		return fmt.Errorf("failed to decode %s event %s: %w", m.Type, m.ID, err)
+	}
+	return nil
+}
+
+// Handler processes a message. Returning an error redelivers the message until its attempts are used up,
+// so handlers should be idempotent.
+type Handler func(ctx context.Context, msg *Message) error
+
+// Publisher sends messages to a topic
+type Publisher interface {
+	Publish(ctx context.Context, topic string, msg *Message) error
+}
+
+// Subscriber delivers messages from a topic to a handler. Subscribers sharing a group split the
+// messages between them, each message going to one of them.
+type Subscriber interface {
+	Subscribe(ctx context.Context, topic, group string, handler Handler) error
+	Close(ctx context.Context) error
+}
+
+// Publish creates a message for data and publishes it to topic, returning the message ID
+func Publish(ctx context.Context, p Publisher, topic, eventType string, data interface{}) (string, error) {
+	msg, err := NewMessage(ctx, eventType, data)
+	if err != nil {
+		return "", err
+	}
+	if err := p.Publish(ctx, topic, msg); err != nil {
+		return "", err
+	}
+	return msg.ID, nil
+}
+
+// Logger is the logging interface used for handler failures
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Config holds configuration shared by the broker implementations
+type Config struct {
+	// Source is recorded in published messages that don't set one, typically the service name
+	Source string
+	// MaxAttempts is how often a message is delivered before it is dropped; zero retries forever
+	MaxAttempts int
+	// RetryDelay is the delay before redelivering a failed message, doubling each attempt up to MaxRetryDelay
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	// HandlerTimeout bounds each handler call; zero means no timeout
+	HandlerTimeout time.Duration
+	Logger         Logger
+}
+
+// DefaultConfig provides sensible defaults
+func DefaultConfig() *Config {
+	return &Config{
+		MaxAttempts:    5,
+		RetryDelay:     time.Second,
+		MaxRetryDelay:  time.Minute,
+		HandlerTimeout: 30 * time.Second,
+		Logger:         log.Default(),
+	}
+}
+
+// Option is a functional option for configuring publishers and subscribers
+type Option func(*Config)
+
+// WithSource sets the source recorded in published messages
+func WithSource(source string) Option {
+	return func(config *Config) {
+		config.Source = source
+	}
+}
+
+// WithMaxAttempts sets how often a message is delivered before it is dropped
+func WithMaxAttempts(attempts int) Option {
+	return func(config *Config) {
+		config.MaxAttempts = attempts
+	}
+}
+
+// WithRetryDelay sets the redelivery delay, which starts at base and doubles up to max
+func WithRetryDelay(base, max time.Duration) Option {
+	return func(config *Config) {
+		config.RetryDelay = base
+		config.MaxRetryDelay = max
+	}
+}
+
+// WithHandlerTimeout sets the timeout for each handler call; zero turns it off
+func WithHandlerTimeout(timeout time.Duration) Option {
+	return func(config *Config) {
+		config.HandlerTimeout = timeout
+	}
+}
+
+// WithLogger sets the logger for handler failures
+func WithLogger(logger Logger) Option {
+	return func(config *Config) {
+		config.Logger = logger
+	}
+}
+
+// NewConfig creates a new events config with options
+func NewConfig(options ...Option) *Config {
+	config := DefaultConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// retryDelay returns the delay before redelivering after the given attempt
+func (c *Config) retryDelay(attempt int) time.Duration {
+	return retry.Backoff(c.RetryDelay, c.MaxRetryDelay, attempt)
+}
+
+// lastAttempt reports whether a failed attempt should not be retried
+func (c *Config) lastAttempt(attempt int) bool {
+	return c.MaxAttempts > 0 && attempt >= c.MaxAttempts
+}
+
+// encode marshals a message for publishing, filling in the source
+func (c *Config) encode(msg *Message) ([]byte, error) {
+	if msg.ID == "" || msg.Type == "" {
+		return nil, fmt.Errorf("%w: ID and type are required", ErrInvalidMessage)
+	}
+	if msg.Source == "" {
+		msg.Source = c.Source
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event %s: %w", msg.ID, err)
+	}
+	return body, nil
+}
+
+// decode unmarshals a received envelope
+func decode(body []byte, attempt int) (*Message, error) {
+	msg := &Message{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if msg.ID == "" || msg.Type == "" {
+		return nil, fmt.Errorf("%w: missing ID or type", ErrInvalidMessage)
+	}
+	msg.Attempt = attempt
+	return msg, nil
+}
+
+// handle runs handler for a message with the tenant and trace IDs restored to ctx, recording the outcome.
+// Panics are recovered and returned as errors.
+func (c *Config) handle(ctx context.Context, topic string, msg *Message, handler Handler) (err error) {
+	ctx = contextFromMessage(ctx, msg)
+	cancel := func() {}
+	if c.HandlerTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.HandlerTimeout)
+	}
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		if rec := recover(); rec != nil {
+			c.Logger.Printf("### 💥 Events: %s handler for %s panicked: %v\n%s", topic, msg.ID, rec, debug.Stack())
+			err = background.PanicError("event handler", rec)
+		}
+		eventsHandlerDuration.WithLabelValues(topic).Observe(time.Since(start).Seconds())
+		c.record(topic, msg, err)
+	}()
+
+	return handler(ctx, msg)
+}
+
+// record counts a handled message and logs failures
+func (c *Config) record(topic string, msg *Message, err error) {
+	switch {
+	case err == nil:
+		eventsProcessedTotal.WithLabelValues(topic, "success").Inc()
+	case c.lastAttempt(msg.Attempt):
+		eventsProcessedTotal.WithLabelValues(topic, "dropped").Inc()
+		c.Logger.Printf("### 📨 Events: dropping %s %s after %d attempts: %v", topic, msg.ID, msg.Attempt, err)
+	default:
+		eventsProcessedTotal.WithLabelValues(topic, "retry").Inc()
+		c.Logger.Printf("### 📨 Events: %s %s attempt %d failed, retrying: %v", topic, msg.ID, msg.Attempt, err)
+	}
+}
+
+// invalid counts and logs a message that couldn't be decoded; it is never retried
+func (c *Config) invalid(topic string, err error) {
+	eventsProcessedTotal.WithLabelValues(topic, "invalid").Inc()
+	c.Logger.Printf("### 📨 Events: discarding message on %s: %v", topic, err)
+}
+
+type traceContextKey struct{}
+
+// WithTenant returns a context whose published events carry tenantID, as tenant.WithID
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return tenant.WithID(ctx, tenantID)
+}
+
+// TenantFromContext returns the tenant ID set with WithTenant, tenant.WithID, or api.TenantMiddleware, or
+// restored from a received message
+func TenantFromContext(ctx context.Context) string {
+	return tenant.ID(ctx)
+}
+
+// WithTrace returns a context whose published events carry traceID
+func WithTrace(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, traceID)
+}
+
+// TraceFromContext returns the trace ID set with WithTrace or restored from a received message, falling back
+// to the request ID set by chi's RequestID middleware
+func TraceFromContext(ctx context.Context) string {
+	if traceID, ok := ctx.Value(traceContextKey{}).(string); ok {
+		return traceID
+	}
+	return middleware.GetReqID(ctx)
+}
+
+// contextFromMessage restores a message's tenant and trace IDs for its handler
+func contextFromMessage(ctx context.Context, msg *Message) context.Context {
+	if msg.TenantID != "" {
+		ctx = WithTenant(ctx, msg.TenantID)
+	}
+	if msg.TraceID != "" {
+		ctx = WithTrace(ctx, msg.TraceID)
+	}
+	return ctx
+}
@@ -0,0 +1,250 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/internal/background"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type syncLogger struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *syncLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(&l.buf, format+"\n", v...)
+}
+
+func (l *syncLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+func TestNewConfigDefaults(t *testing.T) {
+	config := NewConfig()
+
+	if config.MaxAttempts != 5 || config.HandlerTimeout != 30*time.Second {
+		t.Errorf("Expected 5 attempts and a 30s handler timeout, got %d and %s", config.MaxAttempts,
+			config.HandlerTimeout)
+	}
+	if config.RetryDelay != time.Second || config.MaxRetryDelay != time.Minute {
+		t.Errorf("Expected retry delay from 1s to 1m, got %s to %s", config.RetryDelay, config.MaxRetryDelay)
+	}
+}
+
+func TestNewConfigOptions(t *testing.T) {
+	logger := &syncLogger{}
+	config := NewConfig(
+		WithSource("orders"),
+		WithMaxAttempts(2),
+		WithRetryDelay(time.Millisecond, time.Second),
+		WithHandlerTimeout(time.Minute),
+		WithLogger(logger),
+	)
+
+	if config.Source != "orders" || config.MaxAttempts != 2 || config.HandlerTimeout != time.Minute {
+		t.Errorf("Expected options to be applied, got %+v", config)
+	}
+	if config.RetryDelay != time.Millisecond || config.MaxRetryDelay != time.Second || config.Logger != logger {
+		t.Errorf("Expected retry delay and logger to be overridden, got %+v", config)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	config := NewConfig(WithRetryDelay(time.Second, time.Minute))
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{6, 32 * time.Second},
+		{7, time.Minute},
+		{100, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := config.retryDelay(tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestLastAttempt(t *testing.T) {
+	if NewConfig(WithMaxAttempts(3)).lastAttempt(2) || !NewConfig(WithMaxAttempts(3)).lastAttempt(3) {
+		t.Error("Expected the third of three attempts to be the last")
+	}
+	if NewConfig(WithMaxAttempts(0)).lastAttempt(1000) {
+		t.Error("Expected zero max attempts to retry forever")
+	}
+}
+
+func TestNewMessage(t *testing.T) {
+	ctx := WithTrace(WithTenant(context.Background(), "acme"), "trace-1")
+
+	msg, err := NewMessage(ctx, "order.created", map[string]string{"orderId": "42"})
+	if err != nil {
+		t.Fatalf("NewMessage failed: %v", err)
+	}
+
+	if !strings.HasPrefix(msg.ID, "msg_") || msg.Type != "order.created" || msg.Time.IsZero() {
+		t.Errorf("Expected ID, type, and time to be set, got %+v", msg)
+	}
+	if msg.TenantID != "acme" || msg.TraceID != "trace-1" {
+		t.Errorf("Expected tenant and trace from context, got %q and %q", msg.TenantID, msg.TraceID)
+	}
+
+	var data struct {
+		OrderID string `json:"orderId"`
+	}
+	if err := msg.Decode(&data); err != nil || data.OrderID != "42" {
+		t.Errorf("Expected data to decode, got %+v and %v", data, err)
+	}
+
+	other, _ := NewMessage(ctx, "order.created", nil)
+	if other.ID == msg.ID {
+		t.Error("Expected message IDs to be unique")
+	}
+}
+
+func TestNewMessageInvalidData(t *testing.T) {
+	if _, err := NewMessage(context.Background(), "order.created", make(chan int)); err == nil {
+		t.Error("Expected unencodable data to fail")
+	}
+}
+
+func TestTraceFromContextRequestID(t *testing.T) {
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-1")
+	if got := TraceFromContext(ctx); got != "req-1" {
+		t.Errorf("Expected the request ID as trace, got %q", got)
+	}
+	if got := TraceFromContext(WithTrace(ctx, "trace-1")); got != "trace-1" {
+		t.Errorf("Expected an explicit trace to win, got %q", got)
+	}
+	if got := TraceFromContext(context.Background()); got != "" {
+		t.Errorf("Expected no trace, got %q", got)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	config := NewConfig(WithSource("orders"))
+	msg, _ := NewMessage(WithTenant(context.Background(), "acme"), "order.created", map[string]int{"n": 1})
+	msg.Key = "order-42"
+
+	body, err := config.encode(msg)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	if !strings.Contains(string(body), `"source":"orders"`) || !strings.Contains(string(body), `"tenantId":"acme"`) {
+		t.Errorf("Unexpected envelope %s", body)
+	}
+
+	got, err := decode(body, 3)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if got.ID != msg.ID || got.Key != "order-42" || got.Attempt != 3 || string(got.Data) != `{"n":1}` {
+		t.Errorf("Expected round trip, got %+v", got)
+	}
+}
+
+func TestEncodeDecodeInvalid(t *testing.T) {
+	if _, err := NewConfig().encode(&Message{Type: "x"}); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Expected a message without ID to be rejected, got %v", err)
+	}
+
+	for _, body := range []string{"not json", `{"type":"x"}`, `{"id":"1"}`} {
+		if _, err := decode([]byte(body), 1); !errors.Is(err, ErrInvalidMessage) {
+			t.Errorf("decode(%s) error = %v, want ErrInvalidMessage", body, err)
+		}
+	}
+}
+
+func TestHandleRestoresContext(t *testing.T) {
+	config := NewConfig(WithLogger(&syncLogger{}))
+	msg := &Message{ID: "msg_1", Type: "order.created", TenantID: "acme", TraceID: "trace-1", Attempt: 1}
+
+	var tenant, trace string
+	err := config.handle(context.Background(), "orders", msg, func(ctx context.Context, msg *Message) error {
+		tenant, trace = TenantFromContext(ctx), TraceFromContext(ctx)
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("Expected the handler timeout to apply")
+		}
+		return nil
+	})
+
+	if err != nil || tenant != "acme" || trace != "trace-1" {
+		t.Errorf("Expected tenant and trace restored, got %q, %q, and %v", tenant, trace, err)
+	}
+}
+
+func TestHandleWithoutTimeout(t *testing.T) {
+	config := NewConfig(WithLogger(&syncLogger{}), WithHandlerTimeout(0))
+	msg := &Message{ID: "msg_1", Type: "order.created", Attempt: 1}
+
+	err := config.handle(context.Background(), "orders", msg, func(ctx context.Context, msg *Message) error {
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("Expected no deadline when the handler timeout is zero")
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Errorf("Expected the handler to run with a live context, got %v", err)
+	}
+}
+
+func TestHandleRecoversPanic(t *testing.T) {
+	logger := &syncLogger{}
+	config := NewConfig(WithLogger(logger))
+	msg := &Message{ID: "msg_1", Type: "order.created", Attempt: 1}
+
+	err := config.handle(context.Background(), "orders", msg, func(ctx context.Context, msg *Message) error {
+		panic("bad event")
+	})
+
+	if !errors.Is(err, background.ErrPanic) || !strings.Contains(err.Error(), "bad event") {
+		t.Errorf("Expected panic to become an error, got %v", err)
+	}
+	if !strings.Contains(logger.String(), "retrying") {
+		t.Errorf("Expected the failure to be logged, got %q", logger.String())
+	}
+}
+
+type recordingPublisher struct {
+	topic string
+	msg   *Message
+	err   error
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, topic string, msg *Message) error {
+	p.topic, p.msg = topic, msg
+	return p.err
+}
+
+func TestPublish(t *testing.T) {
+	p := &recordingPublisher{}
+	id, err := Publish(context.Background(), p, "orders", "order.created", map[string]string{"orderId": "42"})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if p.topic != "orders" || p.msg.ID != id || p.msg.Type != "order.created" {
+		t.Errorf("Expected the message to be published, got %s and %+v", p.topic, p.msg)
+	}
+
+	p.err = errors.New("broker down")
+	if _, err := Publish(context.Background(), p, "orders", "order.created", nil); err == nil {
+		t.Error("Expected publish errors to be returned")
+	}
+}
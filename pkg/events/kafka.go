@@ -0,0 +1,222 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Headers set on Kafka records alongside the JSON envelope
+const (
+	kafkaHeaderID   = "message-id"
+	kafkaHeaderType = "event-type"
+)
+
+// KafkaRecord is a record as written to or read from a Kafka topic
+type KafkaRecord struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+}
+
+// KafkaWriter writes records to Kafka. It is a small adapter over a Kafka client, such as
+// segmentio/kafka-go's Writer, so services choose their own client; see the README.
+type KafkaWriter interface {
+	WriteRecords(ctx context.Context, records ...KafkaRecord) error
+}
+
+// KafkaReader reads a topic as a member of a consumer group, committing offsets explicitly
+type KafkaReader interface {
+	FetchRecord(ctx context.Context) (KafkaRecord, error)
+	CommitRecords(ctx context.Context, records ...KafkaRecord) error
+	Close() error
+}
+
+// KafkaReaderFunc opens a reader for a topic in a consumer group
+type KafkaReaderFunc func(topic, group string) (KafkaReader, error)
+
+// Kafka publishes and consumes events through Kafka. Messages are keyed by Message.Key, so messages with
+// the same key land on the same partition and are handled in order.
+type Kafka struct {
+	writer  KafkaWriter
+	readers KafkaReaderFunc
+	config  *Config
+	// writerCloser closes a writer Kafka created itself, as NewKafkaGo does
+	writerCloser io.Closer
+
+	mu      sync.Mutex
+	closed  bool
+	cancels []context.CancelFunc
+	open    []KafkaReader
+	loops   sync.WaitGroup
+}
+
+// NewKafka creates a Kafka publisher and subscriber. writer may be nil for services that only consume,
+// and readers nil for services that only publish.
+func NewKafka(writer KafkaWriter, readers KafkaReaderFunc, options ...Option) *Kafka {
+	return &Kafka{writer: writer, readers: readers, config: NewConfig(options...)}
+}
+
+// Publish writes msg to topic, returning once the writer has acknowledged it
+func (k *Kafka) Publish(ctx context.Context, topic string, msg *Message) error {
+	if k.writer == nil {
+		return fmt.Errorf("failed to publish %s to %s: no Kafka writer configured", msg.ID, topic)
+	}
+
+	body, err := k.config.encode(msg)
+	if err != nil {
+		return err
+	}
+
+	record := KafkaRecord{
+		Topic:   topic,
+		Value:   body,
+		Headers: map[string]string{kafkaHeaderID: msg.ID, kafkaHeaderType: msg.Type},
+	}
+	if msg.Key != "" {
+		record.Key = []byte(msg.Key)
+	}
+	if err := k.writer.WriteRecords(ctx, record); err != nil {
+		return fmt.Errorf("failed to publish %s to %s: %w", msg.ID, topic, err)
+	}
+	return nil
+}
+
+// Subscribe reads topic as a member of the consumer group. Records are handled one at a time, retrying
+// failures with backoff, and their offsets are committed once handled, so a record whose handler was
+// interrupted is read again.
+func (k *Kafka) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.closed {
+		return ErrClosed
+	}
+	if k.readers == nil {
+		return fmt.Errorf("failed to subscribe to %s: no Kafka readers configured", topic)
+	}
+
+	reader, err := k.readers(topic, group)
+	if err != nil {
+		return fmt.Errorf("failed to open reader for %s: %w", topic, err)
+	}
+
+	loopCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	k.cancels = append(k.cancels, cancel)
+	k.open = append(k.open, reader)
+	k.loops.Add(1)
+	go k.consume(loopCtx, topic, reader, handler)
+
+	k.config.Logger.Printf("### 📨 Events: consuming %s as %s", topic, group)
+	return nil
+}
+
+// consume fetches and handles records until ctx is cancelled
+func (k *Kafka) consume(ctx context.Context, topic string, reader KafkaReader, handler Handler) {
+	defer k.loops.Done()
+
+	for failures := 0; ctx.Err() == nil; {
+		record, err := reader.FetchRecord(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			failures++
+			k.config.Logger.Printf("### 📨 Events: failed to read %s: %v", topic, err)
+			sleep(ctx, k.config.retryDelay(failures))
+			continue
+		}
+		failures = 0
+
+		if !k.process(ctx, topic, record, handler) {
+			return
+		}
+		if err := reader.CommitRecords(context.WithoutCancel(ctx), record); err != nil {
+			k.config.Logger.Printf("### 📨 Events: failed to commit %s offset %d: %v", topic, record.Offset, err)
+		}
+	}
+}
+
+// process handles a record, retrying until it succeeds or its attempts are used up. It reports whether
+// the record is done with and should be committed, which it isn't when ctx is cancelled while waiting to retry.
+func (k *Kafka) process(ctx context.Context, topic string, record KafkaRecord, handler Handler) bool {
+	msg, err := decode(record.Value, 1)
+	if err != nil {
+		k.config.invalid(topic, err)
+		return true
+	}
+
+	// A handler that is running completes even if the subscriber is closed meanwhile
+	handlerCtx := context.WithoutCancel(ctx)
+	for attempt := 1; ; attempt++ {
+		msg.Attempt = attempt
+		err := k.config.handle(handlerCtx, topic, msg, handler)
+		if err == nil || k.config.lastAttempt(attempt) {
+			return true
+		}
+		if !sleep(ctx, k.config.retryDelay(attempt)) {
+			return false
+		}
+	}
+}
+
+// Close stops every subscription, waits for running handlers to finish or for ctx to be done, and closes
+// the readers. Close doesn't close a writer passed to NewKafka.
+func (k *Kafka) Close(ctx context.Context) error {
+	k.mu.Lock()
+	k.closed = true
+	cancels, readers := k.cancels, k.open
+	k.cancels, k.open = nil, nil
+	k.mu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		k.loops.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("event handlers still running at shutdown: %w", ctx.Err())
+	}
+
+	var errs []error
+	for _, reader := range readers {
+		if err := reader.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if k.writerCloser != nil {
+		if err := k.writerCloser.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(readers) > 0 {
+		k.config.Logger.Printf("### 📨 Events: Kafka subscriptions stopped")
+	}
+	return errors.Join(errs...)
+}
+
+// sleep waits for d, reporting false if ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,260 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeKafkaWriter struct {
+	records []KafkaRecord
+	err     error
+}
+
+func (w *fakeKafkaWriter) WriteRecords(ctx context.Context, records ...KafkaRecord) error {
+	w.records = append(w.records, records...)
+	return w.err
+}
+
+// fakeKafkaReader serves queued records, then blocks until its context is cancelled
+type fakeKafkaReader struct {
+	mu        sync.Mutex
+	records   chan KafkaRecord
+	committed []KafkaRecord
+	closed    bool
+}
+
+func newFakeKafkaReader(records ...KafkaRecord) *fakeKafkaReader {
+	r := &fakeKafkaReader{records: make(chan KafkaRecord, len(records))}
+	for _, record := range records {
+		r.records <- record
+	}
+	return r
+}
+
+func (r *fakeKafkaReader) FetchRecord(ctx context.Context) (KafkaRecord, error) {
+	select {
+	case record := <-r.records:
+		return record, nil
+	case <-ctx.Done():
+		return KafkaRecord{}, ctx.Err()
+	}
+}
+
+func (r *fakeKafkaReader) CommitRecords(ctx context.Context, records ...KafkaRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, records...)
+	return nil
+}
+
+func (r *fakeKafkaReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+func (r *fakeKafkaReader) state() ([]KafkaRecord, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]KafkaRecord(nil), r.committed...), r.closed
+}
+
+func encodedRecord(t *testing.T, offset int64, eventType string) KafkaRecord {
+	t.Helper()
+	msg, err := NewMessage(context.Background(), eventType, map[string]int64{"offset": offset})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := NewConfig().encode(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return KafkaRecord{Topic: "orders", Offset: offset, Value: body}
+}
+
+func TestKafkaPublish(t *testing.T) {
+	writer := &fakeKafkaWriter{}
+	k := NewKafka(writer, nil, WithSource("orders-service"))
+
+	msg, _ := NewMessage(context.Background(), "order.created", nil)
+	msg.Key = "order-42"
+	if err := k.Publish(context.Background(), "orders", msg); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	if len(writer.records) != 1 {
+		t.Fatalf("Expected one record, got %d", len(writer.records))
+	}
+	record := writer.records[0]
+	if record.Topic != "orders" || string(record.Key) != "order-42" {
+		t.Errorf("Expected record keyed for ordering, got %+v", record)
+	}
+	if record.Headers[kafkaHeaderID] != msg.ID || record.Headers[kafkaHeaderType] != "order.created" {
+		t.Errorf("Expected ID and type headers, got %v", record.Headers)
+	}
+	if !strings.Contains(string(record.Value), `"source":"orders-service"`) {
+		t.Errorf("Expected envelope with source, got %s", record.Value)
+	}
+}
+
+func TestKafkaPublishErrors(t *testing.T) {
+	msg, _ := NewMessage(context.Background(), "order.created", nil)
+
+	if err := NewKafka(nil, nil).Publish(context.Background(), "orders", msg); err == nil {
+		t.Error("Expected publishing without a writer to fail")
+	}
+
+	writer := &fakeKafkaWriter{err: errors.New("broker down")}
+	if err := NewKafka(writer, nil).Publish(context.Background(), "orders", msg); err == nil ||
+		!strings.Contains(err.Error(), "broker down") {
+		t.Errorf("Expected writer errors to be returned, got %v", err)
+	}
+}
+
+func TestKafkaSubscribe(t *testing.T) {
+	reader := newFakeKafkaReader(encodedRecord(t, 1, "order.created"), KafkaRecord{Offset: 2, Value: []byte("junk")},
+		encodedRecord(t, 3, "order.paid"))
+
+	var gotTopic, gotGroup string
+	k := NewKafka(nil, func(topic, group string) (KafkaReader, error) {
+		gotTopic, gotGroup = topic, group
+		return reader, nil
+	}, WithLogger(&syncLogger{}))
+
+	var mu sync.Mutex
+	var handled []string
+	err := k.Subscribe(context.Background(), "orders", "billing", func(ctx context.Context, msg *Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, msg.Type)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if gotTopic != "orders" || gotGroup != "billing" {
+		t.Errorf("Expected reader for orders in billing, got %s and %s", gotTopic, gotGroup)
+	}
+
+	waitFor(t, func() bool {
+		committed, _ := reader.state()
+		return len(committed) == 3
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := k.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(handled, ",") != "order.created,order.paid" {
+		t.Errorf("Expected valid messages handled in order, got %v", handled)
+	}
+	if _, closed := reader.state(); !closed {
+		t.Error("Expected Close to close the reader")
+	}
+	if err := k.Subscribe(context.Background(), "orders", "billing", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestKafkaRetries(t *testing.T) {
+	reader := newFakeKafkaReader(encodedRecord(t, 1, "order.created"))
+	k := NewKafka(nil, func(topic, group string) (KafkaReader, error) { return reader, nil },
+		WithLogger(&syncLogger{}), WithMaxAttempts(3), WithRetryDelay(time.Millisecond, time.Millisecond))
+
+	var mu sync.Mutex
+	var attempts []int
+	_ = k.Subscribe(context.Background(), "orders", "billing", func(ctx context.Context, msg *Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts = append(attempts, msg.Attempt)
+		return errors.New("not yet")
+	})
+
+	waitFor(t, func() bool {
+		committed, _ := reader.state()
+		return len(committed) == 1
+	})
+	_ = k.Close(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(attempts) != 3 || attempts[0] != 1 || attempts[2] != 3 {
+		t.Errorf("Expected three numbered attempts before giving up, got %v", attempts)
+	}
+}
+
+func TestKafkaCloseDuringRetryDoesNotCommit(t *testing.T) {
+	reader := newFakeKafkaReader(encodedRecord(t, 1, "order.created"))
+	k := NewKafka(nil, func(topic, group string) (KafkaReader, error) { return reader, nil },
+		WithLogger(&syncLogger{}), WithRetryDelay(time.Hour, time.Hour))
+
+	called := make(chan struct{}, 1)
+	_ = k.Subscribe(context.Background(), "orders", "billing", func(ctx context.Context, msg *Message) error {
+		called <- struct{}{}
+		return errors.New("not yet")
+	})
+	<-called
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := k.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if committed, _ := reader.state(); len(committed) != 0 {
+		t.Errorf("Expected the unfinished record not to be committed, got %v", committed)
+	}
+}
+
+func TestKafkaSubscribeErrors(t *testing.T) {
+	if err := NewKafka(nil, nil).Subscribe(context.Background(), "orders", "billing", nil); err == nil {
+		t.Error("Expected subscribing without readers to fail")
+	}
+
+	k := NewKafka(nil, func(topic, group string) (KafkaReader, error) { return nil, errors.New("no brokers") })
+	if err := k.Subscribe(context.Background(), "orders", "billing", nil); err == nil ||
+		!strings.Contains(err.Error(), "no brokers") {
+		t.Errorf("Expected reader errors to be returned, got %v", err)
+	}
+}
+
+func TestKafkaCloseTimeout(t *testing.T) {
+	reader := newFakeKafkaReader(encodedRecord(t, 1, "order.created"))
+	k := NewKafka(nil, func(topic, group string) (KafkaReader, error) { return reader, nil },
+		WithLogger(&syncLogger{}))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	_ = k.Subscribe(context.Background(), "orders", "billing", func(ctx context.Context, msg *Message) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := k.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Close to time out with a handler running, got %v", err)
+	}
+	close(release)
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,110 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaGoWriter adapts a segmentio/kafka-go Writer to KafkaWriter
+type kafkaGoWriter struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaGoWriter adapts a segmentio/kafka-go Writer to KafkaWriter. Use a Balancer that partitions
+// by key, such as kafka.Hash, so messages with the same key stay in order.
+func NewKafkaGoWriter(writer *kafka.Writer) KafkaWriter {
+	return kafkaGoWriter{writer: writer}
+}
+
+func (w kafkaGoWriter) WriteRecords(ctx context.Context, records ...KafkaRecord) error {
+	messages := make([]kafka.Message, len(records))
+	for i, record := range records {
+		messages[i] = toKafkaGoMessage(record)
+	}
+	return w.writer.WriteMessages(ctx, messages...)
+}
+
+// kafkaGoReader adapts a segmentio/kafka-go Reader to KafkaReader
+type kafkaGoReader struct {
+	reader *kafka.Reader
+}
+
+func (r kafkaGoReader) FetchRecord(ctx context.Context) (KafkaRecord, error) {
+	message, err := r.reader.FetchMessage(ctx)
+	if err != nil {
+		return KafkaRecord{}, err
+	}
+	return fromKafkaGoMessage(message), nil
+}
+
+func (r kafkaGoReader) CommitRecords(ctx context.Context, records ...KafkaRecord) error {
+	messages := make([]kafka.Message, len(records))
+	for i, record := range records {
+		messages[i] = kafka.Message{Topic: record.Topic, Partition: record.Partition, Offset: record.Offset}
+	}
+	return r.reader.CommitMessages(ctx, messages...)
+}
+
+func (r kafkaGoReader) Close() error {
+	return r.reader.Close()
+}
+
+// NewKafkaGoReaders opens segmentio/kafka-go readers for subscriptions, using config as a template
+// whose Topic and GroupID are set for each one. Offsets are committed by Kafka after each record is
+// handled, so leave CommitInterval at zero.
+func NewKafkaGoReaders(config kafka.ReaderConfig) KafkaReaderFunc {
+	return func(topic, group string) (KafkaReader, error) {
+		config := config
+		config.Topic, config.GroupID = topic, group
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		return kafkaGoReader{reader: kafka.NewReader(config)}, nil
+	}
+}
+
+// NewKafkaGo creates a Kafka publisher and subscriber on brokers with segmentio/kafka-go. Messages are
+// partitioned by key, and Publish returns once every in-sync replica has the message. Close also closes
+// the writer it creates. Use NewKafka with NewKafkaGoWriter and NewKafkaGoReaders for other settings,
+// such as TLS or SASL.
+func NewKafkaGo(brokers []string, options ...Option) *Kafka {
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// Publish sends one message at a time, so don't wait for a batch to fill
+		BatchTimeout: 10 * time.Millisecond,
+	}
+	k := NewKafka(NewKafkaGoWriter(writer), NewKafkaGoReaders(kafka.ReaderConfig{Brokers: brokers}), options...)
+	k.writerCloser = writer
+	return k
+}
+
+// toKafkaGoMessage converts a record to a kafka-go message
+func toKafkaGoMessage(record KafkaRecord) kafka.Message {
+	message := kafka.Message{Topic: record.Topic, Key: record.Key, Value: record.Value}
+	for key, value := range record.Headers {
+		message.Headers = append(message.Headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+	return message
+}
+
+// fromKafkaGoMessage converts a kafka-go message to a record
+func fromKafkaGoMessage(message kafka.Message) KafkaRecord {
+	record := KafkaRecord{
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+		Key:       message.Key,
+		Value:     message.Value,
+	}
+	if len(message.Headers) > 0 {
+		record.Headers = make(map[string]string, len(message.Headers))
+		for _, header := range message.Headers {
+			record.Headers[header.Key] = string(header.Value)
+		}
+	}
+	return record
+}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestKafkaGoMessageConversion(t *testing.T) {
+	record := KafkaRecord{
+		Topic:   "orders",
+		Key:     []byte("order-42"),
+		Value:   []byte(`{"id":"msg_1"}`),
+		Headers: map[string]string{kafkaHeaderID: "msg_1", kafkaHeaderType: "order.created"},
+	}
+
+	message := toKafkaGoMessage(record)
+	if message.Topic != "orders" || string(message.Key) != "order-42" || len(message.Headers) != 2 {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	message.Partition, message.Offset = 3, 17
+	got := fromKafkaGoMessage(message)
+	record.Partition, record.Offset = 3, 17
+	if !reflect.DeepEqual(got, record) {
+		t.Errorf("Expected the record back, got %+v", got)
+	}
+
+	if got := fromKafkaGoMessage(kafka.Message{Topic: "orders"}); got.Headers != nil {
+		t.Errorf("Expected no headers, got %v", got.Headers)
+	}
+}
+
+func TestNewKafkaGoReaders(t *testing.T) {
+	if _, err := NewKafkaGoReaders(kafka.ReaderConfig{})("orders", "billing"); err == nil {
+		t.Error("Expected a reader without brokers to be rejected")
+	}
+
+	reader, err := NewKafkaGoReaders(kafka.ReaderConfig{Brokers: []string{"127.0.0.1:1"}})("orders", "billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := reader.(kafkaGoReader).reader.Config()
+	if config.Topic != "orders" || config.GroupID != "billing" {
+		t.Errorf("Expected the subscription's topic and group, got %q and %q", config.Topic, config.GroupID)
+	}
+	if err := reader.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
+
+func TestNewKafkaGo(t *testing.T) {
+	k := NewKafkaGo([]string{"127.0.0.1:1"}, WithLogger(&syncLogger{}))
+	if k.writer == nil || k.readers == nil || k.writerCloser == nil {
+		t.Fatalf("Expected a writer and readers, got %+v", k)
+	}
+	if err := k.Close(context.Background()); err != nil {
+		t.Errorf("Expected Close to close the writer, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATS publishes and consumes events through NATS JetStream. Topics are subjects, which must be captured
+// by an existing stream, and groups are durable consumers on it.
+type NATS struct {
+	js     jetstream.JetStream
+	config *Config
+
+	mu        sync.Mutex
+	closed    bool
+	consumers []jetstream.ConsumeContext
+}
+
+// NewNATS creates a NATS publisher and subscriber on conn. Closing it stops the subscriptions but leaves
+// conn open.
+func NewNATS(conn *nats.Conn, options ...Option) (*NATS, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("a NATS connection is required")
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	return &NATS{js: js, config: NewConfig(options...)}, nil
+}
+
+// Publish sends msg to the topic subject. The message ID is sent as Nats-Msg-Id, so the stream discards
+// messages published twice within its duplicate window.
+func (n *NATS) Publish(ctx context.Context, topic string, msg *Message) error {
+	body, err := n.config.encode(msg)
+	if err != nil {
+		return err
+	}
+
+	m := nats.NewMsg(topic)
+	m.Data = body
+	m.Header.Set(jetstream.MsgIDHeader, msg.ID)
+	if _, err := n.js.PublishMsg(ctx, m); err != nil {
+		return fmt.Errorf("failed to publish %s to %s: %w", msg.ID, topic, err)
+	}
+	return nil
+}
+
+// Subscribe consumes the topic subject with a durable consumer named group, creating or updating it.
+// Instances subscribing with the same group share its messages. Messages are acknowledged once handler
+// returns nil, and redelivered with backoff when it fails.
+func (n *NATS) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return ErrClosed
+	}
+
+	stream, err := n.js.StreamNameBySubject(ctx, topic)
+	if err != nil {
+		return fmt.Errorf("failed to find stream for %s: %w", topic, err)
+	}
+
+	maxDeliver := n.config.MaxAttempts
+	if maxDeliver <= 0 {
+		maxDeliver = -1
+	}
+	consumer, err := n.js.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:       group,
+		FilterSubject: topic,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       2 * n.config.HandlerTimeout,
+		MaxDeliver:    maxDeliver,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create consumer %s on %s: %w", group, stream, err)
+	}
+
+	handlerCtx := context.WithoutCancel(ctx)
+	consumeCtx, err := consumer.Consume(func(m jetstream.Msg) {
+		n.receive(handlerCtx, topic, m, handler)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", topic, err)
+	}
+
+	n.consumers = append(n.consumers, consumeCtx)
+	n.config.Logger.Printf("### 📨 Events: consuming %s as %s", topic, group)
+	return nil
+}
+
+// receive handles one delivery, then acknowledges, redelivers, or terminates it
+func (n *NATS) receive(ctx context.Context, topic string, m jetstream.Msg, handler Handler) {
+	attempt := 1
+	if meta, err := m.Metadata(); err == nil {
+		attempt = int(meta.NumDelivered)
+	}
+
+	msg, err := decode(m.Data(), attempt)
+	if err != nil {
+		n.config.invalid(topic, err)
+		_ = m.TermWithReason(err.Error())
+		return
+	}
+
+	if err := n.config.handle(ctx, topic, msg, handler); err != nil {
+		if n.config.lastAttempt(attempt) {
+			_ = m.Term()
+			return
+		}
+		_ = m.NakWithDelay(n.config.retryDelay(attempt))
+		return
+	}
+
+	if err := m.Ack(); err != nil {
+		n.config.Logger.Printf("### 📨 Events: failed to acknowledge %s on %s: %v", msg.ID, topic, err)
+	}
+}
+
+// Close stops every subscription and waits for running handlers to finish, or for ctx to be done.
+// Unacknowledged messages are redelivered to the group's other members.
+func (n *NATS) Close(ctx context.Context) error {
+	n.mu.Lock()
+	n.closed = true
+	consumers := n.consumers
+	n.consumers = nil
+	n.mu.Unlock()
+
+	for _, consumeCtx := range consumers {
+		consumeCtx.Stop()
+	}
+	for _, consumeCtx := range consumers {
+		select {
+		case <-consumeCtx.Closed():
+		case <-ctx.Done():
+			return fmt.Errorf("event handlers still running at shutdown: %w", ctx.Err())
+		}
+	}
+
+	if len(consumers) > 0 {
+		n.config.Logger.Printf("### 📨 Events: NATS subscriptions stopped")
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// fakeJetStreamMsg records how a delivery was settled
+type fakeJetStreamMsg struct {
+	jetstream.Msg
+	data      []byte
+	delivered uint64
+	settled   string
+	delay     time.Duration
+}
+
+func (m *fakeJetStreamMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{NumDelivered: m.delivered}, nil
+}
+
+func (m *fakeJetStreamMsg) Data() []byte { return m.data }
+
+func (m *fakeJetStreamMsg) Ack() error {
+	m.settled = "ack"
+	return nil
+}
+
+func (m *fakeJetStreamMsg) NakWithDelay(delay time.Duration) error {
+	m.settled, m.delay = "nak", delay
+	return nil
+}
+
+func (m *fakeJetStreamMsg) Term() error {
+	m.settled = "term"
+	return nil
+}
+
+func (m *fakeJetStreamMsg) TermWithReason(reason string) error {
+	m.settled = "term"
+	return nil
+}
+
+func TestNATSReceive(t *testing.T) {
+	body := encodedRecord(t, 1, "order.created").Value
+	failing := func(ctx context.Context, msg *Message) error { return errors.New("not yet") }
+	succeeding := func(ctx context.Context, msg *Message) error { return nil }
+
+	tests := []struct {
+		name        string
+		data        []byte
+		delivered   uint64
+		handler     Handler
+		wantSettled string
+		wantDelay   time.Duration
+	}{
+		{"success", body, 1, succeeding, "ack", 0},
+		{"failure", body, 2, failing, "nak", 2 * time.Second},
+		{"last attempt", body, 3, failing, "term", 0},
+		{"invalid", []byte("junk"), 1, succeeding, "term", 0},
+	}
+
+	n := &NATS{config: NewConfig(WithLogger(&syncLogger{}), WithMaxAttempts(3),
+		WithRetryDelay(time.Second, time.Minute))}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &fakeJetStreamMsg{data: tt.data, delivered: tt.delivered}
+			n.receive(context.Background(), "orders", m, tt.handler)
+
+			if m.settled != tt.wantSettled || m.delay != tt.wantDelay {
+				t.Errorf("Expected %s after %s, got %s after %s", tt.wantSettled, tt.wantDelay, m.settled, m.delay)
+			}
+		})
+	}
+}
+
+func TestNATSReceiveAttempt(t *testing.T) {
+	n := &NATS{config: NewConfig(WithLogger(&syncLogger{}))}
+	m := &fakeJetStreamMsg{data: encodedRecord(t, 1, "order.created").Value, delivered: 4}
+
+	var attempt int
+	n.receive(context.Background(), "orders", m, func(ctx context.Context, msg *Message) error {
+		attempt = msg.Attempt
+		return nil
+	})
+	if attempt != 4 {
+		t.Errorf("Expected the delivery count as attempt, got %d", attempt)
+	}
+}
+
+func TestNATSClose(t *testing.T) {
+	n := &NATS{config: NewConfig(WithLogger(&syncLogger{}))}
+
+	if err := n.Close(context.Background()); err != nil {
+		t.Errorf("Expected Close without subscriptions to succeed, got %v", err)
+	}
+	if err := n.Subscribe(context.Background(), "orders.created", "billing", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestNewNATSRequiresConnection(t *testing.T) {
+	if _, err := NewNATS(nil); err == nil {
+		t.Error("Expected NewNATS to fail without a connection")
+	}
+}
@@ -0,0 +1,529 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/internal/background"
+	"github.com/Okja-Engineering/go-service-kit/internal/poller"
+	"github.com/Okja-Engineering/go-service-kit/internal/retry"
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// outboxRelayedTotal counts outbox rows relayed to the broker by outcome: published, failed, or dead
+var outboxRelayedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "events_outbox_relayed_total",
+	Help: "Total number of outbox events relayed to the broker by outcome",
+}, []string{"result"})
+
+// ErrDeadLetterNotFound is returned by Outbox.Retry when no dead-lettered event has the given message ID
+var ErrDeadLetterNotFound = errors.New("dead-lettered outbox event not found")
+
+// OutboxConfig holds configuration for the transactional outbox
+type OutboxConfig struct {
+	// Table is created by Migration
+	Table string
+	// PollInterval is how often the relay looks for new events
+	PollInterval time.Duration
+	// BatchSize is the most events relayed at once
+	BatchSize int
+	// MaxAttempts is how often an event is relayed before it is dead-lettered; zero retries forever
+	MaxAttempts int
+	// BaseBackoff is the delay before relaying a failed event again, doubling each attempt up to MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Retention is how long published events are kept, during which writing an event with the same ID is
+	// ignored
+	Retention time.Duration
+	Logger    Logger
+}
+
+// DefaultOutboxConfig provides sensible defaults
+func DefaultOutboxConfig() *OutboxConfig {
+	return &OutboxConfig{
+		Table:        "event_outbox",
+		PollInterval: time.Second,
+		BatchSize:    100,
+		MaxAttempts:  10,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   5 * time.Minute,
+		Retention:    24 * time.Hour,
+		Logger:       log.Default(),
+	}
+}
+
+// OutboxOption is a functional option for configuring the outbox
+type OutboxOption func(*OutboxConfig)
+
+// WithOutboxTable sets the outbox table name
+func WithOutboxTable(table string) OutboxOption {
+	return func(config *OutboxConfig) {
+		config.Table = table
+	}
+}
+
+// WithOutboxPollInterval sets how often the relay looks for new events
+func WithOutboxPollInterval(interval time.Duration) OutboxOption {
+	return func(config *OutboxConfig) {
+		config.PollInterval = interval
+	}
+}
+
+// WithOutboxBatchSize sets the most events relayed at once
+func WithOutboxBatchSize(size int) OutboxOption {
+	return func(config *OutboxConfig) {
+		config.BatchSize = size
+	}
+}
+
+// WithOutboxMaxAttempts sets how often an event is relayed before it is dead-lettered
+func WithOutboxMaxAttempts(attempts int) OutboxOption {
+	return func(config *OutboxConfig) {
+		config.MaxAttempts = attempts
+	}
+}
+
+// WithOutboxBackoff sets the delay before relaying a failed event again, which starts at base and doubles up
+// to max
+func WithOutboxBackoff(base, max time.Duration) OutboxOption {
+	return func(config *OutboxConfig) {
+		config.BaseBackoff = base
+		config.MaxBackoff = max
+	}
+}
+
+// WithOutboxRetention sets how long published events are kept for deduplication
+func WithOutboxRetention(retention time.Duration) OutboxOption {
+	return func(config *OutboxConfig) {
+		config.Retention = retention
+	}
+}
+
+// WithOutboxLogger sets the logger for relay failures
+func WithOutboxLogger(logger Logger) OutboxOption {
+	return func(config *OutboxConfig) {
+		config.Logger = logger
+	}
+}
+
+// NewOutboxConfig creates a new outbox config with options
+func NewOutboxConfig(options ...OutboxOption) *OutboxConfig {
+	config := DefaultOutboxConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// OutboxDeadLetter is an outbox event that used up its attempts
+type OutboxDeadLetter struct {
+	Topic     string
+	Message   *Message
+	Attempts  int
+	LastError string
+	DeadAt    time.Time
+}
+
+// Outbox stores events in the same transaction as the writes they describe, and relays them to a broker
+// once committed, so an event is published if and only if its transaction commits
+type Outbox struct {
+	db        database.Database
+	publisher Publisher
+	config    *OutboxConfig
+	pool      poller.Pool
+}
+
+// NewOutbox creates an outbox stored in db that relays events to publisher
+func NewOutbox(db database.Database, publisher Publisher, options ...OutboxOption) *Outbox {
+	return &Outbox{db: db, publisher: publisher, config: NewOutboxConfig(options...)}
+}
+
+// Migration returns the migration that creates the outbox table, for use with database.Migrate. Pick a
+// version that fits the service's own migrations. The table records the ID of the transaction that wrote
+// each event, which needs PostgreSQL 13 or later.
+func (o *Outbox) Migration(version int64) database.Migration {
+	table := pq.QuoteIdentifier(o.config.Table)
+	index := pq.QuoteIdentifier(o.config.Table + "_unpublished")
+
+	up := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id BIGSERIAL PRIMARY KEY,
+	txid XID8 NOT NULL DEFAULT pg_current_xact_id(),
+	message_id TEXT NOT NULL UNIQUE,
+	topic TEXT NOT NULL,
+	aggregate TEXT NOT NULL DEFAULT '',
+	body JSONB NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT,
+	next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	published_at TIMESTAMPTZ,
+	dead_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (txid, id) WHERE published_at IS NULL AND dead_at IS NULL;`,
+		table, index)
+
+	return database.Migration{
+		Version: version,
+		Name:    "create " + o.config.Table,
+		Up:      up,
+		Down:    fmt.Sprintf("DROP TABLE IF EXISTS %s", table),
+	}
+}
+
+// WriteEvent stores msg for publishing to topic once tx commits. Events with the same Key, such as an
+// aggregate ID, are published in the order they were written, as described on Relay. msg.ID is the
+// deduplication key: writing an event whose ID is already in the outbox does nothing, so use a deterministic
+// ID when a write may be retried.
+func (o *Outbox) WriteEvent(ctx context.Context, tx *sql.Tx, topic string, msg *Message) error {
+	if msg.ID == "" || msg.Type == "" {
+		return fmt.Errorf("%w: ID and type are required", ErrInvalidMessage)
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", msg.ID, err)
+	}
+
+	insert := fmt.Sprintf(`INSERT INTO %s (message_id, topic, aggregate, body) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (message_id) DO NOTHING`, pq.QuoteIdentifier(o.config.Table))
+	if _, err := tx.ExecContext(ctx, insert, msg.ID, topic, msg.Key, body); err != nil {
+		return fmt.Errorf("failed to write event %s to outbox: %w", msg.ID, err)
+	}
+	return nil
+}
+
+// Start runs the relay until ctx is done or Stop is called
+func (o *Outbox) Start(ctx context.Context) {
+	started := o.pool.Start(ctx, 1, poller.Loop{
+		// A batch in flight is finished rather than cancelled; full batches are relayed back to back
+		Next: func(ctx context.Context) (bool, error) {
+			relayed, err := o.Relay(context.WithoutCancel(ctx))
+			return relayed >= o.config.BatchSize, err
+		},
+		Interval: o.config.PollInterval,
+		OnError: func(err error) {
+			o.config.Logger.Printf("### 📨 Events: outbox relay failed: %v", err)
+		},
+	})
+	if started {
+		o.config.Logger.Printf("### 📨 Events: outbox relay started")
+	}
+}
+
+// Stop stops the relay, waiting for the batch in flight to finish or for ctx to be done
+func (o *Outbox) Stop(ctx context.Context) error {
+	running, err := o.pool.Stop(ctx)
+	if err != nil {
+		return fmt.Errorf("outbox relay still running at shutdown: %w", err)
+	}
+	if running {
+		o.config.Logger.Printf("### 📨 Events: outbox relay stopped")
+	}
+	return nil
+}
+
+// outboxRow is an unpublished event read by the relay
+type outboxRow struct {
+	id        int64
+	topic     string
+	aggregate string
+	body      []byte
+	attempts  int
+	due       bool
+}
+
+// Relay publishes one batch of unpublished events, returning how many were published. Only one relay
+// runs at a time across instances, holding an advisory lock on its own connection; the others return zero.
+//
+// The relay claims a batch by counting an attempt for each event, publishes it with no transaction open,
+// and then marks the events published, so a slow broker holds no locks on the outbox. An event is published
+// again only if marking it fails, with the same message ID for the broker and consumers to deduplicate. A
+// failed event is retried with backoff and dead-lettered after MaxAttempts; until then, later events with
+// the same Key wait behind it.
+//
+// Events are relayed in the order of the transactions that wrote them, and only once every transaction
+// that started before theirs has finished, so an event that commits late is never passed over. Events of
+// one aggregate keep their order when the transactions that write them lock or update the aggregate before
+// anything else, as they usually do. A long-running transaction anywhere in the database holds the relay
+// back until it ends.
+func (o *Outbox) Relay(ctx context.Context) (int, error) {
+	db := o.db.GetDB()
+	if db == nil {
+		return 0, fmt.Errorf("database connection is closed")
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`,
+		o.config.Table).Scan(&locked); err != nil {
+		background.Discard(conn)
+		return 0, fmt.Errorf("failed to lock outbox: %w", err)
+	}
+	if !locked {
+		_ = conn.Close()
+		return 0, nil
+	}
+	defer o.unlock(conn)
+
+	rows, err := o.claim(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	published, failed := o.publishAll(ctx, rows)
+	if err := o.record(ctx, conn, rows, published, failed); err != nil {
+		return 0, err
+	}
+
+	return len(published), nil
+}
+
+// unlock releases the relay lock and returns conn to the pool, or closes it if the lock can't be released
+func (o *Outbox) unlock(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, o.config.Table); err != nil {
+		o.config.Logger.Printf("### 📨 Events: failed to unlock outbox: %v", err)
+		background.Discard(conn)
+		return
+	}
+	_ = conn.Close()
+}
+
+// claim reads the oldest unpublished events and counts an attempt for each one that is due
+func (o *Outbox) claim(ctx context.Context, conn *sql.Conn) ([]outboxRow, error) {
+	rows, err := o.pending(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := dueRows(rows)
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int64, len(claimed))
+	for i := range claimed {
+		ids[i] = claimed[i].id
+		claimed[i].attempts++
+	}
+	update := fmt.Sprintf(`UPDATE %s SET attempts = attempts + 1 WHERE id = ANY($1)`,
+		pq.QuoteIdentifier(o.config.Table))
+	if _, err := conn.ExecContext(ctx, update, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	return claimed, nil
+}
+
+// pending reads the oldest unpublished events whose transactions are older than every running one
+func (o *Outbox) pending(ctx context.Context, conn *sql.Conn) ([]outboxRow, error) {
+	query := fmt.Sprintf(`SELECT id, topic, aggregate, body, attempts, next_attempt_at <= now() FROM %s
+		WHERE published_at IS NULL AND dead_at IS NULL AND txid < pg_snapshot_xmin(pg_current_snapshot())
+		ORDER BY txid, id LIMIT $1`, pq.QuoteIdentifier(o.config.Table))
+	rows, err := conn.QueryContext(ctx, query, o.config.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var pending []outboxRow
+	for rows.Next() {
+		var row outboxRow
+		if err := rows.Scan(&row.id, &row.topic, &row.aggregate, &row.body, &row.attempts, &row.due); err != nil {
+			return nil, fmt.Errorf("failed to read outbox event: %w", err)
+		}
+		pending = append(pending, row)
+	}
+	return pending, rows.Err()
+}
+
+// dueRows returns the rows that are due, leaving out those behind a row of the same aggregate that is
+// waiting to be retried
+func dueRows(rows []outboxRow) []outboxRow {
+	var due []outboxRow
+	waiting := make(map[string]bool)
+
+	for _, row := range rows {
+		switch {
+		case row.aggregate != "" && waiting[row.aggregate]:
+		case row.due:
+			due = append(due, row)
+		case row.aggregate != "":
+			waiting[row.aggregate] = true
+		}
+	}
+	return due
+}
+
+// publishAll publishes rows in order, skipping the rest of an aggregate after one of its events fails. It
+// returns the IDs of the published rows and the errors of the failed ones.
+func (o *Outbox) publishAll(ctx context.Context, rows []outboxRow) ([]int64, map[int64]error) {
+	var published []int64
+	failed := make(map[int64]error)
+	blocked := make(map[string]bool)
+
+	for _, row := range rows {
+		if row.aggregate != "" && blocked[row.aggregate] {
+			continue
+		}
+
+		err := o.publish(ctx, row)
+		if err == nil {
+			outboxRelayedTotal.WithLabelValues("published").Inc()
+			published = append(published, row.id)
+			continue
+		}
+
+		outboxRelayedTotal.WithLabelValues("failed").Inc()
+		o.config.Logger.Printf("### 📨 Events: failed to relay outbox event %d to %s: %v", row.id, row.topic, err)
+		failed[row.id] = err
+		if row.aggregate != "" {
+			blocked[row.aggregate] = true
+		}
+	}
+
+	return published, failed
+}
+
+// publish sends one outbox event to the broker
+func (o *Outbox) publish(ctx context.Context, row outboxRow) error {
+	msg := &Message{}
+	if err := json.Unmarshal(row.body, msg); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	return o.publisher.Publish(ctx, row.topic, msg)
+}
+
+// record marks published events, schedules or dead-letters failed ones, and deletes events past their
+// retention
+func (o *Outbox) record(ctx context.Context, conn *sql.Conn, rows []outboxRow, ids []int64,
+	failed map[int64]error) error {
+	table := pq.QuoteIdentifier(o.config.Table)
+
+	if len(ids) > 0 {
+		update := fmt.Sprintf(`UPDATE %s SET published_at = now(), last_error = NULL WHERE id = ANY($1)`, table)
+		if _, err := conn.ExecContext(ctx, update, pq.Array(ids)); err != nil {
+			return fmt.Errorf("failed to mark outbox events published: %w", err)
+		}
+	}
+
+	for _, row := range rows {
+		if cause, ok := failed[row.id]; ok {
+			if err := o.fail(ctx, conn, row, cause); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Events skipped behind a failed one of the same aggregate were not attempted
+	if skipped := skippedIDs(rows, ids, failed); len(skipped) > 0 {
+		update := fmt.Sprintf(`UPDATE %s SET attempts = attempts - 1 WHERE id = ANY($1)`, table)
+		if _, err := conn.ExecContext(ctx, update, pq.Array(skipped)); err != nil {
+			return fmt.Errorf("failed to release skipped outbox events: %w", err)
+		}
+	}
+
+	cleanup := fmt.Sprintf(`DELETE FROM %s WHERE published_at < now() - $1 * interval '1 millisecond'`, table)
+	if _, err := conn.ExecContext(ctx, cleanup, o.config.Retention.Milliseconds()); err != nil {
+		return fmt.Errorf("failed to clean up outbox: %w", err)
+	}
+	return nil
+}
+
+// skippedIDs returns the IDs of the rows that were neither published nor failed
+func skippedIDs(rows []outboxRow, published []int64, failed map[int64]error) []int64 {
+	var skipped []int64
+	for _, row := range rows {
+		if _, ok := failed[row.id]; !ok && !slices.Contains(published, row.id) {
+			skipped = append(skipped, row.id)
+		}
+	}
+	return skipped
+}
+
+// fail schedules another attempt at a failed event with backoff, or dead-letters it once its attempts are
+// used up
+func (o *Outbox) fail(ctx context.Context, conn *sql.Conn, row outboxRow, cause error) error {
+	dead := o.config.MaxAttempts > 0 && row.attempts >= o.config.MaxAttempts
+	delay := retry.Backoff(o.config.BaseBackoff, o.config.MaxBackoff, row.attempts)
+
+	update := fmt.Sprintf(`UPDATE %s SET last_error = $1, next_attempt_at = now() + $2 * interval '1 millisecond',
+		dead_at = CASE WHEN $3 THEN now() END WHERE id = $4`, pq.QuoteIdentifier(o.config.Table))
+	if _, err := conn.ExecContext(ctx, update, retry.ErrorMessage(cause), delay.Milliseconds(), dead,
+		row.id); err != nil {
+		return fmt.Errorf("failed to record outbox failure: %w", err)
+	}
+
+	if dead {
+		outboxRelayedTotal.WithLabelValues("dead").Inc()
+		o.config.Logger.Printf("### 📨 Events: outbox event %d to %s dead-lettered after %d attempts", row.id,
+			row.topic, row.attempts)
+	}
+	return nil
+}
+
+// DeadLetters returns outbox events that used up their attempts, newest first
+func (o *Outbox) DeadLetters(ctx context.Context, limit int) ([]OutboxDeadLetter, error) {
+	db := o.db.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+
+	query := fmt.Sprintf(`SELECT topic, body, attempts, coalesce(last_error, ''), dead_at FROM %s
+		WHERE dead_at IS NOT NULL ORDER BY dead_at DESC LIMIT $1`, pq.QuoteIdentifier(o.config.Table))
+	rows, err := db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var letters []OutboxDeadLetter
+	for rows.Next() {
+		var letter OutboxDeadLetter
+		var body []byte
+		if err := rows.Scan(&letter.Topic, &body, &letter.Attempts, &letter.LastError, &letter.DeadAt); err != nil {
+			return nil, fmt.Errorf("failed to read dead letter: %w", err)
+		}
+		letter.Message = &Message{}
+		if err := json.Unmarshal(body, letter.Message); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+		}
+		letters = append(letters, letter)
+	}
+
+	return letters, rows.Err()
+}
+
+// Retry relays a dead-lettered event again with a fresh set of attempts. It is published after any events
+// with the same Key that were relayed while it was dead.
+func (o *Outbox) Retry(ctx context.Context, messageID string) error {
+	db := o.db.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection is closed")
+	}
+
+	update := fmt.Sprintf(`UPDATE %s SET dead_at = NULL, attempts = 0, next_attempt_at = now()
+		WHERE message_id = $1 AND dead_at IS NOT NULL`, pq.QuoteIdentifier(o.config.Table))
+	result, err := db.ExecContext(ctx, update, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to retry outbox event %s: %w", messageID, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("outbox event %s: %w", messageID, ErrDeadLetterNotFound)
+	}
+
+	return nil
+}
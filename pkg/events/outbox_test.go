@@ -0,0 +1,317 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+)
+
+// newTestOutbox creates an outbox whose database was never connected
+func newTestOutbox(publisher Publisher, options ...OutboxOption) *Outbox {
+	return NewOutbox(database.NewPostgreSQL(database.NewConfig()), publisher, options...)
+}
+
+// failingPublisher fails for messages of the given types
+type failingPublisher struct {
+	failTypes map[string]bool
+	published []string
+}
+
+func (p *failingPublisher) Publish(ctx context.Context, topic string, msg *Message) error {
+	if p.failTypes[msg.Type] {
+		return errors.New("broker rejected " + msg.Type)
+	}
+	p.published = append(p.published, msg.Type)
+	return nil
+}
+
+func outboxRowFor(t *testing.T, id int64, aggregate, eventType string) outboxRow {
+	t.Helper()
+	body, err := json.Marshal(&Message{ID: eventType, Type: eventType, Key: aggregate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return outboxRow{id: id, topic: "orders", aggregate: aggregate, body: body}
+}
+
+func TestNewOutboxConfig(t *testing.T) {
+	config := NewOutboxConfig()
+	if config.Table != "event_outbox" || config.BatchSize != 100 || config.Retention != 24*time.Hour {
+		t.Errorf("Unexpected defaults: %+v", config)
+	}
+
+	if config.MaxAttempts != 10 || config.BaseBackoff != time.Second || config.MaxBackoff != 5*time.Minute {
+		t.Errorf("Unexpected retry defaults: %+v", config)
+	}
+
+	logger := &syncLogger{}
+	config = NewOutboxConfig(
+		WithOutboxTable("outbox"),
+		WithOutboxPollInterval(time.Minute),
+		WithOutboxBatchSize(10),
+		WithOutboxMaxAttempts(3),
+		WithOutboxBackoff(time.Millisecond, time.Second),
+		WithOutboxRetention(time.Hour),
+		WithOutboxLogger(logger),
+	)
+	if config.Table != "outbox" || config.PollInterval != time.Minute || config.BatchSize != 10 {
+		t.Errorf("Expected options to be applied, got %+v", config)
+	}
+	if config.MaxAttempts != 3 || config.BaseBackoff != time.Millisecond || config.MaxBackoff != time.Second {
+		t.Errorf("Expected retry options to be applied, got %+v", config)
+	}
+	if config.Retention != time.Hour || config.Logger != logger {
+		t.Errorf("Expected retention and logger to be overridden, got %+v", config)
+	}
+}
+
+func TestOutboxMigration(t *testing.T) {
+	migration := newTestOutbox(nil, WithOutboxTable("outbox")).Migration(12)
+
+	if migration.Version != 12 {
+		t.Errorf("Expected version 12, got %d", migration.Version)
+	}
+	for _, want := range []string{
+		`CREATE TABLE IF NOT EXISTS "outbox"`,
+		"txid XID8 NOT NULL DEFAULT pg_current_xact_id()",
+		"message_id TEXT NOT NULL UNIQUE",
+		"body JSONB NOT NULL",
+		"dead_at TIMESTAMPTZ",
+		`CREATE INDEX IF NOT EXISTS "outbox_unpublished" ON "outbox" (txid, id)`,
+	} {
+		if !strings.Contains(migration.Up, want) {
+			t.Errorf("Expected migration to contain %q, got:\n%s", want, migration.Up)
+		}
+	}
+	if migration.Down != `DROP TABLE IF EXISTS "outbox"` {
+		t.Errorf("Unexpected down migration: %s", migration.Down)
+	}
+}
+
+func TestOutboxWriteEventInvalid(t *testing.T) {
+	o := newTestOutbox(nil)
+	if err := o.WriteEvent(context.Background(), nil, "orders", &Message{Type: "x"}); !errors.Is(err,
+		ErrInvalidMessage) {
+		t.Errorf("Expected a message without ID to be rejected, got %v", err)
+	}
+}
+
+func TestOutboxRelayNotConnected(t *testing.T) {
+	relayed, err := newTestOutbox(nil).Relay(context.Background())
+	if relayed != 0 || err == nil {
+		t.Errorf("Expected nothing relayed and a connection error, got %d and %v", relayed, err)
+	}
+}
+
+func TestOutboxPublishAllKeepsAggregateOrder(t *testing.T) {
+	publisher := &failingPublisher{failTypes: map[string]bool{"order.paid": true, "standalone.bad": true}}
+	o := newTestOutbox(publisher, WithOutboxLogger(&syncLogger{}))
+
+	rows := []outboxRow{
+		outboxRowFor(t, 1, "order-1", "order.created"),
+		outboxRowFor(t, 2, "order-1", "order.paid"),
+		outboxRowFor(t, 3, "order-2", "order.created"),
+		outboxRowFor(t, 4, "order-1", "order.shipped"),
+		outboxRowFor(t, 5, "", "standalone.bad"),
+		outboxRowFor(t, 6, "", "standalone.ok"),
+		{id: 7, topic: "orders", body: []byte("junk")},
+	}
+
+	published, failed := o.publishAll(context.Background(), rows)
+
+	if len(published) != 3 || published[0] != 1 || published[1] != 3 || published[2] != 6 {
+		t.Errorf("Expected rows 1, 3, and 6 published, got %v", published)
+	}
+	if len(failed) != 3 || failed[2] == nil || failed[5] == nil || !errors.Is(failed[7], ErrInvalidMessage) {
+		t.Errorf("Expected rows 2, 5, and 7 to fail, got %v", failed)
+	}
+	if strings.Join(publisher.published, ",") != "order.created,order.created,standalone.ok" {
+		t.Errorf("Expected order.shipped to wait behind the failed order.paid, got %v", publisher.published)
+	}
+}
+
+func TestOutboxStartStop(t *testing.T) {
+	logger := &syncLogger{}
+	o := newTestOutbox(nil, WithOutboxLogger(logger), WithOutboxPollInterval(5*time.Millisecond))
+
+	if err := o.Stop(context.Background()); err != nil {
+		t.Errorf("Expected Stop before Start to be a no-op, got %v", err)
+	}
+
+	o.Start(context.Background())
+	o.Start(context.Background())
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := o.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	output := logger.String()
+	for _, want := range []string{"outbox relay started", "database connection is closed", "outbox relay stopped"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q to be logged, got %q", want, output)
+		}
+	}
+}
+
+func TestDueRows(t *testing.T) {
+	rows := []outboxRow{
+		{id: 1, aggregate: "order-1", due: true},
+		{id: 2, aggregate: "order-2"},
+		{id: 3, aggregate: "order-2", due: true},
+		{id: 4, due: false},
+		{id: 5, due: true},
+		{id: 6, aggregate: "order-1", due: true},
+	}
+
+	var ids []int64
+	for _, row := range dueRows(rows) {
+		ids = append(ids, row.id)
+	}
+	if fmt.Sprint(ids) != "[1 5 6]" {
+		t.Errorf("Expected order-2 to wait behind its retry, got %v", ids)
+	}
+}
+
+func TestSkippedIDs(t *testing.T) {
+	rows := []outboxRow{{id: 1}, {id: 2}, {id: 3}, {id: 4}}
+	skipped := skippedIDs(rows, []int64{1}, map[int64]error{2: errors.New("boom")})
+	if fmt.Sprint(skipped) != "[3 4]" {
+		t.Errorf("Expected rows 3 and 4 skipped, got %v", skipped)
+	}
+}
+
+// newRelayMock scripts a mock whose relay lock is free and whose outbox holds rows
+func newRelayMock(t *testing.T, rows ...[]interface{}) *database.Mock {
+	t.Helper()
+	return database.NewMock().
+		OnQuery("pg_try_advisory_lock", []string{"locked"}, []interface{}{true}).
+		OnQuery("pg_snapshot_xmin", []string{"id", "topic", "aggregate", "body", "attempts", "due"}, rows...)
+}
+
+func relayRow(t *testing.T, id int64, aggregate, eventType string, attempts int) []interface{} {
+	t.Helper()
+	row := outboxRowFor(t, id, aggregate, eventType)
+	return []interface{}{row.id, row.topic, row.aggregate, row.body, int64(attempts), true}
+}
+
+// execsContaining returns the statements sent to mock that contain match
+func execsContaining(mock *database.Mock, match string) []database.MockCall {
+	var calls []database.MockCall
+	for _, call := range mock.CallsTo("Exec") {
+		if strings.Contains(call.Query, match) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func TestOutboxRelay(t *testing.T) {
+	mock := newRelayMock(t,
+		relayRow(t, 1, "order-1", "order.created", 0),
+		relayRow(t, 2, "order-1", "order.paid", 0),
+		relayRow(t, 3, "order-1", "order.shipped", 0),
+		relayRow(t, 4, "order-2", "order.created", 0),
+	)
+	publisher := &failingPublisher{failTypes: map[string]bool{"order.paid": true}}
+	logger := &syncLogger{}
+	o := NewOutbox(mock, publisher, WithOutboxLogger(logger), WithOutboxBackoff(time.Second, time.Minute))
+
+	relayed, err := o.Relay(context.Background())
+	if err != nil || relayed != 2 {
+		t.Fatalf("Expected 2 events relayed, got %d and %v", relayed, err)
+	}
+
+	tests := []struct {
+		match string
+		args  string
+	}{
+		{"attempts = attempts + 1", "[{1,2,3,4}]"},
+		{"published_at = now()", "[{1,4}]"},
+		{"next_attempt_at = now()", "[broker rejected order.paid 1000 false 2]"},
+		{"attempts = attempts - 1", "[{3}]"},
+		{"pg_advisory_unlock", "[event_outbox]"},
+	}
+	for _, tt := range tests {
+		calls := execsContaining(mock, tt.match)
+		if len(calls) != 1 || fmt.Sprint(calls[0].Args) != tt.args {
+			t.Errorf("Expected one %q statement with %s, got %+v", tt.match, tt.args, calls)
+		}
+	}
+	if len(mock.CallsTo("Begin")) != 0 {
+		t.Error("Expected the relay to publish without a transaction open")
+	}
+}
+
+func TestOutboxRelayDeadLetters(t *testing.T) {
+	mock := newRelayMock(t, relayRow(t, 1, "order-1", "order.paid", 2))
+	publisher := &failingPublisher{failTypes: map[string]bool{"order.paid": true}}
+	logger := &syncLogger{}
+	o := NewOutbox(mock, publisher, WithOutboxLogger(logger), WithOutboxMaxAttempts(3))
+
+	if _, err := o.Relay(context.Background()); err != nil {
+		t.Fatalf("Relay failed: %v", err)
+	}
+
+	calls := execsContaining(mock, "dead_at = CASE")
+	if len(calls) != 1 || calls[0].Args[2] != true {
+		t.Errorf("Expected the event to be dead-lettered on its third attempt, got %+v", calls)
+	}
+	if !strings.Contains(logger.String(), "outbox event 1 to orders dead-lettered after 3 attempts") {
+		t.Errorf("Expected the dead letter to be logged, got %s", logger.String())
+	}
+}
+
+func TestOutboxRelayLocked(t *testing.T) {
+	mock := database.NewMock().
+		OnQuery("pg_try_advisory_lock", []string{"locked"}, []interface{}{false})
+	o := NewOutbox(mock, &failingPublisher{})
+
+	relayed, err := o.Relay(context.Background())
+	if err != nil || relayed != 0 {
+		t.Errorf("Expected nothing relayed while another relay runs, got %d and %v", relayed, err)
+	}
+	if len(mock.CallsTo("Exec")) != 0 {
+		t.Errorf("Expected no statements without the lock, got %+v", mock.CallsTo("Exec"))
+	}
+}
+
+func TestOutboxDeadLetters(t *testing.T) {
+	deadAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	row := outboxRowFor(t, 1, "order-1", "order.paid")
+	mock := database.NewMock().OnQuery("dead_at IS NOT NULL",
+		[]string{"topic", "body", "attempts", "last_error", "dead_at"},
+		[]interface{}{"orders", row.body, int64(10), "broker down", deadAt})
+	o := NewOutbox(mock, nil)
+
+	letters, err := o.DeadLetters(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("DeadLetters failed: %v", err)
+	}
+	if len(letters) != 1 || letters[0].Message.Type != "order.paid" || letters[0].Attempts != 10 ||
+		letters[0].LastError != "broker down" || !letters[0].DeadAt.Equal(deadAt) {
+		t.Errorf("Unexpected dead letters: %+v", letters)
+	}
+}
+
+func TestOutboxRetry(t *testing.T) {
+	mock := database.NewMock().OnExec("message_id = $1 AND dead_at IS NOT NULL", 1)
+	o := NewOutbox(mock, nil)
+
+	if err := o.Retry(context.Background(), "order.paid"); err != nil {
+		t.Errorf("Expected the dead letter to be retried, got %v", err)
+	}
+
+	o = NewOutbox(database.NewMock(), nil)
+	if err := o.Retry(context.Background(), "missing"); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("Expected ErrDeadLetterNotFound, got %v", err)
+	}
+}
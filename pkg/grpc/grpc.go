@@ -0,0 +1,231 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/api"
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Config holds configuration for a gRPC server
+type Config struct {
+	// Validator authenticates calls from the bearer token in the "authorization" metadata; nil disables auth
+	Validator auth.Validator
+	// PublicMethods are full method names, such as "/orders.v1.Orders/List", that skip auth. Health and
+	// reflection are always public.
+	PublicMethods []string
+	// Reflection registers the reflection service, letting tools like grpcurl list services
+	Reflection bool
+	// Logger receives call logs and panic stack traces
+	Logger problem.Logger
+	// ServerOptions are passed to grpc.NewServer, e.g. credentials or message size limits
+	ServerOptions []grpc.ServerOption
+	// UnaryInterceptors and StreamInterceptors run after the built-in ones, closest to the handler
+	UnaryInterceptors  []grpc.UnaryServerInterceptor
+	StreamInterceptors []grpc.StreamServerInterceptor
+}
+
+// DefaultConfig provides sensible defaults
+func DefaultConfig() *Config {
+	return &Config{
+		Logger: &problem.DefaultLogger{},
+	}
+}
+
+// Option is a functional option for configuring a gRPC server
+type Option func(*Config)
+
+// WithAuth authenticates calls with validator, such as an auth.JWTValidator
+func WithAuth(validator auth.Validator) Option {
+	return func(config *Config) {
+		config.Validator = validator
+	}
+}
+
+// WithPublicMethods sets the full method names that skip auth
+func WithPublicMethods(methods ...string) Option {
+	return func(config *Config) {
+		config.PublicMethods = methods
+	}
+}
+
+// WithReflection toggles the reflection service
+func WithReflection(enabled bool) Option {
+	return func(config *Config) {
+		config.Reflection = enabled
+	}
+}
+
+// WithLogger sets the logger for calls and panics
+func WithLogger(logger problem.Logger) Option {
+	return func(config *Config) {
+		config.Logger = logger
+	}
+}
+
+// WithServerOptions adds options passed to grpc.NewServer
+func WithServerOptions(options ...grpc.ServerOption) Option {
+	return func(config *Config) {
+		config.ServerOptions = append(config.ServerOptions, options...)
+	}
+}
+
+// WithUnaryInterceptors adds unary interceptors after the built-in ones
+func WithUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) Option {
+	return func(config *Config) {
+		config.UnaryInterceptors = append(config.UnaryInterceptors, interceptors...)
+	}
+}
+
+// WithStreamInterceptors adds stream interceptors after the built-in ones
+func WithStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) Option {
+	return func(config *Config) {
+		config.StreamInterceptors = append(config.StreamInterceptors, interceptors...)
+	}
+}
+
+// NewConfig creates a new gRPC server config with options
+func NewConfig(options ...Option) *Config {
+	config := DefaultConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Server is a gRPC server with logging, metrics, panic recovery, and auth interceptors and the standard
+// health service. Register services on it as on a *grpc.Server.
+type Server struct {
+	*grpc.Server
+	ServiceName string
+	Version     string
+
+	config *Config
+	health *health.Server
+
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+// NewServer creates a server for the named service
+func NewServer(name, version string, options ...Option) *Server {
+	config := NewConfig(options...)
+	s := &Server{ServiceName: name, Version: version, config: config, stopped: make(chan struct{})}
+
+	unary := append([]grpc.UnaryServerInterceptor{s.observeUnary, s.recoverUnary, s.authUnary},
+		config.UnaryInterceptors...)
+	stream := append([]grpc.StreamServerInterceptor{s.observeStream, s.recoverStream, s.authStream},
+		config.StreamInterceptors...)
+	serverOptions := append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}, config.ServerOptions...)
+	s.Server = grpc.NewServer(serverOptions...)
+
+	s.health = health.NewServer()
+	healthpb.RegisterHealthServer(s.Server, s.health)
+	s.SetServing(true)
+
+	if config.Reflection {
+		reflection.Register(s.Server)
+	}
+
+	return s
+}
+
+// Health returns the health service, for setting the status of individual services
+func (s *Server) Health() *health.Server {
+	return s.health
+}
+
+// SetServing sets the health status of the server and every registered service. ServeWith marks
+// services registered before it SERVING.
+func (s *Server) SetServing(serving bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+
+	s.health.SetServingStatus("", status)
+	for service := range s.GetServiceInfo() {
+		s.health.SetServingStatus(service, status)
+	}
+}
+
+// ServeWith serves on lis in the background, sharing base's graceful shutdown: when base starts
+// shutting down, health checks report NOT_SERVING and the server stops taking new calls, and base's
+// shutdown waits for in-flight calls through a "grpc" hook, stopping them at the hook timeout.
+func (s *Server) ServeWith(base *api.Base, lis net.Listener) {
+	log.Printf("### 📡 %s gRPC, listening on %s", s.ServiceName, lis.Addr())
+	s.SetServing(true)
+
+	go func() {
+		if err := s.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			log.Printf("### 💥 gRPC: server failed: %v", err)
+		}
+	}()
+	go func() {
+		<-base.ShuttingDown()
+		s.beginShutdown()
+	}()
+
+	base.OnShutdown("grpc", s.Shutdown)
+}
+
+// Start listens on port and serves with ServeWith
+func (s *Server) Start(base *api.Base, port int) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", port, err)
+	}
+
+	s.ServeWith(base, lis)
+	return nil
+}
+
+// Shutdown stops the server gracefully, waiting for in-flight calls until ctx is done and then
+// cancelling them.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.beginShutdown()
+
+	select {
+	case <-s.stopped:
+		return nil
+	case <-ctx.Done():
+		s.Stop()
+		return fmt.Errorf("gRPC calls were still running at shutdown: %w", ctx.Err())
+	}
+}
+
+// beginShutdown marks the server unhealthy and starts a graceful stop, once
+func (s *Server) beginShutdown() {
+	s.stopOnce.Do(func() {
+		s.health.Shutdown()
+		go func() {
+			s.GracefulStop()
+			close(s.stopped)
+		}()
+	})
+}
+
+// isPublic reports whether a method skips auth
+func (s *Server) isPublic(method string) bool {
+	return isInfrastructure(method) || slices.Contains(s.config.PublicMethods, method)
+}
+
+// isInfrastructure reports whether a method belongs to the health or reflection services
+func isInfrastructure(method string) bool {
+	return strings.HasPrefix(method, "/grpc.health.v1.") || strings.HasPrefix(method, "/grpc.reflection.")
+}
@@ -0,0 +1,210 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/api"
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// syncLogger is a Logger that is safe to read while the server writes to it
+type syncLogger struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *syncLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(&l.buf, format+"\n", v...)
+}
+
+func (l *syncLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+// echoHandler answers test calls; it can be replaced to panic or block
+type echoHandler func(ctx context.Context, in *wrapperspb.StringValue) (*wrapperspb.StringValue, error)
+
+// echoServiceDesc describes a one-method service, standing in for generated code
+var echoServiceDesc = grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Echo",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error,
+			interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(wrapperspb.StringValue)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/test.Echo/Echo"}
+			return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.(echoHandler)(ctx, req.(*wrapperspb.StringValue))
+			})
+		},
+	}},
+}
+
+func echo(_ context.Context, in *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	return wrapperspb.String("echo: " + in.GetValue()), nil
+}
+
+// startTestServer serves s over an in-memory listener and returns a client connection
+func startTestServer(t *testing.T, s *Server, handler echoHandler) *grpc.ClientConn {
+	t.Helper()
+	s.RegisterService(&echoServiceDesc, handler)
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	return dial(t, lis)
+}
+
+// dial connects a client to an in-memory listener
+func dial(t *testing.T, lis *bufconn.Listener) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func callEcho(ctx context.Context, conn *grpc.ClientConn, value string) (string, error) {
+	out := new(wrapperspb.StringValue)
+	err := conn.Invoke(ctx, "/test.Echo/Echo", wrapperspb.String(value), out)
+	return out.GetValue(), err
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if config.Validator != nil || config.Reflection || config.Logger == nil {
+		t.Errorf("Unexpected defaults: %+v", config)
+	}
+
+	validator := auth.NewPassthroughValidator()
+	logger := &syncLogger{}
+	config = NewConfig(
+		WithAuth(validator),
+		WithPublicMethods("/test.Echo/Echo"),
+		WithReflection(true),
+		WithLogger(logger),
+		WithServerOptions(grpc.MaxRecvMsgSize(1024)),
+		WithUnaryInterceptors(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(ctx, req)
+		}),
+	)
+	if config.Validator != validator || len(config.PublicMethods) != 1 || !config.Reflection ||
+		config.Logger != logger {
+		t.Errorf("Expected options to be applied, got %+v", config)
+	}
+	if len(config.ServerOptions) != 1 || len(config.UnaryInterceptors) != 1 {
+		t.Errorf("Expected server options and interceptors to be added, got %+v", config)
+	}
+}
+
+func TestServerHealth(t *testing.T) {
+	s := NewServer("test", "1.0", WithLogger(&syncLogger{}), WithReflection(true))
+	conn := startTestServer(t, s, echo)
+	client := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("Expected SERVING, got %v and %v", resp, err)
+	}
+
+	s.SetServing(true)
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "test.Echo"})
+	if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected registered services to be SERVING, got %v and %v", resp, err)
+	}
+
+	s.SetServing(false)
+	resp, _ = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	if resp.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expected NOT_SERVING, got %v", resp)
+	}
+
+	if _, ok := s.GetServiceInfo()["grpc.reflection.v1.ServerReflection"]; !ok {
+		t.Error("Expected the reflection service to be registered")
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	s := NewServer("test", "1.0", WithLogger(&syncLogger{}))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	conn := startTestServer(t, s, func(ctx context.Context, in *wrapperspb.StringValue) (*wrapperspb.StringValue,
+		error) {
+		close(started)
+		<-release
+		return echo(ctx, in)
+	})
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := callEcho(context.Background(), conn, "slow")
+		result <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err == nil {
+		t.Error("Expected Shutdown to report the call still running at the deadline")
+	}
+	close(release)
+
+	if err := <-result; err == nil {
+		t.Error("Expected the running call to be cancelled")
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected a second Shutdown to succeed, got %v", err)
+	}
+}
+
+func TestServeWith(t *testing.T) {
+	base := api.NewBase("test", "1.0", "", true)
+	s := NewServer("test", "1.0", WithLogger(&syncLogger{}))
+	s.RegisterService(&echoServiceDesc, echoHandler(echo))
+
+	lis := bufconn.Listen(1 << 20)
+	s.ServeWith(base, lis)
+	conn := dial(t, lis)
+
+	if got, err := callEcho(context.Background(), conn, "hi"); err != nil || got != "echo: hi" {
+		t.Fatalf("Expected the call to succeed, got %q and %v", got, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := base.ServeContext(ctx, &http.Server{Addr: "127.0.0.1:0"})
+
+	if len(report.Hooks) != 1 || report.Hooks[0].Name != "grpc" || report.Hooks[0].Error != "" {
+		t.Errorf("Expected the grpc hook to run, got %+v", report.Hooks)
+	}
+	if _, err := callEcho(context.Background(), conn, "late"); err == nil {
+		t.Error("Expected calls after shutdown to fail")
+	}
+}
@@ -0,0 +1,158 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcHandledTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "Total number of gRPC calls completed by the server, by method and status code",
+	}, []string{"method", "code"})
+
+	grpcHandlingSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Duration of gRPC calls handled by the server",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	grpcPanicsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "grpc_panics_recovered_total",
+		Help: "Total number of panics recovered while serving gRPC calls",
+	})
+)
+
+// observeUnary logs and records metrics for unary calls
+func (s *Server) observeUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	s.observe(info.FullMethod, start, err)
+	return resp, err
+}
+
+// observeStream logs and records metrics for streaming calls
+func (s *Server) observeStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	s.observe(info.FullMethod, start, err)
+	return err
+}
+
+func (s *Server) observe(method string, start time.Time, err error) {
+	duration := time.Since(start)
+	code := status.Code(err)
+
+	grpcHandledTotal.WithLabelValues(method, code.String()).Inc()
+	grpcHandlingSeconds.WithLabelValues(method).Observe(duration.Seconds())
+
+	if isInfrastructure(method) {
+		return
+	}
+	if err != nil {
+		s.config.Logger.Printf("### 📡 gRPC: %s %s in %s: %v", method, code, duration.Round(time.Microsecond), err)
+		return
+	}
+	s.config.Logger.Printf("### 📡 gRPC: %s %s in %s", method, code, duration.Round(time.Microsecond))
+}
+
+// recoverUnary turns a panic in a unary handler into an Internal error
+func (s *Server) recoverUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = s.recovered(info.FullMethod, rec)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// recoverStream turns a panic in a stream handler into an Internal error
+func (s *Server) recoverStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = s.recovered(info.FullMethod, rec)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+func (s *Server) recovered(method string, rec interface{}) error {
+	s.config.Logger.Printf("### 💥 gRPC: panic serving %s: %v\n%s", method, rec, debug.Stack())
+	grpcPanicsTotal.Inc()
+	return status.Error(codes.Internal, "an unexpected error occurred")
+}
+
+// authUnary validates the caller of a unary call, adding its claims to the context
+func (s *Server) authUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authStream validates the caller of a streaming call, adding its claims to the stream's context
+func (s *Server) authStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate runs the validator against the call's authorization metadata. The validator works on
+// HTTP requests, so the metadata is presented as the request's Authorization header.
+func (s *Server) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if s.config.Validator == nil || s.isPublic(method) {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, method, http.NoBody)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to read credentials")
+	}
+	for _, value := range md.Get("authorization") {
+		r.Header.Add("Authorization", value)
+	}
+
+	result := s.config.Validator.ValidateRequest(r)
+	if !result.Valid {
+		code := codes.Unauthenticated
+		if result.ErrorCode == "JWKS_UNAVAILABLE" {
+			code = codes.Unavailable
+		}
+		return nil, status.Error(code, result.Error)
+	}
+
+	if result.Claims != nil {
+		ctx = context.WithValue(ctx, auth.JWTClaimsKey, result.Claims)
+	}
+	return ctx, nil
+}
+
+// contextStream replaces the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (cs *contextStream) Context() context.Context {
+	return cs.ctx
+}
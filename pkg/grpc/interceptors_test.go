@@ -0,0 +1,168 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// tokenValidator accepts the bearer token "good", reporting JWKS_UNAVAILABLE for "later"
+type tokenValidator struct{}
+
+func (tokenValidator) Middleware(next http.Handler) http.Handler      { return next }
+func (tokenValidator) Protect(next http.HandlerFunc) http.HandlerFunc { return next }
+
+func (tokenValidator) ValidateRequest(r *http.Request) auth.ValidationResult {
+	switch r.Header.Get("Authorization") {
+	case "Bearer good":
+		return auth.ValidationResult{Valid: true, Claims: jwt.MapClaims{"sub": "user-1"}}
+	case "Bearer later":
+		return auth.ValidationResult{ErrorCode: "JWKS_UNAVAILABLE", Error: "Signing keys are not available yet"}
+	default:
+		return auth.ValidationResult{ErrorCode: "INVALID_TOKEN", Error: "Token validation failed"}
+	}
+}
+
+// whoami echoes the caller's user ID from the claims the auth interceptor adds
+func whoami(ctx context.Context, _ *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	userID, _ := auth.GetUserIDFromContext(ctx)
+	return wrapperspb.String(userID), nil
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	s := NewServer("test", "1.0", WithLogger(&syncLogger{}), WithAuth(tokenValidator{}))
+	conn := startTestServer(t, s, whoami)
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode codes.Code
+		wantUser string
+	}{
+		{"valid token", "good", codes.OK, "user-1"},
+		{"invalid token", "bad", codes.Unauthenticated, ""},
+		{"missing token", "", codes.Unauthenticated, ""},
+		{"keys not loaded", "later", codes.Unavailable, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+tt.token)
+			}
+
+			user, err := callEcho(ctx, conn, "")
+			if status.Code(err) != tt.wantCode || user != tt.wantUser {
+				t.Errorf("Expected %s and user %q, got %v and %q", tt.wantCode, tt.wantUser, err, user)
+			}
+		})
+	}
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected health checks to skip auth, got %v and %v", resp, err)
+	}
+}
+
+func TestAuthPublicMethods(t *testing.T) {
+	s := NewServer("test", "1.0", WithLogger(&syncLogger{}), WithAuth(tokenValidator{}),
+		WithPublicMethods("/test.Echo/Echo"))
+	conn := startTestServer(t, s, echo)
+
+	if got, err := callEcho(context.Background(), conn, "hi"); err != nil || got != "echo: hi" {
+		t.Errorf("Expected a public method to skip auth, got %q and %v", got, err)
+	}
+}
+
+func TestRecoveryInterceptor(t *testing.T) {
+	logger := &syncLogger{}
+	s := NewServer("test", "1.0", WithLogger(logger))
+	conn := startTestServer(t, s, func(context.Context, *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+		panic("boom")
+	})
+
+	_, err := callEcho(context.Background(), conn, "hi")
+	if status.Code(err) != codes.Internal {
+		t.Errorf("Expected Internal, got %v", err)
+	}
+
+	output := logger.String()
+	if !strings.Contains(output, "panic serving /test.Echo/Echo: boom") {
+		t.Errorf("Expected the panic to be logged, got %q", output)
+	}
+	if !strings.Contains(output, "/test.Echo/Echo Internal") {
+		t.Errorf("Expected the call to be logged with its code, got %q", output)
+	}
+}
+
+func TestObserveInterceptorLogging(t *testing.T) {
+	logger := &syncLogger{}
+	s := NewServer("test", "1.0", WithLogger(logger))
+	conn := startTestServer(t, s, echo)
+
+	if _, err := callEcho(context.Background(), conn, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	output := logger.String()
+	if !strings.Contains(output, "/test.Echo/Echo OK in") {
+		t.Errorf("Expected the call to be logged, got %q", output)
+	}
+	if strings.Contains(output, "grpc.health") {
+		t.Errorf("Expected health checks not to be logged, got %q", output)
+	}
+}
+
+// fakeStream is a server stream that only carries a context
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (f *fakeStream) Context() context.Context {
+	return f.ctx
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	s := NewServer("test", "1.0", WithLogger(&syncLogger{}), WithAuth(tokenValidator{}))
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Echo/Watch"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer good"))
+
+	var user string
+	err := s.authStream(nil, &fakeStream{ctx: ctx}, info, func(_ interface{}, ss grpc.ServerStream) error {
+		user, _ = auth.GetUserIDFromContext(ss.Context())
+		return nil
+	})
+	if err != nil || user != "user-1" {
+		t.Errorf("Expected the stream context to carry the claims, got %q and %v", user, err)
+	}
+
+	err = s.authStream(nil, &fakeStream{ctx: context.Background()}, info, func(interface{}, grpc.ServerStream) error {
+		t.Error("Expected the handler not to run")
+		return nil
+	})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated, got %v", err)
+	}
+
+	err = s.recoverStream(nil, &fakeStream{ctx: ctx}, info, func(interface{}, grpc.ServerStream) error {
+		panic("boom")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("Expected a stream panic to become Internal, got %v", err)
+	}
+}
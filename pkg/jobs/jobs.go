@@ -0,0 +1,444 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/internal/background"
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
+	"github.com/Okja-Engineering/go-service-kit/pkg/report"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// jobRunsTotal counts job runs by outcome: success, failure, timeout, cancelled, panic, skipped, or standby
+	jobRunsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "job_runs_total",
+		Help: "Total number of scheduled job runs by outcome",
+	}, []string{"job", "result"})
+
+	// jobDuration observes how long job runs take
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "job_duration_seconds",
+		Help:    "Duration of scheduled job runs",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 10),
+	}, []string{"job"})
+)
+
+// ErrSchedulerStarted is returned when adding a job to a scheduler that is already running
+var ErrSchedulerStarted = errors.New("scheduler already started")
+
+// Func is the work a job does. It should return promptly once ctx is done.
+type Func func(ctx context.Context) error
+
+// Logger is the logging interface used by the scheduler
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// JobConfig holds configuration for a single job
+type JobConfig struct {
+	// Timeout cancels a run's context after this long; zero means no timeout
+	Timeout time.Duration
+	// Jitter delays each run by a random amount up to this, spreading load across replicas
+	Jitter time.Duration
+	// AllowOverlap lets a run start while the previous one is still going; by default it is skipped
+	AllowOverlap bool
+	// RunOnStart runs the job as soon as the scheduler starts, as well as on its schedule. With a
+	// Leadership, it runs once the election has settled, and only on the leader.
+	RunOnStart bool
+	// EveryReplica runs the job even when the scheduler's Leadership says this replica isn't leader
+	EveryReplica bool
+}
+
+// JobOption is a functional option for configuring a job
+type JobOption func(*JobConfig)
+
+// WithTimeout cancels a run's context after timeout
+func WithTimeout(timeout time.Duration) JobOption {
+	return func(config *JobConfig) {
+		config.Timeout = timeout
+	}
+}
+
+// WithJitter delays each run by a random amount up to jitter
+func WithJitter(jitter time.Duration) JobOption {
+	return func(config *JobConfig) {
+		config.Jitter = jitter
+	}
+}
+
+// WithOverlap allows a run to start while the previous one is still going
+func WithOverlap() JobOption {
+	return func(config *JobConfig) {
+		config.AllowOverlap = true
+	}
+}
+
+// WithRunOnStart runs the job when the scheduler starts
+func WithRunOnStart() JobOption {
+	return func(config *JobConfig) {
+		config.RunOnStart = true
+	}
+}
+
+// WithEveryReplica runs the job on every replica, for per-replica work such as clearing a local cache,
+// even when the scheduler only runs jobs on the leader
+func WithEveryReplica() JobOption {
+	return func(config *JobConfig) {
+		config.EveryReplica = true
+	}
+}
+
+// Leadership tells the scheduler whether this replica is the leader; leader.Elector implements it
+type Leadership interface {
+	// LeaderContext returns a context that is cancelled when this replica stops being leader, and whether
+	// it is leader now
+	LeaderContext() (context.Context, bool)
+	// Settled is closed once leadership is known, after the first election attempt
+	Settled() <-chan struct{}
+}
+
+// JobStatus is a snapshot of a job's recent activity
+type JobStatus struct {
+	Name         string    `json:"name"`
+	Running      bool      `json:"running"`
+	NextRun      time.Time `json:"nextRun,omitempty"`
+	LastRun      time.Time `json:"lastRun,omitempty"`
+	LastDuration string    `json:"lastDuration,omitempty"`
+	LastError    string    `json:"lastError,omitempty"`
+	Runs         int64     `json:"runs"`
+	Failures     int64     `json:"failures"`
+}
+
+type job struct {
+	name     string
+	schedule Schedule
+	fn       Func
+	config   *JobConfig
+
+	running atomic.Int32
+	mu      sync.Mutex
+	status  JobStatus
+}
+
+// Scheduler runs jobs on their schedules until it is stopped
+type Scheduler struct {
+	logger     Logger
+	clock      clock.Clock
+	reporter   report.ErrorReporter
+	leadership Leadership
+
+	mu      sync.Mutex
+	jobs    []*job
+	names   map[string]bool
+	started bool
+	cancel  context.CancelFunc
+	loops   sync.WaitGroup
+	runs    sync.WaitGroup
+}
+
+// Option is a functional option for configuring a scheduler
+type Option func(*Scheduler)
+
+// WithLogger sets the logger for job failures and lifecycle messages
+func WithLogger(logger Logger) Option {
+	return func(s *Scheduler) {
+		s.logger = logger
+	}
+}
+
+// WithClock sets the clock runs are scheduled by, e.g. a fake clock in tests. Job timeouts still
+// use real time.
+func WithClock(c clock.Clock) Option {
+	return func(s *Scheduler) {
+		s.clock = c
+	}
+}
+
+// WithReporter sets the reporter that receives job failures and panics; by default report.Default()
+func WithReporter(reporter report.ErrorReporter) Option {
+	return func(s *Scheduler) {
+		s.reporter = reporter
+	}
+}
+
+// WithLeadership runs jobs only while leadership reports this replica as leader, so that with a
+// scheduler on every replica each run happens on exactly one of them. Runs due on other replicas
+// are counted as standby, and a run in progress is cancelled when leadership is lost. Jobs added with
+// WithEveryReplica still run everywhere.
+func WithLeadership(leadership Leadership) Option {
+	return func(s *Scheduler) {
+		s.leadership = leadership
+	}
+}
+
+// NewScheduler creates a scheduler with no jobs
+func NewScheduler(options ...Option) *Scheduler {
+	s := &Scheduler{
+		logger: log.Default(),
+		clock:  clock.Real(),
+		names:  make(map[string]bool),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Add registers a job. Names must be unique, and jobs must be added before Start.
+func (s *Scheduler) Add(name string, schedule Schedule, fn Func, options ...JobOption) error {
+	config := &JobConfig{}
+	for _, option := range options {
+		option(config)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return ErrSchedulerStarted
+	}
+	if s.names[name] {
+		return fmt.Errorf("job %q already added", name)
+	}
+
+	s.names[name] = true
+	s.jobs = append(s.jobs, &job{name: name, schedule: schedule, fn: fn, config: config,
+		status: JobStatus{Name: name}})
+	return nil
+}
+
+// Start runs every job on its schedule until ctx is done or Stop is called
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, j := range s.jobs {
+		s.loops.Add(1)
+		go s.loop(ctx, j)
+	}
+
+	s.logger.Printf("### ⏰ Jobs: scheduler started with %d job(s)", len(s.jobs))
+}
+
+// Stop stops scheduling new runs and waits for running ones to finish, or for ctx to be done.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	s.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.logger.Printf("### ⏰ Jobs: scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("jobs still running at shutdown: %w", ctx.Err())
+	}
+}
+
+// Status reports every job's recent activity, sorted by name
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	jobs := append([]*job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(jobs))
+	for _, j := range jobs {
+		j.mu.Lock()
+		status := j.status
+		j.mu.Unlock()
+		status.Running = j.running.Load() > 0
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, k int) bool { return statuses[i].Name < statuses[k].Name })
+
+	return statuses
+}
+
+// loop waits for each scheduled time and starts a run, until ctx is done
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	defer s.loops.Done()
+
+	if j.config.RunOnStart && s.awaitLeadership(ctx, j) {
+		s.trigger(ctx, j)
+	}
+
+	last := s.clock.Now()
+	for {
+		next := j.schedule.Next(last)
+		if next.IsZero() {
+			s.logger.Printf("### ⏰ Jobs: %s has no further runs", j.name)
+			return
+		}
+		j.mu.Lock()
+		j.status.NextRun = next
+		j.mu.Unlock()
+
+		delay := next.Sub(s.clock.Now())
+		if j.config.Jitter > 0 {
+			delay += rand.N(j.config.Jitter)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(delay):
+		}
+
+		last = next
+		s.trigger(ctx, j)
+	}
+}
+
+// awaitLeadership waits until leadership is known for a job that runs only on the leader, reporting
+// false if ctx is done first
+func (s *Scheduler) awaitLeadership(ctx context.Context, j *job) bool {
+	if s.leadership == nil || j.config.EveryReplica {
+		return true
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-s.leadership.Settled():
+		return true
+	}
+}
+
+// runContext returns the context a run gets: ctx, also cancelled when this replica stops being leader for
+// jobs that run only on the leader. It reports false when another replica is leader.
+func (s *Scheduler) runContext(ctx context.Context, j *job) (context.Context, context.CancelFunc, bool) {
+	if s.leadership == nil || j.config.EveryReplica {
+		return ctx, func() {}, true
+	}
+	leaderCtx, leading := s.leadership.LeaderContext()
+	if !leading {
+		return nil, nil, false
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(leaderCtx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}, true
+}
+
+// trigger starts a run, unless the previous one is still going and overlap isn't allowed, or another
+// replica is leader
+func (s *Scheduler) trigger(ctx context.Context, j *job) {
+	runCtx, cancel, leading := s.runContext(ctx, j)
+	if !leading {
+		jobRunsTotal.WithLabelValues(j.name, "standby").Inc()
+		return
+	}
+	if j.running.Add(1) > 1 && !j.config.AllowOverlap {
+		j.running.Add(-1)
+		cancel()
+		jobRunsTotal.WithLabelValues(j.name, "skipped").Inc()
+		s.logger.Printf("### ⏰ Jobs: %s skipped, previous run still in progress", j.name)
+		return
+	}
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		defer j.running.Add(-1)
+		defer cancel()
+		s.run(runCtx, j)
+	}()
+}
+
+// run executes the job once with its timeout, recovering panics and recording the outcome
+func (s *Scheduler) run(ctx context.Context, j *job) {
+	if j.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.config.Timeout)
+		defer cancel()
+	}
+
+	start := s.clock.Now()
+	err := s.call(ctx, j)
+	duration := s.clock.Now().Sub(start)
+
+	result := "success"
+	switch {
+	case errors.Is(err, background.ErrPanic):
+		result = "panic"
+	case err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result = "timeout"
+	case err != nil && errors.Is(ctx.Err(), context.Canceled):
+		result = "cancelled"
+	case err != nil:
+		result = "failure"
+	}
+	jobRunsTotal.WithLabelValues(j.name, result).Inc()
+	jobDuration.WithLabelValues(j.name).Observe(duration.Seconds())
+
+	j.mu.Lock()
+	j.status.Runs++
+	j.status.LastRun = start
+	j.status.LastDuration = duration.String()
+	j.status.LastError = ""
+	if err != nil {
+		j.status.Failures++
+		j.status.LastError = err.Error()
+	}
+	j.mu.Unlock()
+
+	if err != nil {
+		s.logger.Printf("### ⏰ Jobs: %s failed after %s: %v", j.name, duration, err)
+	}
+	// Panics are reported by call, which has the stack; cancelled runs were stopped on purpose
+	if err != nil && result != "panic" && result != "cancelled" {
+		report.OrDefault(s.reporter).Report(ctx, report.Event{
+			Err:  err,
+			Tags: map[string]string{"job": j.name, "result": result},
+		})
+	}
+}
+
+// call runs the job function, converting a panic into an error
+func (s *Scheduler) call(ctx context.Context, j *job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			stack := debug.Stack()
+			s.logger.Printf("### 💥 Jobs: %s panicked: %v\n%s", j.name, rec, stack)
+			err = background.PanicError("job", rec)
+			report.OrDefault(s.reporter).Report(ctx, report.Event{
+				Err:   err,
+				Stack: stack,
+				Tags:  map[string]string{"job": j.name, "result": "panic"},
+			})
+		}
+	}()
+
+	return j.fn(ctx)
+}
@@ -0,0 +1,360 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
+	"github.com/Okja-Engineering/go-service-kit/pkg/report"
+)
+
+type syncLogger struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *syncLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(&l.buf, format+"\n", v...)
+}
+
+func (l *syncLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSchedulerRunsJobs(t *testing.T) {
+	s := NewScheduler(WithLogger(&syncLogger{}))
+
+	var runs atomic.Int32
+	if err := s.Add("tick", Every(10*time.Millisecond), func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	s.Start(context.Background())
+	waitFor(t, func() bool { return runs.Load() >= 3 })
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	stopped := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	if runs.Load() != stopped {
+		t.Error("Expected no runs after Stop")
+	}
+
+	status := s.Status()
+	if len(status) != 1 || status[0].Runs < 3 || status[0].Failures != 0 {
+		t.Errorf("Expected status to record runs, got %+v", status)
+	}
+}
+
+func TestSchedulerClock(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	s := NewScheduler(WithLogger(&syncLogger{}), WithClock(clk))
+
+	var runs atomic.Int32
+	if err := s.Add("hourly", Every(time.Hour), func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	s.Start(context.Background())
+	defer func() { _ = s.Stop(context.Background()) }()
+
+	clk.BlockUntil(1)
+	clk.Advance(59 * time.Minute)
+	if runs.Load() != 0 {
+		t.Fatal("Expected no run before the hour")
+	}
+
+	clk.Advance(time.Minute)
+	waitFor(t, func() bool { return s.Status()[0].Runs == 1 && clk.Waiters() == 1 })
+
+	status := s.Status()[0]
+	if !status.LastRun.Equal(start.Add(time.Hour)) || !status.NextRun.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("Expected times from the clock, got last %s and next %s", status.LastRun, status.NextRun)
+	}
+}
+
+func TestSchedulerAdd(t *testing.T) {
+	s := NewScheduler(WithLogger(&syncLogger{}))
+	noop := func(ctx context.Context) error { return nil }
+
+	if err := s.Add("cleanup", Every(time.Hour), noop); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := s.Add("cleanup", Every(time.Hour), noop); err == nil {
+		t.Error("Expected duplicate name to be rejected")
+	}
+
+	s.Start(context.Background())
+	defer func() { _ = s.Stop(context.Background()) }()
+
+	if err := s.Add("late", Every(time.Hour), noop); !errors.Is(err, ErrSchedulerStarted) {
+		t.Errorf("Expected ErrSchedulerStarted, got %v", err)
+	}
+}
+
+func TestSchedulerPreventsOverlap(t *testing.T) {
+	logger := &syncLogger{}
+	s := NewScheduler(WithLogger(logger))
+
+	var concurrent, maxConcurrent atomic.Int32
+	release := make(chan struct{})
+	_ = s.Add("slow", Every(5*time.Millisecond), func(ctx context.Context) error {
+		n := concurrent.Add(1)
+		defer concurrent.Add(-1)
+		if n > maxConcurrent.Load() {
+			maxConcurrent.Store(n)
+		}
+		<-release
+		return nil
+	}, WithRunOnStart())
+
+	s.Start(context.Background())
+	waitFor(t, func() bool { return strings.Contains(logger.String(), "slow skipped") })
+	close(release)
+	_ = s.Stop(context.Background())
+
+	if maxConcurrent.Load() != 1 {
+		t.Errorf("Expected one run at a time, got %d", maxConcurrent.Load())
+	}
+}
+
+func TestSchedulerRecordsFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		fn        Func
+		options   []JobOption
+		wantError string
+	}{
+		{"error", func(ctx context.Context) error { return errors.New("disk full") }, nil, "disk full"},
+		{"panic", func(ctx context.Context) error { panic("nil map") }, nil, "job panicked: nil map"},
+		{"timeout", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, []JobOption{WithTimeout(10 * time.Millisecond)}, "context deadline exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScheduler(WithLogger(&syncLogger{}))
+			_ = s.Add(tt.name, Every(time.Hour), tt.fn, append(tt.options, WithRunOnStart())...)
+
+			s.Start(context.Background())
+			waitFor(t, func() bool { return s.Status()[0].Runs == 1 })
+			_ = s.Stop(context.Background())
+
+			status := s.Status()[0]
+			if status.Failures != 1 || status.LastError != tt.wantError {
+				t.Errorf("Expected failure %q, got %+v", tt.wantError, status)
+			}
+		})
+	}
+}
+
+func TestSchedulerReportsFailures(t *testing.T) {
+	var mu sync.Mutex
+	events := make(map[string]report.Event)
+	reporter := report.ReporterFunc(func(_ context.Context, event report.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events[event.Tags["job"]] = event
+	})
+
+	s := NewScheduler(WithLogger(&syncLogger{}), WithReporter(reporter))
+	_ = s.Add("ok", Every(time.Hour), func(ctx context.Context) error { return nil }, WithRunOnStart())
+	_ = s.Add("error", Every(time.Hour), func(ctx context.Context) error { return errors.New("disk full") },
+		WithRunOnStart())
+	_ = s.Add("panic", Every(time.Hour), func(ctx context.Context) error { panic("nil map") }, WithRunOnStart())
+
+	s.Start(context.Background())
+	waitFor(t, func() bool {
+		for _, status := range s.Status() {
+			if status.Runs == 0 {
+				return false
+			}
+		}
+		return true
+	})
+	_ = s.Stop(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 {
+		t.Fatalf("Expected the failure and panic to be reported, got %v", events)
+	}
+	if events["error"].Err.Error() != "disk full" || events["error"].Tags["result"] != "failure" {
+		t.Errorf("Unexpected failure event %+v", events["error"])
+	}
+	if events["panic"].Tags["result"] != "panic" || len(events["panic"].Stack) == 0 {
+		t.Errorf("Expected the panic with its stack, got %+v", events["panic"])
+	}
+}
+
+func TestSchedulerStopTimeout(t *testing.T) {
+	s := NewScheduler(WithLogger(&syncLogger{}))
+	release := make(chan struct{})
+	defer close(release)
+
+	_ = s.Add("stubborn", Every(time.Hour), func(ctx context.Context) error {
+		<-release
+		return nil
+	}, WithRunOnStart())
+
+	s.Start(context.Background())
+	waitFor(t, func() bool { return s.Status()[0].Running })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Stop to give up at the deadline, got %v", err)
+	}
+}
+
+// fakeLeadership is a Leadership that tests elect and depose by hand
+type fakeLeadership struct {
+	settled chan struct{}
+
+	mu     sync.Mutex
+	term   context.Context
+	cancel context.CancelFunc
+}
+
+func newFakeLeadership() *fakeLeadership {
+	return &fakeLeadership{settled: make(chan struct{})}
+}
+
+func (l *fakeLeadership) LeaderContext() (context.Context, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.term, l.term != nil
+}
+
+func (l *fakeLeadership) Settled() <-chan struct{} { return l.settled }
+
+// lead makes this replica leader and settles the election
+func (l *fakeLeadership) lead() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.term, l.cancel = context.WithCancel(context.Background())
+	l.settle()
+}
+
+// lose ends leadership, cancelling its context
+func (l *fakeLeadership) lose() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cancel()
+	l.term = nil
+}
+
+func (l *fakeLeadership) settle() {
+	select {
+	case <-l.settled:
+	default:
+		close(l.settled)
+	}
+}
+
+func TestSchedulerLeadership(t *testing.T) {
+	leadership := newFakeLeadership()
+	s := NewScheduler(WithLogger(&syncLogger{}), WithLeadership(leadership))
+
+	var leaderRuns, replicaRuns atomic.Int32
+	_ = s.Add("report", Every(5*time.Millisecond), func(ctx context.Context) error {
+		leaderRuns.Add(1)
+		return nil
+	})
+	_ = s.Add("local-cache", Every(5*time.Millisecond), func(ctx context.Context) error {
+		replicaRuns.Add(1)
+		return nil
+	}, WithEveryReplica())
+
+	s.Start(context.Background())
+	defer func() { _ = s.Stop(context.Background()) }()
+
+	waitFor(t, func() bool { return replicaRuns.Load() >= 3 })
+	if leaderRuns.Load() != 0 {
+		t.Fatalf("Expected no runs while not leader, got %d", leaderRuns.Load())
+	}
+
+	leadership.lead()
+	waitFor(t, func() bool { return leaderRuns.Load() >= 1 })
+}
+
+func TestSchedulerCancelsRunsOnLeadershipLoss(t *testing.T) {
+	leadership := newFakeLeadership()
+	leadership.lead()
+	s := NewScheduler(WithLogger(&syncLogger{}), WithLeadership(leadership))
+
+	started := make(chan struct{}, 1)
+	_ = s.Add("report", Every(time.Hour), func(ctx context.Context) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithRunOnStart())
+
+	s.Start(context.Background())
+	defer func() { _ = s.Stop(context.Background()) }()
+
+	<-started
+	leadership.lose()
+	waitFor(t, func() bool { return s.Status()[0].Runs == 1 })
+
+	if status := s.Status()[0]; status.Running || status.LastError != context.Canceled.Error() {
+		t.Errorf("Expected the run to be cancelled when leadership was lost, got %+v", status)
+	}
+}
+
+func TestSchedulerRunOnStartAwaitsLeadership(t *testing.T) {
+	leadership := newFakeLeadership()
+	s := NewScheduler(WithLogger(&syncLogger{}), WithLeadership(leadership))
+
+	var runs atomic.Int32
+	_ = s.Add("warm-up", Every(time.Hour), func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}, WithRunOnStart())
+
+	s.Start(context.Background())
+	defer func() { _ = s.Stop(context.Background()) }()
+
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != 0 {
+		t.Fatalf("Expected RunOnStart to wait for the election, got %d runs", runs.Load())
+	}
+
+	// The election settles with this replica as leader, and the job runs
+	leadership.lead()
+	waitFor(t, func() bool { return runs.Load() == 1 })
+}
@@ -0,0 +1,247 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a job runs next
+type Schedule interface {
+	// Next returns the first run time strictly after t, or the zero time if there is none
+	Next(t time.Time) time.Time
+}
+
+// intervalSchedule runs at a fixed interval
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+// Every returns a schedule that runs at a fixed interval, measured from the previous run
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return intervalSchedule{interval: interval}
+}
+
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// cronField is the set of allowed values of a cron field, one bit per value
+type cronField uint64
+
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// cronSchedule runs at times matching a standard five-field cron expression
+type cronSchedule struct {
+	minute, hour, dom, month, dow cronField
+	domAny, dowAny                bool
+	location                      *time.Location
+}
+
+// cronBounds are the value ranges of the five fields, with names for months and weekdays
+var cronBounds = []struct {
+	min, max int
+	names    []string
+}{
+	{0, 59, nil},
+	{0, 23, nil},
+	{1, 31, nil},
+	{1, 12, []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{0, 7, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// cronDescriptors are the supported shorthand expressions
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Cron parses a five-field cron expression (minute hour day-of-month month day-of-week), evaluated in
+// UTC. Fields support *, lists, ranges, steps, and month and weekday names. The descriptors @hourly,
+// @daily, @weekly, @monthly, @yearly, and "@every <duration>" are also accepted.
+func Cron(expr string) (Schedule, error) {
+	return CronIn(expr, time.UTC)
+}
+
+// CronIn parses a cron expression evaluated in the given location
+func CronIn(expr string, location *time.Location) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if every, ok := strings.CutPrefix(expr, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(every))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid interval in %q", expr)
+		}
+		return Every(interval), nil
+	}
+	if descriptor, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+
+	parsed := make([]cronField, 5)
+	for i, field := range fields {
+		f, err := parseCronField(field, cronBounds[i].min, cronBounds[i].max, cronBounds[i].names)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		parsed[i] = f
+	}
+
+	// Sunday can be written as 0 or 7
+	dow := parsed[4]
+	if dow.has(7) {
+		dow |= 1
+	}
+
+	return &cronSchedule{
+		minute:   parsed[0],
+		hour:     parsed[1],
+		dom:      parsed[2],
+		month:    parsed[3],
+		dow:      dow,
+		domAny:   strings.HasPrefix(fields[2], "*"),
+		dowAny:   strings.HasPrefix(fields[4], "*"),
+		location: location,
+	}, nil
+}
+
+// MustCron is Cron that panics on an invalid expression, for schedules fixed at compile time
+func MustCron(expr string) Schedule {
+	schedule, err := Cron(expr)
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+// parseCronField parses one comma-separated field into its set of values
+func parseCronField(field string, min, max int, names []string) (cronField, error) {
+	var set cronField
+
+	for _, part := range strings.Split(field, ",") {
+		lo, hi, step, err := parseCronPart(part, min, max, names)
+		if err != nil {
+			return 0, err
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+// parseCronPart parses "*", "a", or "a-b", each optionally followed by "/step"
+func parseCronPart(part string, min, max int, names []string) (lo, hi, step int, err error) {
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+	step = 1
+	if hasStep {
+		if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+			return 0, 0, 0, fmt.Errorf("invalid step %q", part)
+		}
+	}
+
+	lo, hi = min, max
+	if rangePart != "*" {
+		var isRange bool
+		if lo, hi, isRange, err = parseCronRange(rangePart, names); err != nil {
+			return 0, 0, 0, err
+		}
+		// A single value with a step runs to the end of the field
+		if !isRange && hasStep {
+			hi = max
+		}
+	}
+	if lo < min || hi > max || lo > hi {
+		return 0, 0, 0, fmt.Errorf("value %q out of range %d-%d", part, min, max)
+	}
+
+	return lo, hi, step, nil
+}
+
+// parseCronRange parses "a" or "a-b"
+func parseCronRange(s string, names []string) (lo, hi int, isRange bool, err error) {
+	loPart, hiPart, isRange := strings.Cut(s, "-")
+
+	if lo, err = parseCronValue(loPart, names); err != nil {
+		return 0, 0, false, err
+	}
+	if !isRange {
+		return lo, lo, false, nil
+	}
+	if hi, err = parseCronValue(hiPart, names); err != nil {
+		return 0, 0, false, err
+	}
+	return lo, hi, true, nil
+}
+
+func parseCronValue(s string, names []string) (int, error) {
+	for i, name := range names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+// Next finds the next matching minute, skipping whole months, days, and hours that can't match
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.In(s.location)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, s.location).Add(time.Minute)
+
+	// Five years covers every valid expression, including 29 February
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !s.month.has(int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+		case !s.hour.has(t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+		case !s.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// dayMatches applies cron's rule that when both day fields are restricted, either may match
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom.has(t.Day())
+	dow := s.dow.has(int(t.Weekday()))
+
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// 2025-01-15 is a Wednesday
+	from := time.Date(2025, 1, 15, 10, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2025, 1, 16, 9, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2025, 1, 15, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * mon", time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,20 * *", time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jun *", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * fri", time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := Cron(tt.expr)
+			if err != nil {
+				t.Fatalf("Cron(%q) failed: %v", tt.expr, err)
+			}
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *",
+		"5-1 * * * *", "0 0 * foo *", "@every soon", "@every -1m"} {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := Cron(expr); err == nil {
+				t.Errorf("Expected %q to be rejected", expr)
+			}
+		})
+	}
+}
+
+func TestCronIn(t *testing.T) {
+	location := time.FixedZone("UTC+2", 2*60*60)
+	schedule, err := CronIn("0 9 * * *", location)
+	if err != nil {
+		t.Fatalf("CronIn failed: %v", err)
+	}
+
+	got := schedule.Next(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2025, 1, 15, 7, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Expected 09:00 local to be %s, got %s", want, got.UTC())
+	}
+}
+
+func TestEvery(t *testing.T) {
+	from := time.Now()
+	if got := Every(time.Minute).Next(from); !got.Equal(from.Add(time.Minute)) {
+		t.Errorf("Expected one minute later, got %s", got)
+	}
+}
@@ -0,0 +1,357 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/internal/background"
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// leaderGauge is 1 on the replica that holds an election's lock and 0 elsewhere
+var leaderGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "leader_elected",
+	Help: "Whether this replica is the leader of an election",
+}, []string{"election"})
+
+// releaseTimeout bounds releasing the lock when stepping down, which runs after ctx is done
+const releaseTimeout = 5 * time.Second
+
+// notLeading is the context LeaderContext returns on a follower, which is already cancelled
+var notLeading = func() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}()
+
+// Logger is the logging interface used by the elector
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Config holds configuration for an elector
+type Config struct {
+	// RetryInterval is how often a follower tries to take the lock
+	RetryInterval time.Duration
+	// CheckInterval is how often the leader checks that it still holds the lock
+	CheckInterval time.Duration
+	// OnAcquire is called when this replica becomes leader, with a context that is cancelled when it
+	// stops being leader. It should return promptly, starting any long-running work in a goroutine.
+	OnAcquire func(ctx context.Context)
+	// OnLose is called when this replica stops being leader, including when it steps down on Stop
+	OnLose func()
+	Logger Logger
+	Clock  clock.Clock
+}
+
+// DefaultConfig provides sensible defaults
+func DefaultConfig() *Config {
+	return &Config{
+		RetryInterval: 5 * time.Second,
+		CheckInterval: 5 * time.Second,
+		Logger:        log.Default(),
+		Clock:         clock.Real(),
+	}
+}
+
+// Option is a functional option for configuring an elector
+type Option func(*Config)
+
+// WithRetryInterval sets how often a follower tries to take the lock
+func WithRetryInterval(interval time.Duration) Option {
+	return func(config *Config) {
+		config.RetryInterval = interval
+	}
+}
+
+// WithCheckInterval sets how often the leader checks that it still holds the lock
+func WithCheckInterval(interval time.Duration) Option {
+	return func(config *Config) {
+		config.CheckInterval = interval
+	}
+}
+
+// WithOnAcquire sets the callback for becoming leader
+func WithOnAcquire(fn func(ctx context.Context)) Option {
+	return func(config *Config) {
+		config.OnAcquire = fn
+	}
+}
+
+// WithOnLose sets the callback for no longer being leader
+func WithOnLose(fn func()) Option {
+	return func(config *Config) {
+		config.OnLose = fn
+	}
+}
+
+// WithLogger sets the logger for leadership changes
+func WithLogger(logger Logger) Option {
+	return func(config *Config) {
+		config.Logger = logger
+	}
+}
+
+// WithClock sets the clock the retry and check intervals are measured with
+func WithClock(c clock.Clock) Option {
+	return func(config *Config) {
+		config.Clock = c
+	}
+}
+
+// NewConfig creates a new configuration with the provided options
+func NewConfig(options ...Option) *Config {
+	config := DefaultConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Elector elects one leader among the replicas sharing a database and an election name. The leader
+// holds a session-level PostgreSQL advisory lock on a connection it keeps out of the pool, so
+// leadership passes to another replica as soon as the leader steps down, crashes, or loses its
+// connection.
+type Elector struct {
+	db     database.Database
+	name   string
+	key    int64
+	config *Config
+	leader atomic.Bool
+
+	settled    chan struct{}
+	settleOnce sync.Once
+
+	mu     sync.Mutex
+	term   context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates an elector for the election name; call Start to campaign
+func New(db database.Database, name string, options ...Option) *Elector {
+	return &Elector{
+		db:      db,
+		name:    name,
+		key:     Key(name),
+		config:  NewConfig(options...),
+		settled: make(chan struct{}),
+	}
+}
+
+// Key returns the advisory lock key of an election name
+func Key(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("leader:" + name))
+	return int64(h.Sum64())
+}
+
+// Name returns the election name
+func (e *Elector) Name() string {
+	return e.name
+}
+
+// IsLeader reports whether this replica currently holds the lock
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// LeaderContext returns a context that is cancelled when this replica stops being leader, and whether it
+// is leader now. On a follower the context is already cancelled.
+func (e *Elector) LeaderContext() (context.Context, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.term == nil {
+		return notLeading, false
+	}
+	return e.term, true
+}
+
+// Settled returns a channel that is closed once the first attempt to take the lock has finished, so
+// IsLeader reflects the election rather than a campaign that hasn't started
+func (e *Elector) Settled() <-chan struct{} {
+	return e.settled
+}
+
+// Start campaigns for leadership in the background until ctx is done or Stop is called
+func (e *Elector) Start(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.cancel != nil {
+		return
+	}
+	ctx, e.cancel = context.WithCancel(ctx)
+	e.done = make(chan struct{})
+	go e.campaign(ctx)
+}
+
+// Stop stops campaigning and, if leader, releases the lock so another replica can take over
+func (e *Elector) Stop(ctx context.Context) error {
+	e.mu.Lock()
+	cancel, done := e.cancel, e.done
+	e.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("leader election %s still stopping: %w", e.name, ctx.Err())
+	}
+}
+
+// campaign tries to take the lock every RetryInterval and leads while it holds it
+func (e *Elector) campaign(ctx context.Context) {
+	defer close(e.done)
+	defer e.settle()
+
+	for {
+		conn, err := e.tryAcquire(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			e.config.Logger.Printf("### 👑 Leader: %s failed to campaign: %v", e.name, err)
+		case conn != nil:
+			e.lead(ctx, conn)
+		}
+		e.settle()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.config.Clock.After(e.config.RetryInterval):
+		}
+	}
+}
+
+// tryAcquire takes the lock on a dedicated connection, returning nil if another replica holds it
+func (e *Elector) tryAcquire(ctx context.Context) (*sql.Conn, error) {
+	db := e.db.GetDB()
+	if db == nil {
+		return nil, errors.New("database connection is closed")
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, e.key).Scan(&acquired); err != nil {
+		background.Discard(conn)
+		return nil, fmt.Errorf("failed to take lock: %w", err)
+	}
+	if !acquired {
+		_ = conn.Close()
+		return nil, nil
+	}
+	return conn, nil
+}
+
+// lead runs while this replica holds the lock on conn, checking it every CheckInterval, and steps
+// down when the lock is lost or ctx is done
+func (e *Elector) lead(ctx context.Context, conn *sql.Conn) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	e.mu.Lock()
+	e.term = leaderCtx
+	e.mu.Unlock()
+	e.setLeader(true)
+	e.settle()
+	e.config.Logger.Printf("### 👑 Leader: became leader of %s", e.name)
+	if e.config.OnAcquire != nil {
+		e.config.OnAcquire(leaderCtx)
+	}
+
+	ticker := e.config.Clock.NewTicker(e.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			cancel()
+			e.release(conn)
+			e.stepDown("stepped down")
+			return
+		case <-ticker.C():
+		}
+
+		if err := e.checkLock(ctx, conn); err != nil && ctx.Err() == nil {
+			cancel()
+			background.Discard(conn)
+			e.stepDown(fmt.Sprintf("lost leadership: %v", err))
+			return
+		}
+	}
+}
+
+// checkLock returns an error unless the session of conn still holds the lock
+func (e *Elector) checkLock(ctx context.Context, conn *sql.Conn) error {
+	// A bigint advisory lock is listed in pg_locks with its high and low halves as classid and objid
+	query := `SELECT EXISTS (SELECT 1 FROM pg_locks WHERE locktype = 'advisory' AND pid = pg_backend_pid()
+		AND classid = $1 AND objid = $2 AND objsubid = 1 AND granted)`
+	var held bool
+	err := conn.QueryRowContext(ctx, query, int64(uint32(e.key>>32)), int64(uint32(e.key))).Scan(&held)
+	if err != nil {
+		return err
+	}
+	if !held {
+		return errors.New("lock no longer held")
+	}
+	return nil
+}
+
+// release unlocks and returns conn to the pool, or closes it if the lock can't be released
+func (e *Elector) release(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, e.key); err != nil {
+		e.config.Logger.Printf("### 👑 Leader: %s failed to release lock: %v", e.name, err)
+		background.Discard(conn)
+		return
+	}
+	_ = conn.Close()
+}
+
+// stepDown records the end of leadership and calls OnLose
+func (e *Elector) stepDown(reason string) {
+	e.mu.Lock()
+	e.term = nil
+	e.mu.Unlock()
+	e.setLeader(false)
+	e.config.Logger.Printf("### 👑 Leader: %s of %s", reason, e.name)
+	if e.config.OnLose != nil {
+		e.config.OnLose()
+	}
+}
+
+func (e *Elector) setLeader(leader bool) {
+	e.leader.Store(leader)
+	value := 0.0
+	if leader {
+		value = 1
+	}
+	leaderGauge.WithLabelValues(e.name).Set(value)
+}
+
+// settle marks the election as settled once its first attempt has finished
+func (e *Elector) settle() {
+	e.settleOnce.Do(func() { close(e.settled) })
+}
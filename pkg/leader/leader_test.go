@@ -0,0 +1,160 @@
+package leader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+	"github.com/Okja-Engineering/go-service-kit/pkg/jobs"
+)
+
+// An elector limits scheduled jobs to the leader
+var _ jobs.Leadership = (*Elector)(nil)
+
+type syncLogger struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *syncLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(&l.buf, format+"\n", v...)
+}
+
+func (l *syncLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// newTestElector creates an elector on mock with short intervals and recording callbacks
+func newTestElector(mock *database.Mock, acquired, lost *atomic.Int32) (*Elector, *syncLogger) {
+	logger := &syncLogger{}
+	return New(mock, "reports",
+		WithRetryInterval(5*time.Millisecond),
+		WithCheckInterval(5*time.Millisecond),
+		WithLogger(logger),
+		WithOnAcquire(func(ctx context.Context) { acquired.Add(1) }),
+		WithOnLose(func() { lost.Add(1) }),
+	), logger
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if config.RetryInterval != 5*time.Second || config.CheckInterval != 5*time.Second {
+		t.Errorf("Expected 5s intervals, got %s and %s", config.RetryInterval, config.CheckInterval)
+	}
+
+	config = NewConfig(WithRetryInterval(time.Second), WithCheckInterval(time.Minute))
+	if config.RetryInterval != time.Second || config.CheckInterval != time.Minute {
+		t.Errorf("Expected intervals to be overridden, got %+v", config)
+	}
+}
+
+func TestKey(t *testing.T) {
+	if Key("reports") != Key("reports") {
+		t.Error("Expected the key of a name to be stable")
+	}
+	if Key("reports") == Key("billing") {
+		t.Error("Expected different names to have different keys")
+	}
+}
+
+func TestElectorLeadsAndStepsDown(t *testing.T) {
+	mock := database.NewMock().
+		OnQuery("pg_try_advisory_lock", []string{"acquired"}, []interface{}{true}).
+		OnQuery("pg_locks", []string{"held"}, []interface{}{true})
+	var acquired, lost atomic.Int32
+	e, logger := newTestElector(mock, &acquired, &lost)
+
+	e.Start(context.Background())
+	<-e.Settled()
+	term, leading := e.LeaderContext()
+	if !leading || term.Err() != nil || !e.IsLeader() {
+		t.Fatalf("Expected a live leader context once settled, got %v and %v", leading, term.Err())
+	}
+	waitFor(t, func() bool { return len(mock.CallsTo("Query")) >= 3 })
+
+	if err := e.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if e.IsLeader() || acquired.Load() != 1 || lost.Load() != 1 {
+		t.Errorf("Expected one term, got leader %v, %d acquired, %d lost", e.IsLeader(), acquired.Load(), lost.Load())
+	}
+	if term.Err() == nil {
+		t.Error("Expected the leader context to be cancelled on stepping down")
+	}
+
+	unlocks := mock.CallsTo("Exec")
+	if len(unlocks) != 1 || !strings.Contains(unlocks[0].Query, "pg_advisory_unlock") ||
+		unlocks[0].Args[0] != Key("reports") {
+		t.Errorf("Expected the lock to be released, got %+v", unlocks)
+	}
+	if !strings.Contains(logger.String(), "became leader of reports") {
+		t.Errorf("Expected leadership to be logged, got %s", logger.String())
+	}
+}
+
+func TestElectorLosesLock(t *testing.T) {
+	mock := database.NewMock().
+		OnQuery("pg_try_advisory_lock", []string{"acquired"}, []interface{}{true}).
+		OnQuery("pg_locks", []string{"held"}, []interface{}{false})
+	var acquired, lost atomic.Int32
+	e, logger := newTestElector(mock, &acquired, &lost)
+
+	e.Start(context.Background())
+	defer func() { _ = e.Stop(context.Background()) }()
+
+	// Each term ends at the first check, and the elector campaigns again
+	waitFor(t, func() bool { return lost.Load() >= 2 })
+	if acquired.Load() < 2 {
+		t.Errorf("Expected the elector to campaign again, got %d terms", acquired.Load())
+	}
+	if !strings.Contains(logger.String(), "lost leadership: lock no longer held") {
+		t.Errorf("Expected the loss to be logged, got %s", logger.String())
+	}
+}
+
+func TestElectorFollows(t *testing.T) {
+	mock := database.NewMock().
+		OnQuery("pg_try_advisory_lock", []string{"acquired"}, []interface{}{false})
+	var acquired, lost atomic.Int32
+	e, _ := newTestElector(mock, &acquired, &lost)
+
+	e.Start(context.Background())
+	<-e.Settled()
+	if term, leading := e.LeaderContext(); leading || term.Err() == nil {
+		t.Errorf("Expected a follower to get a cancelled context, got %v", leading)
+	}
+	waitFor(t, func() bool { return len(mock.CallsTo("Query")) >= 3 })
+	if err := e.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	if e.IsLeader() || acquired.Load() != 0 || lost.Load() != 0 {
+		t.Errorf("Expected a follower, got leader %v, %d acquired, %d lost", e.IsLeader(), acquired.Load(),
+			lost.Load())
+	}
+	if len(mock.CallsTo("Exec")) != 0 {
+		t.Error("Expected a follower not to release anything")
+	}
+}
@@ -0,0 +1,177 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/redact"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// BodyCaptureOption is a functional option for body capture configuration
+type BodyCaptureOption func(*BodyCaptureConfig)
+
+// BodyCaptureConfig holds configuration for capturing request and response bodies
+type BodyCaptureConfig struct {
+	Logger Logger
+	// MaxBytes is how much of each body is kept; the rest is dropped and marked as truncated
+	MaxBytes int
+	// MinStatus logs the bodies of responses with at least this status
+	MinStatus int
+	// DebugHeader logs the bodies of any request carrying it; empty, the default, disables it. Anyone can
+	// send a header, so only set it on routes behind authentication.
+	DebugHeader string
+	// Redactor removes credentials and personal data from captured bodies
+	Redactor *redact.Redactor
+}
+
+// DefaultBodyCaptureConfig provides sensible defaults
+func DefaultBodyCaptureConfig() *BodyCaptureConfig {
+	return &BodyCaptureConfig{
+		Logger:    log.New(os.Stdout, "", log.LstdFlags),
+		MaxBytes:  4096,
+		MinStatus: http.StatusInternalServerError,
+		Redactor:  redact.Default(),
+	}
+}
+
+// WithBodyLogger sets the logger captured bodies are written to
+func WithBodyLogger(logger Logger) BodyCaptureOption {
+	return func(config *BodyCaptureConfig) {
+		config.Logger = logger
+	}
+}
+
+// WithMaxBodyBytes sets how much of each body is kept
+func WithMaxBodyBytes(maxBytes int) BodyCaptureOption {
+	return func(config *BodyCaptureConfig) {
+		config.MaxBytes = maxBytes
+	}
+}
+
+// WithCaptureStatus sets the lowest response status whose bodies are logged
+func WithCaptureStatus(status int) BodyCaptureOption {
+	return func(config *BodyCaptureConfig) {
+		config.MinStatus = status
+	}
+}
+
+// WithDebugHeader sets the request header that logs bodies whatever the status; empty disables it. Use it
+// only behind authentication, since any client can send the header.
+func WithDebugHeader(header string) BodyCaptureOption {
+	return func(config *BodyCaptureConfig) {
+		config.DebugHeader = header
+	}
+}
+
+// WithBodyRedactor sets the redactor applied to captured bodies; nil logs them as sent
+func WithBodyRedactor(redactor *redact.Redactor) BodyCaptureOption {
+	return func(config *BodyCaptureConfig) {
+		config.Redactor = redactor
+	}
+}
+
+// NewBodyCaptureConfig creates a new body capture config with options
+func NewBodyCaptureConfig(options ...BodyCaptureOption) *BodyCaptureConfig {
+	config := DefaultBodyCaptureConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// BodyCapture returns debug middleware that keeps the start of each request and response body and
+// logs them, redacted, when the response status is at least MinStatus, the request carries the
+// debug header if one is set, or the process-wide log level is debug. Bodies the handler doesn't read aren't captured.
+func BodyCapture(options ...BodyCaptureOption) func(next http.Handler) http.Handler {
+	config := NewBodyCaptureConfig(options...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestBody := &cappedBuffer{max: config.MaxBytes}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.TeeReader(r.Body, requestBody), r.Body}
+			}
+
+			responseBody := &cappedBuffer{max: config.MaxBytes}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(responseBody)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			debug := config.DebugHeader != "" && r.Header.Get(config.DebugHeader) != ""
+			if status >= config.MinStatus || debug || Enabled(slog.LevelDebug) {
+				config.Logger.Printf("%s %s %d, request body: %q, response body: %q", r.Method,
+					r.URL.Path, status, config.redactBody(requestBody, r.Header), config.redactBody(responseBody, ww.Header()))
+			}
+		})
+	}
+}
+
+// redactBody returns a captured body with sensitive JSON and form fields redacted and other text
+// masked. JSON that can't be parsed, such as a truncated document, is replaced by the mask, since
+// its sensitive fields can't be found.
+func (config *BodyCaptureConfig) redactBody(body *cappedBuffer, header http.Header) string {
+	data := body.buf.Bytes()
+	if config.Redactor != nil && len(data) > 0 {
+		data = config.redact(data, header.Get("Content-Type"))
+	}
+
+	if body.truncated {
+		return string(data) + "...(truncated)"
+	}
+	return string(data)
+}
+
+// redact redacts a body according to its content type
+func (config *BodyCaptureConfig) redact(data []byte, contentType string) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		redacted, err := config.Redactor.JSON(data)
+		if err != nil {
+			return []byte(redact.Mask)
+		}
+		return redacted
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			return []byte(redact.Mask)
+		}
+		return []byte(config.Redactor.Query(form).Encode())
+	default:
+		return []byte(config.Redactor.String(string(data)))
+	}
+}
+
+// cappedBuffer keeps the first max bytes written to it and drops the rest
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+// Write never fails, so it can't disturb the reader or writer it tees from
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.max - b.buf.Len(); room < n {
+		b.truncated = true
+		p = p[:max(room, 0)]
+	}
+	b.buf.Write(p)
+	return n, nil
+}
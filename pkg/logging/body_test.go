@@ -0,0 +1,152 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// bufferLogger collects formatted log lines
+type bufferLogger struct {
+	bytes.Buffer
+}
+
+func (l *bufferLogger) Printf(format string, v ...interface{}) {
+	fmt.Fprintf(&l.Buffer, format+"\n", v...)
+}
+
+func (l *bufferLogger) Println(v ...interface{}) {
+	fmt.Fprintln(&l.Buffer, v...)
+}
+
+func TestBodyCapture(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     []BodyCaptureOption
+		contentType string
+		body        string
+		debug       bool
+		status      int
+		contains    []string
+		excludes    []string
+	}{
+		{
+			name:        "logs server errors with JSON redacted",
+			contentType: "application/json",
+			body:        `{"user":"jane","password":"hunter2"}`,
+			status:      http.StatusInternalServerError,
+			contains:    []string{"POST /orders 500", `\"user\":\"jane\"`, "[REDACTED]", "response body: \"failed\""},
+			excludes:    []string{"hunter2"},
+		},
+		{
+			name:     "skips successful requests",
+			body:     "hello",
+			status:   http.StatusOK,
+			excludes: []string{"hello"},
+		},
+		{
+			name:     "ignores the debug header by default",
+			body:     "hello",
+			debug:    true,
+			status:   http.StatusOK,
+			excludes: []string{"hello"},
+		},
+		{
+			name:     "logs requests with the debug header",
+			options:  []BodyCaptureOption{WithDebugHeader("X-Debug-Body")},
+			body:     "hello",
+			debug:    true,
+			status:   http.StatusOK,
+			contains: []string{"POST /orders 200", `request body: "hello"`},
+		},
+		{
+			name:        "redacts form fields",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "user=jane&token=abc123",
+			status:      http.StatusBadGateway,
+			contains:    []string{"user=jane"},
+			excludes:    []string{"abc123"},
+		},
+		{
+			name:     "truncates long bodies",
+			options:  []BodyCaptureOption{WithMaxBodyBytes(5)},
+			body:     "hello world",
+			status:   http.StatusInternalServerError,
+			contains: []string{`request body: "hello...(truncated)"`},
+			excludes: []string{"world"},
+		},
+		{
+			name:        "masks truncated JSON",
+			options:     []BodyCaptureOption{WithMaxBodyBytes(20)},
+			contentType: "application/json",
+			body:        `{"user":"jane","password":"hunter2"}`,
+			status:      http.StatusInternalServerError,
+			contains:    []string{`request body: "[REDACTED]...(truncated)"`},
+			excludes:    []string{"jane"},
+		},
+		{
+			name:     "lowers the capture status",
+			options:  []BodyCaptureOption{WithCaptureStatus(http.StatusBadRequest)},
+			body:     "bad",
+			status:   http.StatusUnprocessableEntity,
+			contains: []string{`request body: "bad"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &bufferLogger{}
+			options := append([]BodyCaptureOption{WithBodyLogger(logger)}, tt.options...)
+
+			var received string
+			handler := BodyCapture(options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				received = string(body)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("failed"))
+			}))
+
+			req := httptest.NewRequest("POST", "/orders", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.debug {
+				req.Header.Set("X-Debug-Body", "1")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if received != tt.body || rec.Body.String() != "failed" {
+				t.Errorf("Expected bodies to pass through, got %q and %q", received, rec.Body.String())
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(logger.String(), want) {
+					t.Errorf("Expected the log to contain %s, got %q", want, logger.String())
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(logger.String(), unwanted) {
+					t.Errorf("Expected the log not to contain %s, got %q", unwanted, logger.String())
+				}
+			}
+		})
+	}
+}
+
+func TestBodyCaptureDebugLevel(t *testing.T) {
+	SetLevel(slog.LevelDebug)
+	defer SetLevel(slog.LevelInfo)
+
+	logger := &bufferLogger{}
+	handler := BodyCapture(WithBodyLogger(logger))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if !strings.Contains(logger.String(), `response body: "ok"`) {
+		t.Errorf("Expected bodies to be logged at debug level, got %q", logger.String())
+	}
+}
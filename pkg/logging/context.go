@@ -0,0 +1,160 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/Okja-Engineering/go-service-kit/pkg/tenant"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type contextKey string
+
+const loggerKey contextKey = "logger"
+
+// FromContext returns the logger stored by ContextLogger or NewContext, or slog.Default() when
+// there is none, so code can log with the request's correlation fields without being passed a logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// NewContext returns a copy of ctx carrying logger
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// With returns a copy of ctx whose logger carries more attributes, as for slog.Logger.With
+func With(ctx context.Context, args ...any) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(args...))
+}
+
+// ContextLoggerOption is a functional option for context logger configuration
+type ContextLoggerOption func(*ContextLoggerConfig)
+
+// ContextLoggerConfig holds configuration for the request-scoped logger
+type ContextLoggerConfig struct {
+	// Logger is the base logger; nil uses slog.Default() when each request starts
+	Logger *slog.Logger
+	// TenantFunc returns the caller's tenant; by default the tenant set by api.TenantMiddleware
+	TenantFunc func(r *http.Request) string
+	// UserFunc returns the caller's user ID; by default from the JWT claims, as auth.GetUserIDFromContext
+	UserFunc func(r *http.Request) string
+}
+
+// DefaultContextLoggerConfig provides sensible defaults
+func DefaultContextLoggerConfig() *ContextLoggerConfig {
+	return &ContextLoggerConfig{
+		TenantFunc: defaultTenant,
+		UserFunc:   defaultUser,
+	}
+}
+
+// WithBaseLogger sets the logger that request loggers are derived from
+func WithBaseLogger(logger *slog.Logger) ContextLoggerOption {
+	return func(config *ContextLoggerConfig) {
+		config.Logger = logger
+	}
+}
+
+// WithTenantFunc sets how the caller's tenant is found
+func WithTenantFunc(fn func(r *http.Request) string) ContextLoggerOption {
+	return func(config *ContextLoggerConfig) {
+		config.TenantFunc = fn
+	}
+}
+
+// WithUserFunc sets how the caller's user ID is found
+func WithUserFunc(fn func(r *http.Request) string) ContextLoggerOption {
+	return func(config *ContextLoggerConfig) {
+		config.UserFunc = fn
+	}
+}
+
+// NewContextLoggerConfig creates a new context logger config with options
+func NewContextLoggerConfig(options ...ContextLoggerOption) *ContextLoggerConfig {
+	config := DefaultContextLoggerConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// ContextLogger returns middleware storing a logger for FromContext with the request ID, method,
+// path, chi route pattern, tenant, and user. Mount it after chi's RequestID and authentication so
+// they are known; the route pattern is filled in once the router has matched.
+func ContextLogger(options ...ContextLoggerOption) func(next http.Handler) http.Handler {
+	config := NewContextLoggerConfig(options...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := config.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+
+			args := []any{slog.String("method", r.Method), slog.String("path", r.URL.Path)}
+			if requestID := middleware.GetReqID(r.Context()); requestID != "" {
+				args = append(args, slog.String("requestId", requestID))
+			}
+			if config.TenantFunc != nil {
+				if tenantID := config.TenantFunc(r); tenantID != "" {
+					args = append(args, slog.String("tenantId", tenantID))
+				}
+			}
+			if config.UserFunc != nil {
+				if userID := config.UserFunc(r); userID != "" {
+					args = append(args, slog.String("userId", userID))
+				}
+			}
+
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				logger = slog.New(routeHandler{Handler: logger.Handler(), rctx: rctx})
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), logger.With(args...))))
+		})
+	}
+}
+
+// routeHandler adds chi's route pattern to each record as it is written, since the router only
+// matches the route after middleware has run, and attributes given to With are resolved at once
+type routeHandler struct {
+	slog.Handler
+	rctx *chi.Context
+}
+
+// Handle implements slog.Handler
+func (h routeHandler) Handle(ctx context.Context, record slog.Record) error {
+	if pattern := h.rctx.RoutePattern(); pattern != "" {
+		record.AddAttrs(slog.String("route", pattern))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h routeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return routeHandler{Handler: h.Handler.WithAttrs(attrs), rctx: h.rctx}
+}
+
+// WithGroup implements slog.Handler
+func (h routeHandler) WithGroup(name string) slog.Handler {
+	return routeHandler{Handler: h.Handler.WithGroup(name), rctx: h.rctx}
+}
+
+// defaultTenant reads the tenant resolved by api.TenantMiddleware. Headers aren't read, since any
+// client can send them.
+func defaultTenant(r *http.Request) string {
+	return tenant.ID(r.Context())
+}
+
+// defaultUser reads the user ID from the JWT claims
+func defaultUser(r *http.Request) string {
+	userID, _ := auth.GetUserIDFromContext(r.Context())
+	return userID
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/Okja-Engineering/go-service-kit/pkg/tenant"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestFromContext(t *testing.T) {
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("Expected the default logger outside a request")
+	}
+
+	var buf bytes.Buffer
+	ctx := NewContext(context.Background(), slog.New(slog.NewJSONHandler(&buf, nil)))
+	FromContext(With(ctx, "orderId", "o-1")).Info("shipped")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil || entry["orderId"] != "o-1" {
+		t.Errorf("Expected the attribute added with With, got %s", buf.String())
+	}
+}
+
+func TestContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// As a validator and api.TenantMiddleware would
+			claims := jwt.MapClaims{"sub": "user-1", "tenant_id": "acme"}
+			ctx := tenant.WithID(context.WithValue(r.Context(), auth.JWTClaimsKey, claims), "acme")
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	router.Use(ContextLogger(WithBaseLogger(base)))
+	router.Get("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("loading order")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/42", nil))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON entry, got %q: %v", buf.String(), err)
+	}
+	expected := map[string]interface{}{
+		"msg":      "loading order",
+		"method":   "GET",
+		"path":     "/orders/42",
+		"route":    "/orders/{id}",
+		"tenantId": "acme",
+		"userId":   "user-1",
+	}
+	for field, want := range expected {
+		if entry[field] != want {
+			t.Errorf("Expected %s %v, got %v", field, want, entry[field])
+		}
+	}
+	if entry["requestId"] == nil {
+		t.Errorf("Expected a request ID, got %v", entry)
+	}
+}
+
+func TestContextLoggerFuncs(t *testing.T) {
+	var buf bytes.Buffer
+	handler := ContextLogger(
+		WithBaseLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+		WithTenantFunc(func(r *http.Request) string { return "globex" }),
+		WithUserFunc(nil),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("hello")
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON entry, got %q: %v", buf.String(), err)
+	}
+	if entry["tenantId"] != "globex" || entry["userId"] != nil {
+		t.Errorf("Expected the custom tenant and no user, got %v", entry)
+	}
+}
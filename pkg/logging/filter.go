@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RequestFilter decides from the whole request whether to leave it out of the log. It is checked
+// after the handler runs, so chi's route pattern is known.
+type RequestFilter interface {
+	ShouldFilterRequest(r *http.Request) bool
+}
+
+// FilterFunc adapts a function to a RequestFilter
+type FilterFunc func(r *http.Request) bool
+
+// ShouldFilterRequest implements RequestFilter
+func (f FilterFunc) ShouldFilterRequest(r *http.Request) bool {
+	return f(r)
+}
+
+// MethodFilter filters requests with any of the HTTP methods, such as OPTIONS
+func MethodFilter(methods ...string) FilterFunc {
+	return func(r *http.Request) bool {
+		return slices.ContainsFunc(methods, func(method string) bool { return strings.EqualFold(method, r.Method) })
+	}
+}
+
+// HeaderFilter filters requests carrying the header, or whose header matches pattern when it is
+// not nil, such as User-Agent matching ^kube-probe/
+func HeaderFilter(name string, pattern *regexp.Regexp) FilterFunc {
+	return func(r *http.Request) bool {
+		values := r.Header.Values(name)
+		if pattern == nil {
+			return len(values) > 0
+		}
+		return slices.ContainsFunc(values, pattern.MatchString)
+	}
+}
+
+// RouteFilter filters requests served by any of chi's route patterns, such as /users/{id},
+// whatever the IDs in the URL
+func RouteFilter(patterns ...string) FilterFunc {
+	return func(r *http.Request) bool {
+		rctx := chi.RouteContext(r.Context())
+		return rctx != nil && slices.Contains(patterns, rctx.RoutePattern())
+	}
+}
+
+// URLFilterFunc adapts a URLFilter, such as a RegexURLFilter, to combine it with other filters
+func URLFilterFunc(filter URLFilter) FilterFunc {
+	return func(r *http.Request) bool {
+		return filter.ShouldFilter(r.URL.String())
+	}
+}
+
+// Any filters requests that any of the filters filter
+func Any(filters ...RequestFilter) FilterFunc {
+	return func(r *http.Request) bool {
+		for _, filter := range filters {
+			if filter.ShouldFilterRequest(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// All filters requests that every one of the filters filters; with no filters, none are filtered
+func All(filters ...RequestFilter) FilterFunc {
+	return func(r *http.Request) bool {
+		for _, filter := range filters {
+			if !filter.ShouldFilterRequest(r) {
+				return false
+			}
+		}
+		return len(filters) > 0
+	}
+}
+
+// Not filters the requests that filter doesn't
+func Not(filter RequestFilter) FilterFunc {
+	return func(r *http.Request) bool {
+		return !filter.ShouldFilterRequest(r)
+	}
+}
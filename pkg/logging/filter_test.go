@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRequestFilters(t *testing.T) {
+	probe := HeaderFilter("User-Agent", regexp.MustCompile(`^kube-probe/`))
+	never := FilterFunc(func(r *http.Request) bool { return false })
+
+	tests := []struct {
+		name      string
+		filter    RequestFilter
+		method    string
+		userAgent string
+		expected  bool
+	}{
+		{"method matches", MethodFilter("options", "HEAD"), "OPTIONS", "", true},
+		{"method differs", MethodFilter("OPTIONS"), "GET", "", false},
+		{"header matches", probe, "GET", "kube-probe/1.29", true},
+		{"header differs", probe, "GET", "curl/8.5.0", false},
+		{"header present", HeaderFilter("User-Agent", nil), "GET", "curl/8.5.0", true},
+		{"header absent", HeaderFilter("X-Debug", nil), "GET", "", false},
+		{"URL filter", URLFilterFunc(&RegexURLFilter{pattern: regexp.MustCompile(`^/users`)}), "GET", "", true},
+		{"any matches", Any(never, MethodFilter("GET")), "GET", "", true},
+		{"any without matches", Any(never, probe), "GET", "", false},
+		{"all match", All(MethodFilter("GET"), probe), "GET", "kube-probe/1.29", true},
+		{"all but one match", All(MethodFilter("POST"), probe), "GET", "kube-probe/1.29", false},
+		{"all of nothing", All(), "GET", "", false},
+		{"not", Not(probe), "GET", "curl/8.5.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/users/42", nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			if got := tt.filter.ShouldFilterRequest(req); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRouteFilter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewRequestLogger(WithOutput(&buf), WithJSON(), WithRequestFilter(RouteFilter("/users/{id}")))
+
+	router := chi.NewRouter()
+	router.Use(logger.Middleware())
+	router.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	if buf.Len() != 0 {
+		t.Errorf("Expected the filtered route not to be logged, got %q", buf.String())
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/42", nil))
+	if buf.Len() == 0 {
+		t.Error("Expected other routes to be logged")
+	}
+}
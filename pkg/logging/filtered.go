@@ -3,11 +3,13 @@ package logging
 import (
 	"io"
 	"log"
+	"math/rand/v2"
 	"net/http"
 	"os"
 	"regexp"
 	"time"
 
+	"github.com/Okja-Engineering/go-service-kit/pkg/redact"
 	"github.com/go-chi/chi/middleware"
 )
 
@@ -40,8 +42,16 @@ type LoggingConfig struct {
 	Logger    Logger
 	Formatter middleware.LogFormatter
 	URLFilter URLFilter
-	NoColor   bool
-	Output    io.Writer
+	// RequestFilter leaves requests out of the log by method, header, route, or a combination
+	RequestFilter RequestFilter
+	NoColor       bool
+	Output        io.Writer
+	// Redactor removes credentials and personal data from logged URLs
+	Redactor *redact.Redactor
+	// SampleRate is the fraction of 2xx responses logged, from 0 to 1; errors are always logged
+	SampleRate float64
+	// SlowThreshold logs requests taking at least this long regardless of sampling; zero disables it
+	SlowThreshold time.Duration
 }
 
 // DefaultLoggingConfig provides sensible defaults
@@ -53,9 +63,11 @@ func DefaultLoggingConfig() *LoggingConfig {
 			Logger:  logger,
 			NoColor: false,
 		},
-		URLFilter: nil, // No filtering by default
-		NoColor:   false,
-		Output:    os.Stdout,
+		URLFilter:  nil, // No filtering by default
+		NoColor:    false,
+		Output:     os.Stdout,
+		Redactor:   redact.Default(),
+		SampleRate: 1,
 	}
 }
 
@@ -73,6 +85,13 @@ func WithFormatter(formatter middleware.LogFormatter) LoggingOption {
 	}
 }
 
+// WithJSON logs each request as a JSON object with a JSONLogFormatter writing to the configured Output
+func WithJSON() LoggingOption {
+	return func(config *LoggingConfig) {
+		config.Formatter = NewJSONLogFormatter(nil)
+	}
+}
+
 // WithURLFilter sets a custom URL filter
 func WithURLFilter(filter URLFilter) LoggingOption {
 	return func(config *LoggingConfig) {
@@ -87,6 +106,13 @@ func WithRegexFilter(pattern *regexp.Regexp) LoggingOption {
 	}
 }
 
+// WithRequestFilter sets a filter on whole requests, checked in addition to the URL filter
+func WithRequestFilter(filter RequestFilter) LoggingOption {
+	return func(config *LoggingConfig) {
+		config.RequestFilter = filter
+	}
+}
+
 // WithNoColor disables color output
 func WithNoColor(noColor bool) LoggingOption {
 	return func(config *LoggingConfig) {
@@ -101,12 +127,45 @@ func WithOutput(output io.Writer) LoggingOption {
 	}
 }
 
+// WithRedactor sets the redactor applied to logged URLs; nil logs them as received
+func WithRedactor(redactor *redact.Redactor) LoggingOption {
+	return func(config *LoggingConfig) {
+		config.Redactor = redactor
+	}
+}
+
+// WithSampleRate logs only this fraction of 2xx responses, from 0 to 1, to cut the volume of
+// high-traffic services; 4xx and 5xx responses are always logged
+func WithSampleRate(rate float64) LoggingOption {
+	return func(config *LoggingConfig) {
+		config.SampleRate = rate
+	}
+}
+
+// WithSlowThreshold always logs requests taking at least threshold, even when sampled out
+func WithSlowThreshold(threshold time.Duration) LoggingOption {
+	return func(config *LoggingConfig) {
+		config.SlowThreshold = threshold
+	}
+}
+
 // NewLoggingConfig creates a new logging config with options
 func NewLoggingConfig(options ...LoggingOption) *LoggingConfig {
 	config := DefaultLoggingConfig()
+	defaultFormatter := config.Formatter
 	for _, option := range options {
 		option(config)
 	}
+	// The default text formatter follows Output and NoColor, so logs can go to a file
+	if config.Formatter == defaultFormatter && (config.Output != os.Stdout || config.NoColor) {
+		config.Formatter = &middleware.DefaultLogFormatter{
+			Logger:  log.New(config.Output, "", log.LstdFlags),
+			NoColor: config.NoColor,
+		}
+	}
+	if formatter, ok := config.Formatter.(*JSONLogFormatter); ok && formatter.Output == nil {
+		formatter.Output = config.Output
+	}
 	return config
 }
 
@@ -131,12 +190,18 @@ func (rl *RequestLogger) Middleware() func(next http.Handler) http.Handler {
 				return
 			}
 
-			entry := rl.config.Formatter.NewLogEntry(r)
+			entry := rl.config.Formatter.NewLogEntry(rl.redactRequest(r))
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
 			t1 := time.Now()
 			defer func() {
-				entry.Write(ww.Status(), ww.BytesWritten(), ww.Header(), time.Since(t1), nil)
+				if rl.config.RequestFilter != nil && rl.config.RequestFilter.ShouldFilterRequest(r) {
+					return
+				}
+				elapsed := time.Since(t1)
+				if rl.sampled(ww.Status(), elapsed) {
+					entry.Write(ww.Status(), ww.BytesWritten(), ww.Header(), elapsed, nil)
+				}
 			}()
 
 			next.ServeHTTP(ww, middleware.WithLogEntry(r, entry))
@@ -146,6 +211,31 @@ func (rl *RequestLogger) Middleware() func(next http.Handler) http.Handler {
 	}
 }
 
+// sampled reports whether a response is logged: always for errors and slow requests, otherwise
+// at the sample rate
+func (rl *RequestLogger) sampled(status int, elapsed time.Duration) bool {
+	// A handler that writes nothing responds 200
+	if status != 0 && (status < 200 || status >= 300) {
+		return true
+	}
+	if rl.config.SlowThreshold > 0 && elapsed >= rl.config.SlowThreshold {
+		return true
+	}
+	return rl.config.SampleRate >= 1 || rand.Float64() < rl.config.SampleRate
+}
+
+// redactRequest returns a copy of r for the log entry, with sensitive query parameters redacted
+func (rl *RequestLogger) redactRequest(r *http.Request) *http.Request {
+	if rl.config.Redactor == nil || r.URL.RawQuery == "" {
+		return r
+	}
+
+	logged := r.WithContext(r.Context())
+	logged.URL = rl.config.Redactor.URL(r.URL)
+	logged.RequestURI = logged.URL.RequestURI()
+	return logged
+}
+
 // Legacy functions for backward compatibility
 func NewFilteredRequestLogger(filterOut *regexp.Regexp) func(next http.Handler) http.Handler {
 	logger := NewRequestLogger(WithRegexFilter(filterOut))
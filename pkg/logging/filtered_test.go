@@ -7,7 +7,9 @@ import (
 	"net/http/httptest"
 	"regexp"
 	"testing"
+	"time"
 
+	"github.com/Okja-Engineering/go-service-kit/pkg/redact"
 	"github.com/go-chi/chi/middleware"
 )
 
@@ -400,3 +402,72 @@ func TestFilteredRequestLoggerWithComplexRegex(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestLoggerRedactsURLs(t *testing.T) {
+	tests := []struct {
+		name     string
+		redactor *redact.Redactor
+		want     string
+	}{
+		{"default redactor", redact.Default(), "/reset?page=2&token=%5BREDACTED%5D"},
+		{"redaction disabled", nil, "/reset?token=abc&page=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			formatter := &middleware.DefaultLogFormatter{Logger: log.New(&buf, "", 0), NoColor: true}
+			logger := NewRequestLogger(WithFormatter(formatter), WithRedactor(tt.redactor))
+
+			var seen string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = r.URL.Query().Get("token")
+			})
+			logger.Middleware()(handler).ServeHTTP(httptest.NewRecorder(),
+				httptest.NewRequest("GET", "/reset?token=abc&page=2", nil))
+
+			if !bytes.Contains(buf.Bytes(), []byte(tt.want)) {
+				t.Errorf("Expected the log to contain %s, got %q", tt.want, buf.String())
+			}
+			if seen != "abc" {
+				t.Errorf("Expected the handler to see the original query, got %q", seen)
+			}
+		})
+	}
+}
+
+func TestRequestLoggerSampling(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []LoggingOption
+		status  int
+		logged  bool
+	}{
+		{"logs everything by default", nil, http.StatusOK, true},
+		{"samples out 2xx", []LoggingOption{WithSampleRate(0)}, http.StatusOK, false},
+		{"samples out implicit 200", []LoggingOption{WithSampleRate(0)}, 0, false},
+		{"keeps 4xx", []LoggingOption{WithSampleRate(0)}, http.StatusNotFound, true},
+		{"keeps 5xx", []LoggingOption{WithSampleRate(0)}, http.StatusBadGateway, true},
+		{"keeps slow requests", []LoggingOption{WithSampleRate(0), WithSlowThreshold(time.Nanosecond)}, http.StatusOK, true},
+		{"ignores fast requests", []LoggingOption{WithSampleRate(0), WithSlowThreshold(time.Hour)}, http.StatusOK, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			options := append([]LoggingOption{WithOutput(&buf), WithJSON()}, tt.options...)
+			logger := NewRequestLogger(options...)
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+			})
+			logger.Middleware()(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+			if logged := buf.Len() > 0; logged != tt.logged {
+				t.Errorf("Expected logged %v, got %q", tt.logged, buf.String())
+			}
+		})
+	}
+}
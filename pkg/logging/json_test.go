@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/tenant"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestJSONLogFormatter(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewRequestLogger(WithOutput(&out), WithJSON())
+
+	handler := middleware.RequestID(logger.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	})))
+
+	req := httptest.NewRequest("POST", "/orders?token=secret", nil)
+	req.RemoteAddr = "203.0.113.7:1234"
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("X-Tenant-ID", "spoofed") // only the tenant resolved by api.TenantMiddleware is logged
+	req = req.WithContext(tenant.WithID(req.Context(), "acme"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("Expected one JSON line, got %q: %v", out.String(), err)
+	}
+
+	expected := map[string]interface{}{
+		"method":     "POST",
+		"path":       "/orders",
+		"status":     float64(http.StatusCreated),
+		"bytes":      float64(5),
+		"tenant_id":  "acme",
+		"user_agent": "test-agent",
+		"client_ip":  "203.0.113.7",
+	}
+	for field, want := range expected {
+		if line[field] != want {
+			t.Errorf("Expected %s %v, got %v", field, want, line[field])
+		}
+	}
+	for _, field := range []string{"ts", "latency_ms", "request_id"} {
+		if _, ok := line[field]; !ok {
+			t.Errorf("Expected %s in %v", field, line)
+		}
+	}
+	if strings.Contains(out.String(), "secret") {
+		t.Errorf("Expected the query to be left out, got %s", out.String())
+	}
+}
+
+func TestJSONLogFormatterFuncs(t *testing.T) {
+	var out bytes.Buffer
+	formatter := NewJSONLogFormatter(&out)
+	formatter.ClientIPFunc = func(r *http.Request) string { return "198.51.100.1" }
+	formatter.TenantFunc = func(r *http.Request) string { return "globex" }
+	logger := NewRequestLogger(WithFormatter(formatter))
+
+	handler := logger.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	var line jsonLogLine
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", out.String(), err)
+	}
+	if line.ClientIP != "198.51.100.1" || line.TenantID != "globex" {
+		t.Errorf("Expected the custom client IP and tenant, got %+v", line)
+	}
+	if line.Status != http.StatusOK {
+		t.Errorf("Expected an implicit 200, got %d", line.Status)
+	}
+}
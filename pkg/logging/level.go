@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// level is the process-wide minimum log level, which can be changed while running
+var level = new(slog.LevelVar)
+
+// Level returns the process-wide log level. Pass it as slog.HandlerOptions.Level so handlers
+// follow changes made with SetLevel.
+func Level() *slog.LevelVar {
+	return level
+}
+
+// SetLevel changes the process-wide log level
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// Enabled reports whether messages at l are logged at the current level
+func Enabled(l slog.Level) bool {
+	return l >= level.Level()
+}
+
+// ParseLevel parses a level name: debug, info, warn, or error, in any case, optionally with an
+// offset such as "debug-2"
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: expected debug, info, warn, or error", s)
+	}
+	return l, nil
+}
@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{" warn ", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"debug-2", slog.LevelDebug - 2, false},
+		{"verbose", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLevel(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %t, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	defer SetLevel(slog.LevelInfo)
+
+	if !Enabled(slog.LevelInfo) || Enabled(slog.LevelDebug) {
+		t.Error("Expected info to be the default level")
+	}
+
+	SetLevel(slog.LevelWarn)
+	if Enabled(slog.LevelInfo) || !Enabled(slog.LevelError) || Level().Level() != slog.LevelWarn {
+		t.Errorf("Expected warn and above to be enabled, got level %s", Level().Level())
+	}
+}
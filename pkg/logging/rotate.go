@@ -0,0 +1,415 @@
+package logging
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
+)
+
+// rotatedTimeFormat stamps rotated files; it sorts in time order
+const rotatedTimeFormat = "20060102T150405.000"
+
+// RotatingFileOption is a functional option for rotating file configuration
+type RotatingFileOption func(*RotatingFileConfig)
+
+// RotatingFileConfig holds configuration for a RotatingFile
+type RotatingFileConfig struct {
+	// MaxSize rotates the file before a write would take it past this many bytes; zero disables it
+	MaxSize int64
+	// RotateEvery rotates the file at each multiple of this interval, such as daily; zero disables it
+	RotateEvery time.Duration
+	// MaxBackups is how many rotated files are kept; zero keeps them all
+	MaxBackups int
+	// MaxAge removes rotated files older than this; zero keeps them all
+	MaxAge time.Duration
+	// Compress gzips rotated files in the background
+	Compress bool
+	// ReopenSignals make WatchSignals reopen the file, after an external tool such as logrotate moved it
+	ReopenSignals []os.Signal
+	// FileMode is the permission of new files
+	FileMode os.FileMode
+	Logger   Logger
+	Clock    clock.Clock
+}
+
+// DefaultRotatingFileConfig provides sensible defaults
+func DefaultRotatingFileConfig() *RotatingFileConfig {
+	return &RotatingFileConfig{
+		MaxSize:       100 << 20,
+		MaxBackups:    7,
+		Compress:      true,
+		ReopenSignals: []os.Signal{syscall.SIGHUP},
+		FileMode:      0o640,
+		Logger:        log.Default(),
+		Clock:         clock.Real(),
+	}
+}
+
+// WithMaxSize sets the size in bytes a file may reach before it is rotated; zero disables it
+func WithMaxSize(size int64) RotatingFileOption {
+	return func(config *RotatingFileConfig) {
+		config.MaxSize = size
+	}
+}
+
+// WithRotateEvery rotates the file at each multiple of interval; zero disables it
+func WithRotateEvery(interval time.Duration) RotatingFileOption {
+	return func(config *RotatingFileConfig) {
+		config.RotateEvery = interval
+	}
+}
+
+// WithMaxBackups sets how many rotated files are kept; zero keeps them all
+func WithMaxBackups(backups int) RotatingFileOption {
+	return func(config *RotatingFileConfig) {
+		config.MaxBackups = backups
+	}
+}
+
+// WithMaxAge removes rotated files older than age; zero keeps them all
+func WithMaxAge(age time.Duration) RotatingFileOption {
+	return func(config *RotatingFileConfig) {
+		config.MaxAge = age
+	}
+}
+
+// WithCompress sets whether rotated files are gzipped
+func WithCompress(compress bool) RotatingFileOption {
+	return func(config *RotatingFileConfig) {
+		config.Compress = compress
+	}
+}
+
+// WithReopenSignals sets the signals that make WatchSignals reopen the file
+func WithReopenSignals(signals ...os.Signal) RotatingFileOption {
+	return func(config *RotatingFileConfig) {
+		config.ReopenSignals = signals
+	}
+}
+
+// WithFileMode sets the permission of new files
+func WithFileMode(mode os.FileMode) RotatingFileOption {
+	return func(config *RotatingFileConfig) {
+		config.FileMode = mode
+	}
+}
+
+// WithRotateLogger sets where failures to compress or remove rotated files are reported
+func WithRotateLogger(logger Logger) RotatingFileOption {
+	return func(config *RotatingFileConfig) {
+		config.Logger = logger
+	}
+}
+
+// WithRotateClock sets the clock that times rotations and stamps rotated files
+func WithRotateClock(c clock.Clock) RotatingFileOption {
+	return func(config *RotatingFileConfig) {
+		config.Clock = c
+	}
+}
+
+// NewRotatingFileConfig creates a new rotating file config with options
+func NewRotatingFileConfig(options ...RotatingFileOption) *RotatingFileConfig {
+	config := DefaultRotatingFileConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// RotatingFile is an io.Writer appending to a log file, for services not running under a log
+// collector. It renames the file to name-<time>.ext when it grows too large or a rotation interval
+// passes, then compresses and prunes the rotated files in the background. It is safe for concurrent use.
+type RotatingFile struct {
+	path   string
+	config *RotatingFileConfig
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	rotateAt time.Time
+
+	// mill compresses and prunes rotated files, one pass at a time
+	mill sync.Mutex
+	wg   sync.WaitGroup
+}
+
+// NewRotatingFile opens path for appending, creating it and its directory if needed
+func NewRotatingFile(path string, options ...RotatingFileOption) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, config: NewRotatingFileConfig(options...)}
+	if f.config.Clock == nil {
+		f.config.Clock = clock.Real()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write appends p to the file, rotating it first when it is due
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, fmt.Errorf("log file %s is closed", f.path)
+	}
+	if f.due(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file now
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return fmt.Errorf("log file %s is closed", f.path)
+	}
+	return f.rotate()
+}
+
+// Reopen closes and reopens the file at its path, so writes follow a file that was moved away
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return fmt.Errorf("log file %s is closed", f.path)
+	}
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	return f.open()
+}
+
+// WatchSignals reopens the file whenever a reopen signal (SIGHUP by default) arrives, until ctx is done
+func (f *RotatingFile) WatchSignals(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, f.config.ReopenSignals...)
+
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signals:
+				if err := f.Reopen(); err != nil {
+					f.config.Logger.Printf("### 📝 Log file: reopen on %s failed: %v", sig, err)
+				}
+			}
+		}
+	}()
+}
+
+// Close closes the file and waits for rotated files to be compressed
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	f.wg.Wait()
+	if err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	return nil
+}
+
+// open opens the file for appending; the caller holds mu
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, f.config.FileMode)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	if every := f.config.RotateEvery; every > 0 {
+		f.rotateAt = f.config.Clock.Now().Truncate(every).Add(every)
+	}
+	return nil
+}
+
+// due reports whether the file must be rotated before writing n more bytes; the caller holds mu
+func (f *RotatingFile) due(n int64) bool {
+	if f.config.MaxSize > 0 && f.size > 0 && f.size+n > f.config.MaxSize {
+		return true
+	}
+	return !f.rotateAt.IsZero() && !f.config.Clock.Now().Before(f.rotateAt)
+}
+
+// rotate renames the file aside and opens a new one; the caller holds mu
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	rotated := f.rotatedPath(f.config.Clock.Now())
+	if err := os.Rename(f.path, rotated); err != nil && !os.IsNotExist(err) {
+		// Keep writing to the same file rather than losing logs
+		_ = f.open()
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.millRotated()
+	}()
+	return nil
+}
+
+// rotatedPath names the file rotated at t, moving on a millisecond at a time past names already taken
+func (f *RotatingFile) rotatedPath(t time.Time) string {
+	ext := filepath.Ext(f.path)
+	for {
+		rotated := strings.TrimSuffix(f.path, ext) + "-" + t.UTC().Format(rotatedTimeFormat) + ext
+		if !fileExists(rotated) && !fileExists(rotated+".gz") {
+			return rotated
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// fileExists reports whether anything is at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// millRotated compresses rotated files and removes those past retention
+func (f *RotatingFile) millRotated() {
+	f.mill.Lock()
+	defer f.mill.Unlock()
+
+	backups, err := f.backups()
+	if err != nil {
+		f.config.Logger.Printf("### 📝 Log file: failed to list rotated files: %v", err)
+		return
+	}
+
+	now := f.config.Clock.Now()
+	for i, backup := range backups {
+		switch {
+		case f.expired(i, backup, now):
+			if err := os.Remove(backup.path); err != nil {
+				f.config.Logger.Printf("### 📝 Log file: failed to remove %s: %v", backup.path, err)
+			}
+		case f.config.Compress && !strings.HasSuffix(backup.path, ".gz"):
+			if err := compressFile(backup.path, f.config.FileMode); err != nil {
+				f.config.Logger.Printf("### 📝 Log file: failed to compress %s: %v", backup.path, err)
+			}
+		}
+	}
+}
+
+// expired reports whether a rotated file, the i-th newest, is past retention
+func (f *RotatingFile) expired(i int, backup rotatedFile, now time.Time) bool {
+	if f.config.MaxBackups > 0 && i >= f.config.MaxBackups {
+		return true
+	}
+	return f.config.MaxAge > 0 && now.Sub(backup.time) > f.config.MaxAge
+}
+
+// rotatedFile is a rotated log file and the time it was rotated
+type rotatedFile struct {
+	path string
+	time time.Time
+}
+
+// backups returns the rotated files, newest first
+func (f *RotatingFile) backups() ([]rotatedFile, error) {
+	ext := filepath.Ext(f.path)
+	prefix := filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []rotatedFile
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp, ok := strings.CutPrefix(strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext), prefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		rotatedAt, err := time.Parse(rotatedTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, rotatedFile{path: filepath.Join(filepath.Dir(f.path), name), time: rotatedAt})
+	}
+
+	slices.SortFunc(backups, func(a, b rotatedFile) int { return b.time.Compare(a.time) })
+	return backups, nil
+}
+
+// compressFile gzips path to path.gz and removes the original
+func compressFile(path string, mode os.FileMode) (err error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = out.Close()
+			_ = os.Remove(path + ".gz")
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
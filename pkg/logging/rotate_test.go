@@ -0,0 +1,201 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
+)
+
+// rotatedNames returns the names of the files in dir other than the active log
+func rotatedNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", dir, err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Name() != "app.log" {
+			names = append(names, entry.Name())
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestRotatingFileBySize(t *testing.T) {
+	dir := t.TempDir()
+	clk := clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	f, err := NewRotatingFile(filepath.Join(dir, "app.log"),
+		WithMaxSize(10), WithCompress(false), WithMaxBackups(2), WithRotateClock(clk))
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+		clk.Advance(time.Second)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	current, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+	if string(current) != "fourth\n" {
+		t.Errorf("Expected the latest line in the active file, got %q", current)
+	}
+	want := []string{"app-20240301T120002.000.log", "app-20240301T120003.000.log"}
+	if got := rotatedNames(t, dir); !slices.Equal(got, want) {
+		t.Errorf("Expected the two newest backups %v, got %v", want, got)
+	}
+	third, _ := os.ReadFile(filepath.Join(dir, want[1]))
+	if string(third) != "third\n" {
+		t.Errorf("Expected the newest backup to hold the third line, got %q", third)
+	}
+}
+
+func TestRotatingFileByTime(t *testing.T) {
+	dir := t.TempDir()
+	clk := clock.NewFake(time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC))
+	f, err := NewRotatingFile(filepath.Join(dir, "app.log"),
+		WithMaxSize(0), WithRotateEvery(24*time.Hour), WithRotateClock(clk))
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+
+	_, _ = f.Write([]byte("before midnight\n"))
+	clk.Advance(30 * time.Minute)
+	_, _ = f.Write([]byte("still before\n"))
+	clk.Advance(time.Hour)
+	_, _ = f.Write([]byte("after midnight\n"))
+	if err := f.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	names := rotatedNames(t, dir)
+	if len(names) != 1 || names[0] != "app-20240302T003000.000.log.gz" {
+		t.Fatalf("Expected one compressed backup, got %v", names)
+	}
+
+	file, err := os.Open(filepath.Join(dir, names[0]))
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Expected a gzip backup: %v", err)
+	}
+	content, _ := io.ReadAll(gz)
+	if string(content) != "before midnight\nstill before\n" {
+		t.Errorf("Expected the day's lines in the backup, got %q", content)
+	}
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	clk := clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	f, err := NewRotatingFile(filepath.Join(dir, "app.log"),
+		WithCompress(false), WithMaxBackups(0), WithMaxAge(time.Hour), WithRotateClock(clk))
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+
+	for i := range 3 {
+		if i > 0 {
+			clk.Advance(45 * time.Minute)
+		}
+		_, _ = f.Write([]byte("line\n"))
+		if err := f.Rotate(); err != nil {
+			t.Fatalf("Failed to rotate: %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	want := []string{"app-20240301T124500.000.log", "app-20240301T133000.000.log"}
+	if got := rotatedNames(t, dir); !slices.Equal(got, want) {
+		t.Errorf("Expected backups within the last hour %v, got %v", want, got)
+	}
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := NewRotatingFile(path)
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	_, _ = f.Write([]byte("old\n"))
+	// An external tool moves the file away, then asks for it to be reopened
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("Failed to move: %v", err)
+	}
+	if err := f.Reopen(); err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	_, _ = f.Write([]byte("new\n"))
+
+	current, _ := os.ReadFile(path)
+	moved, _ := os.ReadFile(path + ".1")
+	if string(current) != "new\n" || string(moved) != "old\n" {
+		t.Errorf("Expected writes to follow the reopened file, got %q and %q", current, moved)
+	}
+}
+
+func TestRotatingFileWatchSignals(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := NewRotatingFile(path, WithReopenSignals(syscall.SIGUSR1))
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	f.WatchSignals(t.Context())
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("Failed to move: %v", err)
+	}
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("Failed to signal: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !fileExists(path) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !fileExists(path) {
+		t.Fatal("Expected the file to be reopened on the signal")
+	}
+}
+
+func TestRequestLoggerFileOutput(t *testing.T) {
+	f, err := NewRotatingFile(filepath.Join(t.TempDir(), "access.log"))
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	config := NewLoggingConfig(WithOutput(f), WithNoColor(true))
+	entry := config.Formatter.NewLogEntry(httptest.NewRequest("GET", "/orders", nil))
+	entry.Write(200, 2, nil, time.Millisecond, nil)
+
+	content, _ := os.ReadFile(f.path)
+	if !strings.Contains(string(content), `"GET http://example.com/orders HTTP/1.1"`) {
+		t.Errorf("Expected the text log in the file, got %q", content)
+	}
+}
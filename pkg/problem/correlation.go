@@ -0,0 +1,139 @@
+package problem
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/redact"
+	"github.com/Okja-Engineering/go-service-kit/pkg/report"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// redacted replaces sensitive values in captured error contexts
+const redacted = redact.Mask
+
+// ErrorContext is what was known about a request when a problem was returned for it,
+// with credentials and other sensitive values redacted
+type ErrorContext struct {
+	RequestID string            `json:"requestId"`
+	Time      time.Time         `json:"time"`
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Query     string            `json:"query,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Status    int               `json:"status"`
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Detail    string            `json:"detail,omitempty"`
+}
+
+// ContextStore keeps error contexts so support staff can look them up by request ID
+type ContextStore interface {
+	Save(ec ErrorContext)
+	Get(requestID string) (ErrorContext, bool)
+}
+
+// MemoryContextStore keeps the most recent error contexts in memory
+type MemoryContextStore struct {
+	mu       sync.Mutex
+	capacity int
+	order    []string
+	entries  map[string]ErrorContext
+}
+
+// NewMemoryContextStore creates a store holding up to capacity contexts, evicting the oldest first
+func NewMemoryContextStore(capacity int) *MemoryContextStore {
+	return &MemoryContextStore{
+		capacity: max(capacity, 1),
+		entries:  make(map[string]ErrorContext),
+	}
+}
+
+// Save stores an error context, replacing any earlier one for the same request
+func (s *MemoryContextStore) Save(ec ErrorContext) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[ec.RequestID]; !exists {
+		if len(s.order) >= s.capacity {
+			delete(s.entries, s.order[0])
+			s.order = s.order[1:]
+		}
+		s.order = append(s.order, ec.RequestID)
+	}
+	s.entries[ec.RequestID] = ec
+}
+
+// Get returns the error context captured for a request ID
+func (s *MemoryContextStore) Get(requestID string) (ErrorContext, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ec, ok := s.entries[requestID]
+	return ec, ok
+}
+
+func defaultRequestID(r *http.Request) string {
+	return middleware.GetReqID(r.Context())
+}
+
+// correlate fills the instance from the template and captures the error context, when configured
+func (pm *ProblemManager) correlate(p *Problem, r *http.Request) {
+	if pm.config.RequestIDFunc == nil || (pm.config.InstanceTemplate == "" && pm.config.ContextStore == nil) {
+		return
+	}
+
+	requestID := pm.config.RequestIDFunc(r)
+	if requestID == "" {
+		return
+	}
+
+	if pm.config.InstanceTemplate != "" {
+		p.Instance = strings.ReplaceAll(pm.config.InstanceTemplate, "{requestID}", url.PathEscape(requestID))
+	}
+
+	if pm.config.ContextStore != nil && p.Status >= pm.config.ContextMinStatus {
+		pm.config.ContextStore.Save(captureContext(p, r, requestID))
+	}
+}
+
+// report sends the error behind a 5xx problem to the error reporter, with the request attached
+func (pm *ProblemManager) report(p *Problem, r *http.Request) {
+	if p.cause == nil || p.Status < http.StatusInternalServerError {
+		return
+	}
+
+	tags := map[string]string{"problem_type": p.Type}
+	if pm.config.RequestIDFunc != nil {
+		if requestID := pm.config.RequestIDFunc(r); requestID != "" {
+			tags["request_id"] = requestID
+		}
+	}
+	report.OrDefault(pm.config.Reporter).Report(r.Context(), report.Event{Err: p.cause, Request: r, Tags: tags})
+}
+
+// captureContext records the request and problem, redacting sensitive headers and query parameters
+func captureContext(p *Problem, r *http.Request, requestID string) ErrorContext {
+	ec := ErrorContext{
+		RequestID: requestID,
+		Time:      time.Now().UTC(),
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Headers:   make(map[string]string, len(r.Header)),
+		Status:    p.Status,
+		Type:      p.Type,
+		Title:     p.Title,
+		Detail:    p.Detail,
+	}
+
+	redactor := redact.Default()
+	for name, values := range r.Header {
+		ec.Headers[name] = redactor.Value(name, strings.Join(values, ", "))
+	}
+	ec.Query = redactor.Query(r.URL.Query()).Encode()
+
+	return ec
+}
@@ -0,0 +1,143 @@
+package problem
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/report"
+)
+
+func requestIDFunc(r *http.Request) string {
+	return r.Header.Get("X-Request-Id")
+}
+
+func TestRespondPopulatesInstanceAndCapturesContext(t *testing.T) {
+	store := NewMemoryContextStore(10)
+	pm := NewProblemManager(
+		WithLogger(&MockLogger{output: &bytes.Buffer{}}),
+		WithInstanceTemplate("/errors/{requestID}"),
+		WithRequestIDFunc(requestIDFunc),
+		WithContextStore(store, 500),
+	)
+
+	r := httptest.NewRequest(http.MethodPost, "/orders?page=2&access_token=abc", nil)
+	r.Header.Set("X-Request-Id", "req/1")
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+
+	p := pm.New("db-error", "Database Error", 500, "connection refused", r.URL.Path)
+	pm.Respond(p, w, r)
+
+	if p.Instance != "/errors/req%2F1" {
+		t.Errorf("Expected instance from template, got %s", p.Instance)
+	}
+	if !strings.Contains(w.Body.String(), `"instance":"/errors/req%2F1"`) {
+		t.Errorf("Expected instance in response, got %s", w.Body.String())
+	}
+
+	ec, ok := store.Get("req/1")
+	if !ok {
+		t.Fatal("Expected error context to be captured")
+	}
+	if ec.Path != "/orders" || ec.Method != http.MethodPost || ec.Detail != "connection refused" {
+		t.Errorf("Unexpected error context: %+v", ec)
+	}
+	if ec.Headers["Authorization"] != redacted || ec.Headers["Accept"] != "application/json" {
+		t.Errorf("Expected only sensitive headers to be redacted, got %v", ec.Headers)
+	}
+	if ec.Query != "access_token=%5BREDACTED%5D&page=2" {
+		t.Errorf("Expected sensitive query values to be redacted, got %s", ec.Query)
+	}
+}
+
+func TestRespondCorrelationSkips(t *testing.T) {
+	store := NewMemoryContextStore(10)
+	pm := NewProblemManager(
+		WithLogErrors(false),
+		WithInstanceTemplate("/errors/{requestID}"),
+		WithRequestIDFunc(requestIDFunc),
+		WithContextStore(store, 500),
+	)
+
+	// Without a request ID the instance is left alone
+	r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	p := pm.New("db-error", "Database Error", 500, "", "/orders")
+	pm.Respond(p, httptest.NewRecorder(), r)
+	if p.Instance != "/orders" {
+		t.Errorf("Expected instance to be unchanged, got %s", p.Instance)
+	}
+
+	// Client errors are below the capture threshold
+	r.Header.Set("X-Request-Id", "req-2")
+	pm.Respond(pm.New("not-found", "Not Found", 404, "", "/orders"), httptest.NewRecorder(), r)
+	if _, ok := store.Get("req-2"); ok {
+		t.Error("Expected 404 not to be captured")
+	}
+}
+
+func TestMemoryContextStoreEvictsOldest(t *testing.T) {
+	store := NewMemoryContextStore(2)
+	for _, id := range []string{"a", "b", "a", "c"} {
+		store.Save(ErrorContext{RequestID: id})
+	}
+
+	if _, ok := store.Get("a"); ok {
+		t.Error("Expected oldest entry to be evicted")
+	}
+	for _, id := range []string{"b", "c"} {
+		if _, ok := store.Get(id); !ok {
+			t.Errorf("Expected %s to be kept", id)
+		}
+	}
+}
+
+func TestSetDefaultManager(t *testing.T) {
+	store := NewMemoryContextStore(10)
+	SetDefaultManager(NewProblemManager(WithLogErrors(false), WithInstanceTemplate("/errors/{requestID}"),
+		WithRequestIDFunc(requestIDFunc), WithContextStore(store, 500)))
+	defer SetDefaultManager(nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	r.Header.Set("X-Request-Id", "req-3")
+	p := New("db-error", "Database Error", 500, "", "/orders")
+	p.Respond(httptest.NewRecorder(), r)
+
+	if p.Instance != "/errors/req-3" {
+		t.Errorf("Expected package functions to use the default manager, got instance %s", p.Instance)
+	}
+}
+
+func TestRespondReportsServerErrors(t *testing.T) {
+	var events []report.Event
+	pm := NewProblemManager(
+		WithLogger(&MockLogger{output: &bytes.Buffer{}}),
+		WithRequestIDFunc(requestIDFunc),
+		WithReporter(report.ReporterFunc(func(_ context.Context, event report.Event) {
+			events = append(events, event)
+		})),
+	)
+
+	r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	r.Header.Set("X-Request-Id", "req-1")
+	cause := errors.New("connection refused")
+
+	pm.Respond(pm.Wrap(500, "db-error", "/orders", cause), httptest.NewRecorder(), r)
+	pm.Respond(pm.Wrap(404, "not-found", "/orders", errors.New("no such order")), httptest.NewRecorder(), r)
+	pm.Respond(pm.New("db-error", "Database Error", 500, "no cause", "/orders"), httptest.NewRecorder(), r)
+	pm.Respond(NewMapper().Map(cause, "/orders"), httptest.NewRecorder(), r)
+
+	if len(events) != 2 {
+		t.Fatalf("Expected only wrapped and mapped 5xx errors to be reported, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.Err != cause || event.Request != r || event.Tags["request_id"] != "req-1" {
+			t.Errorf("Expected the cause and request in the event, got %+v", event)
+		}
+	}
+}
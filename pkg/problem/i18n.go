@@ -0,0 +1,126 @@
+package problem
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// Message is the translated title and detail for a problem type. An empty Detail keeps the
+// problem's own, which often carries specifics such as an ID.
+type Message struct {
+	Title  string
+	Detail string
+}
+
+// Catalog maps problem types to their messages in one language
+type Catalog map[string]Message
+
+// Localizer translates problem titles and details into the language a request asks for with
+// Accept-Language. The type is never translated, so clients can still match on it.
+type Localizer struct {
+	mu       sync.RWMutex
+	tags     []language.Tag
+	catalogs []Catalog
+	matcher  language.Matcher
+}
+
+// NewLocalizer creates a localizer for problems written in the fallback language, e.g. "en". Requests
+// that accept none of the registered languages get the problem as written.
+func NewLocalizer(fallback string) (*Localizer, error) {
+	tag, err := language.Parse(fallback)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fallback language %q: %w", fallback, err)
+	}
+
+	l := &Localizer{tags: []language.Tag{tag}, catalogs: []Catalog{nil}}
+	l.matcher = language.NewMatcher(l.tags)
+	return l, nil
+}
+
+// Register adds a catalog for a language, such as "de" or "pt-BR", merging it with any catalog already
+// registered for that language
+func (l *Localizer) Register(lang string, catalog Catalog) error {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return fmt.Errorf("invalid language %q: %w", lang, err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, existing := range l.tags {
+		if existing == tag {
+			if l.catalogs[i] == nil {
+				l.catalogs[i] = make(Catalog)
+			}
+			for problemType, message := range catalog {
+				l.catalogs[i][problemType] = message
+			}
+			return nil
+		}
+	}
+
+	l.tags = append(l.tags, tag)
+	l.catalogs = append(l.catalogs, catalog)
+	l.matcher = language.NewMatcher(l.tags)
+	return nil
+}
+
+// Language returns the registered language that best matches the request's Accept-Language
+// header, or the fallback
+func (l *Localizer) Language(r *http.Request) string {
+	tag, _ := l.match(r)
+	return tag.String()
+}
+
+// match finds the best registered language for a request, with its catalog
+func (l *Localizer) match(r *http.Request) (language.Tag, Catalog) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	accepted, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil || len(accepted) == 0 {
+		return l.tags[0], l.catalogs[0]
+	}
+	_, index, confidence := l.matcher.Match(accepted...)
+	if confidence == language.No {
+		return l.tags[0], l.catalogs[0]
+	}
+	return l.tags[index], l.catalogs[index]
+}
+
+// Localize returns a copy of p with its title and detail in the request's language, and that
+// language. Problems whose type has no message in the catalog are returned as they are.
+func (l *Localizer) Localize(p *Problem, r *http.Request) (*Problem, string) {
+	tag, catalog := l.match(r)
+
+	message, ok := catalog[p.Type]
+	if !ok {
+		return p, l.tags[0].String()
+	}
+
+	localized := *p
+	if message.Title != "" {
+		localized.Title = message.Title
+	}
+	if message.Detail != "" {
+		localized.Detail = message.Detail
+	}
+	return &localized, tag.String()
+}
+
+// localize translates a problem for the request when a localizer is configured, setting the
+// Content-Language of the response
+func (pm *ProblemManager) localize(p *Problem, resp http.ResponseWriter, req *http.Request) *Problem {
+	if pm.config.Localizer == nil {
+		return p
+	}
+
+	localized, lang := pm.config.Localizer.Localize(p, req)
+	resp.Header().Set("Content-Language", lang)
+	resp.Header().Add("Vary", "Accept-Language")
+	return localized
+}
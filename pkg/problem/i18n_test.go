@@ -0,0 +1,156 @@
+package problem
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestLocalizer(t *testing.T) *Localizer {
+	t.Helper()
+	l, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := l.Register("de", Catalog{
+		"not-found": {Title: "Nicht gefunden", Detail: "Die Ressource existiert nicht"},
+		"conflict":  {Title: "Konflikt"},
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := l.Register("fr", Catalog{"not-found": {Title: "Introuvable"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return l
+}
+
+func TestLocalizerLanguage(t *testing.T) {
+	l := newTestLocalizer(t)
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		expected       string
+	}{
+		{"no header", "", "en"},
+		{"exact match", "de", "de"},
+		{"regional variant", "de-AT", "de"},
+		{"quality order", "fr;q=0.5, de;q=0.9", "de"},
+		{"first supported", "es, fr", "fr"},
+		{"unsupported", "ja", "en"},
+		{"malformed", ";;;q=x", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if got := l.Language(r); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLocalizerLocalize(t *testing.T) {
+	l := newTestLocalizer(t)
+
+	tests := []struct {
+		name           string
+		problemType    string
+		expectedTitle  string
+		expectedDetail string
+		expectedLang   string
+	}{
+		{"title and detail", "not-found", "Nicht gefunden", "Die Ressource existiert nicht", "de"},
+		{"title only keeps detail", "conflict", "Konflikt", "order 42 already exists", "de"},
+		{"missing type", "db-error", "Original", "order 42 already exists", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Language", "de-DE,de;q=0.9,en;q=0.8")
+			p := &Problem{Type: tt.problemType, Title: "Original", Detail: "order 42 already exists", Status: 409}
+
+			localized, lang := l.Localize(p, r)
+			if localized.Title != tt.expectedTitle || localized.Detail != tt.expectedDetail {
+				t.Errorf("Expected %q/%q, got %q/%q", tt.expectedTitle, tt.expectedDetail, localized.Title, localized.Detail)
+			}
+			if localized.Type != tt.problemType {
+				t.Errorf("Expected type to stay %s, got %s", tt.problemType, localized.Type)
+			}
+			if lang != tt.expectedLang {
+				t.Errorf("Expected language %s, got %s", tt.expectedLang, lang)
+			}
+			if p.Title != "Original" {
+				t.Errorf("Expected original problem to be unchanged, got title %s", p.Title)
+			}
+		})
+	}
+}
+
+func TestLocalizerRegisterMerges(t *testing.T) {
+	l := newTestLocalizer(t)
+	if err := l.Register("de", Catalog{"forbidden": {Title: "Verboten"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := l.Register("not a language!", Catalog{}); err == nil {
+		t.Error("Expected error for invalid language")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "de")
+	for problemType, title := range map[string]string{"forbidden": "Verboten", "not-found": "Nicht gefunden"} {
+		localized, _ := l.Localize(&Problem{Type: problemType}, r)
+		if localized.Title != title {
+			t.Errorf("Expected %s for %s, got %s", title, problemType, localized.Title)
+		}
+	}
+}
+
+func TestNewLocalizerInvalidFallback(t *testing.T) {
+	if _, err := NewLocalizer("not a language!"); err == nil {
+		t.Error("Expected error for invalid fallback language")
+	}
+}
+
+func TestRespondLocalizes(t *testing.T) {
+	pm := NewProblemManager(
+		WithLogger(&MockLogger{output: &bytes.Buffer{}}),
+		WithLocalizer(newTestLocalizer(t)),
+	)
+
+	r := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	r.Header.Set("Accept-Language", "de")
+	w := httptest.NewRecorder()
+	pm.Respond(pm.New("not-found", "Not Found", 404, "order 42 not found", "/orders/42"), w, r)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if body["title"] != "Nicht gefunden" || body["detail"] != "Die Ressource existiert nicht" {
+		t.Errorf("Expected localized title and detail, got %v", body)
+	}
+	if body["type"] != "not-found" {
+		t.Errorf("Expected type to stay not-found, got %v", body["type"])
+	}
+	if got := w.Header().Get("Content-Language"); got != "de" {
+		t.Errorf("Expected Content-Language de, got %s", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Accept-Language" {
+		t.Errorf("Expected Vary Accept-Language, got %s", got)
+	}
+
+	r.Header.Set("Accept", "text/plain")
+	w = httptest.NewRecorder()
+	pm.Respond(pm.New("not-found", "Not Found", 404, "order 42 not found", "/orders/42"), w, r)
+	if !bytes.Contains(w.Body.Bytes(), []byte("Nicht gefunden")) {
+		t.Errorf("Expected localized text body, got %s", w.Body.String())
+	}
+}
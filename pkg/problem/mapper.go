@@ -0,0 +1,119 @@
+package problem
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// Problemer is implemented by errors that render themselves as a problem, such as validation errors
+type Problemer interface {
+	Problem(instance string) *Problem
+}
+
+// Template describes the problem an error maps to. When Detail is empty, client errors (4xx) use
+// the error message as detail; server errors never do, to avoid leaking internals.
+type Template struct {
+	Type   string
+	Title  string
+	Status int
+	Detail string
+}
+
+// mapping is a registered rule, consulted in registration order
+type mapping struct {
+	match    func(err error) bool
+	template Template
+}
+
+// Mapper converts errors to problems using registered rules
+type Mapper struct {
+	mu       sync.RWMutex
+	mappings []mapping
+	fallback Template
+}
+
+// NewMapper creates a mapper with no rules that maps every error to a 500
+func NewMapper() *Mapper {
+	return &Mapper{
+		fallback: Template{
+			Type:   "internal-error",
+			Title:  "Internal Server Error",
+			Status: http.StatusInternalServerError,
+			Detail: "An unexpected error occurred",
+		},
+	}
+}
+
+// DefaultMapper creates a mapper with rules for common standard library errors
+func DefaultMapper() *Mapper {
+	m := NewMapper()
+	m.Register(sql.ErrNoRows, Template{Type: "not-found", Title: "Not Found", Status: http.StatusNotFound,
+		Detail: "The requested resource was not found"})
+	m.Register(context.DeadlineExceeded, Template{Type: "timeout", Title: "Gateway Timeout",
+		Status: http.StatusGatewayTimeout, Detail: "The request took too long to complete"})
+	RegisterType[*http.MaxBytesError](m, Template{Type: "body-too-large", Title: "Request Body Too Large",
+		Status: http.StatusRequestEntityTooLarge})
+	return m
+}
+
+// Register maps errors matching target with errors.Is to a problem template
+func (m *Mapper) Register(target error, t Template) *Mapper {
+	return m.RegisterFunc(func(err error) bool { return errors.Is(err, target) }, t)
+}
+
+// RegisterFunc maps errors for which match returns true to a problem template
+func (m *Mapper) RegisterFunc(match func(err error) bool, t Template) *Mapper {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.mappings = append(m.mappings, mapping{match: match, template: t})
+	return m
+}
+
+// RegisterType maps errors of type T, found with errors.As, to a problem template
+func RegisterType[T error](m *Mapper, t Template) *Mapper {
+	return m.RegisterFunc(func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}, t)
+}
+
+// SetFallback sets the template used for errors that match no rule
+func (m *Mapper) SetFallback(t Template) *Mapper {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.fallback = t
+	return m
+}
+
+// Map converts an error to a problem. Errors that implement Problemer render themselves; otherwise
+// the first matching rule applies, falling back to a 500.
+func (m *Mapper) Map(err error, instance string) *Problem {
+	var pe Problemer
+	if errors.As(err, &pe) {
+		return pe.Problem(instance)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, mapping := range m.mappings {
+		if mapping.match(err) {
+			return mapping.template.problem(err, instance)
+		}
+	}
+
+	return m.fallback.problem(err, instance)
+}
+
+func (t Template) problem(err error, instance string) *Problem {
+	detail := t.Detail
+	if detail == "" && t.Status < http.StatusInternalServerError && err != nil {
+		detail = err.Error()
+	}
+	return &Problem{Type: t.Type, Title: t.Title, Status: t.Status, Detail: detail, Instance: instance, cause: err}
+}
@@ -0,0 +1,79 @@
+package problem
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+var errOutOfStock = errors.New("item is out of stock")
+
+type conflictError struct {
+	Resource string
+}
+
+func (e *conflictError) Error() string {
+	return e.Resource + " was modified concurrently"
+}
+
+type selfRenderingError struct{}
+
+func (e selfRenderingError) Error() string {
+	return "invalid"
+}
+
+func (e selfRenderingError) Problem(instance string) *Problem {
+	return New("invalid", "Invalid", http.StatusUnprocessableEntity, "", instance).WithField("name", "is required")
+}
+
+func TestMapper(t *testing.T) {
+	mapper := DefaultMapper().
+		Register(errOutOfStock, Template{Type: "out-of-stock", Title: "Out of Stock", Status: http.StatusConflict})
+	RegisterType[*conflictError](mapper, Template{Type: "conflict", Title: "Conflict", Status: http.StatusConflict})
+	mapper.RegisterFunc(func(err error) bool { return err.Error() == "upstream" },
+		Template{Type: "upstream", Title: "Bad Gateway", Status: http.StatusBadGateway})
+
+	tests := []struct {
+		name       string
+		err        error
+		wantType   string
+		wantStatus int
+		wantDetail string
+	}{
+		{"no rows", fmt.Errorf("loading order: %w", sql.ErrNoRows), "not-found", 404,
+			"The requested resource was not found"},
+		{"deadline", context.DeadlineExceeded, "timeout", 504, "The request took too long to complete"},
+		{"body too large", &http.MaxBytesError{Limit: 10}, "body-too-large", 413, "http: request body too large"},
+		{"sentinel uses error as detail", errOutOfStock, "out-of-stock", 409, "item is out of stock"},
+		{"type", fmt.Errorf("saving: %w", &conflictError{Resource: "order"}), "conflict", 409,
+			"saving: order was modified concurrently"},
+		{"func with server status hides detail", errors.New("upstream"), "upstream", 502, ""},
+		{"self rendering", fmt.Errorf("wrapped: %w", selfRenderingError{}), "invalid", 422, ""},
+		{"fallback", errors.New("disk on fire"), "internal-error", 500, "An unexpected error occurred"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := mapper.Map(tt.err, "/orders/1")
+
+			if p.Type != tt.wantType || p.Status != tt.wantStatus || p.Detail != tt.wantDetail {
+				t.Errorf("Map() = %s/%d/%q, want %s/%d/%q",
+					p.Type, p.Status, p.Detail, tt.wantType, tt.wantStatus, tt.wantDetail)
+			}
+			if p.Instance != "/orders/1" {
+				t.Errorf("Expected instance /orders/1, got %s", p.Instance)
+			}
+		})
+	}
+}
+
+func TestMapperFallback(t *testing.T) {
+	mapper := NewMapper().SetFallback(Template{Type: "unavailable", Title: "Unavailable", Status: 503})
+
+	if p := mapper.Map(sql.ErrNoRows, ""); p.Status != 503 {
+		t.Errorf("Expected empty mapper to use fallback, got %d", p.Status)
+	}
+}
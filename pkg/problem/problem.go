@@ -1,11 +1,19 @@
 package problem
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
+	"mime"
 	"net/http"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/report"
 )
 
 // Logger defines the interface for logging operations
@@ -29,14 +37,27 @@ type ProblemConfig struct {
 	Logger    Logger
 	LogPrefix string
 	LogErrors bool
+	// InstanceTemplate, if set, replaces the instance of responded problems, with {requestID} substituted
+	InstanceTemplate string
+	// RequestIDFunc extracts the request ID used for instances and captured error contexts
+	RequestIDFunc func(r *http.Request) string
+	// ContextStore, if set, captures a redacted context for responded problems with at least ContextMinStatus
+	ContextStore     ContextStore
+	ContextMinStatus int
+	// Reporter receives wrapped errors behind responded 5xx problems; nil uses report.Default()
+	Reporter report.ErrorReporter
+	// Localizer, if set, translates the title and detail of responded problems
+	Localizer *Localizer
 }
 
 // DefaultProblemConfig provides sensible defaults
 func DefaultProblemConfig() *ProblemConfig {
 	return &ProblemConfig{
-		Logger:    &DefaultLogger{},
-		LogPrefix: "### 💥 API",
-		LogErrors: true,
+		Logger:           &DefaultLogger{},
+		LogPrefix:        "### 💥 API",
+		LogErrors:        true,
+		RequestIDFunc:    defaultRequestID,
+		ContextMinStatus: http.StatusInternalServerError,
 	}
 }
 
@@ -61,6 +82,42 @@ func WithLogErrors(logErrors bool) ProblemOption {
 	}
 }
 
+// WithInstanceTemplate sets a URI template for problem instances, e.g. "/errors/{requestID}"
+func WithInstanceTemplate(template string) ProblemOption {
+	return func(config *ProblemConfig) {
+		config.InstanceTemplate = template
+	}
+}
+
+// WithRequestIDFunc sets how the request ID is read; by default from chi's RequestID middleware
+func WithRequestIDFunc(fn func(r *http.Request) string) ProblemOption {
+	return func(config *ProblemConfig) {
+		config.RequestIDFunc = fn
+	}
+}
+
+// WithContextStore captures a redacted error context for problems with at least minStatus
+func WithContextStore(store ContextStore, minStatus int) ProblemOption {
+	return func(config *ProblemConfig) {
+		config.ContextStore = store
+		config.ContextMinStatus = minStatus
+	}
+}
+
+// WithReporter sets the reporter that receives wrapped errors behind responded 5xx problems
+func WithReporter(reporter report.ErrorReporter) ProblemOption {
+	return func(config *ProblemConfig) {
+		config.Reporter = reporter
+	}
+}
+
+// WithLocalizer translates the title and detail of responded problems into the request's language
+func WithLocalizer(localizer *Localizer) ProblemOption {
+	return func(config *ProblemConfig) {
+		config.Localizer = localizer
+	}
+}
+
 // NewProblemConfig creates a new problem config with options
 func NewProblemConfig(options ...ProblemOption) *ProblemConfig {
 	config := DefaultProblemConfig()
@@ -82,16 +139,34 @@ func NewProblemManager(options ...ProblemOption) *ProblemManager {
 }
 
 type Problem struct {
-	Type     string `json:"type"`
-	Title    string `json:"title"`
-	Status   int    `json:"status,omitempty"`
-	Detail   string `json:"detail,omitempty"`
-	Instance string `json:"instance,omitempty"`
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status,omitempty"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	// Extensions are additional members serialized alongside the core fields, e.g. a trace ID
+	Extensions map[string]interface{} `json:"-"`
+	// cause is the error given to Wrap or Map, reported when a 5xx problem is responded
+	cause error
+}
+
+// coreMembers are the members defined by the Problem struct, which extensions cannot override
+var coreMembers = map[string]bool{
+	"type": true, "title": true, "status": true, "detail": true, "instance": true, "errors": true,
+}
+
+// FieldError describes why a single field or parameter failed validation
+type FieldError struct {
+	Field string `json:"field"`
+	// Code identifies the rule that failed, such as "required" or "max", for clients to match on
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
 }
 
 // New creates a new problem with the manager's configuration
 func (pm *ProblemManager) New(typeStr string, title string, status int, detail, instance string) *Problem {
-	return &Problem{typeStr, title, status, detail, instance}
+	return &Problem{Type: typeStr, Title: title, Status: status, Detail: detail, Instance: instance}
 }
 
 // Send sends the problem response with logging
@@ -104,11 +179,31 @@ func (pm *ProblemManager) Send(p *Problem, resp http.ResponseWriter) {
 	_ = json.NewEncoder(resp).Encode(p)
 }
 
+// Respond sends the problem as problem+json or plain text, whichever the request's Accept header prefers
+func (pm *ProblemManager) Respond(p *Problem, resp http.ResponseWriter, req *http.Request) {
+	pm.correlate(p, req)
+	pm.report(p, req)
+	p = pm.localize(p, resp, req)
+
+	if !prefersText(req.Header.Get("Accept")) {
+		pm.Send(p, resp)
+		return
+	}
+
+	if pm.config.LogErrors {
+		pm.config.Logger.Printf("%s %s", pm.config.LogPrefix, p.Error())
+	}
+	resp.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	resp.WriteHeader(p.Status)
+	_, _ = resp.Write([]byte(p.Text()))
+}
+
 // Wrap wraps an error into a problem response
 func (pm *ProblemManager) Wrap(status int, typeStr string, instance string, err error) *Problem {
 	var p *Problem
 	if err != nil {
 		p = pm.New(typeStr, MyCaller(), status, err.Error(), instance)
+		p.cause = err
 	} else {
 		p = pm.New(typeStr, MyCaller(), status, "Other error occurred", instance)
 	}
@@ -116,20 +211,37 @@ func (pm *ProblemManager) Wrap(status int, typeStr string, instance string, err
 	return p
 }
 
+var defaultManager atomic.Pointer[ProblemManager]
+
+// SetDefaultManager sets the manager used by the package-level functions and Problem methods
+func SetDefaultManager(pm *ProblemManager) {
+	defaultManager.Store(pm)
+}
+
+// DefaultManager returns the manager used by the package-level functions and Problem methods
+func DefaultManager() *ProblemManager {
+	if pm := defaultManager.Load(); pm != nil {
+		return pm
+	}
+	return NewProblemManager()
+}
+
 // Legacy functions for backward compatibility
 func New(typeStr string, title string, status int, detail, instance string) *Problem {
-	manager := NewProblemManager()
-	return manager.New(typeStr, title, status, detail, instance)
+	return DefaultManager().New(typeStr, title, status, detail, instance)
 }
 
 func (p *Problem) Send(resp http.ResponseWriter) {
-	manager := NewProblemManager()
-	manager.Send(p, resp)
+	DefaultManager().Send(p, resp)
+}
+
+// Respond sends the problem as problem+json or plain text, whichever the request's Accept header prefers
+func (p *Problem) Respond(resp http.ResponseWriter, req *http.Request) {
+	DefaultManager().Respond(p, resp, req)
 }
 
 func Wrap(status int, typeStr string, instance string, err error) *Problem {
-	manager := NewProblemManager()
-	return manager.Wrap(status, typeStr, instance, err)
+	return DefaultManager().Wrap(status, typeStr, instance, err)
 }
 
 func (p Problem) Error() string {
@@ -137,6 +249,163 @@ func (p Problem) Error() string {
 		p.Type, p.Title, p.Status, p.Detail, p.Instance)
 }
 
+// WithDetail sets the detail and returns the problem for chaining
+func (p *Problem) WithDetail(detail string) *Problem {
+	p.Detail = detail
+	return p
+}
+
+// WithInstance sets the instance and returns the problem for chaining
+func (p *Problem) WithInstance(instance string) *Problem {
+	p.Instance = instance
+	return p
+}
+
+// WithField adds a field validation error and returns the problem for chaining
+func (p *Problem) WithField(field, message string) *Problem {
+	p.Errors = append(p.Errors, FieldError{Field: field, Message: message})
+	return p
+}
+
+// WithExtension adds an extension member and returns the problem for chaining.
+// Names of core members (type, title, status, detail, instance, errors) are ignored when serialized.
+func (p *Problem) WithExtension(name string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions[name] = value
+	return p
+}
+
+// problemAlias has the fields of Problem without its methods, to avoid recursive marshaling
+type problemAlias Problem
+
+// MarshalJSON serializes the core members followed by any extension members in name order
+func (p Problem) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(problemAlias(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+
+	names := make([]string, 0, len(p.Extensions))
+	for name := range p.Extensions {
+		if !coreMembers[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for _, name := range names {
+		value, err := json.Marshal(p.Extensions[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal problem extension %s: %w", name, err)
+		}
+		key, _ := json.Marshal(name)
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON reads the core members and collects any others into Extensions
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var alias problemAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	for name, raw := range members {
+		if coreMembers[name] {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		if alias.Extensions == nil {
+			alias.Extensions = make(map[string]interface{})
+		}
+		alias.Extensions[name] = value
+	}
+
+	*p = Problem(alias)
+	return nil
+}
+
+// Text renders the problem as plain text for clients that don't accept JSON
+func (p Problem) Text() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%d %s\n", p.Status, p.Title)
+	if p.Detail != "" {
+		fmt.Fprintf(&sb, "%s\n", p.Detail)
+	}
+	for _, fe := range p.Errors {
+		fmt.Fprintf(&sb, "- %s: %s\n", fe.Field, fe.Message)
+	}
+	if p.Instance != "" {
+		fmt.Fprintf(&sb, "instance: %s\n", p.Instance)
+	}
+
+	names := make([]string, 0, len(p.Extensions))
+	for name := range p.Extensions {
+		if !coreMembers[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s: %v\n", name, p.Extensions[name])
+	}
+
+	return sb.String()
+}
+
+// prefersText reports whether an Accept header ranks plain text above JSON
+func prefersText(accept string) bool {
+	if accept == "" {
+		return false
+	}
+
+	jsonQ, textQ := -1.0, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(raw, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		switch mediaType {
+		case "application/problem+json", "application/json", "application/*", "*/*":
+			jsonQ = max(jsonQ, q)
+		case "text/plain", "text/*":
+			textQ = max(textQ, q)
+		}
+	}
+
+	return textQ > jsonQ
+}
+
 func getFrame(skipFrames int) runtime.Frame {
 	targetFrameIndex := skipFrames + 2
 
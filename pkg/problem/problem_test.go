@@ -2,7 +2,9 @@ package problem
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 )
@@ -312,3 +314,99 @@ func TestProblemJSONSerialization(t *testing.T) {
 		t.Error("Expected response to contain '400'")
 	}
 }
+
+func TestProblemFieldErrors(t *testing.T) {
+	problem := New("invalid-query", "Invalid query parameters", 400, "", "/items")
+	problem.Errors = []FieldError{{Field: "limit", Message: "must be at most 100"}}
+
+	w := httptest.NewRecorder()
+	problem.Send(w)
+
+	if !bytes.Contains(w.Body.Bytes(), []byte(`"errors":[{"field":"limit","message":"must be at most 100"}]`)) {
+		t.Errorf("Expected field errors in body, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	New("test-type", "Test Title", 400, "", "").Send(w)
+	if bytes.Contains(w.Body.Bytes(), []byte(`"errors"`)) {
+		t.Error("Expected errors to be omitted when empty")
+	}
+}
+
+func TestProblemExtensions(t *testing.T) {
+	p := New("out-of-credit", "You do not have enough credit", 403, "", "/accounts/12345").
+		WithField("amount", "must not exceed balance").
+		WithExtension("balance", 30).
+		WithExtension("traceId", "abc123").
+		WithExtension("status", 200)
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := `{"type":"out-of-credit","title":"You do not have enough credit","status":403,` +
+		`"instance":"/accounts/12345","errors":[{"field":"amount","message":"must not exceed balance"}],` +
+		`"balance":30,"traceId":"abc123"}`
+	if string(data) != want {
+		t.Errorf("Unexpected JSON:\n%s\nwant:\n%s", data, want)
+	}
+
+	var decoded Problem
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.Status != 403 || decoded.Extensions["traceId"] != "abc123" || decoded.Extensions["balance"] != float64(30) {
+		t.Errorf("Unexpected decoded problem: %+v", decoded)
+	}
+	if _, ok := decoded.Extensions["status"]; ok {
+		t.Error("Expected core members to be excluded from extensions")
+	}
+}
+
+func TestProblemRespondNegotiatesContentType(t *testing.T) {
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", "application/problem+json"},
+		{"application/json", "application/problem+json"},
+		{"*/*", "application/problem+json"},
+		{"text/plain", "text/plain; charset=utf-8"},
+		{"text/html, text/plain;q=0.9, */*;q=0.8", "text/plain; charset=utf-8"},
+		{"text/plain;q=0.5, application/json", "application/problem+json"},
+		{"text/plain;q=0", "application/problem+json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			New("not-found", "Not Found", 404, "No such order", "/orders/1").
+				WithField("id", "unknown").
+				Respond(w, r)
+
+			if w.Code != 404 {
+				t.Errorf("Expected status 404, got %d", w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Expected Content-Type %s, got %s", tt.contentType, got)
+			}
+		})
+	}
+}
+
+func TestProblemText(t *testing.T) {
+	p := New("not-found", "Not Found", 404, "No such order", "/orders/1").
+		WithField("id", "unknown").
+		WithExtension("traceId", "abc123")
+
+	want := "404 Not Found\nNo such order\n- id: unknown\ninstance: /orders/1\ntraceId: abc123\n"
+	if p.Text() != want {
+		t.Errorf("Unexpected text:\n%s\nwant:\n%s", p.Text(), want)
+	}
+}
@@ -0,0 +1,38 @@
+package problem
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// CodeInvalid is the code given to field errors that do not set one
+const CodeInvalid = "invalid"
+
+// FromValidationErrors creates a 422 problem listing each field that failed validation, with a code
+// clients can match on and a message for people. Fields without a code are given CodeInvalid.
+func (pm *ProblemManager) FromValidationErrors(errs []FieldError, instance string) *Problem {
+	p := pm.New("validation-failed", "Validation failed", http.StatusUnprocessableEntity,
+		fmt.Sprintf("%d field(s) are invalid", len(errs)), instance)
+
+	p.Errors = make([]FieldError, len(errs))
+	for i, fe := range errs {
+		if fe.Code == "" {
+			fe.Code = CodeInvalid
+		}
+		p.Errors[i] = fe
+	}
+	return p
+}
+
+// FromValidationErrors creates a 422 problem listing each field that failed validation, using the
+// default manager
+func FromValidationErrors(errs []FieldError, instance string) *Problem {
+	return DefaultManager().FromValidationErrors(errs, instance)
+}
+
+// WithFieldCode adds a field validation error with a machine-readable code, such as "required" or
+// "taken", and returns the problem for chaining
+func (p *Problem) WithFieldCode(field, code, message string) *Problem {
+	p.Errors = append(p.Errors, FieldError{Field: field, Code: code, Message: message})
+	return p
+}
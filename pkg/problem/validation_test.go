@@ -0,0 +1,67 @@
+package problem
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromValidationErrors(t *testing.T) {
+	pm := NewProblemManager(WithLogger(&MockLogger{output: &bytes.Buffer{}}))
+	errs := []FieldError{
+		{Field: "email", Code: "required", Message: "is required"},
+		{Field: "age", Message: "must be a number"},
+	}
+
+	p := pm.FromValidationErrors(errs, "/users")
+
+	if p.Type != "validation-failed" || p.Status != http.StatusUnprocessableEntity || p.Instance != "/users" {
+		t.Errorf("Unexpected problem: %+v", p)
+	}
+	if p.Detail != "2 field(s) are invalid" {
+		t.Errorf("Expected detail counting fields, got %s", p.Detail)
+	}
+	if p.Errors[0].Code != "required" || p.Errors[1].Code != CodeInvalid {
+		t.Errorf("Expected codes required and invalid, got %+v", p.Errors)
+	}
+	if errs[1].Code != "" {
+		t.Error("Expected the given errors to be unchanged")
+	}
+
+	w := httptest.NewRecorder()
+	p.Respond(w, httptest.NewRequest(http.MethodPost, "/users", nil))
+
+	var body struct {
+		Errors []map[string]string `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if w.Code != http.StatusUnprocessableEntity || len(body.Errors) != 2 {
+		t.Fatalf("Expected 422 with 2 errors, got %d %s", w.Code, w.Body.String())
+	}
+	expected := map[string]string{"field": "email", "code": "required", "message": "is required"}
+	for key, value := range expected {
+		if body.Errors[0][key] != value {
+			t.Errorf("Expected %s %q, got %q", key, value, body.Errors[0][key])
+		}
+	}
+}
+
+func TestWithFieldCode(t *testing.T) {
+	p := New("conflict", "Conflict", http.StatusConflict, "", "").
+		WithFieldCode("email", "taken", "is already registered").
+		WithField("name", "is too short")
+
+	if len(p.Errors) != 2 {
+		t.Fatalf("Expected 2 errors, got %d", len(p.Errors))
+	}
+	if p.Errors[0] != (FieldError{Field: "email", Code: "taken", Message: "is already registered"}) {
+		t.Errorf("Unexpected field error: %+v", p.Errors[0])
+	}
+	if p.Errors[1].Code != "" {
+		t.Errorf("Expected WithField to leave the code empty, got %s", p.Errors[1].Code)
+	}
+}
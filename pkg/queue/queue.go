@@ -0,0 +1,360 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+	"github.com/Okja-Engineering/go-service-kit/pkg/report"
+	"github.com/lib/pq"
+)
+
+// ErrJobNotFound is returned by Retry when no dead job has the given ID
+var ErrJobNotFound = errors.New("job not found")
+
+// Job statuses stored in the jobs table
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDead    = "dead"
+)
+
+// Logger is the logging interface used by the queue
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Config holds configuration for a queue and its workers
+type Config struct {
+	// Table is the jobs table, created by Migration
+	Table string
+	// RLSContextVarName is the setting the tenant policy reads, matching database.Config
+	RLSContextVarName string
+	// Concurrency is the number of jobs a worker processes at once
+	Concurrency int
+	// PollInterval is how often idle workers look for jobs
+	PollInterval time.Duration
+	// VisibilityTimeout is how long a claimed job is hidden from other workers; a job that isn't
+	// finished by then is assumed lost and runs again. Handlers get it as their deadline.
+	VisibilityTimeout time.Duration
+	// MaxAttempts is how often a job runs before it is dead-lettered
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry, doubling each attempt up to MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Logger      Logger
+	// Reporter receives handler panics and dead-lettered jobs; nil uses report.Default()
+	Reporter report.ErrorReporter
+}
+
+// DefaultConfig provides sensible defaults
+func DefaultConfig() *Config {
+	return &Config{
+		Table:             "queue_jobs",
+		RLSContextVarName: "app.current_tenant_id",
+		Concurrency:       4,
+		PollInterval:      time.Second,
+		VisibilityTimeout: 5 * time.Minute,
+		MaxAttempts:       5,
+		BaseBackoff:       time.Second,
+		MaxBackoff:        time.Hour,
+		Logger:            log.Default(),
+	}
+}
+
+// Option is a functional option for configuring a queue
+type Option func(*Config)
+
+// WithTable sets the jobs table name
+func WithTable(table string) Option {
+	return func(config *Config) {
+		config.Table = table
+	}
+}
+
+// WithRLSContextVarName sets the setting the tenant policy reads
+func WithRLSContextVarName(name string) Option {
+	return func(config *Config) {
+		config.RLSContextVarName = name
+	}
+}
+
+// WithConcurrency sets the number of jobs a worker processes at once
+func WithConcurrency(concurrency int) Option {
+	return func(config *Config) {
+		config.Concurrency = concurrency
+	}
+}
+
+// WithPollInterval sets how often idle workers look for jobs
+func WithPollInterval(interval time.Duration) Option {
+	return func(config *Config) {
+		config.PollInterval = interval
+	}
+}
+
+// WithVisibilityTimeout sets how long a claimed job is hidden from other workers
+func WithVisibilityTimeout(timeout time.Duration) Option {
+	return func(config *Config) {
+		config.VisibilityTimeout = timeout
+	}
+}
+
+// WithMaxAttempts sets how often a job runs before it is dead-lettered
+func WithMaxAttempts(attempts int) Option {
+	return func(config *Config) {
+		config.MaxAttempts = attempts
+	}
+}
+
+// WithBackoff sets the retry delay, which starts at base and doubles up to max
+func WithBackoff(base, max time.Duration) Option {
+	return func(config *Config) {
+		config.BaseBackoff = base
+		config.MaxBackoff = max
+	}
+}
+
+// WithLogger sets the logger for job failures
+func WithLogger(logger Logger) Option {
+	return func(config *Config) {
+		config.Logger = logger
+	}
+}
+
+// WithReporter sets the reporter that receives handler panics and dead-lettered jobs
+func WithReporter(reporter report.ErrorReporter) Option {
+	return func(config *Config) {
+		config.Reporter = reporter
+	}
+}
+
+// NewConfig creates a new queue config with options
+func NewConfig(options ...Option) *Config {
+	config := DefaultConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Job is a unit of work claimed by a worker
+type Job struct {
+	ID          int64           `json:"id"`
+	Queue       string          `json:"queue"`
+	TenantID    string          `json:"tenantId,omitempty"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempt     int             `json:"attempt"`
+	MaxAttempts int             `json:"maxAttempts"`
+	LastError   string          `json:"lastError,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+
+	// Tx is the transaction the handler runs in, scoped to the job's tenant. It commits together
+	// with the job's completion, so database work and acknowledgement succeed or fail as one.
+	Tx *sql.Tx `json:"-"`
+}
+
+// Decode unmarshals the job's payload into v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Queue enqueues jobs into a PostgreSQL table and runs workers that process them
+type Queue struct {
+	db     database.Database
+	config *Config
+}
+
+// New creates a queue backed by the given database
+func New(db database.Database, options ...Option) *Queue {
+	return &Queue{db: db, config: NewConfig(options...)}
+}
+
+// Migration returns the migration that creates the jobs table with its row level security policy,
+// for use with database.Migrate. Pick a version that fits the service's own migrations.
+func (q *Queue) Migration(version int64) database.Migration {
+	table := pq.QuoteIdentifier(q.config.Table)
+	setting := pq.QuoteLiteral(q.config.RLSContextVarName)
+	index := pq.QuoteIdentifier(q.config.Table + "_ready")
+	policy := pq.QuoteIdentifier(q.config.Table + "_tenant_isolation")
+
+	up := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id BIGSERIAL PRIMARY KEY,
+	queue TEXT NOT NULL,
+	tenant_id TEXT NOT NULL DEFAULT '',
+	payload JSONB NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	max_attempts INT NOT NULL,
+	run_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	locked_until TIMESTAMPTZ,
+	last_error TEXT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (queue, run_at) WHERE status <> 'dead';
+ALTER TABLE %[1]s ENABLE ROW LEVEL SECURITY;
+CREATE POLICY %[3]s ON %[1]s
+	USING (coalesce(current_setting(%[4]s, true), '') IN ('', tenant_id))
+	WITH CHECK (coalesce(current_setting(%[4]s, true), '') IN ('', tenant_id));`, table, index, policy, setting)
+
+	return database.Migration{
+		Version: version,
+		Name:    "create " + q.config.Table,
+		Up:      up,
+		Down:    "DROP TABLE IF EXISTS " + table,
+	}
+}
+
+// EnqueueConfig holds options for a single job
+type EnqueueConfig struct {
+	TenantID    string
+	RunAt       time.Time
+	MaxAttempts int
+}
+
+// EnqueueOption is a functional option for enqueuing a job
+type EnqueueOption func(*EnqueueConfig)
+
+// WithTenant scopes the job to a tenant; its handler runs with that tenant's RLS context
+func WithTenant(tenantID string) EnqueueOption {
+	return func(config *EnqueueConfig) {
+		config.TenantID = tenantID
+	}
+}
+
+// WithDelay runs the job no sooner than delay from now
+func WithDelay(delay time.Duration) EnqueueOption {
+	return func(config *EnqueueConfig) {
+		config.RunAt = time.Now().Add(delay)
+	}
+}
+
+// WithRunAt runs the job no sooner than t
+func WithRunAt(t time.Time) EnqueueOption {
+	return func(config *EnqueueConfig) {
+		config.RunAt = t
+	}
+}
+
+// WithJobMaxAttempts overrides the queue's attempt limit for one job
+func WithJobMaxAttempts(attempts int) EnqueueOption {
+	return func(config *EnqueueConfig) {
+		config.MaxAttempts = attempts
+	}
+}
+
+// Enqueue adds a job with a JSON payload to the named queue and returns its ID
+func (q *Queue) Enqueue(ctx context.Context, queue string, payload interface{},
+	options ...EnqueueOption) (int64, error) {
+	db := q.db.GetDB()
+	if db == nil {
+		return 0, fmt.Errorf("database connection is closed")
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin enqueue: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	id, err := q.EnqueueTx(ctx, tx, queue, payload, options...)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit enqueue: %w", err)
+	}
+	return id, nil
+}
+
+// EnqueueTx adds a job within an existing transaction, so it is only queued if the transaction commits
+func (q *Queue) EnqueueTx(ctx context.Context, tx *sql.Tx, queue string, payload interface{},
+	options ...EnqueueOption) (int64, error) {
+	config := &EnqueueConfig{MaxAttempts: q.config.MaxAttempts}
+	for _, option := range options {
+		option(config)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	if config.TenantID != "" {
+		if _, err := tx.ExecContext(ctx, `SELECT set_config($1, $2, true)`,
+			q.config.RLSContextVarName, config.TenantID); err != nil {
+			return 0, fmt.Errorf("failed to set tenant context: %w", err)
+		}
+	}
+
+	runAt := config.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+
+	var id int64
+	insert := fmt.Sprintf(`INSERT INTO %s (queue, tenant_id, payload, max_attempts, run_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`, pq.QuoteIdentifier(q.config.Table))
+	if err := tx.QueryRowContext(ctx, insert, queue, config.TenantID, data, config.MaxAttempts, runAt).
+		Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return id, nil
+}
+
+// DeadLetters returns jobs on the named queue that used up their attempts, newest first
+func (q *Queue) DeadLetters(ctx context.Context, queue string, limit int) ([]Job, error) {
+	db := q.db.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+
+	query := fmt.Sprintf(`SELECT id, queue, tenant_id, payload, attempts, max_attempts, coalesce(last_error, ''),
+		created_at FROM %s WHERE queue = $1 AND status = $2 ORDER BY updated_at DESC LIMIT $3`,
+		pq.QuoteIdentifier(q.config.Table))
+	rows, err := db.QueryContext(ctx, query, queue, StatusDead, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var jobs []Job
+	for rows.Next() {
+		var j Job
+		if err := rows.Scan(&j.ID, &j.Queue, &j.TenantID, &j.Payload, &j.Attempt, &j.MaxAttempts,
+			&j.LastError, &j.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read dead letter: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Retry requeues a dead-lettered job with a fresh set of attempts
+func (q *Queue) Retry(ctx context.Context, id int64) error {
+	db := q.db.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection is closed")
+	}
+
+	update := fmt.Sprintf(`UPDATE %s SET status = $1, attempts = 0, run_at = now(), locked_until = NULL,
+		updated_at = now() WHERE id = $2 AND status = $3`, pq.QuoteIdentifier(q.config.Table))
+	result, err := db.ExecContext(ctx, update, StatusPending, id, StatusDead)
+	if err != nil {
+		return fmt.Errorf("failed to retry job %d: %w", id, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("dead job %d: %w", id, ErrJobNotFound)
+	}
+
+	return nil
+}
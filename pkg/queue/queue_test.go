@@ -0,0 +1,126 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+)
+
+// newTestQueue creates a queue whose database was never connected
+func newTestQueue(options ...Option) *Queue {
+	return New(database.NewPostgreSQL(database.NewConfig()), options...)
+}
+
+func TestNewConfigDefaults(t *testing.T) {
+	config := NewConfig()
+
+	if config.Table != "queue_jobs" {
+		t.Errorf("Expected table queue_jobs, got %s", config.Table)
+	}
+	if config.RLSContextVarName != "app.current_tenant_id" {
+		t.Errorf("Expected the database package's RLS setting, got %s", config.RLSContextVarName)
+	}
+	if config.Concurrency != 4 || config.MaxAttempts != 5 {
+		t.Errorf("Expected concurrency 4 and 5 attempts, got %d and %d", config.Concurrency, config.MaxAttempts)
+	}
+	if config.VisibilityTimeout != 5*time.Minute {
+		t.Errorf("Expected 5m visibility timeout, got %s", config.VisibilityTimeout)
+	}
+}
+
+func TestNewConfigOptions(t *testing.T) {
+	config := NewConfig(
+		WithTable("tasks"),
+		WithRLSContextVarName("app.tenant"),
+		WithConcurrency(8),
+		WithPollInterval(time.Minute),
+		WithVisibilityTimeout(time.Second),
+		WithMaxAttempts(2),
+		WithBackoff(time.Millisecond, time.Second),
+	)
+
+	if config.Table != "tasks" || config.RLSContextVarName != "app.tenant" {
+		t.Errorf("Expected table and RLS setting to be overridden, got %s and %s", config.Table,
+			config.RLSContextVarName)
+	}
+	if config.Concurrency != 8 || config.PollInterval != time.Minute || config.VisibilityTimeout != time.Second {
+		t.Errorf("Expected worker settings to be overridden, got %+v", config)
+	}
+	if config.MaxAttempts != 2 || config.BaseBackoff != time.Millisecond || config.MaxBackoff != time.Second {
+		t.Errorf("Expected retry settings to be overridden, got %+v", config)
+	}
+}
+
+func TestMigration(t *testing.T) {
+	q := newTestQueue(WithTable("tasks"), WithRLSContextVarName("app.tenant"))
+	migration := q.Migration(42)
+
+	if migration.Version != 42 {
+		t.Errorf("Expected version 42, got %d", migration.Version)
+	}
+	for _, want := range []string{
+		`CREATE TABLE IF NOT EXISTS "tasks"`,
+		"tenant_id TEXT NOT NULL",
+		"locked_until TIMESTAMPTZ",
+		`ALTER TABLE "tasks" ENABLE ROW LEVEL SECURITY`,
+		`CREATE POLICY "tasks_tenant_isolation"`,
+		"current_setting('app.tenant', true)",
+	} {
+		if !strings.Contains(migration.Up, want) {
+			t.Errorf("Expected migration to contain %q, got:\n%s", want, migration.Up)
+		}
+	}
+	if migration.Down != `DROP TABLE IF EXISTS "tasks"` {
+		t.Errorf("Unexpected down migration: %s", migration.Down)
+	}
+}
+
+func TestQueueNotConnected(t *testing.T) {
+	q := newTestQueue()
+	ctx := context.Background()
+
+	if _, err := q.Enqueue(ctx, "emails", map[string]string{"to": "a@example.com"}); err == nil {
+		t.Error("Expected Enqueue to fail without a connection")
+	}
+	if _, err := q.DeadLetters(ctx, "emails", 10); err == nil {
+		t.Error("Expected DeadLetters to fail without a connection")
+	}
+	if err := q.Retry(ctx, 1); err == nil || errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Expected a connection error from Retry, got %v", err)
+	}
+}
+
+func TestEnqueueOptions(t *testing.T) {
+	runAt := time.Now().Add(time.Hour)
+	config := &EnqueueConfig{}
+	for _, option := range []EnqueueOption{WithTenant("acme"), WithRunAt(runAt), WithJobMaxAttempts(9)} {
+		option(config)
+	}
+
+	if config.TenantID != "acme" || !config.RunAt.Equal(runAt) || config.MaxAttempts != 9 {
+		t.Errorf("Expected options to apply, got %+v", config)
+	}
+
+	WithDelay(time.Minute)(config)
+	if until := time.Until(config.RunAt); until < 59*time.Second || until > time.Minute {
+		t.Errorf("Expected run time a minute from now, got %s", until)
+	}
+}
+
+func TestJobDecode(t *testing.T) {
+	job := &Job{Payload: []byte(`{"to":"a@example.com"}`)}
+
+	var payload struct {
+		To string `json:"to"`
+	}
+	if err := job.Decode(&payload); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if payload.To != "a@example.com" {
+		t.Errorf("Expected decoded payload, got %+v", payload)
+	}
+}
@@ -0,0 +1,249 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/internal/background"
+	"github.com/Okja-Engineering/go-service-kit/internal/poller"
+	"github.com/Okja-Engineering/go-service-kit/internal/retry"
+	"github.com/Okja-Engineering/go-service-kit/pkg/report"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// queueJobsTotal counts processed jobs by outcome: success, retry, dead, or panic
+	queueJobsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "queue_jobs_processed_total",
+		Help: "Total number of queued jobs processed by outcome",
+	}, []string{"queue", "result"})
+
+	// queueJobDuration observes how long handlers take
+	queueJobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "queue_job_duration_seconds",
+		Help:    "Duration of queued job handlers",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 10),
+	}, []string{"queue"})
+)
+
+// errLeaseLost is returned when a job's visibility timeout ran out and another worker claimed it
+var errLeaseLost = errors.New("job lease lost")
+
+// Handler processes a job. Returning an error retries the job with backoff until its attempts are
+// used up, after which it is dead-lettered. ctx is cancelled when the visibility timeout runs out.
+type Handler func(ctx context.Context, job *Job) error
+
+// Worker claims jobs from one queue and runs them with a pool of goroutines
+type Worker struct {
+	queue   *Queue
+	name    string
+	handler Handler
+	pool    poller.Pool
+}
+
+// Worker creates a worker that processes jobs on the named queue with handler
+func (q *Queue) Worker(name string, handler Handler) *Worker {
+	return &Worker{queue: q, name: name, handler: handler}
+}
+
+// Start runs the worker pool until ctx is done or Stop is called
+func (w *Worker) Start(ctx context.Context) {
+	concurrency := max(w.queue.config.Concurrency, 1)
+	started := w.pool.Start(ctx, concurrency, poller.Loop{
+		Next:     w.ProcessNext,
+		Interval: w.queue.config.PollInterval,
+		OnError: func(err error) {
+			w.queue.config.Logger.Printf("### 📬 Queue: %s: %v", w.name, err)
+		},
+	})
+	if started {
+		w.queue.config.Logger.Printf("### 📬 Queue: %s worker started with concurrency %d", w.name, concurrency)
+	}
+}
+
+// Stop stops claiming new jobs and waits for running ones to finish, or for ctx to be done. Running
+// handlers aren't cancelled, so jobs in flight complete instead of using up an attempt.
+func (w *Worker) Stop(ctx context.Context) error {
+	running, err := w.pool.Stop(ctx)
+	if err != nil {
+		return fmt.Errorf("queue %s jobs still running at shutdown: %w", w.name, err)
+	}
+	if running {
+		w.queue.config.Logger.Printf("### 📬 Queue: %s worker stopped", w.name)
+	}
+	return nil
+}
+
+// ProcessNext claims and runs a single job, reporting whether there was one. Workers call it in a
+// loop; it is exported for tests and for draining a queue synchronously.
+func (w *Worker) ProcessNext(ctx context.Context) (bool, error) {
+	job, err := w.claim(ctx)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	// The handler runs to completion even if the worker is stopped meanwhile
+	runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), w.queue.config.VisibilityTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err = w.run(runCtx, job)
+	queueJobDuration.WithLabelValues(w.name).Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		queueJobsTotal.WithLabelValues(w.name, "success").Inc()
+		return true, nil
+	}
+	if errors.Is(err, errLeaseLost) {
+		return true, fmt.Errorf("job %d: %w", job.ID, err)
+	}
+
+	return true, w.fail(context.WithoutCancel(ctx), job, err)
+}
+
+// claim takes the next ready job, or one whose visibility timeout expired, hiding it from other workers
+func (w *Worker) claim(ctx context.Context) (*Job, error) {
+	db := w.queue.db.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+
+	table := pq.QuoteIdentifier(w.queue.config.Table)
+	query := fmt.Sprintf(`UPDATE %[1]s SET status = $1, attempts = attempts + 1,
+		locked_until = now() + $2 * interval '1 millisecond', updated_at = now()
+		WHERE id = (
+			SELECT id FROM %[1]s WHERE queue = $3
+				AND ((status = $4 AND run_at <= now()) OR (status = $1 AND locked_until < now()))
+			ORDER BY run_at, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, queue, tenant_id, payload, attempts, max_attempts, coalesce(last_error, ''), created_at`,
+		table)
+
+	job := &Job{}
+	err := db.QueryRowContext(ctx, query, StatusRunning, w.queue.config.VisibilityTimeout.Milliseconds(),
+		w.name, StatusPending).Scan(&job.ID, &job.Queue, &job.TenantID, &job.Payload, &job.Attempt,
+		&job.MaxAttempts, &job.LastError, &job.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	return job, nil
+}
+
+// run calls the handler in a transaction scoped to the job's tenant, deleting the job in the same
+// transaction when it succeeds
+func (w *Worker) run(ctx context.Context, job *Job) error {
+	db := w.queue.db.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection is closed")
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin job transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if job.TenantID != "" {
+		if _, err := tx.ExecContext(ctx, `SELECT set_config($1, $2, true)`,
+			w.queue.config.RLSContextVarName, job.TenantID); err != nil {
+			return fmt.Errorf("failed to set tenant context: %w", err)
+		}
+	}
+
+	job.Tx = tx
+	if err := w.call(ctx, job); err != nil {
+		return err
+	}
+
+	// The attempt count acts as a lease token, so a worker whose job was reclaimed can't complete it
+	remove := fmt.Sprintf(`DELETE FROM %s WHERE id = $1 AND attempts = $2`,
+		pq.QuoteIdentifier(w.queue.config.Table))
+	result, err := tx.ExecContext(ctx, remove, job.ID, job.Attempt)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return errLeaseLost
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit job: %w", err)
+	}
+	return nil
+}
+
+// call runs the handler, converting a panic into an error
+func (w *Worker) call(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			stack := debug.Stack()
+			w.queue.config.Logger.Printf("### 💥 Queue: %s job %d panicked: %v\n%s", w.name, job.ID, rec, stack)
+			err = background.PanicError("job handler", rec)
+			w.report(ctx, job, err, stack)
+		}
+	}()
+
+	return w.handler(ctx, job)
+}
+
+// fail schedules a retry with backoff, or dead-letters the job once its attempts are used up
+func (w *Worker) fail(ctx context.Context, job *Job, cause error) error {
+	status := StatusPending
+	delay := retry.Backoff(w.queue.config.BaseBackoff, w.queue.config.MaxBackoff, job.Attempt)
+	result := "retry"
+	if job.Attempt >= job.MaxAttempts {
+		status = StatusDead
+		delay = 0
+		result = "dead"
+	}
+	if errors.Is(cause, background.ErrPanic) {
+		result = "panic"
+	}
+	queueJobsTotal.WithLabelValues(w.name, result).Inc()
+
+	db := w.queue.db.GetDB()
+	if db == nil {
+		return fmt.Errorf("job %d failed (%v) and could not be rescheduled: database connection is closed",
+			job.ID, cause)
+	}
+
+	update := fmt.Sprintf(`UPDATE %s SET status = $1, run_at = now() + $2 * interval '1 millisecond',
+		locked_until = NULL, last_error = $3, updated_at = now() WHERE id = $4 AND attempts = $5`,
+		pq.QuoteIdentifier(w.queue.config.Table))
+	if _, err := db.ExecContext(ctx, update, status, delay.Milliseconds(),
+		retry.ErrorMessage(cause), job.ID, job.Attempt); err != nil {
+		return fmt.Errorf("job %d failed (%v) and could not be rescheduled: %w", job.ID, cause, err)
+	}
+
+	if status == StatusDead {
+		err := fmt.Errorf("job %d dead-lettered after %d attempts: %w", job.ID, job.Attempt, cause)
+		// Panics were reported with their stack by call
+		if !errors.Is(cause, background.ErrPanic) {
+			w.report(ctx, job, err, nil)
+		}
+		return err
+	}
+	return fmt.Errorf("job %d attempt %d failed, retrying in %s: %w", job.ID, job.Attempt, delay, cause)
+}
+
+// report sends a job's failure to the error reporter, tagged with the queue, job, and tenant
+func (w *Worker) report(ctx context.Context, job *Job, err error, stack []byte) {
+	tags := map[string]string{"queue": w.name, "job_id": strconv.FormatInt(job.ID, 10)}
+	if job.TenantID != "" {
+		tags["tenant_id"] = job.TenantID
+	}
+	report.OrDefault(w.queue.config.Reporter).Report(ctx, report.Event{Err: err, Stack: stack, Tags: tags})
+}
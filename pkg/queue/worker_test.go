@@ -0,0 +1,100 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/internal/background"
+	"github.com/Okja-Engineering/go-service-kit/pkg/report"
+)
+
+type syncLogger struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *syncLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(&l.buf, format+"\n", v...)
+}
+
+func (l *syncLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+func TestWorkerProcessNextNotConnected(t *testing.T) {
+	w := newTestQueue().Worker("emails", func(ctx context.Context, job *Job) error { return nil })
+
+	processed, err := w.ProcessNext(context.Background())
+	if processed || err == nil {
+		t.Errorf("Expected no job and a connection error, got %v and %v", processed, err)
+	}
+}
+
+func TestWorkerStartStop(t *testing.T) {
+	logger := &syncLogger{}
+	q := newTestQueue(WithLogger(logger), WithConcurrency(2), WithPollInterval(5*time.Millisecond))
+	w := q.Worker("emails", func(ctx context.Context, job *Job) error { return nil })
+
+	if err := w.Stop(context.Background()); err != nil {
+		t.Errorf("Expected Stop before Start to be a no-op, got %v", err)
+	}
+
+	w.Start(context.Background())
+	w.Start(context.Background())
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := w.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	output := logger.String()
+	if !strings.Contains(output, "emails worker started with concurrency 2") {
+		t.Errorf("Expected start to be logged, got %q", output)
+	}
+	if !strings.Contains(output, "database connection is closed") {
+		t.Errorf("Expected claim failures to be logged, got %q", output)
+	}
+	if !strings.Contains(output, "emails worker stopped") {
+		t.Errorf("Expected stop to be logged, got %q", output)
+	}
+}
+
+func TestWorkerCallRecoversPanic(t *testing.T) {
+	var events []report.Event
+	q := newTestQueue(WithLogger(&syncLogger{}), WithReporter(report.ReporterFunc(
+		func(_ context.Context, event report.Event) { events = append(events, event) })))
+	w := q.Worker("emails", func(ctx context.Context, job *Job) error { panic("bad payload") })
+
+	err := w.call(context.Background(), &Job{ID: 7, TenantID: "acme"})
+	if !errors.Is(err, background.ErrPanic) || !strings.Contains(err.Error(), "bad payload") {
+		t.Errorf("Expected panic to become an error, got %v", err)
+	}
+	if len(events) != 1 || events[0].Err != err || len(events[0].Stack) == 0 {
+		t.Fatalf("Expected the panic to be reported with its stack, got %+v", events)
+	}
+	tags := events[0].Tags
+	if tags["queue"] != "emails" || tags["job_id"] != "7" || tags["tenant_id"] != "acme" {
+		t.Errorf("Expected the queue, job, and tenant in the tags, got %v", tags)
+	}
+}
+
+func TestWorkerFailNotConnected(t *testing.T) {
+	w := newTestQueue().Worker("emails", nil)
+
+	err := w.fail(context.Background(), &Job{ID: 3, Attempt: 1, MaxAttempts: 5}, errors.New("smtp down"))
+	if err == nil || !strings.Contains(err.Error(), "smtp down") {
+		t.Errorf("Expected the handler error to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryCounter is a counter and when it expires
+type memoryCounter struct {
+	value   int64
+	expires time.Time
+}
+
+// MemoryStore keeps counters in process memory. Counters are not shared between instances and are
+// lost on restart, so it suits tests and single-instance services.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]memoryCounter
+	now      func() time.Time
+}
+
+// NewMemoryStore creates an in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]memoryCounter), now: time.Now}
+}
+
+// Increment adds amount to the counter under key, dropping expired counters as it goes
+func (s *MemoryStore) Increment(_ context.Context, key string, amount int64, expires time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	counter, ok := s.counters[key]
+	if !ok || now.After(counter.expires) {
+		s.dropExpired(now)
+		counter = memoryCounter{}
+	}
+
+	counter.value += amount
+	counter.expires = expires
+	s.counters[key] = counter
+	return counter.value, nil
+}
+
+// Get returns the counter under key
+func (s *MemoryStore) Get(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counter, ok := s.counters[key]
+	if !ok || s.now().After(counter.expires) {
+		return 0, nil
+	}
+	return counter.value, nil
+}
+
+// dropExpired removes expired counters; the caller holds the lock
+func (s *MemoryStore) dropExpired(now time.Time) {
+	for key, counter := range s.counters {
+		if now.After(counter.expires) {
+			delete(s.counters, key)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	later := time.Now().Add(time.Hour)
+
+	for _, amount := range []int64{3, 4} {
+		if _, err := store.Increment(ctx, "a", amount, later); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if used, _ := store.Get(ctx, "a"); used != 7 {
+		t.Errorf("Expected 7, got %d", used)
+	}
+	if used, _ := store.Get(ctx, "missing"); used != 0 {
+		t.Errorf("Expected 0 for a missing counter, got %d", used)
+	}
+
+	past := time.Now().Add(-time.Second)
+	_, _ = store.Increment(ctx, "expired", 5, past)
+	if used, _ := store.Get(ctx, "expired"); used != 0 {
+		t.Errorf("Expected an expired counter to read as 0, got %d", used)
+	}
+	if used, _ := store.Increment(ctx, "expired", 2, later); used != 2 {
+		t.Errorf("Expected an expired counter to start again, got %d", used)
+	}
+}
@@ -0,0 +1,50 @@
+package quota
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// Middleware counts each request against the subject's requests quota. Once the quota is used up,
+// requests are refused with 429 and Retry-After until the window resets. Responses carry
+// X-Quota-Limit, X-Quota-Remaining, and X-Quota-Reset headers. Requests without a subject are not
+// counted, and a store failure lets the request through.
+func (m *Meter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject := m.config.SubjectFunc(r)
+		if subject == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		usage, err := m.Record(r.Context(), subject, MetricRequests, 1)
+		if err != nil {
+			log.Printf("### 📊 Quota: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if usage.Limit >= 0 {
+			w.Header().Set("X-Quota-Limit", strconv.FormatInt(usage.Limit, 10))
+			w.Header().Set("X-Quota-Remaining", strconv.FormatInt(usage.Remaining, 10))
+			w.Header().Set("X-Quota-Reset", usage.ResetsAt.Format(time.RFC3339))
+		}
+
+		if usage.Exceeded() {
+			retryAfter := math.Ceil(usage.ResetsAt.Sub(m.now()).Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(max(int(retryAfter), 1)))
+			problem.New("quota-exceeded", "Quota Exceeded", http.StatusTooManyRequests,
+				fmt.Sprintf("The %s quota of %d requests is used up until %s", usage.Period, usage.Limit,
+					usage.ResetsAt.Format(time.RFC3339)), r.URL.Path).Respond(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,69 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// failingStore fails every operation
+type failingStore struct{}
+
+func (failingStore) Increment(context.Context, string, int64, time.Time) (int64, error) {
+	return 0, errors.New("store unavailable")
+}
+
+func (failingStore) Get(context.Context, string) (int64, error) {
+	return 0, errors.New("store unavailable")
+}
+
+func TestMiddleware(t *testing.T) {
+	now := time.Date(2024, 5, 10, 23, 59, 30, 0, time.UTC)
+	m := newTestMeter(now, WithQuota(MetricRequests, 2, Daily),
+		WithSubjectFunc(func(r *http.Request) string { return r.Header.Get("X-Tenant-ID") }))
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(tenantID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/orders", nil)
+		req.Header.Set("X-Tenant-ID", tenantID)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("acme")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Quota-Remaining") != "1" {
+		t.Errorf("Unexpected first response %d %v", rec.Code, rec.Header())
+	}
+	if rec.Header().Get("X-Quota-Reset") != "2024-05-11T00:00:00Z" {
+		t.Errorf("Expected the window end as the reset, got %s", rec.Header().Get("X-Quota-Reset"))
+	}
+
+	send("acme")
+	rec = send("acme")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "30" {
+		t.Errorf("Expected 429 with Retry-After 30, got %d %v", rec.Code, rec.Header())
+	}
+	if rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("Expected a problem response, got %s", rec.Header().Get("Content-Type"))
+	}
+
+	if rec := send(""); rec.Code != http.StatusOK || rec.Header().Get("X-Quota-Limit") != "" {
+		t.Errorf("Expected requests without a subject to pass uncounted, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestMiddlewareStoreFailure(t *testing.T) {
+	m := New(WithStore(failingStore{}), WithQuota(MetricRequests, 1, Daily),
+		WithSubjectFunc(func(*http.Request) string { return "acme" }))
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/orders", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected requests to pass when the store fails, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package quota
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+	"github.com/lib/pq"
+)
+
+// PostgresStore keeps counters in a PostgreSQL table
+type PostgresStore struct {
+	db    database.Database
+	table string
+}
+
+// NewPostgresStore creates a store using table, "quota_usage" when empty
+func NewPostgresStore(db database.Database, table string) *PostgresStore {
+	if table == "" {
+		table = "quota_usage"
+	}
+	return &PostgresStore{db: db, table: table}
+}
+
+// Migration returns the migration that creates the usage table, for use with database.Migrate. Pick a
+// version that fits the service's own migrations.
+func (s *PostgresStore) Migration(version int64) database.Migration {
+	table := pq.QuoteIdentifier(s.table)
+	index := pq.QuoteIdentifier(s.table + "_expires_at")
+
+	up := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	key TEXT PRIMARY KEY,
+	used BIGINT NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (expires_at);`, table, index)
+
+	return database.Migration{
+		Version: version,
+		Name:    "create " + s.table,
+		Up:      up,
+		Down:    "DROP TABLE IF EXISTS " + table,
+	}
+}
+
+// Increment adds amount to the counter under key in a single upsert, so concurrent increments from
+// several instances are never lost. An expired counter starts again from amount.
+func (s *PostgresStore) Increment(ctx context.Context, key string, amount int64, expires time.Time) (int64, error) {
+	db := s.db.GetDB()
+	if db == nil {
+		return 0, fmt.Errorf("database connection is closed")
+	}
+
+	table := pq.QuoteIdentifier(s.table)
+	query := "INSERT INTO " + table + ` AS u (key, used, expires_at) VALUES ($1, $2, $3)
+ON CONFLICT (key) DO UPDATE SET
+	used = CASE WHEN u.expires_at <= now() THEN EXCLUDED.used ELSE u.used + EXCLUDED.used END,
+	expires_at = EXCLUDED.expires_at
+RETURNING used`
+
+	var used int64
+	err := db.QueryRowContext(ctx, query, key, amount, expires).Scan(&used)
+	return used, err
+}
+
+// Get returns the counter under key, zero when there is none or it has expired
+func (s *PostgresStore) Get(ctx context.Context, key string) (int64, error) {
+	db := s.db.GetDB()
+	if db == nil {
+		return 0, fmt.Errorf("database connection is closed")
+	}
+
+	var used int64
+	query := "SELECT used FROM " + pq.QuoteIdentifier(s.table) + " WHERE key = $1 AND expires_at > now()"
+	err := db.QueryRowContext(ctx, query, key).Scan(&used)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return used, err
+}
+
+// DeleteExpired removes expired counters, returning how many were deleted. Run it periodically, for
+// example as a scheduled job, to keep the table small.
+func (s *PostgresStore) DeleteExpired(ctx context.Context) (int64, error) {
+	db := s.db.GetDB()
+	if db == nil {
+		return 0, fmt.Errorf("database connection is closed")
+	}
+
+	result, err := db.ExecContext(ctx, "DELETE FROM "+pq.QuoteIdentifier(s.table)+" WHERE expires_at <= now()")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired usage: %w", err)
+	}
+	return result.RowsAffected()
+}
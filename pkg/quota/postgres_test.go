@@ -0,0 +1,43 @@
+package quota
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+)
+
+func TestPostgresStoreMigration(t *testing.T) {
+	db := database.NewPostgreSQL(database.NewConfig())
+
+	if got := NewPostgresStore(db, "").table; got != "quota_usage" {
+		t.Errorf("Expected default table quota_usage, got %s", got)
+	}
+
+	migration := NewPostgresStore(db, "api_usage").Migration(12)
+	if migration.Version != 12 {
+		t.Errorf("Expected version 12, got %d", migration.Version)
+	}
+	for _, want := range []string{`CREATE TABLE IF NOT EXISTS "api_usage"`, "used BIGINT NOT NULL"} {
+		if !strings.Contains(migration.Up, want) {
+			t.Errorf("Expected migration to contain %q, got:\n%s", want, migration.Up)
+		}
+	}
+}
+
+func TestPostgresStoreNotConnected(t *testing.T) {
+	store := NewPostgresStore(database.NewPostgreSQL(database.NewConfig()), "")
+	ctx := context.Background()
+
+	if _, err := store.Increment(ctx, "a", 1, time.Now().Add(time.Hour)); err == nil {
+		t.Error("Expected Increment to fail without a connection")
+	}
+	if _, err := store.Get(ctx, "a"); err == nil {
+		t.Error("Expected Get to fail without a connection")
+	}
+	if _, err := store.DeleteExpired(ctx); err == nil {
+		t.Error("Expected DeleteExpired to fail without a connection")
+	}
+}
@@ -0,0 +1,262 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/api"
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+)
+
+// ErrQuotaExceeded is returned by Consume when usage would go over the quota
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Common metrics
+const (
+	MetricRequests     = "requests"
+	MetricRowsWritten  = "rows_written"
+	MetricStorageBytes = "storage_bytes"
+)
+
+// Period is the window a quota applies to; usage starts again at zero in each window
+type Period string
+
+const (
+	// Daily windows start at midnight UTC
+	Daily Period = "daily"
+	// Monthly windows start at midnight UTC on the first of the month
+	Monthly Period = "monthly"
+)
+
+// Window returns the start and end of the window containing t
+func (p Period) Window(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	switch p {
+	case Monthly:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	default:
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1)
+	}
+}
+
+// Quota limits the usage of a metric per period
+type Quota struct {
+	Metric string `json:"metric"`
+	Limit  int64  `json:"limit"`
+	Period Period `json:"period"`
+}
+
+// Usage is a subject's usage of a metric in the current window. Limit and Remaining are -1 for
+// metrics without a quota.
+type Usage struct {
+	Subject   string    `json:"subject"`
+	Metric    string    `json:"metric"`
+	Used      int64     `json:"used"`
+	Limit     int64     `json:"limit"`
+	Remaining int64     `json:"remaining"`
+	Period    Period    `json:"period"`
+	ResetsAt  time.Time `json:"resetsAt"`
+}
+
+// Exceeded reports whether usage is over the limit
+func (u Usage) Exceeded() bool {
+	return u.Limit >= 0 && u.Used > u.Limit
+}
+
+// setUsed sets the usage and what remains of the limit
+func (u *Usage) setUsed(used int64) {
+	u.Used = used
+	u.Remaining = -1
+	if u.Limit >= 0 {
+		u.Remaining = max(u.Limit-used, 0)
+	}
+}
+
+// Store keeps usage counters shared by every instance of a service
+type Store interface {
+	// Increment adds amount to the counter under key, which may expire at expires, and returns the new total
+	Increment(ctx context.Context, key string, amount int64, expires time.Time) (int64, error)
+	// Get returns the counter under key, zero if there is none
+	Get(ctx context.Context, key string) (int64, error)
+}
+
+// QuotaFunc returns the quota of a subject for a metric, e.g. from the subject's plan; ok is false
+// to fall back to the default quota of the metric
+type QuotaFunc func(ctx context.Context, subject, metric string) (quota Quota, ok bool)
+
+// Config holds configuration for usage metering
+type Config struct {
+	// Store keeps the counters; the default is in memory, which is only suitable for one instance
+	Store Store
+	// Quotas are the default quota of each metric
+	Quotas map[string]Quota
+	// QuotaFunc overrides Quotas, e.g. to look up the subject's plan
+	QuotaFunc QuotaFunc
+	// SubjectFunc identifies who a request's usage is counted against; by default the tenant from
+	// api.TenantMiddleware, then the verified user ID
+	SubjectFunc func(r *http.Request) string
+	// Prefix namespaces counter keys, e.g. with the service name
+	Prefix string
+}
+
+// DefaultConfig provides sensible defaults
+func DefaultConfig() *Config {
+	return &Config{
+		Store:       NewMemoryStore(),
+		Quotas:      make(map[string]Quota),
+		SubjectFunc: defaultSubject,
+		Prefix:      "quota",
+	}
+}
+
+// Option is a functional option for configuring usage metering
+type Option func(*Config)
+
+// WithStore sets where counters are kept
+func WithStore(store Store) Option {
+	return func(config *Config) {
+		config.Store = store
+	}
+}
+
+// WithQuota sets the default quota of a metric
+func WithQuota(metric string, limit int64, period Period) Option {
+	return func(config *Config) {
+		config.Quotas[metric] = Quota{Metric: metric, Limit: limit, Period: period}
+	}
+}
+
+// WithQuotaFunc sets how a subject's quotas are found, overriding the defaults
+func WithQuotaFunc(fn QuotaFunc) Option {
+	return func(config *Config) {
+		config.QuotaFunc = fn
+	}
+}
+
+// WithSubjectFunc sets who a request's usage is counted against
+func WithSubjectFunc(fn func(r *http.Request) string) Option {
+	return func(config *Config) {
+		config.SubjectFunc = fn
+	}
+}
+
+// WithPrefix sets the namespace of counter keys
+func WithPrefix(prefix string) Option {
+	return func(config *Config) {
+		config.Prefix = prefix
+	}
+}
+
+// NewConfig creates a new metering config with options
+func NewConfig(options ...Option) *Config {
+	config := DefaultConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Meter counts usage per subject against quotas
+type Meter struct {
+	config *Config
+	now    func() time.Time
+}
+
+// New creates a usage meter
+func New(options ...Option) *Meter {
+	config := NewConfig(options...)
+	log.Printf("### 📊 Quota: metering %d metric(s)", len(config.Quotas))
+	return &Meter{config: config, now: time.Now}
+}
+
+// Record adds amount to a subject's usage of a metric, whether or not it goes over the quota, and
+// returns the usage. Use it for usage that has already happened, such as rows written.
+func (m *Meter) Record(ctx context.Context, subject, metric string, amount int64) (Usage, error) {
+	quota, limited := m.quota(ctx, subject, metric)
+	usage := m.usage(subject, quota, limited)
+
+	key, expires := m.key(subject, quota)
+	used, err := m.config.Store.Increment(ctx, key, amount, expires)
+	if err != nil {
+		return usage, fmt.Errorf("failed to record %s usage for %s: %w", metric, subject, err)
+	}
+
+	usage.setUsed(used)
+	return usage, nil
+}
+
+// Consume records amount like Record and returns ErrQuotaExceeded when it takes usage over the quota
+func (m *Meter) Consume(ctx context.Context, subject, metric string, amount int64) (Usage, error) {
+	usage, err := m.Record(ctx, subject, metric, amount)
+	if err != nil {
+		return usage, err
+	}
+	if usage.Exceeded() {
+		return usage, fmt.Errorf("%s quota of %d per %s for %s: %w", metric, usage.Limit, usage.Period,
+			subject, ErrQuotaExceeded)
+	}
+	return usage, nil
+}
+
+// Usage returns a subject's usage of a metric in the current window, including what remains
+func (m *Meter) Usage(ctx context.Context, subject, metric string) (Usage, error) {
+	quota, limited := m.quota(ctx, subject, metric)
+	usage := m.usage(subject, quota, limited)
+
+	key, _ := m.key(subject, quota)
+	used, err := m.config.Store.Get(ctx, key)
+	if err != nil {
+		return usage, fmt.Errorf("failed to read %s usage for %s: %w", metric, subject, err)
+	}
+
+	usage.setUsed(used)
+	return usage, nil
+}
+
+// quota returns the subject's quota of a metric; unlimited metrics are counted daily
+func (m *Meter) quota(ctx context.Context, subject, metric string) (Quota, bool) {
+	if m.config.QuotaFunc != nil {
+		if quota, ok := m.config.QuotaFunc(ctx, subject, metric); ok {
+			quota.Metric = metric
+			return quota, true
+		}
+	}
+	if quota, ok := m.config.Quotas[metric]; ok {
+		return quota, true
+	}
+	return Quota{Metric: metric, Period: Daily}, false
+}
+
+// usage starts a Usage for the current window of a quota
+func (m *Meter) usage(subject string, quota Quota, limited bool) Usage {
+	_, end := quota.Period.Window(m.now())
+	usage := Usage{Subject: subject, Metric: quota.Metric, Limit: quota.Limit, Period: quota.Period, ResetsAt: end}
+	if !limited {
+		usage.Limit = -1
+	}
+	return usage
+}
+
+// key returns the counter key of the current window and when it can expire
+func (m *Meter) key(subject string, quota Quota) (string, time.Time) {
+	start, end := quota.Period.Window(m.now())
+	window := start.Format("20060102")
+	key := fmt.Sprintf("%s:%s:%s:%s:%s", m.config.Prefix, subject, quota.Metric, quota.Period, window)
+	// Keep counters a day past their window so usage can still be read just after a reset
+	return key, end.Add(24 * time.Hour)
+}
+
+// defaultSubject counts usage against the tenant, then the verified user
+func defaultSubject(r *http.Request) string {
+	if tenantID, ok := api.TenantFromContext(r.Context()); ok && tenantID != "" {
+		return tenantID
+	}
+	userID, _ := auth.GetUserIDFromContext(r.Context())
+	return userID
+}
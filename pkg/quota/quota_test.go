@@ -0,0 +1,112 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestMeter returns a meter with an in-memory store whose clocks are fixed at now
+func newTestMeter(now time.Time, options ...Option) *Meter {
+	store := NewMemoryStore()
+	m := New(append([]Option{WithStore(store)}, options...)...)
+	m.now = func() time.Time { return now }
+	store.now = m.now
+	return m
+}
+
+func TestPeriodWindow(t *testing.T) {
+	at := time.Date(2024, 2, 29, 15, 30, 0, 0, time.FixedZone("NZDT", 13*3600))
+
+	tests := []struct {
+		period    Period
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{Daily, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Monthly, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.period), func(t *testing.T) {
+			start, end := tt.period.Window(at)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("Expected %v to %v, got %v to %v", tt.wantStart, tt.wantEnd, start, end)
+			}
+		})
+	}
+}
+
+func TestConsume(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	m := newTestMeter(now, WithQuota(MetricRowsWritten, 100, Monthly))
+	ctx := context.Background()
+
+	usage, err := m.Consume(ctx, "acme", MetricRowsWritten, 60)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if usage.Used != 60 || usage.Remaining != 40 || !usage.ResetsAt.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+
+	usage, err = m.Consume(ctx, "acme", MetricRowsWritten, 50)
+	if !errors.Is(err, ErrQuotaExceeded) || !usage.Exceeded() || usage.Remaining != 0 {
+		t.Errorf("Expected the quota to be exceeded, got %+v, %v", usage, err)
+	}
+
+	if usage, _ := m.Usage(ctx, "globex", MetricRowsWritten); usage.Used != 0 || usage.Remaining != 100 {
+		t.Errorf("Expected subjects to be counted separately, got %+v", usage)
+	}
+
+	// The next window starts from zero
+	next := now.AddDate(0, 1, 0)
+	m.now = func() time.Time { return next }
+	if usage, _ := m.Usage(ctx, "acme", MetricRowsWritten); usage.Used != 0 {
+		t.Errorf("Expected usage to reset in the next window, got %+v", usage)
+	}
+}
+
+func TestRecordUnlimited(t *testing.T) {
+	m := newTestMeter(time.Now())
+
+	usage, err := m.Consume(context.Background(), "acme", MetricStorageBytes, 1<<30)
+	if err != nil || usage.Exceeded() {
+		t.Fatalf("Expected metrics without a quota to be unlimited, got %+v, %v", usage, err)
+	}
+	if usage.Limit != -1 || usage.Remaining != -1 || usage.Used != 1<<30 {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+}
+
+func TestQuotaFunc(t *testing.T) {
+	plans := map[string]int64{"enterprise": 1000}
+	m := newTestMeter(time.Now(),
+		WithQuota(MetricRequests, 10, Daily),
+		WithQuotaFunc(func(_ context.Context, subject, metric string) (Quota, bool) {
+			limit, ok := plans[subject]
+			return Quota{Limit: limit, Period: Monthly}, ok
+		}))
+
+	enterprise, _ := m.Usage(context.Background(), "enterprise", MetricRequests)
+	trial, _ := m.Usage(context.Background(), "trial", MetricRequests)
+	if enterprise.Limit != 1000 || enterprise.Period != Monthly || enterprise.Metric != MetricRequests {
+		t.Errorf("Expected the plan's quota, got %+v", enterprise)
+	}
+	if trial.Limit != 10 || trial.Period != Daily {
+		t.Errorf("Expected the default quota, got %+v", trial)
+	}
+}
+
+func TestKey(t *testing.T) {
+	m := newTestMeter(time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC), WithPrefix("orders"))
+
+	key, expires := m.key("acme", Quota{Metric: MetricRequests, Period: Monthly})
+	if key != "orders:acme:requests:monthly:20240501" {
+		t.Errorf("Unexpected key %s", key)
+	}
+	if !expires.Equal(time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected counters to outlive their window by a day, got %v", expires)
+	}
+}
@@ -0,0 +1,23 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisStoreUnreachable(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() { _ = client.Close() })
+	store := NewRedisStore(client)
+	ctx := context.Background()
+
+	if _, err := store.Increment(ctx, "a", 1, time.Now().Add(time.Hour)); err == nil {
+		t.Error("Expected Increment to fail")
+	}
+	if _, err := store.Get(ctx, "a"); err == nil {
+		t.Error("Expected Get to fail")
+	}
+}
@@ -0,0 +1,280 @@
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Mask replaces redacted values
+const Mask = "[REDACTED]"
+
+// Masker hides sensitive parts of a value, such as an email address, leaving the rest readable
+type Masker func(value string) string
+
+// DefaultFields are the case-insensitive substrings of field names whose values are redacted
+var DefaultFields = []string{
+	"password", "passwd", "secret", "token", "apikey", "api_key", "api-key", "signature", "credential", "ssn",
+	"cardnumber", "card_number", "cvv",
+}
+
+// DefaultHeaders are the headers whose values are redacted, in addition to those matching a field pattern
+var DefaultHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// Config holds configuration for a Redactor
+type Config struct {
+	// Fields are case-insensitive substrings of field, parameter, and header names whose values are redacted
+	Fields []string
+	// Headers are header names whose values are redacted
+	Headers []string
+	// Maskers are applied to every value that is not redacted outright
+	Maskers []Masker
+}
+
+// DefaultConfig provides sensible defaults
+func DefaultConfig() *Config {
+	return &Config{
+		Fields:  slices.Clone(DefaultFields),
+		Headers: slices.Clone(DefaultHeaders),
+		Maskers: []Masker{MaskEmail, MaskCreditCard},
+	}
+}
+
+// Option is a functional option for configuring a Redactor
+type Option func(*Config)
+
+// WithFields adds field name patterns to redact
+func WithFields(fields ...string) Option {
+	return func(config *Config) {
+		config.Fields = append(config.Fields, fields...)
+	}
+}
+
+// WithHeaders adds header names to redact
+func WithHeaders(headers ...string) Option {
+	return func(config *Config) {
+		config.Headers = append(config.Headers, headers...)
+	}
+}
+
+// WithMaskers adds maskers applied to values that are not redacted
+func WithMaskers(maskers ...Masker) Option {
+	return func(config *Config) {
+		config.Maskers = append(config.Maskers, maskers...)
+	}
+}
+
+// WithoutMaskers removes every masker, including the defaults
+func WithoutMaskers() Option {
+	return func(config *Config) {
+		config.Maskers = nil
+	}
+}
+
+// NewConfig creates a new redaction config with options
+func NewConfig(options ...Option) *Config {
+	config := DefaultConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Redactor removes credentials and personal data from values before they are logged or stored
+type Redactor struct {
+	config *Config
+}
+
+// New creates a redactor; with no options it redacts DefaultFields and DefaultHeaders and masks
+// email addresses and card numbers
+func New(options ...Option) *Redactor {
+	return &Redactor{config: NewConfig(options...)}
+}
+
+// defaultRedactor is shared by callers without their own rules
+var defaultRedactor = New()
+
+// Default returns a redactor with the default rules
+func Default() *Redactor {
+	return defaultRedactor
+}
+
+// With returns a copy of the redactor with more options applied
+func (r *Redactor) With(options ...Option) *Redactor {
+	config := &Config{
+		Fields:  slices.Clone(r.config.Fields),
+		Headers: slices.Clone(r.config.Headers),
+		Maskers: slices.Clone(r.config.Maskers),
+	}
+	for _, option := range options {
+		option(config)
+	}
+	return &Redactor{config: config}
+}
+
+// Sensitive reports whether values named name are redacted outright
+func (r *Redactor) Sensitive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, field := range r.config.Fields {
+		if strings.Contains(lower, strings.ToLower(field)) {
+			return true
+		}
+	}
+	for _, header := range r.config.Headers {
+		if strings.EqualFold(name, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// Value redacts a named value: sensitive names are replaced with Mask, and other values are masked.
+// Empty values are kept, since they reveal only that nothing is set.
+func (r *Redactor) Value(name, value string) string {
+	if value == "" {
+		return value
+	}
+	if r.Sensitive(name) {
+		return Mask
+	}
+	return r.String(value)
+}
+
+// String applies every masker to a free-form value
+func (r *Redactor) String(value string) string {
+	for _, mask := range r.config.Maskers {
+		value = mask(value)
+	}
+	return value
+}
+
+// Header returns a copy of h with sensitive values redacted
+func (r *Redactor) Header(h http.Header) http.Header {
+	redacted := make(http.Header, len(h))
+	for name, values := range h {
+		redacted[name] = make([]string, len(values))
+		for i, value := range values {
+			redacted[name][i] = r.Value(name, value)
+		}
+	}
+	return redacted
+}
+
+// Query returns a copy of values with sensitive parameters redacted
+func (r *Redactor) Query(values url.Values) url.Values {
+	redacted := make(url.Values, len(values))
+	for name, list := range values {
+		redacted[name] = make([]string, len(list))
+		for i, value := range list {
+			redacted[name][i] = r.Value(name, value)
+		}
+	}
+	return redacted
+}
+
+// URL returns a copy of u with sensitive query parameters redacted
+func (r *Redactor) URL(u *url.URL) *url.URL {
+	redacted := *u
+	if u.RawQuery != "" {
+		redacted.RawQuery = r.Query(u.Query()).Encode()
+	}
+	return &redacted
+}
+
+// JSON redacts a JSON document, replacing the values of sensitive fields at any depth and masking
+// other strings
+func (r *Redactor) JSON(data []byte) ([]byte, error) {
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	return json.Marshal(r.Tree(tree))
+}
+
+// Tree redacts decoded JSON in place, as produced by unmarshalling into an interface{}
+func (r *Redactor) Tree(node interface{}) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if s, ok := value.(string); ok {
+				v[key] = r.Value(key, s)
+			} else if r.Sensitive(key) && value != nil {
+				v[key] = Mask
+			} else {
+				v[key] = r.Tree(value)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = r.Tree(v[i])
+		}
+	case string:
+		return r.String(v)
+	}
+	return node
+}
+
+var (
+	emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+	cardPattern  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+)
+
+// MaskEmail keeps the first character and domain of email addresses: "jane@example.com" becomes
+// "j***@example.com"
+func MaskEmail(value string) string {
+	return emailPattern.ReplaceAllString(value, "$1***@$2")
+}
+
+// MaskCreditCard masks all but the last four digits of card numbers, recognised by their length
+// and Luhn checksum so other long numbers are left alone
+func MaskCreditCard(value string) string {
+	return cardPattern.ReplaceAllStringFunc(value, func(match string) string {
+		digits := digitsOf(match)
+		if len(digits) < 13 || !luhn(digits) {
+			return match
+		}
+
+		masked := []byte(match)
+		remaining := len(digits)
+		for i := range masked {
+			if masked[i] >= '0' && masked[i] <= '9' {
+				if remaining > 4 {
+					masked[i] = '*'
+				}
+				remaining--
+			}
+		}
+		return string(masked)
+	})
+}
+
+// digitsOf returns the digits in s
+func digitsOf(s string) string {
+	return strings.Map(func(c rune) rune {
+		if c >= '0' && c <= '9' {
+			return c
+		}
+		return -1
+	}, s)
+}
+
+// luhn reports whether a digit string passes the Luhn checksum
+func luhn(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,119 @@
+package redact
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if len(config.Fields) != len(DefaultFields) || len(config.Headers) != len(DefaultHeaders) ||
+		len(config.Maskers) != 2 {
+		t.Errorf("Unexpected defaults: %+v", config)
+	}
+
+	config = NewConfig(WithFields("dob"), WithHeaders("X-Session"), WithoutMaskers(), WithMaskers(MaskEmail))
+	if config.Fields[len(config.Fields)-1] != "dob" || config.Headers[len(config.Headers)-1] != "X-Session" ||
+		len(config.Maskers) != 1 {
+		t.Errorf("Expected options to be applied, got %+v", config)
+	}
+}
+
+func TestValue(t *testing.T) {
+	r := New()
+	tests := []struct {
+		name  string
+		field string
+		value string
+		want  string
+	}{
+		{"password", "password", "hunter2", Mask},
+		{"case-insensitive", "DB_PASSWORD", "hunter2", Mask},
+		{"substring", "refreshToken", "abc", Mask},
+		{"header", "Cookie", "session=abc", Mask},
+		{"empty kept", "apiKey", "", ""},
+		{"email masked", "contact", "Reach jane.doe@example.com today", "Reach j***@example.com today"},
+		{"card masked", "note", "card 4111 1111 1111 1111 on file", "card **** **** **** 1111 on file"},
+		{"other numbers kept", "orderId", "1234567890123", "1234567890123"},
+		{"plain value", "name", "Ann", "Ann"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Value(tt.field, tt.value); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMaskCreditCard(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"4111111111111111", "************1111"},
+		{"5500-0000-0000-0004", "****-****-****-0004"},
+		{"4111111111111112", "4111111111111112"}, // fails the Luhn check
+		{"call 555-0100", "call 555-0100"},
+	}
+
+	for _, tt := range tests {
+		if got := MaskCreditCard(tt.input); got != tt.want {
+			t.Errorf("MaskCreditCard(%q): expected %q, got %q", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	r := New(WithFields("dob"))
+	input := `{"user":{"name":"Ann","email":"ann@example.com","password":"p","dob":"1990-01-01"},` +
+		`"credentials":{"id":"x"},"cards":[{"cvv":123,"number":"4111111111111111"}],"apiKey":""}`
+
+	got, err := r.JSON([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"apiKey":"","cards":[{"cvv":"[REDACTED]","number":"************1111"}],` +
+		`"credentials":"[REDACTED]","user":{"dob":"[REDACTED]","email":"a***@example.com","name":"Ann",` +
+		`"password":"[REDACTED]"}}`
+	if string(got) != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if _, err := r.JSON([]byte("not json")); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+}
+
+func TestHeaderAndQuery(t *testing.T) {
+	r := Default()
+	h := http.Header{"Authorization": {"Bearer abc"}, "X-Auth-Token": {"t"}, "Accept": {"application/json"}}
+
+	redacted := r.Header(h)
+	if redacted.Get("Authorization") != Mask || redacted.Get("X-Auth-Token") != Mask ||
+		redacted.Get("Accept") != "application/json" {
+		t.Errorf("Unexpected headers: %v", redacted)
+	}
+	if h.Get("Authorization") != "Bearer abc" {
+		t.Error("Expected the original headers to be left alone")
+	}
+
+	u, _ := url.Parse("/reset?token=abc&page=2")
+	if got := r.URL(u).String(); got != "/reset?page=2&token=%5BREDACTED%5D" {
+		t.Errorf("Unexpected URL: %s", got)
+	}
+	if u.RawQuery != "token=abc&page=2" {
+		t.Error("Expected the original URL to be left alone")
+	}
+}
+
+func TestWith(t *testing.T) {
+	base := New()
+	extended := base.With(WithFields("dob"))
+
+	if !extended.Sensitive("dob") || base.Sensitive("dob") {
+		t.Error("Expected With to extend a copy, leaving the original unchanged")
+	}
+}
@@ -0,0 +1,71 @@
+package report
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+)
+
+// Event is an unhandled error and what was known when it happened
+type Event struct {
+	Err error
+	// Request is the request being served, if any; reporters redact it before sending
+	Request *http.Request
+	// Stack is the stack trace of a recovered panic
+	Stack []byte
+	// Tags are short indexed values, such as the request ID, job name, or queue
+	Tags map[string]string
+	// Extra is additional context shown with the event
+	Extra map[string]interface{}
+}
+
+// ErrorReporter sends unhandled errors to an alerting system. Report must not block for long, as
+// it is called while serving requests and running jobs.
+type ErrorReporter interface {
+	Report(ctx context.Context, event Event)
+}
+
+// ReporterFunc adapts a function to an ErrorReporter
+type ReporterFunc func(ctx context.Context, event Event)
+
+// Report implements ErrorReporter
+func (f ReporterFunc) Report(ctx context.Context, event Event) {
+	f(ctx, event)
+}
+
+// Nop returns a reporter that discards events, the default until SetDefault is called
+func Nop() ErrorReporter {
+	return ReporterFunc(func(context.Context, Event) {})
+}
+
+// defaultReporter is the process-wide reporter, boxed since atomic.Pointer needs a concrete type
+var defaultReporter atomic.Pointer[holder]
+
+type holder struct {
+	reporter ErrorReporter
+}
+
+// SetDefault sets the process-wide reporter used by components without their own
+func SetDefault(reporter ErrorReporter) {
+	if reporter == nil {
+		reporter = Nop()
+	}
+	defaultReporter.Store(&holder{reporter: reporter})
+}
+
+// Default returns the process-wide reporter
+func Default() ErrorReporter {
+	if h := defaultReporter.Load(); h != nil {
+		return h.reporter
+	}
+	return Nop()
+}
+
+// OrDefault returns reporter, or the process-wide one when reporter is nil, for configs where the
+// reporter is optional
+func OrDefault(reporter ErrorReporter) ErrorReporter {
+	if reporter == nil {
+		return Default()
+	}
+	return reporter
+}
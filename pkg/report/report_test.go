@@ -0,0 +1,33 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	defer SetDefault(nil)
+
+	var reported []error
+	reporter := ReporterFunc(func(_ context.Context, event Event) {
+		reported = append(reported, event.Err)
+	})
+
+	OrDefault(nil).Report(context.Background(), Event{Err: errors.New("dropped")})
+	if len(reported) != 0 {
+		t.Fatalf("Expected the default reporter to discard events, got %v", reported)
+	}
+
+	SetDefault(reporter)
+	OrDefault(nil).Report(context.Background(), Event{Err: errors.New("boom")})
+	if len(reported) != 1 || reported[0].Error() != "boom" {
+		t.Errorf("Expected the event to reach the process-wide reporter, got %v", reported)
+	}
+
+	own := 0
+	OrDefault(ReporterFunc(func(context.Context, Event) { own++ })).Report(context.Background(), Event{})
+	if own != 1 || len(reported) != 1 {
+		t.Errorf("Expected a component's own reporter to take precedence")
+	}
+}
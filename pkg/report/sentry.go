@@ -0,0 +1,319 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/redact"
+)
+
+// Logger is the logging interface used by reporters
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// SentryOption is a functional option for configuring a SentryReporter
+type SentryOption func(*SentryConfig)
+
+// SentryConfig holds configuration for a SentryReporter
+type SentryConfig struct {
+	Environment string
+	Release     string
+	ServerName  string
+	Client      *http.Client
+	// MaxPending is how many events may be on their way at once; more are dropped and logged
+	MaxPending int
+	// Redactor removes credentials and personal data from the request sent with events
+	Redactor *redact.Redactor
+	Logger   Logger
+}
+
+// DefaultSentryConfig provides sensible defaults
+func DefaultSentryConfig() *SentryConfig {
+	return &SentryConfig{
+		Client:     &http.Client{Timeout: 5 * time.Second},
+		MaxPending: 100,
+		Redactor:   redact.Default(),
+		Logger:     log.Default(),
+	}
+}
+
+// WithEnvironment sets the environment events are tagged with, e.g. production
+func WithEnvironment(environment string) SentryOption {
+	return func(config *SentryConfig) {
+		config.Environment = environment
+	}
+}
+
+// WithRelease sets the release events are tagged with, e.g. the service version
+func WithRelease(release string) SentryOption {
+	return func(config *SentryConfig) {
+		config.Release = release
+	}
+}
+
+// WithServerName sets the server name events are tagged with, e.g. the hostname
+func WithServerName(name string) SentryOption {
+	return func(config *SentryConfig) {
+		config.ServerName = name
+	}
+}
+
+// WithHTTPClient sets the client events are sent with
+func WithHTTPClient(client *http.Client) SentryOption {
+	return func(config *SentryConfig) {
+		config.Client = client
+	}
+}
+
+// WithMaxPending sets how many events may be on their way at once
+func WithMaxPending(maxPending int) SentryOption {
+	return func(config *SentryConfig) {
+		config.MaxPending = maxPending
+	}
+}
+
+// WithRedactor sets the redactor applied to requests sent with events
+func WithRedactor(redactor *redact.Redactor) SentryOption {
+	return func(config *SentryConfig) {
+		config.Redactor = redactor
+	}
+}
+
+// WithLogger sets the logger for events that could not be sent
+func WithLogger(logger Logger) SentryOption {
+	return func(config *SentryConfig) {
+		config.Logger = logger
+	}
+}
+
+// NewSentryConfig creates a new Sentry config with options
+func NewSentryConfig(options ...SentryOption) *SentryConfig {
+	config := DefaultSentryConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// SentryReporter sends events to Sentry, or a compatible service such as GlitchTip, over its HTTP
+// API. Events are sent in the background; call Flush before exiting so none are lost.
+type SentryReporter struct {
+	config   *SentryConfig
+	dsn      string
+	endpoint string
+	auth     string
+	pending  chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewSentryReporter creates a reporter for the project identified by dsn, such as
+// https://<key>@o123.ingest.sentry.io/456
+func NewSentryReporter(dsn string, options ...SentryOption) (*SentryReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	// Self-hosted Sentry may live under a path, as in https://key@example.com/sentry/456
+	path := strings.TrimSuffix(u.Path, "/")
+	prefix, project := path[:max(strings.LastIndex(path, "/"), 0)], path[strings.LastIndex(path, "/")+1:]
+	if u.Scheme == "" || u.Host == "" || u.User.Username() == "" || project == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: expected scheme://key@host/project")
+	}
+
+	config := NewSentryConfig(options...)
+	return &SentryReporter{
+		config:   config,
+		dsn:      dsn,
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, project),
+		auth:     "Sentry sentry_version=7, sentry_client=go-service-kit/1.0, sentry_key=" + u.User.Username(),
+		pending:  make(chan struct{}, max(config.MaxPending, 1)),
+	}, nil
+}
+
+// Report implements ErrorReporter, sending the event in the background
+func (s *SentryReporter) Report(_ context.Context, event Event) {
+	if event.Err == nil {
+		return
+	}
+	// The request may be reused once the handler returns, so the payload is built now
+	envelope, eventID, err := s.envelope(event)
+	if err != nil {
+		s.config.Logger.Printf("### 🚨 Report: failed to encode Sentry event: %v", err)
+		return
+	}
+
+	select {
+	case s.pending <- struct{}{}:
+	default:
+		s.config.Logger.Printf("### 🚨 Report: dropped Sentry event %s, too many pending: %v", eventID, event.Err)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() { <-s.pending }()
+		if err := s.send(envelope); err != nil {
+			s.config.Logger.Printf("### 🚨 Report: failed to send Sentry event %s: %v", eventID, err)
+		}
+	}()
+}
+
+// Flush waits for pending events to be sent, or until ctx is done
+func (s *SentryReporter) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("sentry events still pending: %w", ctx.Err())
+	}
+}
+
+// sentryEvent is the event payload, whose field names the Sentry protocol defines
+//
+//nolint:tagliatelle
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Platform    string                 `json:"platform"`
+	Level       string                 `json:"level"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Exception   sentryExceptions       `json:"exception"`
+	Request     *sentryRequest         `json:"request,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+//nolint:tagliatelle
+type sentryRequest struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	QueryString string            `json:"query_string,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// envelope encodes an event as a Sentry envelope, returning it with the event ID
+func (s *SentryReporter) envelope(event Event) ([]byte, string, error) {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	eventID := hex.EncodeToString(id)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	payload := sentryEvent{
+		EventID:     eventID,
+		Timestamp:   now,
+		Platform:    "go",
+		Level:       "error",
+		ServerName:  s.config.ServerName,
+		Release:     s.config.Release,
+		Environment: s.config.Environment,
+		Exception: sentryExceptions{Values: []sentryException{
+			{Type: fmt.Sprintf("%T", event.Err), Value: event.Err.Error()},
+		}},
+		Request: s.request(event.Request),
+		Tags:    event.Tags,
+		Extra:   event.Extra,
+	}
+	if len(event.Stack) > 0 {
+		payload.Extra = make(map[string]interface{}, len(event.Extra)+1)
+		for k, v := range event.Extra {
+			payload.Extra[k] = v
+		}
+		payload.Extra["stack"] = string(event.Stack)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", err
+	}
+	header, _ := json.Marshal(map[string]string{"event_id": eventID, "sent_at": now, "dsn": s.dsn})
+	item, _ := json.Marshal(map[string]interface{}{"type": "event", "length": len(body)})
+
+	var envelope bytes.Buffer
+	for _, line := range [][]byte{header, item, body} {
+		envelope.Write(line)
+		envelope.WriteByte('\n')
+	}
+	return envelope.Bytes(), eventID, nil
+}
+
+// request describes the request sent with an event, redacted
+func (s *SentryReporter) request(r *http.Request) *sentryRequest {
+	if r == nil {
+		return nil
+	}
+
+	u := *r.URL
+	if u.Host == "" {
+		u.Host = r.Host
+	}
+	if u.Scheme == "" {
+		u.Scheme = "http"
+		if r.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+	query, header := u.RawQuery, r.Header
+	if s.config.Redactor != nil {
+		query = s.config.Redactor.Query(u.Query()).Encode()
+		header = s.config.Redactor.Header(header)
+	}
+	u.RawQuery = ""
+
+	req := &sentryRequest{Method: r.Method, URL: u.String(), QueryString: query, Headers: make(map[string]string)}
+	for name, values := range header {
+		req.Headers[name] = strings.Join(values, ", ")
+	}
+	return req
+}
+
+// send posts an envelope to Sentry
+func (s *SentryReporter) send(envelope []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(envelope))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+
+	resp, err := s.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry responded %s", resp.Status)
+	}
+	return nil
+}
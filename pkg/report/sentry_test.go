@@ -0,0 +1,161 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sentryServer records the envelopes posted to it
+type sentryServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	paths     []string
+	auth      []string
+	envelopes [][]byte
+}
+
+func newSentryServer(t *testing.T, status int) *sentryServer {
+	s := &sentryServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.paths = append(s.paths, r.URL.Path)
+		s.auth = append(s.auth, r.Header.Get("X-Sentry-Auth"))
+		s.envelopes = append(s.envelopes, body)
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// dsn returns a DSN for the server under path
+func (s *sentryServer) dsn(path string) string {
+	return strings.Replace(s.URL, "://", "://public@", 1) + path
+}
+
+// event decodes the event in the i-th envelope
+func (s *sentryServer) event(t *testing.T, i int) sentryEvent {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := bytes.Split(bytes.TrimSpace(s.envelopes[i]), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header, item header, and event, got %q", s.envelopes[i])
+	}
+	var event sentryEvent
+	if err := json.Unmarshal(lines[2], &event); err != nil {
+		t.Fatalf("Invalid event %q: %v", lines[2], err)
+	}
+	return event
+}
+
+func TestNewSentryReporter(t *testing.T) {
+	tests := []struct {
+		dsn      string
+		endpoint string
+		valid    bool
+	}{
+		{"https://key@o1.ingest.sentry.io/42", "https://o1.ingest.sentry.io/api/42/envelope/", true},
+		{"https://key@example.com/sentry/42", "https://example.com/sentry/api/42/envelope/", true},
+		{"https://example.com/42", "", false},
+		{"https://key@example.com/", "", false},
+		{"not a dsn", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			reporter, err := NewSentryReporter(tt.dsn)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("Expected %q to be rejected", tt.dsn)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if reporter.endpoint != tt.endpoint {
+				t.Errorf("Expected endpoint %s, got %s", tt.endpoint, reporter.endpoint)
+			}
+		})
+	}
+}
+
+func TestSentryReporter(t *testing.T) {
+	server := newSentryServer(t, http.StatusOK)
+	reporter, err := NewSentryReporter(server.dsn("/42"), WithEnvironment("production"), WithRelease("1.2.3"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/orders?token=secret&page=2", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("User-Agent", "test")
+	reporter.Report(context.Background(), Event{
+		Err:     errors.New("database unreachable"),
+		Request: req,
+		Stack:   []byte("goroutine 1 [running]"),
+		Tags:    map[string]string{"request_id": "req-1"},
+	})
+	if err := reporter.Flush(context.Background()); err != nil {
+		t.Fatalf("Unexpected flush error: %v", err)
+	}
+
+	if len(server.paths) != 1 || server.paths[0] != "/api/42/envelope/" {
+		t.Fatalf("Expected one envelope posted to the project, got %v", server.paths)
+	}
+	if !strings.Contains(server.auth[0], "sentry_key=public") {
+		t.Errorf("Expected the DSN key in the auth header, got %s", server.auth[0])
+	}
+
+	event := server.event(t, 0)
+	if event.Exception.Values[0].Value != "database unreachable" || event.Level != "error" ||
+		event.Environment != "production" || event.Release != "1.2.3" || event.Tags["request_id"] != "req-1" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if event.Extra["stack"] != "goroutine 1 [running]" {
+		t.Errorf("Expected the stack in extra, got %v", event.Extra)
+	}
+	if event.Request == nil || event.Request.Method != "POST" || event.Request.URL != "http://example.com/orders" {
+		t.Fatalf("Expected the request, got %+v", event.Request)
+	}
+	if strings.Contains(event.Request.QueryString, "secret") || event.Request.Headers["Authorization"] == "Bearer secret" {
+		t.Errorf("Expected credentials to be redacted, got %+v", event.Request)
+	}
+}
+
+func TestSentryReporterFailures(t *testing.T) {
+	server := newSentryServer(t, http.StatusTooManyRequests)
+	var logs bytes.Buffer
+	reporter, err := NewSentryReporter(server.dsn("/42"), WithLogger(log.New(&logs, "", 0)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	reporter.Report(context.Background(), Event{Err: errors.New("boom")})
+	reporter.Report(context.Background(), Event{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := reporter.Flush(ctx); err != nil {
+		t.Fatalf("Unexpected flush error: %v", err)
+	}
+
+	if len(server.paths) != 1 {
+		t.Errorf("Expected events without an error to be skipped, got %d", len(server.paths))
+	}
+	if !strings.Contains(logs.String(), "failed to send") {
+		t.Errorf("Expected the rejection to be logged, got %q", logs.String())
+	}
+}
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// CSRFToken returns the session's CSRF token, creating it on first use. It changes when the session ID
+// is renewed.
+func (s *Session) CSRFToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rec.CSRFToken == "" {
+		s.rec.CSRFToken = rand.Text()
+		s.modified = true
+	}
+	return s.rec.CSRFToken
+}
+
+// CSRFToken returns the CSRF token of the request's session for embedding in a form or page, or "" when
+// the session middleware isn't installed
+func CSRFToken(r *http.Request) string {
+	if s := Get(r); s != nil {
+		return s.CSRFToken()
+	}
+	return ""
+}
+
+// validCSRF reports whether token matches the session's CSRF token
+func (s *Session) validCSRF(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rec.CSRFToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.rec.CSRFToken)) == 1
+}
+
+// RequireCSRF creates middleware that rejects unsafe requests with 403 Forbidden unless they carry the
+// session's CSRF token in the CSRF header or form field. It must run inside Middleware.
+func (m *Manager) RequireCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := r.Header.Get(m.config.CSRFHeader)
+		if token == "" && m.config.CSRFField != "" {
+			token = r.PostFormValue(m.config.CSRFField)
+		}
+
+		if s := Get(r); s == nil || !s.validCSRF(token) {
+			problem.New("csrf-token-invalid", "Invalid CSRF Token", http.StatusForbidden,
+				"The request is missing a valid CSRF token", r.URL.Path).Respond(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
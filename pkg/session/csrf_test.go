@@ -0,0 +1,80 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRequireCSRF(t *testing.T) {
+	m := newTestManager(t)
+
+	// A GET renders the form, creating the token
+	var token string
+	rec := roundTrip(m, nil, func(w http.ResponseWriter, r *http.Request) {
+		token = CSRFToken(r)
+	})
+	cookie := sessionCookie(rec)
+	if token == "" || cookie == nil {
+		t.Fatal("Expected a CSRF token saved in the session")
+	}
+
+	handler := m.Middleware(m.RequireCSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	tests := []struct {
+		name   string
+		method string
+		header string
+		form   url.Values
+		want   int
+	}{
+		{"safe method", http.MethodGet, "", nil, http.StatusNoContent},
+		{"header", http.MethodPost, token, nil, http.StatusNoContent},
+		{"form field", http.MethodPost, "", url.Values{"csrf_token": {token}}, http.StatusNoContent},
+		{"missing", http.MethodPost, "", nil, http.StatusForbidden},
+		{"wrong", http.MethodDelete, "not-the-token", nil, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.form.Encode()))
+			req.AddCookie(cookie)
+			if tt.header != "" {
+				req.Header.Set("X-CSRF-Token", tt.header)
+			}
+			if tt.form != nil {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestRequireCSRFWithoutToken(t *testing.T) {
+	m := newTestManager(t)
+	handler := m.Middleware(m.RequireCSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected handler not to run")
+	})))
+
+	// A session that never issued a token accepts no token, including an empty one
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403, got %d", rec.Code)
+	}
+}
+
+func TestCSRFTokenWithoutMiddleware(t *testing.T) {
+	if got := CSRFToken(httptest.NewRequest(http.MethodGet, "/", nil)); got != "" {
+		t.Errorf("Expected no token without a session, got %q", got)
+	}
+}
@@ -0,0 +1,278 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/crypto"
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// ErrCookieTooLarge is returned by Save when a cookie session grows beyond what browsers accept; keep
+// large values in a server-side Store instead
+var ErrCookieTooLarge = errors.New("session cookie too large")
+
+// errInvalidSession marks a cookie that was tampered with, sealed with an unknown key, or unreadable
+var errInvalidSession = errors.New("invalid session cookie")
+
+// maxCookieSize is the most browsers reliably store for a cookie, name and value together
+const maxCookieSize = 4096
+
+// Manager loads and saves sessions from request cookies
+type Manager struct {
+	config *Config
+	aeads  []cipher.AEAD
+}
+
+// NewManager creates a session manager. At least one key is required.
+func NewManager(options ...Option) (*Manager, error) {
+	config := NewConfig(options...)
+	if len(config.Keys) == 0 {
+		return nil, errors.New("at least one session key is required")
+	}
+
+	m := &Manager{config: config}
+	for i, key := range config.Keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid session key %d: %w", i, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("invalid session key %d: %w", i, err)
+		}
+		m.aeads = append(m.aeads, aead)
+	}
+	return m, nil
+}
+
+// Load returns the request's session, or a new one when there is no valid, unexpired session. It only
+// fails when the store can't be reached.
+func (m *Manager) Load(r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(m.config.CookieName)
+	if err != nil {
+		return newSession(), nil
+	}
+
+	rec, err := m.decode(r, cookie.Value)
+	if errors.Is(err, ErrNotFound) || errors.Is(err, errInvalidSession) {
+		return newSession(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	now := time.Now()
+	if rec.expired(m.config, now) {
+		if m.config.Store != nil {
+			_ = m.config.Store.Delete(r.Context(), storeKey(rec.ID))
+		}
+		return newSession(), nil
+	}
+
+	s := &Session{rec: *rec}
+	// Recording activity is throttled, so an active session isn't rewritten on every request
+	if now.Sub(rec.LastSeen) >= m.config.IdleTimeout/10 {
+		s.rec.LastSeen = now
+		s.modified = true
+	}
+	return s, nil
+}
+
+// decode opens the cookie value and reads the session it holds or refers to
+func (m *Manager) decode(r *http.Request, value string) (*record, error) {
+	data, err := m.open(value)
+	if err != nil {
+		return nil, err
+	}
+
+	id := ""
+	if m.config.Store != nil {
+		id = string(data)
+		if data, err = m.config.Store.Load(r.Context(), storeKey(id)); err != nil {
+			return nil, err
+		}
+	}
+
+	var rec record
+	if err := m.config.Codec.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidSession, err)
+	}
+	if m.config.Store != nil && rec.ID != id {
+		return nil, errInvalidSession
+	}
+	if rec.Values == nil {
+		rec.Values = make(map[string]interface{})
+	}
+	return &rec, nil
+}
+
+// Save writes the session's cookie, and its data to the store, if it has changed. It must be called
+// before the response is written; the middleware does so automatically.
+func (m *Manager) Save(w http.ResponseWriter, r *http.Request, s *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.modified {
+		return nil
+	}
+
+	store := m.config.Store
+	if store != nil && s.previousID != "" {
+		if err := store.Delete(r.Context(), storeKey(s.previousID)); err != nil {
+			return fmt.Errorf("failed to delete renewed session: %w", err)
+		}
+		s.previousID = ""
+	}
+
+	if s.destroyed {
+		return m.destroy(w, r, s)
+	}
+
+	value, err := m.encode(r, &s.rec)
+	if err != nil {
+		return err
+	}
+	if len(m.config.CookieName)+len(value) > maxCookieSize {
+		return ErrCookieTooLarge
+	}
+
+	http.SetCookie(w, m.cookie(value, 0, s.rec.CreatedAt.Add(m.config.AbsoluteTimeout)))
+	s.modified = false
+	s.isNew = false
+	return nil
+}
+
+// destroy deletes a destroyed session from the store and expires its cookie
+func (m *Manager) destroy(w http.ResponseWriter, r *http.Request, s *Session) error {
+	if m.config.Store != nil && !s.isNew {
+		if err := m.config.Store.Delete(r.Context(), storeKey(s.rec.ID)); err != nil {
+			return fmt.Errorf("failed to delete session: %w", err)
+		}
+	}
+	http.SetCookie(w, m.cookie("", -1, time.Time{}))
+	s.modified = false
+	return nil
+}
+
+// encode saves the session to the store, if there is one, and returns the sealed cookie value
+func (m *Manager) encode(r *http.Request, rec *record) (string, error) {
+	data, err := m.config.Codec.Marshal(rec)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	if m.config.Store != nil {
+		ttl := time.Until(rec.expiresAt(m.config))
+		if err := m.config.Store.Save(r.Context(), storeKey(rec.ID), data, ttl); err != nil {
+			return "", fmt.Errorf("failed to save session: %w", err)
+		}
+		data = []byte(rec.ID)
+	}
+	return m.seal(data), nil
+}
+
+func (m *Manager) cookie(value string, maxAge int, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.config.CookieName,
+		Value:    value,
+		Path:     m.config.CookiePath,
+		Domain:   m.config.CookieDomain,
+		Expires:  expires,
+		MaxAge:   maxAge,
+		Secure:   m.config.Secure,
+		HttpOnly: true,
+		SameSite: m.config.SameSite,
+	}
+}
+
+// seal encrypts and authenticates data with the newest key, binding it to the cookie name
+func (m *Manager) seal(data []byte) string {
+	aead := m.aeads[0]
+	nonce := make([]byte, aead.NonceSize())
+	_, _ = rand.Read(nonce)
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, data, []byte(m.config.CookieName)))
+}
+
+// open decrypts a cookie value sealed with any of the keys
+func (m *Manager) open(value string) ([]byte, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidSession
+	}
+
+	for _, aead := range m.aeads {
+		if len(raw) < aead.NonceSize() {
+			continue
+		}
+		nonce, sealed := raw[:aead.NonceSize()], raw[aead.NonceSize():]
+		if data, err := aead.Open(nil, nonce, sealed, []byte(m.config.CookieName)); err == nil {
+			return data, nil
+		}
+	}
+	return nil, errInvalidSession
+}
+
+// storeKey is what a session is stored under, so a leaked store doesn't reveal usable session IDs
+func storeKey(id string) string {
+	return crypto.HashToken(id)
+}
+
+// Middleware loads the session into the request context and saves it before the response is written.
+// Changes made after the handler starts writing the response are not saved.
+func (m *Manager) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := m.Load(r)
+		if err != nil {
+			log.Printf("### 🍪 Session: %v", err)
+			problem.New("session-unavailable", "Session Unavailable", http.StatusServiceUnavailable,
+				"The session store could not be reached", r.URL.Path).Respond(w, r)
+			return
+		}
+
+		r = r.WithContext(WithSession(r.Context(), s))
+		sw := &saveWriter{ResponseWriter: w, save: func() {
+			if err := m.Save(w, r, s); err != nil {
+				log.Printf("### 🍪 Session: %v", err)
+			}
+		}}
+
+		next.ServeHTTP(sw, r)
+		sw.saveOnce()
+	})
+}
+
+// saveWriter saves the session just before the response headers are written
+type saveWriter struct {
+	http.ResponseWriter
+	save  func()
+	saved bool
+}
+
+func (sw *saveWriter) saveOnce() {
+	if !sw.saved {
+		sw.saved = true
+		sw.save()
+	}
+}
+
+func (sw *saveWriter) WriteHeader(status int) {
+	sw.saveOnce()
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *saveWriter) Write(p []byte) (int, error) {
+	sw.saveOnce()
+	return sw.ResponseWriter.Write(p)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (sw *saveWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
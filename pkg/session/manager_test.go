@@ -0,0 +1,299 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+// memoryStore is a Store for tests
+type memoryStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	err  error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{data: make(map[string][]byte)}
+}
+
+func (s *memoryStore) Load(_ context.Context, id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	data, ok := s.data[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *memoryStore) Save(_ context.Context, id string, data []byte, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[id] = data
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, id)
+	return nil
+}
+
+func (s *memoryStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.data)
+}
+
+func newTestManager(t *testing.T, options ...Option) *Manager {
+	t.Helper()
+	m, err := NewManager(append([]Option{WithKeys(testKey)}, options...)...)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return m
+}
+
+// roundTrip runs handler through the middleware with cookie, returning the response
+func roundTrip(m *Manager, cookie *http.Cookie, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	m.Middleware(handler).ServeHTTP(rec, req)
+	return rec
+}
+
+func sessionCookie(rec *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "session" {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestNewManager(t *testing.T) {
+	if _, err := NewManager(); err == nil {
+		t.Error("Expected error without keys")
+	}
+	if _, err := NewManager(WithKeys([]byte("short"))); err == nil {
+		t.Error("Expected error for a key of invalid length")
+	}
+}
+
+func TestCookieSession(t *testing.T) {
+	m := newTestManager(t)
+
+	rec := roundTrip(m, nil, func(w http.ResponseWriter, r *http.Request) {
+		Get(r).Set("theme", "dark")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	cookie := sessionCookie(rec)
+	if cookie == nil {
+		t.Fatal("Expected a session cookie")
+	}
+	if !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("Expected HttpOnly, Secure, Lax cookie, got %+v", cookie)
+	}
+	if strings.Contains(cookie.Value, "dark") {
+		t.Error("Expected cookie contents to be encrypted")
+	}
+
+	rec = roundTrip(m, cookie, func(w http.ResponseWriter, r *http.Request) {
+		if got := Get(r).GetString("theme"); got != "dark" {
+			t.Errorf("Expected value from cookie, got %q", got)
+		}
+		if Get(r).IsNew() {
+			t.Error("Expected an existing session")
+		}
+	})
+	if sessionCookie(rec) != nil {
+		t.Error("Expected an unchanged session not to rewrite its cookie")
+	}
+}
+
+func TestNoCookieForEmptySession(t *testing.T) {
+	rec := roundTrip(newTestManager(t), nil, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	if sessionCookie(rec) != nil {
+		t.Error("Expected no cookie for an untouched new session")
+	}
+}
+
+func TestInvalidCookie(t *testing.T) {
+	m := newTestManager(t)
+	other, err := NewManager(WithKeys([]byte("fedcba9876543210fedcba9876543210")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not base64", "!!!"},
+		{"too short", "AAAA"},
+		{"other key", other.seal([]byte(`{"id":"x","values":{"userId":"admin"}}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roundTrip(m, &http.Cookie{Name: "session", Value: tt.value}, func(w http.ResponseWriter, r *http.Request) {
+				if !Get(r).IsNew() || Get(r).UserID() != "" {
+					t.Error("Expected a new session for an invalid cookie")
+				}
+			})
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := []byte("fedcba9876543210fedcba9876543210")
+	old := newTestManager(t, WithKeys(oldKey))
+	rec := roundTrip(old, nil, func(w http.ResponseWriter, r *http.Request) {
+		Get(r).Set("theme", "dark")
+	})
+
+	rotated := newTestManager(t, WithKeys(testKey, oldKey))
+	roundTrip(rotated, sessionCookie(rec), func(w http.ResponseWriter, r *http.Request) {
+		if Get(r).GetString("theme") != "dark" {
+			t.Error("Expected cookie sealed with the old key to be accepted")
+		}
+	})
+}
+
+func TestSessionExpiry(t *testing.T) {
+	m := newTestManager(t, WithIdleTimeout(time.Minute), WithAbsoluteTimeout(time.Hour))
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		created time.Time
+		seen    time.Time
+		want    bool
+	}{
+		{"active", now.Add(-time.Minute), now.Add(-time.Second), false},
+		{"idle", now.Add(-10 * time.Minute), now.Add(-2 * time.Minute), true},
+		{"past absolute timeout", now.Add(-2 * time.Hour), now, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(record{
+				ID: "id", Values: map[string]interface{}{"userId": "user-1"}, CreatedAt: tt.created, LastSeen: tt.seen,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cookie := &http.Cookie{Name: "session", Value: m.seal(data)}
+			roundTrip(m, cookie, func(w http.ResponseWriter, r *http.Request) {
+				if expired := Get(r).UserID() == ""; expired != tt.want {
+					t.Errorf("Expected expired=%v, got %v", tt.want, expired)
+				}
+			})
+		})
+	}
+}
+
+func TestStoreSession(t *testing.T) {
+	store := newMemoryStore()
+	m := newTestManager(t, WithStore(store))
+
+	var firstID string
+	rec := roundTrip(m, nil, func(w http.ResponseWriter, r *http.Request) {
+		firstID = Get(r).ID()
+		Get(r).Set("cart", "3 items")
+	})
+	cookie := sessionCookie(rec)
+	if store.len() != 1 {
+		t.Fatalf("Expected session in store, got %d entries", store.len())
+	}
+	if _, ok := store.data[storeKey(firstID)]; !ok {
+		t.Error("Expected session stored under a hash of its ID")
+	}
+
+	// Logging in renews the ID, so the pre-login cookie no longer works
+	rec = roundTrip(m, cookie, func(w http.ResponseWriter, r *http.Request) {
+		Get(r).Login("user-1")
+	})
+	loggedIn := sessionCookie(rec)
+	if loggedIn == nil || loggedIn.Value == cookie.Value {
+		t.Fatal("Expected a new cookie on login")
+	}
+	if store.len() != 1 {
+		t.Errorf("Expected the old session to be deleted, got %d entries", store.len())
+	}
+	roundTrip(m, cookie, func(w http.ResponseWriter, r *http.Request) {
+		if Get(r).UserID() != "" {
+			t.Error("Expected pre-login cookie not to reach the logged-in session")
+		}
+	})
+	roundTrip(m, loggedIn, func(w http.ResponseWriter, r *http.Request) {
+		if Get(r).UserID() != "user-1" || Get(r).GetString("cart") != "3 items" {
+			t.Errorf("Expected logged-in session with values kept, got %v", Get(r).rec.Values)
+		}
+		Get(r).Destroy()
+	})
+
+	if store.len() != 0 {
+		t.Errorf("Expected destroyed session to be deleted, got %d entries", store.len())
+	}
+}
+
+func TestDestroyExpiresCookie(t *testing.T) {
+	m := newTestManager(t)
+	rec := roundTrip(m, nil, func(w http.ResponseWriter, r *http.Request) {
+		Get(r).Set("k", "v")
+	})
+
+	rec = roundTrip(m, sessionCookie(rec), func(w http.ResponseWriter, r *http.Request) {
+		Get(r).Destroy()
+	})
+	if cookie := sessionCookie(rec); cookie == nil || cookie.MaxAge >= 0 {
+		t.Errorf("Expected an expired cookie, got %+v", cookie)
+	}
+}
+
+func TestStoreUnavailable(t *testing.T) {
+	store := newMemoryStore()
+	m := newTestManager(t, WithStore(store))
+	rec := roundTrip(m, nil, func(w http.ResponseWriter, r *http.Request) {
+		Get(r).Set("k", "v")
+	})
+
+	store.err = errors.New("connection refused")
+	rec = roundTrip(m, sessionCookie(rec), func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected handler not to run without a session")
+	})
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503, got %d", rec.Code)
+	}
+}
+
+func TestCookieTooLarge(t *testing.T) {
+	m := newTestManager(t)
+	s := newSession()
+	s.Set("big", strings.Repeat("x", maxCookieSize))
+
+	err := m.Save(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), s)
+	if !errors.Is(err, ErrCookieTooLarge) {
+		t.Errorf("Expected ErrCookieTooLarge, got %v", err)
+	}
+}
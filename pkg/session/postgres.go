@@ -0,0 +1,102 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+	"github.com/lib/pq"
+)
+
+// PostgresStore keeps sessions in a PostgreSQL table
+type PostgresStore struct {
+	db    database.Database
+	table string
+}
+
+// NewPostgresStore creates a store using table, "sessions" when empty
+func NewPostgresStore(db database.Database, table string) *PostgresStore {
+	if table == "" {
+		table = "sessions"
+	}
+	return &PostgresStore{db: db, table: table}
+}
+
+// Migration returns the migration that creates the sessions table, for use with database.Migrate. Pick a
+// version that fits the service's own migrations.
+func (s *PostgresStore) Migration(version int64) database.Migration {
+	table := pq.QuoteIdentifier(s.table)
+	index := pq.QuoteIdentifier(s.table + "_expires_at")
+
+	up := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id TEXT PRIMARY KEY,
+	data BYTEA NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (expires_at);`, table, index)
+
+	return database.Migration{
+		Version: version,
+		Name:    "create " + s.table,
+		Up:      up,
+		Down:    "DROP TABLE IF EXISTS " + table,
+	}
+}
+
+// Load returns the session data, or ErrNotFound when there is none or it has expired
+func (s *PostgresStore) Load(ctx context.Context, id string) ([]byte, error) {
+	db := s.db.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+
+	var data []byte
+	query := "SELECT data FROM " + pq.QuoteIdentifier(s.table) + " WHERE id = $1 AND expires_at > now()"
+	err := db.QueryRowContext(ctx, query, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Save inserts or replaces the session data
+func (s *PostgresStore) Save(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	db := s.db.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection is closed")
+	}
+
+	query := "INSERT INTO " + pq.QuoteIdentifier(s.table) + ` (id, data, expires_at) VALUES ($1, $2, $3)
+ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, expires_at = EXCLUDED.expires_at`
+	_, err := db.ExecContext(ctx, query, id, data, time.Now().Add(ttl))
+	return err
+}
+
+// Delete removes the session
+func (s *PostgresStore) Delete(ctx context.Context, id string) error {
+	db := s.db.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection is closed")
+	}
+
+	_, err := db.ExecContext(ctx, "DELETE FROM "+pq.QuoteIdentifier(s.table)+" WHERE id = $1", id)
+	return err
+}
+
+// DeleteExpired removes expired sessions, returning how many were deleted. Run it periodically, for
+// example as a scheduled job, to keep the table small.
+func (s *PostgresStore) DeleteExpired(ctx context.Context) (int64, error) {
+	db := s.db.GetDB()
+	if db == nil {
+		return 0, fmt.Errorf("database connection is closed")
+	}
+
+	result, err := db.ExecContext(ctx, "DELETE FROM "+pq.QuoteIdentifier(s.table)+" WHERE expires_at <= now()")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
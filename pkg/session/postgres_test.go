@@ -0,0 +1,54 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+)
+
+func TestPostgresStoreMigration(t *testing.T) {
+	db := database.NewPostgreSQL(database.NewConfig())
+
+	if got := NewPostgresStore(db, "").table; got != "sessions" {
+		t.Errorf("Expected default table sessions, got %s", got)
+	}
+
+	migration := NewPostgresStore(db, "web_sessions").Migration(7)
+	if migration.Version != 7 {
+		t.Errorf("Expected version 7, got %d", migration.Version)
+	}
+	for _, want := range []string{
+		`CREATE TABLE IF NOT EXISTS "web_sessions"`,
+		"data BYTEA NOT NULL",
+		`CREATE INDEX IF NOT EXISTS "web_sessions_expires_at"`,
+	} {
+		if !strings.Contains(migration.Up, want) {
+			t.Errorf("Expected migration to contain %q, got:\n%s", want, migration.Up)
+		}
+	}
+	if migration.Down != `DROP TABLE IF EXISTS "web_sessions"` {
+		t.Errorf("Unexpected down migration: %s", migration.Down)
+	}
+}
+
+func TestPostgresStoreNotConnected(t *testing.T) {
+	store := NewPostgresStore(database.NewPostgreSQL(database.NewConfig()), "")
+	ctx := context.Background()
+
+	if _, err := store.Load(ctx, "abc"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a connection error from Load, got %v", err)
+	}
+	if err := store.Save(ctx, "abc", []byte("{}"), time.Minute); err == nil {
+		t.Error("Expected Save to fail without a connection")
+	}
+	if err := store.Delete(ctx, "abc"); err == nil {
+		t.Error("Expected Delete to fail without a connection")
+	}
+	if _, err := store.DeleteExpired(ctx); err == nil {
+		t.Error("Expected DeleteExpired to fail without a connection")
+	}
+}
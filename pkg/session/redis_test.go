@@ -0,0 +1,43 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// unreachableClient points at a port where nothing listens so commands fail fast
+func unreachableClient(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestRedisStoreKey(t *testing.T) {
+	client := unreachableClient(t)
+
+	if got := NewRedisStore(client, "shop").key("abc"); got != "shop:session:abc" {
+		t.Errorf("Expected prefixed key, got %s", got)
+	}
+	if got := NewRedisStore(client, "").key("abc"); got != "session:abc" {
+		t.Errorf("Expected default namespace, got %s", got)
+	}
+}
+
+func TestRedisStoreUnreachable(t *testing.T) {
+	ctx := context.Background()
+	store := NewRedisStore(unreachableClient(t), "")
+
+	if _, err := store.Load(ctx, "abc"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a connection error rather than not found, got %v", err)
+	}
+	if err := store.Save(ctx, "abc", []byte("{}"), time.Minute); err == nil {
+		t.Error("Expected Save to fail")
+	}
+	if err := store.Delete(ctx, "abc"); err == nil {
+		t.Error("Expected Delete to fail")
+	}
+}
@@ -0,0 +1,314 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/state"
+)
+
+// ErrNotFound is returned by a Store when it has no session for an ID, or it has expired
+var ErrNotFound = errors.New("session not found")
+
+// userIDKey is the session value Login stores the user ID under
+const userIDKey = "userId"
+
+// Store keeps session data on the server, so the cookie only carries the session ID. Stores are given a
+// hash of the ID, so their contents can't be used to hijack sessions.
+type Store interface {
+	// Load returns the data saved for id, or ErrNotFound
+	Load(ctx context.Context, id string) ([]byte, error)
+	// Save stores data for id, expiring it after ttl
+	Save(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	// Delete removes the session, if it exists
+	Delete(ctx context.Context, id string) error
+}
+
+// Config holds session configuration
+type Config struct {
+	CookieName string
+	// Keys encrypt and authenticate cookies with AES-GCM, and must be 16, 24 or 32 bytes. The first key
+	// seals new cookies; the rest are still accepted, so keys can be rotated without logging everyone out.
+	Keys [][]byte
+	// Store keeps sessions on the server; when nil the whole session is kept in the cookie
+	Store Store
+	Codec state.Codec
+	// IdleTimeout ends a session that hasn't been used for this long
+	IdleTimeout time.Duration
+	// AbsoluteTimeout ends a session this long after it was created, however active it is
+	AbsoluteTimeout time.Duration
+
+	CookiePath   string
+	CookieDomain string
+	Secure       bool
+	SameSite     http.SameSite
+
+	CSRFHeader string
+	CSRFField  string
+}
+
+// DefaultConfig provides sensible defaults
+func DefaultConfig() *Config {
+	return &Config{
+		CookieName:      "session",
+		Codec:           state.JSONCodec{},
+		IdleTimeout:     30 * time.Minute,
+		AbsoluteTimeout: 24 * time.Hour,
+		CookiePath:      "/",
+		Secure:          true,
+		SameSite:        http.SameSiteLaxMode,
+		CSRFHeader:      "X-CSRF-Token",
+		CSRFField:       "csrf_token",
+	}
+}
+
+// Option is a functional option for configuring sessions
+type Option func(*Config)
+
+// WithCookieName sets the name of the session cookie
+func WithCookieName(name string) Option {
+	return func(config *Config) {
+		config.CookieName = name
+	}
+}
+
+// WithKeys sets the cookie keys, newest first
+func WithKeys(keys ...[]byte) Option {
+	return func(config *Config) {
+		config.Keys = keys
+	}
+}
+
+// WithStore keeps sessions in store instead of the cookie
+func WithStore(store Store) Option {
+	return func(config *Config) {
+		config.Store = store
+	}
+}
+
+// WithCodec sets how session data is serialized
+func WithCodec(codec state.Codec) Option {
+	return func(config *Config) {
+		config.Codec = codec
+	}
+}
+
+// WithIdleTimeout sets how long an unused session lasts
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(config *Config) {
+		config.IdleTimeout = timeout
+	}
+}
+
+// WithAbsoluteTimeout sets the maximum lifetime of a session
+func WithAbsoluteTimeout(timeout time.Duration) Option {
+	return func(config *Config) {
+		config.AbsoluteTimeout = timeout
+	}
+}
+
+// WithCookiePath sets the path the cookie is sent for
+func WithCookiePath(path string) Option {
+	return func(config *Config) {
+		config.CookiePath = path
+	}
+}
+
+// WithCookieDomain sets the domain the cookie is sent for
+func WithCookieDomain(domain string) Option {
+	return func(config *Config) {
+		config.CookieDomain = domain
+	}
+}
+
+// WithSecure sets whether the cookie is only sent over HTTPS; disable it for local development over HTTP
+func WithSecure(secure bool) Option {
+	return func(config *Config) {
+		config.Secure = secure
+	}
+}
+
+// WithSameSite sets the cookie's SameSite attribute
+func WithSameSite(sameSite http.SameSite) Option {
+	return func(config *Config) {
+		config.SameSite = sameSite
+	}
+}
+
+// WithCSRFHeader sets the request header checked for the CSRF token
+func WithCSRFHeader(header string) Option {
+	return func(config *Config) {
+		config.CSRFHeader = header
+	}
+}
+
+// WithCSRFField sets the form field checked for the CSRF token
+func WithCSRFField(field string) Option {
+	return func(config *Config) {
+		config.CSRFField = field
+	}
+}
+
+// NewConfig creates a new session config with options
+func NewConfig(options ...Option) *Config {
+	config := DefaultConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// record is the serialized form of a session
+type record struct {
+	ID        string                 `json:"id"`
+	Values    map[string]interface{} `json:"values"`
+	CSRFToken string                 `json:"csrfToken,omitempty"`
+	CreatedAt time.Time              `json:"createdAt"`
+	LastSeen  time.Time              `json:"lastSeen"`
+}
+
+// Session holds the values for one user's session. It is safe for concurrent use.
+type Session struct {
+	mu        sync.Mutex
+	rec       record
+	isNew     bool
+	modified  bool
+	destroyed bool
+	// previousID is the ID replaced by RenewID, deleted from the store on save
+	previousID string
+}
+
+// newSession starts an empty session
+func newSession() *Session {
+	now := time.Now()
+	return &Session{
+		rec:   record{ID: rand.Text(), Values: make(map[string]interface{}), CreatedAt: now, LastSeen: now},
+		isNew: true,
+	}
+}
+
+// ID returns the session ID
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rec.ID
+}
+
+// IsNew reports whether the session was started by this request
+func (s *Session) IsNew() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isNew
+}
+
+// CreatedAt returns when the session started
+func (s *Session) CreatedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rec.CreatedAt
+}
+
+// Get returns the value stored under key. With the default JSON codec, numbers come back as float64.
+func (s *Session) Get(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.rec.Values[key]
+	return value, ok
+}
+
+// GetString returns the string stored under key, or "" if there is none
+func (s *Session) GetString(key string) string {
+	value, _ := s.Get(key)
+	str, _ := value.(string)
+	return str
+}
+
+// Set stores a value under key
+func (s *Session) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rec.Values[key] = value
+	s.modified = true
+}
+
+// Delete removes the value stored under key
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rec.Values[key]; ok {
+		delete(s.rec.Values, key)
+		s.modified = true
+	}
+}
+
+// UserID returns the user ID stored by Login, or "" for an anonymous session
+func (s *Session) UserID() string {
+	return s.GetString(userIDKey)
+}
+
+// Login records the user ID and renews the session, so an ID planted before login can't be used to
+// ride the logged-in session
+func (s *Session) Login(userID string) {
+	s.RenewID()
+	s.Set(userIDKey, userID)
+}
+
+// RenewID gives the session a new ID and CSRF token, keeping its values. Call it whenever the user's
+// privileges change, such as on login; Login does so itself.
+func (s *Session) RenewID() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isNew && s.previousID == "" {
+		s.previousID = s.rec.ID
+	}
+	s.rec.ID = rand.Text()
+	s.rec.CSRFToken = ""
+	s.modified = true
+}
+
+// Destroy ends the session, removing it from the store and expiring the cookie
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.destroyed = true
+	s.modified = true
+	s.rec.Values = make(map[string]interface{})
+}
+
+// expired reports whether the session has passed its idle or absolute timeout at now
+func (r *record) expired(config *Config, now time.Time) bool {
+	return now.Sub(r.LastSeen) > config.IdleTimeout || now.Sub(r.CreatedAt) > config.AbsoluteTimeout
+}
+
+// expiresAt returns when the session ends if it isn't used again
+func (r *record) expiresAt(config *Config) time.Time {
+	idle := r.LastSeen.Add(config.IdleTimeout)
+	absolute := r.CreatedAt.Add(config.AbsoluteTimeout)
+	if idle.Before(absolute) {
+		return idle
+	}
+	return absolute
+}
+
+type contextKey struct{}
+
+// WithSession returns a copy of ctx carrying the session
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the session added by the middleware, if any
+func FromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(contextKey{}).(*Session)
+	return s, ok
+}
+
+// Get returns the request's session, or nil when the middleware isn't installed
+func Get(r *http.Request) *Session {
+	s, _ := FromContext(r.Context())
+	return s
+}
@@ -0,0 +1,135 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/state"
+)
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if config.CookieName != "session" || !config.Secure || config.SameSite != http.SameSiteLaxMode {
+		t.Errorf("Expected secure Lax session cookie by default, got %+v", config)
+	}
+	if config.IdleTimeout != 30*time.Minute || config.AbsoluteTimeout != 24*time.Hour {
+		t.Errorf("Expected 30m idle and 24h absolute timeouts, got %s and %s", config.IdleTimeout,
+			config.AbsoluteTimeout)
+	}
+
+	store := NewRedisStore(nil, "")
+	config = NewConfig(
+		WithCookieName("sid"),
+		WithKeys([]byte("k1"), []byte("k2")),
+		WithStore(store),
+		WithCodec(state.GobCodec{}),
+		WithIdleTimeout(time.Minute),
+		WithAbsoluteTimeout(time.Hour),
+		WithCookiePath("/app"),
+		WithCookieDomain("example.com"),
+		WithSecure(false),
+		WithSameSite(http.SameSiteStrictMode),
+		WithCSRFHeader("X-XSRF-Token"),
+		WithCSRFField("_csrf"),
+	)
+	if config.CookieName != "sid" || len(config.Keys) != 2 || config.Store != store {
+		t.Errorf("Expected cookie name, keys and store to be set, got %+v", config)
+	}
+	if _, ok := config.Codec.(state.GobCodec); !ok {
+		t.Errorf("Expected gob codec, got %T", config.Codec)
+	}
+	if config.IdleTimeout != time.Minute || config.AbsoluteTimeout != time.Hour {
+		t.Errorf("Expected timeouts to be overridden, got %+v", config)
+	}
+	if config.CookiePath != "/app" || config.CookieDomain != "example.com" || config.Secure ||
+		config.SameSite != http.SameSiteStrictMode {
+		t.Errorf("Expected cookie attributes to be overridden, got %+v", config)
+	}
+	if config.CSRFHeader != "X-XSRF-Token" || config.CSRFField != "_csrf" {
+		t.Errorf("Expected CSRF names to be overridden, got %+v", config)
+	}
+}
+
+func TestSessionValues(t *testing.T) {
+	s := newSession()
+	if !s.IsNew() || s.ID() == "" {
+		t.Fatalf("Expected a new session with an ID")
+	}
+
+	s.Set("theme", "dark")
+	if got := s.GetString("theme"); got != "dark" {
+		t.Errorf("Expected dark, got %q", got)
+	}
+	if _, ok := s.Get("missing"); ok {
+		t.Error("Expected missing value to be absent")
+	}
+
+	s.Delete("theme")
+	if _, ok := s.Get("theme"); ok {
+		t.Error("Expected value to be deleted")
+	}
+}
+
+func TestSessionLogin(t *testing.T) {
+	s := &Session{rec: record{ID: "planted", Values: map[string]interface{}{"cart": "3 items"}}}
+	csrf := s.CSRFToken()
+
+	s.Login("user-1")
+
+	if s.ID() == "planted" {
+		t.Error("Expected login to renew the session ID")
+	}
+	if s.previousID != "planted" {
+		t.Errorf("Expected old ID to be queued for deletion, got %q", s.previousID)
+	}
+	if s.CSRFToken() == csrf {
+		t.Error("Expected login to rotate the CSRF token")
+	}
+	if s.UserID() != "user-1" || s.GetString("cart") != "3 items" {
+		t.Errorf("Expected user ID set and values kept, got %v", s.rec.Values)
+	}
+}
+
+func TestRecordExpiry(t *testing.T) {
+	config := NewConfig(WithIdleTimeout(time.Minute), WithAbsoluteTimeout(time.Hour))
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		created time.Time
+		seen    time.Time
+		want    bool
+	}{
+		{"active", now.Add(-10 * time.Minute), now.Add(-10 * time.Second), false},
+		{"idle", now.Add(-10 * time.Minute), now.Add(-2 * time.Minute), true},
+		{"past absolute timeout", now.Add(-2 * time.Hour), now, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &record{CreatedAt: tt.created, LastSeen: tt.seen}
+			if got := rec.expired(config, now); got != tt.want {
+				t.Errorf("expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	rec := &record{CreatedAt: now.Add(-59 * time.Minute), LastSeen: now}
+	if got := rec.expiresAt(config); !got.Equal(rec.CreatedAt.Add(time.Hour)) {
+		t.Errorf("Expected absolute timeout to cap expiry, got %s", got)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("Expected no session in an empty context")
+	}
+
+	s := newSession()
+	got, ok := FromContext(WithSession(context.Background(), s))
+	if !ok || got != s {
+		t.Error("Expected session from context")
+	}
+}
@@ -0,0 +1,116 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileStore keeps snapshots as files in a local directory, suitable for a single instance or a
+// pod with a persistent volume
+type FileStore struct {
+	dir string
+}
+
+// fileSnapshot is the on-disk format, recording when the data stops being useful
+type fileSnapshot struct {
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+	Data      []byte    `json:"data"`
+}
+
+// NewFileStore creates a store in dir, which is created with mode 0700 if missing. The directory must
+// be owned by the current user and not accessible by anyone else, or Save and Load fail, since
+// whoever can write a snapshot controls the state restored from it.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// checkDir creates the directory if needed and checks that only the current user can use it
+func (s *FileStore) checkDir() error {
+	if s.dir == "" {
+		return errors.New("state directory is not set")
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	info, err := os.Lstat(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to check state directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("state directory %s is not a directory", s.dir)
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		return fmt.Errorf("state directory %s must not be accessible by other users, has mode %s", s.dir,
+			info.Mode().Perm())
+	}
+	return checkOwner(s.dir, info)
+}
+
+// Save writes the snapshot atomically, so a crash mid-write never leaves a corrupt file
+func (s *FileStore) Save(_ context.Context, key string, data []byte, ttl time.Duration) error {
+	if err := s.checkDir(); err != nil {
+		return err
+	}
+
+	snapshot := fileSnapshot{Data: data}
+	if ttl > 0 {
+		snapshot.ExpiresAt = time.Now().Add(ttl)
+	}
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".snapshot-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), s.path(key))
+}
+
+// Load reads a snapshot, returning ErrNotFound when the file is missing or expired
+func (s *FileStore) Load(_ context.Context, key string) ([]byte, error) {
+	if err := s.checkDir(); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot fileSnapshot
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return nil, fmt.Errorf("corrupt snapshot file: %w", err)
+	}
+	if !snapshot.ExpiresAt.IsZero() && time.Now().After(snapshot.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+
+	return snapshot.Data, nil
+}
+
+// path maps a key to a file name, keeping keys from escaping the directory
+func (s *FileStore) path(key string) string {
+	name := strings.NewReplacer("/", "_", `\`, "_", "..", "_").Replace(key)
+	return filepath.Join(s.dir, name+".snapshot")
+}
@@ -0,0 +1,93 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "state")
+	store := NewFileStore(dir)
+
+	tests := []struct {
+		name    string
+		key     string
+		ttl     time.Duration
+		wantErr error
+	}{
+		{"no expiry", "tokens", 0, nil},
+		{"within ttl", "limits", time.Minute, nil},
+		{"expired", "stale", time.Nanosecond, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := store.Save(ctx, tt.key, []byte("data"), tt.ttl); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+			time.Sleep(time.Millisecond)
+
+			data, err := store.Load(ctx, tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && string(data) != "data" {
+				t.Errorf("Expected saved data, got %q", data)
+			}
+		})
+	}
+
+	if _, err := store.Load(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for missing key, got %v", err)
+	}
+}
+
+func TestFileStoreKeysStayInDirectory(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileStore(filepath.Join(dir, "state"))
+
+	if err := store.Save(context.Background(), "../escape", []byte("x"), 0); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "escape.snapshot")); err == nil {
+		t.Error("Expected key not to escape the state directory")
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "state"))
+	if len(entries) != 1 {
+		t.Errorf("Expected one snapshot file and no temp files, got %d entries", len(entries))
+	}
+}
+
+func TestFileStoreDirectoryChecks(t *testing.T) {
+	ctx := context.Background()
+
+	if err := NewFileStore("").Save(ctx, "tokens", []byte("x"), 0); err == nil {
+		t.Error("Expected an empty directory to be rejected")
+	}
+
+	shared := filepath.Join(t.TempDir(), "shared")
+	if err := os.Mkdir(shared, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Chmod(shared, 0o777)
+	if err := NewFileStore(shared).Save(ctx, "tokens", []byte("x"), 0); err == nil {
+		t.Error("Expected a directory other users can write to be rejected")
+	}
+	if _, err := NewFileStore(shared).Load(ctx, "tokens"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected Load from a shared directory to fail, got %v", err)
+	}
+
+	created := filepath.Join(t.TempDir(), "state")
+	if err := NewFileStore(created).Save(ctx, "tokens", []byte("x"), 0); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if info, _ := os.Stat(created); info.Mode().Perm() != 0o700 {
+		t.Errorf("Expected the directory to be created with mode 0700, got %s", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package state
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkOwner checks that the current user owns the directory
+func checkOwner(dir string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if ok && int(stat.Uid) != os.Geteuid() {
+		return fmt.Errorf("state directory %s is owned by uid %d, not the current user", dir, stat.Uid)
+	}
+	return nil
+}
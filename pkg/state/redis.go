@@ -0,0 +1,41 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps snapshots in Redis, so a replacement instance can pick up where the old one left off
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a store that namespaces its keys with prefix, e.g. the service name
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Save stores the snapshot, letting Redis expire it after ttl
+func (s *RedisStore) Save(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, s.key(key), data, ttl).Err()
+}
+
+// Load returns the snapshot, or ErrNotFound when Redis has none
+func (s *RedisStore) Load(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.client.Get(ctx, s.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *RedisStore) key(key string) string {
+	if s.prefix == "" {
+		return "state:" + key
+	}
+	return s.prefix + ":state:" + key
+}
@@ -0,0 +1,19 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisStoreKey(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	defer func() { _ = client.Close() }()
+
+	if got := NewRedisStore(client, "orders").key("jwt-cache"); got != "orders:state:jwt-cache" {
+		t.Errorf("Expected prefixed key, got %s", got)
+	}
+	if got := NewRedisStore(client, "").key("jwt-cache"); got != "state:jwt-cache" {
+		t.Errorf("Expected default namespace, got %s", got)
+	}
+}
@@ -0,0 +1,175 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned by Load when there is no snapshot for a key, or it has expired
+var ErrNotFound = errors.New("state snapshot not found")
+
+// ErrInvalidSignature is returned by Load when a signed snapshot was not written with the signing key
+var ErrInvalidSignature = errors.New("state snapshot signature is invalid")
+
+// ErrNoStore is returned by Save and Load when no Store is configured
+var ErrNoStore = errors.New("no state store configured")
+
+// Store persists snapshots of in-memory state, so it survives a restart
+type Store interface {
+	// Save stores data under key; a zero ttl keeps it until overwritten
+	Save(ctx context.Context, key string, data []byte, ttl time.Duration) error
+	// Load returns the data saved under key, or ErrNotFound
+	Load(ctx context.Context, key string) ([]byte, error)
+}
+
+// Codec serializes snapshots for a Store
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec serializes snapshots as JSON, the default
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec serializes snapshots with encoding/gob, which is more compact for large snapshots
+type GobCodec struct{}
+
+func init() {
+	// Decoded JSON values such as JWT claims hold these inside interface{} fields
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+}
+
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// Config holds configuration for saving and loading snapshots
+type Config struct {
+	Store Store
+	Codec Codec
+	// TTL bounds how long a snapshot is useful; older ones are ignored on load
+	TTL time.Duration
+	// SigningKey, when set, authenticates snapshots with HMAC-SHA256, so a snapshot written by anyone
+	// without the key is rejected on load
+	SigningKey []byte
+}
+
+// DefaultConfig provides sensible defaults. There is no default store; set one with WithStore.
+func DefaultConfig() *Config {
+	return &Config{
+		Codec: JSONCodec{},
+		TTL:   10 * time.Minute,
+	}
+}
+
+// Option is a functional option for configuring snapshots
+type Option func(*Config)
+
+// WithStore sets where snapshots are kept
+func WithStore(store Store) Option {
+	return func(config *Config) {
+		config.Store = store
+	}
+}
+
+// WithCodec sets how snapshots are serialized
+func WithCodec(codec Codec) Option {
+	return func(config *Config) {
+		config.Codec = codec
+	}
+}
+
+// WithTTL sets how long a snapshot is useful
+func WithTTL(ttl time.Duration) Option {
+	return func(config *Config) {
+		config.TTL = ttl
+	}
+}
+
+// WithSigningKey authenticates snapshots with key, which should be a secret of at least 32 bytes
+func WithSigningKey(key []byte) Option {
+	return func(config *Config) {
+		config.SigningKey = key
+	}
+}
+
+// NewConfig creates a new snapshot config with options
+func NewConfig(options ...Option) *Config {
+	config := DefaultConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Save serializes v with the codec and saves it under key
+func (c *Config) Save(ctx context.Context, key string, v interface{}) error {
+	if c.Store == nil {
+		return ErrNoStore
+	}
+	data, err := c.Codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s snapshot: %w", key, err)
+	}
+	if c.SigningKey != nil {
+		data = append(c.mac(key, data), data...)
+	}
+	if err := c.Store.Save(ctx, key, data, c.TTL); err != nil {
+		return fmt.Errorf("failed to save %s snapshot: %w", key, err)
+	}
+	return nil
+}
+
+// Load reads the snapshot saved under key into v. It returns ErrNotFound when there is none.
+func (c *Config) Load(ctx context.Context, key string, v interface{}) error {
+	if c.Store == nil {
+		return ErrNoStore
+	}
+	data, err := c.Store.Load(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to load %s snapshot: %w", key, err)
+	}
+	if c.SigningKey != nil {
+		if len(data) < sha256.Size || !hmac.Equal(data[:sha256.Size], c.mac(key, data[sha256.Size:])) {
+			return fmt.Errorf("failed to load %s snapshot: %w", key, ErrInvalidSignature)
+		}
+		data = data[sha256.Size:]
+	}
+	if err := c.Codec.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s snapshot: %w", key, err)
+	}
+	return nil
+}
+
+// mac authenticates data saved under key, so a snapshot can't be moved to another key either
+func (c *Config) mac(key string, data []byte) []byte {
+	h := hmac.New(sha256.New, c.SigningKey)
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write(data)
+	return h.Sum(nil)
+}
@@ -0,0 +1,103 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type sample struct {
+	Name   string
+	Claims map[string]interface{}
+}
+
+func TestCodecs(t *testing.T) {
+	in := sample{Name: "limits", Claims: map[string]interface{}{"aud": []interface{}{"api"}, "exp": 1.5}}
+
+	for name, codec := range map[string]Codec{"json": JSONCodec{}, "gob": GobCodec{}} {
+		t.Run(name, func(t *testing.T) {
+			data, err := codec.Marshal(in)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+
+			var out sample
+			if err := codec.Unmarshal(data, &out); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if !reflect.DeepEqual(in, out) {
+				t.Errorf("Expected %+v, got %+v", in, out)
+			}
+		})
+	}
+}
+
+func TestConfigSaveLoad(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileStore(filepath.Join(t.TempDir(), "state"))
+	config := NewConfig(WithStore(store), WithCodec(GobCodec{}), WithTTL(time.Minute))
+
+	if err := config.Save(ctx, "sample", sample{Name: "saved"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	var out sample
+	if err := config.Load(ctx, "sample", &out); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if out.Name != "saved" {
+		t.Errorf("Expected saved snapshot, got %+v", out)
+	}
+
+	if err := config.Load(ctx, "missing", &out); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestConfigSigningKey(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileStore(filepath.Join(t.TempDir(), "state"))
+	config := NewConfig(WithStore(store), WithSigningKey([]byte("0123456789abcdef0123456789abcdef")))
+
+	if err := config.Save(ctx, "sample", sample{Name: "signed"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	var out sample
+	if err := config.Load(ctx, "sample", &out); err != nil || out.Name != "signed" {
+		t.Fatalf("Expected signed snapshot to load, got %+v %v", out, err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"tampered", []byte(`{"Name":"forged"}`)},
+		{"too short", []byte("x")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = store.Save(ctx, "sample", tt.data, 0)
+			if err := config.Load(ctx, "sample", &out); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Expected ErrInvalidSignature, got %v", err)
+			}
+		})
+	}
+
+	// A snapshot signed for one key is not accepted under another
+	_ = config.Save(ctx, "other", sample{Name: "moved"})
+	data, _ := store.Load(ctx, "other")
+	_ = store.Save(ctx, "sample", data, 0)
+	if err := config.Load(ctx, "sample", &out); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected a moved snapshot to be rejected, got %v", err)
+	}
+}
+
+func TestConfigWithoutStore(t *testing.T) {
+	config := NewConfig()
+	if err := config.Save(context.Background(), "sample", sample{}); !errors.Is(err, ErrNoStore) {
+		t.Errorf("Expected ErrNoStore, got %v", err)
+	}
+}
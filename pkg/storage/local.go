@@ -0,0 +1,338 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/crypto"
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// tempPrefix marks partly written uploads, which List skips
+const tempPrefix = ".upload-"
+
+// Local keeps objects as files under a directory, for development, tests, and single-instance services.
+// Content types aren't stored; they are detected from the key's extension or the content when read.
+type Local struct {
+	dir    string
+	config *Config
+}
+
+// NewLocal creates a store in dir, creating the directory if needed
+func NewLocal(dir string, options ...Option) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &Local{dir: dir, config: NewConfig(options...)}, nil
+}
+
+// path maps a key to its file, rejecting keys outside the directory
+func (l *Local) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes the object atomically, so readers never see a partial file and a failed upload leaves
+// any previous object in place
+func (l *Local) Put(_ context.Context, key string, r io.Reader, options ...PutOption) (*Object, error) {
+	file, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	body, contentType, err := l.config.prepare(key, r, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", key, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), tempPrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file for %s: %w", key, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	size, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", key, err)
+	}
+
+	return &Object{Key: key, Size: size, ContentType: contentType, LastModified: time.Now().UTC()}, nil
+}
+
+// Get opens the object's file
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, *Object, error) {
+	file, err := l.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", key, err)
+	}
+
+	object, err := l.describe(key, f)
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+	return f, object, nil
+}
+
+// Stat describes the object's file
+func (l *Local) Stat(ctx context.Context, key string) (*Object, error) {
+	f, object, err := l.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	_ = f.Close()
+	return object, nil
+}
+
+// describe reads an open file's size and content type, leaving it positioned at the start
+func (l *Local) describe(key string, f *os.File) (*Object, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", key, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+
+	return &Object{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  DetectContentType(key, head[:n]),
+		LastModified: info.ModTime().UTC(),
+	}, nil
+}
+
+// Delete removes the object's file
+func (l *Local) Delete(_ context.Context, key string) error {
+	file, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// List walks the directory for files whose keys start with prefix. Content types are left empty.
+func (l *Local) List(_ context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(l.dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasPrefix(entry.Name(), tempPrefix) {
+			return err
+		}
+
+		rel, err := filepath.Rel(l.dir, file)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Key: key, Size: info.Size(), LastModified: info.ModTime().UTC()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %q: %w", prefix, err)
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// PresignGet returns a signed URL for the store's Handler to serve the object
+func (l *Local) PresignGet(_ context.Context, key string, expiry time.Duration) (string, error) {
+	return l.presign(http.MethodGet, key, expiry)
+}
+
+// PresignPut returns a signed URL for the store's Handler to accept an upload to key
+func (l *Local) PresignPut(_ context.Context, key string, expiry time.Duration) (string, error) {
+	return l.presign(http.MethodPut, key, expiry)
+}
+
+func (l *Local) presign(method, key string, expiry time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	if l.config.BaseURL == "" || len(l.config.SigningKey) == 0 {
+		return "", fmt.Errorf("presigned URLs need a base URL and signing key, set with WithPresigning")
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {l.sign(method, key, expires)},
+	}
+	return l.config.BaseURL + "/" + escapeKey(key) + "?" + query.Encode(), nil
+}
+
+func (l *Local) sign(method, key, expires string) string {
+	return crypto.SignHMAC(l.config.SigningKey, []byte(method+"\n"+key+"\n"+expires))
+}
+
+// escapeKey escapes each segment of a key for use in a URL path
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// Handler serves presigned URLs: GET and HEAD download an object and PUT uploads one. Mount it at the
+// base URL set with WithPresigning, stripping the mount path, e.g.
+// r.Mount("/files", http.StripPrefix("/files", store.Handler())).
+func (l *Local) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		method := r.Method
+		if method == http.MethodHead {
+			method = http.MethodGet
+		}
+		if method != http.MethodGet && method != http.MethodPut {
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			problem.New("method-not-allowed", "Method Not Allowed", http.StatusMethodNotAllowed,
+				"presigned URLs accept GET, HEAD, and PUT", r.URL.Path).Respond(w, r)
+			return
+		}
+
+		if err := l.verify(method, key, r.URL.Query()); err != nil {
+			problem.New("invalid-signature", "Invalid Signature", http.StatusForbidden, err.Error(),
+				r.URL.Path).Respond(w, r)
+			return
+		}
+
+		if method == http.MethodPut {
+			l.serveUpload(w, r, key)
+			return
+		}
+		l.serveDownload(w, r, key)
+	})
+}
+
+// verify checks a presigned URL's signature and expiry
+func (l *Local) verify(method, key string, query url.Values) error {
+	if len(l.config.SigningKey) == 0 {
+		return fmt.Errorf("presigned URLs are not enabled")
+	}
+	expires := query.Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !crypto.VerifyHMAC(l.config.SigningKey, []byte(method+"\n"+key+"\n"+expires),
+		query.Get("signature")) {
+		return fmt.Errorf("the URL signature is invalid")
+	}
+	if time.Now().Unix() > unix {
+		return fmt.Errorf("the URL has expired")
+	}
+	return nil
+}
+
+func (l *Local) serveDownload(w http.ResponseWriter, r *http.Request, key string) {
+	f, object, err := l.Get(r.Context(), key)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	w.Header().Set("Content-Type", object.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if !inlineSafe(object.ContentType) {
+		// Anything a browser could run, such as HTML or SVG, is downloaded rather than rendered on our origin
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment",
+			map[string]string{"filename": path.Base(key)}))
+		w.Header().Set("Content-Security-Policy", "sandbox")
+	}
+	http.ServeContent(w, r, path.Base(key), object.LastModified, f.(io.ReadSeeker))
+}
+
+// inlineTypes are the content types safe to render inline, because browsers don't run script in them
+var inlineTypes = map[string]bool{
+	"text/plain": true,
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+	"image/avif": true,
+	"audio/mpeg": true,
+	"audio/ogg":  true,
+	"video/mp4":  true,
+	"video/webm": true,
+}
+
+// inlineSafe reports whether content of contentType can be served inline
+func inlineSafe(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && inlineTypes[mediaType]
+}
+
+func (l *Local) serveUpload(w http.ResponseWriter, r *http.Request, key string) {
+	var options []PutOption
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		options = append(options, WithContentType(contentType))
+	}
+
+	if _, err := l.Put(r.Context(), key, r.Body, options...); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// respondError maps storage errors to problem responses
+func respondError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		problem.New("not-found", "Not Found", http.StatusNotFound, err.Error(), r.URL.Path).Respond(w, r)
+	case errors.Is(err, ErrInvalidKey):
+		problem.New("invalid-key", "Invalid Key", http.StatusBadRequest, err.Error(), r.URL.Path).Respond(w, r)
+	case errors.Is(err, ErrTooLarge):
+		problem.New("too-large", "Payload Too Large", http.StatusRequestEntityTooLarge, err.Error(),
+			r.URL.Path).Respond(w, r)
+	default:
+		problem.Wrap(http.StatusInternalServerError, "storage", r.URL.Path, err).Respond(w, r)
+	}
+}
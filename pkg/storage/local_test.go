@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocal(t *testing.T, options ...Option) *Local {
+	t.Helper()
+	store, err := NewLocal(t.TempDir(), options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestLocalPutGet(t *testing.T) {
+	store := newTestLocal(t)
+	ctx := context.Background()
+
+	object, err := store.Put(ctx, "users/42/notes.txt", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if object.Size != 5 || object.ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("Unexpected object: %+v", object)
+	}
+
+	r, object, err := store.Get(ctx, "users/42/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close() }()
+	content, _ := io.ReadAll(r)
+	if string(content) != "hello" || object.Size != 5 || object.ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("Expected the stored content, got %q and %+v", content, object)
+	}
+
+	if _, err := store.Stat(ctx, "users/42"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a directory not to be an object, got %v", err)
+	}
+	if _, _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := store.Put(ctx, "../escape", strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestLocalPutTooLarge(t *testing.T) {
+	store := newTestLocal(t, WithMaxSize(10))
+	ctx := context.Background()
+
+	if _, err := store.Put(ctx, "a.txt", strings.NewReader("small")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Put(ctx, "a.txt", strings.NewReader(strings.Repeat("x", 11))); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected ErrTooLarge, got %v", err)
+	}
+
+	r, _, err := store.Get(ctx, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close() }()
+	if content, _ := io.ReadAll(r); string(content) != "small" {
+		t.Errorf("Expected the previous object to be kept, got %q", content)
+	}
+
+	entries, _ := os.ReadDir(store.dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected no partial upload left behind, got %d files", len(entries))
+	}
+}
+
+func TestLocalListDelete(t *testing.T) {
+	store := newTestLocal(t)
+	ctx := context.Background()
+	for _, key := range []string{"b/2.txt", "a/1.txt", "b/1.txt"} {
+		if _, err := store.Put(ctx, key, strings.NewReader(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(store.dir, "b", tempPrefix+"123"), []byte("partial"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := store.List(ctx, "b/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[0].Key != "b/1.txt" || objects[1].Key != "b/2.txt" || objects[0].Size != 7 {
+		t.Errorf("Expected b/1.txt and b/2.txt, got %+v", objects)
+	}
+
+	if err := store.Delete(ctx, "b/1.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "b/1.txt"); err != nil {
+		t.Errorf("Expected deleting a missing object to succeed, got %v", err)
+	}
+	if objects, _ := store.List(ctx, ""); len(objects) != 2 {
+		t.Errorf("Expected 2 objects left, got %+v", objects)
+	}
+}
+
+func TestLocalPresignRequiresConfig(t *testing.T) {
+	if _, err := newTestLocal(t).PresignGet(context.Background(), "a.txt", time.Minute); err == nil {
+		t.Error("Expected presigning without WithPresigning to fail")
+	}
+}
+
+func TestLocalHandler(t *testing.T) {
+	store := newTestLocal(t, WithPresigning("http://files.test/files", []byte("secret")))
+	server := httptest.NewServer(http.StripPrefix("/files", store.Handler()))
+	defer server.Close()
+	ctx := context.Background()
+
+	// presignedPath signs for the store's base URL and returns the path and query to request from server
+	presignedPath := func(method, key string, expiry time.Duration) string {
+		var signed string
+		var err error
+		if method == http.MethodPut {
+			signed, err = store.PresignPut(ctx, key, expiry)
+		} else {
+			signed, err = store.PresignGet(ctx, key, expiry)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, _ := url.Parse(signed)
+		return server.URL + u.RequestURI()
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, presignedPath(http.MethodPut, "docs/a b.txt", time.Minute),
+		strings.NewReader("uploaded"))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected upload to succeed, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(presignedPath(http.MethodGet, "docs/a b.txt", time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "uploaded" {
+		t.Errorf("Expected the uploaded content, got %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get("X-Content-Type-Options") != "nosniff" {
+		t.Error("Expected downloads to disable sniffing")
+	}
+	if resp.Header.Get("Content-Disposition") != "" {
+		t.Errorf("Expected plain text to be served inline, got %q", resp.Header.Get("Content-Disposition"))
+	}
+
+	if _, err := store.Put(ctx, "docs/page.html", strings.NewReader("<html><script>alert(1)</script>")); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.Get(presignedPath(http.MethodGet, "docs/page.html", time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.Header.Get("Content-Disposition") != `attachment; filename=page.html` ||
+		resp.Header.Get("Content-Security-Policy") != "sandbox" {
+		t.Errorf("Expected HTML to be downloaded in a sandbox, got %q and %q",
+			resp.Header.Get("Content-Disposition"), resp.Header.Get("Content-Security-Policy"))
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{"missing object", http.MethodGet, presignedPath(http.MethodGet, "missing.txt", time.Minute),
+			http.StatusNotFound},
+		{"expired", http.MethodGet, presignedPath(http.MethodGet, "docs/a b.txt", -time.Minute),
+			http.StatusForbidden},
+		{"upload signature used to download", http.MethodGet,
+			presignedPath(http.MethodPut, "docs/a b.txt", time.Minute), http.StatusForbidden},
+		{"other key", http.MethodGet, strings.Replace(presignedPath(http.MethodGet, "docs/a b.txt", time.Minute),
+			"a%20b", "c", 1), http.StatusForbidden},
+		{"unsigned", http.MethodGet, server.URL + "/files/docs/a%20b.txt", http.StatusForbidden},
+		{"delete", http.MethodDelete, presignedPath(http.MethodGet, "docs/a b.txt", time.Minute),
+			http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.target, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// S3 keeps objects in a bucket of S3 or an S3-compatible service such as MinIO or Cloudflare R2
+type S3 struct {
+	client  *s3.Client
+	presign *s3.PresignClient
+	bucket  string
+	config  *Config
+}
+
+// NewS3 creates a store for bucket using client. For S3-compatible services, create the client with
+// their endpoint as BaseEndpoint, and usually UsePathStyle.
+func NewS3(client *s3.Client, bucket string, options ...Option) *S3 {
+	return &S3{client: client, presign: s3.NewPresignClient(client), bucket: bucket, config: NewConfig(options...)}
+}
+
+// Put uploads the object. Content up to PartSize is sent in one request; larger content is streamed as
+// a multipart upload, holding one part in memory at a time.
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, options ...PutOption) (*Object, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	body, contentType, err := s.config.prepare(key, r, options)
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := readPart(body, s.config.PartSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	if int64(len(first)) < s.config.PartSize {
+		return s.putSingle(ctx, key, contentType, first)
+	}
+	return s.putMultipart(ctx, key, contentType, first, body)
+}
+
+func (s *S3) putSingle(ctx context.Context, key, contentType string, content []byte) (*Object, error) {
+	out, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(content),
+		ContentLength: aws.Int64(int64(len(content))),
+		ContentType:   aws.String(contentType),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+
+	return &Object{Key: key, Size: int64(len(content)), ContentType: contentType, ETag: aws.ToString(out.ETag),
+		LastModified: time.Now().UTC()}, nil
+}
+
+func (s *S3) putMultipart(ctx context.Context, key, contentType string, first []byte, body io.Reader) (*Object,
+	error) {
+	created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:            aws.String(s.bucket),
+		Key:               aws.String(key),
+		ContentType:       aws.String(contentType),
+		ChecksumAlgorithm: types.ChecksumAlgorithmCrc32,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start upload of %s: %w", key, err)
+	}
+
+	parts, size, err := s.uploadParts(ctx, key, created.UploadId, first, body)
+	if err == nil {
+		var out *s3.CompleteMultipartUploadOutput
+		out, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(s.bucket),
+			Key:             aws.String(key),
+			UploadId:        created.UploadId,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+		if err == nil {
+			return &Object{Key: key, Size: size, ContentType: contentType, ETag: aws.ToString(out.ETag),
+				LastModified: time.Now().UTC()}, nil
+		}
+	}
+
+	// Abort even if ctx was cancelled, so the parts don't linger and cost storage
+	_, _ = s.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: created.UploadId,
+	})
+	return nil, fmt.Errorf("failed to upload %s: %w", key, err)
+}
+
+// uploadParts sends part after part until body is exhausted, returning the completed parts and total size
+func (s *S3) uploadParts(ctx context.Context, key string, uploadID *string, part []byte,
+	body io.Reader) ([]types.CompletedPart, int64, error) {
+	var parts []types.CompletedPart
+	var size int64
+
+	for number := int32(1); len(part) > 0; number++ {
+		out, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:            aws.String(s.bucket),
+			Key:               aws.String(key),
+			UploadId:          uploadID,
+			PartNumber:        aws.Int32(number),
+			Body:              bytes.NewReader(part),
+			ContentLength:     aws.Int64(int64(len(part))),
+			ChecksumAlgorithm: types.ChecksumAlgorithmCrc32,
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("part %d: %w", number, err)
+		}
+		parts = append(parts, types.CompletedPart{
+			ETag:          out.ETag,
+			PartNumber:    aws.Int32(number),
+			ChecksumCRC32: out.ChecksumCRC32,
+		})
+		size += int64(len(part))
+
+		if part, err = readPart(body, s.config.PartSize); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return parts, size, nil
+}
+
+// minPartBuffer is the buffer readPart starts with, so a small object doesn't cost a whole part
+const minPartBuffer = 64 << 10
+
+// readPart reads up to size bytes, returning fewer only at the end of r. Its buffer doubles as content
+// arrives, up to size.
+func readPart(r io.Reader, size int64) ([]byte, error) {
+	part := make([]byte, 0, min(size, minPartBuffer))
+	for int64(len(part)) < size {
+		if len(part) == cap(part) {
+			part = slices.Grow(part, int(min(size, 2*int64(cap(part))))-len(part))
+		}
+		n, err := r.Read(part[len(part):min(int64(cap(part)), size)])
+		part = part[:len(part)+n]
+		if errors.Is(err, io.EOF) {
+			return part, nil
+		}
+		if err != nil {
+			return part, err
+		}
+	}
+	return part, nil
+}
+
+// Get downloads the object
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	if err := validateKey(key); err != nil {
+		return nil, nil, err
+	}
+
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, nil, s.wrap("download", key, err)
+	}
+
+	return out.Body, &Object{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		ContentType:  aws.ToString(out.ContentType),
+		ETag:         aws.ToString(out.ETag),
+		LastModified: aws.ToTime(out.LastModified).UTC(),
+	}, nil
+}
+
+// Stat describes the object with a HEAD request
+func (s *S3) Stat(ctx context.Context, key string) (*Object, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, s.wrap("stat", key, err)
+	}
+
+	return &Object{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		ContentType:  aws.ToString(out.ContentType),
+		ETag:         aws.ToString(out.ETag),
+		LastModified: aws.ToTime(out.LastModified).UTC(),
+	}, nil
+}
+
+// Delete removes the object
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(key)})
+	if err != nil {
+		return s.wrap("delete", key, err)
+	}
+	return nil
+}
+
+// List pages through the objects under prefix. Content types are left empty.
+func (s *S3) List(ctx context.Context, prefix string) ([]Object, error) {
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	var objects []Object
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %q: %w", prefix, err)
+		}
+		for _, item := range page.Contents {
+			objects = append(objects, Object{
+				Key:          aws.ToString(item.Key),
+				Size:         aws.ToInt64(item.Size),
+				ETag:         aws.ToString(item.ETag),
+				LastModified: aws.ToTime(item.LastModified).UTC(),
+			})
+		}
+	}
+
+	return objects, nil
+}
+
+// PresignGet returns a SigV4-signed URL that downloads the object
+func (s *S3) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+
+	req, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(s.bucket),
+		Key: aws.String(key)}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download of %s: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+// PresignPut returns a SigV4-signed URL that uploads the object with a PUT request
+func (s *S3) PresignPut(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+
+	req, err := s.presign.PresignPutObject(ctx, &s3.PutObjectInput{Bucket: aws.String(s.bucket),
+		Key: aws.String(key)}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign upload of %s: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+// wrap adds context to an S3 error, mapping missing objects to ErrNotFound
+func (s *S3) wrap(action, key string, err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "NoSuchKey" || apiErr.ErrorCode() == "NotFound") {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return fmt.Errorf("failed to %s %s: %w", action, key, err)
+}
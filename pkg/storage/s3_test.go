@@ -0,0 +1,297 @@
+package storage
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3 serves the path-style S3 requests the store makes, keeping objects in memory
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	types    map[string]string
+	uploads  map[string]map[string][]byte
+	aborted  int
+	requests []string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}, types: map[string]string{}, uploads: map[string]map[string][]byte{}}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	query := r.URL.Query()
+	f.requests = append(f.requests, r.Method+" "+r.URL.RawQuery)
+
+	switch {
+	case r.Method == http.MethodGet && query.Get("list-type") == "2":
+		f.list(w, query.Get("prefix"))
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.uploads["upload-1"] = map[string][]byte{}
+		f.types[key] = r.Header.Get("Content-Type")
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key>"+
+			"<UploadId>upload-1</UploadId></InitiateMultipartUploadResult>", key)
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		body, _ := io.ReadAll(r.Body)
+		f.uploads[query.Get("uploadId")][query.Get("partNumber")] = body
+		w.Header().Set("ETag", `"part-`+query.Get("partNumber")+`"`)
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		f.complete(w, key, query.Get("uploadId"))
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		f.aborted++
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.object(w, r, key)
+	}
+}
+
+func (f *fakeS3) object(w http.ResponseWriter, r *http.Request, key string) {
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"etag-1"`)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet, http.MethodHead:
+		body, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				fmt.Fprint(w, "<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>")
+			}
+			return
+		}
+		w.Header().Set("Content-Type", f.types[key])
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		w.Header().Set("ETag", `"etag-1"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(body)
+		}
+	}
+}
+
+func (f *fakeS3) complete(w http.ResponseWriter, key, uploadID string) {
+	parts := f.uploads[uploadID]
+	var content []byte
+	for i := 1; i <= len(parts); i++ {
+		content = append(content, parts[fmt.Sprint(i)]...)
+	}
+	f.objects[key] = content
+	delete(f.uploads, uploadID)
+	fmt.Fprintf(w, "<CompleteMultipartUploadResult><Key>%s</Key><ETag>\"etag-2\"</ETag>"+
+		"</CompleteMultipartUploadResult>", key)
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix string) {
+	type content struct {
+		Key  string `xml:"Key"`
+		Size int    `xml:"Size"`
+	}
+	result := struct {
+		XMLName  xml.Name  `xml:"ListBucketResult"`
+		Contents []content `xml:"Contents"`
+	}{}
+	for key, body := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			result.Contents = append(result.Contents, content{Key: key, Size: len(body)})
+		}
+	}
+	sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func newTestS3(t *testing.T, handler http.Handler, options ...Option) *S3 {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "key", SecretAccessKey: "secret"}, nil
+		}),
+	})
+	return NewS3(client, "bucket", options...)
+}
+
+func TestS3PutGet(t *testing.T) {
+	fake := newFakeS3()
+	store := newTestS3(t, fake)
+	ctx := context.Background()
+
+	object, err := store.Put(ctx, "users/42/notes.txt", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if object.Size != 5 || object.ContentType != "text/plain; charset=utf-8" || object.ETag != `"etag-1"` {
+		t.Errorf("Unexpected object: %+v", object)
+	}
+	if string(fake.objects["users/42/notes.txt"]) != "hello" {
+		t.Errorf("Expected the content to be uploaded in one request, got %q", fake.objects["users/42/notes.txt"])
+	}
+
+	r, object, err := store.Get(ctx, "users/42/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close() }()
+	content, _ := io.ReadAll(r)
+	if string(content) != "hello" || object.Size != 5 || object.ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("Expected the stored content, got %q and %+v", content, object)
+	}
+
+	if object, err := store.Stat(ctx, "users/42/notes.txt"); err != nil || object.Size != 5 {
+		t.Errorf("Expected Stat to describe the object, got %+v and %v", object, err)
+	}
+	if _, _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected Get of a missing object to return ErrNotFound, got %v", err)
+	}
+	if _, err := store.Stat(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected Stat of a missing object to return ErrNotFound, got %v", err)
+	}
+}
+
+func TestS3PutMultipart(t *testing.T) {
+	fake := newFakeS3()
+	store := newTestS3(t, fake, WithPartSize(1024))
+	content := strings.Repeat("0123456789", 250)
+
+	object, err := store.Put(context.Background(), "big.bin", strings.NewReader(content),
+		WithContentType("application/octet-stream"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if object.Size != int64(len(content)) || object.ETag != `"etag-2"` {
+		t.Errorf("Unexpected object: %+v", object)
+	}
+	if string(fake.objects["big.bin"]) != content {
+		t.Errorf("Expected the parts to be joined into the object, got %d bytes", len(fake.objects["big.bin"]))
+	}
+
+	parts := 0
+	for _, request := range fake.requests {
+		if strings.HasPrefix(request, http.MethodPut) && strings.Contains(request, "partNumber") {
+			parts++
+		}
+	}
+	if parts != 3 {
+		t.Errorf("Expected 3 parts, got %d", parts)
+	}
+}
+
+func TestReadPart(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		size    int64
+		want    string
+	}{
+		{"empty", "", 1 << 20, ""},
+		{"shorter than a part", "hello", 1 << 20, "hello"},
+		{"exactly a part", "hello", 5, "hello"},
+		{"longer than a part", "hello world", 5, "hello"},
+		{"several buffer sizes", strings.Repeat("x", 3*minPartBuffer), 1 << 20, strings.Repeat("x", 3*minPartBuffer)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			part, err := readPart(strings.NewReader(tt.content), tt.size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(part) != tt.want {
+				t.Errorf("Expected %d bytes, got %d", len(tt.want), len(part))
+			}
+			if len(tt.want) < minPartBuffer && cap(part) > minPartBuffer {
+				t.Errorf("Expected a small read to keep a small buffer, got capacity %d", cap(part))
+			}
+		})
+	}
+}
+
+func TestS3PutMultipartAbort(t *testing.T) {
+	fake := newFakeS3()
+	store := newTestS3(t, fake, WithPartSize(1024), WithMaxSize(1500))
+
+	_, err := store.Put(context.Background(), "big.bin", strings.NewReader(strings.Repeat("x", 2000)))
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected ErrTooLarge, got %v", err)
+	}
+	if fake.aborted != 1 || len(fake.uploads) != 0 {
+		t.Errorf("Expected the multipart upload to be aborted, got %d aborts", fake.aborted)
+	}
+	if _, ok := fake.objects["big.bin"]; ok {
+		t.Error("Expected no object to be stored")
+	}
+}
+
+func TestS3ListDelete(t *testing.T) {
+	fake := newFakeS3()
+	store := newTestS3(t, fake)
+	ctx := context.Background()
+	for _, key := range []string{"b/2.txt", "a/1.txt", "b/1.txt"} {
+		if _, err := store.Put(ctx, key, strings.NewReader(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	objects, err := store.List(ctx, "b/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[0].Key != "b/1.txt" || objects[1].Key != "b/2.txt" || objects[0].Size != 7 {
+		t.Errorf("Expected b/1.txt and b/2.txt, got %+v", objects)
+	}
+
+	if err := store.Delete(ctx, "b/1.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.objects["b/1.txt"]; ok {
+		t.Error("Expected the object to be deleted")
+	}
+}
+
+func TestS3Presign(t *testing.T) {
+	store := newTestS3(t, http.NotFoundHandler())
+	ctx := context.Background()
+
+	get, err := store.PresignGet(ctx, "docs/a.txt", 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(get, "/bucket/docs/a.txt?") || !strings.Contains(get, "X-Amz-Expires=900") ||
+		!strings.Contains(get, "X-Amz-Signature=") {
+		t.Errorf("Expected a SigV4 presigned URL, got %s", get)
+	}
+
+	if _, err := store.PresignPut(ctx, "docs/a.txt", time.Minute); err != nil {
+		t.Errorf("Expected PresignPut to succeed, got %v", err)
+	}
+	if _, err := store.PresignGet(ctx, "../a.txt", time.Minute); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+}
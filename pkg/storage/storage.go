@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when no object has the given key
+	ErrNotFound = errors.New("object not found")
+	// ErrTooLarge is returned by Put when the content is larger than the size limit
+	ErrTooLarge = errors.New("object too large")
+	// ErrInvalidKey is returned for keys that are empty, absolute, or step outside the store
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Object describes a stored object
+type Object struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"contentType,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// Blob stores objects under slash-separated keys
+type Blob interface {
+	// Put streams r to key, replacing any object there
+	Put(ctx context.Context, key string, r io.Reader, options ...PutOption) (*Object, error)
+	// Get opens the object at key; the caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// Stat describes the object at key without reading it
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Delete removes the object at key; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// List describes the objects whose keys start with prefix, in key order
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// PresignGet returns a URL that downloads the object without credentials until expiry
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// PresignPut returns a URL that uploads the object with a PUT request without credentials until expiry
+	PresignPut(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// Config holds configuration shared by the store implementations
+type Config struct {
+	// MaxSize is the largest object Put accepts by default; zero means no limit
+	MaxSize int64
+	// PartSize is the size of the parts large S3 uploads are sent in, bounding the memory each upload uses
+	PartSize int64
+	// BaseURL and SigningKey sign the local store's presigned URLs, which its Handler serves
+	BaseURL    string
+	SigningKey []byte
+}
+
+// DefaultConfig provides sensible defaults
+func DefaultConfig() *Config {
+	return &Config{
+		PartSize: 8 << 20,
+	}
+}
+
+// Option is a functional option for configuring stores
+type Option func(*Config)
+
+// WithMaxSize sets the largest object Put accepts by default
+func WithMaxSize(size int64) Option {
+	return func(config *Config) {
+		config.MaxSize = size
+	}
+}
+
+// WithPartSize sets the part size for large S3 uploads; S3 requires at least 5 MiB
+func WithPartSize(size int64) Option {
+	return func(config *Config) {
+		config.PartSize = size
+	}
+}
+
+// WithPresigning sets the base URL the local store's Handler is mounted at, and the key its presigned URLs
+// are signed with
+func WithPresigning(baseURL string, signingKey []byte) Option {
+	return func(config *Config) {
+		config.BaseURL = strings.TrimSuffix(baseURL, "/")
+		config.SigningKey = signingKey
+	}
+}
+
+// NewConfig creates a new storage config with options
+func NewConfig(options ...Option) *Config {
+	config := DefaultConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// PutConfig holds per-object options for Put
+type PutConfig struct {
+	// ContentType is detected from the content and key when empty
+	ContentType string
+	// MaxSize overrides the store's size limit when positive
+	MaxSize int64
+}
+
+// PutOption is a functional option for a single Put
+type PutOption func(*PutConfig)
+
+// WithContentType sets the object's content type instead of detecting it
+func WithContentType(contentType string) PutOption {
+	return func(config *PutConfig) {
+		config.ContentType = contentType
+	}
+}
+
+// WithLimit sets the size limit for this object
+func WithLimit(size int64) PutOption {
+	return func(config *PutConfig) {
+		config.MaxSize = size
+	}
+}
+
+// prepare applies put options to r, detecting the content type from its first bytes and limiting its size.
+// The returned reader yields the whole content.
+func (c *Config) prepare(key string, r io.Reader, options []PutOption) (io.Reader, string, error) {
+	put := &PutConfig{MaxSize: c.MaxSize}
+	for _, option := range options {
+		option(put)
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, "", fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	head = head[:n]
+
+	contentType := put.ContentType
+	if contentType == "" {
+		contentType = DetectContentType(key, head)
+	}
+
+	body := io.MultiReader(bytes.NewReader(head), r)
+	if put.MaxSize > 0 {
+		body = &limitedReader{r: body, remaining: put.MaxSize, limit: put.MaxSize}
+	}
+	return body, contentType, nil
+}
+
+// DetectContentType sniffs the content type from the first bytes of a file, falling back to its name's
+// extension when the content isn't recognized
+func DetectContentType(name string, head []byte) string {
+	sniffed := http.DetectContentType(head)
+	if sniffed != "application/octet-stream" {
+		return sniffed
+	}
+	if byExtension := mime.TypeByExtension(path.Ext(name)); byExtension != "" {
+		return byExtension
+	}
+	return sniffed
+}
+
+// limitedReader fails with ErrTooLarge once more than limit bytes are read
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	limit     int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, l.tooLarge()
+	}
+	// Read one byte past the limit, to tell content of exactly the limit from longer content
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, l.tooLarge()
+	}
+	return n, err
+}
+
+func (l *limitedReader) tooLarge() error {
+	return fmt.Errorf("%w: the limit is %d bytes", ErrTooLarge, l.limit)
+}
+
+// validateKey rejects keys that are empty, absolute, or have empty, "." or ".." segments
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
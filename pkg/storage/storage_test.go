@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if config.MaxSize != 0 || config.PartSize != 8<<20 {
+		t.Errorf("Unexpected defaults: %+v", config)
+	}
+
+	config = NewConfig(WithMaxSize(100), WithPartSize(5<<20), WithPresigning("https://example.com/files/", []byte("k")))
+	if config.MaxSize != 100 || config.PartSize != 5<<20 {
+		t.Errorf("Expected sizes to be overridden, got %+v", config)
+	}
+	if config.BaseURL != "https://example.com/files" || string(config.SigningKey) != "k" {
+		t.Errorf("Expected presigning with the trailing slash trimmed, got %+v", config)
+	}
+}
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		head []byte
+		want string
+	}{
+		{"sniffed png", "avatar", []byte("\x89PNG\r\n\x1a\n"), "image/png"},
+		{"sniff beats extension", "avatar.txt", []byte("\x89PNG\r\n\x1a\n"), "image/png"},
+		{"extension when unrecognized", "data.json", []byte{0x00, 0x01}, "application/json"},
+		{"text", "notes", []byte("hello"), "text/plain; charset=utf-8"},
+		{"unknown", "blob", []byte{0x00, 0x01}, "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectContentType(tt.key, tt.head); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	content := strings.Repeat("a", 1000)
+
+	body, contentType, err := NewConfig().prepare("notes", strings.NewReader(content), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(body)
+	if string(got) != content || contentType != "text/plain; charset=utf-8" {
+		t.Errorf("Expected the whole content as text, got %d bytes of %q", len(got), contentType)
+	}
+
+	_, contentType, err = NewConfig().prepare("notes", strings.NewReader(content),
+		[]PutOption{WithContentType("text/markdown")})
+	if err != nil || contentType != "text/markdown" {
+		t.Errorf("Expected the given content type, got %q and %v", contentType, err)
+	}
+}
+
+func TestPrepareLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		config  *Config
+		options []PutOption
+		wantErr bool
+	}{
+		{"no limit", 2000, NewConfig(), nil, false},
+		{"under limit", 99, NewConfig(WithMaxSize(100)), nil, false},
+		{"exactly limit", 100, NewConfig(WithMaxSize(100)), nil, false},
+		{"over limit", 101, NewConfig(WithMaxSize(100)), nil, true},
+		{"over limit past sniffing", 1000, NewConfig(WithMaxSize(600)), nil, true},
+		{"per-object limit", 101, NewConfig(), []PutOption{WithLimit(100)}, true},
+		{"per-object override", 150, NewConfig(WithMaxSize(100)), []PutOption{WithLimit(200)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _, err := tt.config.prepare("k", bytes.NewReader(make([]byte, tt.size)), tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(body)
+			if tt.wantErr != errors.Is(err, ErrTooLarge) {
+				t.Errorf("Expected too large %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && len(got) != tt.size {
+				t.Errorf("Expected %d bytes, got %d", tt.size, len(got))
+			}
+		})
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"avatar.png", true},
+		{"users/42/avatar.png", true},
+		{"", false},
+		{"/etc/passwd", false},
+		{"../secret", false},
+		{"users/../../secret", false},
+		{"users/./avatar.png", false},
+		{"users//avatar.png", false},
+		{"users/", false},
+		{`users\avatar.png`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			err := validateKey(tt.key)
+			if tt.valid != (err == nil) {
+				t.Errorf("Expected valid %v, got %v", tt.valid, err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Expected ErrInvalidKey, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package tenant
+
+import "context"
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx carrying the caller's tenant ID. api.TenantMiddleware sets it for
+// requests and events consumers restore it from messages; database query stats, repositories,
+// published events, and request logs read it.
+func WithID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenantID)
+}
+
+// FromContext returns the tenant ID set with WithID, reporting whether there is one
+func FromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(contextKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// ID returns the tenant ID set with WithID, or "" when there is none
+func ID(ctx context.Context) string {
+	tenantID, _ := FromContext(ctx)
+	return tenantID
+}
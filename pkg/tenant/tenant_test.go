@@ -0,0 +1,32 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		want   string
+		wantOK bool
+	}{
+		{"set", WithID(context.Background(), "acme"), "acme", true},
+		{"not set", context.Background(), "", false},
+		{"empty", WithID(context.Background(), ""), "", false},
+		{"overridden", WithID(WithID(context.Background(), "acme"), "globex"), "globex", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FromContext(tt.ctx)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Expected %q %v, got %q %v", tt.want, tt.wantOK, got, ok)
+			}
+			if ID(tt.ctx) != tt.want {
+				t.Errorf("Expected ID %q, got %q", tt.want, ID(tt.ctx))
+			}
+		})
+	}
+}
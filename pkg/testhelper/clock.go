@@ -0,0 +1,17 @@
+package testhelper
+
+import (
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
+)
+
+// NewFakeClock returns a clock that stands still at start until the test advances it. Pass it to
+// rate limiters, JWT validators, and schedulers with their WithClock options or Clock config fields.
+// A zero start means the current time.
+func NewFakeClock(start time.Time) *clock.Fake {
+	if start.IsZero() {
+		start = time.Now()
+	}
+	return clock.NewFake(start)
+}
@@ -0,0 +1,30 @@
+package testhelper
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewFakeClock(t *testing.T) {
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	clk := NewFakeClock(start)
+	issuer := NewJWTIssuer(t, WithIssuerClock(clk), WithTokenTTL(time.Hour))
+	validator := issuer.Validator(t)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	issuer.Authorize(t, req, nil)
+
+	if result := validator.ValidateRequest(req); !result.Valid {
+		t.Fatalf("Expected a token issued at the fake time to be valid, got %s", result.Error)
+	}
+
+	clk.Advance(2 * time.Hour)
+	if result := validator.ValidateRequest(req); result.Valid {
+		t.Error("Expected the token to expire as the clock moved")
+	}
+
+	if since := time.Since(NewFakeClock(time.Time{}).Now()); since < 0 || since > time.Second {
+		t.Errorf("Expected a zero start to mean now, got %s off", since)
+	}
+}
@@ -0,0 +1,120 @@
+package testhelper
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LookupJSONPath returns the value at path in a JSON document. Paths start at the root "$" and
+// select object members with .name or ["name"] and array elements with [index], so
+// "$.items[0].id" is the id of the first item. Values are decoded as by encoding/json, so
+// numbers are float64.
+func LookupJSONPath(body []byte, path string) (interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("response is not JSON: %w", err)
+	}
+
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	value := doc
+	for _, step := range steps {
+		if value, err = step.apply(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return value, nil
+}
+
+// jsonPathStep selects an object member by name, or an array element when index is set
+type jsonPathStep struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+// apply selects the step from value
+func (s jsonPathStep) apply(value interface{}) (interface{}, error) {
+	if s.isIndex {
+		array, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("[%d] applied to %T, not an array", s.index, value)
+		}
+		if s.index < 0 || s.index >= len(array) {
+			return nil, fmt.Errorf("index %d out of range for %d elements", s.index, len(array))
+		}
+		return array[s.index], nil
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("member %q applied to %T, not an object", s.name, value)
+	}
+	member, ok := object[s.name]
+	if !ok {
+		return nil, fmt.Errorf("no member %q", s.name)
+	}
+	return member, nil
+}
+
+// parseJSONPath splits a path into steps
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSON path %q must start with $", path)
+	}
+
+	var steps []jsonPathStep
+	rest := path[1:]
+	for rest != "" {
+		var step jsonPathStep
+		var err error
+		switch rest[0] {
+		case '.':
+			step, rest = parseJSONPathName(rest[1:])
+		case '[':
+			step, rest, err = parseJSONPathBracket(rest)
+		default:
+			err = fmt.Errorf("unexpected %q", rest)
+		}
+		if err == nil && !step.isIndex && step.name == "" {
+			err = fmt.Errorf("empty member name")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON path %q: %w", path, err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// parseJSONPathName reads a member name up to the next . or [
+func parseJSONPathName(rest string) (jsonPathStep, string) {
+	end := strings.IndexAny(rest, ".[")
+	if end < 0 {
+		end = len(rest)
+	}
+	return jsonPathStep{name: rest[:end]}, rest[end:]
+}
+
+// parseJSONPathBracket reads [index] or ["name"]
+func parseJSONPathBracket(rest string) (jsonPathStep, string, error) {
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return jsonPathStep{}, "", fmt.Errorf("unclosed [")
+	}
+	inner, rest := rest[1:end], rest[end+1:]
+
+	if name, err := strconv.Unquote(inner); err == nil {
+		return jsonPathStep{name: name}, rest, nil
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return jsonPathStep{}, "", fmt.Errorf("invalid index %q", inner)
+	}
+	return jsonPathStep{index: index, isIndex: true}, rest, nil
+}
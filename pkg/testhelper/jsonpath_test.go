@@ -0,0 +1,68 @@
+package testhelper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookupJSONPath(t *testing.T) {
+	body := []byte(`{"items":[{"id":"abc","tags":["a","b"]},{"id":"def"}],"total":2,"odd.key":true}`)
+
+	tests := []struct {
+		path    string
+		want    interface{}
+		wantErr bool
+	}{
+		{path: "$.items[0].id", want: "abc"},
+		{path: "$.items[1].id", want: "def"},
+		{path: "$.items[0].tags[1]", want: "b"},
+		{path: "$.total", want: float64(2)},
+		{path: `$["odd.key"]`, want: true},
+		{path: "$.items[0]", want: map[string]interface{}{"id": "abc", "tags": []interface{}{"a", "b"}}},
+		{path: "$.missing", wantErr: true},
+		{path: "$.items[2]", wantErr: true},
+		{path: "$.total[0]", wantErr: true},
+		{path: "$.items.id", wantErr: true},
+		{path: "items", wantErr: true},
+		{path: "$.", wantErr: true},
+		{path: "$.items[x]", wantErr: true},
+		{path: "$.items[0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := LookupJSONPath(body, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := LookupJSONPath([]byte("not json"), "$"); err == nil {
+		t.Error("Expected an error for a body that is not JSON")
+	}
+}
+
+func TestJSONEqual(t *testing.T) {
+	tests := []struct {
+		got      interface{}
+		expected interface{}
+		want     bool
+	}{
+		{float64(3), 3, true},
+		{float64(3), int64(3), true},
+		{float64(3), "3", false},
+		{[]interface{}{"a"}, []string{"a"}, true},
+		{map[string]interface{}{"n": float64(1)}, map[string]int{"n": 1}, true},
+		{nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		if got := jsonEqual(tt.got, tt.expected); got != tt.want {
+			t.Errorf("jsonEqual(%v, %v) = %v, want %v", tt.got, tt.expected, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package testhelper
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/Okja-Engineering/go-service-kit/pkg/clock"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTIssuerConfig holds configuration for a test token issuer
+type JWTIssuerConfig struct {
+	// ClientID is the default aud claim, and the client ID of validators from the issuer
+	ClientID string
+	// KeyID is the kid of the signing key
+	KeyID string
+	// Subject is the default sub claim
+	Subject string
+	// TokenTTL sets the default exp claim
+	TokenTTL time.Duration
+	// Clock stamps the default iat and exp claims, and is given to validators from the issuer
+	Clock clock.Clock
+}
+
+// DefaultJWTIssuerConfig provides sensible defaults
+func DefaultJWTIssuerConfig() *JWTIssuerConfig {
+	return &JWTIssuerConfig{
+		ClientID: "test-client",
+		KeyID:    "test-key",
+		Subject:  "test-user",
+		TokenTTL: 1 * time.Hour,
+		Clock:    clock.Real(),
+	}
+}
+
+// JWTIssuerOption is a functional option for configuring a test token issuer
+type JWTIssuerOption func(*JWTIssuerConfig)
+
+// WithClientID sets the audience of tokens and the client ID of validators
+func WithClientID(clientID string) JWTIssuerOption {
+	return func(config *JWTIssuerConfig) {
+		config.ClientID = clientID
+	}
+}
+
+// WithKeyID sets the kid of the signing key
+func WithKeyID(keyID string) JWTIssuerOption {
+	return func(config *JWTIssuerConfig) {
+		config.KeyID = keyID
+	}
+}
+
+// WithSubject sets the default subject of tokens
+func WithSubject(subject string) JWTIssuerOption {
+	return func(config *JWTIssuerConfig) {
+		config.Subject = subject
+	}
+}
+
+// WithTokenTTL sets how long tokens are valid by default
+func WithTokenTTL(ttl time.Duration) JWTIssuerOption {
+	return func(config *JWTIssuerConfig) {
+		config.TokenTTL = ttl
+	}
+}
+
+// WithIssuerClock sets the clock tokens are issued and validated by, e.g. from NewFakeClock
+func WithIssuerClock(c clock.Clock) JWTIssuerOption {
+	return func(config *JWTIssuerConfig) {
+		config.Clock = c
+	}
+}
+
+// NewJWTIssuerConfig creates a new issuer config with options
+func NewJWTIssuerConfig(options ...JWTIssuerOption) *JWTIssuerConfig {
+	config := DefaultJWTIssuerConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// JWTIssuer signs test tokens with a generated RSA key and serves its JWKS from an httptest
+// server, standing in for an identity provider
+type JWTIssuer struct {
+	config *JWTIssuerConfig
+	key    *rsa.PrivateKey
+	server *httptest.Server
+}
+
+// NewJWTIssuer creates an issuer with a fresh key; its JWKS server is closed when the test ends
+func NewJWTIssuer(t *testing.T, options ...JWTIssuerOption) *JWTIssuer {
+	t.Helper()
+	config := NewJWTIssuerConfig(options...)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate signing key: %v", err)
+	}
+
+	jwks, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": config.KeyID,
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode JWKS: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(ContentType, ApplicationJSON)
+		_, _ = w.Write(jwks)
+	}))
+	t.Cleanup(server.Close)
+
+	return &JWTIssuer{config: config, key: key, server: server}
+}
+
+// JWKSURL returns the URL serving the issuer's JWKS
+func (i *JWTIssuer) JWKSURL() string {
+	return i.server.URL
+}
+
+// Config returns a validator config trusting the issuer, for tests that need other settings
+func (i *JWTIssuer) Config() *auth.JWTConfig {
+	config := auth.DefaultJWTConfig()
+	config.ClientID = i.config.ClientID
+	config.JWKSURL = i.server.URL
+	config.Clock = i.config.Clock
+	return config
+}
+
+// Validator returns a validator trusting the issuer; it is closed when the test ends
+func (i *JWTIssuer) Validator(t *testing.T) *auth.JWTValidator {
+	t.Helper()
+	validator, err := auth.NewJWTValidator(i.Config())
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	t.Cleanup(validator.Close)
+	return validator
+}
+
+// Token returns a signed token valid for validators from the issuer. The sub, aud, iat, and exp
+// claims are set by default; claims overrides them, and a nil value removes one, so
+//
+//	issuer.Token(t, jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})
+//
+// is an expired token and
+//
+//	issuer.Token(t, jwt.MapClaims{"aud": nil})
+//
+// has no audience.
+func (i *JWTIssuer) Token(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	now := clock.OrReal(i.config.Clock).Now()
+	merged := jwt.MapClaims{
+		"sub": i.config.Subject,
+		"aud": i.config.ClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(i.config.TokenTTL).Unix(),
+	}
+	for name, value := range claims {
+		if value == nil {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, merged)
+	token.Header["kid"] = i.config.KeyID
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed
+}
+
+// Authorize sets a bearer token with claims on the request
+func (i *JWTIssuer) Authorize(t *testing.T, r *http.Request, claims jwt.MapClaims) {
+	t.Helper()
+	r.Header.Set("Authorization", "Bearer "+i.Token(t, claims))
+}
+
+// Headers returns an Authorization header with a token for claims, for TestCase.Headers
+func (i *JWTIssuer) Headers(t *testing.T, claims jwt.MapClaims) map[string]string {
+	t.Helper()
+	return map[string]string{"Authorization": "Bearer " + i.Token(t, claims)}
+}
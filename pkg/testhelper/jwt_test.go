@@ -0,0 +1,56 @@
+package testhelper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWTIssuer(t *testing.T) {
+	issuer := NewJWTIssuer(t, WithClientID("orders-api"), WithSubject("user-42"))
+	validator := issuer.Validator(t)
+
+	router := chi.NewRouter()
+	router.Get("/me", validator.Protect(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := auth.GetUserIDFromContext(r.Context())
+		_, _ = w.Write([]byte(`{"id":"` + userID + `"}`))
+	}))
+
+	Run(t, router, []TestCase{
+		{
+			Name: "valid token", URL: "/me", Method: "GET", Headers: issuer.Headers(t, nil),
+			CheckStatus: http.StatusOK, CheckBody: "user-42", CheckBodyCount: 1,
+		},
+		{
+			Name: "claims override defaults", URL: "/me", Method: "GET",
+			Headers:     issuer.Headers(t, jwt.MapClaims{"sub": "user-7"}),
+			CheckStatus: http.StatusOK, CheckBody: "user-7", CheckBodyCount: 1,
+		},
+		{
+			Name: "expired token", URL: "/me", Method: "GET",
+			Headers:     issuer.Headers(t, jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}),
+			CheckStatus: http.StatusUnauthorized,
+		},
+		{
+			Name: "missing audience", URL: "/me", Method: "GET", Headers: issuer.Headers(t, jwt.MapClaims{"aud": nil}),
+			CheckStatus: http.StatusUnauthorized,
+		},
+		{Name: "no token", URL: "/me", Method: "GET", CheckStatus: http.StatusUnauthorized},
+	})
+}
+
+func TestJWTIssuerUntrusted(t *testing.T) {
+	trusted := NewJWTIssuer(t)
+	other := NewJWTIssuer(t)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	other.Authorize(t, req, nil)
+	if result := trusted.Validator(t).ValidateRequest(req); result.Valid {
+		t.Error("Expected a token from another issuer to be rejected")
+	}
+}
@@ -0,0 +1,192 @@
+package testhelper
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// LoadScenario is a request fired repeatedly and concurrently by RunLoad, with the thresholds its
+// results must meet. Zero latency thresholds are not checked.
+type LoadScenario struct {
+	Name    string
+	Method  string
+	URL     string
+	Body    string
+	Headers map[string]string
+	// Requests is how many requests are sent in total; the default is 100
+	Requests int
+	// Concurrency is how many requests are in flight at once; the default is 10
+	Concurrency int
+	// IsError decides which statuses count as errors; the default is 400 and above
+	IsError func(status int) bool
+
+	// MaxErrorRate is the fraction of requests allowed to be errors; by default none are
+	MaxErrorRate float64
+	MaxP50       time.Duration
+	MaxP95       time.Duration
+	MaxP99       time.Duration
+}
+
+// LoadSpec is the scenarios run by RunLoad, one after another
+type LoadSpec struct {
+	Scenarios []LoadScenario
+}
+
+// LoadResult summarizes a scenario's requests
+type LoadResult struct {
+	Scenario string
+	Requests int
+	Errors   int
+	// ErrorRate is Errors as a fraction of Requests
+	ErrorRate float64
+	// Statuses counts the responses by status code
+	Statuses map[int]int
+	P50      time.Duration
+	P95      time.Duration
+	P99      time.Duration
+	Max      time.Duration
+	Mean     time.Duration
+	// Duration is the wall time of the scenario, and Throughput its requests per second
+	Duration   time.Duration
+	Throughput float64
+}
+
+// RunLoad fires each scenario's requests at handler from concurrent workers, as subtests, and
+// fails the subtest when the error rate or a latency percentile exceeds the scenario's thresholds.
+// The results are returned for further assertions, such as how many requests a rate limiter refused.
+func RunLoad(t *testing.T, handler http.Handler, spec LoadSpec) []LoadResult {
+	t.Helper()
+	results := make([]LoadResult, 0, len(spec.Scenarios))
+
+	for _, scenario := range spec.Scenarios {
+		t.Run(scenario.Name, func(t *testing.T) {
+			t.Helper()
+			result := runScenario(handler, scenario)
+			t.Logf("%d requests in %s (%.0f/s): %.1f%% errors, p50 %s, p95 %s, p99 %s, max %s",
+				result.Requests, result.Duration.Round(time.Millisecond), result.Throughput, result.ErrorRate*100,
+				result.P50, result.P95, result.P99, result.Max)
+			checkLoadThresholds(t, scenario, result)
+			results = append(results, result)
+		})
+	}
+
+	return results
+}
+
+// runScenario sends a scenario's requests and summarizes them
+func runScenario(handler http.Handler, scenario LoadScenario) LoadResult {
+	requests := scenario.Requests
+	if requests <= 0 {
+		requests = 100
+	}
+	concurrency := scenario.Concurrency
+	if concurrency <= 0 {
+		concurrency = 10
+	}
+	concurrency = min(concurrency, requests)
+	isError := scenario.IsError
+	if isError == nil {
+		isError = func(status int) bool { return status >= http.StatusBadRequest }
+	}
+
+	latencies := make([]time.Duration, requests)
+	statuses := make([]int, requests)
+	next := make(chan int)
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				latencies[i], statuses[i] = sendLoadRequest(handler, scenario)
+			}
+		}()
+	}
+	for i := range requests {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return summarizeLoad(scenario.Name, latencies, statuses, time.Since(start), isError)
+}
+
+// sendLoadRequest sends one request and returns its latency and status
+func sendLoadRequest(handler http.Handler, scenario LoadScenario) (time.Duration, int) {
+	req := httptest.NewRequest(scenario.Method, scenario.URL, strings.NewReader(scenario.Body))
+	for k, v := range scenario.Headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+
+	start := time.Now()
+	handler.ServeHTTP(rec, req)
+	return time.Since(start), rec.Code
+}
+
+// summarizeLoad computes the result of a scenario
+func summarizeLoad(name string, latencies []time.Duration, statuses []int, elapsed time.Duration,
+	isError func(int) bool) LoadResult {
+	result := LoadResult{
+		Scenario:   name,
+		Requests:   len(latencies),
+		Statuses:   make(map[int]int),
+		Duration:   elapsed,
+		Throughput: float64(len(latencies)) / elapsed.Seconds(),
+	}
+
+	var total time.Duration
+	for i, status := range statuses {
+		result.Statuses[status]++
+		if isError(status) {
+			result.Errors++
+		}
+		total += latencies[i]
+	}
+	result.ErrorRate = float64(result.Errors) / float64(result.Requests)
+	result.Mean = total / time.Duration(result.Requests)
+
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	result.P50 = percentile(sorted, 50)
+	result.P95 = percentile(sorted, 95)
+	result.P99 = percentile(sorted, 99)
+	result.Max = sorted[len(sorted)-1]
+	return result
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+// checkLoadThresholds reports each threshold the result exceeds
+func checkLoadThresholds(t *testing.T, scenario LoadScenario, result LoadResult) {
+	t.Helper()
+	if result.ErrorRate > scenario.MaxErrorRate {
+		t.Errorf("Error rate %.2f%% exceeds %.2f%%, statuses: %v", result.ErrorRate*100, scenario.MaxErrorRate*100,
+			result.Statuses)
+	}
+
+	for _, check := range []struct {
+		name      string
+		got, want time.Duration
+	}{
+		{"p50", result.P50, scenario.MaxP50},
+		{"p95", result.P95, scenario.MaxP95},
+		{"p99", result.P99, scenario.MaxP99},
+	} {
+		if check.want > 0 && check.got > check.want {
+			t.Errorf("%s latency %s exceeds %s", check.name, check.got, check.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package testhelper
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunLoad(t *testing.T) {
+	var served atomic.Int64
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Refuse every fourth request, like a rate limiter
+		if served.Add(1)%4 == 0 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if r.Header.Get("X-Test") != "load" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+
+	results := RunLoad(t, handler, LoadSpec{Scenarios: []LoadScenario{
+		{
+			Name:         "limited",
+			Method:       http.MethodGet,
+			URL:          "/orders",
+			Headers:      map[string]string{"X-Test": "load"},
+			Requests:     200,
+			Concurrency:  20,
+			MaxErrorRate: 0.25,
+			MaxP99:       time.Second,
+		},
+		{
+			Name:     "rate limit responses are not errors",
+			Method:   http.MethodGet,
+			URL:      "/orders",
+			Headers:  map[string]string{"X-Test": "load"},
+			Requests: 40,
+			IsError:  func(status int) bool { return status != http.StatusOK && status != http.StatusTooManyRequests },
+		},
+	}})
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	limited := results[0]
+	if limited.Requests != 200 || limited.Errors != 50 || limited.Statuses[http.StatusTooManyRequests] != 50 {
+		t.Errorf("Unexpected result %+v", limited)
+	}
+	if limited.P50 > limited.P95 || limited.P95 > limited.P99 || limited.P99 > limited.Max || limited.Throughput <= 0 {
+		t.Errorf("Expected ordered percentiles, got %+v", limited)
+	}
+	if results[1].Errors != 0 {
+		t.Errorf("Expected no errors with a custom IsError, got %+v", results[1])
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{50, 50 * time.Millisecond},
+		{95, 95 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+		{100, 100 * time.Millisecond},
+		{0, 1 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %s, want %s", tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package testhelper
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+)
+
+// quoteEscaper escapes quoted Content-Disposition parameters, as mime/multipart does
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// Multipart is a multipart/form-data request body
+type Multipart struct {
+	// Fields are plain form fields
+	Fields map[string]string
+	// Files are file parts
+	Files []MultipartFile
+}
+
+// MultipartFile is a file part of a multipart body
+type MultipartFile struct {
+	// Field is the form field name
+	Field string
+	// Filename is the file name sent to the server
+	Filename string
+	// ContentType defaults to application/octet-stream
+	ContentType string
+	// Content is the file content
+	Content []byte
+}
+
+// encode returns the body and its content type, which carries the boundary
+func (m *Multipart) encode() ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	for name, value := range m.Fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, "", err
+		}
+	}
+
+	for _, file := range m.Files {
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(file.Field), quoteEscaper.Replace(file.Filename)))
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(file.Content); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
@@ -0,0 +1,102 @@
+package testhelper
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+)
+
+// NoRedirects is a redirect policy that returns redirect responses instead of following them
+func NoRedirects(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// RunServer executes test cases against handler served by a real httptest.Server, for middleware
+// that depends on real connections, such as streaming, timeouts, or TLS. Cookies set by one case
+// are sent by the following ones, so cases can log in and then use the session. Responses are
+// checked by the configured ResponseValidator, as for Run.
+func (th *TestHelper) RunServer(t *testing.T, handler http.Handler, testCases []TestCase) {
+	t.Helper()
+	server, client := th.startServer(t, handler)
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Helper()
+			if th.config.LogTestExecution {
+				th.config.Logger.Printf("### Running test against server: %s %s", tc.Method, tc.URL)
+			}
+			if tc.Teardown != nil {
+				defer tc.Teardown(t)
+			}
+			if tc.Setup != nil {
+				tc.Setup(t)
+			}
+
+			body, contentType := requestBody(t, &tc)
+			req, err := http.NewRequestWithContext(context.Background(), tc.Method, server.URL+tc.URL,
+				bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			th.setHeaders(req, &tc, contentType)
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Errorf("Request failed: %v", err)
+				return
+			}
+			defer resp.Body.Close()
+
+			th.config.ResponseValidator.Validate(t, recordResponse(t, resp), &tc)
+		})
+	}
+}
+
+// startServer starts a server for handler and a client for it, both closed when the test ends
+func (th *TestHelper) startServer(t *testing.T, handler http.Handler) (*httptest.Server, *http.Client) {
+	t.Helper()
+	var server *httptest.Server
+	if th.config.TLS {
+		server = httptest.NewTLSServer(handler)
+	} else {
+		server = httptest.NewServer(handler)
+	}
+	t.Cleanup(server.Close)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("Failed to create cookie jar: %v", err)
+	}
+
+	// The server's client trusts its certificate
+	client := server.Client()
+	client.Jar = jar
+	client.CheckRedirect = th.config.RedirectPolicy
+	client.Timeout = th.config.ClientTimeout
+	return server, client
+}
+
+// recordResponse copies a response into a recorder, so validators handle both modes alike
+func recordResponse(t *testing.T, resp *http.Response) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	for name, values := range resp.Header {
+		rec.Header()[name] = values
+	}
+	rec.WriteHeader(resp.StatusCode)
+	if _, err := io.Copy(rec, resp.Body); err != nil {
+		t.Errorf("Failed to read body: %v", err)
+	}
+	return rec
+}
+
+// RunServer executes test cases against handler served by a real server with default settings
+func RunServer(t *testing.T, handler http.Handler, testCases []TestCase) {
+	helper := NewTestHelper()
+	helper.RunServer(t, handler, testCases)
+}
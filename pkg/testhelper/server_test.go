@@ -0,0 +1,94 @@
+package testhelper
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// sessionRouter logs in with a cookie and reports who the cookie belongs to
+func sessionRouter() chi.Router {
+	router := chi.NewRouter()
+	router.Post("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "user-42", Path: "/"})
+		http.Redirect(w, r, "/me", http.StatusSeeOther)
+	})
+	router.Get("/me", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil {
+			http.Error(w, "not logged in", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"` + cookie.Value + `","tls":` + strconv.FormatBool(r.TLS != nil) + `}`))
+	})
+	return router
+}
+
+func TestRunServerCookiesAndRedirects(t *testing.T) {
+	helper := NewTestHelper(WithLogTestExecution(false))
+
+	helper.RunServer(t, sessionRouter(), []TestCase{
+		{Name: "anonymous", URL: "/me", Method: http.MethodGet, CheckStatus: http.StatusUnauthorized},
+		{
+			Name: "login follows the redirect", URL: "/login", Method: http.MethodPost,
+			CheckStatus: http.StatusOK, CheckJSONPath: map[string]interface{}{"$.id": "user-42"},
+		},
+		{
+			Name: "cookie is kept", URL: "/me", Method: http.MethodGet,
+			CheckStatus: http.StatusOK, CheckJSONPath: map[string]interface{}{"$.id": "user-42", "$.tls": false},
+		},
+	})
+
+	NewTestHelper(WithLogTestExecution(false), WithRedirectPolicy(NoRedirects)).RunServer(t, sessionRouter(),
+		[]TestCase{{
+			Name: "redirect is returned", URL: "/login", Method: http.MethodPost,
+			CheckStatus: http.StatusSeeOther, CheckHeaders: map[string]string{"Location": "^/me$"},
+		}})
+}
+
+func TestRunServerTLS(t *testing.T) {
+	helper := NewTestHelper(WithLogTestExecution(false), WithTLS(true))
+
+	helper.RunServer(t, sessionRouter(), []TestCase{
+		{Name: "login", URL: "/login", Method: http.MethodPost, CheckStatus: http.StatusOK},
+		{
+			Name: "served over TLS", URL: "/me", Method: http.MethodGet,
+			CheckStatus: http.StatusOK, CheckJSONPath: map[string]interface{}{"$.tls": true},
+		},
+	})
+}
+
+func TestMultipart(t *testing.T) {
+	router := chi.NewRouter()
+	router.Post("/upload", func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("avatar")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+		_, _ = w.Write([]byte(r.FormValue("name") + ":" + header.Filename + ":" + header.Header.Get("Content-Type") +
+			":" + string(content)))
+	})
+
+	upload := TestCase{
+		Name:   "multipart upload",
+		URL:    "/upload",
+		Method: http.MethodPost,
+		Multipart: &Multipart{
+			Fields: map[string]string{"name": "ada"},
+			Files:  []MultipartFile{{Field: "avatar", Filename: "ada.png", ContentType: "image/png", Content: []byte("PNG")}},
+		},
+		CheckStatus:    http.StatusOK,
+		CheckBody:      "^ada:ada.png:image/png:PNG$",
+		CheckBodyCount: 1,
+	}
+
+	helper := NewTestHelper(WithLogTestExecution(false))
+	helper.Run(t, router, []TestCase{upload})
+	helper.RunServer(t, router, []TestCase{upload})
+}
@@ -2,15 +2,19 @@
 package testhelper
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"regexp"
 	"strconv"
-	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -64,20 +68,71 @@ func (v *DefaultResponseValidator) Validate(t *testing.T, rec *httptest.Response
 	}
 
 	if test.CheckBody != "" {
-		bodyCheckRegex, err := regexp.Compile(test.CheckBody)
+		v.validateBody(t, body, test)
+	}
+	v.validateHeaders(t, resp.Header, test)
+	v.validateJSONPaths(t, body, test)
+}
+
+// validateBody counts the matches of the CheckBody regex
+func (v *DefaultResponseValidator) validateBody(t *testing.T, body []byte, test *TestCase) {
+	t.Helper()
+	bodyCheckRegex, err := regexp.Compile(test.CheckBody)
+	if err != nil {
+		t.Errorf("Invalid body check regex: %v", err)
+		return
+	}
+
+	matches := bodyCheckRegex.FindAllStringIndex(string(body), -1)
+
+	if len(matches) != test.CheckBodyCount {
+		t.Errorf("'%s' not found %d times in body\nBODY: %s", test.CheckBody, test.CheckBodyCount, body)
+	}
+}
+
+// validateHeaders matches response headers against the CheckHeaders regexes
+func (v *DefaultResponseValidator) validateHeaders(t *testing.T, header http.Header, test *TestCase) {
+	t.Helper()
+	for name, pattern := range test.CheckHeaders {
+		headerCheckRegex, err := regexp.Compile(pattern)
 		if err != nil {
-			t.Errorf("Invalid body check regex: %v", err)
-			return
+			t.Errorf("Invalid check regex for header %s: %v", name, err)
+			continue
 		}
+		if value := header.Get(name); !headerCheckRegex.MatchString(value) {
+			t.Errorf("Header %s is '%s', wanted a match for '%s'", name, value, pattern)
+		}
+	}
+}
 
-		matches := bodyCheckRegex.FindAllStringIndex(string(body), -1)
-
-		if len(matches) != test.CheckBodyCount {
-			t.Errorf("'%s' not found %d times in body\nBODY: %s", test.CheckBody, test.CheckBodyCount, body)
+// validateJSONPaths compares the values at the CheckJSONPath paths with those expected
+func (v *DefaultResponseValidator) validateJSONPaths(t *testing.T, body []byte, test *TestCase) {
+	t.Helper()
+	for path, expected := range test.CheckJSONPath {
+		got, err := LookupJSONPath(body, path)
+		if err != nil {
+			t.Errorf("%v\nBODY: %s", err, body)
+			continue
+		}
+		if !jsonEqual(got, expected) {
+			t.Errorf("%s is %v (%T), wanted %v (%T)", path, got, got, expected, expected)
 		}
 	}
 }
 
+// jsonEqual compares a decoded JSON value with an expected Go value, as JSON, so 3 equals 3.0
+func jsonEqual(got, expected interface{}) bool {
+	encoded, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(got, normalized)
+}
+
 // TestHelperOption is a functional option for test helper configuration
 type TestHelperOption func(*TestHelperConfig)
 
@@ -87,6 +142,11 @@ type TestHelperConfig struct {
 	ResponseValidator ResponseValidator
 	LogTestExecution  bool
 	DefaultHeaders    map[string]string
+
+	// Live server settings, used by RunServer
+	TLS            bool
+	RedirectPolicy func(req *http.Request, via []*http.Request) error
+	ClientTimeout  time.Duration
 }
 
 // DefaultTestHelperConfig provides sensible defaults
@@ -98,6 +158,8 @@ func DefaultTestHelperConfig() *TestHelperConfig {
 		DefaultHeaders: map[string]string{
 			ContentType: ApplicationJSON,
 		},
+		TLS:           false,
+		ClientTimeout: 10 * time.Second,
 	}
 }
 
@@ -129,6 +191,27 @@ func WithDefaultHeaders(headers map[string]string) TestHelperOption {
 	}
 }
 
+// WithTLS serves RunServer cases over HTTPS with a self-signed certificate the client trusts
+func WithTLS(tls bool) TestHelperOption {
+	return func(config *TestHelperConfig) {
+		config.TLS = tls
+	}
+}
+
+// WithRedirectPolicy sets how RunServer's client follows redirects; NoRedirects stops at the first response
+func WithRedirectPolicy(policy func(req *http.Request, via []*http.Request) error) TestHelperOption {
+	return func(config *TestHelperConfig) {
+		config.RedirectPolicy = policy
+	}
+}
+
+// WithClientTimeout bounds each RunServer request
+func WithClientTimeout(timeout time.Duration) TestHelperOption {
+	return func(config *TestHelperConfig) {
+		config.ClientTimeout = timeout
+	}
+}
+
 // NewTestHelperConfig creates a new test helper config with options
 func NewTestHelperConfig(options ...TestHelperOption) *TestHelperConfig {
 	config := DefaultTestHelperConfig()
@@ -159,6 +242,8 @@ type TestCase struct {
 	Method string
 	// Body is the optional request body for POST, PUT, etc.
 	Body string
+	// Multipart is a multipart/form-data body, sent instead of Body.
+	Multipart *Multipart
 	// Headers is an optional map of headers to set on the request.
 	Headers map[string]string
 	// CheckBody is a regex to match against the response body.
@@ -167,6 +252,17 @@ type TestCase struct {
 	CheckBodyCount int
 	// CheckStatus is the expected HTTP status code.
 	CheckStatus int
+	// CheckJSONPath maps JSON paths such as "$.items[0].id" to the value expected there.
+	CheckJSONPath map[string]interface{}
+	// CheckHeaders maps response headers to a regex their value must match; "^$" expects no header.
+	CheckHeaders map[string]string
+	// Context holds values added to the request context, such as claims set by auth middleware. It
+	// only applies to Run, as values can't cross a real connection.
+	Context map[interface{}]interface{}
+	// Setup runs before the request, e.g. to seed a store.
+	Setup func(t *testing.T)
+	// Teardown runs after the response is validated, even if the case fails.
+	Teardown func(t *testing.T)
 }
 
 // Validate checks if the HTTP method of the test case is valid.
@@ -191,17 +287,13 @@ func (th *TestHelper) Run(t *testing.T, router chi.Router, testCases []TestCase)
 			if th.config.LogTestExecution {
 				th.config.Logger.Printf("### Running test: %s %s", tc.Method, tc.URL)
 			}
-			req := th.newRequest(t, &tc)
-
-			// Set default headers first
-			for k, v := range th.config.DefaultHeaders {
-				req.Header.Set(k, v)
+			if tc.Teardown != nil {
+				defer tc.Teardown(t)
 			}
-
-			// Set custom headers if provided (override defaults)
-			for k, v := range tc.Headers {
-				req.Header.Set(k, v)
+			if tc.Setup != nil {
+				tc.Setup(t)
 			}
+			req := th.newRequest(t, &tc)
 
 			rec := httptest.NewRecorder()
 			router.ServeHTTP(rec, req)
@@ -213,11 +305,48 @@ func (th *TestHelper) Run(t *testing.T, router chi.Router, testCases []TestCase)
 // newRequest creates a new HTTP request for a test case.
 func (th *TestHelper) newRequest(t *testing.T, test *TestCase) *http.Request {
 	t.Helper()
-	req := httptest.NewRequest(test.Method, test.URL, strings.NewReader(test.Body))
-	req.Header.Set(ContentLength, strconv.Itoa(len(test.Body)))
+	body, contentType := requestBody(t, test)
+	req := httptest.NewRequest(test.Method, test.URL, bytes.NewReader(body))
+	req.Header.Set(ContentLength, strconv.Itoa(len(body)))
+	th.setHeaders(req, test, contentType)
+
+	if len(test.Context) > 0 {
+		ctx := req.Context()
+		for key, value := range test.Context {
+			ctx = context.WithValue(ctx, key, value)
+		}
+		req = req.WithContext(ctx)
+	}
 	return req
 }
 
+// setHeaders sets the default headers, then the body's content type, then the test case's headers
+func (th *TestHelper) setHeaders(req *http.Request, test *TestCase, contentType string) {
+	for k, v := range th.config.DefaultHeaders {
+		req.Header.Set(k, v)
+	}
+	if contentType != "" {
+		req.Header.Set(ContentType, contentType)
+	}
+	for k, v := range test.Headers {
+		req.Header.Set(k, v)
+	}
+}
+
+// requestBody returns the body of a test case, and its content type when the body sets one
+func requestBody(t *testing.T, test *TestCase) ([]byte, string) {
+	t.Helper()
+	if test.Multipart == nil {
+		return []byte(test.Body), ""
+	}
+
+	body, contentType, err := test.Multipart.encode()
+	if err != nil {
+		t.Fatalf("Failed to encode multipart body: %v", err)
+	}
+	return body, contentType
+}
+
 // Legacy functions for backward compatibility
 func Run(t *testing.T, router chi.Router, testCases []TestCase) {
 	helper := NewTestHelper()
@@ -476,3 +476,46 @@ func TestRunWithRegexBody(t *testing.T) {
 
 	Run(t, router, testCases)
 }
+
+func TestRunWithStructuredChecks(t *testing.T) {
+	type tenantKey struct{}
+	var seeded, cleaned []string
+
+	router := chi.NewRouter()
+	router.Get("/orders", func(w http.ResponseWriter, r *http.Request) {
+		tenant, _ := r.Context().Value(tenantKey{}).(string)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", "1")
+		if _, err := w.Write([]byte(`{"tenant":"` + tenant + `","items":[{"id":"abc","total":42}]}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	})
+
+	testCases := []TestCase{
+		{
+			Name:        "json paths, headers, and context",
+			URL:         "/orders",
+			Method:      http.MethodGet,
+			Context:     map[interface{}]interface{}{tenantKey{}: "acme"},
+			CheckStatus: http.StatusOK,
+			CheckJSONPath: map[string]interface{}{
+				"$.tenant":         "acme",
+				"$.items[0].id":    "abc",
+				"$.items[0].total": 42,
+			},
+			CheckHeaders: map[string]string{
+				"Content-Type":  "^application/json$",
+				"X-Total-Count": `^\d+$`,
+				"Set-Cookie":    "^$",
+			},
+			Setup:    func(t *testing.T) { seeded = append(seeded, t.Name()) },
+			Teardown: func(t *testing.T) { cleaned = append(cleaned, t.Name()) },
+		},
+	}
+
+	Run(t, router, testCases)
+
+	if len(seeded) != 1 || len(cleaned) != 1 {
+		t.Errorf("Expected setup and teardown to run once, got %v and %v", seeded, cleaned)
+	}
+}
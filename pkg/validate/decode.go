@@ -0,0 +1,203 @@
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// DecodeConfig holds configuration for decoding request bodies
+type DecodeConfig struct {
+	// MaxBodySize is the largest accepted body in bytes
+	MaxBodySize int64
+	// DisallowUnknownFields rejects bodies containing fields not present in the target struct
+	DisallowUnknownFields bool
+	// SkipValidation only decodes, for callers that fill more fields before validating with Struct
+	SkipValidation bool
+}
+
+// DefaultDecodeConfig provides sensible defaults
+func DefaultDecodeConfig() *DecodeConfig {
+	return &DecodeConfig{
+		MaxBodySize:           1 << 20, // 1 MiB
+		DisallowUnknownFields: true,
+	}
+}
+
+// DecodeOption is a functional option for configuring decoding
+type DecodeOption func(*DecodeConfig)
+
+// WithMaxBodySize sets the largest accepted body in bytes
+func WithMaxBodySize(size int64) DecodeOption {
+	return func(config *DecodeConfig) {
+		config.MaxBodySize = size
+	}
+}
+
+// WithAllowUnknownFields accepts bodies containing fields not present in the target struct
+func WithAllowUnknownFields() DecodeOption {
+	return func(config *DecodeConfig) {
+		config.DisallowUnknownFields = false
+	}
+}
+
+// WithoutValidation only decodes the body, leaving validation with Struct to the caller
+func WithoutValidation() DecodeOption {
+	return func(config *DecodeConfig) {
+		config.SkipValidation = true
+	}
+}
+
+// NewDecodeConfig creates a new decode config with options
+func NewDecodeConfig(options ...DecodeOption) *DecodeConfig {
+	config := DefaultDecodeConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// DecodeError describes a request body that could not be decoded
+type DecodeError struct {
+	Status int
+	Detail string
+	Errors []problem.FieldError
+}
+
+func (e *DecodeError) Error() string {
+	return e.Detail
+}
+
+// Problem converts the error into a problem+json response
+func (e *DecodeError) Problem(instance string) *problem.Problem {
+	p := problem.New("invalid-body", "Invalid request body", e.Status, e.Detail, instance)
+	p.Errors = e.Errors
+	return p
+}
+
+// Decode reads a single JSON value from the request body into dst and validates it with Struct.
+// Malformed bodies return a *DecodeError and invalid fields a *ValidationError.
+func Decode(r *http.Request, dst interface{}, options ...DecodeOption) error {
+	config := NewDecodeConfig(options...)
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return &DecodeError{Status: http.StatusBadRequest, Detail: "request body is empty"}
+	}
+
+	body := http.MaxBytesReader(nil, r.Body, config.MaxBodySize)
+	decoder := json.NewDecoder(body)
+	if config.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+
+	if err := decoder.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return &DecodeError{Status: http.StatusBadRequest, Detail: "request body must contain a single JSON value"}
+	}
+
+	if config.SkipValidation {
+		return nil
+	}
+	return Struct(dst)
+}
+
+// decodeError maps encoding/json errors to client-friendly decode errors
+func decodeError(err error) *DecodeError {
+	var (
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+		maxBytesErr *http.MaxBytesError
+	)
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return &DecodeError{Status: http.StatusBadRequest, Detail: "request body is empty"}
+	case errors.As(err, &maxBytesErr):
+		return &DecodeError{
+			Status: http.StatusRequestEntityTooLarge,
+			Detail: fmt.Sprintf("request body must not be larger than %d bytes", maxBytesErr.Limit),
+		}
+	case errors.As(err, &syntaxErr):
+		return &DecodeError{
+			Status: http.StatusBadRequest,
+			Detail: fmt.Sprintf("malformed JSON at position %d", syntaxErr.Offset),
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &DecodeError{Status: http.StatusBadRequest, Detail: "malformed JSON: unexpected end of body"}
+	case errors.As(err, &typeErr):
+		return &DecodeError{
+			Status: http.StatusBadRequest,
+			Detail: "request body contains a value of the wrong type",
+			Errors: []problem.FieldError{{
+				Field: typeErr.Field, Code: "type", Message: "must be of type " + typeErr.Type.String(),
+			}},
+		}
+	}
+
+	if field, ok := unknownField(err); ok {
+		return &DecodeError{
+			Status: http.StatusBadRequest,
+			Detail: "request body contains an unknown field",
+			Errors: []problem.FieldError{{Field: field, Code: "unknown", Message: "is not allowed"}},
+		}
+	}
+
+	return &DecodeError{Status: http.StatusBadRequest, Detail: err.Error()}
+}
+
+// unknownField extracts the field name from the error returned by DisallowUnknownFields
+func unknownField(err error) (string, bool) {
+	var field string
+	if n, _ := fmt.Sscanf(err.Error(), "json: unknown field %q", &field); n == 1 {
+		return field, true
+	}
+	return "", false
+}
+
+// problemError is implemented by errors that know how to render themselves as a problem
+type problemError interface {
+	Problem(instance string) *problem.Problem
+}
+
+// SendError writes err as a problem response, in plain text if the client prefers it over JSON.
+// Decode and validation errors keep their status and field details; any other error becomes a 500.
+func SendError(w http.ResponseWriter, r *http.Request, err error) {
+	var pe problemError
+	if errors.As(err, &pe) {
+		pe.Problem(r.URL.Path).Respond(w, r)
+		return
+	}
+	problem.Wrap(http.StatusInternalServerError, "validation", r.URL.Path, err).Respond(w, r)
+}
+
+type bodyContextKey struct{}
+
+// Body returns middleware that decodes and validates the request body into a new T,
+// responding with a problem on failure. Handlers retrieve the value with FromContext.
+func Body[T any](options ...DecodeOption) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			dst := new(T)
+			if err := Decode(r, dst, options...); err != nil {
+				SendError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), bodyContextKey{}, dst)))
+		})
+	}
+}
+
+// FromContext returns the body decoded by the Body middleware
+func FromContext[T any](ctx context.Context) (*T, bool) {
+	v, ok := ctx.Value(bodyContextKey{}).(*T)
+	return v, ok
+}
@@ -0,0 +1,127 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type createUser struct {
+	Name  string `json:"name" validate:"required,max=20"`
+	Email string `json:"email" validate:"required,format=email"`
+	Age   int    `json:"age" validate:"min=13"`
+}
+
+func TestDecodeConfig(t *testing.T) {
+	config := DefaultDecodeConfig()
+	if config.MaxBodySize != 1<<20 {
+		t.Errorf("Expected default max body size 1MiB, got %d", config.MaxBodySize)
+	}
+	if !config.DisallowUnknownFields {
+		t.Error("Expected unknown fields to be rejected by default")
+	}
+
+	config = NewDecodeConfig(WithMaxBodySize(512), WithAllowUnknownFields())
+	if config.MaxBodySize != 512 || config.DisallowUnknownFields {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		options    []DecodeOption
+		wantStatus int
+		wantField  string
+	}{
+		{"valid", `{"name":"Jo","email":"jo@example.com","age":30}`, nil, 0, ""},
+		{"empty", ``, nil, http.StatusBadRequest, ""},
+		{"malformed", `{"name":`, nil, http.StatusBadRequest, ""},
+		{"syntax", `{"name" "Jo"}`, nil, http.StatusBadRequest, ""},
+		{"wrong type", `{"name":"Jo","email":"jo@example.com","age":"old"}`, nil, http.StatusBadRequest, "age"},
+		{"unknown field", `{"name":"Jo","email":"jo@example.com","admin":true}`, nil, http.StatusBadRequest, "admin"},
+		{"unknown allowed", `{"name":"Jo","email":"jo@example.com","admin":true}`,
+			[]DecodeOption{WithAllowUnknownFields()}, 0, ""},
+		{"trailing data", `{"name":"Jo","email":"jo@example.com"}{}`, nil, http.StatusBadRequest, ""},
+		{"too large", `{"name":"` + strings.Repeat("a", 100) + `"}`,
+			[]DecodeOption{WithMaxBodySize(32)}, http.StatusRequestEntityTooLarge, ""},
+		{"invalid fields", `{"name":"Jo","email":"nope","age":5}`, nil, http.StatusUnprocessableEntity, "email"},
+		{"validation skipped", `{"name":"Jo","email":"nope","age":5}`, []DecodeOption{WithoutValidation()}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+
+			var dst createUser
+			err := Decode(r, &dst, tt.options...)
+
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+
+			var pe problemError
+			if !errors.As(err, &pe) {
+				t.Fatalf("Expected problem error, got %v", err)
+			}
+			p := pe.Problem(r.URL.Path)
+			if p.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d (%s)", tt.wantStatus, p.Status, p.Detail)
+			}
+			if tt.wantField != "" && (len(p.Errors) == 0 || p.Errors[0].Field != tt.wantField) {
+				t.Errorf("Expected field error for %s, got %+v", tt.wantField, p.Errors)
+			}
+		})
+	}
+}
+
+func TestBodyMiddleware(t *testing.T) {
+	handler := Body[createUser]()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := FromContext[createUser](r.Context())
+		if !ok {
+			t.Error("Expected decoded body in context")
+			return
+		}
+		_, _ = w.Write([]byte(user.Name))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users",
+		strings.NewReader(`{"name":"Jo","email":"jo@example.com"}`)))
+	if w.Code != http.StatusOK || w.Body.String() != "Jo" {
+		t.Errorf("Expected 200 with name, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":""}`)))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Expected problem+json, got %s", ct)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	if errs, _ := body["errors"].([]interface{}); len(errs) != 2 {
+		t.Errorf("Expected 2 field errors, got %v", body["errors"])
+	}
+}
+
+func TestSendErrorFallback(t *testing.T) {
+	w := httptest.NewRecorder()
+	SendError(w, httptest.NewRequest(http.MethodPost, "/users", nil), errors.New("boom"))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d", w.Code)
+	}
+}
@@ -0,0 +1,257 @@
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// ValidationError aggregates every field that failed validation
+type ValidationError struct {
+	Errors []problem.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		messages = append(messages, fe.Field+": "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Problem converts the error into a 422 problem+json response listing each invalid field
+func (e *ValidationError) Problem(instance string) *problem.Problem {
+	return problem.FromValidationErrors(e.Errors, instance)
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Struct validates v, a struct or pointer to a struct, using `validate` field tags.
+// Rules are comma separated:
+//
+//	required     the value must not be zero (or nil, or empty)
+//	min=n max=n  numeric bounds, or length bounds for strings, slices, and maps
+//	format=name  string format: email, url, uuid, date (YYYY-MM-DD), or datetime (RFC 3339)
+//	oneof=a b c  allowed values, space separated
+//
+// Zero values are treated as absent, so only required applies to them. Nested structs and
+// slices of structs are validated recursively. Fields are reported by their JSON names,
+// such as "items[0].sku", with the name of the failing rule as their code. Failures are
+// returned as a *ValidationError.
+func Struct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return fmt.Errorf("validate: nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: expected a struct, got %T", v)
+	}
+
+	var errs []problem.FieldError
+	validateStruct(rv, "", &errs)
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// validateStruct checks each exported field of a struct value
+func validateStruct(rv reflect.Value, prefix string, errs *[]problem.FieldError) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := jsonFieldName(field)
+		if name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		validateField(rv.Field(i), name, field.Tag.Get("validate"), errs)
+	}
+}
+
+// validateField applies the rules for one field and recurses into nested values
+func validateField(fv reflect.Value, name, tag string, errs *[]problem.FieldError) {
+	rules := parseRules(tag)
+
+	if _, required := rules["required"]; required && fv.IsZero() {
+		*errs = append(*errs, problem.FieldError{Field: name, Code: "required", Message: "is required"})
+		return
+	}
+
+	for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return
+		}
+		fv = fv.Elem()
+	}
+
+	if fv.IsZero() && fv.Kind() != reflect.Struct {
+		return
+	}
+
+	if code, msg := checkRules(fv, rules); msg != "" {
+		*errs = append(*errs, problem.FieldError{Field: name, Code: code, Message: msg})
+		return
+	}
+
+	validateNested(fv, name, errs)
+}
+
+// validateNested recurses into structs and collections of structs
+func validateNested(fv reflect.Value, name string, errs *[]problem.FieldError) {
+	switch fv.Kind() {
+	case reflect.Struct:
+		if fv.Type() != reflect.TypeOf(time.Time{}) {
+			validateStruct(fv, name, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fv.Len(); i++ {
+			validateField(fv.Index(i), fmt.Sprintf("%s[%d]", name, i), "", errs)
+		}
+	}
+}
+
+// parseRules splits a validate tag into rule names and arguments
+func parseRules(tag string) map[string]string {
+	rules := make(map[string]string)
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		rules[key] = value
+	}
+	return rules
+}
+
+// checkRules returns the name of the first failing rule and a message describing it, or "" if all pass
+func checkRules(fv reflect.Value, rules map[string]string) (string, string) {
+	if limit, ok := rules["min"]; ok {
+		if msg := checkBound(fv, limit, true); msg != "" {
+			return "min", msg
+		}
+	}
+	if limit, ok := rules["max"]; ok {
+		if msg := checkBound(fv, limit, false); msg != "" {
+			return "max", msg
+		}
+	}
+	if format, ok := rules["format"]; ok && fv.Kind() == reflect.String {
+		if msg := checkFormat(fv.String(), format); msg != "" {
+			return "format", msg
+		}
+	}
+	if allowed, ok := rules["oneof"]; ok {
+		options := strings.Fields(allowed)
+		if !slices.Contains(options, fmt.Sprint(fv.Interface())) {
+			return "oneof", "must be one of " + strings.Join(options, ", ")
+		}
+	}
+	return "", ""
+}
+
+// checkBound compares a value, or its length, against a min or max limit
+func checkBound(fv reflect.Value, limitStr string, isMin bool) string {
+	limit, err := strconv.ParseFloat(limitStr, 64)
+	if err != nil {
+		return ""
+	}
+
+	n, isLength := measure(fv)
+	if isMin && n < limit {
+		if isLength {
+			return "must have at least " + limitStr + " " + lengthUnit(fv)
+		}
+		return "must be at least " + limitStr
+	}
+	if !isMin && n > limit {
+		if isLength {
+			return "must have at most " + limitStr + " " + lengthUnit(fv)
+		}
+		return "must be at most " + limitStr
+	}
+	return ""
+}
+
+// measure returns the numeric value or length used for bounds checks
+func measure(fv reflect.Value) (float64, bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), false
+	case reflect.String:
+		return float64(utf8.RuneCountInString(fv.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(fv.Len()), true
+	default:
+		return 0, false
+	}
+}
+
+func lengthUnit(fv reflect.Value) string {
+	if fv.Kind() == reflect.String {
+		return "characters"
+	}
+	return "items"
+}
+
+// checkFormat validates well-known string formats
+func checkFormat(s, format string) string {
+	switch format {
+	case "email":
+		if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+			return "must be a valid email address"
+		}
+	case "url":
+		if u, err := url.ParseRequestURI(s); err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be a valid URL"
+		}
+	case "uuid":
+		if !uuidPattern.MatchString(s) {
+			return "must be a valid UUID"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	case "datetime":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return "must be an RFC 3339 timestamp"
+		}
+	}
+	return ""
+}
+
+// jsonFieldName returns the name a field has in JSON
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
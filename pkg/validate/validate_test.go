@@ -0,0 +1,159 @@
+package validate
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+type address struct {
+	City     string `json:"city" validate:"required"`
+	Postcode string `json:"postcode" validate:"min=4,max=10"`
+}
+
+type lineItem struct {
+	SKU      string `json:"sku" validate:"required"`
+	Quantity int    `json:"quantity" validate:"required,min=1,max=99"`
+}
+
+type order struct {
+	Email    string     `json:"email" validate:"required,format=email"`
+	Website  string     `json:"website" validate:"format=url"`
+	ID       string     `json:"id" validate:"format=uuid"`
+	Delivery string     `json:"delivery" validate:"format=date"`
+	Priority string     `json:"priority" validate:"oneof=low normal high"`
+	Note     *string    `json:"note" validate:"max=5"`
+	Address  address    `json:"address"`
+	Items    []lineItem `json:"items" validate:"required,min=1"`
+}
+
+func validOrder() order {
+	return order{
+		Email:    "jo@example.com",
+		Website:  "https://example.com",
+		ID:       "123e4567-e89b-12d3-a456-426614174000",
+		Delivery: "2024-06-01",
+		Priority: "high",
+		Address:  address{City: "Wellington", Postcode: "6011"},
+		Items:    []lineItem{{SKU: "A1", Quantity: 2}},
+	}
+}
+
+func TestStructValid(t *testing.T) {
+	o := validOrder()
+	if err := Struct(&o); err != nil {
+		t.Errorf("Expected valid order, got %v", err)
+	}
+
+	// Optional fields may be left empty
+	o.Website, o.ID, o.Delivery, o.Priority = "", "", "", ""
+	if err := Struct(o); err != nil {
+		t.Errorf("Expected empty optional fields to be valid, got %v", err)
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	note := "far too long"
+	o := validOrder()
+	o.Email = "not-an-email"
+	o.Website = "example.com"
+	o.ID = "1234"
+	o.Delivery = "01/06/2024"
+	o.Priority = "urgent"
+	o.Note = &note
+	o.Address = address{Postcode: "12"}
+	o.Items = []lineItem{{SKU: "A1", Quantity: 100}, {Quantity: 1}}
+
+	err := Struct(&o)
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+
+	expected := map[string]string{
+		"email":             "must be a valid email address",
+		"website":           "must be a valid URL",
+		"id":                "must be a valid UUID",
+		"delivery":          "must be a date (YYYY-MM-DD)",
+		"priority":          "must be one of low, normal, high",
+		"note":              "must have at most 5 characters",
+		"address.city":      "is required",
+		"address.postcode":  "must have at least 4 characters",
+		"items[0].quantity": "must be at most 99",
+		"items[1].sku":      "is required",
+	}
+
+	if len(validationErr.Errors) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), validationErr.Errors)
+	}
+	for _, fe := range validationErr.Errors {
+		if expected[fe.Field] != fe.Message {
+			t.Errorf("Field %s: expected %q, got %q", fe.Field, expected[fe.Field], fe.Message)
+		}
+	}
+}
+
+func TestStructErrorCodes(t *testing.T) {
+	o := validOrder()
+	o.Email = ""
+	o.Priority = "urgent"
+	o.Address.Postcode = "12"
+	o.Items[0].Quantity = 100
+	o.ID = "1234"
+
+	var validationErr *ValidationError
+	if !errors.As(Struct(o), &validationErr) {
+		t.Fatal("Expected ValidationError")
+	}
+
+	expected := map[string]string{
+		"email":             "required",
+		"priority":          "oneof",
+		"address.postcode":  "min",
+		"items[0].quantity": "max",
+		"id":                "format",
+	}
+	for _, fe := range validationErr.Errors {
+		if expected[fe.Field] != fe.Code {
+			t.Errorf("Field %s: expected code %q, got %q", fe.Field, expected[fe.Field], fe.Code)
+		}
+	}
+}
+
+func TestStructRequiredCollection(t *testing.T) {
+	o := validOrder()
+	o.Items = nil
+
+	var validationErr *ValidationError
+	if !errors.As(Struct(o), &validationErr) {
+		t.Fatal("Expected ValidationError")
+	}
+	if validationErr.Errors[0].Field != "items" || validationErr.Errors[0].Message != "is required" {
+		t.Errorf("Unexpected error: %+v", validationErr.Errors[0])
+	}
+}
+
+func TestStructInvalidInput(t *testing.T) {
+	var nilOrder *order
+	if err := Struct(nilOrder); err == nil {
+		t.Error("Expected error for nil pointer")
+	}
+	if err := Struct("not a struct"); err == nil {
+		t.Error("Expected error for non-struct")
+	}
+}
+
+func TestValidationErrorProblem(t *testing.T) {
+	err := &ValidationError{Errors: []problem.FieldError{{Field: "email", Message: "is required"}}}
+
+	p := err.Problem("/orders")
+	if p.Status != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", p.Status)
+	}
+	if len(p.Errors) != 1 || p.Instance != "/orders" {
+		t.Errorf("Unexpected problem: %+v", p)
+	}
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when an endpoint is on a loopback, link-local, or private network
+var ErrForbiddenAddress = errors.New("webhook address not allowed")
+
+// forbiddenChecks match the addresses endpoints must not reach unless private networks are allowed
+var forbiddenChecks = []func(netip.Addr) bool{
+	netip.Addr.IsLoopback,
+	netip.Addr.IsPrivate,
+	netip.Addr.IsLinkLocalUnicast,
+	netip.Addr.IsLinkLocalMulticast,
+	netip.Addr.IsInterfaceLocalMulticast,
+	netip.Addr.IsMulticast,
+	netip.Addr.IsUnspecified,
+}
+
+// forbiddenPrefixes are ranges netip has no check for: "this network" and the carrier-grade NAT range,
+// which some clouds serve metadata from
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// forbiddenAddr reports whether addr is one endpoints must not reach
+func forbiddenAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, check := range forbiddenChecks {
+		if check(addr) {
+			return true
+		}
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forbiddenHost reports whether a URL host is an address endpoints must not reach, or a localhost name,
+// without resolving it
+func forbiddenHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && forbiddenAddr(addr)
+}
+
+// denyForbidden is a net.Dialer Control hook that refuses connections to forbidden addresses. It runs
+// after DNS resolution, so a host name that resolves, or is rebound, to a private address is refused too.
+func denyForbidden(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil || forbiddenAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	return nil
+}
+
+// newHTTPClient returns the client deliveries are sent with when none is configured
+func newHTTPClient(config *Config) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = denyForbidden
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the endpoint, so endpoints are reached directly
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: config.Timeout, Transport: transport}
+}
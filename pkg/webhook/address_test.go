@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestForbiddenAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"0.0.0.0", true},
+		{"100.100.100.200", true},
+		{"224.0.0.1", true},
+		{"::ffff:127.0.0.1", true},
+		{"93.184.216.34", false},
+		{"2606:2800:220:1::1", false},
+	}
+
+	for _, tt := range tests {
+		if got := forbiddenAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("forbiddenAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestForbiddenHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"localhost", true},
+		{"LOCALHOST.", true},
+		{"api.localhost", true},
+		{"10.0.0.1", true},
+		{"example.com", false},
+		{"93.184.216.34", false},
+	}
+
+	for _, tt := range tests {
+		if got := forbiddenHost(tt.host); got != tt.want {
+			t.Errorf("forbiddenHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestDenyForbidden(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"93.184.216.34:443", false},
+		{"127.0.0.1:8080", true},
+		{"[fe80::1]:443", true},
+		{"not-an-address", true},
+	}
+
+	for _, tt := range tests {
+		err := denyForbidden("tcp", tt.address, nil)
+		if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrForbiddenAddress)) {
+			t.Errorf("denyForbidden(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/internal/poller"
+	"github.com/Okja-Engineering/go-service-kit/internal/retry"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// webhookDeliveriesTotal counts delivery attempts by outcome: delivered, retry, or failed
+	webhookDeliveriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhook_deliveries_total",
+		Help: "Total number of webhook delivery attempts by outcome",
+	}, []string{"result"})
+
+	// webhookDeliveryDuration observes how long endpoints take to respond
+	webhookDeliveryDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "webhook_delivery_duration_seconds",
+		Help:    "Duration of webhook delivery requests",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// pendingDelivery is a claimed delivery with what's needed to send it
+type pendingDelivery struct {
+	id        int64
+	eventID   string
+	eventType string
+	body      []byte
+	attempt   int
+	url       string
+	secret    string
+}
+
+// StatusError is returned when an endpoint responds with a status other than 2xx
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("endpoint responded %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Start runs the delivery workers until ctx is done or Stop is called
+func (s *Service) Start(ctx context.Context) {
+	concurrency := max(s.config.Concurrency, 1)
+	started := s.pool.Start(ctx, concurrency, poller.Loop{
+		Next:     s.DeliverNext,
+		Interval: s.config.PollInterval,
+		OnError: func(err error) {
+			s.config.Logger.Printf("### 🪝 Webhook: %v", err)
+		},
+	})
+	if started {
+		s.config.Logger.Printf("### 🪝 Webhook: delivery started with concurrency %d", concurrency)
+	}
+}
+
+// Stop stops claiming deliveries and waits for those in flight to finish, or for ctx to be done.
+func (s *Service) Stop(ctx context.Context) error {
+	running, err := s.pool.Stop(ctx)
+	if err != nil {
+		return fmt.Errorf("webhook deliveries still in flight at shutdown: %w", err)
+	}
+	if running {
+		s.config.Logger.Printf("### 🪝 Webhook: delivery stopped")
+	}
+	return nil
+}
+
+// DeliverNext claims and sends a single due delivery, reporting whether there was one. Workers call it in
+// a loop; it is exported for tests and for sending deliveries synchronously.
+func (s *Service) DeliverNext(ctx context.Context) (bool, error) {
+	delivery, err := s.claim(ctx)
+	if err != nil || delivery == nil {
+		return false, err
+	}
+
+	// A delivery in flight completes even if the service is stopped meanwhile
+	sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	statusCode, err := s.send(sendCtx, delivery)
+	webhookDeliveryDuration.Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		webhookDeliveriesTotal.WithLabelValues("delivered").Inc()
+		return true, s.complete(context.WithoutCancel(ctx), delivery, statusCode)
+	}
+	return true, s.fail(context.WithoutCancel(ctx), delivery, statusCode, err)
+}
+
+// claim takes the next due delivery, pushing its next attempt past the request timeout so no other
+// worker sends it meanwhile. If the worker dies mid-delivery, it is sent again once that passes.
+func (s *Service) claim(ctx context.Context) (*pendingDelivery, error) {
+	db := s.db.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+
+	query := fmt.Sprintf(`UPDATE %[1]s d SET attempts = d.attempts + 1,
+		next_attempt_at = now() + $1 * interval '1 millisecond'
+		FROM %[2]s e
+		WHERE e.id = d.endpoint_id AND d.id = (
+			SELECT id FROM %[1]s WHERE status = $2 AND next_attempt_at <= now()
+			ORDER BY next_attempt_at, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING d.id, d.event_id, d.event_type, d.body, d.attempts, e.url, e.secret`,
+		pq.QuoteIdentifier(s.config.DeliveriesTable), pq.QuoteIdentifier(s.config.EndpointsTable))
+
+	d := &pendingDelivery{}
+	lease := 2 * s.config.Timeout
+	err := db.QueryRowContext(ctx, query, lease.Milliseconds(), StatusPending).
+		Scan(&d.id, &d.eventID, &d.eventType, &d.body, &d.attempt, &d.url, &d.secret)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook delivery: %w", err)
+	}
+
+	return d, nil
+}
+
+// send posts the signed event, returning the response status. Any status other than 2xx is an error.
+func (s *Service) send(ctx context.Context, d *pendingDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(d.body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", s.config.UserAgent)
+	req.Header.Set(HeaderID, d.eventID)
+	req.Header.Set(HeaderEvent, d.eventType)
+	req.Header.Set("Webhook-Attempt", strconv.Itoa(d.attempt))
+	req.Header.Set(HeaderSignature, Sign(d.secret, time.Now(), d.body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	// Drain a little of the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, &StatusError{StatusCode: resp.StatusCode}
+	}
+	return resp.StatusCode, nil
+}
+
+// complete marks a delivery as delivered. The attempt count acts as a lease token, so a worker whose
+// delivery was reclaimed can't overwrite a later attempt's result.
+func (s *Service) complete(ctx context.Context, d *pendingDelivery, statusCode int) error {
+	db := s.db.GetDB()
+	if db == nil {
+		return fmt.Errorf("delivery %d sent but not recorded: database connection is closed", d.id)
+	}
+
+	update := fmt.Sprintf(`UPDATE %s SET status = $1, last_status_code = $2, last_error = NULL,
+		delivered_at = now() WHERE id = $3 AND attempts = $4`, pq.QuoteIdentifier(s.config.DeliveriesTable))
+	if _, err := db.ExecContext(ctx, update, StatusDelivered, statusCode, d.id, d.attempt); err != nil {
+		return fmt.Errorf("delivery %d sent but not recorded: %w", d.id, err)
+	}
+	return nil
+}
+
+// fail schedules a retry with backoff, or marks the delivery failed once its attempts are used up
+func (s *Service) fail(ctx context.Context, d *pendingDelivery, statusCode int, cause error) error {
+	status := StatusPending
+	delay := retry.Backoff(s.config.BaseBackoff, s.config.MaxBackoff, d.attempt)
+	result := "retry"
+	if d.attempt >= s.config.MaxAttempts {
+		status = StatusFailed
+		delay = 0
+		result = "failed"
+	}
+	webhookDeliveriesTotal.WithLabelValues(result).Inc()
+
+	db := s.db.GetDB()
+	if db == nil {
+		return fmt.Errorf("delivery %d failed (%v) and could not be rescheduled: database connection is closed",
+			d.id, cause)
+	}
+
+	var code sql.NullInt64
+	if statusCode > 0 {
+		code = sql.NullInt64{Int64: int64(statusCode), Valid: true}
+	}
+	update := fmt.Sprintf(`UPDATE %s SET status = $1, next_attempt_at = now() + $2 * interval '1 millisecond',
+		last_status_code = $3, last_error = $4 WHERE id = $5 AND attempts = $6`,
+		pq.QuoteIdentifier(s.config.DeliveriesTable))
+	if _, err := db.ExecContext(ctx, update, status, delay.Milliseconds(), code, retry.ErrorMessage(cause),
+		d.id, d.attempt); err != nil {
+		return fmt.Errorf("delivery %d failed (%v) and could not be rescheduled: %w", d.id, cause, err)
+	}
+
+	if status == StatusFailed {
+		return fmt.Errorf("delivery %d of %s to %s failed after %d attempts: %w", d.id, d.eventID, d.url,
+			d.attempt, cause)
+	}
+	return fmt.Errorf("delivery %d of %s to %s attempt %d failed, retrying in %s: %w", d.id, d.eventID, d.url,
+		d.attempt, delay, cause)
+}
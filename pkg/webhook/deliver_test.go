@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncLogger struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *syncLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(&l.buf, format+"\n", v...)
+}
+
+func (l *syncLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+func TestSend(t *testing.T) {
+	var got *http.Request
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	s := newTestService(WithPrivateNetworks(true))
+	d := &pendingDelivery{id: 1, eventID: "evt_1", eventType: "order.created", body: []byte(`{"id":"evt_1"}`),
+		attempt: 2, url: server.URL, secret: "whsec_test"}
+
+	status, err := s.send(context.Background(), d)
+	if err != nil || status != http.StatusAccepted {
+		t.Fatalf("Expected 202 and no error, got %d and %v", status, err)
+	}
+
+	if got.Method != http.MethodPost || got.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected a JSON POST, got %s %s", got.Method, got.Header.Get("Content-Type"))
+	}
+	if got.Header.Get(HeaderID) != "evt_1" || got.Header.Get(HeaderEvent) != "order.created" {
+		t.Errorf("Expected event headers, got %v", got.Header)
+	}
+	if got.Header.Get("Webhook-Attempt") != "2" || got.Header.Get("User-Agent") != "go-service-kit-webhook" {
+		t.Errorf("Expected attempt and user agent headers, got %v", got.Header)
+	}
+	if err := Verify("whsec_test", got.Header.Get(HeaderSignature), gotBody, DefaultTolerance); err != nil {
+		t.Errorf("Expected the delivery signature to verify, got %v", err)
+	}
+}
+
+func TestSendErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	s := newTestService(WithPrivateNetworks(true))
+	status, err := s.send(context.Background(), &pendingDelivery{url: server.URL, body: []byte("{}")})
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected a StatusError, got %v", err)
+	}
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", status)
+	}
+}
+
+func TestSendUnreachable(t *testing.T) {
+	s := newTestService(WithTimeout(time.Second), WithPrivateNetworks(true))
+	status, err := s.send(context.Background(), &pendingDelivery{url: "http://127.0.0.1:1", body: []byte("{}")})
+	if err == nil || status != 0 {
+		t.Errorf("Expected a connection error and no status, got %d and %v", status, err)
+	}
+}
+
+func TestSendForbiddenAddress(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	status, err := newTestService().send(context.Background(), &pendingDelivery{url: server.URL, body: []byte("{}")})
+	if !errors.Is(err, ErrForbiddenAddress) || status != 0 || called {
+		t.Errorf("Expected a loopback endpoint to be refused, got %d and %v", status, err)
+	}
+}
+
+func TestDeliverNextNotConnected(t *testing.T) {
+	sent, err := newTestService().DeliverNext(context.Background())
+	if sent || err == nil {
+		t.Errorf("Expected no delivery and a connection error, got %v and %v", sent, err)
+	}
+}
+
+func TestServiceStartStop(t *testing.T) {
+	logger := &syncLogger{}
+	s := newTestService(WithLogger(logger), WithConcurrency(2), WithPollInterval(5*time.Millisecond))
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Errorf("Expected Stop before Start to be a no-op, got %v", err)
+	}
+
+	s.Start(context.Background())
+	s.Start(context.Background())
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	output := logger.String()
+	if !strings.Contains(output, "delivery started with concurrency 2") {
+		t.Errorf("Expected start to be logged, got %q", output)
+	}
+	if !strings.Contains(output, "database connection is closed") {
+		t.Errorf("Expected claim failures to be logged, got %q", output)
+	}
+	if !strings.Contains(output, "delivery stopped") {
+		t.Errorf("Expected stop to be logged, got %q", output)
+	}
+}
@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/crypto"
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+)
+
+// Headers sent with every delivery
+const (
+	// HeaderID carries the event ID, the same for every attempt, so receivers can skip duplicates
+	HeaderID        = "Webhook-ID"
+	HeaderEvent     = "Webhook-Event"
+	HeaderSignature = "Webhook-Signature"
+)
+
+// DefaultTolerance is how old a delivery's signature may be before receivers reject it as a replay
+const DefaultTolerance = 5 * time.Minute
+
+// maxBodySize bounds the request bodies VerifyRequest reads
+const maxBodySize = 5 << 20
+
+var (
+	// ErrInvalidSignature is returned when a signature is missing, malformed, or doesn't match
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrSignatureExpired is returned when a signature's timestamp is outside the tolerance
+	ErrSignatureExpired = errors.New("webhook signature expired")
+)
+
+// Sign returns the Webhook-Signature header for body sent at timestamp: "t=<unix seconds>,v1=<hex>", where
+// the signature is the HMAC-SHA256 of "<unix seconds>.<body>" with secret
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + ts + ",v1=" + crypto.SignHMAC([]byte(secret), signedPayload(ts, body))
+}
+
+func signedPayload(ts string, body []byte) []byte {
+	return append([]byte(ts+"."), body...)
+}
+
+// Verify checks a Webhook-Signature header against body. The timestamp must be within tolerance of now,
+// so captured deliveries can't be replayed later; zero tolerance skips that check.
+func Verify(secret, header string, body []byte, tolerance time.Duration) error {
+	ts, signatures := parseSignature(header)
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(unix, 0)); tolerance > 0 && (age > tolerance || age < -tolerance) {
+		return fmt.Errorf("%w: signed %s ago", ErrSignatureExpired, age.Round(time.Second))
+	}
+
+	payload := signedPayload(ts, body)
+	for _, signature := range signatures {
+		if crypto.VerifyHMAC([]byte(secret), payload, signature) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// parseSignature splits a Webhook-Signature header into its timestamp and signatures
+func parseSignature(header string) (ts string, signatures []string) {
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	return ts, signatures
+}
+
+// VerifyRequest reads and verifies a delivery, returning its body. The request body is replaced, so
+// handlers can still read it.
+func VerifyRequest(r *http.Request, secret string, tolerance time.Duration) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := Verify(secret, r.Header.Get(HeaderSignature), body, tolerance); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// VerifyMiddleware rejects deliveries whose signature doesn't verify with 401 Unauthorized
+func VerifyMiddleware(secret string, tolerance time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := VerifyRequest(r, secret, tolerance); err != nil {
+				problem.New("invalid-webhook-signature", "Invalid Webhook Signature", http.StatusUnauthorized,
+					err.Error(), r.URL.Path).Respond(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
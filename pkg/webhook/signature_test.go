@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":"evt_1","type":"order.created"}`)
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		header  string
+		body    []byte
+		wantErr error
+	}{
+		{"valid", Sign("secret", now, body), body, nil},
+		{"wrong secret", Sign("other", now, body), body, ErrInvalidSignature},
+		{"tampered body", Sign("secret", now, body), []byte(`{"id":"evt_2"}`), ErrInvalidSignature},
+		{"expired", Sign("secret", now.Add(-time.Hour), body), body, ErrSignatureExpired},
+		{"future", Sign("secret", now.Add(time.Hour), body), body, ErrSignatureExpired},
+		{"missing", "", body, ErrInvalidSignature},
+		{"no timestamp", "v1=abc", body, ErrInvalidSignature},
+		{"no signature", "t=" + strconv.FormatInt(now.Unix(), 10), body, ErrInvalidSignature},
+		{"rotated secret", Sign("secret", now, body) + ",v1=" + strings.Split(Sign("old", now, body), "v1=")[1],
+			body, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify("secret", tt.header, tt.body, DefaultTolerance)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyZeroTolerance(t *testing.T) {
+	body := []byte("{}")
+	if err := Verify("secret", Sign("secret", time.Now().Add(-24*time.Hour), body), body, 0); err != nil {
+		t.Errorf("Expected zero tolerance to skip the timestamp check, got %v", err)
+	}
+}
+
+func TestSignFormat(t *testing.T) {
+	header := Sign("secret", time.Unix(1700000000, 0), []byte("{}"))
+	if !strings.HasPrefix(header, "t=1700000000,v1=") || len(header) != len("t=1700000000,v1=")+64 {
+		t.Errorf("Unexpected signature header %q", header)
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	body := `{"id":"evt_1"}`
+	req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+	req.Header.Set(HeaderSignature, Sign("secret", time.Now(), []byte(body)))
+
+	got, err := VerifyRequest(req, "secret", DefaultTolerance)
+	if err != nil {
+		t.Fatalf("VerifyRequest failed: %v", err)
+	}
+	if string(got) != body {
+		t.Errorf("Expected body %s, got %s", body, got)
+	}
+
+	again, _ := io.ReadAll(req.Body)
+	if string(again) != body {
+		t.Errorf("Expected request body to be readable again, got %s", again)
+	}
+}
+
+func TestVerifyMiddleware(t *testing.T) {
+	handler := VerifyMiddleware("secret", DefaultTolerance)(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+
+	body := `{"id":"evt_1"}`
+	tests := []struct {
+		name       string
+		signature  string
+		wantStatus int
+	}{
+		{"valid", Sign("secret", time.Now(), []byte(body)), http.StatusOK},
+		{"invalid", Sign("other", time.Now(), []byte(body)), http.StatusUnauthorized},
+		{"missing", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+			if tt.signature != "" {
+				req.Header.Set(HeaderSignature, tt.signature)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if tt.wantStatus == http.StatusOK && rec.Body.String() != body {
+				t.Errorf("Expected handler to read the body, got %s", rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusUnauthorized &&
+				!strings.Contains(rec.Body.String(), "invalid-webhook-signature") {
+				t.Errorf("Expected problem response, got %s", rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,439 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/internal/poller"
+	"github.com/Okja-Engineering/go-service-kit/pkg/crypto"
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+	"github.com/lib/pq"
+)
+
+// ErrNotFound is returned when no endpoint or delivery has the given ID
+var ErrNotFound = errors.New("webhook not found")
+
+// Delivery statuses stored in the deliveries table
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Logger is the logging interface used for delivery failures
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Config holds configuration for webhook endpoints and delivery
+type Config struct {
+	// EndpointsTable and DeliveriesTable are created by Migration
+	EndpointsTable  string
+	DeliveriesTable string
+	// Concurrency is the number of deliveries sent at once
+	Concurrency int
+	// PollInterval is how often idle workers look for deliveries
+	PollInterval time.Duration
+	// Timeout bounds each delivery request
+	Timeout time.Duration
+	// MaxAttempts is how often a delivery is tried before it is marked failed
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry, doubling each attempt up to MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// AllowPrivateNetworks lets endpoints be on loopback, link-local, and private networks, which are
+	// refused by default so subscribers can't reach internal services
+	AllowPrivateNetworks bool
+	// HTTPClient sends deliveries; when nil, a client with Timeout that refuses private networks is used. A
+	// custom client is used as is, so it must do its own filtering.
+	HTTPClient *http.Client
+	UserAgent  string
+	Logger     Logger
+}
+
+// DefaultConfig provides sensible defaults, retrying for about an hour
+func DefaultConfig() *Config {
+	return &Config{
+		EndpointsTable:  "webhook_endpoints",
+		DeliveriesTable: "webhook_deliveries",
+		Concurrency:     4,
+		PollInterval:    time.Second,
+		Timeout:         10 * time.Second,
+		MaxAttempts:     8,
+		BaseBackoff:     30 * time.Second,
+		MaxBackoff:      time.Hour,
+		UserAgent:       "go-service-kit-webhook",
+		Logger:          log.Default(),
+	}
+}
+
+// Option is a functional option for configuring webhooks
+type Option func(*Config)
+
+// WithTables sets the endpoints and deliveries table names
+func WithTables(endpoints, deliveries string) Option {
+	return func(config *Config) {
+		config.EndpointsTable = endpoints
+		config.DeliveriesTable = deliveries
+	}
+}
+
+// WithConcurrency sets the number of deliveries sent at once
+func WithConcurrency(concurrency int) Option {
+	return func(config *Config) {
+		config.Concurrency = concurrency
+	}
+}
+
+// WithPollInterval sets how often idle workers look for deliveries
+func WithPollInterval(interval time.Duration) Option {
+	return func(config *Config) {
+		config.PollInterval = interval
+	}
+}
+
+// WithTimeout sets the timeout for each delivery request
+func WithTimeout(timeout time.Duration) Option {
+	return func(config *Config) {
+		config.Timeout = timeout
+	}
+}
+
+// WithMaxAttempts sets how often a delivery is tried before it is marked failed
+func WithMaxAttempts(attempts int) Option {
+	return func(config *Config) {
+		config.MaxAttempts = attempts
+	}
+}
+
+// WithBackoff sets the retry delay, which starts at base and doubles up to max
+func WithBackoff(base, max time.Duration) Option {
+	return func(config *Config) {
+		config.BaseBackoff = base
+		config.MaxBackoff = max
+	}
+}
+
+// WithPrivateNetworks sets whether endpoints may be on loopback, link-local, and private networks
+func WithPrivateNetworks(allowed bool) Option {
+	return func(config *Config) {
+		config.AllowPrivateNetworks = allowed
+	}
+}
+
+// WithHTTPClient sets the client deliveries are sent with
+func WithHTTPClient(client *http.Client) Option {
+	return func(config *Config) {
+		config.HTTPClient = client
+	}
+}
+
+// WithLogger sets the logger for delivery failures
+func WithLogger(logger Logger) Option {
+	return func(config *Config) {
+		config.Logger = logger
+	}
+}
+
+// NewConfig creates a new webhook config with options
+func NewConfig(options ...Option) *Config {
+	config := DefaultConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Endpoint is a URL that receives events
+type Endpoint struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Secret signs deliveries. Show it to the subscriber once, when the endpoint is registered.
+	Secret string `json:"-"`
+	// Events the endpoint receives; empty receives every event
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Event is the JSON body of every delivery
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Delivery records the attempts to send one event to one endpoint
+type Delivery struct {
+	ID             int64      `json:"id"`
+	EndpointID     string     `json:"endpointId"`
+	EventID        string     `json:"eventId"`
+	EventType      string     `json:"eventType"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastStatusCode int        `json:"lastStatusCode,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+}
+
+// Service registers endpoints, records events for delivery, and runs the workers that send them
+type Service struct {
+	db     database.Database
+	config *Config
+	client *http.Client
+	pool   poller.Pool
+}
+
+// New creates a webhook service backed by the given database
+func New(db database.Database, options ...Option) *Service {
+	config := NewConfig(options...)
+	client := config.HTTPClient
+	if client == nil {
+		client = newHTTPClient(config)
+	}
+	return &Service{db: db, config: config, client: client}
+}
+
+// Migration returns the migration that creates the endpoints and deliveries tables, for use with
+// database.Migrate. Pick a version that fits the service's own migrations.
+func (s *Service) Migration(version int64) database.Migration {
+	endpoints := pq.QuoteIdentifier(s.config.EndpointsTable)
+	deliveries := pq.QuoteIdentifier(s.config.DeliveriesTable)
+	index := pq.QuoteIdentifier(s.config.DeliveriesTable + "_pending")
+
+	up := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id TEXT PRIMARY KEY,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	events TEXT[] NOT NULL DEFAULT '{}',
+	active BOOLEAN NOT NULL DEFAULT true,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS %[2]s (
+	id BIGSERIAL PRIMARY KEY,
+	endpoint_id TEXT NOT NULL REFERENCES %[1]s (id) ON DELETE CASCADE,
+	event_id TEXT NOT NULL,
+	event_type TEXT NOT NULL,
+	body BYTEA NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	last_status_code INT,
+	last_error TEXT,
+	next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	delivered_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS %[3]s ON %[2]s (next_attempt_at) WHERE status = 'pending';`,
+		endpoints, deliveries, index)
+
+	return database.Migration{
+		Version: version,
+		Name:    "create " + s.config.EndpointsTable + " and " + s.config.DeliveriesTable,
+		Up:      up,
+		Down:    fmt.Sprintf("DROP TABLE IF EXISTS %s;\nDROP TABLE IF EXISTS %s", deliveries, endpoints),
+	}
+}
+
+// Register adds an endpoint for the given events, or every event when none are given, with a new signing
+// secret
+func (s *Service) Register(ctx context.Context, endpointURL string, events ...string) (*Endpoint, error) {
+	if err := validateURL(endpointURL, s.config.AllowPrivateNetworks); err != nil {
+		return nil, err
+	}
+
+	db := s.db.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+
+	id, err := crypto.GenerateSecureTokenWithLength(12)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := crypto.GenerateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &Endpoint{ID: "we_" + id, URL: endpointURL, Secret: "whsec_" + secret, Events: events, Active: true}
+	if endpoint.Events == nil {
+		endpoint.Events = []string{}
+	}
+
+	insert := fmt.Sprintf(`INSERT INTO %s (id, url, secret, events) VALUES ($1, $2, $3, $4) RETURNING created_at`,
+		pq.QuoteIdentifier(s.config.EndpointsTable))
+	if err := db.QueryRowContext(ctx, insert, endpoint.ID, endpoint.URL, endpoint.Secret,
+		pq.Array(endpoint.Events)).Scan(&endpoint.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to register webhook endpoint: %w", err)
+	}
+
+	return endpoint, nil
+}
+
+// validateURL checks that an endpoint URL is an absolute http or https URL and, unless allowPrivate,
+// that its host is not a private address. Host names are checked when deliveries connect.
+func validateURL(endpointURL string, allowPrivate bool) error {
+	parsed, err := url.Parse(endpointURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q: must be an absolute http or https URL", endpointURL)
+	}
+	if !allowPrivate && forbiddenHost(parsed.Hostname()) {
+		return fmt.Errorf("invalid webhook URL %q: %w", endpointURL, ErrForbiddenAddress)
+	}
+	return nil
+}
+
+// Endpoints returns every registered endpoint, including its secret
+func (s *Service) Endpoints(ctx context.Context) ([]Endpoint, error) {
+	db := s.db.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+
+	query := fmt.Sprintf(`SELECT id, url, secret, events, active, created_at FROM %s ORDER BY created_at`,
+		pq.QuoteIdentifier(s.config.EndpointsTable))
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var endpoints []Endpoint
+	for rows.Next() {
+		var e Endpoint
+		if err := rows.Scan(&e.ID, &e.URL, &e.Secret, pq.Array(&e.Events), &e.Active, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, e)
+	}
+
+	return endpoints, rows.Err()
+}
+
+// SetActive pauses or resumes deliveries to an endpoint. Events published while it is paused aren't
+// delivered to it.
+func (s *Service) SetActive(ctx context.Context, id string, active bool) error {
+	query := fmt.Sprintf(`UPDATE %s SET active = $1 WHERE id = $2`, pq.QuoteIdentifier(s.config.EndpointsTable))
+	return s.execOne(ctx, query, "update webhook endpoint", active, id)
+}
+
+// Unregister removes an endpoint and its delivery history
+func (s *Service) Unregister(ctx context.Context, id string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, pq.QuoteIdentifier(s.config.EndpointsTable))
+	return s.execOne(ctx, query, "unregister webhook endpoint", id)
+}
+
+// Publish records an event for delivery to every active endpoint subscribed to its type, returning the
+// event ID. data is encoded as JSON.
+func (s *Service) Publish(ctx context.Context, eventType string, data interface{}) (string, error) {
+	db := s.db.GetDB()
+	if db == nil {
+		return "", fmt.Errorf("database connection is closed")
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin publish: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	id, err := s.PublishTx(ctx, tx, eventType, data)
+	if err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit publish: %w", err)
+	}
+
+	return id, nil
+}
+
+// PublishTx records an event in tx, so it is only delivered if the surrounding transaction commits
+func (s *Service) PublishTx(ctx context.Context, tx *sql.Tx, eventType string, data interface{}) (string, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	id, err := crypto.GenerateSecureTokenWithLength(12)
+	if err != nil {
+		return "", err
+	}
+	event := Event{ID: "evt_" + id, Type: eventType, CreatedAt: time.Now().UTC(), Data: raw}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	insert := fmt.Sprintf(`INSERT INTO %s (endpoint_id, event_id, event_type, body)
+		SELECT id, $1, $2, $3 FROM %s WHERE active AND (cardinality(events) = 0 OR $2 = ANY(events))`,
+		pq.QuoteIdentifier(s.config.DeliveriesTable), pq.QuoteIdentifier(s.config.EndpointsTable))
+	if _, err := tx.ExecContext(ctx, insert, event.ID, event.Type, body); err != nil {
+		return "", fmt.Errorf("failed to publish webhook event: %w", err)
+	}
+
+	return event.ID, nil
+}
+
+// Deliveries returns an endpoint's most recent deliveries, newest first
+func (s *Service) Deliveries(ctx context.Context, endpointID string, limit int) ([]Delivery, error) {
+	db := s.db.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+
+	query := fmt.Sprintf(`SELECT id, endpoint_id, event_id, event_type, status, attempts,
+		coalesce(last_status_code, 0), coalesce(last_error, ''), next_attempt_at, created_at, delivered_at
+		FROM %s WHERE endpoint_id = $1 ORDER BY id DESC LIMIT $2`, pq.QuoteIdentifier(s.config.DeliveriesTable))
+	rows, err := db.QueryContext(ctx, query, endpointID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var d Delivery
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+			&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt); err != nil {
+			return nil, fmt.Errorf("failed to read webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// Redeliver sends a delivery again with a fresh set of attempts, whatever its status
+func (s *Service) Redeliver(ctx context.Context, id int64) error {
+	query := fmt.Sprintf(`UPDATE %s SET status = $1, attempts = 0, next_attempt_at = now() WHERE id = $2`,
+		pq.QuoteIdentifier(s.config.DeliveriesTable))
+	return s.execOne(ctx, query, "redeliver webhook", StatusPending, id)
+}
+
+// execOne runs a statement that should affect one row, returning ErrNotFound when it affects none
+func (s *Service) execOne(ctx context.Context, query, action string, args ...interface{}) error {
+	db := s.db.GetDB()
+	if db == nil {
+		return fmt.Errorf("database connection is closed")
+	}
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("failed to %s: %w", action, ErrNotFound)
+	}
+	return nil
+}
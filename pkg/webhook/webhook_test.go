@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/database"
+)
+
+// newTestService creates a service whose database was never connected
+func newTestService(options ...Option) *Service {
+	return New(database.NewPostgreSQL(database.NewConfig()), options...)
+}
+
+func TestNewConfigDefaults(t *testing.T) {
+	config := NewConfig()
+
+	if config.EndpointsTable != "webhook_endpoints" || config.DeliveriesTable != "webhook_deliveries" {
+		t.Errorf("Expected default table names, got %s and %s", config.EndpointsTable, config.DeliveriesTable)
+	}
+	if config.Concurrency != 4 || config.MaxAttempts != 8 {
+		t.Errorf("Expected concurrency 4 and 8 attempts, got %d and %d", config.Concurrency, config.MaxAttempts)
+	}
+	if config.Timeout != 10*time.Second || config.BaseBackoff != 30*time.Second || config.MaxBackoff != time.Hour {
+		t.Errorf("Unexpected timing defaults: %+v", config)
+	}
+}
+
+func TestNewConfigOptions(t *testing.T) {
+	client := &http.Client{}
+	config := NewConfig(
+		WithTables("hooks", "hook_deliveries"),
+		WithConcurrency(2),
+		WithPollInterval(time.Minute),
+		WithTimeout(time.Second),
+		WithMaxAttempts(3),
+		WithBackoff(time.Millisecond, time.Second),
+		WithPrivateNetworks(true),
+		WithHTTPClient(client),
+	)
+
+	if config.EndpointsTable != "hooks" || config.DeliveriesTable != "hook_deliveries" {
+		t.Errorf("Expected table names to be overridden, got %s and %s", config.EndpointsTable,
+			config.DeliveriesTable)
+	}
+	if config.Concurrency != 2 || config.PollInterval != time.Minute || config.Timeout != time.Second {
+		t.Errorf("Expected worker settings to be overridden, got %+v", config)
+	}
+	if config.MaxAttempts != 3 || config.BaseBackoff != time.Millisecond || config.MaxBackoff != time.Second {
+		t.Errorf("Expected retry settings to be overridden, got %+v", config)
+	}
+	if config.HTTPClient != client || !config.AllowPrivateNetworks {
+		t.Error("Expected HTTP client and private networks to be overridden")
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	if s := newTestService(WithTimeout(3 * time.Second)); s.client.Timeout != 3*time.Second {
+		t.Errorf("Expected default client to use the delivery timeout, got %s", s.client.Timeout)
+	}
+
+	client := &http.Client{}
+	if s := newTestService(WithHTTPClient(client)); s.client != client {
+		t.Error("Expected the configured client to be used")
+	}
+}
+
+func TestMigration(t *testing.T) {
+	s := newTestService(WithTables("hooks", "hook_deliveries"))
+	migration := s.Migration(7)
+
+	if migration.Version != 7 {
+		t.Errorf("Expected version 7, got %d", migration.Version)
+	}
+	for _, want := range []string{
+		`CREATE TABLE IF NOT EXISTS "hooks"`,
+		`CREATE TABLE IF NOT EXISTS "hook_deliveries"`,
+		`REFERENCES "hooks" (id) ON DELETE CASCADE`,
+		`CREATE INDEX IF NOT EXISTS "hook_deliveries_pending"`,
+	} {
+		if !strings.Contains(migration.Up, want) {
+			t.Errorf("Expected migration to contain %q, got:\n%s", want, migration.Up)
+		}
+	}
+	if migration.Down != "DROP TABLE IF EXISTS \"hook_deliveries\";\nDROP TABLE IF EXISTS \"hooks\"" {
+		t.Errorf("Unexpected down migration: %s", migration.Down)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		wantErr      bool
+	}{
+		{"https://example.com/hooks", false, false},
+		{"http://localhost:8080/hooks", false, true},
+		{"http://localhost:8080/hooks", true, false},
+		{"http://127.0.0.1/hooks", false, true},
+		{"http://169.254.169.254/latest/meta-data", false, true},
+		{"http://10.0.0.5/hooks", false, true},
+		{"http://[::1]/hooks", false, true},
+		{"http://93.184.216.34/hooks", false, false},
+		{"ftp://example.com/hooks", false, true},
+		{"/hooks", false, true},
+		{"https://", false, true},
+		{"://bad", false, true},
+	}
+
+	for _, tt := range tests {
+		if err := validateURL(tt.url, tt.allowPrivate); (err != nil) != tt.wantErr {
+			t.Errorf("validateURL(%q, %v) error = %v, wantErr %v", tt.url, tt.allowPrivate, err, tt.wantErr)
+		}
+	}
+}
+
+func TestServiceNotConnected(t *testing.T) {
+	s := newTestService()
+	ctx := context.Background()
+
+	if _, err := s.Register(ctx, "https://example.com/hooks"); err == nil {
+		t.Error("Expected Register to fail without a connection")
+	}
+	if _, err := s.Register(ctx, "not a url"); err == nil || !strings.Contains(err.Error(), "invalid webhook URL") {
+		t.Errorf("Expected URL to be validated before connecting, got %v", err)
+	}
+	if _, err := s.Endpoints(ctx); err == nil {
+		t.Error("Expected Endpoints to fail without a connection")
+	}
+	if _, err := s.Publish(ctx, "order.created", map[string]string{"id": "1"}); err == nil {
+		t.Error("Expected Publish to fail without a connection")
+	}
+	if _, err := s.Deliveries(ctx, "we_1", 10); err == nil {
+		t.Error("Expected Deliveries to fail without a connection")
+	}
+	if err := s.Redeliver(ctx, 1); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a connection error from Redeliver, got %v", err)
+	}
+	if err := s.SetActive(ctx, "we_1", false); err == nil {
+		t.Error("Expected SetActive to fail without a connection")
+	}
+	if err := s.Unregister(ctx, "we_1"); err == nil {
+		t.Error("Expected Unregister to fail without a connection")
+	}
+}
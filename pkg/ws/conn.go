@@ -0,0 +1,286 @@
+package ws
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// ErrConnClosed is returned when sending on a connection that is closing or closed
+	ErrConnClosed = errors.New("websocket connection closed")
+	// ErrSendBufferFull is returned when a client reads too slowly to keep up; the connection is closed
+	ErrSendBufferFull = errors.New("websocket send buffer full")
+)
+
+// outbound is a message waiting for the write pump
+type outbound struct {
+	messageType int
+	data        []byte
+}
+
+// Conn is an open WebSocket connection. Sends are queued and written by the connection's own
+// goroutine, so they are safe to call from anywhere.
+type Conn struct {
+	server  *Server
+	ws      *websocket.Conn
+	request *http.Request
+	id      string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	send   chan outbound
+
+	closeOnce sync.Once
+	closing   chan struct{}
+	closeMsg  []byte
+
+	mu       sync.Mutex
+	claims   jwt.MapClaims
+	onClose  []func()
+	finished bool
+}
+
+func newConn(s *Server, wsConn *websocket.Conn, ctx context.Context, r *http.Request) *Conn {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Conn{
+		server:  s,
+		ws:      wsConn,
+		request: r,
+		id:      newConnID(),
+		ctx:     ctx,
+		cancel:  cancel,
+		send:    make(chan outbound, max(s.config.SendBuffer, 1)),
+		closing: make(chan struct{}),
+	}
+}
+
+// newConnID returns a random identifier for logs and hub bookkeeping
+func newConnID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ID returns a random identifier for the connection
+func (c *Conn) ID() string {
+	return c.id
+}
+
+// Request returns the upgrade request, for its headers, query, and route parameters
+func (c *Conn) Request() *http.Request {
+	return c.request
+}
+
+// RemoteAddr returns the client's network address
+func (c *Conn) RemoteAddr() string {
+	return c.ws.RemoteAddr().String()
+}
+
+// Subprotocol returns the negotiated subprotocol, if any
+func (c *Conn) Subprotocol() string {
+	return c.ws.Subprotocol()
+}
+
+// Context carries the upgrade request's values and the client's claims, and is done once the connection closes
+func (c *Conn) Context() context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ctx
+}
+
+// Claims returns the claims of the authenticated client, or nil without an authenticator
+func (c *Conn) Claims() jwt.MapClaims {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.claims
+}
+
+// UserID returns the authenticated client's user ID, read from the same claims as auth.GetUserIDFromContext
+func (c *Conn) UserID() string {
+	userID, _ := auth.GetUserIDFromContext(c.Context())
+	return userID
+}
+
+// setClaims records the client's claims and makes them available from Context
+func (c *Conn) setClaims(claims jwt.MapClaims) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.claims = claims
+	c.ctx = context.WithValue(c.ctx, auth.JWTClaimsKey, claims)
+}
+
+// Send queues a text message
+func (c *Conn) Send(data []byte) error {
+	return c.enqueue(outbound{messageType: websocket.TextMessage, data: data})
+}
+
+// SendBinary queues a binary message
+func (c *Conn) SendBinary(data []byte) error {
+	return c.enqueue(outbound{messageType: websocket.BinaryMessage, data: data})
+}
+
+// SendJSON encodes v as JSON and queues it as a text message
+func (c *Conn) SendJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	return c.Send(data)
+}
+
+// enqueue hands a message to the write pump, closing connections that fall too far behind
+func (c *Conn) enqueue(msg outbound) error {
+	select {
+	case <-c.closing:
+		return ErrConnClosed
+	case <-c.ctx.Done():
+		return ErrConnClosed
+	default:
+	}
+
+	select {
+	case c.send <- msg:
+		return nil
+	default:
+		c.Close(websocket.CloseTryAgainLater, "client too slow")
+		return ErrSendBufferFull
+	}
+}
+
+// Close starts the closing handshake with the given code and reason. Queued messages are written first.
+func (c *Conn) Close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeMsg = websocket.FormatCloseMessage(code, reason)
+		close(c.closing)
+	})
+}
+
+// closeWith sends a close frame directly, for connections whose write pump isn't running
+func (c *Conn) closeWith(code int, reason string) {
+	deadline := time.Now().Add(c.server.config.WriteTimeout)
+	_ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+}
+
+// OnClose registers fn to run once the connection has closed, straight away if it already has
+func (c *Conn) OnClose(fn func()) {
+	c.mu.Lock()
+	if c.finished {
+		c.mu.Unlock()
+		fn()
+		return
+	}
+	c.onClose = append(c.onClose, fn)
+	c.mu.Unlock()
+}
+
+// finish releases the connection and runs its close callbacks
+func (c *Conn) finish() {
+	c.mu.Lock()
+	c.finished = true
+	callbacks := c.onClose
+	c.onClose = nil
+	c.mu.Unlock()
+
+	c.cancel()
+	_ = c.ws.Close()
+	for _, fn := range callbacks {
+		fn()
+	}
+}
+
+// readPump reads messages until the connection closes or a pong is overdue. It returns nil for a
+// normal close and the error that ended the connection otherwise.
+func (c *Conn) readPump(onMessage func(c *Conn, data []byte)) error {
+	config := c.server.config
+	c.ws.SetReadLimit(config.ReadLimit)
+	_ = c.ws.SetReadDeadline(time.Now().Add(config.PongTimeout))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(config.PongTimeout))
+	})
+
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			if c.closedByServer() || websocket.IsCloseError(err, websocket.CloseNormalClosure,
+				websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+				return nil
+			}
+			return err
+		}
+
+		_ = c.ws.SetReadDeadline(time.Now().Add(config.PongTimeout))
+		if onMessage != nil {
+			onMessage(c, data)
+		}
+	}
+}
+
+func (c *Conn) closedByServer() bool {
+	select {
+	case <-c.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// writePump writes queued messages and pings until the connection closes
+func (c *Conn) writePump() {
+	config := c.server.config
+	ticker := time.NewTicker(config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-c.send:
+			if err := c.write(msg); err != nil {
+				_ = c.ws.Close()
+				return
+			}
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(config.WriteTimeout)); err != nil {
+				_ = c.ws.Close()
+				return
+			}
+		case <-c.closing:
+			c.drain()
+			_ = c.ws.WriteControl(websocket.CloseMessage, c.closeMsg, time.Now().Add(config.WriteTimeout))
+			// Give the client a moment to reply before the read pump gives up
+			_ = c.ws.SetReadDeadline(time.Now().Add(config.WriteTimeout))
+			return
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// drain writes messages queued before Close
+func (c *Conn) drain() {
+	for {
+		select {
+		case msg := <-c.send:
+			if err := c.write(msg); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (c *Conn) write(msg outbound) error {
+	_ = c.ws.SetWriteDeadline(time.Now().Add(c.server.config.WriteTimeout))
+	return c.ws.WriteMessage(msg.messageType, msg.data)
+}
@@ -0,0 +1,94 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestConnSendBufferFull(t *testing.T) {
+	c := newConn(NewServer(WithSendBuffer(1)), nil, context.Background(), nil)
+
+	if err := c.Send([]byte("one")); err != nil {
+		t.Fatalf("Expected the first send to queue, got %v", err)
+	}
+	if err := c.Send([]byte("two")); !errors.Is(err, ErrSendBufferFull) {
+		t.Errorf("Expected ErrSendBufferFull, got %v", err)
+	}
+	if err := c.Send([]byte("three")); !errors.Is(err, ErrConnClosed) {
+		t.Errorf("Expected a slow client to be closed, got %v", err)
+	}
+}
+
+func TestConnSendJSONError(t *testing.T) {
+	c := newConn(NewServer(), nil, context.Background(), nil)
+
+	if err := c.SendJSON(make(chan int)); err == nil {
+		t.Error("Expected an encoding error")
+	}
+}
+
+func TestConnPingKeepsAlive(t *testing.T) {
+	_, srv := newTestServer(t, Handler{
+		OnMessage: func(c *Conn, data []byte) { _ = c.Send(data) },
+	}, WithPing(10*time.Millisecond, 50*time.Millisecond))
+
+	conn, _, err := dial(t, srv, "", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+
+	// Reading lets the client answer pings, well past the pong timeout
+	time.AfterFunc(150*time.Millisecond, func() {
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("still here"))
+	})
+	if got := readText(t, conn); got != "still here" {
+		t.Errorf("Expected the connection to stay open, got %q", got)
+	}
+}
+
+func TestConnPongTimeout(t *testing.T) {
+	disconnected := make(chan error, 1)
+	_, srv := newTestServer(t, Handler{
+		OnDisconnect: func(c *Conn, err error) { disconnected <- err },
+	}, WithPing(10*time.Millisecond, 30*time.Millisecond))
+
+	// A client that never reads never answers pings
+	if _, _, err := dial(t, srv, "", nil); err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+
+	select {
+	case err := <-disconnected:
+		if err == nil {
+			t.Error("Expected a timeout error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the unresponsive client to be disconnected")
+	}
+}
+
+func TestConnReadLimit(t *testing.T) {
+	disconnected := make(chan error, 1)
+	_, srv := newTestServer(t, Handler{
+		OnDisconnect: func(c *Conn, err error) { disconnected <- err },
+	}, WithReadLimit(8))
+
+	conn, _, err := dial(t, srv, "", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	_ = conn.WriteMessage(websocket.TextMessage, []byte("much too long"))
+
+	select {
+	case err := <-disconnected:
+		if !errors.Is(err, websocket.ErrReadLimit) {
+			t.Errorf("Expected ErrReadLimit, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected an oversized message to close the connection")
+	}
+}
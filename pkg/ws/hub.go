@@ -0,0 +1,99 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Hub groups connections into named rooms for broadcasting. Connections leave every room when they close.
+type Hub struct {
+	mu    sync.RWMutex
+	rooms map[string]map[*Conn]struct{}
+}
+
+// NewHub creates an empty hub
+func NewHub() *Hub {
+	return &Hub{rooms: make(map[string]map[*Conn]struct{})}
+}
+
+// Join adds a connection to a room
+func (h *Hub) Join(room string, c *Conn) {
+	h.mu.Lock()
+	members, ok := h.rooms[room]
+	if !ok {
+		members = make(map[*Conn]struct{})
+		h.rooms[room] = members
+	}
+	_, joined := members[c]
+	members[c] = struct{}{}
+	h.mu.Unlock()
+
+	if !joined {
+		c.OnClose(func() { h.Leave(room, c) })
+	}
+}
+
+// Leave removes a connection from a room
+func (h *Hub) Leave(room string, c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	members, ok := h.rooms[room]
+	if !ok {
+		return
+	}
+	delete(members, c)
+	if len(members) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// Broadcast queues a text message for every connection in a room and returns how many accepted it.
+// Connections too slow to keep up are closed rather than holding up the others.
+func (h *Hub) Broadcast(room string, data []byte) int {
+	h.mu.RLock()
+	members := make([]*Conn, 0, len(h.rooms[room]))
+	for c := range h.rooms[room] {
+		members = append(members, c)
+	}
+	h.mu.RUnlock()
+
+	sent := 0
+	for _, c := range members {
+		if c.Send(data) == nil {
+			sent++
+		}
+	}
+	return sent
+}
+
+// BroadcastJSON encodes v once and broadcasts it to a room
+func (h *Hub) BroadcastJSON(room string, v interface{}) (int, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode message: %w", err)
+	}
+	return h.Broadcast(room, data), nil
+}
+
+// Members returns the number of connections in a room
+func (h *Hub) Members(room string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rooms[room])
+}
+
+// Rooms lists the rooms with at least one connection, sorted by name
+func (h *Hub) Rooms() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	rooms := make([]string, 0, len(h.rooms))
+	for room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	sort.Strings(rooms)
+	return rooms
+}
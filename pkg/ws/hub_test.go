@@ -0,0 +1,71 @@
+package ws
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestHubJoinLeave(t *testing.T) {
+	s := NewServer()
+	a := newConn(s, nil, context.Background(), nil)
+	b := newConn(s, nil, context.Background(), nil)
+
+	hub := NewHub()
+	hub.Join("orders", a)
+	hub.Join("orders", a)
+	hub.Join("orders", b)
+	hub.Join("alerts", b)
+
+	if got := hub.Members("orders"); got != 2 {
+		t.Errorf("Expected 2 members, got %d", got)
+	}
+	if got := hub.Rooms(); !reflect.DeepEqual(got, []string{"alerts", "orders"}) {
+		t.Errorf("Unexpected rooms: %v", got)
+	}
+
+	hub.Leave("alerts", b)
+	if got := hub.Rooms(); !reflect.DeepEqual(got, []string{"orders"}) {
+		t.Errorf("Expected empty rooms to be removed, got %v", got)
+	}
+
+	if sent := hub.Broadcast("orders", []byte("hi")); sent != 2 {
+		t.Errorf("Expected the message queued for 2 members, got %d", sent)
+	}
+	if sent, err := hub.BroadcastJSON("missing", map[string]int{"n": 1}); err != nil || sent != 0 {
+		t.Errorf("Expected nothing sent to an empty room, got %d, %v", sent, err)
+	}
+}
+
+func TestHubBroadcastAndDisconnect(t *testing.T) {
+	hub := NewHub()
+	s, srv := newTestServer(t, Handler{
+		OnConnect: func(c *Conn) error {
+			hub.Join("orders", c)
+			return nil
+		},
+	})
+
+	first, _, err := dial(t, srv, "", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	second, _, err := dial(t, srv, "", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	waitFor(t, func() bool { return hub.Members("orders") == 2 })
+
+	if sent, err := hub.BroadcastJSON("orders", map[string]string{"status": "shipped"}); err != nil || sent != 2 {
+		t.Fatalf("Expected broadcast to 2 members, got %d, %v", sent, err)
+	}
+	if got := readText(t, first); got != `{"status":"shipped"}` {
+		t.Errorf("Unexpected message: %s", got)
+	}
+	if got := readText(t, second); got != `{"status":"shipped"}` {
+		t.Errorf("Unexpected message: %s", got)
+	}
+
+	_ = first.Close()
+	waitFor(t, func() bool { return hub.Members("orders") == 1 && s.Conns() == 1 })
+}
@@ -0,0 +1,372 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/Okja-Engineering/go-service-kit/pkg/problem"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+)
+
+// ErrServerClosed is returned by Handle once Shutdown has started
+var ErrServerClosed = errors.New("websocket server closed")
+
+// Authenticator validates the bearer token of an upgrade request; any auth.Validator implements it
+type Authenticator interface {
+	ValidateRequest(r *http.Request) auth.ValidationResult
+}
+
+// Logger is the logging interface used by the server
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Config holds configuration for WebSocket connections
+type Config struct {
+	// ReadLimit is the largest message accepted from a client, larger ones close the connection
+	ReadLimit int64
+	// WriteTimeout bounds each write, so a stalled client can't hold up its writer
+	WriteTimeout time.Duration
+	// PingInterval is how often pings are sent; PongTimeout is how long to wait for any message or pong
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+	// SendBuffer is how many outgoing messages may queue per connection before sends fail
+	SendBuffer int
+	// Authenticator, when set, requires a valid token at upgrade or in the first message
+	Authenticator Authenticator
+	// AuthTimeout is how long a client has to send its auth message when it didn't send a token at upgrade
+	AuthTimeout time.Duration
+	// CheckOrigin decides which origins may connect; nil allows only the request's own host
+	CheckOrigin func(r *http.Request) bool
+	// Subprotocols are offered to clients in order of preference
+	Subprotocols []string
+	Logger       Logger
+}
+
+// DefaultConfig provides sensible defaults
+func DefaultConfig() *Config {
+	return &Config{
+		ReadLimit:    64 << 10,
+		WriteTimeout: 10 * time.Second,
+		PingInterval: 50 * time.Second,
+		PongTimeout:  60 * time.Second,
+		SendBuffer:   64,
+		AuthTimeout:  10 * time.Second,
+		Logger:       log.Default(),
+	}
+}
+
+// Option is a functional option for configuring a server
+type Option func(*Config)
+
+// WithReadLimit sets the largest message accepted from a client
+func WithReadLimit(limit int64) Option {
+	return func(config *Config) {
+		config.ReadLimit = limit
+	}
+}
+
+// WithWriteTimeout sets how long each write may take
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(config *Config) {
+		config.WriteTimeout = timeout
+	}
+}
+
+// WithPing sets how often pings are sent and how long to wait for a pong before closing.
+// The timeout should be longer than the interval.
+func WithPing(interval, timeout time.Duration) Option {
+	return func(config *Config) {
+		config.PingInterval = interval
+		config.PongTimeout = timeout
+	}
+}
+
+// WithSendBuffer sets how many outgoing messages may queue per connection
+func WithSendBuffer(size int) Option {
+	return func(config *Config) {
+		config.SendBuffer = size
+	}
+}
+
+// WithAuthenticator requires connections to authenticate with a token
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(config *Config) {
+		config.Authenticator = authenticator
+	}
+}
+
+// WithAuthTimeout sets how long a client has to send its auth message
+func WithAuthTimeout(timeout time.Duration) Option {
+	return func(config *Config) {
+		config.AuthTimeout = timeout
+	}
+}
+
+// WithCheckOrigin sets which origins may connect
+func WithCheckOrigin(check func(r *http.Request) bool) Option {
+	return func(config *Config) {
+		config.CheckOrigin = check
+	}
+}
+
+// WithSubprotocols sets the subprotocols offered to clients
+func WithSubprotocols(protocols ...string) Option {
+	return func(config *Config) {
+		config.Subprotocols = protocols
+	}
+}
+
+// WithLogger sets the logger for connection errors and lifecycle messages
+func WithLogger(logger Logger) Option {
+	return func(config *Config) {
+		config.Logger = logger
+	}
+}
+
+// NewConfig creates a new server config with options
+func NewConfig(options ...Option) *Config {
+	config := DefaultConfig()
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Handler receives connection events. OnMessage is called for each message in the order received,
+// on the connection's read goroutine. Any of the functions may be nil.
+type Handler struct {
+	// OnConnect runs once the connection is upgraded and authenticated; an error closes it
+	OnConnect func(c *Conn) error
+	// OnMessage runs for each text or binary message
+	OnMessage func(c *Conn, data []byte)
+	// OnDisconnect runs once the connection has closed, with the error that ended it if any
+	OnDisconnect func(c *Conn, err error)
+}
+
+// Server upgrades requests to WebSocket connections and tracks them for graceful shutdown
+type Server struct {
+	config   *Config
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	conns   map[*Conn]struct{}
+	closing bool
+	wg      sync.WaitGroup
+}
+
+// NewServer creates a WebSocket server with options
+func NewServer(options ...Option) *Server {
+	config := NewConfig(options...)
+	return &Server{
+		config: config,
+		upgrader: websocket.Upgrader{
+			CheckOrigin:  config.CheckOrigin,
+			Subprotocols: config.Subprotocols,
+		},
+		conns: make(map[*Conn]struct{}),
+	}
+}
+
+// Handle returns a handler that upgrades requests on a route and runs the connection with h.
+// A token may be sent as a bearer Authorization header or an access_token query parameter; browsers,
+// which can't set headers on WebSocket requests, can instead send {"type":"auth","token":"..."} as the
+// first message.
+func (s *Server) Handle(h Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var claims jwt.MapClaims
+		authenticated := s.config.Authenticator == nil
+		token := bearerToken(r)
+		if !authenticated && token != "" {
+			result := s.config.Authenticator.ValidateRequest(withBearer(r, token))
+			if !result.Valid {
+				problem.New("unauthorized", "Unauthorized", http.StatusUnauthorized, result.Error,
+					r.URL.Path).Respond(w, r)
+				return
+			}
+			claims, authenticated = result.Claims, true
+		}
+
+		if s.isClosing() {
+			problem.New("shutting-down", "Service Unavailable", http.StatusServiceUnavailable,
+				ErrServerClosed.Error(), r.URL.Path).Respond(w, r)
+			return
+		}
+
+		wsConn, err := s.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// The upgrader has already sent an error response
+			s.config.Logger.Printf("### 🔌 WebSocket: upgrade failed for %s: %v", r.URL.Path, err)
+			return
+		}
+
+		// The request context ends when this handler returns, so the connection keeps only its values
+		c := newConn(s, wsConn, context.WithoutCancel(r.Context()), r)
+		if !s.track(c) {
+			c.closeWith(websocket.CloseGoingAway, "server shutting down")
+			_ = wsConn.Close()
+			return
+		}
+
+		go s.run(c, h, claims, authenticated)
+	}
+}
+
+// run authenticates the connection if needed, then runs its pumps until it closes
+func (s *Server) run(c *Conn, h Handler, claims jwt.MapClaims, authenticated bool) {
+	defer s.untrack(c)
+
+	if !authenticated {
+		var err error
+		if claims, err = s.authenticate(c); err != nil {
+			c.closeWith(websocket.ClosePolicyViolation, "authentication failed")
+			_ = c.ws.Close()
+			s.config.Logger.Printf("### 🔌 WebSocket: %s rejected: %v", c.RemoteAddr(), err)
+			return
+		}
+	}
+	if claims != nil {
+		c.setClaims(claims)
+	}
+
+	go c.writePump()
+
+	if h.OnConnect != nil {
+		if err := h.OnConnect(c); err != nil {
+			c.Close(websocket.ClosePolicyViolation, err.Error())
+			c.readPump(nil)
+			return
+		}
+	}
+
+	err := c.readPump(h.OnMessage)
+	if h.OnDisconnect != nil {
+		h.OnDisconnect(c, err)
+	}
+}
+
+// authMessage is the first message a client sends when it didn't authenticate at upgrade
+type authMessage struct {
+	Type  string `json:"type"`
+	Token string `json:"token"`
+}
+
+// authenticate waits for an auth message and validates its token
+func (s *Server) authenticate(c *Conn) (jwt.MapClaims, error) {
+	_ = c.ws.SetReadDeadline(time.Now().Add(s.config.AuthTimeout))
+	_, data, err := c.ws.ReadMessage()
+	if err != nil {
+		return nil, fmt.Errorf("no auth message: %w", err)
+	}
+
+	var msg authMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "auth" || msg.Token == "" {
+		return nil, errors.New("first message must be an auth message")
+	}
+
+	result := s.config.Authenticator.ValidateRequest(withBearer(c.request, msg.Token))
+	if !result.Valid {
+		return nil, fmt.Errorf("%s: %s", result.ErrorCode, result.Error)
+	}
+
+	_ = c.ws.SetWriteDeadline(time.Now().Add(s.config.WriteTimeout))
+	if err := c.ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"authenticated"}`)); err != nil {
+		return nil, fmt.Errorf("failed to acknowledge auth: %w", err)
+	}
+
+	return result.Claims, nil
+}
+
+// Conns returns the number of open connections
+func (s *Server) Conns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// Shutdown closes every connection with a going-away close frame and waits for them to finish, or for
+// ctx to be done. New upgrades are refused from then on.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	conns := make([]*Conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		c.Close(websocket.CloseGoingAway, "server shutting down")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.config.Logger.Printf("### 🔌 WebSocket: closed %d connection(s) at shutdown", len(conns))
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for c := range s.conns {
+			_ = c.ws.Close()
+		}
+		s.mu.Unlock()
+		return fmt.Errorf("websocket connections still open at shutdown: %w", ctx.Err())
+	}
+}
+
+func (s *Server) isClosing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closing
+}
+
+// track registers a connection, refusing it once shutdown has started
+func (s *Server) track(c *Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closing {
+		return false
+	}
+	s.conns[c] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server) untrack(c *Conn) {
+	c.finish()
+
+	s.mu.Lock()
+	delete(s.conns, c)
+	s.mu.Unlock()
+	s.wg.Done()
+}
+
+// bearerToken reads a token from the Authorization header or the access_token query parameter
+func bearerToken(r *http.Request) string {
+	if parts := strings.Fields(r.Header.Get("Authorization")); len(parts) == 2 && strings.EqualFold(parts[0], "bearer") {
+		return parts[1]
+	}
+	return r.URL.Query().Get("access_token")
+}
+
+// withBearer returns a copy of r carrying token in its Authorization header, for validators that read it there
+func withBearer(r *http.Request, token string) *http.Request {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
@@ -0,0 +1,291 @@
+package ws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Okja-Engineering/go-service-kit/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+)
+
+type syncLogger struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *syncLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(&l.buf, format+"\n", v...)
+}
+
+// stubAuthenticator accepts a single token
+type stubAuthenticator struct {
+	token string
+}
+
+func (a stubAuthenticator) ValidateRequest(r *http.Request) auth.ValidationResult {
+	if r.Header.Get("Authorization") == "Bearer "+a.token {
+		return auth.ValidationResult{Valid: true, Claims: jwt.MapClaims{"sub": "user-1"}}
+	}
+	return auth.ValidationResult{ErrorCode: "INVALID_TOKEN", Error: "bad token"}
+}
+
+// echoHandler sends every message back, and greets clients with their user ID
+var echoHandler = Handler{
+	OnConnect: func(c *Conn) error {
+		return c.SendJSON(map[string]string{"user": c.UserID()})
+	},
+	OnMessage: func(c *Conn, data []byte) {
+		_ = c.Send(data)
+	},
+}
+
+func newTestServer(t *testing.T, h Handler, options ...Option) (*Server, *httptest.Server) {
+	t.Helper()
+
+	s := NewServer(append([]Option{WithLogger(&syncLogger{})}, options...)...)
+	srv := httptest.NewServer(s.Handle(h))
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func dial(t *testing.T, srv *httptest.Server, query string, header http.Header) (*websocket.Conn, *http.Response,
+	error) {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + query
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if conn != nil {
+		t.Cleanup(func() { _ = conn.Close() })
+	}
+	return conn, resp, err
+}
+
+func readText(t *testing.T, conn *websocket.Conn) string {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	return string(data)
+}
+
+func TestNewConfigOptions(t *testing.T) {
+	config := NewConfig(
+		WithReadLimit(1024),
+		WithWriteTimeout(time.Second),
+		WithPing(time.Second, 2*time.Second),
+		WithSendBuffer(8),
+		WithAuthTimeout(3*time.Second),
+		WithSubprotocols("v1.events"),
+	)
+
+	if config.ReadLimit != 1024 || config.WriteTimeout != time.Second || config.SendBuffer != 8 {
+		t.Errorf("Expected limits to be overridden, got %+v", config)
+	}
+	if config.PingInterval != time.Second || config.PongTimeout != 2*time.Second {
+		t.Errorf("Expected ping settings to be overridden, got %s and %s", config.PingInterval, config.PongTimeout)
+	}
+	if config.AuthTimeout != 3*time.Second || len(config.Subprotocols) != 1 {
+		t.Errorf("Expected auth timeout and subprotocols to be set, got %+v", config)
+	}
+	if DefaultConfig().PingInterval >= DefaultConfig().PongTimeout {
+		t.Error("Expected the default ping interval to be shorter than the pong timeout")
+	}
+}
+
+func TestServerEcho(t *testing.T) {
+	_, srv := newTestServer(t, echoHandler)
+
+	conn, _, err := dial(t, srv, "", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+
+	if got := readText(t, conn); got != `{"user":""}` {
+		t.Errorf("Expected an anonymous greeting, got %s", got)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got := readText(t, conn); got != "hello" {
+		t.Errorf("Expected echo, got %q", got)
+	}
+}
+
+func TestServerAuthAtUpgrade(t *testing.T) {
+	_, srv := newTestServer(t, echoHandler, WithAuthenticator(stubAuthenticator{token: "secret"}))
+
+	tests := []struct {
+		name     string
+		query    string
+		header   http.Header
+		wantUser string
+		wantCode int
+	}{
+		{"header", "", http.Header{"Authorization": {"Bearer secret"}}, `{"user":"user-1"}`, 0},
+		{"query", "?access_token=secret", nil, `{"user":"user-1"}`, 0},
+		{"invalid", "", http.Header{"Authorization": {"Bearer wrong"}}, "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, resp, err := dial(t, srv, tt.query, tt.header)
+			if tt.wantCode != 0 {
+				if err == nil || resp == nil || resp.StatusCode != tt.wantCode {
+					t.Fatalf("Expected status %d, got %v", tt.wantCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Dial failed: %v", err)
+			}
+			if got := readText(t, conn); got != tt.wantUser {
+				t.Errorf("Expected %s, got %s", tt.wantUser, got)
+			}
+		})
+	}
+}
+
+func TestServerAuthMessage(t *testing.T) {
+	_, srv := newTestServer(t, echoHandler, WithAuthenticator(stubAuthenticator{token: "secret"}),
+		WithAuthTimeout(time.Second))
+
+	conn, _, err := dial(t, srv, "", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"auth","token":"secret"}`))
+
+	if got := readText(t, conn); got != `{"type":"authenticated"}` {
+		t.Errorf("Expected an auth acknowledgement, got %s", got)
+	}
+	if got := readText(t, conn); got != `{"user":"user-1"}` {
+		t.Errorf("Expected the authenticated user, got %s", got)
+	}
+}
+
+func TestServerAuthMessageRejected(t *testing.T) {
+	_, srv := newTestServer(t, echoHandler, WithAuthenticator(stubAuthenticator{token: "secret"}))
+
+	conn, _, err := dial(t, srv, "", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"auth","token":"wrong"}`))
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Errorf("Expected a policy violation close, got %v", err)
+	}
+}
+
+func TestServerOnConnectError(t *testing.T) {
+	_, srv := newTestServer(t, Handler{
+		OnConnect: func(c *Conn) error { return errors.New("room is full") },
+	})
+
+	conn, _, err := dial(t, srv, "", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.ClosePolicyViolation || closeErr.Text != "room is full" {
+		t.Errorf("Expected the connect error as the close reason, got %v", err)
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	disconnected := make(chan error, 1)
+	s, srv := newTestServer(t, Handler{
+		OnDisconnect: func(c *Conn, err error) { disconnected <- err },
+	})
+
+	conn, _, err := dial(t, srv, "", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	waitFor(t, func() bool { return s.Conns() == 1 })
+
+	// The client echoes the close frame while reading
+	closed := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		closed <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if err := <-closed; !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Expected a going-away close, got %v", err)
+	}
+	if err := <-disconnected; err != nil {
+		t.Errorf("Expected a clean disconnect, got %v", err)
+	}
+	if s.Conns() != 0 {
+		t.Errorf("Expected no connections after shutdown, got %d", s.Conns())
+	}
+
+	if _, resp, err := dial(t, srv, "", nil); err == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected new connections to be refused, got %v", err)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		header string
+		want   string
+	}{
+		{"header", "/ws", "Bearer abc", "abc"},
+		{"lowercase scheme", "/ws", "bearer abc", "abc"},
+		{"query", "/ws?access_token=xyz", "", "xyz"},
+		{"header wins", "/ws?access_token=xyz", "Bearer abc", "abc"},
+		{"basic auth ignored", "/ws", "Basic abc", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			if got := bearerToken(r); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}